@@ -1,6 +1,7 @@
 /**
  * API client — Auth (8080), Ride (8083), User (8081) — driver app
  */
+import { Platform } from "react-native";
 import { config } from "./config";
 
 export type TokenResponse = {
@@ -54,6 +55,7 @@ export type Ride = {
   passenger_id: string;
   driver_id?: string;
   status: string;
+  // approximate (~1 km, district-level address) until this driver's bid is accepted
   from: Point;
   to: Point;
   price?: number;
@@ -66,6 +68,7 @@ export type Bid = {
   driver_id: string;
   price: number;
   status: string;
+  counter_price?: number; // passenger's counter-offer: placeBid again to accept it
   created_at: string;
 };
 
@@ -87,6 +90,16 @@ export async function getRide(token: string, rideId: string): Promise<Ride> {
   return res.json();
 }
 
+/** Reason codes of a 403 on bidding (ride service verified-driver gate) */
+const bidIneligibleMessages: Record<string, string> = {
+  not_driver: "Only drivers can place bids",
+  verification_missing: "Complete driver verification to place bids",
+  verification_pending: "Your verification is still under review",
+  verification_rejected: "Your verification was rejected; submit it again",
+  documents_missing: "Upload your license and photo to place bids",
+  documents_expired: "Your documents have expired; upload new ones",
+};
+
 export async function placeBid(
   token: string,
   rideId: string,
@@ -102,11 +115,89 @@ export async function placeBid(
   );
   if (!res.ok) {
     const err = await res.json().catch(() => ({}));
-    throw new Error((err as { error?: string }).error ?? "Place bid failed");
+    const { error, reason } = err as { error?: string; reason?: string };
+    throw new Error(
+      (reason && bidIneligibleMessages[reason]) ?? error ?? "Place bid failed"
+    );
   }
   return res.json();
 }
 
+export async function withdrawBid(token: string, rideId: string, bidId: string): Promise<Bid> {
+  const res = await fetch(
+    `${config.rideApiUrl}/api/v1/rides/${rideId}/bids/${bidId}`,
+    { method: "DELETE", headers: authHeaders(token) }
+  );
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error((err as { error?: string }).error ?? "Withdraw bid failed");
+  }
+  return res.json();
+}
+
+// In-ride chat (ride matched or in progress); new messages arrive as chat.message
+// events and read receipts as chat.read, as JSON text frames on the location stream
+// WebSocket (/ws/drivers/:id/locations)
+export type ChatMessage = {
+  id: string;
+  ride_id: string;
+  sender_id: string;
+  sender_role: "passenger" | "driver";
+  recipient_id: string;
+  text: string;
+  quick_reply?: string;
+  created_at: string;
+  read_at?: string;
+};
+export type QuickReply = { code: string; text: string; role: string };
+
+export async function sendChatMessage(
+  token: string,
+  rideId: string,
+  message: { text?: string; quick_reply?: string }
+): Promise<ChatMessage> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/chat`, {
+    method: "POST",
+    headers: authHeaders(token),
+    body: JSON.stringify(message),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error((err as { error?: string }).error ?? "Send message failed");
+  }
+  return res.json();
+}
+
+export async function listChatMessages(
+  token: string,
+  rideId: string,
+  since?: string
+): Promise<ChatMessage[]> {
+  const query = since ? `?since=${encodeURIComponent(since)}` : "";
+  const res = await fetch(
+    `${config.rideApiUrl}/api/v1/rides/${rideId}/chat${query}`,
+    { headers: authHeaders(token) }
+  );
+  if (!res.ok) throw new Error("List messages failed");
+  return res.json();
+}
+
+export async function markChatRead(token: string, rideId: string): Promise<void> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/chat/read`, {
+    method: "POST",
+    headers: authHeaders(token),
+  });
+  if (!res.ok) throw new Error("Mark read failed");
+}
+
+export async function listQuickReplies(token: string): Promise<QuickReply[]> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/chat/quick-replies`, {
+    headers: authHeaders(token),
+  });
+  if (!res.ok) throw new Error("List quick replies failed");
+  return res.json();
+}
+
 export async function listMyRides(token: string, limit = 20): Promise<Ride[]> {
   const res = await fetch(
     `${config.rideApiUrl}/api/v1/rides?limit=${limit}`,
@@ -117,17 +208,19 @@ export async function listMyRides(token: string, limit = 20): Promise<Ride[]> {
   return (data as { rides: Ride[] }).rides ?? [];
 }
 
+// pin — the passenger's pickup PIN, required to start rides created with one
 export async function updateRideStatus(
   token: string,
   rideId: string,
-  status: string
+  status: string,
+  pin?: string
 ): Promise<Ride> {
   const res = await fetch(
     `${config.rideApiUrl}/api/v1/rides/${rideId}/status`,
     {
       method: "PATCH",
       headers: authHeaders(token),
-      body: JSON.stringify({ status }),
+      body: JSON.stringify({ status, pin }),
     }
   );
   if (!res.ok) {
@@ -137,6 +230,65 @@ export async function updateRideStatus(
   return res.json();
 }
 
+// SOS: snapshot of the ride, driver and positions goes to support as a high-priority alert
+export async function raiseSOS(
+  token: string,
+  rideId: string,
+  message?: string,
+  location?: { lat: number; lng: number }
+): Promise<{ id: string; status: string }> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/sos`, {
+    method: "POST",
+    headers: authHeaders(token),
+    body: JSON.stringify({ message, location }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error((err as { error?: string }).error ?? "SOS failed");
+  }
+  return res.json();
+}
+
+// Trip link for a trusted contact: live status and driver position without login
+export type ShareLink = {
+  id: string;
+  ride_id: string;
+  token?: string; // returned on creation only
+  url?: string;
+  expires_at: string;
+  revoked_at?: string;
+  created_at: string;
+};
+
+export async function createShareLink(token: string, rideId: string): Promise<ShareLink> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/share`, {
+    method: "POST",
+    headers: authHeaders(token),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error((err as { error?: string }).error ?? "Share trip failed");
+  }
+  return res.json();
+}
+
+export async function listShareLinks(token: string, rideId: string): Promise<ShareLink[]> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/share`, {
+    headers: authHeaders(token),
+  });
+  if (!res.ok) throw new Error("List links failed");
+  const data = await res.json();
+  return (data as { links: ShareLink[] }).links ?? [];
+}
+
+export async function revokeShareLink(token: string, rideId: string, linkId: string): Promise<void> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/share/${linkId}`, {
+    method: "DELETE",
+    headers: authHeaders(token),
+  });
+  if (!res.ok) throw new Error("Revoke link failed");
+}
+
 export async function createDriverProfile(
   token: string,
   licenseNumber: string
@@ -417,15 +569,19 @@ export const TAG_LABELS: Record<string, string> = {
 
 // ============ MAP SETTINGS ============
 
-export type MapProvider = "google" | "yandex";
+export type MapProvider = "google" | "yandex" | "osm";
 
 export type MapSettings = {
   provider: MapProvider;
-  api_key: string;
+  platform?: "android" | "ios";
+  api_key: string; // restricted key for this platform only
+  proxy_url?: string; // geocode/suggest/route via user service, never call providers directly
 };
 
 export async function getMapSettings(): Promise<MapSettings> {
-  const res = await fetch(`${config.userApiUrl}/api/v1/settings/maps`);
+  const res = await fetch(
+    `${config.userApiUrl}/api/v1/settings/maps?platform=${Platform.OS}`
+  );
   if (!res.ok) {
     // Default to google if settings not available
     return { provider: "google", api_key: "" };
@@ -2,6 +2,7 @@
  * API client — Auth (8080), Ride (8083), User (8081)
  * All ride/user endpoints require Authorization: Bearer <token>
  */
+import { Platform } from "react-native";
 import { config } from "./config";
 
 export type TokenResponse = {
@@ -50,6 +51,39 @@ function authHeaders(token: string): HeadersInit {
 }
 
 export type Point = { lat: number; lng: number; address?: string };
+export type VehicleClass = "economy" | "comfort" | "business" | "cargo";
+export type RideCategory = "economy" | "comfort" | "cargo" | "courier" | "intercity";
+export type Contact = { name: string; phone: string };
+export type Delivery = {
+  sender: Contact;
+  recipient: Contact;
+  comment?: string;
+  code?: string;
+  confirmed_at?: string;
+};
+export type AutoAccept = {
+  max_price?: number;
+  min_rating?: number;
+  max_eta_minutes?: number;
+};
+export type CreateRideExtras = {
+  category?: RideCategory;
+  seats?: number;
+  scheduled_at?: string;
+  delivery?: Delivery;
+  auto_accept?: AutoAccept;
+  pickup_pin?: boolean; // driver starts the ride with the PIN shown to the passenger
+};
+export type PickupPIN = {
+  code?: string;
+  attempts?: number;
+  verified_at?: string;
+};
+export type VehicleFeature = "minivan" | "child_seat" | "pet_friendly" | "wheelchair";
+export type RideOptions = {
+  vehicle_class?: VehicleClass;
+  features?: VehicleFeature[];
+};
 export type Ride = {
   id: string;
   passenger_id: string;
@@ -58,27 +92,50 @@ export type Ride = {
   from: Point;
   to: Point;
   price?: number;
+  options?: RideOptions;
+  category?: RideCategory;
+  seats?: number;
+  scheduled_at?: string;
+  delivery?: Delivery;
+  auto_accept?: AutoAccept;
+  pickup_pin?: PickupPIN;
   created_at: string;
   updated_at: string;
 };
+export type DriverCard = {
+  driver_id: string;
+  display_name: string;
+  avatar_url?: string;
+  vehicle_model: string;
+  vehicle_plate: string;
+  vehicle_color?: string;
+  vehicle_class: string;
+};
 export type Bid = {
   id: string;
   ride_id: string;
   driver_id: string;
   price: number;
   status: string;
+  counter_price?: number;
   created_at: string;
+  driver?: DriverCard;
+  rating?: { average_score: number; total_ratings: number };
+  distance_km?: number;
+  eta_minutes?: number;
 };
 
 export async function createRide(
   token: string,
   from: Point,
-  to: Point
+  to: Point,
+  options?: RideOptions,
+  extras?: CreateRideExtras
 ): Promise<Ride> {
   const res = await fetch(`${config.rideApiUrl}/api/v1/rides`, {
     method: "POST",
     headers: authHeaders(token),
-    body: JSON.stringify({ from, to }),
+    body: JSON.stringify({ from, to, options, ...extras }),
   });
   if (!res.ok) {
     const err = await res.json().catch(() => ({}));
@@ -115,6 +172,103 @@ export async function listBids(token: string, rideId: string): Promise<Bid[]> {
   return (data as { bids: Bid[] }).bids ?? [];
 }
 
+export async function counterBid(
+  token: string,
+  rideId: string,
+  bidId: string,
+  price: number
+): Promise<Bid> {
+  const res = await fetch(
+    `${config.rideApiUrl}/api/v1/rides/${rideId}/bids/${bidId}/counter`,
+    {
+      method: "POST",
+      headers: authHeaders(token),
+      body: JSON.stringify({ price }),
+    }
+  );
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error((err as { error?: string }).error ?? "Counter-offer failed");
+  }
+  return res.json();
+}
+
+// Ride event stream (SSE): bid.placed, bid.updated, bid.withdrawn, bid.countered,
+// ride.matched, ride.status, chat.message, chat.read; stream.reset = refetch ride, bids and chat. Open with an SSE
+// client that sends the Authorization header and Last-Event-ID on reconnect.
+export type RideEvent = {
+  ride_id: string;
+  type: string;
+  driver_id?: string;
+  data: unknown;
+  at: string;
+};
+export function rideEventsUrl(rideId: string): string {
+  return `${config.rideApiUrl}/api/v1/rides/${rideId}/events`;
+}
+
+// In-ride chat (ride matched or in progress); new messages arrive as chat.message
+// events and read receipts as chat.read
+export type ChatMessage = {
+  id: string;
+  ride_id: string;
+  sender_id: string;
+  sender_role: "passenger" | "driver";
+  recipient_id: string;
+  text: string;
+  quick_reply?: string;
+  created_at: string;
+  read_at?: string;
+};
+export type QuickReply = { code: string; text: string; role: string };
+
+export async function sendChatMessage(
+  token: string,
+  rideId: string,
+  message: { text?: string; quick_reply?: string }
+): Promise<ChatMessage> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/chat`, {
+    method: "POST",
+    headers: authHeaders(token),
+    body: JSON.stringify(message),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error((err as { error?: string }).error ?? "Send message failed");
+  }
+  return res.json();
+}
+
+export async function listChatMessages(
+  token: string,
+  rideId: string,
+  since?: string
+): Promise<ChatMessage[]> {
+  const query = since ? `?since=${encodeURIComponent(since)}` : "";
+  const res = await fetch(
+    `${config.rideApiUrl}/api/v1/rides/${rideId}/chat${query}`,
+    { headers: authHeaders(token) }
+  );
+  if (!res.ok) throw new Error("List messages failed");
+  return res.json();
+}
+
+export async function markChatRead(token: string, rideId: string): Promise<void> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/chat/read`, {
+    method: "POST",
+    headers: authHeaders(token),
+  });
+  if (!res.ok) throw new Error("Mark read failed");
+}
+
+export async function listQuickReplies(token: string): Promise<QuickReply[]> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/chat/quick-replies`, {
+    headers: authHeaders(token),
+  });
+  if (!res.ok) throw new Error("List quick replies failed");
+  return res.json();
+}
+
 export async function acceptBid(
   token: string,
   rideId: string,
@@ -155,6 +309,65 @@ export async function updateRideStatus(
   return res.json();
 }
 
+// SOS: snapshot of the ride, driver and positions goes to support as a high-priority alert
+export async function raiseSOS(
+  token: string,
+  rideId: string,
+  message?: string,
+  location?: { lat: number; lng: number }
+): Promise<{ id: string; status: string }> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/sos`, {
+    method: "POST",
+    headers: authHeaders(token),
+    body: JSON.stringify({ message, location }),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error((err as { error?: string }).error ?? "SOS failed");
+  }
+  return res.json();
+}
+
+// Trip link for a trusted contact: live status and driver position without login
+export type ShareLink = {
+  id: string;
+  ride_id: string;
+  token?: string; // returned on creation only
+  url?: string;
+  expires_at: string;
+  revoked_at?: string;
+  created_at: string;
+};
+
+export async function createShareLink(token: string, rideId: string): Promise<ShareLink> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/share`, {
+    method: "POST",
+    headers: authHeaders(token),
+  });
+  if (!res.ok) {
+    const err = await res.json().catch(() => ({}));
+    throw new Error((err as { error?: string }).error ?? "Share trip failed");
+  }
+  return res.json();
+}
+
+export async function listShareLinks(token: string, rideId: string): Promise<ShareLink[]> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/share`, {
+    headers: authHeaders(token),
+  });
+  if (!res.ok) throw new Error("List links failed");
+  const data = await res.json();
+  return (data as { links: ShareLink[] }).links ?? [];
+}
+
+export async function revokeShareLink(token: string, rideId: string, linkId: string): Promise<void> {
+  const res = await fetch(`${config.rideApiUrl}/api/v1/rides/${rideId}/share/${linkId}`, {
+    method: "DELETE",
+    headers: authHeaders(token),
+  });
+  if (!res.ok) throw new Error("Revoke link failed");
+}
+
 // ============ PAYMENTS ============
 
 export const PAYMENT_PROVIDERS = {
@@ -481,15 +694,19 @@ export async function getActivePromos(token: string): Promise<Promo[]> {
 
 // ============ MAP SETTINGS ============
 
-export type MapProvider = "google" | "yandex";
+export type MapProvider = "google" | "yandex" | "osm";
 
 export type MapSettings = {
   provider: MapProvider;
-  api_key: string;
+  platform?: "android" | "ios";
+  api_key: string; // restricted key for this platform only
+  proxy_url?: string; // geocode/suggest/route via user service, never call providers directly
 };
 
 export async function getMapSettings(): Promise<MapSettings> {
-  const res = await fetch(`${config.userApiUrl}/api/v1/settings/maps`);
+  const res = await fetch(
+    `${config.userApiUrl}/api/v1/settings/maps?platform=${Platform.OS}`
+  );
   if (!res.ok) {
     // Default to google if settings not available
     return { provider: "google", api_key: "" };
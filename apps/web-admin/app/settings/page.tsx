@@ -8,6 +8,31 @@ import {
   MapProvider,
 } from "@/lib/api";
 
+type ClientKeys = {
+  google_maps_android_key: string;
+  google_maps_ios_key: string;
+  yandex_maps_android_key: string;
+  yandex_maps_ios_key: string;
+};
+
+const EMPTY_CLIENT_KEYS: ClientKeys = {
+  google_maps_android_key: "",
+  google_maps_ios_key: "",
+  yandex_maps_android_key: "",
+  yandex_maps_ios_key: "",
+};
+
+const CLIENT_KEY_FIELDS: {
+  field: keyof ClientKeys;
+  provider: MapProvider;
+  label: string;
+}[] = [
+  { field: "google_maps_android_key", provider: "google", label: "Google Maps — Android" },
+  { field: "google_maps_ios_key", provider: "google", label: "Google Maps — iOS" },
+  { field: "yandex_maps_android_key", provider: "yandex", label: "Яндекс Карты — Android" },
+  { field: "yandex_maps_ios_key", provider: "yandex", label: "Яндекс Карты — iOS" },
+];
+
 export default function SettingsPage() {
   const [settings, setSettings] = useState<AppSettings | null>(null);
   const [loading, setLoading] = useState(true);
@@ -19,6 +44,7 @@ export default function SettingsPage() {
   const [mapProvider, setMapProvider] = useState<MapProvider>("google");
   const [googleApiKey, setGoogleApiKey] = useState("");
   const [yandexApiKey, setYandexApiKey] = useState("");
+  const [clientKeys, setClientKeys] = useState<ClientKeys>(EMPTY_CLIENT_KEYS);
   const [language, setLanguage] = useState("ru");
   const [currency, setCurrency] = useState("RUB");
 
@@ -35,6 +61,12 @@ export default function SettingsPage() {
       setMapProvider(data.map_provider);
       setGoogleApiKey(data.google_maps_api_key ?? "");
       setYandexApiKey(data.yandex_maps_api_key ?? "");
+      setClientKeys({
+        google_maps_android_key: data.google_maps_android_key ?? "",
+        google_maps_ios_key: data.google_maps_ios_key ?? "",
+        yandex_maps_android_key: data.yandex_maps_android_key ?? "",
+        yandex_maps_ios_key: data.yandex_maps_ios_key ?? "",
+      });
       setLanguage(data.default_language);
       setCurrency(data.default_currency);
     } catch (err) {
@@ -56,6 +88,10 @@ export default function SettingsPage() {
         map_provider: mapProvider,
         google_maps_api_key: googleApiKey || undefined,
         yandex_maps_api_key: yandexApiKey || undefined,
+        google_maps_android_key: clientKeys.google_maps_android_key || undefined,
+        google_maps_ios_key: clientKeys.google_maps_ios_key || undefined,
+        yandex_maps_android_key: clientKeys.yandex_maps_android_key || undefined,
+        yandex_maps_ios_key: clientKeys.yandex_maps_ios_key || undefined,
         default_language: language,
         default_currency: currency,
       });
@@ -146,6 +182,23 @@ export default function SettingsPage() {
                 </p>
               </div>
             </label>
+
+            <label className="flex items-center p-4 border rounded-lg cursor-pointer hover:bg-gray-50 transition">
+              <input
+                type="radio"
+                name="mapProvider"
+                value="osm"
+                checked={mapProvider === "osm"}
+                onChange={(e) => setMapProvider(e.target.value as MapProvider)}
+                className="w-4 h-4 text-blue-600"
+              />
+              <div className="ml-3">
+                <span className="font-medium">OpenStreetMap</span>
+                <p className="text-sm text-gray-500">
+                  Бесплатно, геокодирование через Nominatim, API ключ не нужен
+                </p>
+              </div>
+            </label>
           </div>
         </section>
 
@@ -155,8 +208,9 @@ export default function SettingsPage() {
             🔑 API ключи
           </h2>
           <p className="text-sm text-gray-600 mb-4">
-            Введите API ключи для картографических сервисов. Ключ активного
-            провайдера будет передаваться мобильным приложениям.
+            Серверные ключи используются только прокси карт (геокодинг,
+            подсказки, маршруты) и никогда не передаются клиентам. Сохранённые
+            ключи отображаются замаскированными.
           </p>
 
           <div className="space-y-4">
@@ -216,6 +270,39 @@ export default function SettingsPage() {
           </div>
         </section>
 
+        {/* Client keys */}
+        <section className="mb-8 bg-white rounded-lg shadow p-6">
+          <h2 className="text-lg font-semibold mb-4 flex items-center gap-2">
+            📱 Ключи мобильных приложений
+          </h2>
+          <p className="text-sm text-gray-600 mb-4">
+            Ограниченные ключи для отображения карты в приложениях. В консоли
+            провайдера ограничьте каждый ключ package name (Android) или
+            bundle ID (iOS). Приложение получает только ключ своей платформы.
+          </p>
+
+          <div className="grid grid-cols-2 gap-4">
+            {CLIENT_KEY_FIELDS.map(({ field, provider, label }) => (
+              <div key={field}>
+                <label className="block text-sm font-medium text-gray-700 mb-1">
+                  {label}
+                  {mapProvider === provider && (
+                    <span className="ml-2 text-xs text-green-600">(активен)</span>
+                  )}
+                </label>
+                <input
+                  type="password"
+                  value={clientKeys[field]}
+                  onChange={(e) =>
+                    setClientKeys({ ...clientKeys, [field]: e.target.value })
+                  }
+                  className="w-full px-3 py-2 border rounded-lg focus:ring-2 focus:ring-blue-500 focus:border-blue-500"
+                />
+              </div>
+            ))}
+          </div>
+        </section>
+
         {/* Localization */}
         <section className="mb-8 bg-white rounded-lg shadow p-6">
           <h2 className="text-lg font-semibold mb-4 flex items-center gap-2">
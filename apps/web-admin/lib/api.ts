@@ -68,6 +68,57 @@ export async function fetchRides(): Promise<Ride[]> {
   return (data as { rides?: Ride[] }).rides ?? [];
 }
 
+// ============ SOS ALERTS ============
+
+export type SOSAlert = {
+  id: string;
+  ride_id: string;
+  reporter_id: string;
+  reporter_role: "passenger" | "driver";
+  message?: string;
+  snapshot: {
+    ride: Ride;
+    driver?: { display_name: string; vehicle_model: string; vehicle_plate: string; vehicle_color?: string };
+    driver_location?: { lat: number; lng: number };
+    reporter_location?: { lat: number; lng: number };
+  };
+  status: "open" | "acknowledged" | "resolved";
+  handled_by?: string;
+  created_at: string;
+  updated_at: string;
+};
+
+// fetchSOSAlerts — open and acknowledged alerts, newest first (all = include resolved)
+export async function fetchSOSAlerts(all = false): Promise<SOSAlert[]> {
+  const res = await fetch(`${rideApi()}/api/v1/admin/sos?all=${all}`, {
+    headers: authHeaders(),
+    cache: "no-store",
+  });
+  if (!res.ok) return [];
+  const data = await res.json();
+  return (data as { alerts?: SOSAlert[] }).alerts ?? [];
+}
+
+export async function updateSOSAlert(
+  id: string,
+  status: "acknowledged" | "resolved"
+): Promise<{ success: boolean; error?: string }> {
+  try {
+    const res = await fetch(`${rideApi()}/api/v1/admin/sos/${id}`, {
+      method: "PATCH",
+      headers: { ...authHeaders(), "Content-Type": "application/json" },
+      body: JSON.stringify({ status }),
+    });
+    if (!res.ok) {
+      const data = await res.json();
+      return { success: false, error: data.error ?? "Update failed" };
+    }
+    return { success: true };
+  } catch {
+    return { success: false, error: "Network error" };
+  }
+}
+
 // ============ USERS ============
 
 export type User = {
@@ -471,13 +522,18 @@ export async function deletePromo(id: string): Promise<void> {
 
 // ============ APP SETTINGS ============
 
-export type MapProvider = "google" | "yandex";
+export type MapProvider = "google" | "yandex" | "osm";
 
 export type AppSettings = {
   id: string;
   map_provider: MapProvider;
+  // Keys come back masked ("••••1234"); sending a masked value keeps the stored key
   google_maps_api_key?: string;
   yandex_maps_api_key?: string;
+  google_maps_android_key?: string;
+  google_maps_ios_key?: string;
+  yandex_maps_android_key?: string;
+  yandex_maps_ios_key?: string;
   default_language: string;
   default_currency: string;
   updated_at: string;
@@ -488,6 +544,10 @@ export type UpdateSettingsInput = {
   map_provider: MapProvider;
   google_maps_api_key?: string;
   yandex_maps_api_key?: string;
+  google_maps_android_key?: string;
+  google_maps_ios_key?: string;
+  yandex_maps_android_key?: string;
+  yandex_maps_ios_key?: string;
   default_language?: string;
   default_currency?: string;
 };
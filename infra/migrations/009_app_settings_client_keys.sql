@@ -0,0 +1,35 @@
+-- Restricted, platform-scoped map keys for mobile apps.
+-- Server keys (google_maps_api_key, yandex_maps_api_key) are used only by the
+-- user-service maps proxy and are never returned to clients.
+
+ALTER TABLE app_settings
+    ADD COLUMN IF NOT EXISTS google_maps_android_key TEXT,
+    ADD COLUMN IF NOT EXISTS google_maps_ios_key TEXT,
+    ADD COLUMN IF NOT EXISTS yandex_maps_android_key TEXT,
+    ADD COLUMN IF NOT EXISTS yandex_maps_ios_key TEXT;
+
+-- Keep API keys out of the audit history
+CREATE OR REPLACE FUNCTION log_settings_change()
+RETURNS TRIGGER AS $$
+DECLARE
+    secrets TEXT[] := ARRAY[
+        'google_maps_api_key', 'yandex_maps_api_key',
+        'google_maps_android_key', 'google_maps_ios_key',
+        'yandex_maps_android_key', 'yandex_maps_ios_key'
+    ];
+BEGIN
+    INSERT INTO app_settings_history (settings_id, map_provider, changed_by, old_values, new_values)
+    VALUES (
+        NEW.id,
+        NEW.map_provider,
+        NEW.updated_by,
+        to_jsonb(OLD) - secrets,
+        to_jsonb(NEW) - secrets
+    );
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE app_settings_history
+SET old_values = old_values - ARRAY['google_maps_api_key', 'yandex_maps_api_key'],
+    new_values = new_values - ARRAY['google_maps_api_key', 'yandex_maps_api_key'];
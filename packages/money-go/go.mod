@@ -0,0 +1,3 @@
+module github.com/alexevil1979/indrive/packages/money-go
+
+go 1.23
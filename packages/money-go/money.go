@@ -0,0 +1,229 @@
+// Package money provides exact monetary amounts: integer minor units (kopecks, cents)
+// with an ISO 4217 currency code. Floats appear only at the edges, for legacy wire
+// formats, and are converted through their shortest decimal representation.
+package money
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidAmount = errors.New("money: invalid decimal amount")
+	// ErrPrecision — the amount has more decimals than the currency's minor unit
+	ErrPrecision = errors.New("money: more decimals than the currency allows")
+	ErrOverflow  = errors.New("money: amount out of range")
+)
+
+// RUB — the default currency of the platform
+const RUB = "RUB"
+
+type currencyInfo struct {
+	exponent int    // decimals of the minor unit
+	numeric  string // ISO 4217 numeric code
+}
+
+var currencies = map[string]currencyInfo{
+	"RUB": {2, "643"},
+	"USD": {2, "840"},
+	"EUR": {2, "978"},
+	"KZT": {2, "398"},
+	"UZS": {2, "860"},
+	"BYN": {2, "933"},
+	"KGS": {2, "417"},
+	"AMD": {2, "051"},
+	"GEL": {2, "981"},
+	"JPY": {0, "392"},
+	"KRW": {0, "410"},
+}
+
+// Exponent returns the number of decimals of the currency's minor unit (2 for unknown codes)
+func Exponent(currency string) int {
+	if c, ok := currencies[strings.ToUpper(currency)]; ok {
+		return c.exponent
+	}
+	return 2
+}
+
+// NumericCode returns the ISO 4217 numeric code ("643" for RUB), empty for unknown codes
+func NumericCode(currency string) string {
+	return currencies[strings.ToUpper(currency)].numeric
+}
+
+// Money — an amount in minor units of a currency
+type Money struct {
+	Minor    int64  // 19999 = 199.99 RUB
+	Currency string // ISO 4217 alphabetic code
+}
+
+// New returns minor units of the currency
+func New(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: strings.ToUpper(currency)}
+}
+
+// Parse reads an exact decimal amount in major units ("199.99", "-5", "1e2"); more
+// decimals than the currency has is ErrPrecision, not a silent rounding
+func Parse(s, currency string) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return Money{}, ErrInvalidAmount
+	}
+	r.Mul(r, scale(Exponent(currency)))
+	if !r.IsInt() {
+		return Money{}, ErrPrecision
+	}
+	if !r.Num().IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return New(r.Num().Int64(), currency), nil
+}
+
+// FromMajor converts a legacy float amount, rounding half away from zero to the minor
+// unit. The float is read as its shortest decimal form, so 199.99 is 19999 kopecks and
+// 1.005 rounds to 1.01 (not 1.00 as float arithmetic would).
+func FromMajor(v float64, currency string) Money {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(v, 'f', -1, 64))
+	if !ok { // NaN, ±Inf
+		return New(0, currency)
+	}
+	r.Mul(r, scale(Exponent(currency)))
+	return New(roundHalfAway(r.Num(), r.Denom()), currency)
+}
+
+// Major returns the amount in major units as a float, for legacy wire formats only
+func (m Money) Major() float64 {
+	f, _ := strconv.ParseFloat(m.Decimal(), 64)
+	return f
+}
+
+// Decimal formats the amount in major units with exactly the currency's decimals: "199.99"
+func (m Money) Decimal() string {
+	exp := Exponent(m.Currency)
+	neg := m.Minor < 0
+	digits := new(big.Int).Abs(big.NewInt(m.Minor)).String()
+	if exp > 0 {
+		if len(digits) <= exp {
+			digits = strings.Repeat("0", exp-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+	}
+	if neg {
+		return "-" + digits
+	}
+	return digits
+}
+
+// String — "199.99 RUB"
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.Currency
+}
+
+func (m Money) IsZero() bool     { return m.Minor == 0 }
+func (m Money) IsPositive() bool { return m.Minor > 0 }
+func (m Money) IsNegative() bool { return m.Minor < 0 }
+
+// SameCurrency reports whether both amounts are in one currency; a zero amount without
+// a currency (the zero Money) matches any
+func (m Money) SameCurrency(o Money) bool {
+	return m.Currency == o.Currency || (m.Currency == "" && m.Minor == 0) || (o.Currency == "" && o.Minor == 0)
+}
+
+// Add returns m + o. Mixing currencies is a programming error and panics; callers
+// comparing external input check SameCurrency first.
+func (m Money) Add(o Money) Money {
+	return Money{Minor: m.Minor + o.Minor, Currency: m.currencyWith(o)}
+}
+
+// Sub returns m - o (see Add for currencies)
+func (m Money) Sub(o Money) Money {
+	return Money{Minor: m.Minor - o.Minor, Currency: m.currencyWith(o)}
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+// Mul returns m × n
+func (m Money) Mul(n int64) Money {
+	return Money{Minor: m.Minor * n, Currency: m.Currency}
+}
+
+// Cmp compares amounts of one currency: -1, 0 or +1 (see Add for currencies)
+func (m Money) Cmp(o Money) int {
+	m.currencyWith(o)
+	switch {
+	case m.Minor < o.Minor:
+		return -1
+	case m.Minor > o.Minor:
+		return 1
+	}
+	return 0
+}
+
+// Min returns the smaller of two amounts of one currency
+func Min(a, b Money) Money {
+	if a.Cmp(b) <= 0 {
+		return a.In(b.Currency)
+	}
+	return b.In(a.Currency)
+}
+
+// In returns the amount with the currency set when it has none (the zero Money)
+func (m Money) In(currency string) Money {
+	if m.Currency == "" {
+		m.Currency = strings.ToUpper(currency)
+	}
+	return m
+}
+
+func (m Money) currencyWith(o Money) string {
+	if !m.SameCurrency(o) {
+		panic(fmt.Sprintf("money: currency mismatch: %s and %s", m.Currency, o.Currency))
+	}
+	if m.Currency == "" {
+		return o.Currency
+	}
+	return m.Currency
+}
+
+// MarshalJSON writes the amount as a JSON number in major units (199.99); the currency
+// travels in a sibling field
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON reads a JSON number or numeric string in major units, exactly; the
+// currency (and so the number of decimals) is the one already set, else two decimals
+func (m *Money) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(bytes.TrimSpace(b), `"`)
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := Parse(string(b), m.Currency)
+	if err != nil {
+		return err
+	}
+	m.Minor = v.Minor
+	return nil
+}
+
+func scale(exp int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+}
+
+// roundHalfAway divides num by den (den > 0), rounding half away from zero
+func roundHalfAway(num, den *big.Int) int64 {
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+	return q.Int64()
+}
@@ -0,0 +1,195 @@
+package money
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"testing"
+	"testing/quick"
+)
+
+// smallMinor — amounts a float64 carries exactly through its shortest decimal form
+func smallMinor(r *rand.Rand) int64 {
+	return r.Int63n(2e13) - 1e13
+}
+
+func quickConfig() *quick.Config {
+	return &quick.Config{MaxCount: 2000}
+}
+
+func TestFromMajor_Kopecks(t *testing.T) {
+	cases := []struct {
+		in   float64
+		want int64
+	}{
+		{199.99, 19999}, // int64(199.99 * 100) is 19998
+		{0.29, 29},
+		{1.005, 101},
+		{-1.005, -101},
+		{0.004, 0},
+		{1e-9, 0},
+		{math.NaN(), 0},
+	}
+	for _, c := range cases {
+		if got := FromMajor(c.in, RUB).Minor; got != c.want {
+			t.Errorf("FromMajor(%v) = %d, want %d", c.in, got, c.want)
+		}
+	}
+	if got := FromMajor(1234.5, "JPY").Minor; got != 1235 {
+		t.Errorf("JPY has no minor unit, got %d", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]int64{"199.99": 19999, "5": 500, "-0.5": -50, "1e2": 10000, " 0.10 ": 10} {
+		m, err := Parse(in, RUB)
+		if err != nil || m.Minor != want || m.Currency != RUB {
+			t.Errorf("Parse(%q) = %v, %v; want %d", in, m, err, want)
+		}
+	}
+	if _, err := Parse("1.001", RUB); err != ErrPrecision {
+		t.Errorf("sub-kopeck amounts must be rejected, got %v", err)
+	}
+	if _, err := Parse("12,5", RUB); err != ErrInvalidAmount {
+		t.Errorf("got %v", err)
+	}
+	if _, err := Parse("1e30", RUB); err != ErrOverflow {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestProperty_DecimalRoundTrip(t *testing.T) {
+	prop := func(minor int64) bool {
+		m := New(minor, RUB)
+		back, err := Parse(m.Decimal(), RUB)
+		return err == nil && back == m
+	}
+	if err := quick.Check(prop, quickConfig()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_FloatRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		m := New(smallMinor(r), RUB)
+		if got := FromMajor(m.Major(), RUB); got != m {
+			t.Fatalf("%s → %v → %s", m, m.Major(), got)
+		}
+	}
+}
+
+func TestProperty_JSONRoundTrip(t *testing.T) {
+	prop := func(minor int64) bool {
+		b, err := json.Marshal(New(minor, RUB))
+		if err != nil {
+			return false
+		}
+		back := Money{Currency: RUB}
+		return json.Unmarshal(b, &back) == nil && back.Minor == minor
+	}
+	if err := quick.Check(prop, quickConfig()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProperty_AddSub(t *testing.T) {
+	prop := func(a, b int32) bool {
+		x, y := New(int64(a), RUB), New(int64(b), RUB)
+		return x.Add(y).Sub(y) == x && x.Add(y) == y.Add(x) && x.Sub(x).IsZero()
+	}
+	if err := quick.Check(prop, quickConfig()); err != nil {
+		t.Error(err)
+	}
+	if got := (Money{}).Add(New(5, RUB)); got != New(5, RUB) {
+		t.Errorf("the zero Money takes the other currency, got %v", got)
+	}
+}
+
+func TestAdd_CurrencyMismatchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("mixing currencies must panic")
+		}
+	}()
+	New(1, RUB).Add(New(1, "USD"))
+}
+
+func TestRate_Of(t *testing.T) {
+	cases := []struct {
+		rate  Rate
+		minor int64
+		want  int64
+	}{
+		{1000, 5, 1},        // 10% of 0.05 = 0.005 → 0.01
+		{1000, 4, 0},        // 0.004 → 0.00
+		{1000, 19999, 2000}, // 19.999 → 20.00
+		{1250, 19999, 2500}, // 12.5% of 199.99 = 24.99875 → 25.00
+		{333, 10000, 333},   // 3.33%
+		{Percent100, 777, 777},
+		{1000, -5, -1}, // half away from zero
+	}
+	for _, c := range cases {
+		if got := c.rate.Of(New(c.minor, RUB)).Minor; got != c.want {
+			t.Errorf("%s%% of %d = %d, want %d", c.rate, c.minor, got, c.want)
+		}
+	}
+}
+
+func TestProperty_RateOf(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 100000; i++ {
+		m := New(r.Int63n(1e12), RUB)
+		rate := Rate(r.Int63n(int64(Percent100) + 1))
+		share := rate.Of(m)
+		// within the order, and off the exact product by at most half a minor unit
+		if share.Minor < 0 || share.Minor > m.Minor {
+			t.Fatalf("%s%% of %s = %s", rate, m, share)
+		}
+		diff := share.Minor*int64(Percent100) - m.Minor*int64(rate)
+		if diff < -int64(Percent100)/2 || diff > int64(Percent100)/2 {
+			t.Fatalf("%s%% of %s = %s is not the nearest kopeck", rate, m, share)
+		}
+		// a larger rate never gives a smaller share
+		if rate < Percent100 && (rate+1).Of(m).Minor < share.Minor {
+			t.Fatalf("not monotonic at %s%% of %s", rate, m)
+		}
+	}
+	if Percent100.Of(New(12345, RUB)).Minor != 12345 {
+		t.Error("100% is the whole amount")
+	}
+}
+
+func TestRate_ParseAndJSON(t *testing.T) {
+	for in, want := range map[string]Rate{"10": 1000, "12.5": 1250, "0.25": 25, "100": Percent100} {
+		r, err := ParseRate(in)
+		if err != nil || r != want {
+			t.Errorf("ParseRate(%q) = %d, %v", in, r, err)
+		}
+	}
+	if _, err := ParseRate("10.125"); err != ErrPrecision {
+		t.Errorf("got %v", err)
+	}
+	b, _ := json.Marshal(Rate(1250))
+	if string(b) != "12.5" {
+		t.Errorf("json: %s", b)
+	}
+	var r Rate
+	if err := json.Unmarshal([]byte("7.25"), &r); err != nil || r != 725 {
+		t.Errorf("unmarshal: %d, %v", r, err)
+	}
+}
+
+func TestDecimalAndCodes(t *testing.T) {
+	for minor, want := range map[int64]string{0: "0.00", 5: "0.05", -5: "-0.05", 19999: "199.99", math.MinInt64: "-92233720368547758.08"} {
+		if got := New(minor, RUB).Decimal(); got != want {
+			t.Errorf("Decimal(%d) = %s, want %s", minor, got, want)
+		}
+	}
+	if New(500, "jpy").Decimal() != "500" || NumericCode(RUB) != "643" || NumericCode("XXX") != "" {
+		t.Error("currency table")
+	}
+	if New(19999, RUB).String() != "199.99 RUB" {
+		t.Error(New(19999, RUB).String())
+	}
+}
@@ -0,0 +1,61 @@
+package money
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+)
+
+// Rate — a percentage in basis points: 1050 = 10.5%
+type Rate int64
+
+// Percent100 — 100%
+const Percent100 Rate = 10000
+
+// ParseRate reads a percentage with at most two decimals: "10", "12.5", "0.25"
+func ParseRate(s string) (Rate, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return 0, ErrInvalidAmount
+	}
+	r.Mul(r, big.NewRat(100, 1))
+	if !r.IsInt() {
+		return 0, ErrPrecision
+	}
+	if !r.Num().IsInt64() {
+		return 0, ErrOverflow
+	}
+	return Rate(r.Num().Int64()), nil
+}
+
+// Of returns the rate's share of m, rounded half away from zero to the minor unit
+// (commercial rounding, as on fiscal receipts): 10% of 0.05 is 0.01, of 0.04 is 0.00
+func (r Rate) Of(m Money) Money {
+	num := new(big.Int).Mul(big.NewInt(m.Minor), big.NewInt(int64(r)))
+	return Money{Minor: roundHalfAway(num, big.NewInt(int64(Percent100))), Currency: m.Currency}
+}
+
+// String — the percentage: "12.5"
+func (r Rate) String() string {
+	d := New(int64(r), "").Decimal()
+	return strings.TrimSuffix(strings.TrimRight(d, "0"), ".")
+}
+
+// MarshalJSON writes the percentage as a JSON number (12.5)
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON reads a percentage number (or numeric string) exactly
+func (r *Rate) UnmarshalJSON(b []byte) error {
+	b = bytes.Trim(bytes.TrimSpace(b), `"`)
+	if string(b) == "null" {
+		return nil
+	}
+	v, err := ParseRate(string(b))
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
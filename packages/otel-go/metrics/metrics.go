@@ -13,6 +13,7 @@ import (
 // Metrics holds common HTTP metrics for a service.
 type Metrics struct {
 	serviceName string
+	namespace   string
 	registry    *prometheus.Registry
 
 	// HTTP metrics
@@ -43,6 +44,7 @@ func New(cfg Config) *Metrics {
 
 	m := &Metrics{
 		serviceName: cfg.ServiceName,
+		namespace:   cfg.Namespace,
 		registry:    registry,
 
 		RequestsTotal: factory.NewCounterVec(prometheus.CounterOpts{
@@ -106,6 +108,17 @@ func (m *Metrics) RecordError(errType string) {
 	m.ErrorsTotal.WithLabelValues(errType).Inc()
 }
 
+// NewCounterVec registers a service counter, named like the built-in ones
+// (<namespace>_<service>_<name>).
+func (m *Metrics) NewCounterVec(name, help string, labels ...string) *prometheus.CounterVec {
+	return promauto.With(m.registry).NewCounterVec(prometheus.CounterOpts{
+		Namespace: m.namespace,
+		Subsystem: m.serviceName,
+		Name:      name,
+		Help:      help,
+	}, labels)
+}
+
 // Registry returns the Prometheus registry for custom collectors.
 func (m *Metrics) Registry() *prometheus.Registry {
 	return m.registry
@@ -0,0 +1,4 @@
+-- Vehicle class and features declared on verification, fixed by admin on approval.
+-- Approved values are synced to geolocation (nearest-driver filters) and read by ride (feed, bids).
+ALTER TABLE driver_verifications ADD COLUMN IF NOT EXISTS vehicle_class VARCHAR(20) NOT NULL DEFAULT 'economy'; -- economy, comfort, business
+ALTER TABLE driver_verifications ADD COLUMN IF NOT EXISTS vehicle_features TEXT[] NOT NULL DEFAULT '{}'; -- minivan, child_seat, pet_friendly, wheelchair
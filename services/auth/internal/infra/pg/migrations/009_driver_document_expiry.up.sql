@@ -0,0 +1,3 @@
+-- Document expiry, set by admin on approval. The ride service refuses bids from drivers
+-- whose required documents (license, photo) have expired.
+ALTER TABLE driver_documents ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+-- Vehicle color declared on verification; shown to passengers on bids with model and plate.
+ALTER TABLE driver_verifications ADD COLUMN IF NOT EXISTS vehicle_color VARCHAR(30) NOT NULL DEFAULT '';
@@ -2,24 +2,54 @@ package http
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
+	"github.com/ridehail/geolocation/internal/delivery/pb"
 	"github.com/ridehail/geolocation/internal/domain"
 	"github.com/ridehail/geolocation/internal/usecase"
 )
 
+// maxBatchBody — protobuf fix is ~40 bytes, JSON ~150; 500 fixes fit comfortably
+const maxBatchBody = 128 << 10
+
 type LocationUseCase interface {
-	UpdateDriverLocation(ctx context.Context, driverID string, lat, lng float64) error
+	IngestFixes(ctx context.Context, fixes []domain.LocationFix) (usecase.IngestResult, error)
 	FindNearestDrivers(ctx context.Context, q domain.NearestQuery) ([]domain.DriverLocation, error)
+	DriverLocations(ctx context.Context, driverIDs []string) ([]domain.DriverLocation, error)
+	SetDriverAttributes(ctx context.Context, a domain.DriverAttributes) (*domain.DriverAttributes, error)
+	GetDriverAttributes(ctx context.Context, driverID string) (*domain.DriverAttributes, error)
 }
 
-// UpdateLocationRequest — POST /api/v1/drivers/:id/location
+// UpdateLocationRequest — POST /api/v1/drivers/:id/location (motion fields optional)
 type UpdateLocationRequest struct {
-	Lat float64 `json:"lat"`
-	Lng float64 `json:"lng"`
+	Lat         float64 `json:"lat"`
+	Lng         float64 `json:"lng"`
+	Heading     float64 `json:"heading,omitempty"`
+	Speed       float64 `json:"speed,omitempty"`
+	Accuracy    float64 `json:"accuracy,omitempty"`
+	TimestampMs int64   `json:"timestamp_ms,omitempty"` // device time; server time if omitted
+}
+
+func (r UpdateLocationRequest) fix(driverID string) domain.LocationFix {
+	f := domain.LocationFix{
+		DriverID: driverID,
+		Location: domain.Location{Lat: r.Lat, Lng: r.Lng, Heading: r.Heading, Speed: r.Speed, Accuracy: r.Accuracy},
+	}
+	if r.TimestampMs > 0 {
+		f.Timestamp = time.UnixMilli(r.TimestampMs)
+	}
+	return f
+}
+
+// LocationBatchRequest — POST /api/v1/drivers/:id/locations (JSON form of proto LocationBatch)
+type LocationBatchRequest struct {
+	Fixes []UpdateLocationRequest `json:"fixes"`
 }
 
 func UpdateDriverLocation(uc LocationUseCase) echo.HandlerFunc {
@@ -32,37 +62,157 @@ func UpdateDriverLocation(uc LocationUseCase) echo.HandlerFunc {
 		if err := c.Bind(&req); err != nil {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
 		}
-		if err := uc.UpdateDriverLocation(c.Request().Context(), driverID, req.Lat, req.Lng); err != nil {
-			if err == usecase.ErrInvalidCoordinates {
-				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
-			}
+		fix := req.fix(driverID)
+		if !fix.Location.Valid() {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": usecase.ErrInvalidCoordinates.Error()})
+		}
+		if _, err := uc.IngestFixes(c.Request().Context(), []domain.LocationFix{fix}); err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update location"})
 		}
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
 	}
 }
 
+// UpdateDriverLocations — POST /api/v1/drivers/:id/locations — batch of buffered fixes.
+// Body: proto LocationBatch (Content-Type: application/x-protobuf) or {"fixes":[...]}.
+// Out-of-order and invalid fixes are dropped; response: {"accepted":1,"dropped":9}
+func UpdateDriverLocations(uc LocationUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		driverID := c.Param("id")
+		if driverID == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "driver id required"})
+		}
+		var fixes []domain.LocationFix
+		if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), pb.ContentType) {
+			body, err := io.ReadAll(io.LimitReader(c.Request().Body, maxBatchBody+1))
+			if err != nil || len(body) > maxBatchBody {
+				return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": "batch too large"})
+			}
+			if fixes, err = pb.DecodeBatch(body, driverID); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+		} else {
+			var req LocationBatchRequest
+			if err := c.Bind(&req); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
+			}
+			for _, f := range req.Fixes {
+				fixes = append(fixes, f.fix(driverID))
+			}
+		}
+		res, err := uc.IngestFixes(c.Request().Context(), fixes)
+		if err != nil {
+			if err == usecase.ErrBatchTooLarge {
+				return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update locations"})
+		}
+		return c.JSON(http.StatusOK, res)
+	}
+}
+
 // Nearest — GET /api/v1/drivers/nearest?lat=55.75&lng=37.62&radius_km=5&limit=10
+// Optional filters: &class=comfort&features=child_seat,pet_friendly (approved vehicle attributes)
 func NearestDrivers(uc LocationUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		lat, _ := strconv.ParseFloat(c.QueryParam("lat"), 64)
-		lng, _ := strconv.ParseFloat(c.QueryParam("lng"), 64)
-		radiusKm, _ := strconv.ParseFloat(c.QueryParam("radius_km"), 64)
+		lat, errLat := strconv.ParseFloat(c.QueryParam("lat"), 64)
+		lng, errLng := strconv.ParseFloat(c.QueryParam("lng"), 64)
 		limit, _ := strconv.Atoi(c.QueryParam("limit"))
-		if lat == 0 && lng == 0 {
+		if errLat != nil || errLng != nil || (lat == 0 && lng == 0) {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "lat and lng required"})
 		}
+		var radiusKm float64
+		if r := c.QueryParam("radius_km"); r != "" {
+			var err error
+			if radiusKm, err = strconv.ParseFloat(r, 64); err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": usecase.ErrInvalidRadius.Error()})
+			}
+		}
 		if radiusKm <= 0 {
 			radiusKm = 10
 		}
 		if limit <= 0 {
 			limit = 10
 		}
-		q := domain.NearestQuery{Lat: lat, Lng: lng, RadiusKM: radiusKm, Limit: limit}
+		q := domain.NearestQuery{Lat: lat, Lng: lng, RadiusKM: radiusKm, Limit: limit, Class: c.QueryParam("class")}
+		if f := c.QueryParam("features"); f != "" {
+			q.Features = strings.Split(f, ",")
+		}
 		drivers, err := uc.FindNearestDrivers(c.Request().Context(), q)
 		if err != nil {
+			if err == usecase.ErrInvalidAttributes || err == usecase.ErrInvalidCoordinates || err == usecase.ErrInvalidRadius {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "search failed"})
 		}
 		return c.JSON(http.StatusOK, map[string]interface{}{"drivers": drivers})
 	}
 }
+
+// DriverLocations — GET /api/v1/drivers/locations?ids=d1,d2 — current positions (ETA on ride bids);
+// drivers offline or without a recent fix are omitted. Service tokens only (behind JWTAuth).
+func DriverLocations(uc LocationUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if role, _ := c.Get(UserRoleKey).(string); role != ServiceRole {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "service token required"})
+		}
+		var ids []string
+		if q := c.QueryParam("ids"); q != "" {
+			ids = strings.Split(q, ",")
+		}
+		drivers, err := uc.DriverLocations(c.Request().Context(), ids)
+		if err != nil {
+			if err == usecase.ErrTooManyDrivers {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "lookup failed"})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"drivers": drivers})
+	}
+}
+
+// DriverAttributesRequest — PUT /api/v1/drivers/:id/attributes (from user service on verification approval)
+type DriverAttributesRequest struct {
+	VehicleClass string   `json:"vehicle_class"`
+	Features     []string `json:"features"`
+}
+
+// SetDriverAttributes — PUT /api/v1/drivers/:id/attributes — {"vehicle_class":"comfort","features":["child_seat"]};
+// service tokens or the driver's own (behind JWTAuth)
+func SetDriverAttributes(uc LocationUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if role, _ := c.Get(UserRoleKey).(string); role != ServiceRole && c.Get(UserIDKey) != c.Param("id") {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "not allowed to set these attributes"})
+		}
+		var req DriverAttributesRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		}
+		a, err := uc.SetDriverAttributes(c.Request().Context(), domain.DriverAttributes{
+			DriverID:     c.Param("id"),
+			VehicleClass: req.VehicleClass,
+			Features:     req.Features,
+		})
+		if err != nil {
+			if err == usecase.ErrInvalidAttributes {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to store attributes"})
+		}
+		return c.JSON(http.StatusOK, a)
+	}
+}
+
+// GetDriverAttributes — GET /api/v1/drivers/:id/attributes
+func GetDriverAttributes(uc LocationUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		a, err := uc.GetDriverAttributes(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get attributes"})
+		}
+		if a == nil {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "attributes not found"})
+		}
+		return c.JSON(http.StatusOK, a)
+	}
+}
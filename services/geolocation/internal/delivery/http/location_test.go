@@ -0,0 +1,91 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/geolocation/internal/domain"
+	"github.com/ridehail/geolocation/internal/infra/jwt"
+)
+
+// tokens accepts "<role>-<uid>"
+type tokens struct{}
+
+func (tokens) Validate(token string) (*jwt.Claims, error) {
+	role, uid, ok := strings.Cut(token, "-")
+	if !ok {
+		return nil, jwt.ErrInvalidToken
+	}
+	return &jwt.Claims{UserID: uid, Role: role}, nil
+}
+
+type locations struct {
+	LocationUseCase
+}
+
+func (locations) DriverLocations(ctx context.Context, driverIDs []string) ([]domain.DriverLocation, error) {
+	return []domain.DriverLocation{{DriverID: "d1"}}, nil
+}
+
+// call runs h behind JWTAuth with the Authorization header set to auth (if any)
+func call(h echo.HandlerFunc, method, target, auth string, params ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	var names, values []string
+	for i := 0; i+1 < len(params); i += 2 {
+		names, values = append(names, params[i]), append(values, params[i+1])
+	}
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+	_ = JWTAuth(tokens{})(h)(c)
+	return rec
+}
+
+func TestDriverLocations_ServiceTokenOnly(t *testing.T) {
+	h := DriverLocations(locations{})
+	for _, tc := range []struct {
+		name, auth string
+		status     int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"bad token", "Bearer nope", http.StatusUnauthorized},
+		{"driver token", "Bearer driver-d1", http.StatusForbidden},
+		{"admin token", "Bearer admin-a1", http.StatusForbidden},
+		{"service token", "Bearer service-svc:ride", http.StatusOK},
+	} {
+		if rec := call(h, http.MethodGet, "/api/v1/drivers/locations?ids=d1", tc.auth); rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
+
+func (locations) SetDriverAttributes(ctx context.Context, a domain.DriverAttributes) (*domain.DriverAttributes, error) {
+	return &a, nil
+}
+
+func TestSetDriverAttributes_DriverOrService(t *testing.T) {
+	h := SetDriverAttributes(locations{})
+	for _, tc := range []struct {
+		name, auth string
+		status     int
+	}{
+		{"no token", "", http.StatusUnauthorized},
+		{"another driver", "Bearer driver-d2", http.StatusForbidden},
+		{"passenger", "Bearer passenger-p1", http.StatusForbidden},
+		{"the driver", "Bearer driver-d1", http.StatusOK},
+		{"service token", "Bearer service-svc:user", http.StatusOK},
+	} {
+		if rec := call(h, http.MethodPut, "/api/v1/drivers/d1/attributes", tc.auth, "id", "d1"); rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Package pb — wire codec for proto/location.proto (LocationBatch, LocationFix).
+// Hand-written on protowire: two small messages do not justify protoc codegen.
+package pb
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/ridehail/geolocation/internal/domain"
+)
+
+// ContentType — HTTP content type of protobuf-encoded bodies
+const ContentType = "application/x-protobuf"
+
+var ErrMalformed = errors.New("malformed protobuf location batch")
+
+// Field numbers (proto/location.proto)
+const (
+	batchFixes = 1
+
+	fixLat         = 1
+	fixLng         = 2
+	fixHeading     = 3
+	fixSpeed       = 4
+	fixAccuracy    = 5
+	fixTimestampMs = 6
+)
+
+// DecodeBatch parses a LocationBatch; driverID is taken from the authenticated
+// stream/path, never from the payload
+func DecodeBatch(b []byte, driverID string) ([]domain.LocationFix, error) {
+	var out []domain.LocationFix
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, ErrMalformed
+		}
+		b = b[n:]
+		if num == batchFixes && typ == protowire.BytesType {
+			msg, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return nil, ErrMalformed
+			}
+			fix, err := decodeFix(msg)
+			if err != nil {
+				return nil, err
+			}
+			fix.DriverID = driverID
+			out = append(out, fix)
+			b = b[n:]
+			continue
+		}
+		n = protowire.ConsumeFieldValue(num, typ, b)
+		if n < 0 {
+			return nil, ErrMalformed
+		}
+		b = b[n:]
+	}
+	return out, nil
+}
+
+func decodeFix(b []byte) (domain.LocationFix, error) {
+	var f domain.LocationFix
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return f, ErrMalformed
+		}
+		b = b[n:]
+		switch {
+		case typ == protowire.Fixed64Type && (num == fixLat || num == fixLng):
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return f, ErrMalformed
+			}
+			if num == fixLat {
+				f.Location.Lat = math.Float64frombits(v)
+			} else {
+				f.Location.Lng = math.Float64frombits(v)
+			}
+			b = b[n:]
+		case typ == protowire.Fixed32Type && (num == fixHeading || num == fixSpeed || num == fixAccuracy):
+			v, n := protowire.ConsumeFixed32(b)
+			if n < 0 {
+				return f, ErrMalformed
+			}
+			val := float64(math.Float32frombits(v))
+			switch num {
+			case fixHeading:
+				f.Location.Heading = val
+			case fixSpeed:
+				f.Location.Speed = val
+			case fixAccuracy:
+				f.Location.Accuracy = val
+			}
+			b = b[n:]
+		case typ == protowire.VarintType && num == fixTimestampMs:
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return f, ErrMalformed
+			}
+			if ms := int64(v); ms > 0 {
+				f.Timestamp = time.UnixMilli(ms)
+			}
+			b = b[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, b)
+			if n < 0 {
+				return f, ErrMalformed
+			}
+			b = b[n:]
+		}
+	}
+	return f, nil
+}
+
+// EncodeBatch serializes fixes as a LocationBatch (driver IDs are not encoded)
+func EncodeBatch(fixes []domain.LocationFix) []byte {
+	var b []byte
+	for _, f := range fixes {
+		msg := encodeFix(f)
+		b = protowire.AppendTag(b, batchFixes, protowire.BytesType)
+		b = protowire.AppendBytes(b, msg)
+	}
+	return b
+}
+
+func encodeFix(f domain.LocationFix) []byte {
+	var b []byte
+	b = protowire.AppendTag(b, fixLat, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(f.Location.Lat))
+	b = protowire.AppendTag(b, fixLng, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(f.Location.Lng))
+	for _, fv := range []struct {
+		num protowire.Number
+		val float64
+	}{{fixHeading, f.Location.Heading}, {fixSpeed, f.Location.Speed}, {fixAccuracy, f.Location.Accuracy}} {
+		if fv.val != 0 {
+			b = protowire.AppendTag(b, fv.num, protowire.Fixed32Type)
+			b = protowire.AppendFixed32(b, math.Float32bits(float32(fv.val)))
+		}
+	}
+	if !f.Timestamp.IsZero() {
+		b = protowire.AppendTag(b, fixTimestampMs, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(f.Timestamp.UnixMilli()))
+	}
+	return b
+}
@@ -0,0 +1,37 @@
+package pb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ridehail/geolocation/internal/domain"
+)
+
+func TestBatchRoundTrip(t *testing.T) {
+	ts := time.UnixMilli(1760000000123)
+	in := []domain.LocationFix{
+		{Location: domain.Location{Lat: 55.751244, Lng: 37.618423, Heading: 182.5, Speed: 13.25, Accuracy: 4}, Timestamp: ts},
+		{Location: domain.Location{Lat: -33.8688, Lng: 151.2093}, Timestamp: ts.Add(time.Second)},
+	}
+	out, err := DecodeBatch(EncodeBatch(in), "d1")
+	if err != nil {
+		t.Fatalf("DecodeBatch: %v", err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("want 2 fixes, got %d", len(out))
+	}
+	for i := range in {
+		if out[i].DriverID != "d1" || out[i].Location != in[i].Location || !out[i].Timestamp.Equal(in[i].Timestamp) {
+			t.Errorf("fix %d: got %+v, want %+v", i, out[i], in[i])
+		}
+	}
+	if size := len(EncodeBatch(in[:1])); size > 48 {
+		t.Errorf("full fix encodes to %d bytes, expected compact (<= 48)", size)
+	}
+}
+
+func TestDecodeBatch_Malformed(t *testing.T) {
+	if _, err := DecodeBatch([]byte{0x0a, 0x05, 0x09}, "d1"); err != ErrMalformed {
+		t.Errorf("want ErrMalformed, got %v", err)
+	}
+}
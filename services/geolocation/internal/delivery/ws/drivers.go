@@ -0,0 +1,80 @@
+package ws
+
+import (
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/ridehail/geolocation/internal/domain"
+)
+
+// writeTimeout — a relayed frame that cannot be written in time drops the connection
+const writeTimeout = 10 * time.Second
+
+// DriverConns — open driver location streams of this instance by driver id; the
+// ride chat relay pushes text frames down them
+type DriverConns struct {
+	mu    sync.Mutex
+	conns map[string]map[*driverConn]struct{}
+}
+
+// driverConn serializes writes: gorilla allows one concurrent writer per connection
+type driverConn struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func NewDriverConns() *DriverConns {
+	return &DriverConns{conns: map[string]map[*driverConn]struct{}{}}
+}
+
+func (d *DriverConns) add(driverID string, conn *websocket.Conn) *driverConn {
+	dc := &driverConn{conn: conn}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.conns[driverID] == nil {
+		d.conns[driverID] = map[*driverConn]struct{}{}
+	}
+	d.conns[driverID][dc] = struct{}{}
+	return dc
+}
+
+func (d *DriverConns) remove(driverID string, dc *driverConn) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.conns[driverID], dc)
+	if len(d.conns[driverID]) == 0 {
+		delete(d.conns, driverID)
+	}
+}
+
+// Deliver writes the event as a JSON text frame to every stream the driver has open
+// here; users without one (passengers, other instances) are skipped
+func (d *DriverConns) Deliver(userID string, e domain.RideEvent) {
+	d.mu.Lock()
+	targets := make([]*driverConn, 0, len(d.conns[userID]))
+	for dc := range d.conns[userID] {
+		targets = append(targets, dc)
+	}
+	d.mu.Unlock()
+	if len(targets) == 0 {
+		return
+	}
+	msg, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	for _, dc := range targets {
+		dc.mu.Lock()
+		_ = dc.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		err := dc.conn.WriteMessage(websocket.TextMessage, msg)
+		dc.mu.Unlock()
+		if err != nil {
+			slog.Warn("ws relay failed", "driver_id", userID, "error", err)
+			dc.conn.Close() // the read loop ends and unregisters
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ws
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/geolocation/internal/domain"
+	"github.com/ridehail/geolocation/internal/infra/jwt"
+	"github.com/ridehail/geolocation/internal/usecase"
+)
+
+// tokens accepts "token-<user id>"
+type tokens struct{}
+
+func (tokens) Validate(token string) (*jwt.Claims, error) {
+	userID, ok := strings.CutPrefix(token, "token-")
+	if !ok {
+		return nil, jwt.ErrInvalidToken
+	}
+	return &jwt.Claims{UserID: userID, Role: "driver"}, nil
+}
+
+type noIngest struct{}
+
+func (noIngest) IngestFixes(ctx context.Context, fixes []domain.LocationFix) (usecase.IngestResult, error) {
+	return usecase.IngestResult{}, nil
+}
+
+func TestDriverConns_RelaysChatToDriverStream(t *testing.T) {
+	conns := NewDriverConns()
+	e := echo.New()
+	e.GET("/ws/drivers/:id/locations", HandleDriverLocations(noIngest{}, conns, tokens{}))
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/drivers/d1/locations"
+	for token, status := range map[string]int{"": http.StatusUnauthorized, "forged": http.StatusUnauthorized, "token-d2": http.StatusForbidden} {
+		_, resp, err := websocket.DefaultDialer.Dial(url+"?token="+token, nil)
+		if err == nil || resp == nil || resp.StatusCode != status {
+			t.Errorf("token %q: must be refused with %d, got %v", token, status, resp)
+		}
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer token-d1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conns.mu.Lock()
+		n := len(conns.conns["d1"])
+		conns.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stream not registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	conns.Deliver("p1", domain.RideEvent{RideID: "r1", Type: "chat.message", To: "p1"}) // no socket: skipped
+	conns.Deliver("d1", domain.RideEvent{RideID: "r1", Type: "chat.message", To: "d1", Data: json.RawMessage(`{"text":"5 minutes"}`)})
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	typ, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got domain.RideEvent
+	if typ != websocket.TextMessage || json.Unmarshal(data, &got) != nil || got.RideID != "r1" || string(got.Data) != `{"text":"5 minutes"}` {
+		t.Fatalf("relayed frame %d %s", typ, data)
+	}
+}
@@ -0,0 +1,94 @@
+package ws
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/geolocation/internal/delivery/pb"
+	"github.com/ridehail/geolocation/internal/domain"
+	"github.com/ridehail/geolocation/internal/infra/jwt"
+	"github.com/ridehail/geolocation/internal/usecase"
+)
+
+const (
+	// maxFrameSize — one binary frame carries a LocationBatch (≈40 bytes per fix)
+	maxFrameSize = 32 << 10
+	// streamIdleTimeout — drivers send at least one fix (or ping) within this window
+	streamIdleTimeout = 2 * time.Minute
+)
+
+type LocationIngester interface {
+	IngestFixes(ctx context.Context, fixes []domain.LocationFix) (usecase.IngestResult, error)
+}
+
+type JWTValidator interface {
+	Validate(tokenString string) (*jwt.Claims, error)
+}
+
+// HandleDriverLocations — GET /ws/drivers/:id/locations — long-lived driver stream.
+// Each binary frame is a proto LocationBatch (proto/location.proto); the server does
+// not ack frames. Malformed frames close the stream with 1003 (unsupported data).
+// With conns, the server pushes ride chat events to the driver as JSON text frames.
+// The driver's own token is required before the upgrade: Authorization: Bearer, or
+// ?token= for clients that cannot set headers on a WebSocket.
+func HandleDriverLocations(uc LocationIngester, conns *DriverConns, tokens JWTValidator) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		driverID := c.Param("id")
+		token := c.QueryParam("token")
+		if auth := c.Request().Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			token = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if token == "" {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "missing token"})
+		}
+		claims, err := tokens.Validate(token)
+		if err != nil {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"error": "invalid or expired token"})
+		}
+		if claims.UserID != driverID {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "not this driver's stream"})
+		}
+		conn, err := upgrader.Upgrade(c.Response(), c.Request(), nil)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if conns != nil {
+			dc := conns.add(driverID, conn)
+			defer conns.remove(driverID, dc)
+		}
+		conn.SetReadLimit(maxFrameSize)
+		_ = conn.SetReadDeadline(time.Now().Add(streamIdleTimeout))
+		conn.SetPingHandler(func(data string) error {
+			_ = conn.SetReadDeadline(time.Now().Add(streamIdleTimeout))
+			return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(5*time.Second))
+		})
+
+		for {
+			typ, data, err := conn.ReadMessage()
+			if err != nil {
+				return nil
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(streamIdleTimeout))
+			if typ != websocket.BinaryMessage {
+				continue
+			}
+			fixes, err := pb.DecodeBatch(data, driverID)
+			if err != nil {
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseUnsupportedData, err.Error()),
+					time.Now().Add(5*time.Second))
+				return nil
+			}
+			if _, err := uc.IngestFixes(c.Request().Context(), fixes); err != nil {
+				slog.Warn("driver location ingest failed", "driver_id", driverID, "error", err)
+			}
+		}
+	}
+}
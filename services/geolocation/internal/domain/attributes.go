@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Vehicle classes, lowest to highest: a driver serves requests for their class and below
+const (
+	VehicleClassEconomy  = "economy"
+	VehicleClassComfort  = "comfort"
+	VehicleClassBusiness = "business"
+)
+
+// VehicleClassCargo — cargo van: unranked, serves cargo requests only (exact match)
+const VehicleClassCargo = "cargo"
+
+// Vehicle features (attributes a passenger can require)
+const (
+	FeatureMinivan     = "minivan"
+	FeatureChildSeat   = "child_seat"
+	FeaturePetFriendly = "pet_friendly"
+	FeatureWheelchair  = "wheelchair"
+)
+
+var classRank = map[string]int{
+	VehicleClassEconomy:  0,
+	VehicleClassComfort:  1,
+	VehicleClassBusiness: 2,
+}
+
+var knownFeatures = map[string]bool{
+	FeatureMinivan:     true,
+	FeatureChildSeat:   true,
+	FeaturePetFriendly: true,
+	FeatureWheelchair:  true,
+}
+
+// IsValidVehicleClass reports whether class is a known vehicle class
+func IsValidVehicleClass(class string) bool {
+	_, ok := classRank[class]
+	return ok || class == VehicleClassCargo
+}
+
+// classServes reports whether a driver of class have serves a request for class want:
+// ranked classes serve their rank and below, unranked ones only themselves
+func classServes(have, want string) bool {
+	haveRank, ok1 := classRank[have]
+	wantRank, ok2 := classRank[want]
+	if !ok1 || !ok2 {
+		return have == want
+	}
+	return haveRank >= wantRank
+}
+
+// NormalizeFeatures lowercases, dedupes and sorts features; ok is false on an unknown feature
+func NormalizeFeatures(features []string) (out []string, ok bool) {
+	seen := make(map[string]bool, len(features))
+	for _, f := range features {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || seen[f] {
+			continue
+		}
+		if !knownFeatures[f] {
+			return nil, false
+		}
+		seen[f] = true
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	return out, true
+}
+
+// DriverAttributes — vehicle class and features from the driver's approved verification
+// (synced from the user service)
+type DriverAttributes struct {
+	DriverID     string    `json:"driver_id"`
+	VehicleClass string    `json:"vehicle_class"`
+	Features     []string  `json:"features,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at,omitempty"`
+}
+
+// Matches reports whether the driver serves class (empty = any) and has every feature
+func (a *DriverAttributes) Matches(class string, features []string) bool {
+	if a == nil {
+		return class == "" && len(features) == 0
+	}
+	if class != "" && !classServes(a.VehicleClass, class) {
+		return false
+	}
+	for _, f := range features {
+		found := false
+		for _, have := range a.Features {
+			if have == f {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -1,23 +1,54 @@
 // Package domain — Geolocation bounded context: driver position, nearest search
 package domain
 
-// Location — lat/lng (WGS84)
+import "time"
+
+// Location — lat/lng (WGS84) plus motion data from the device GPS
 type Location struct {
-	Lat float64 `json:"lat"`
-	Lng float64 `json:"lng"`
+	Lat      float64 `json:"lat"`
+	Lng      float64 `json:"lng"`
+	Heading  float64 `json:"heading,omitempty"`  // degrees clockwise from north, [0,360)
+	Speed    float64 `json:"speed,omitempty"`    // m/s
+	Accuracy float64 `json:"accuracy,omitempty"` // horizontal accuracy, meters
+}
+
+// Valid reports whether coordinates are within WGS84 bounds
+func (l Location) Valid() bool {
+	return l.Lat >= -90 && l.Lat <= 90 && l.Lng >= -180 && l.Lng <= 180
+}
+
+// LocationFix — one GPS fix reported by a driver device
+type LocationFix struct {
+	DriverID  string    `json:"driver_id"`
+	Location  Location  `json:"location"`
+	Timestamp time.Time `json:"timestamp"` // device time of the fix
 }
 
 // DriverLocation — driver id + position (Redis GEO member)
 type DriverLocation struct {
-	DriverID string   `json:"driver_id"`
-	Location Location `json:"location"`
-	Distance float64  `json:"distance,omitempty"` // km, filled on nearest search
+	DriverID  string    `json:"driver_id"`
+	Location  Location  `json:"location"`
+	Distance  float64   `json:"distance,omitempty"`   // km, filled on nearest search
+	UpdatedAt time.Time `json:"updated_at,omitempty"` // device time of the last accepted fix
+	// Attributes — filled on nearest search with class/feature filters
+	Attributes *DriverAttributes `json:"attributes,omitempty"`
 }
 
-// NearestQuery — input for search
+// MaxNearestRadiusKM — widest nearest search; larger radii are capped to it
+const MaxNearestRadiusKM = 50.0
+
+// NearestQuery — input for search; Class/Features restrict results to drivers
+// whose approved vehicle serves the class and has all the features
 type NearestQuery struct {
 	Lat      float64
 	Lng      float64
 	RadiusKM float64
 	Limit    int
+	Class    string
+	Features []string
+}
+
+// Filtered reports whether the query restricts drivers by vehicle attributes
+func (q NearestQuery) Filtered() bool {
+	return q.Class != "" || len(q.Features) > 0
 }
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// RideEvent — event of the ride service's ride.events stream; geolocation relays the
+// chat ones (chat.message, chat.read) to the recipient driver's tracking WebSocket
+type RideEvent struct {
+	RideID string          `json:"ride_id"`
+	Type   string          `json:"type"`
+	To     string          `json:"to,omitempty"` // recipient user id
+	Data   json.RawMessage `json:"data"`
+	At     time.Time       `json:"at"`
+}
+
+// IsChat reports whether the event is a chat event addressed to someone
+func (e RideEvent) IsChat() bool {
+	return strings.HasPrefix(e.Type, "chat.") && e.To != ""
+}
@@ -0,0 +1,85 @@
+// Package kafka — ride service events consumed by geolocation
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/IBM/sarama"
+
+	"github.com/ridehail/geolocation/internal/domain"
+)
+
+// TopicRideEvents — ride stream events (ride service), keyed by ride id
+const TopicRideEvents = "ride.events"
+
+// ChatSink — delivers an event to the recipient's open connection on this instance
+type ChatSink interface {
+	Deliver(userID string, e domain.RideEvent)
+}
+
+// ChatRelayConsumer reads every partition of ride.events from the newest offset and
+// passes chat events to the local WebSocket registry. No consumer group: a driver's
+// socket may be open on any instance.
+type ChatRelayConsumer struct {
+	consumer sarama.Consumer
+	sink     ChatSink
+}
+
+func NewChatRelayConsumer(brokers []string, sink ChatSink) (*ChatRelayConsumer, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Return.Errors = true
+	consumer, err := sarama.NewConsumer(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	return &ChatRelayConsumer{consumer: consumer, sink: sink}, nil
+}
+
+// Run consumes all partitions until ctx is cancelled
+func (c *ChatRelayConsumer) Run(ctx context.Context) error {
+	partitions, err := c.consumer.Partitions(TopicRideEvents)
+	if err != nil {
+		return err
+	}
+	for _, p := range partitions {
+		pc, err := c.consumer.ConsumePartition(TopicRideEvents, p, sarama.OffsetNewest)
+		if err != nil {
+			return err
+		}
+		go c.consumePartition(ctx, pc)
+	}
+	return nil
+}
+
+func (c *ChatRelayConsumer) consumePartition(ctx context.Context, pc sarama.PartitionConsumer) {
+	defer pc.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return
+			}
+			var e domain.RideEvent
+			if err := json.Unmarshal(msg.Value, &e); err != nil {
+				slog.Warn("kafka bad ride event", "offset", msg.Offset, "error", err)
+				continue
+			}
+			if e.IsChat() {
+				c.sink.Deliver(e.To, e)
+			}
+		case err, ok := <-pc.Errors():
+			if !ok {
+				return
+			}
+			slog.Warn("kafka consume failed", "topic", TopicRideEvents, "error", err)
+		}
+	}
+}
+
+func (c *ChatRelayConsumer) Close() error {
+	return c.consumer.Close()
+}
@@ -1,40 +1,179 @@
-// Package redis — Redis GEO: driver positions (GEORADIUS for nearest)
+// Package redis — Redis GEO: driver positions (GEORADIUS for nearest).
+// Drivers are sharded by coarse geohash cell: one GEO key per cell
+// ("drivers:location:<geohash>"), so search cost tracks local density, not the global fleet.
 package redis
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 
 	"github.com/ridehail/geolocation/internal/domain"
 )
 
+// GeoKeyDriversMeta — hash driver_id -> last fix metadata (heading, speed, accuracy, device time);
+// GEO sorted sets only hold coordinates
+const GeoKeyDriversMeta = "drivers:location:meta"
+
+// GeoKeyDriverAttributes — hash driver_id -> vehicle attributes JSON (synced from user service;
+// not removed when the driver goes offline)
+const GeoKeyDriverAttributes = "drivers:attributes"
+
+const (
+	// DefaultShardPrecision — geohash length of a shard cell (4 ≈ 39×20 km)
+	DefaultShardPrecision = 4
+	// maxSearchShards — cells queried per nearest search; wider radii are narrowed to fit
+	maxSearchShards = 64
+	// minSearchRadiusKm — narrowing stops here: the driver's own cell is searched
+	minSearchRadiusKm = 0.1
+)
+
 type GeoStore struct {
-	cli *redis.Client
-	key string
+	cli       *redis.Client
+	precision int
+	metaKey   string
+	attrKey   string
 }
 
-func NewGeoStore(cli *redis.Client) *GeoStore {
+func NewGeoStore(cli *redis.Client, shardPrecision int) *GeoStore {
 	if cli == nil {
 		return nil
 	}
-	return &GeoStore{cli: cli, key: GeoKeyDrivers}
+	if shardPrecision <= 0 || shardPrecision > 6 {
+		shardPrecision = DefaultShardPrecision
+	}
+	return &GeoStore{cli: cli, precision: shardPrecision, metaKey: GeoKeyDriversMeta, attrKey: GeoKeyDriverAttributes}
 }
 
-// Update driver position (GEOADD)
-func (s *GeoStore) Set(ctx context.Context, driverID string, lat, lng float64) error {
-	return s.cli.GeoAdd(ctx, s.key, &redis.GeoLocation{
-		Name:      driverID,
-		Longitude: lng,
-		Latitude:  lat,
-	}).Err()
+// shardKey — GEO key of a geohash cell
+func shardKey(cell string) string {
+	return GeoKeyDrivers + ":" + cell
 }
 
-// Nearest drivers (GEORADIUS with WITHDIST, LIMIT)
+func (s *GeoStore) shardOf(lat, lng float64) string {
+	return geohash(lat, lng, s.precision)
+}
+
+// fixMeta — compact JSON stored per driver in the meta hash
+type fixMeta struct {
+	Heading  float64 `json:"h,omitempty"`
+	Speed    float64 `json:"s,omitempty"`
+	Accuracy float64 `json:"a,omitempty"`
+	TsMs     int64   `json:"t"`
+	Shard    string  `json:"g"` // geohash cell the driver is currently indexed in
+}
+
+// SetFixes writes positions and metadata for many drivers: one multi-member GEOADD
+// per shard, ZREM from the previous shard for drivers that crossed a cell border,
+// and a single HSET — all in one pipeline
+func (s *GeoStore) SetFixes(ctx context.Context, fixes []domain.LocationFix) error {
+	if len(fixes) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(fixes))
+	for _, f := range fixes {
+		ids = append(ids, f.DriverID)
+	}
+	prev, err := s.meta(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	geo := make(map[string][]*redis.GeoLocation)
+	moved := make(map[string][]interface{})
+	meta := make([]interface{}, 0, 2*len(fixes))
+	for _, f := range fixes {
+		cell := s.shardOf(f.Location.Lat, f.Location.Lng)
+		geo[cell] = append(geo[cell], &redis.GeoLocation{
+			Name:      f.DriverID,
+			Longitude: f.Location.Lng,
+			Latitude:  f.Location.Lat,
+		})
+		if old, ok := prev[f.DriverID]; ok && old.Shard != "" && old.Shard != cell {
+			moved[old.Shard] = append(moved[old.Shard], f.DriverID)
+		}
+		b, err := json.Marshal(fixMeta{
+			Heading:  f.Location.Heading,
+			Speed:    f.Location.Speed,
+			Accuracy: f.Location.Accuracy,
+			TsMs:     f.Timestamp.UnixMilli(),
+			Shard:    cell,
+		})
+		if err != nil {
+			return err
+		}
+		meta = append(meta, f.DriverID, b)
+	}
+	_, err = s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for cell, members := range moved {
+			pipe.ZRem(ctx, shardKey(cell), members...)
+		}
+		for cell, locs := range geo {
+			pipe.GeoAdd(ctx, shardKey(cell), locs...)
+		}
+		pipe.HSet(ctx, s.metaKey, meta...)
+		return nil
+	})
+	return err
+}
+
+// LastFixTimes — device time of the last stored fix per driver (HMGET)
+func (s *GeoStore) LastFixTimes(ctx context.Context, driverIDs []string) (map[string]time.Time, error) {
+	metas, err := s.meta(ctx, driverIDs)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]time.Time, len(metas))
+	for id, m := range metas {
+		out[id] = time.UnixMilli(m.TsMs)
+	}
+	return out, nil
+}
+
+func (s *GeoStore) meta(ctx context.Context, driverIDs []string) (map[string]fixMeta, error) {
+	out := make(map[string]fixMeta, len(driverIDs))
+	if len(driverIDs) == 0 {
+		return out, nil
+	}
+	vals, err := s.cli.HMGet(ctx, s.metaKey, driverIDs...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range vals {
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var m fixMeta
+		if err := json.Unmarshal([]byte(str), &m); err == nil {
+			out[driverIDs[i]] = m
+		}
+	}
+	return out, nil
+}
+
+// Nearest drivers — GEORADIUS (WITHDIST, LIMIT) on every shard the search circle
+// touches, pipelined; results merged by distance so searches across a cell border work
 func (s *GeoStore) Nearest(ctx context.Context, lat, lng, radiusKm float64, limit int) ([]domain.DriverLocation, error) {
 	if limit <= 0 {
 		limit = 10
 	}
+	if !(domain.Location{Lat: lat, Lng: lng}).Valid() || !(radiusKm > 0) || math.IsInf(radiusKm, 0) {
+		return nil, fmt.Errorf("nearest: invalid search circle %v,%v r=%v", lat, lng, radiusKm)
+	}
+	cells := geohashCover(lat, lng, radiusKm, s.precision, maxSearchShards)
+	for cells == nil && radiusKm > minSearchRadiusKm {
+		radiusKm /= 2
+		cells = geohashCover(lat, lng, radiusKm, s.precision, maxSearchShards)
+	}
+	if cells == nil {
+		cells = []string{geohash(lat, lng, s.precision)}
+	}
 	q := &redis.GeoRadiusQuery{
 		Radius:    radiusKm,
 		Unit:      "km",
@@ -43,7 +182,34 @@ func (s *GeoStore) Nearest(ctx context.Context, lat, lng, radiusKm float64, limi
 		Count:     limit,
 		Sort:      "ASC",
 	}
-	results, err := s.cli.GeoRadius(ctx, s.key, lng, lat, q).Result()
+	cmds := make([]*redis.GeoLocationCmd, 0, len(cells))
+	_, err := s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, cell := range cells {
+			cmds = append(cmds, pipe.GeoRadius(ctx, shardKey(cell), lng, lat, q))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	var results []redis.GeoLocation
+	for _, cmd := range cmds {
+		locs, err := cmd.Result()
+		if err != nil && err != redis.Nil {
+			return nil, err
+		}
+		results = append(results, locs...)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Dist < results[j].Dist })
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	ids := make([]string, 0, len(results))
+	for _, r := range results {
+		ids = append(ids, r.Name)
+	}
+	metas, err := s.meta(ctx, ids)
 	if err != nil {
 		return nil, err
 	}
@@ -54,12 +220,102 @@ func (s *GeoStore) Nearest(ctx context.Context, lat, lng, radiusKm float64, limi
 			Location: domain.Location{Lat: r.Latitude, Lng: r.Longitude},
 			Distance: r.Dist,
 		}
+		if m, ok := metas[r.Name]; ok {
+			loc.Location.Heading = m.Heading
+			loc.Location.Speed = m.Speed
+			loc.Location.Accuracy = m.Accuracy
+			loc.UpdatedAt = time.UnixMilli(m.TsMs)
+		}
 		out = append(out, loc)
 	}
 	return out, nil
 }
 
-// Remove driver (e.g. offline) — ZREM by member name (Redis GEO is sorted set)
+// Positions — current position and fix metadata per driver: HMGET meta for the shard,
+// then GEOPOS per driver pipelined (missing = driver not indexed)
+func (s *GeoStore) Positions(ctx context.Context, driverIDs []string) (map[string]domain.DriverLocation, error) {
+	metas, err := s.meta(ctx, driverIDs)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]domain.DriverLocation, len(metas))
+	if len(metas) == 0 {
+		return out, nil
+	}
+	cmds := make(map[string]*redis.GeoPosCmd, len(metas))
+	_, err = s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for id, m := range metas {
+			if m.Shard != "" {
+				cmds[id] = pipe.GeoPos(ctx, shardKey(m.Shard), id)
+			}
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for id, cmd := range cmds {
+		pos, err := cmd.Result()
+		if err != nil || len(pos) == 0 || pos[0] == nil {
+			continue
+		}
+		m := metas[id]
+		out[id] = domain.DriverLocation{
+			DriverID: id,
+			Location: domain.Location{
+				Lat: pos[0].Latitude, Lng: pos[0].Longitude,
+				Heading: m.Heading, Speed: m.Speed, Accuracy: m.Accuracy,
+			},
+			UpdatedAt: time.UnixMilli(m.TsMs),
+		}
+	}
+	return out, nil
+}
+
+// Remove driver (e.g. offline) — ZREM from its shard (Redis GEO is sorted set) + meta
 func (s *GeoStore) Remove(ctx context.Context, driverID string) error {
-	return s.cli.ZRem(ctx, s.key, driverID).Err()
+	metas, err := s.meta(ctx, []string{driverID})
+	if err != nil {
+		return err
+	}
+	_, err = s.cli.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		if m, ok := metas[driverID]; ok && m.Shard != "" {
+			pipe.ZRem(ctx, shardKey(m.Shard), driverID)
+		}
+		pipe.HDel(ctx, s.metaKey, driverID)
+		return nil
+	})
+	return err
+}
+
+// SetAttributes — HSET driver's vehicle attributes
+func (s *GeoStore) SetAttributes(ctx context.Context, a domain.DriverAttributes) error {
+	b, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	return s.cli.HSet(ctx, s.attrKey, a.DriverID, b).Err()
+}
+
+// Attributes — HMGET vehicle attributes for drivers
+func (s *GeoStore) Attributes(ctx context.Context, driverIDs []string) (map[string]*domain.DriverAttributes, error) {
+	out := make(map[string]*domain.DriverAttributes, len(driverIDs))
+	if len(driverIDs) == 0 {
+		return out, nil
+	}
+	vals, err := s.cli.HMGet(ctx, s.attrKey, driverIDs...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range vals {
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+		var a domain.DriverAttributes
+		if err := json.Unmarshal([]byte(str), &a); err == nil {
+			out[driverIDs[i]] = &a
+		}
+	}
+	return out, nil
 }
@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"math"
+
+	"github.com/ridehail/geolocation/internal/domain"
+)
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// geohash encodes coordinates to a geohash of the given precision (chars)
+func geohash(lat, lng float64, precision int) string {
+	latLo, latHi := -90.0, 90.0
+	lngLo, lngHi := -180.0, 180.0
+	out := make([]byte, 0, precision)
+	even := true // bits alternate starting with longitude
+	var ch, bit int
+	for len(out) < precision {
+		if even {
+			mid := (lngLo + lngHi) / 2
+			if lng >= mid {
+				ch |= 1 << (4 - bit)
+				lngLo = mid
+			} else {
+				lngHi = mid
+			}
+		} else {
+			mid := (latLo + latHi) / 2
+			if lat >= mid {
+				ch |= 1 << (4 - bit)
+				latLo = mid
+			} else {
+				latHi = mid
+			}
+		}
+		even = !even
+		if bit++; bit == 5 {
+			out = append(out, geohashAlphabet[ch])
+			ch, bit = 0, 0
+		}
+	}
+	return string(out)
+}
+
+// geohashCellSize returns cell height (lat) and width (lng) in degrees for a precision
+func geohashCellSize(precision int) (latDeg, lngDeg float64) {
+	bits := 5 * precision
+	lngBits := (bits + 1) / 2
+	latBits := bits / 2
+	return 180 / math.Pow(2, float64(latBits)), 360 / math.Pow(2, float64(lngBits))
+}
+
+// geohashCover returns geohash cells of the given precision that intersect the
+// bounding box of a circle (radiusKm around lat/lng). Returns nil when more than
+// maxCells would be needed, or for a circle that is not finite — callers then fall
+// back to a narrower search.
+func geohashCover(lat, lng, radiusKm float64, precision, maxCells int) []string {
+	if !(domain.Location{Lat: lat, Lng: lng}).Valid() || !(radiusKm >= 0) || math.IsInf(radiusKm, 0) {
+		return nil // would never finish walking the box
+	}
+	const kmPerDegLat = 111.32
+	dLat := radiusKm / kmPerDegLat
+	cosLat := math.Cos(lat * math.Pi / 180)
+	dLng := 180.0
+	if cosLat > 1e-6 {
+		dLng = math.Min(180, radiusKm/(kmPerDegLat*cosLat))
+	}
+	minLat, maxLat := math.Max(-90, lat-dLat), math.Min(90, lat+dLat)
+	cellLat, cellLng := geohashCellSize(precision)
+
+	seen := make(map[string]struct{})
+	var out []string
+	add := func(la, ln float64) bool {
+		ln = wrapLng(ln)
+		h := geohash(math.Min(la, 90-1e-9), ln, precision)
+		if _, ok := seen[h]; ok {
+			return true
+		}
+		if len(out) == maxCells {
+			return false
+		}
+		seen[h] = struct{}{}
+		out = append(out, h)
+		return true
+	}
+	// sample one point per cell row/column, plus the box edges
+	for la := minLat; ; la += cellLat {
+		la = math.Min(la, maxLat)
+		for ln := lng - dLng; ; ln += cellLng {
+			ln = math.Min(ln, lng+dLng)
+			if !add(la, ln) {
+				return nil
+			}
+			if ln >= lng+dLng {
+				break
+			}
+		}
+		if la >= maxLat {
+			break
+		}
+	}
+	return out
+}
+
+func wrapLng(lng float64) float64 {
+	for lng >= 180 {
+		lng -= 360
+	}
+	for lng < -180 {
+		lng += 360
+	}
+	return lng
+}
@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeohash(t *testing.T) {
+	if got := geohash(57.64911, 10.40744, 11); got != "u4pruydqqvj" {
+		t.Errorf("geohash = %q, want u4pruydqqvj", got)
+	}
+}
+
+func TestGeohashCover(t *testing.T) {
+	cellLat, cellLng := geohashCellSize(4)
+
+	// centre of the precision-4 cell around Moscow, 1 km — a single shard
+	lat := cellLat * (math.Floor(55.7558/cellLat) + 0.5)
+	lng := cellLng * (math.Floor(37.6173/cellLng) + 0.5)
+	cells := geohashCover(lat, lng, 1, 4, maxSearchShards)
+	if len(cells) != 1 || cells[0] != geohash(lat, lng, 4) {
+		t.Errorf("small search: got %v", cells)
+	}
+
+	// a point just west of a cell border: the search must include the neighbour cell
+	border := cellLng * float64(int(37.6173/cellLng)+1)
+	west := geohash(55.7558, border-0.001, 4)
+	east := geohash(55.7558, border+0.001, 4)
+	cells = geohashCover(55.7558, border-0.001, 2, 4, maxSearchShards)
+	if !contains(cells, west) || !contains(cells, east) {
+		t.Errorf("border search %v must include %s and %s", cells, west, east)
+	}
+
+	// too wide for the shard budget
+	if cells := geohashCover(55.7558, 37.6173, 2000, 4, maxSearchShards); cells != nil {
+		t.Errorf("want nil for oversized search, got %d cells", len(cells))
+	}
+
+	// a circle that is not finite is never walked
+	for _, r := range []float64{math.Inf(1), math.NaN()} {
+		if cells := geohashCover(55.7558, 37.6173, r, 4, maxSearchShards); cells != nil {
+			t.Errorf("radius %v: want nil, got %d cells", r, len(cells))
+		}
+	}
+	if cells := geohashCover(math.NaN(), 37.6173, 1, 4, maxSearchShards); cells != nil {
+		t.Errorf("NaN latitude: want nil, got %d cells", len(cells))
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// GeoKeyDrivers — prefix of per-shard GEO keys ("drivers:location:<geohash>")
 const GeoKeyDrivers = "drivers:location"
 
 // New creates Redis client. Addr required for geolocation (GEO commands).
@@ -3,31 +3,124 @@ package usecase
 import (
 	"context"
 	"errors"
+	"math"
+	"time"
 
 	"github.com/ridehail/geolocation/internal/domain"
 )
 
-var ErrInvalidCoordinates = errors.New("invalid coordinates: lat in [-90,90], lng in [-180,180]")
+var (
+	ErrInvalidCoordinates = errors.New("invalid coordinates: lat in [-90,90], lng in [-180,180]")
+	ErrBatchTooLarge      = errors.New("too many fixes in one batch")
+	ErrInvalidAttributes  = errors.New("invalid vehicle class or feature")
+	ErrTooManyDrivers     = errors.New("too many driver ids")
+	ErrInvalidRadius      = errors.New("radius_km must be a positive number")
+)
+
+const (
+	// MaxBatchSize — fixes accepted per batch (≈8 min of 1 Hz GPS)
+	MaxBatchSize = 500
+	// maxClockSkew — device timestamps further in the future are clamped to server time
+	maxClockSkew = 30 * time.Second
+	// filterOverfetch — candidates fetched per requested driver when filtering by attributes
+	filterOverfetch = 5
+	// maxFilterFetch — cap on candidates fetched for a filtered search
+	maxFilterFetch = 200
+	// MaxLocationsBatch — driver ids per positions lookup
+	MaxLocationsBatch = 100
+	// LocationMaxAge — positions older than this are treated as offline on lookup
+	LocationMaxAge = 5 * time.Minute
+)
 
 type GeoStore interface {
-	Set(ctx context.Context, driverID string, lat, lng float64) error
+	// SetFixes writes positions (GEOADD) and fix metadata for many drivers in one pipeline
+	SetFixes(ctx context.Context, fixes []domain.LocationFix) error
+	// LastFixTimes returns device time of the last stored fix per driver (missing = none)
+	LastFixTimes(ctx context.Context, driverIDs []string) (map[string]time.Time, error)
 	Nearest(ctx context.Context, lat, lng, radiusKm float64, limit int) ([]domain.DriverLocation, error)
+	// Positions returns current position per driver (missing = not indexed)
+	Positions(ctx context.Context, driverIDs []string) (map[string]domain.DriverLocation, error)
 	Remove(ctx context.Context, driverID string) error
+	// SetAttributes stores a driver's vehicle attributes (kept while the driver is offline)
+	SetAttributes(ctx context.Context, a domain.DriverAttributes) error
+	// Attributes returns vehicle attributes per driver (missing = never synced)
+	Attributes(ctx context.Context, driverIDs []string) (map[string]*domain.DriverAttributes, error)
+}
+
+// IngestResult — outcome of a batch: accepted fixes were written, the rest dropped
+// (invalid, superseded by a newer fix in the same batch, or older than the stored fix)
+type IngestResult struct {
+	Accepted int `json:"accepted"`
+	Dropped  int `json:"dropped"`
 }
 
 type LocationUseCase struct {
 	store GeoStore
+	now   func() time.Time
 }
 
 func NewLocationUseCase(store GeoStore) *LocationUseCase {
-	return &LocationUseCase{store: store}
+	return &LocationUseCase{store: store, now: time.Now}
 }
 
 func (uc *LocationUseCase) UpdateDriverLocation(ctx context.Context, driverID string, lat, lng float64) error {
 	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
 		return ErrInvalidCoordinates
 	}
-	return uc.store.Set(ctx, driverID, lat, lng)
+	_, err := uc.IngestFixes(ctx, []domain.LocationFix{{
+		DriverID:  driverID,
+		Location:  domain.Location{Lat: lat, Lng: lng},
+		Timestamp: uc.clock(),
+	}})
+	return err
+}
+
+// IngestFixes stores a batch of fixes (one or many drivers). Only the newest fix per
+// driver is written; fixes not newer than the stored one are dropped as out-of-order.
+func (uc *LocationUseCase) IngestFixes(ctx context.Context, fixes []domain.LocationFix) (IngestResult, error) {
+	if len(fixes) > MaxBatchSize {
+		return IngestResult{}, ErrBatchTooLarge
+	}
+	now := uc.clock()
+	latest := make(map[string]domain.LocationFix, 1)
+	order := make([]string, 0, 1)
+	for _, f := range fixes {
+		if f.DriverID == "" || !f.Location.Valid() {
+			continue
+		}
+		if f.Timestamp.IsZero() || f.Timestamp.After(now.Add(maxClockSkew)) {
+			f.Timestamp = now
+		}
+		prev, seen := latest[f.DriverID]
+		if !seen {
+			order = append(order, f.DriverID)
+		}
+		if !seen || f.Timestamp.After(prev.Timestamp) {
+			latest[f.DriverID] = f
+		}
+	}
+	if len(order) == 0 {
+		return IngestResult{Dropped: len(fixes)}, nil
+	}
+
+	last, err := uc.store.LastFixTimes(ctx, order)
+	if err != nil {
+		return IngestResult{}, err
+	}
+	write := make([]domain.LocationFix, 0, len(order))
+	for _, id := range order {
+		f := latest[id]
+		if t, ok := last[id]; ok && !f.Timestamp.After(t) {
+			continue
+		}
+		write = append(write, f)
+	}
+	if len(write) > 0 {
+		if err := uc.store.SetFixes(ctx, write); err != nil {
+			return IngestResult{}, err
+		}
+	}
+	return IngestResult{Accepted: len(write), Dropped: len(fixes) - len(write)}, nil
 }
 
 func (uc *LocationUseCase) FindNearestDrivers(ctx context.Context, q domain.NearestQuery) ([]domain.DriverLocation, error) {
@@ -37,9 +130,120 @@ func (uc *LocationUseCase) FindNearestDrivers(ctx context.Context, q domain.Near
 	if q.RadiusKM <= 0 {
 		q.RadiusKM = 10
 	}
-	return uc.store.Nearest(ctx, q.Lat, q.Lng, q.RadiusKM, q.Limit)
+	if !(domain.Location{Lat: q.Lat, Lng: q.Lng}).Valid() {
+		return nil, ErrInvalidCoordinates
+	}
+	if math.IsNaN(q.RadiusKM) || math.IsInf(q.RadiusKM, 0) {
+		return nil, ErrInvalidRadius
+	}
+	q.RadiusKM = math.Min(q.RadiusKM, domain.MaxNearestRadiusKM)
+	if !q.Filtered() {
+		return uc.store.Nearest(ctx, q.Lat, q.Lng, q.RadiusKM, q.Limit)
+	}
+	if q.Class != "" && !domain.IsValidVehicleClass(q.Class) {
+		return nil, ErrInvalidAttributes
+	}
+	features, ok := domain.NormalizeFeatures(q.Features)
+	if !ok {
+		return nil, ErrInvalidAttributes
+	}
+
+	// Most nearby drivers may not match: fetch more candidates, then filter by attributes
+	fetch := q.Limit * filterOverfetch
+	if fetch > maxFilterFetch {
+		fetch = maxFilterFetch
+	}
+	if fetch < q.Limit {
+		fetch = q.Limit
+	}
+	candidates, err := uc.store.Nearest(ctx, q.Lat, q.Lng, q.RadiusKM, fetch)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.DriverID)
+	}
+	attrs, err := uc.store.Attributes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]domain.DriverLocation, 0, q.Limit)
+	for _, c := range candidates {
+		a := attrs[c.DriverID]
+		if a == nil || !a.Matches(q.Class, features) {
+			continue
+		}
+		c.Attributes = a
+		out = append(out, c)
+		if len(out) == q.Limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+// DriverLocations returns current positions of the given drivers (e.g. ETA on ride bids);
+// drivers without a fix in the last LocationMaxAge are omitted
+func (uc *LocationUseCase) DriverLocations(ctx context.Context, driverIDs []string) ([]domain.DriverLocation, error) {
+	ids := make([]string, 0, len(driverIDs))
+	seen := map[string]bool{}
+	for _, id := range driverIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > MaxLocationsBatch {
+		return nil, ErrTooManyDrivers
+	}
+	positions, err := uc.store.Positions(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	cutoff := uc.clock().Add(-LocationMaxAge)
+	out := make([]domain.DriverLocation, 0, len(positions))
+	for _, id := range ids {
+		if loc, ok := positions[id]; ok && loc.UpdatedAt.After(cutoff) {
+			out = append(out, loc)
+		}
+	}
+	return out, nil
+}
+
+// SetDriverAttributes stores vehicle class/features of an approved driver (pushed by the user service)
+func (uc *LocationUseCase) SetDriverAttributes(ctx context.Context, a domain.DriverAttributes) (*domain.DriverAttributes, error) {
+	if a.DriverID == "" || !domain.IsValidVehicleClass(a.VehicleClass) {
+		return nil, ErrInvalidAttributes
+	}
+	features, ok := domain.NormalizeFeatures(a.Features)
+	if !ok {
+		return nil, ErrInvalidAttributes
+	}
+	a.Features = features
+	a.UpdatedAt = uc.clock()
+	if err := uc.store.SetAttributes(ctx, a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetDriverAttributes returns stored vehicle attributes (nil if never synced)
+func (uc *LocationUseCase) GetDriverAttributes(ctx context.Context, driverID string) (*domain.DriverAttributes, error) {
+	attrs, err := uc.store.Attributes(ctx, []string{driverID})
+	if err != nil {
+		return nil, err
+	}
+	return attrs[driverID], nil
 }
 
 func (uc *LocationUseCase) RemoveDriver(ctx context.Context, driverID string) error {
 	return uc.store.Remove(ctx, driverID)
 }
+
+func (uc *LocationUseCase) clock() time.Time {
+	if uc.now == nil {
+		return time.Now()
+	}
+	return uc.now()
+}
@@ -2,11 +2,82 @@ package usecase
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/ridehail/geolocation/internal/domain"
 )
 
+type fakeGeoStore struct {
+	fixes   map[string]domain.LocationFix
+	writes  [][]domain.LocationFix
+	nearest domain.NearestQuery
+	found   []domain.DriverLocation
+	attrs   map[string]*domain.DriverAttributes
+}
+
+func newFakeGeoStore() *fakeGeoStore {
+	return &fakeGeoStore{fixes: map[string]domain.LocationFix{}, attrs: map[string]*domain.DriverAttributes{}}
+}
+
+func (s *fakeGeoStore) SetFixes(ctx context.Context, fixes []domain.LocationFix) error {
+	s.writes = append(s.writes, fixes)
+	for _, f := range fixes {
+		s.fixes[f.DriverID] = f
+	}
+	return nil
+}
+
+func (s *fakeGeoStore) LastFixTimes(ctx context.Context, driverIDs []string) (map[string]time.Time, error) {
+	out := map[string]time.Time{}
+	for _, id := range driverIDs {
+		if f, ok := s.fixes[id]; ok {
+			out[id] = f.Timestamp
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeGeoStore) Nearest(ctx context.Context, lat, lng, radiusKm float64, limit int) ([]domain.DriverLocation, error) {
+	s.nearest = domain.NearestQuery{Lat: lat, Lng: lng, RadiusKM: radiusKm, Limit: limit}
+	if len(s.found) > limit {
+		return s.found[:limit], nil
+	}
+	return s.found, nil
+}
+
+func (s *fakeGeoStore) Positions(ctx context.Context, driverIDs []string) (map[string]domain.DriverLocation, error) {
+	out := map[string]domain.DriverLocation{}
+	for _, id := range driverIDs {
+		if f, ok := s.fixes[id]; ok {
+			out[id] = domain.DriverLocation{DriverID: id, Location: f.Location, UpdatedAt: f.Timestamp}
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeGeoStore) Remove(ctx context.Context, driverID string) error {
+	delete(s.fixes, driverID)
+	return nil
+}
+
+func (s *fakeGeoStore) SetAttributes(ctx context.Context, a domain.DriverAttributes) error {
+	s.attrs[a.DriverID] = &a
+	return nil
+}
+
+func (s *fakeGeoStore) Attributes(ctx context.Context, driverIDs []string) (map[string]*domain.DriverAttributes, error) {
+	out := map[string]*domain.DriverAttributes{}
+	for _, id := range driverIDs {
+		if a, ok := s.attrs[id]; ok {
+			out[id] = a
+		}
+	}
+	return out, nil
+}
+
 func TestLocationUseCase_UpdateDriverLocation_InvalidCoords(t *testing.T) {
 	uc := &LocationUseCase{} // nil store; we only check coords first
 	err := uc.UpdateDriverLocation(context.Background(), "d1", 100, 0)
@@ -20,11 +91,193 @@ func TestLocationUseCase_UpdateDriverLocation_InvalidCoords(t *testing.T) {
 }
 
 func TestLocationUseCase_FindNearestDrivers_DefaultLimit(t *testing.T) {
-	uc := &LocationUseCase{} // nil store will panic on Nearest; just check default limit in usecase
+	store := newFakeGeoStore()
+	uc := NewLocationUseCase(store)
 	q := domain.NearestQuery{Lat: 55.75, Lng: 37.62, Limit: 0, RadiusKM: 0}
-	if q.Limit != 0 {
-		t.Fail()
+	if _, err := uc.FindNearestDrivers(context.Background(), q); err != nil {
+		t.Fatalf("FindNearestDrivers: %v", err)
+	}
+	if store.nearest.Limit != 10 || store.nearest.RadiusKM != 10 {
+		t.Errorf("defaults not applied: %+v", store.nearest)
+	}
+}
+
+func TestLocationUseCase_FindNearestDrivers_BoundsTheCircle(t *testing.T) {
+	store := newFakeGeoStore()
+	uc := NewLocationUseCase(store)
+	ctx := context.Background()
+	for _, q := range []domain.NearestQuery{
+		{Lat: 55.75, Lng: 37.62, RadiusKM: math.Inf(1)},
+		{Lat: 55.75, Lng: 37.62, RadiusKM: math.NaN()},
+	} {
+		if _, err := uc.FindNearestDrivers(ctx, q); err != ErrInvalidRadius {
+			t.Errorf("radius %v: expected ErrInvalidRadius, got %v", q.RadiusKM, err)
+		}
+	}
+	if _, err := uc.FindNearestDrivers(ctx, domain.NearestQuery{Lat: math.NaN(), Lng: 37.62}); err != ErrInvalidCoordinates {
+		t.Errorf("NaN latitude: expected ErrInvalidCoordinates, got %v", err)
+	}
+	if _, err := uc.FindNearestDrivers(ctx, domain.NearestQuery{Lat: 55.75, Lng: 37.62, RadiusKM: 1e9}); err != nil || store.nearest.RadiusKM != domain.MaxNearestRadiusKM {
+		t.Errorf("a huge radius must be capped: %+v, %v", store.nearest, err)
+	}
+}
+
+func TestLocationUseCase_FindNearestDrivers_FiltersByAttributes(t *testing.T) {
+	store := newFakeGeoStore()
+	uc := NewLocationUseCase(store)
+	ctx := context.Background()
+	for _, id := range []string{"economy", "comfort", "comfort-kids", "business-kids", "unsynced"} {
+		store.found = append(store.found, domain.DriverLocation{DriverID: id})
+	}
+	for _, a := range []domain.DriverAttributes{
+		{DriverID: "economy", VehicleClass: domain.VehicleClassEconomy, Features: []string{"child_seat"}},
+		{DriverID: "comfort", VehicleClass: domain.VehicleClassComfort},
+		{DriverID: "comfort-kids", VehicleClass: domain.VehicleClassComfort, Features: []string{" Child_Seat ", "pet_friendly"}},
+		{DriverID: "business-kids", VehicleClass: domain.VehicleClassBusiness, Features: []string{"child_seat"}},
+	} {
+		if _, err := uc.SetDriverAttributes(ctx, a); err != nil {
+			t.Fatalf("SetDriverAttributes(%s): %v", a.DriverID, err)
+		}
+	}
+
+	got, err := uc.FindNearestDrivers(ctx, domain.NearestQuery{Lat: 55.75, Lng: 37.62, Limit: 2, Class: "comfort", Features: []string{"child_seat"}})
+	if err != nil {
+		t.Fatalf("FindNearestDrivers: %v", err)
+	}
+	if len(got) != 2 || got[0].DriverID != "comfort-kids" || got[1].DriverID != "business-kids" {
+		t.Fatalf("expected comfort-kids, business-kids; got %+v", got)
+	}
+	if got[0].Attributes == nil || got[0].Attributes.VehicleClass != domain.VehicleClassComfort {
+		t.Errorf("attributes not attached: %+v", got[0])
+	}
+	if store.nearest.Limit <= 2 {
+		t.Errorf("filtered search should overfetch candidates, fetched %d", store.nearest.Limit)
+	}
+
+	all, _ := uc.FindNearestDrivers(ctx, domain.NearestQuery{Lat: 55.75, Lng: 37.62, Limit: 10})
+	if len(all) != 5 {
+		t.Errorf("unfiltered search must include unsynced drivers, got %d", len(all))
+	}
+
+	if _, err := uc.FindNearestDrivers(ctx, domain.NearestQuery{Lat: 55.75, Lng: 37.62, Features: []string{"jacuzzi"}}); err != ErrInvalidAttributes {
+		t.Errorf("expected ErrInvalidAttributes for unknown feature, got %v", err)
+	}
+	if _, err := uc.SetDriverAttributes(ctx, domain.DriverAttributes{DriverID: "d1", VehicleClass: "limo"}); err != ErrInvalidAttributes {
+		t.Errorf("expected ErrInvalidAttributes for unknown class, got %v", err)
+	}
+
+	// cargo vans serve cargo requests only, and only cargo vans serve them
+	store.found = append(store.found, domain.DriverLocation{DriverID: "van"})
+	if _, err := uc.SetDriverAttributes(ctx, domain.DriverAttributes{DriverID: "van", VehicleClass: domain.VehicleClassCargo}); err != nil {
+		t.Fatalf("SetDriverAttributes(van): %v", err)
+	}
+	vans, _ := uc.FindNearestDrivers(ctx, domain.NearestQuery{Lat: 55.75, Lng: 37.62, Limit: 10, Class: domain.VehicleClassCargo})
+	if len(vans) != 1 || vans[0].DriverID != "van" {
+		t.Errorf("cargo search: expected only the van, got %+v", vans)
+	}
+	economy, _ := uc.FindNearestDrivers(ctx, domain.NearestQuery{Lat: 55.75, Lng: 37.62, Limit: 10, Class: domain.VehicleClassEconomy})
+	for _, d := range economy {
+		if d.DriverID == "van" {
+			t.Errorf("cargo van must not serve economy requests")
+		}
+	}
+}
+
+func fixAt(driverID string, lat float64, ts time.Time) domain.LocationFix {
+	return domain.LocationFix{DriverID: driverID, Location: domain.Location{Lat: lat, Lng: 37.6, Heading: 90, Speed: 12}, Timestamp: ts}
+}
+
+func TestLocationUseCase_IngestFixes_KeepsNewestPerDriver(t *testing.T) {
+	store := newFakeGeoStore()
+	uc := NewLocationUseCase(store)
+	base := time.Now().Add(-time.Minute)
+
+	res, err := uc.IngestFixes(context.Background(), []domain.LocationFix{
+		fixAt("d1", 55.70, base),
+		fixAt("d1", 55.72, base.Add(2*time.Second)),
+		fixAt("d1", 55.71, base.Add(time.Second)), // arrived late within the batch
+		fixAt("d2", 55.80, base),
+		fixAt("d2", 95, base.Add(time.Second)), // invalid
+	})
+	if err != nil {
+		t.Fatalf("IngestFixes: %v", err)
+	}
+	if res.Accepted != 2 || res.Dropped != 3 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if len(store.writes) != 1 {
+		t.Fatalf("want one pipelined write, got %d", len(store.writes))
+	}
+	if got := store.fixes["d1"].Location.Lat; got != 55.72 {
+		t.Errorf("d1 stored lat %v, want newest 55.72", got)
+	}
+}
+
+func TestLocationUseCase_IngestFixes_DropsOutOfOrder(t *testing.T) {
+	store := newFakeGeoStore()
+	uc := NewLocationUseCase(store)
+	base := time.Now().Add(-time.Minute)
+	ctx := context.Background()
+
+	if _, err := uc.IngestFixes(ctx, []domain.LocationFix{fixAt("d1", 55.72, base.Add(5*time.Second))}); err != nil {
+		t.Fatal(err)
+	}
+	// delayed batch from a flaky connection — older than what is stored
+	res, err := uc.IngestFixes(ctx, []domain.LocationFix{fixAt("d1", 55.70, base)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Accepted != 0 || res.Dropped != 1 || len(store.writes) != 1 {
+		t.Errorf("stale fix not dropped: %+v, writes=%d", res, len(store.writes))
+	}
+	if store.fixes["d1"].Location.Lat != 55.72 {
+		t.Errorf("stale fix overwrote position")
+	}
+}
+
+func TestLocationUseCase_IngestFixes_ClampsFutureTimestamp(t *testing.T) {
+	store := newFakeGeoStore()
+	now := time.Now()
+	uc := &LocationUseCase{store: store, now: func() time.Time { return now }}
+
+	if _, err := uc.IngestFixes(context.Background(), []domain.LocationFix{fixAt("d1", 55.7, now.Add(time.Hour))}); err != nil {
+		t.Fatal(err)
+	}
+	if ts := store.fixes["d1"].Timestamp; !ts.Equal(now) {
+		t.Errorf("future timestamp not clamped: %v", ts)
+	}
+}
+
+func TestLocationUseCase_IngestFixes_BatchTooLarge(t *testing.T) {
+	uc := NewLocationUseCase(newFakeGeoStore())
+	if _, err := uc.IngestFixes(context.Background(), make([]domain.LocationFix, MaxBatchSize+1)); err != ErrBatchTooLarge {
+		t.Errorf("want ErrBatchTooLarge, got %v", err)
+	}
+}
+
+func TestLocationUseCase_DriverLocations_SkipsStale(t *testing.T) {
+	store := newFakeGeoStore()
+	now := time.Now()
+	store.fixes["d1"] = fixAt("d1", 55.7, now.Add(-time.Minute))
+	store.fixes["d2"] = fixAt("d2", 55.8, now.Add(-LocationMaxAge-time.Second))
+	uc := &LocationUseCase{store: store, now: func() time.Time { return now }}
+
+	got, err := uc.DriverLocations(context.Background(), []string{"d1", "d2", "d3", "d1", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].DriverID != "d1" || got[0].Location.Lat != 55.7 {
+		t.Errorf("want only fresh d1, got %+v", got)
+	}
+}
+
+func TestLocationUseCase_DriverLocations_TooMany(t *testing.T) {
+	uc := NewLocationUseCase(newFakeGeoStore())
+	ids := make([]string, MaxLocationsBatch+1)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("d%d", i)
+	}
+	if _, err := uc.DriverLocations(context.Background(), ids); err != ErrTooManyDrivers {
+		t.Errorf("want ErrTooManyDrivers, got %v", err)
 	}
-	// Defaults applied inside FindNearestDrivers; with nil store we'd panic - skip full test
-	_ = q
 }
@@ -1,5 +1,5 @@
 // Package main — RideHail Geolocation Service (2026)
-// Driver tracking (Redis GEO), nearest search, WebSocket stub
+// Driver tracking (Redis GEO), nearest search, WebSocket streams (locations in, ride chat out)
 package main
 
 import (
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -19,6 +21,8 @@ import (
 
 	httphandler "github.com/ridehail/geolocation/internal/delivery/http"
 	"github.com/ridehail/geolocation/internal/delivery/ws"
+	"github.com/ridehail/geolocation/internal/infra/jwt"
+	"github.com/ridehail/geolocation/internal/infra/kafka"
 	"github.com/ridehail/geolocation/internal/infra/redis"
 	"github.com/ridehail/geolocation/internal/usecase"
 )
@@ -31,7 +35,10 @@ func main() {
 
 	port := getEnv("PORT", "8082")
 	redisAddr := getEnv("REDIS_ADDR", "localhost:6379")
+	jwtSecret := getEnv("JWT_SECRET", "dev-secret-change-in-production")
 	otlpEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	kafkaBrokers := getEnv("KAFKA_BROKERS", "") // ride chat relay to driver WebSockets
+	shardPrecision, _ := strconv.Atoi(getEnv("GEO_SHARD_PRECISION", "4"))
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -63,11 +70,33 @@ func main() {
 	log.Info("redis ready")
 
 	// Initialize use cases
-	geoStore := redis.NewGeoStore(rdb)
+	geoStore := redis.NewGeoStore(rdb, shardPrecision)
 	locUC := usecase.NewLocationUseCase(geoStore)
+	jwtValidator := jwt.NewValidator(jwtSecret)
 	hub := ws.NewHub()
 	go hub.Run()
 
+	// Ride chat relay: chat events from ride.events go to the recipient driver's location stream
+	driverConns := ws.NewDriverConns()
+	if kafkaBrokers != "" {
+		brokers := strings.Split(kafkaBrokers, ",")
+		for i := range brokers {
+			brokers[i] = strings.TrimSpace(brokers[i])
+		}
+		consumeCtx, stopConsume := context.WithCancel(context.Background())
+		defer stopConsume()
+		rc, err := kafka.NewChatRelayConsumer(brokers, driverConns)
+		if err == nil {
+			err = rc.Run(consumeCtx)
+			defer rc.Close()
+		}
+		if err != nil {
+			log.Warn("ride chat relay unavailable", "error", err)
+		} else {
+			log.Info("ride chat relay ready")
+		}
+	}
+
 	// Setup Echo
 	e := echo.New()
 	e.HideBanner = true
@@ -79,8 +108,13 @@ func main() {
 	e.GET("/health", httphandler.Health)
 	e.GET("/metrics", echo.WrapHandler(m.Handler()))
 	e.POST("/api/v1/drivers/:id/location", httphandler.UpdateDriverLocation(locUC))
+	e.POST("/api/v1/drivers/:id/locations", httphandler.UpdateDriverLocations(locUC))
 	e.GET("/api/v1/drivers/nearest", httphandler.NearestDrivers(locUC))
+	e.GET("/api/v1/drivers/locations", httphandler.DriverLocations(locUC), httphandler.JWTAuth(jwtValidator))
+	e.PUT("/api/v1/drivers/:id/attributes", httphandler.SetDriverAttributes(locUC), httphandler.JWTAuth(jwtValidator))
+	e.GET("/api/v1/drivers/:id/attributes", httphandler.GetDriverAttributes(locUC))
 	e.GET("/ws/tracking", ws.HandleTracking(hub))
+	e.GET("/ws/drivers/:id/locations", ws.HandleDriverLocations(locUC, driverConns, jwtValidator))
 
 	// Start server
 	go func() {
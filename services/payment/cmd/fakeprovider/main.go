@@ -0,0 +1,66 @@
+// Command fakeprovider runs the fake Tinkoff, YooMoney and Sber APIs for local end-to-end
+// testing of the payment service. Point the service at it with TINKOFF_API_URL,
+// YOOMONEY_API_URL and SBER_API_URL; credentials are read from the same variables as
+// the service's, so both can share one environment.
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ridehail/payment/internal/infra/gateway/fakeprovider"
+)
+
+func main() {
+	port := getEnv("FAKE_PROVIDER_PORT", "8090")
+	baseURL := getEnv("FAKE_PROVIDER_BASE_URL", "http://localhost:"+port)
+	webhooks := getEnv("PAYMENT_WEBHOOK_URL", "http://localhost:8084/webhooks")
+
+	fake := fakeprovider.New(fakeprovider.Config{
+		BaseURL:               baseURL,
+		TinkoffTerminalKey:    os.Getenv("TINKOFF_TERMINAL_KEY"),
+		TinkoffPassword:       os.Getenv("TINKOFF_PASSWORD"),
+		TinkoffNotifyURL:      webhooks + "/tinkoff",
+		YooMoneyShopID:        os.Getenv("YOOMONEY_SHOP_ID"),
+		YooMoneySecretKey:     os.Getenv("YOOMONEY_SECRET_KEY"),
+		YooMoneyWebhookSecret: os.Getenv("YOOMONEY_WEBHOOK_SECRET"),
+		YooMoneyWebhookURL:    webhooks + "/yoomoney",
+		SberUserName:          os.Getenv("SBER_USERNAME"),
+		SberPassword:          os.Getenv("SBER_PASSWORD"),
+		SberToken:             os.Getenv("SBER_TOKEN"),
+		SberCallbackURL:       webhooks + "/sber",
+	})
+	defer fake.Close()
+
+	srv := &http.Server{Addr: ":" + port, Handler: fake}
+	go func() {
+		slog.Info("fake provider listening", "port", port, "tinkoff", fake.TinkoffURL(),
+			"yoomoney", fake.YooMoneyURL(), "sber", fake.SberURL())
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Error("shutdown", "error", err)
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,260 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/usecase"
+)
+
+// EarningsHandler handles driver earnings, payouts and commission endpoints
+type EarningsHandler struct {
+	uc *usecase.EarningsUseCase
+}
+
+// NewEarningsHandler creates a new earnings handler
+func NewEarningsHandler(uc *usecase.EarningsUseCase) *EarningsHandler {
+	return &EarningsHandler{uc: uc}
+}
+
+// driverID returns the caller when they are a driver
+func driverID(c echo.Context) (string, bool) {
+	userID, _ := c.Get(UserIDKey).(string)
+	role, _ := c.Get(UserRoleKey).(string)
+	return userID, userID != "" && role == "driver"
+}
+
+func pageParams(c echo.Context) (limit, offset int) {
+	limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	offset, _ = strconv.Atoi(c.QueryParam("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// ============ Driver endpoints ============
+
+// Earnings handles GET /api/v1/driver/earnings — balance and ride history
+func (h *EarningsHandler) Earnings(c echo.Context) error {
+	id, ok := driverID(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, errorResponse("driver access required"))
+	}
+	limit, offset := pageParams(c)
+	summary, err := h.uc.Summary(c.Request().Context(), id, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, summary)
+}
+
+// Payouts handles GET /api/v1/driver/payouts
+func (h *EarningsHandler) Payouts(c echo.Context) error {
+	id, ok := driverID(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, errorResponse("driver access required"))
+	}
+	limit, offset := pageParams(c)
+	payouts, err := h.uc.ListPayouts(c.Request().Context(), id, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"payouts": payouts})
+}
+
+// Statement handles GET /api/v1/driver/payouts/:id — the payout with the rides of its week
+func (h *EarningsHandler) Statement(c echo.Context) error {
+	id, ok := driverID(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, errorResponse("driver access required"))
+	}
+	st, err := h.uc.Statement(c.Request().Context(), id, c.Param("id"))
+	if errors.Is(err, domain.ErrPayoutNotFound) {
+		return c.JSON(http.StatusNotFound, errorResponse(err.Error()))
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, st)
+}
+
+// GetPayoutAccount handles GET /api/v1/driver/payout-account
+func (h *EarningsHandler) GetPayoutAccount(c echo.Context) error {
+	id, ok := driverID(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, errorResponse("driver access required"))
+	}
+	p, err := h.uc.Profile(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// PayoutAccountRequest — where the driver's payouts go
+type PayoutAccountRequest struct {
+	Provider    string `json:"provider"`    // yoomoney | stub
+	Destination string `json:"destination"` // payout token or YooMoney wallet number
+}
+
+// SetPayoutAccount handles PUT /api/v1/driver/payout-account
+func (h *EarningsHandler) SetPayoutAccount(c echo.Context) error {
+	id, ok := driverID(c)
+	if !ok {
+		return c.JSON(http.StatusForbidden, errorResponse("driver access required"))
+	}
+	var req PayoutAccountRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("invalid request"))
+	}
+	p, err := h.uc.SetPayoutDestination(c.Request().Context(), id, req.Provider, req.Destination)
+	if errors.Is(err, domain.ErrInvalidPayoutSetup) {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// ============ Admin endpoints ============
+
+// ListCommissionRules handles GET /api/v1/admin/commission-rules
+func (h *EarningsHandler) ListCommissionRules(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	rules, err := h.uc.ListRules(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"rules": rules})
+}
+
+// CommissionRuleRequest — a commission rule; omitted city, category or tier match any
+type CommissionRuleRequest struct {
+	City     string     `json:"city"`
+	Category string     `json:"category"`
+	Tier     string     `json:"tier"`
+	Rate     money.Rate `json:"rate"` // percent: 12.5
+}
+
+func (req *CommissionRuleRequest) rule() *domain.CommissionRule {
+	return &domain.CommissionRule{City: req.City, Category: req.Category, Tier: req.Tier, Rate: req.Rate}
+}
+
+// CreateCommissionRule handles POST /api/v1/admin/commission-rules
+func (h *EarningsHandler) CreateCommissionRule(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	var req CommissionRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("invalid request"))
+	}
+	rule := req.rule()
+	if err := h.uc.CreateRule(c.Request().Context(), rule); err != nil {
+		return ruleError(c, err)
+	}
+	return c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateCommissionRule handles PUT /api/v1/admin/commission-rules/:id
+func (h *EarningsHandler) UpdateCommissionRule(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	var req CommissionRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("invalid request"))
+	}
+	rule := req.rule()
+	rule.ID = c.Param("id")
+	if err := h.uc.UpdateRule(c.Request().Context(), rule); err != nil {
+		return ruleError(c, err)
+	}
+	return c.JSON(http.StatusOK, rule)
+}
+
+// DeleteCommissionRule handles DELETE /api/v1/admin/commission-rules/:id
+func (h *EarningsHandler) DeleteCommissionRule(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	if err := h.uc.DeleteRule(c.Request().Context(), c.Param("id")); err != nil {
+		return ruleError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func ruleError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidCommission):
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	case errors.Is(err, domain.ErrRuleNotFound):
+		return c.JSON(http.StatusNotFound, errorResponse(err.Error()))
+	case errors.Is(err, domain.ErrRuleExists):
+		return c.JSON(http.StatusConflict, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+}
+
+// DriverProfileRequest — city and tier of a driver
+type DriverProfileRequest struct {
+	City string `json:"city"`
+	Tier string `json:"tier"` // default standard
+}
+
+// SetDriverProfile handles PUT /api/v1/admin/drivers/:id/profile
+func (h *EarningsHandler) SetDriverProfile(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	var req DriverProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("invalid request"))
+	}
+	p, err := h.uc.SetDriverProfile(c.Request().Context(), c.Param("id"), req.City, req.Tier)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, p)
+}
+
+// ListPayoutBatches handles GET /api/v1/admin/payouts/batches
+func (h *EarningsHandler) ListPayoutBatches(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	limit, offset := pageParams(c)
+	batches, err := h.uc.ListBatches(c.Request().Context(), limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"batches": batches})
+}
+
+// RunPayouts handles POST /api/v1/admin/payouts/run — what the weekly scheduler does
+func (h *EarningsHandler) RunPayouts(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	batch, err := h.uc.RunPayouts(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"batch": batch})
+}
@@ -0,0 +1,61 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/usecase"
+)
+
+// LedgerHandler handles admin ledger endpoints
+type LedgerHandler struct {
+	uc *usecase.LedgerUseCase
+}
+
+// NewLedgerHandler creates a new ledger handler
+func NewLedgerHandler(uc *usecase.LedgerUseCase) *LedgerHandler {
+	return &LedgerHandler{uc: uc}
+}
+
+// Balances handles GET /api/v1/admin/ledger/balances?prefix=
+func (h *LedgerHandler) Balances(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+
+	tb, err := h.uc.Balances(c.Request().Context(), c.QueryParam("prefix"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, tb)
+}
+
+// Entries handles GET /api/v1/admin/ledger/entries?account=&reference=&kind=&limit=&offset=
+func (h *LedgerHandler) Entries(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+
+	f := domain.LedgerFilter{
+		Account:   c.QueryParam("account"),
+		Reference: c.QueryParam("reference"),
+		Kind:      c.QueryParam("kind"),
+	}
+	f.Limit, _ = strconv.Atoi(c.QueryParam("limit"))
+	f.Offset, _ = strconv.Atoi(c.QueryParam("offset"))
+
+	entries, err := h.uc.Entries(c.Request().Context(), f)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"entries": entries,
+		"limit":   f.Limit,
+		"offset":  f.Offset,
+	})
+}
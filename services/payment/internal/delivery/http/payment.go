@@ -2,11 +2,12 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"io"
 	"net/http"
 	"strconv"
 
+	"github.com/alexevil1979/indrive/packages/money-go"
 	"github.com/labstack/echo/v4"
 
 	"github.com/ridehail/payment/internal/domain"
@@ -17,11 +18,12 @@ import (
 type PaymentUseCase interface {
 	CreatePayment(ctx context.Context, input usecase.CreatePaymentInput) (*domain.PaymentIntent, error)
 	GetByRideID(ctx context.Context, rideID string) (*domain.Payment, error)
+	ListByTrip(ctx context.Context, tripID string) ([]*domain.Payment, error)
 	GetByID(ctx context.Context, id string) (*domain.Payment, error)
 	ListByUser(ctx context.Context, userID string, limit, offset int) ([]*domain.Payment, error)
-	ConfirmPayment(ctx context.Context, id string) (*domain.Payment, error)
-	ProcessWebhook(ctx context.Context, provider string, body []byte, signature string) error
+	ConfirmPayment(ctx context.Context, id, userID, role string) (*domain.Payment, error)
 	RefundPayment(ctx context.Context, req domain.RefundRequest) (*domain.RefundResult, error)
+	ListRefunds(ctx context.Context, paymentID string) ([]*domain.Refund, error)
 	ListPaymentMethods(ctx context.Context, userID string) ([]*domain.PaymentMethod, error)
 	DeletePaymentMethod(ctx context.Context, id, userID string) error
 	SetDefaultPaymentMethod(ctx context.Context, id, userID string) error
@@ -30,10 +32,13 @@ type PaymentUseCase interface {
 
 // CreatePaymentRequest — POST /api/v1/payments (checkout)
 type CreatePaymentRequest struct {
-	RideID      string  `json:"ride_id"`
-	Amount      float64 `json:"amount"`
-	Method      string  `json:"method"`      // cash | card
-	Provider    string  `json:"provider"`    // cash | tinkoff | yoomoney | sber
+	RideID      string  `json:"ride_id"`    // ride, or shared trip id with booking_id
+	BookingID   string  `json:"booking_id"` // shared trip seat booking
+	Amount      json.Number `json:"amount"`   // major units, at most the currency's decimals: 199.99
+	Currency    string  `json:"currency"`    // default RUB
+	Method      string  `json:"method"`      // cash | card | wallet
+	Provider    string  `json:"provider"`    // cash | tinkoff | yoomoney | sber | wallet; card: omit to route
+	CardBrand   string  `json:"card_brand"`  // card: visa | mastercard | mir, when the app knows it
 	Description string  `json:"description"`
 	ReturnURL   string  `json:"return_url"`
 	SaveCard    bool    `json:"save_card"`
@@ -57,13 +62,22 @@ func CreatePayment(uc PaymentUseCase) echo.HandlerFunc {
 		if req.Method == "" {
 			req.Method = domain.MethodCash
 		}
+		if req.Currency == "" {
+			req.Currency = money.RUB
+		}
+		amount, err := parseAmount(req.Amount, req.Currency)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "amount: " + err.Error()})
+		}
 
 		input := usecase.CreatePaymentInput{
 			RideID:      req.RideID,
+			BookingID:   req.BookingID,
 			UserID:      userID,
-			Amount:      req.Amount,
+			Amount:      amount,
 			Method:      req.Method,
 			Provider:    req.Provider,
+			CardBrand:   req.CardBrand,
 			Description: req.Description,
 			ReturnURL:   req.ReturnURL,
 			SaveCard:    req.SaveCard,
@@ -80,36 +94,68 @@ func CreatePayment(uc PaymentUseCase) echo.HandlerFunc {
 			if errors.Is(err, domain.ErrPaymentExists) {
 				return c.JSON(http.StatusConflict, map[string]string{"error": "payment already exists for this ride"})
 			}
+			if errors.Is(err, domain.ErrInsufficientFunds) || errors.Is(err, domain.ErrWalletCurrency) {
+				return c.JSON(http.StatusPaymentRequired, map[string]string{"error": err.Error()})
+			}
+			if errors.Is(err, domain.ErrNoCardProvider) || errors.Is(err, domain.ErrProviderError) {
+				return c.JSON(http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+			}
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
 		return c.JSON(http.StatusCreated, intent)
 	}
 }
 
-// GetPayment returns payment by ID
+// GetPayment returns payment by ID; to the payer and staff only
 func GetPayment(uc PaymentUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		id := c.Param("id")
 		p, err := uc.GetByID(c.Request().Context(), id)
-		if err != nil || p == nil {
+		if err != nil || p == nil || !canViewPayment(c, p) {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": "payment not found"})
 		}
 		return c.JSON(http.StatusOK, p)
 	}
 }
 
-// GetPaymentByRide returns payment by ride ID
+// canViewPayment — the payer, admins and support see a payment; to anyone else it
+// does not exist
+func canViewPayment(c echo.Context, p *domain.Payment) bool {
+	userID, _ := c.Get(UserIDKey).(string)
+	role, _ := c.Get(UserRoleKey).(string)
+	return p.UserID == userID || role == "admin" || role == "support"
+}
+
+// GetPaymentByRide returns payment by ride ID; to the payer and staff only
 func GetPaymentByRide(uc PaymentUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		rideID := c.Param("rideId")
 		p, err := uc.GetByRideID(c.Request().Context(), rideID)
-		if err != nil || p == nil {
+		if err != nil || p == nil || !canViewPayment(c, p) {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": "payment not found"})
 		}
 		return c.JSON(http.StatusOK, p)
 	}
 }
 
+// ListTripPayments returns per-passenger payments of a shared trip: the caller's own
+// bookings, every booking to admins and support
+func ListTripPayments(uc PaymentUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		all, err := uc.ListByTrip(c.Request().Context(), c.Param("tripId"))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list payments"})
+		}
+		payments := make([]*domain.Payment, 0, len(all))
+		for _, p := range all {
+			if canViewPayment(c, p) {
+				payments = append(payments, p)
+			}
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"payments": payments})
+	}
+}
+
 // ListPayments returns user's payments
 func ListPayments(uc PaymentUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
@@ -125,15 +171,23 @@ func ListPayments(uc PaymentUseCase) echo.HandlerFunc {
 	}
 }
 
-// ConfirmPayment confirms cash payment
+// ConfirmPayment confirms cash payment; the ride's driver or staff only
 func ConfirmPayment(uc PaymentUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		id := c.Param("id")
-		p, err := uc.ConfirmPayment(c.Request().Context(), id)
+		userID, _ := c.Get(UserIDKey).(string)
+		role, _ := c.Get(UserRoleKey).(string)
+		p, err := uc.ConfirmPayment(c.Request().Context(), id, userID, role)
 		if err != nil {
 			if errors.Is(err, domain.ErrPaymentNotFound) {
 				return c.JSON(http.StatusNotFound, map[string]string{"error": "payment not found"})
 			}
+			if errors.Is(err, domain.ErrConfirmNotAllowed) {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+			if errors.Is(err, domain.ErrNotRideDriver) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+			}
 			if errors.Is(err, domain.ErrPaymentNotPending) {
 				return c.JSON(http.StatusBadRequest, map[string]string{"error": "payment is not pending"})
 			}
@@ -143,68 +197,84 @@ func ConfirmPayment(uc PaymentUseCase) echo.HandlerFunc {
 	}
 }
 
-// RefundRequest — POST /api/v1/payments/:id/refund
+// RefundRequest — POST /api/v1/payments/:id/refund (admin, support)
 type RefundRequest struct {
-	Amount float64 `json:"amount"` // 0 = full refund
-	Reason string  `json:"reason"`
+	Amount       json.Number `json:"amount"` // omitted or 0 = what is left to refund
+	Reason       string      `json:"reason"`
+	ToWallet     bool        `json:"to_wallet"`    // credit the payer's wallet instead of the card
+	Compensation bool        `json:"compensation"` // admins: a cash payment credited to the wallet
 }
 
-// RefundPayment processes a refund
+// RefundPayment processes a refund; staff only
 func RefundPayment(uc PaymentUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		id := c.Param("id")
+		userID, _ := c.Get(UserIDKey).(string)
+		role, _ := c.Get(UserRoleKey).(string)
+		if role != "admin" && role != "support" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin or support access required"})
+		}
 
 		var req RefundRequest
 		if err := c.Bind(&req); err != nil {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
 		}
+		if req.Compensation && role != "admin" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin access required"})
+		}
+		amount, err := parseAmount(req.Amount, "") // in the payment's currency
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "amount: " + err.Error()})
+		}
 
 		result, err := uc.RefundPayment(c.Request().Context(), domain.RefundRequest{
 			PaymentID: id,
-			Amount:    req.Amount,
-			Reason:    req.Reason,
+			Amount:    amount,
+			Reason:       req.Reason,
+			ToWallet:     req.ToWallet,
+			Compensation: req.Compensation,
+			RequestedBy:  userID,
 		})
 		if err != nil {
 			if errors.Is(err, domain.ErrPaymentNotFound) {
 				return c.JSON(http.StatusNotFound, map[string]string{"error": "payment not found"})
 			}
+			if errors.Is(err, domain.ErrOwnPaymentRefund) {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+			}
 			if errors.Is(err, domain.ErrRefundNotAllowed) {
 				return c.JSON(http.StatusBadRequest, map[string]string{"error": "refund not allowed"})
 			}
+			if errors.Is(err, domain.ErrInvalidAmount) {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "refund amount must be positive"})
+			}
+			if errors.Is(err, domain.ErrRefundExceedsBalance) {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "refund amount exceeds the refundable balance"})
+			}
+			if errors.Is(err, domain.ErrInsufficientFunds) {
+				return c.JSON(http.StatusConflict, map[string]string{"error": "top-up already spent from the wallet"})
+			}
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
 		return c.JSON(http.StatusOK, result)
 	}
 }
 
-// --- Webhooks ---
-
-// Webhook handles payment provider callbacks
-func Webhook(uc PaymentUseCase, provider string) echo.HandlerFunc {
+// ListRefunds returns the refunds of a payment; to the payer and staff only
+func ListRefunds(uc PaymentUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		body, err := io.ReadAll(c.Request().Body)
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"error": "failed to read body"})
-		}
-
-		// Get signature header (varies by provider)
-		signature := ""
-		switch provider {
-		case domain.ProviderTinkoff:
-			// Tinkoff uses Token in body
-		case domain.ProviderYooMoney:
-			signature = c.Request().Header.Get("X-YooKassa-Signature")
-		case domain.ProviderSber:
-			signature = c.Request().Header.Get("X-Signature")
+		p, err := uc.GetByID(c.Request().Context(), c.Param("id"))
+		if err != nil || p == nil || !canViewPayment(c, p) {
+			return c.JSON(http.StatusNotFound, map[string]string{"error": "payment not found"})
 		}
-
-		if err := uc.ProcessWebhook(c.Request().Context(), provider, body, signature); err != nil {
-			// Log error but return OK to prevent retries
-			c.Logger().Error("webhook processing error", "provider", provider, "error", err)
+		refunds, err := uc.ListRefunds(c.Request().Context(), p.ID)
+		if err != nil {
+			if errors.Is(err, domain.ErrPaymentNotFound) {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "payment not found"})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list refunds"})
 		}
-
-		// Always return OK for webhooks
-		return c.String(http.StatusOK, "OK")
+		return c.JSON(http.StatusOK, refunds)
 	}
 }
 
@@ -255,3 +325,12 @@ func GetProviders(uc PaymentUseCase) echo.HandlerFunc {
 		return c.JSON(http.StatusOK, map[string][]string{"providers": providers})
 	}
 }
+
+// parseAmount reads a request amount exactly: a decimal in major units with at most the
+// currency's decimals (two when the currency is left to the use case); empty is zero
+func parseAmount(n json.Number, currency string) (money.Money, error) {
+	if n == "" {
+		return money.New(0, currency), nil
+	}
+	return money.Parse(n.String(), currency)
+}
@@ -0,0 +1,189 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+	"github.com/ridehail/payment/internal/usecase"
+)
+
+// paymentStore — payments and ride drivers in memory
+type paymentStore struct {
+	usecase.PaymentRepository
+	payments map[string]*domain.Payment
+	drivers  map[string]string // ride → driver, for completed rides
+	entries  []*domain.JournalEntry
+}
+
+func (s *paymentStore) GetByID(ctx context.Context, id string) (*domain.Payment, error) {
+	return s.payments[id], nil
+}
+
+func (s *paymentStore) GetByRideID(ctx context.Context, rideID string) (*domain.Payment, error) {
+	for _, p := range s.payments {
+		if p.RideID == rideID && p.BookingID == "" {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *paymentStore) ListRefunds(ctx context.Context, paymentID string) ([]*domain.Refund, error) {
+	return nil, nil
+}
+
+func (s *paymentStore) ListByTrip(ctx context.Context, tripID string) ([]*domain.Payment, error) {
+	var out []*domain.Payment
+	for _, p := range s.payments {
+		if p.RideID == tripID && p.BookingID != "" {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (s *paymentStore) GetRideDriver(ctx context.Context, rideID string) (string, error) {
+	return s.drivers[rideID], nil
+}
+
+func (s *paymentStore) UpdatePayment(ctx context.Context, p *domain.Payment, entries ...*domain.JournalEntry) error {
+	s.entries = append(s.entries, entries...)
+	return nil
+}
+
+func newPaymentStore() *paymentStore {
+	rub := func(minor int64) money.Money { return money.New(minor, money.RUB) }
+	pending := func(id, rideID, purpose, method, provider string) *domain.Payment {
+		return &domain.Payment{ID: id, RideID: rideID, Purpose: purpose, UserID: "p1", Method: method, Provider: provider,
+			Status: domain.PaymentStatusPending, Amount: rub(50000), Currency: money.RUB, Refunded: rub(0)}
+	}
+	held := pending("hold", "r3", domain.PurposeRide, domain.MethodCard, domain.ProviderTinkoff)
+	held.Authorized = &held.Amount
+	return &paymentStore{
+		payments: map[string]*domain.Payment{
+			"cash":   pending("cash", "r1", domain.PurposeRide, domain.MethodCash, domain.ProviderCash),
+			"card":   pending("card", "r2", domain.PurposeRide, domain.MethodCard, domain.ProviderTinkoff),
+			"hold":   held,
+			"wallet": pending("wallet", "r4", domain.PurposeRide, domain.MethodWallet, domain.ProviderWallet),
+			"topup":  pending("topup", "", domain.PurposeWalletTopUp, domain.MethodCard, domain.ProviderYooMoney),
+		},
+		drivers: map[string]string{"r1": "d1", "r2": "d1", "r3": "d1", "r4": "d1"},
+	}
+}
+
+// serve runs h as userID with role; params are path parameter name/value pairs
+func serve(h echo.HandlerFunc, userID, role string, params ...string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+	var names, values []string
+	for i := 0; i+1 < len(params); i += 2 {
+		names, values = append(names, params[i]), append(values, params[i+1])
+	}
+	c.SetParamNames(names...)
+	c.SetParamValues(values...)
+	c.Set(UserIDKey, userID)
+	c.Set(UserRoleKey, role)
+	_ = h(c)
+	return rec
+}
+
+func TestConfirmPayment(t *testing.T) {
+	store := newPaymentStore()
+	h := ConfirmPayment(usecase.NewPaymentUseCase(store, gateway.NewManager(), nil, nil, nil))
+
+	cases := []struct {
+		name, id, userID, role string
+		status                 int
+	}{
+		{"card ride payment", "card", "d1", "driver", http.StatusBadRequest},
+		{"3DS-pending hold", "hold", "a1", "admin", http.StatusBadRequest},
+		{"wallet payment", "wallet", "d1", "driver", http.StatusBadRequest},
+		{"wallet top-up by its payer", "topup", "p1", "passenger", http.StatusBadRequest},
+		{"cash by the passenger", "cash", "p1", "passenger", http.StatusForbidden},
+		{"cash by another driver", "cash", "d2", "driver", http.StatusForbidden},
+		{"cash by the ride's driver", "cash", "d1", "driver", http.StatusOK},
+		{"cash already confirmed, by support", "cash", "s1", "support", http.StatusOK},
+		{"missing", "nope", "a1", "admin", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if rec := serve(h, tc.userID, tc.role, "id", tc.id); rec.Code != tc.status {
+			t.Errorf("%s: status %d, want %d: %s", tc.name, rec.Code, tc.status, rec.Body)
+		}
+	}
+	for id, p := range store.payments {
+		if completed := p.Status == domain.PaymentStatusCompleted; completed != (id == "cash") {
+			t.Errorf("%s: status %s", id, p.Status)
+		}
+	}
+	if len(store.entries) != 1 {
+		t.Errorf("only the cash payment is booked: %d entries", len(store.entries))
+	}
+}
+
+func TestListTripPayments(t *testing.T) {
+	store := newPaymentStore()
+	for _, b := range []struct{ id, userID string }{{"b1", "p1"}, {"b2", "p2"}} {
+		store.payments[b.id] = &domain.Payment{ID: b.id, RideID: "t1", BookingID: b.id, Purpose: domain.PurposeRide, UserID: b.userID,
+			Method: domain.MethodCard, Provider: domain.ProviderTinkoff, Status: domain.PaymentStatusCompleted,
+			Amount: money.New(30000, money.RUB), Currency: money.RUB}
+	}
+	h := ListTripPayments(usecase.NewPaymentUseCase(store, gateway.NewManager(), nil, nil, nil))
+
+	for _, tc := range []struct {
+		userID, role string
+		want         int
+	}{{"p1", "passenger", 1}, {"p3", "passenger", 0}, {"d1", "driver", 0}, {"s1", "support", 2}, {"a1", "admin", 2}} {
+		rec := serve(h, tc.userID, tc.role, "tripId", "t1")
+		var body struct {
+			Payments []*domain.Payment `json:"payments"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || rec.Code != http.StatusOK || len(body.Payments) != tc.want {
+			t.Errorf("%s (%s): %d payments, want %d: %s", tc.userID, tc.role, len(body.Payments), tc.want, rec.Body)
+			continue
+		}
+		for _, p := range body.Payments {
+			if p.UserID != tc.userID && tc.role == "passenger" {
+				t.Errorf("%s sees %s's booking", tc.userID, p.UserID)
+			}
+		}
+	}
+}
+
+func TestPaymentViews_PayerAndStaffOnly(t *testing.T) {
+	uc := usecase.NewPaymentUseCase(newPaymentStore(), gateway.NewManager(), nil, nil, nil)
+	routes := []struct {
+		name  string
+		h     echo.HandlerFunc
+		param string
+		value string
+	}{
+		{"GET /payments/:id", GetPayment(uc), "id", "card"},
+		{"GET /payments/ride/:rideId", GetPaymentByRide(uc), "rideId", "r2"},
+		{"GET /payments/:id/refunds", ListRefunds(uc), "id", "card"},
+	}
+	callers := []struct {
+		userID, role string
+		status       int
+	}{
+		{"p1", "passenger", http.StatusOK},
+		{"a1", "admin", http.StatusOK},
+		{"s1", "support", http.StatusOK},
+		{"p2", "passenger", http.StatusNotFound},
+		{"d1", "driver", http.StatusNotFound},
+	}
+	for _, r := range routes {
+		for _, caller := range callers {
+			if rec := serve(r.h, caller.userID, caller.role, r.param, r.value); rec.Code != caller.status {
+				t.Errorf("%s as %s (%s): status %d, want %d", r.name, caller.userID, caller.role, rec.Code, caller.status)
+			}
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/alexevil1979/indrive/packages/money-go"
 	"github.com/labstack/echo/v4"
 
 	"github.com/ridehail/payment/internal/domain"
@@ -23,8 +26,9 @@ func NewPromoHandler(uc *usecase.PromoUseCase) *PromoHandler {
 
 // ValidatePromoRequest is the request for validating a promo code
 type ValidatePromoRequest struct {
-	Code        string  `json:"code"`
-	OrderAmount float64 `json:"order_amount"`
+	Code        string      `json:"code"`
+	OrderAmount json.Number `json:"order_amount"`
+	Currency    string      `json:"currency"` // default RUB
 }
 
 // ValidatePromo handles POST /api/v1/promos/validate
@@ -39,7 +43,12 @@ func (h *PromoHandler) ValidatePromo(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, errorResponse("invalid request"))
 	}
 
-	result, err := h.uc.ValidatePromo(c.Request().Context(), req.Code, userID, req.OrderAmount)
+	order, err := parseAmount(req.OrderAmount, currencyOrDefault(req.Currency))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("order_amount: "+err.Error()))
+	}
+
+	result, err := h.uc.ValidatePromo(c.Request().Context(), req.Code, userID, order)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
 	}
@@ -49,9 +58,10 @@ func (h *PromoHandler) ValidatePromo(c echo.Context) error {
 
 // ApplyPromoRequest is the request for applying a promo code
 type ApplyPromoRequest struct {
-	Code        string  `json:"code"`
-	RideID      string  `json:"ride_id"`
-	OrderAmount float64 `json:"order_amount"`
+	Code        string      `json:"code"`
+	RideID      string      `json:"ride_id"`
+	OrderAmount json.Number `json:"order_amount"`
+	Currency    string      `json:"currency"` // default RUB
 }
 
 // ApplyPromo handles POST /api/v1/promos/apply
@@ -66,7 +76,12 @@ func (h *PromoHandler) ApplyPromo(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, errorResponse("invalid request"))
 	}
 
-	result, err := h.uc.ApplyPromo(c.Request().Context(), req.Code, userID, req.RideID, req.OrderAmount)
+	order, err := parseAmount(req.OrderAmount, currencyOrDefault(req.Currency))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("order_amount: "+err.Error()))
+	}
+
+	result, err := h.uc.ApplyPromo(c.Request().Context(), req.Code, userID, req.RideID, order)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
 	}
@@ -100,16 +115,17 @@ func (h *PromoHandler) GetMyPromos(c echo.Context) error {
 
 // CreatePromoRequest is the request for creating a promo
 type CreatePromoRequest struct {
-	Code          string  `json:"code"`
-	Description   string  `json:"description"`
-	Type          string  `json:"type"` // percent or fixed
-	Value         float64 `json:"value"`
-	MinOrderValue float64 `json:"min_order_value"`
-	MaxDiscount   float64 `json:"max_discount"`
-	UsageLimit    int     `json:"usage_limit"`
-	PerUserLimit  int     `json:"per_user_limit"`
-	StartsAt      string  `json:"starts_at,omitempty"`  // RFC3339
-	ExpiresAt     string  `json:"expires_at,omitempty"` // RFC3339
+	Code          string      `json:"code"`
+	Description   string      `json:"description"`
+	Type          string      `json:"type"`     // percent or fixed
+	Value         json.Number `json:"value"`    // percent (10.5 = 10.5%) or, for fixed, the amount off
+	Currency      string      `json:"currency"` // default RUB
+	MinOrderValue json.Number `json:"min_order_value"`
+	MaxDiscount   json.Number `json:"max_discount"`
+	UsageLimit    int         `json:"usage_limit"`
+	PerUserLimit  int         `json:"per_user_limit"`
+	StartsAt      string      `json:"starts_at,omitempty"`  // RFC3339
+	ExpiresAt     string      `json:"expires_at,omitempty"` // RFC3339
 }
 
 // CreatePromo handles POST /api/v1/admin/promos
@@ -125,18 +141,19 @@ func (h *PromoHandler) CreatePromo(c echo.Context) error {
 	}
 
 	promo := &domain.Promo{
-		Code:          req.Code,
-		Description:   req.Description,
-		Type:          domain.PromoType(req.Type),
-		Value:         req.Value,
-		MinOrderValue: req.MinOrderValue,
-		MaxDiscount:   req.MaxDiscount,
-		UsageLimit:    req.UsageLimit,
-		PerUserLimit:  req.PerUserLimit,
-		IsActive:      true,
-		StartsAt:      time.Now(),
+		Code:         req.Code,
+		Description:  req.Description,
+		Type:         domain.PromoType(req.Type),
+		Currency:     currencyOrDefault(req.Currency),
+		UsageLimit:   req.UsageLimit,
+		PerUserLimit: req.PerUserLimit,
+		IsActive:     true,
+		StartsAt:     time.Now(),
 	}
 
+	if err := setPromoAmounts(promo, &req.Value, &req.MinOrderValue, &req.MaxDiscount); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
 	if req.StartsAt != "" {
 		if t, err := time.Parse(time.RFC3339, req.StartsAt); err == nil {
 			promo.StartsAt = t
@@ -157,16 +174,16 @@ func (h *PromoHandler) CreatePromo(c echo.Context) error {
 
 // UpdatePromoRequest is the request for updating a promo
 type UpdatePromoRequest struct {
-	Description   *string  `json:"description,omitempty"`
-	Type          *string  `json:"type,omitempty"`
-	Value         *float64 `json:"value,omitempty"`
-	MinOrderValue *float64 `json:"min_order_value,omitempty"`
-	MaxDiscount   *float64 `json:"max_discount,omitempty"`
-	UsageLimit    *int     `json:"usage_limit,omitempty"`
-	PerUserLimit  *int     `json:"per_user_limit,omitempty"`
-	IsActive      *bool    `json:"is_active,omitempty"`
-	StartsAt      *string  `json:"starts_at,omitempty"`
-	ExpiresAt     *string  `json:"expires_at,omitempty"`
+	Description   *string      `json:"description,omitempty"`
+	Type          *string      `json:"type,omitempty"`
+	Value         *json.Number `json:"value,omitempty"`
+	MinOrderValue *json.Number `json:"min_order_value,omitempty"`
+	MaxDiscount   *json.Number `json:"max_discount,omitempty"`
+	UsageLimit    *int         `json:"usage_limit,omitempty"`
+	PerUserLimit  *int         `json:"per_user_limit,omitempty"`
+	IsActive      *bool        `json:"is_active,omitempty"`
+	StartsAt      *string      `json:"starts_at,omitempty"`
+	ExpiresAt     *string      `json:"expires_at,omitempty"`
 }
 
 // UpdatePromo handles PUT /api/v1/admin/promos/:id
@@ -194,14 +211,8 @@ func (h *PromoHandler) UpdatePromo(c echo.Context) error {
 	if req.Type != nil {
 		promo.Type = domain.PromoType(*req.Type)
 	}
-	if req.Value != nil {
-		promo.Value = *req.Value
-	}
-	if req.MinOrderValue != nil {
-		promo.MinOrderValue = *req.MinOrderValue
-	}
-	if req.MaxDiscount != nil {
-		promo.MaxDiscount = *req.MaxDiscount
+	if err := setPromoAmounts(promo, req.Value, req.MinOrderValue, req.MaxDiscount); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
 	}
 	if req.UsageLimit != nil {
 		promo.UsageLimit = *req.UsageLimit
@@ -224,7 +235,7 @@ func (h *PromoHandler) UpdatePromo(c echo.Context) error {
 	}
 
 	if err := h.uc.UpdatePromo(c.Request().Context(), promo); err != nil {
-		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
 	}
 
 	return c.JSON(http.StatusOK, promo)
@@ -308,12 +319,14 @@ func (h *PromoHandler) ListActivePromos(c echo.Context) error {
 
 	// Return only public fields
 	type PublicPromo struct {
-		Code          string  `json:"code"`
-		Description   string  `json:"description"`
-		Type          string  `json:"type"`
-		Value         float64 `json:"value"`
-		MinOrderValue float64 `json:"min_order_value"`
-		MaxDiscount   float64 `json:"max_discount"`
+		Code          string      `json:"code"`
+		Description   string      `json:"description"`
+		Type          string      `json:"type"`
+		Percent       money.Rate  `json:"percent"`
+		AmountOff     money.Money `json:"amount_off"`
+		Currency      string      `json:"currency"`
+		MinOrderValue money.Money `json:"min_order_value"`
+		MaxDiscount   money.Money `json:"max_discount"`
 	}
 
 	public := make([]PublicPromo, 0, len(promos))
@@ -323,7 +336,9 @@ func (h *PromoHandler) ListActivePromos(c echo.Context) error {
 				Code:          p.Code,
 				Description:   p.Description,
 				Type:          string(p.Type),
-				Value:         p.Value,
+				Percent:       p.Percent,
+				AmountOff:     p.AmountOff,
+				Currency:      p.Currency,
 				MinOrderValue: p.MinOrderValue,
 				MaxDiscount:   p.MaxDiscount,
 			})
@@ -332,3 +347,46 @@ func (h *PromoHandler) ListActivePromos(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, map[string]interface{}{"promos": public})
 }
+
+// setPromoAmounts parses the given (non-nil) request amounts into the promo: value is
+// the percent for percent promos and the amount off in the promo currency for fixed ones
+func setPromoAmounts(promo *domain.Promo, value, minOrder, maxDiscount *json.Number) error {
+	if value != nil {
+		switch promo.Type {
+		case domain.PromoTypePercent:
+			r, err := money.ParseRate(value.String())
+			if err != nil {
+				return errors.New("value: percent with at most 2 decimals")
+			}
+			promo.Percent = r
+		case domain.PromoTypeFixed:
+			m, err := parseAmount(*value, promo.Currency)
+			if err != nil {
+				return errors.New("value: " + err.Error())
+			}
+			promo.AmountOff = m
+		}
+	}
+	if minOrder != nil {
+		m, err := parseAmount(*minOrder, promo.Currency)
+		if err != nil {
+			return errors.New("min_order_value: " + err.Error())
+		}
+		promo.MinOrderValue = m
+	}
+	if maxDiscount != nil {
+		m, err := parseAmount(*maxDiscount, promo.Currency)
+		if err != nil {
+			return errors.New("max_discount: " + err.Error())
+		}
+		promo.MaxDiscount = m
+	}
+	return nil
+}
+
+func currencyOrDefault(currency string) string {
+	if currency == "" {
+		return money.RUB
+	}
+	return currency
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/payment/internal/usecase"
+)
+
+// ReconcileHandler handles admin endpoints of the payment status reconciler
+type ReconcileHandler struct {
+	uc *usecase.ReconcileUseCase
+}
+
+// NewReconcileHandler creates a new reconcile handler
+func NewReconcileHandler(uc *usecase.ReconcileUseCase) *ReconcileHandler {
+	return &ReconcileHandler{uc: uc}
+}
+
+// Mismatches handles GET /api/v1/admin/payments/reconciliation?outcome=&limit=&offset=
+func (h *ReconcileHandler) Mismatches(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	limit, offset := pageParams(c)
+	mismatches, err := h.uc.Mismatches(c.Request().Context(), c.QueryParam("outcome"), limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"mismatches": mismatches})
+}
+
+// Run handles POST /api/v1/admin/payments/reconcile — what the background reconciler does
+func (h *ReconcileHandler) Run(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	run, err := h.uc.Run(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,137 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// RoutingUseCase — card routing rules and gateway health
+type RoutingUseCase interface {
+	ListRules(ctx context.Context) ([]*domain.RoutingRule, error)
+	CreateRule(ctx context.Context, rule *domain.RoutingRule) error
+	UpdateRule(ctx context.Context, rule *domain.RoutingRule) error
+	DeleteRule(ctx context.Context, id string) error
+	Health() []domain.GatewayHealth
+}
+
+// RoutingHandler handles admin card routing endpoints
+type RoutingHandler struct {
+	uc RoutingUseCase
+}
+
+// NewRoutingHandler creates routing handler
+func NewRoutingHandler(uc RoutingUseCase) *RoutingHandler {
+	return &RoutingHandler{uc: uc}
+}
+
+// Health handles GET /api/v1/admin/gateways/health
+func (h *RoutingHandler) Health(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"gateways": h.uc.Health()})
+}
+
+// ListRules handles GET /api/v1/admin/routing-rules
+func (h *RoutingHandler) ListRules(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	rules, err := h.uc.ListRules(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"rules": rules})
+}
+
+// RoutingRuleRequest — a routing rule; omitted bounds and brand match any
+type RoutingRuleRequest struct {
+	Name      string       `json:"name"`
+	Priority  int          `json:"priority"`   // lowest is checked first
+	MinAmount *money.Money `json:"min_amount"` // rubles, inclusive
+	MaxAmount *money.Money `json:"max_amount"` // rubles, exclusive
+	CardBrand string       `json:"card_brand"` // visa | mastercard | mir
+	Providers []string     `json:"providers"`  // tinkoff | yoomoney | sber
+	Strategy  string       `json:"strategy"`   // priority (default) | cost | health
+}
+
+func (req *RoutingRuleRequest) rule() *domain.RoutingRule {
+	rule := &domain.RoutingRule{Name: req.Name, Priority: req.Priority, CardBrand: req.CardBrand,
+		Providers: req.Providers, Strategy: req.Strategy}
+	if req.MinAmount != nil {
+		m := req.MinAmount.In(money.RUB)
+		rule.MinAmount = &m
+	}
+	if req.MaxAmount != nil {
+		m := req.MaxAmount.In(money.RUB)
+		rule.MaxAmount = &m
+	}
+	return rule
+}
+
+// CreateRule handles POST /api/v1/admin/routing-rules
+func (h *RoutingHandler) CreateRule(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	var req RoutingRuleRequest
+	if err := c.Bind(&req); err != nil || req.Name == "" {
+		return c.JSON(http.StatusBadRequest, errorResponse("invalid request"))
+	}
+	rule := req.rule()
+	if err := h.uc.CreateRule(c.Request().Context(), rule); err != nil {
+		return routingRuleError(c, err)
+	}
+	return c.JSON(http.StatusCreated, rule)
+}
+
+// UpdateRule handles PUT /api/v1/admin/routing-rules/:id
+func (h *RoutingHandler) UpdateRule(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	var req RoutingRuleRequest
+	if err := c.Bind(&req); err != nil || req.Name == "" {
+		return c.JSON(http.StatusBadRequest, errorResponse("invalid request"))
+	}
+	rule := req.rule()
+	rule.ID = c.Param("id")
+	if err := h.uc.UpdateRule(c.Request().Context(), rule); err != nil {
+		return routingRuleError(c, err)
+	}
+	return c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule handles DELETE /api/v1/admin/routing-rules/:id
+func (h *RoutingHandler) DeleteRule(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	if err := h.uc.DeleteRule(c.Request().Context(), c.Param("id")); err != nil {
+		return routingRuleError(c, err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func routingRuleError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrInvalidRoutingRule):
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	case errors.Is(err, domain.ErrRoutingRuleNotFound):
+		return c.JSON(http.StatusNotFound, errorResponse(err.Error()))
+	case errors.Is(err, domain.ErrRoutingRuleExists):
+		return c.JSON(http.StatusConflict, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+}
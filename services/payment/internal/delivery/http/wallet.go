@@ -0,0 +1,147 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/usecase"
+)
+
+// WalletHandler handles passenger wallet endpoints
+type WalletHandler struct {
+	uc *usecase.WalletUseCase
+}
+
+// NewWalletHandler creates a new wallet handler
+func NewWalletHandler(uc *usecase.WalletUseCase) *WalletHandler {
+	return &WalletHandler{uc: uc}
+}
+
+// GetWallet handles GET /api/v1/wallet
+func (h *WalletHandler) GetWallet(c echo.Context) error {
+	userID, _ := c.Get(UserIDKey).(string)
+	w, err := h.uc.Wallet(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, w)
+}
+
+// TopUpRequest — POST /api/v1/wallet/topups
+type TopUpRequest struct {
+	Amount    json.Number `json:"amount"`   // major units: 500.00
+	Currency  string      `json:"currency"` // default RUB
+	Provider  string      `json:"provider"` // tinkoff | yoomoney | sber
+	ReturnURL string      `json:"return_url"`
+	SaveCard  bool        `json:"save_card"`
+	TokenID   string      `json:"token_id"` // Use saved card
+	UserEmail string      `json:"user_email"`
+	UserPhone string      `json:"user_phone"`
+}
+
+// TopUp handles POST /api/v1/wallet/topups — the wallet is credited once the payment completes
+func (h *WalletHandler) TopUp(c echo.Context) error {
+	userID, _ := c.Get(UserIDKey).(string)
+	var req TopUpRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("invalid request"))
+	}
+	if req.Currency == "" {
+		req.Currency = money.RUB
+	}
+	amount, err := parseAmount(req.Amount, req.Currency)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("amount: "+err.Error()))
+	}
+
+	intent, err := h.uc.TopUp(c.Request().Context(), usecase.TopUpInput{
+		UserID:    userID,
+		Amount:    amount,
+		Provider:  req.Provider,
+		ReturnURL: req.ReturnURL,
+		SaveCard:  req.SaveCard,
+		TokenID:   req.TokenID,
+		UserEmail: req.UserEmail,
+		UserPhone: req.UserPhone,
+	})
+	if errors.Is(err, domain.ErrInvalidAmount) || errors.Is(err, domain.ErrInvalidMethod) || errors.Is(err, domain.ErrInvalidProvider) {
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusCreated, intent)
+}
+
+// Statement handles GET /api/v1/wallet/statement?limit=&offset=
+func (h *WalletHandler) Statement(c echo.Context) error {
+	userID, _ := c.Get(UserIDKey).(string)
+	limit, offset := pageParams(c)
+	st, err := h.uc.Statement(c.Request().Context(), userID, limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, st)
+}
+
+// ============ Admin endpoints ============
+
+// CreditRequest — POST /api/v1/admin/wallets/:userId/credits
+type CreditRequest struct {
+	Kind      string      `json:"kind"`      // referral | compensation
+	Amount    json.Number `json:"amount"`    // major units, RUB
+	Reference string      `json:"reference"` // referral or support case id; credited once
+	Reason    string      `json:"reason"`
+}
+
+// Credit handles POST /api/v1/admin/wallets/:userId/credits
+func (h *WalletHandler) Credit(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	var req CreditRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("invalid request"))
+	}
+	amount, err := parseAmount(req.Amount, money.RUB)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("amount: "+err.Error()))
+	}
+
+	credit := &domain.WalletCredit{
+		UserID:    c.Param("userId"),
+		Kind:      req.Kind,
+		Amount:    amount,
+		Reference: req.Reference,
+		Reason:    req.Reason,
+	}
+	err = h.uc.Credit(c.Request().Context(), credit)
+	switch {
+	case errors.Is(err, domain.ErrInvalidCredit):
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	case errors.Is(err, domain.ErrCreditExists):
+		return c.JSON(http.StatusConflict, errorResponse(err.Error()))
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusCreated, credit)
+}
+
+// Reconcile handles GET /api/v1/admin/wallets/reconciliation
+func (h *WalletHandler) Reconcile(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	rec, err := h.uc.Reconcile(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, rec)
+}
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/usecase"
+)
+
+// WebhookHandler handles provider callbacks and the admin view of the webhook inbox
+type WebhookHandler struct {
+	uc *usecase.WebhookUseCase
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(uc *usecase.WebhookUseCase) *WebhookHandler {
+	return &WebhookHandler{uc: uc}
+}
+
+// Receive handles POST /webhooks/<provider>: the callback is stored and acknowledged;
+// it is applied by the inbox worker
+func (h *WebhookHandler) Receive(provider string) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, errorResponse("failed to read body"))
+		}
+
+		// Get signature header (varies by provider)
+		signature := ""
+		switch provider {
+		case domain.ProviderTinkoff:
+			// Tinkoff uses Token in body
+		case domain.ProviderYooMoney:
+			signature = c.Request().Header.Get("X-YooKassa-Signature")
+		case domain.ProviderSber:
+			signature = c.Request().Header.Get("X-Signature")
+		}
+
+		headers := make(map[string]string, len(c.Request().Header))
+		for name := range c.Request().Header {
+			headers[name] = c.Request().Header.Get(name)
+		}
+
+		// Not stored: let the provider deliver again
+		if err := h.uc.Receive(c.Request().Context(), provider, headers, body, signature); err != nil {
+			c.Logger().Error("webhook not stored", "provider", provider, "error", err)
+			return c.String(http.StatusInternalServerError, "retry")
+		}
+		return c.String(http.StatusOK, "OK")
+	}
+}
+
+// List handles GET /api/v1/admin/webhooks?provider=&status=&limit=&offset=
+func (h *WebhookHandler) List(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	limit, offset := pageParams(c)
+	webhooks, err := h.uc.List(c.Request().Context(), c.QueryParam("provider"), c.QueryParam("status"), limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"webhooks": webhooks})
+}
+
+// Replay handles POST /api/v1/admin/webhooks/:id/replay
+func (h *WebhookHandler) Replay(c echo.Context) error {
+	role, ok := c.Get(UserRoleKey).(string)
+	if !ok || role != "admin" {
+		return c.JSON(http.StatusForbidden, errorResponse("admin access required"))
+	}
+	w, err := h.uc.Replay(c.Request().Context(), c.Param("id"))
+	switch {
+	case errors.Is(err, domain.ErrWebhookNotFound):
+		return c.JSON(http.StatusNotFound, errorResponse(err.Error()))
+	case errors.Is(err, domain.ErrWebhookNotReplayable):
+		return c.JSON(http.StatusConflict, errorResponse(err.Error()))
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, w)
+}
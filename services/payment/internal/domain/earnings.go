@@ -0,0 +1,255 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+// Journal entry kinds of driver earnings and payouts
+const (
+	EntryRideEarning  = "ride.earning"  // reference: ride id
+	EntryPayoutSent   = "payout.sent"   // reference: payout id
+	EntryPayoutFailed = "payout.failed" // reversal of payout.sent
+)
+
+// DriverTierStandard — tier of drivers nobody has assigned one
+const DriverTierStandard = "standard"
+
+// Payout providers (a payout gateway each); cash rides are never paid out
+const (
+	PayoutProviderYooMoney = "yoomoney" // YooMoney Payouts (ЮKassa Выплаты)
+	PayoutProviderStub     = "stub"     // local development: paid immediately
+)
+
+// Payout statuses
+const (
+	PayoutStatusPending    = "pending"    // booked, not sent yet
+	PayoutStatusProcessing = "processing" // accepted by the provider
+	PayoutStatusPaid       = "paid"
+	PayoutStatusFailed     = "failed" // the amount is back on the driver's balance
+)
+
+// Earnings errors
+var (
+	ErrInvalidCommission  = errors.New("commission rate must be between 0 and 100%")
+	ErrRuleNotFound       = errors.New("commission rule not found")
+	ErrRuleExists         = errors.New("commission rule for this city, category and tier already exists")
+	ErrInvalidPayoutSetup = errors.New("invalid payout provider or destination")
+	ErrPayoutNotFound     = errors.New("payout not found")
+	ErrBatchExists        = errors.New("payout batch for this period already exists")
+	ErrRideNotPaid        = errors.New("card or wallet payment of the ride is not completed")
+	ErrNoFare             = errors.New("ride fare is unknown")
+)
+
+// CommissionRule — platform commission on rides; an empty City, Category or Tier
+// matches any. The most specific matching rule applies: city outranks category,
+// category outranks tier.
+type CommissionRule struct {
+	ID        string     `json:"id"`
+	City      string     `json:"city,omitempty"`
+	Category  string     `json:"category,omitempty"`
+	Tier      string     `json:"tier,omitempty"`
+	Rate      money.Rate `json:"rate"` // percent of the fare, tips excluded
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Validate checks the rate
+func (r *CommissionRule) Validate() error {
+	if r.Rate < 0 || r.Rate > money.Percent100 {
+		return ErrInvalidCommission
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to a ride
+func (r *CommissionRule) Matches(city, category, tier string) bool {
+	return (r.City == "" || r.City == city) &&
+		(r.Category == "" || r.Category == category) &&
+		(r.Tier == "" || r.Tier == tier)
+}
+
+func (r *CommissionRule) specificity() int {
+	s := 0
+	if r.City != "" {
+		s += 4
+	}
+	if r.Category != "" {
+		s += 2
+	}
+	if r.Tier != "" {
+		s++
+	}
+	return s
+}
+
+// MatchCommissionRule returns the most specific rule matching the ride, nil when none does
+func MatchCommissionRule(rules []*CommissionRule, city, category, tier string) *CommissionRule {
+	var best *CommissionRule
+	for _, r := range rules {
+		if r.Matches(city, category, tier) && (best == nil || r.specificity() > best.specificity()) {
+			best = r
+		}
+	}
+	return best
+}
+
+// DriverProfile — what earnings and payouts need to know about a driver: city and
+// tier (set by admins) select the commission, the payout destination is the driver's
+type DriverProfile struct {
+	DriverID          string    `json:"driver_id"`
+	City              string    `json:"city,omitempty"`
+	Tier              string    `json:"tier"`
+	PayoutProvider    string    `json:"payout_provider,omitempty"`
+	PayoutDestination string    `json:"payout_destination,omitempty"` // payout token or wallet number
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// CanBePaidOut reports whether the driver has set a payout destination
+func (a *DriverProfile) CanBePaidOut() bool {
+	return a != nil && a.PayoutProvider != "" && a.PayoutDestination != ""
+}
+
+// RideCompletion — a completed ride as reported by the ride service. It is kept until
+// the driver's earning is recorded, which for a card ride waits for its payment.
+type RideCompletion struct {
+	RideID        string
+	DriverID      string
+	Category      string
+	PaymentMethod string    // cash | card as chosen for the ride; empty when not reported
+	Fare          *RideFare // nil when the ride has no price
+	CreatedAt     time.Time
+}
+
+// RideEarning — what a completed ride changed on the driver's balance. Card and wallet
+// rides are paid to the platform: the driver is credited the fare less commission. Cash
+// rides are paid to the driver: their balance is debited the commission owed.
+type RideEarning struct {
+	ID          string      `json:"id"`
+	RideID      string      `json:"ride_id"`
+	DriverID    string      `json:"driver_id"`
+	PassengerID string      `json:"-"`      // card and wallet rides: whose payment is settled
+	Method      string      `json:"method"` // card | wallet | cash
+	City        string      `json:"city,omitempty"`
+	Category    string      `json:"category,omitempty"`
+	Tier        string      `json:"tier"`
+	Fare        money.Money `json:"fare"` // paid by the passenger, tips included
+	Tip         money.Money `json:"tip"`
+	Rate        money.Rate  `json:"rate"`
+	RuleID      string      `json:"rule_id,omitempty"` // empty: the default rate
+	Commission  money.Money `json:"commission"`
+	Net         money.Money `json:"net"` // change of the driver's balance; negative for cash rides
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Settle computes commission and net from Fare, Tip and Rate
+func (e *RideEarning) Settle() {
+	base := e.Fare.Sub(e.Tip)
+	if base.IsNegative() {
+		base = money.New(0, e.Fare.Currency)
+	}
+	e.Commission = e.Rate.Of(base).In(e.Fare.Currency)
+	if PaidToPlatform(e.Method) {
+		e.Net = e.Fare.Sub(e.Commission)
+	} else {
+		e.Net = e.Commission.Neg()
+	}
+}
+
+// LedgerEntry books the earning; nil when nothing moves (a cash ride without commission)
+func (e *RideEarning) LedgerEntry() *JournalEntry {
+	driver := DriverAccount(e.DriverID)
+	if !PaidToPlatform(e.Method) {
+		if !e.Commission.IsPositive() {
+			return nil
+		}
+		return transfer(EntryRideEarning, e.RideID, driver, AccountPlatformCommission, e.Commission)
+	}
+	entry := &JournalEntry{Kind: EntryRideEarning, Reference: e.RideID}
+	entry.Postings = append(entry.Postings, Posting{Account: PassengerAccount(e.PassengerID), Amount: e.Fare})
+	if e.Net.IsPositive() {
+		entry.Postings = append(entry.Postings, Posting{Account: driver, Amount: e.Net.Neg()})
+	}
+	if e.Commission.IsPositive() {
+		entry.Postings = append(entry.Postings, Posting{Account: AccountPlatformCommission, Amount: e.Commission.Neg()})
+	}
+	return entry
+}
+
+// Payout — transfer of a driver's earnings balance to their payout destination
+type Payout struct {
+	ID          string      `json:"id"`
+	BatchID     string      `json:"batch_id"`
+	DriverID    string      `json:"driver_id"`
+	Amount      money.Money `json:"amount"`
+	Provider    string      `json:"provider"`
+	Destination string      `json:"-"`
+	Status      string      `json:"status"`
+	ExternalID  string      `json:"external_id,omitempty"`
+	FailReason  string      `json:"fail_reason,omitempty"`
+	PeriodStart time.Time   `json:"period_start"`
+	PeriodEnd   time.Time   `json:"period_end"`
+	CreatedAt   time.Time   `json:"created_at"`
+	PaidAt      *time.Time  `json:"paid_at,omitempty"`
+}
+
+// SentEntry books the payout: the amount leaves the driver's balance for the provider
+func (p *Payout) SentEntry() *JournalEntry {
+	return transfer(EntryPayoutSent, p.ID, DriverAccount(p.DriverID), ClearingAccount(p.Provider), p.Amount)
+}
+
+// FailedEntry reverses SentEntry when the provider rejects the payout
+func (p *Payout) FailedEntry() *JournalEntry {
+	return transfer(EntryPayoutFailed, p.ID, ClearingAccount(p.Provider), DriverAccount(p.DriverID), p.Amount)
+}
+
+// PayoutBatch — the weekly run paying out every driver's balance at PeriodEnd
+type PayoutBatch struct {
+	ID          string      `json:"id"`
+	PeriodStart time.Time   `json:"period_start"`
+	PeriodEnd   time.Time   `json:"period_end"`
+	Count       int         `json:"count"`
+	Total       money.Money `json:"total"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// PayoutWeek — the last full week before now: Monday 00:00 UTC to the next Monday
+func PayoutWeek(now time.Time) (start, end time.Time) {
+	now = now.UTC()
+	daysSinceMonday := (int(now.Weekday()) + 6) % 7
+	end = time.Date(now.Year(), now.Month(), now.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+	return end.AddDate(0, 0, -7), end
+}
+
+// PayoutStatement — a payout with the rides of its period. CarriedOver is the part of
+// the amount earned before the period (balance left from earlier weeks, failed payouts).
+type PayoutStatement struct {
+	Payout      *Payout        `json:"payout"`
+	Rides       []*RideEarning `json:"rides"`
+	Fares       money.Money    `json:"fares"`
+	Commission  money.Money    `json:"commission"`
+	Net         money.Money    `json:"net"`
+	CarriedOver money.Money    `json:"carried_over"`
+}
+
+// NewPayoutStatement sums the rides of the payout's period
+func NewPayoutStatement(p *Payout, rides []*RideEarning) *PayoutStatement {
+	cur := p.Amount.Currency
+	st := &PayoutStatement{Payout: p, Rides: rides,
+		Fares: money.New(0, cur), Commission: money.New(0, cur), Net: money.New(0, cur)}
+	for _, r := range rides {
+		st.Fares = st.Fares.Add(r.Fare)
+		st.Commission = st.Commission.Add(r.Commission)
+		st.Net = st.Net.Add(r.Net)
+	}
+	st.CarriedOver = p.Amount.Sub(st.Net)
+	return st
+}
+
+// EarningsSummary — the driver's balance and recent rides
+type EarningsSummary struct {
+	Balance money.Money    `json:"balance"` // owed to the driver; negative: commission owed to the platform
+	Rides   []*RideEarning `json:"rides"`
+}
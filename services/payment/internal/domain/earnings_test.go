@@ -0,0 +1,94 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+func TestMatchCommissionRule(t *testing.T) {
+	rules := []*CommissionRule{
+		{ID: "any", Rate: 1500},
+		{ID: "comfort", Category: "comfort", Rate: 1800},
+		{ID: "gold", Tier: "gold", Rate: 1000},
+		{ID: "kazan", City: "kazan", Rate: 1200},
+		{ID: "kazan-comfort", City: "kazan", Category: "comfort", Rate: 1600},
+	}
+	cases := []struct{ city, category, tier, want string }{
+		{"moscow", "economy", "standard", "any"},
+		{"moscow", "comfort", "gold", "comfort"}, // category outranks tier
+		{"moscow", "economy", "gold", "gold"},
+		{"kazan", "economy", "gold", "kazan"}, // city outranks category and tier
+		{"kazan", "comfort", "standard", "kazan-comfort"},
+	}
+	for _, c := range cases {
+		if got := MatchCommissionRule(rules, c.city, c.category, c.tier); got == nil || got.ID != c.want {
+			t.Errorf("%s/%s/%s: got %+v, want %s", c.city, c.category, c.tier, got, c.want)
+		}
+	}
+	if MatchCommissionRule(rules[1:2], "moscow", "economy", "standard") != nil {
+		t.Error("no rule must match")
+	}
+	if (&CommissionRule{Rate: 10001}).Validate() == nil || (&CommissionRule{Rate: -1}).Validate() == nil {
+		t.Error("rates outside 0..100% must be rejected")
+	}
+}
+
+func TestRideEarning_Settle(t *testing.T) {
+	card := &RideEarning{RideID: "r1", DriverID: "d1", PassengerID: "p1", Method: MethodCard,
+		Fare: rub(65050), Tip: rub(5000), Rate: 1500}
+	card.Settle()
+	// 15% of 600.50 = 90.075 → 90.08; tips are not commissioned
+	if card.Commission.Minor != 9008 || card.Net.Minor != 56042 {
+		t.Fatalf("card: commission %s net %s", card.Commission, card.Net)
+	}
+	entry := card.LedgerEntry()
+	if err := entry.Validate(); err != nil || len(entry.Postings) != 3 || entry.Reference != "r1" {
+		t.Fatalf("card entry %+v: %v", entry, err)
+	}
+	if entry.Postings[1].Account != "driver:d1" || entry.Postings[1].Amount.Minor != -56042 {
+		t.Errorf("driver must be credited the net: %+v", entry.Postings[1])
+	}
+
+	cash := &RideEarning{RideID: "r2", DriverID: "d1", Method: MethodCash, Fare: rub(40000), Rate: 1500}
+	cash.Settle()
+	if cash.Commission.Minor != 6000 || cash.Net.Minor != -6000 {
+		t.Fatalf("cash: commission %s net %s", cash.Commission, cash.Net)
+	}
+	entry = cash.LedgerEntry()
+	if err := entry.Validate(); err != nil || entry.Postings[0].Account != "driver:d1" || entry.Postings[0].Amount.Minor != 6000 {
+		t.Errorf("cash: driver must be debited the commission: %+v %v", entry, err)
+	}
+
+	free := &RideEarning{RideID: "r3", DriverID: "d1", Method: MethodCash, Fare: rub(40000)}
+	free.Settle()
+	if free.LedgerEntry() != nil {
+		t.Error("cash ride without commission moves nothing")
+	}
+}
+
+func TestPayoutWeekAndStatement(t *testing.T) {
+	start, end := PayoutWeek(time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC)) // Wednesday
+	if !start.Equal(time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("week %s – %s", start, end)
+	}
+	if _, end := PayoutWeek(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)); end.Day() != 12 {
+		t.Errorf("on Monday 00:00 the week just ended: %s", end)
+	}
+
+	p := &Payout{ID: "po1", DriverID: "d1", Provider: PayoutProviderStub, Amount: rub(100000)}
+	st := NewPayoutStatement(p, []*RideEarning{
+		{Fare: rub(50000), Commission: rub(7500), Net: rub(42500)},
+		{Fare: rub(40000), Commission: rub(6000), Net: rub(-6000)},
+	})
+	if st.Fares.Minor != 90000 || st.Net.Minor != 36500 || st.CarriedOver.Minor != 63500 {
+		t.Errorf("statement %+v", st)
+	}
+	if err := p.SentEntry().Validate(); err != nil {
+		t.Error(err)
+	}
+	if back := p.FailedEntry(); back.Postings[1].Account != "driver:d1" || back.Postings[1].Amount != money.New(-100000, money.RUB) {
+		t.Errorf("failed payout must credit the driver back: %+v", back)
+	}
+}
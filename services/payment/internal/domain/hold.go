@@ -0,0 +1,41 @@
+package domain
+
+import "github.com/alexevil1979/indrive/packages/money-go"
+
+// RideFare — what the passenger owes for a completed ride: the agreed price plus
+// extras added on the way. Extras the ride service does not report are zero.
+type RideFare struct {
+	Price   money.Money `json:"price"`
+	Waiting money.Money `json:"waiting"`
+	Stops   money.Money `json:"stops"`
+	Tip     money.Money `json:"tip"`
+}
+
+// Total — the amount to capture
+func (f RideFare) Total() money.Money {
+	return f.Price.Add(f.Waiting).Add(f.Stops).Add(f.Tip)
+}
+
+// In sets the currency of every part that has none
+func (f RideFare) In(currency string) RideFare {
+	return RideFare{
+		Price:   f.Price.In(currency),
+		Waiting: f.Waiting.In(currency),
+		Stops:   f.Stops.In(currency),
+		Tip:     f.Tip.In(currency),
+	}
+}
+
+// HoldAmount — what is authorized at match: the agreed price plus buffer, so waiting,
+// stops and tips usually fit in the hold
+func HoldAmount(price money.Money, buffer money.Rate) money.Money {
+	return price.Add(buffer.Of(price))
+}
+
+// CaptureAmount — the final fare, capped at the hold (providers never capture more
+// than was authorized); short is the part of the fare left uncaptured
+func CaptureAmount(fare RideFare, held money.Money) (capture, short money.Money) {
+	total := fare.Total()
+	capture = money.Min(total, held)
+	return capture, total.Sub(capture)
+}
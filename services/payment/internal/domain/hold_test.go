@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+func TestHoldAndCaptureAmounts(t *testing.T) {
+	hold := HoldAmount(rub(65050), 2000) // 650.50 + 20% = 780.60
+	if hold.Minor != 78060 {
+		t.Fatalf("hold %s, want 780.60", hold)
+	}
+
+	fare := RideFare{Price: rub(65050), Waiting: rub(4000), Tip: rub(5000)}
+	capture, short := CaptureAmount(fare, hold)
+	if capture.Minor != 74050 || !short.IsZero() {
+		t.Errorf("within the hold: capture %s short %s", capture, short)
+	}
+
+	fare.Stops = rub(10000)
+	capture, short = CaptureAmount(fare, hold)
+	if capture != hold || short.Minor != 5990 {
+		t.Errorf("above the hold: capture %s short %s", capture, short)
+	}
+
+	parts := RideFare{Price: money.New(100, "")}.In(money.RUB)
+	if parts.Price.Currency != money.RUB || parts.Tip.Currency != money.RUB || parts.Total().Minor != 100 {
+		t.Errorf("In: %+v", parts)
+	}
+}
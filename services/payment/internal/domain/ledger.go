@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+// Ledger accounts. Per-user and per-provider accounts are "<type>:<id>".
+const (
+	AccountPlatformCommission = "platform:commission"   // platform revenue from rides
+	AccountPromoBudget        = "platform:promo_budget" // discounts funded by the platform
+)
+
+// Account types (prefix of the account name)
+const (
+	AccountTypePassenger = "passenger" // paid by the passenger (credit), refunded (debit)
+	AccountTypeDriver    = "driver"    // earnings owed to the driver
+	AccountTypeClearing  = "clearing"  // money at the gateway (or cash collected), not yet settled
+	AccountTypeWallet    = "wallet"    // the passenger's in-app balance, owed to them
+	AccountTypePlatform  = "platform"
+)
+
+// Journal entry kinds
+const (
+	EntryPaymentCaptured = "payment.captured"
+	EntryPaymentRefunded = "payment.refunded"
+	EntryPromoDiscount   = "promo.discount"
+)
+
+// Ledger errors
+var (
+	ErrUnbalancedEntry = errors.New("journal entry is not balanced")
+	ErrInvalidAccount  = errors.New("invalid ledger account")
+)
+
+// PassengerAccount — the passenger's account
+func PassengerAccount(userID string) string { return AccountTypePassenger + ":" + userID }
+
+// DriverAccount — the driver's account
+func DriverAccount(driverID string) string { return AccountTypeDriver + ":" + driverID }
+
+// ClearingAccount — the gateway's clearing account; cash payments clear through "clearing:cash"
+func ClearingAccount(provider string) string { return AccountTypeClearing + ":" + provider }
+
+// IsValidAccount checks the "<type>:<id>" form with a known type
+func IsValidAccount(account string) bool {
+	typ, id, ok := strings.Cut(account, ":")
+	if !ok || id == "" {
+		return false
+	}
+	switch typ {
+	case AccountTypePassenger, AccountTypeDriver, AccountTypeClearing, AccountTypeWallet, AccountTypePlatform:
+		return true
+	}
+	return false
+}
+
+// Posting — one side of a journal entry: debit positive, credit negative
+type Posting struct {
+	Account string      `json:"account"`
+	Amount  money.Money `json:"amount"`
+}
+
+// JournalEntry — one money movement; its postings sum to zero in every currency.
+// Entries are never changed: corrections are new entries.
+type JournalEntry struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	Reference string    `json:"reference"` // payment, refund or promo usage id
+	Memo      string    `json:"memo,omitempty"`
+	Postings  []Posting `json:"postings"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks the entry is bookable: a kind, at least two non-zero postings to
+// valid accounts with a currency, and zero sum per currency
+func (e *JournalEntry) Validate() error {
+	if e.Kind == "" || len(e.Postings) < 2 {
+		return ErrUnbalancedEntry
+	}
+	sums := map[string]int64{}
+	for _, p := range e.Postings {
+		if !IsValidAccount(p.Account) {
+			return ErrInvalidAccount
+		}
+		if p.Amount.IsZero() || p.Amount.Currency == "" {
+			return ErrUnbalancedEntry
+		}
+		sums[p.Amount.Currency] += p.Amount.Minor
+	}
+	for _, sum := range sums {
+		if sum != 0 {
+			return ErrUnbalancedEntry
+		}
+	}
+	return nil
+}
+
+// transfer — an entry moving amount from one account (credit) to another (debit)
+func transfer(kind, reference, debit, credit string, amount money.Money) *JournalEntry {
+	return &JournalEntry{
+		Kind:      kind,
+		Reference: reference,
+		Postings: []Posting{
+			{Account: debit, Amount: amount},
+			{Account: credit, Amount: amount.Neg()},
+		},
+	}
+}
+
+// fundingAccount — where the money of p comes from: the payer's wallet for wallet
+// payments, the provider's clearing account otherwise
+func fundingAccount(p *Payment) string {
+	if p.Provider == ProviderWallet {
+		return WalletAccount(p.UserID)
+	}
+	return ClearingAccount(p.Provider)
+}
+
+// CaptureEntry — the passenger paid p.Amount through the gateway, in cash or from
+// their wallet; a completed top-up credits the wallet instead of a ride
+func CaptureEntry(p *Payment) *JournalEntry {
+	if p.Purpose == PurposeWalletTopUp {
+		return transfer(EntryWalletTopUp, p.ID, ClearingAccount(p.Provider), WalletAccount(p.UserID), p.Amount)
+	}
+	return transfer(EntryPaymentCaptured, p.ID, fundingAccount(p), PassengerAccount(p.UserID), p.Amount)
+}
+
+// RefundEntry — amount of p goes back the way it was paid; a refunded top-up leaves the
+// wallet. The reference is the refund, set when it is stored.
+func RefundEntry(p *Payment, amount money.Money) *JournalEntry {
+	if p.Purpose == PurposeWalletTopUp {
+		return transfer(EntryPaymentRefunded, "", WalletAccount(p.UserID), ClearingAccount(p.Provider), amount)
+	}
+	return transfer(EntryPaymentRefunded, "", PassengerAccount(p.UserID), fundingAccount(p), amount)
+}
+
+// RefundToWalletEntry — amount of p is refunded to the payer's wallet whatever the
+// payment method; the reference is the refund
+func RefundToWalletEntry(p *Payment, amount money.Money) *JournalEntry {
+	return transfer(EntryPaymentRefunded, "", PassengerAccount(p.UserID), WalletAccount(p.UserID), amount)
+}
+
+// PromoDiscountEntry — the promo budget pays discount on behalf of the passenger; the
+// reference is the promo usage, set when it is stored
+func PromoDiscountEntry(userID string, discount money.Money) *JournalEntry {
+	return transfer(EntryPromoDiscount, "", AccountPromoBudget, PassengerAccount(userID), discount)
+}
+
+// AccountBalance — totals of one account in one currency; Balance = Debits - Credits
+type AccountBalance struct {
+	Account string      `json:"account"`
+	Debits  money.Money `json:"debits"`
+	Credits money.Money `json:"credits"`
+	Balance money.Money `json:"balance"`
+}
+
+// TrialBalance — balances of accounts; over the whole ledger Totals are zero per currency
+type TrialBalance struct {
+	Accounts []AccountBalance       `json:"accounts"`
+	Totals   map[string]money.Money `json:"totals"`
+	Balanced bool                   `json:"balanced"`
+}
+
+// NewTrialBalance sums account balances per currency
+func NewTrialBalance(accounts []AccountBalance) *TrialBalance {
+	tb := &TrialBalance{Accounts: accounts, Totals: map[string]money.Money{}, Balanced: true}
+	for _, a := range accounts {
+		tb.Totals[a.Balance.Currency] = tb.Totals[a.Balance.Currency].Add(a.Balance)
+	}
+	for cur, total := range tb.Totals {
+		tb.Totals[cur] = total.In(cur)
+		if !total.IsZero() {
+			tb.Balanced = false
+		}
+	}
+	return tb
+}
+
+// LedgerFilter — audit trail query
+type LedgerFilter struct {
+	Account   string // entries with a posting to the account
+	Reference string
+	Kind      string
+	Limit     int
+	Offset    int
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+func TestJournalEntry_Validate(t *testing.T) {
+	p := &Payment{ID: "pay-1", UserID: "u1", Provider: ProviderTinkoff, Amount: rub(50000)}
+	capture := CaptureEntry(p)
+	if err := capture.Validate(); err != nil {
+		t.Fatalf("capture: %v", err)
+	}
+	if capture.Reference != "pay-1" || capture.Postings[0].Account != "clearing:tinkoff" ||
+		capture.Postings[1].Account != "passenger:u1" || capture.Postings[1].Amount.Minor != -50000 {
+		t.Errorf("capture postings: %+v", capture)
+	}
+
+	cases := map[string]*JournalEntry{
+		"one posting": {Kind: EntryPaymentCaptured, Postings: []Posting{{Account: "passenger:u1", Amount: rub(100)}}},
+		"unbalanced": {Kind: EntryPaymentCaptured, Postings: []Posting{
+			{Account: "passenger:u1", Amount: rub(100)}, {Account: "clearing:cash", Amount: rub(-90)},
+		}},
+		"mixed currencies": {Kind: EntryPaymentCaptured, Postings: []Posting{
+			{Account: "passenger:u1", Amount: rub(100)}, {Account: "clearing:cash", Amount: money.New(-100, "USD")},
+		}},
+		"zero posting": {Kind: EntryPaymentCaptured, Postings: []Posting{
+			{Account: "passenger:u1", Amount: rub(0)}, {Account: "clearing:cash", Amount: rub(0)},
+		}},
+		"bad account": transfer(EntryPromoDiscount, "x", "bonus:u1", AccountPromoBudget, rub(100)),
+	}
+	for name, e := range cases {
+		if err := e.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestTrialBalance(t *testing.T) {
+	var balances []AccountBalance
+	for _, e := range []*JournalEntry{
+		CaptureEntry(&Payment{ID: "pay-1", UserID: "u1", Provider: ProviderCash, Amount: rub(30000)}),
+		RefundEntry(&Payment{ID: "pay-1", UserID: "u1", Provider: ProviderCash}, rub(5000)),
+		PromoDiscountEntry("u1", rub(2000)),
+	} {
+		for _, p := range e.Postings {
+			b := AccountBalance{Account: p.Account, Balance: p.Amount}
+			balances = append(balances, b)
+		}
+	}
+	tb := NewTrialBalance(balances)
+	if !tb.Balanced || !tb.Totals[money.RUB].IsZero() {
+		t.Errorf("whole ledger must balance: %+v", tb.Totals)
+	}
+
+	tb = NewTrialBalance(balances[:1])
+	if tb.Balanced || tb.Totals[money.RUB].Minor != 30000 {
+		t.Errorf("a single account does not balance: %+v", tb.Totals)
+	}
+}
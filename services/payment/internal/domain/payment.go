@@ -4,12 +4,15 @@ package domain
 import (
 	"errors"
 	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
 )
 
 // Payment methods
 const (
-	MethodCash = "cash"
-	MethodCard = "card"
+	MethodCash   = "cash"
+	MethodCard   = "card"
+	MethodWallet = "wallet" // in-app wallet balance
 )
 
 // Payment providers
@@ -18,15 +21,24 @@ const (
 	ProviderTinkoff = "tinkoff" // Tinkoff Acquiring
 	ProviderYooMoney = "yoomoney" // YooMoney (ЮКасса)
 	ProviderSber    = "sber"    // SberPay / Sberbank Acquiring
+	ProviderWallet  = "wallet"  // In-app wallet (no gateway)
+)
+
+// Payment purposes
+const (
+	PurposeRide        = "ride"         // a ride or a shared trip seat
+	PurposeWalletTopUp = "wallet_topup" // money added to the payer's wallet; no ride
 )
 
 // Payment statuses
 const (
 	PaymentStatusPending    = "pending"    // Created, awaiting payment
 	PaymentStatusProcessing = "processing" // Payment in progress
+	PaymentStatusAuthorized = "authorized" // Amount held on the card, awaiting capture or void
 	PaymentStatusCompleted  = "completed"  // Successfully paid
 	PaymentStatusFailed     = "failed"     // Payment failed
 	PaymentStatusCancelled  = "cancelled"  // Cancelled by user/system
+	PaymentStatusPartiallyRefunded = "partially_refunded" // Part of the amount refunded; more can be
 	PaymentStatusRefunded   = "refunded"   // Refunded to user
 )
 
@@ -39,25 +51,35 @@ var (
 	ErrPaymentExists      = errors.New("payment already exists for this ride")
 	ErrPaymentNotPending  = errors.New("payment is not in pending status")
 	ErrRefundNotAllowed   = errors.New("refund not allowed for this payment")
+	ErrOwnPaymentRefund   = errors.New("refunds of one's own payments are not allowed")
+	ErrConfirmNotAllowed  = errors.New("only cash ride payments are confirmed by hand")
+	ErrNotRideDriver      = errors.New("only the ride's driver or staff can confirm its payment")
 	ErrProviderError      = errors.New("payment provider error")
+	ErrPaymentNotAuthorized = errors.New("payment is not authorized")
 )
 
 // Payment — main payment entity
 type Payment struct {
 	ID          string    `json:"id"`
-	RideID      string    `json:"ride_id"`
+	RideID      string    `json:"ride_id,omitempty"`     // ride, or shared trip for seat bookings; empty for top-ups
+	Purpose     string    `json:"purpose"`               // ride | wallet_topup
+	BookingID   string    `json:"booking_id,omitempty"`  // shared trip seat booking (per-passenger payment)
 	UserID      string    `json:"user_id"`
-	Amount      float64   `json:"amount"`
-	Currency    string    `json:"currency"`
-	Method      string    `json:"method"`   // cash | card
-	Provider    string    `json:"provider"` // cash | tinkoff | yoomoney | sber
+	Amount      money.Money `json:"amount"`   // minor units; serialized in major units
+	Currency    string    `json:"currency"` // = Amount.Currency
+	Method      string    `json:"method"`   // cash | card | wallet
+	Provider    string    `json:"provider"` // cash | tinkoff | yoomoney | sber | wallet
 	Status      string    `json:"status"`
+	Authorized  *money.Money `json:"authorized,omitempty"` // held at match (two-stage); Amount is what was captured
+	AuthorizedAt *time.Time `json:"authorized_at,omitempty"`
 	ExternalID  string    `json:"external_id,omitempty"`  // Provider's payment ID
 	ConfirmURL  string    `json:"confirm_url,omitempty"`  // URL for 3DS / redirect
 	Description string    `json:"description,omitempty"`
 	Metadata    string    `json:"metadata,omitempty"`     // JSON metadata
 	FailReason  string    `json:"fail_reason,omitempty"`
-	RefundedAt  *time.Time `json:"refunded_at,omitempty"`
+	Routing     *RoutingDecision `json:"routing,omitempty"` // card payments: how the provider was chosen
+	Refunded    money.Money `json:"refunded"` // refunds that have not failed, pending included
+	RefundedAt  *time.Time `json:"refunded_at,omitempty"` // when the whole amount was refunded
 	PaidAt      *time.Time `json:"paid_at,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
@@ -83,7 +105,7 @@ type PaymentIntent struct {
 	PaymentID   string `json:"payment_id"`
 	ConfirmURL  string `json:"confirm_url"`  // URL to redirect user
 	Provider    string `json:"provider"`
-	Amount      float64 `json:"amount"`
+	Amount      money.Money `json:"amount"`
 	Currency    string `json:"currency"`
 	Description string `json:"description"`
 }
@@ -91,15 +113,19 @@ type PaymentIntent struct {
 // RefundRequest — refund parameters
 type RefundRequest struct {
 	PaymentID string  `json:"payment_id"`
-	Amount    float64 `json:"amount,omitempty"` // Partial refund amount (0 = full)
+	Amount    money.Money `json:"amount,omitempty"` // Partial refund amount (zero = full)
 	Reason    string  `json:"reason,omitempty"`
+	ToWallet  bool    `json:"to_wallet,omitempty"` // credit the payer's wallet instead of the card
+	// Compensation — a cash payment credited to the payer's wallet as compensation (admins only)
+	Compensation bool   `json:"compensation,omitempty"`
+	RequestedBy  string `json:"-"` // staff member issuing the refund; never the payer
 }
 
 // RefundResult — refund response
 type RefundResult struct {
 	RefundID   string    `json:"refund_id"`
 	PaymentID  string    `json:"payment_id"`
-	Amount     float64   `json:"amount"`
+	Amount     money.Money `json:"amount"`
 	Status     string    `json:"status"`
 	RefundedAt time.Time `json:"refunded_at"`
 }
@@ -107,18 +133,21 @@ type RefundResult struct {
 // WebhookEvent — incoming webhook from provider
 type WebhookEvent struct {
 	Provider    string `json:"provider"`
+	EventID     string `json:"event_id"`     // Provider's notification identity: redeliveries repeat it
 	EventType   string `json:"event_type"`   // payment.succeeded, payment.failed, refund.succeeded
 	PaymentID   string `json:"payment_id"`   // Our payment ID (from metadata)
 	ExternalID  string `json:"external_id"`  // Provider's payment ID
+	RefundID    string `json:"refund_id,omitempty"` // Provider's refund ID, for refund events where it has one
 	Status      string `json:"status"`
-	Amount      float64 `json:"amount"`
+	Amount      money.Money `json:"amount"`
 	RawPayload  string `json:"raw_payload"`  // Original JSON
+	SignatureVerified bool `json:"signature_verified"` // false when the provider has no secret configured
 }
 
 // IsValidProvider checks if provider is valid
 func IsValidProvider(p string) bool {
 	switch p {
-	case ProviderCash, ProviderTinkoff, ProviderYooMoney, ProviderSber:
+	case ProviderCash, ProviderTinkoff, ProviderYooMoney, ProviderSber, ProviderWallet:
 		return true
 	}
 	return false
@@ -126,10 +155,16 @@ func IsValidProvider(p string) bool {
 
 // IsValidMethod checks if method is valid
 func IsValidMethod(m string) bool {
-	return m == MethodCash || m == MethodCard
+	return m == MethodCash || m == MethodCard || m == MethodWallet
+}
+
+// PaidToPlatform reports whether the method pays the platform (card, wallet) rather
+// than the driver (cash)
+func PaidToPlatform(method string) bool {
+	return method == MethodCard || method == MethodWallet
 }
 
 // RequiresGateway returns true if payment needs external gateway
 func RequiresGateway(provider string) bool {
-	return provider != ProviderCash
+	return provider != ProviderCash && provider != ProviderWallet
 }
@@ -1,7 +1,11 @@
 // Package domain — Promo codes for discounts
 package domain
 
-import "time"
+import (
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
 
 // PromoType defines the discount type
 type PromoType string
@@ -16,10 +20,12 @@ type Promo struct {
 	ID            string    `json:"id"`
 	Code          string    `json:"code"`           // Unique promo code (e.g. "WELCOME10")
 	Description   string    `json:"description"`    // Human-readable description
-	Type          PromoType `json:"type"`           // percent or fixed
-	Value         float64   `json:"value"`          // Discount value (10 for 10% or 100 for 100 RUB)
-	MinOrderValue float64   `json:"min_order_value"` // Minimum order to apply (0 = no minimum)
-	MaxDiscount   float64   `json:"max_discount"`   // Max discount for percent type (0 = no limit)
+	Type          PromoType   `json:"type"`            // percent or fixed
+	Percent       money.Rate  `json:"percent"`         // percent type: share off (10.5 = 10.5%)
+	AmountOff     money.Money `json:"amount_off"`      // fixed type: amount off
+	Currency      string      `json:"currency"`        // of the amounts; orders in other currencies don't qualify
+	MinOrderValue money.Money `json:"min_order_value"` // Minimum order to apply (0 = no minimum)
+	MaxDiscount   money.Money `json:"max_discount"`    // Max discount for percent type (0 = no limit)
 	UsageLimit    int       `json:"usage_limit"`    // Total uses allowed (0 = unlimited)
 	UsageCount    int       `json:"usage_count"`    // Current usage count
 	PerUserLimit  int       `json:"per_user_limit"` // Uses per user (0 = unlimited)
@@ -36,7 +42,7 @@ type UserPromo struct {
 	UserID    string    `json:"user_id"`
 	PromoID   string    `json:"promo_id"`
 	RideID    string    `json:"ride_id,omitempty"` // Ride where promo was applied
-	Discount  float64   `json:"discount"`          // Actual discount amount applied
+	Discount  money.Money `json:"discount"`        // Actual discount amount applied
 	UsedAt    time.Time `json:"used_at"`
 }
 
@@ -44,8 +50,8 @@ type UserPromo struct {
 type PromoResult struct {
 	Valid       bool    `json:"valid"`
 	Promo       *Promo  `json:"promo,omitempty"`
-	Discount    float64 `json:"discount"`     // Calculated discount amount
-	FinalPrice  float64 `json:"final_price"`  // Price after discount
+	Discount    money.Money `json:"discount"`    // Calculated discount amount
+	FinalPrice  money.Money `json:"final_price"` // Price after discount
 	Error       string  `json:"error,omitempty"`
 }
 
@@ -72,28 +78,28 @@ func (p *Promo) IsValid() bool {
 	return true
 }
 
-// CalculateDiscount calculates the discount for a given order amount
-func (p *Promo) CalculateDiscount(orderAmount float64) float64 {
-	if orderAmount < p.MinOrderValue {
-		return 0
+// CalculateDiscount calculates the discount for an order in the promo's currency.
+// Percent discounts are rounded half away from zero to the minor unit before the
+// MaxDiscount cap; the discount never exceeds the order.
+func (p *Promo) CalculateDiscount(order money.Money) money.Money {
+	zero := money.New(0, order.Currency)
+	if order.Currency != p.Currency || order.Cmp(p.MinOrderValue) < 0 {
+		return zero
 	}
-	
-	var discount float64
-	
+
+	var discount money.Money
 	switch p.Type {
 	case PromoTypePercent:
-		discount = orderAmount * (p.Value / 100)
-		if p.MaxDiscount > 0 && discount > p.MaxDiscount {
-			discount = p.MaxDiscount
+		discount = p.Percent.Of(order)
+		if p.MaxDiscount.IsPositive() {
+			discount = money.Min(discount, p.MaxDiscount)
 		}
 	case PromoTypeFixed:
-		discount = p.Value
+		discount = p.AmountOff
+	default:
+		return zero
 	}
-	
+
 	// Discount cannot exceed order amount
-	if discount > orderAmount {
-		discount = orderAmount
-	}
-	
-	return discount
+	return money.Min(discount, order)
 }
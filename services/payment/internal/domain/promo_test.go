@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+func rub(minor int64) money.Money { return money.New(minor, money.RUB) }
+
+func TestPromo_CalculateDiscount(t *testing.T) {
+	welcome := &Promo{Type: PromoTypePercent, Percent: 1000, Currency: money.RUB, MinOrderValue: rub(20000), MaxDiscount: rub(50000)}
+	cases := []struct {
+		promo *Promo
+		order money.Money
+		want  int64
+	}{
+		{welcome, rub(19999), 0},      // below the minimum
+		{welcome, rub(20005), 2001},   // 10% of 200.05 = 20.005 → 20.01
+		{welcome, rub(900000), 50000}, // capped
+		{welcome, money.New(90000, "USD"), 0},
+		{&Promo{Type: PromoTypeFixed, AmountOff: rub(10000), Currency: money.RUB}, rub(7550), 7550}, // not above the order
+		{&Promo{Type: PromoTypePercent, Percent: 1250, Currency: money.RUB}, rub(19999), 2500},
+	}
+	for i, c := range cases {
+		if got := c.promo.CalculateDiscount(c.order); got.Minor != c.want || got.Currency != c.order.Currency {
+			t.Errorf("case %d: discount %s, want %d", i, got, c.want)
+		}
+	}
+}
+
+func TestPromo_CalculateDiscount_Properties(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < 50000; i++ {
+		p := &Promo{Type: PromoTypePercent, Percent: money.Rate(1 + r.Int63n(int64(money.Percent100))), Currency: money.RUB}
+		if r.Intn(2) == 0 {
+			p = &Promo{Type: PromoTypeFixed, AmountOff: rub(1 + r.Int63n(1e6)), Currency: money.RUB}
+		}
+		if r.Intn(3) == 0 {
+			p.MaxDiscount = rub(r.Int63n(1e5))
+		}
+		order := rub(r.Int63n(1e8))
+		d := p.CalculateDiscount(order)
+		final := order.Sub(d)
+		if d.IsNegative() || final.IsNegative() || final.Add(d) != order {
+			t.Fatalf("%+v on %s: discount %s", p, order, d)
+		}
+		if p.MaxDiscount.IsPositive() && p.Type == PromoTypePercent && d.Cmp(p.MaxDiscount) > 0 {
+			t.Fatalf("cap exceeded: %s > %s", d, p.MaxDiscount)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package domain
+
+import "time"
+
+// Reconciliation outcomes
+const (
+	ReconcileApplied    = "applied"    // the provider's status was applied: its webhook was lost
+	ReconcileExpired    = "expired"    // abandoned intent cancelled
+	ReconcileUnresolved = "unresolved" // provider status we cannot apply on our own (refunded while pending here)
+	ReconcileFailed     = "failed"     // the provider could not be asked; retried next run
+)
+
+// FailReasonExpired — fail reason of payments cancelled by the reconciler
+const FailReasonExpired = "expired: not confirmed by the provider in time"
+
+// PaymentMismatch — a payment whose status differed from the provider's
+type PaymentMismatch struct {
+	ID             string    `json:"id"`
+	PaymentID      string    `json:"payment_id"`
+	Provider       string    `json:"provider"`
+	LocalStatus    string    `json:"local_status"`              // before reconciliation
+	ProviderStatus string    `json:"provider_status,omitempty"` // empty: the provider was never reached
+	Outcome        string    `json:"outcome"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ReconcileRun — what one reconciler pass did
+type ReconcileRun struct {
+	Checked    int `json:"checked"`
+	Applied    int `json:"applied"`
+	Expired    int `json:"expired"`
+	Unresolved int `json:"unresolved"`
+	Failed     int `json:"failed"`
+}
+
+// Add counts an outcome
+func (r *ReconcileRun) Add(outcome string) {
+	switch outcome {
+	case ReconcileApplied:
+		r.Applied++
+	case ReconcileExpired:
+		r.Expired++
+	case ReconcileUnresolved:
+		r.Unresolved++
+	case ReconcileFailed:
+		r.Failed++
+	}
+}
+
+// StatusEvent maps a provider status to the webhook event with the same effect; false
+// while the payment is still in flight or when the status cannot be applied
+func StatusEvent(status string) (string, bool) {
+	switch status {
+	case PaymentStatusCompleted:
+		return "payment.succeeded", true
+	case PaymentStatusAuthorized:
+		return "payment.authorized", true
+	case PaymentStatusFailed:
+		return "payment.failed", true
+	case PaymentStatusCancelled:
+		return "payment.cancelled", true
+	}
+	return "", false
+}
+
+// IsInFlight reports whether a status still waits for the provider
+func IsInFlight(status string) bool {
+	return status == PaymentStatusPending || status == PaymentStatusProcessing
+}
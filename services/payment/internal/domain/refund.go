@@ -0,0 +1,127 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+// Refund statuses
+const (
+	RefundStatusPending   = "pending"   // sent to the provider, outcome not known yet
+	RefundStatusSucceeded = "succeeded" // money returned
+	RefundStatusFailed    = "failed"    // refused by the provider; its amount is refundable again
+)
+
+// Refund errors
+var (
+	ErrRefundExceedsBalance = errors.New("refund exceeds the refundable balance")
+	ErrRefundNotFound       = errors.New("refund not found")
+)
+
+// Refund — money returned from a payment, to its card or to the payer's wallet. A
+// payment can be refunded in parts up to its amount.
+type Refund struct {
+	ID          string      `json:"id"`
+	PaymentID   string      `json:"payment_id"`
+	Amount      money.Money `json:"amount"`
+	Currency    string      `json:"currency"` // = Amount.Currency
+	Reason      string      `json:"reason,omitempty"`
+	ToWallet    bool        `json:"to_wallet"`
+	ExternalID  string      `json:"external_id,omitempty"` // provider's refund ID
+	Status      string      `json:"status"`
+	CreatedAt   time.Time   `json:"created_at"`
+	ProcessedAt *time.Time  `json:"processed_at,omitempty"` // when it succeeded or failed
+}
+
+// Settle records the outcome of the refund
+func (r *Refund) Settle(status string, at time.Time) {
+	r.Status = status
+	if status != RefundStatusPending {
+		r.ProcessedAt = &at
+	}
+}
+
+// IsPaid reports whether a payment in status was paid, whatever was refunded of it since
+func IsPaid(status string) bool {
+	return status == PaymentStatusCompleted || status == PaymentStatusPartiallyRefunded || status == PaymentStatusRefunded
+}
+
+// IsRefundable reports whether a payment in status can be refunded (further)
+func IsRefundable(status string) bool {
+	return status == PaymentStatusCompleted || status == PaymentStatusPartiallyRefunded
+}
+
+// Refundable — what is left to refund of the payment
+func (p *Payment) Refundable() money.Money {
+	return p.Amount.Sub(p.Refunded)
+}
+
+// ReserveRefund counts amount toward the refunded total of the payment, before the
+// refund is sent, so concurrent refunds cannot exceed the amount together
+func (p *Payment) ReserveRefund(amount money.Money, now time.Time) error {
+	if !amount.IsPositive() || !amount.SameCurrency(p.Amount) {
+		return ErrInvalidAmount
+	}
+	if !IsRefundable(p.Status) {
+		return ErrRefundNotAllowed
+	}
+	if amount.Cmp(p.Refundable()) > 0 {
+		return ErrRefundExceedsBalance
+	}
+	p.setRefunded(p.Refunded.Add(amount), now)
+	return nil
+}
+
+// ReleaseRefund takes the amount of a failed refund off the refunded total
+func (p *Payment) ReleaseRefund(amount money.Money, now time.Time) {
+	p.setRefunded(p.Refunded.Sub(amount), now)
+}
+
+// setRefunded sets the refunded total and the status it gives the payment
+func (p *Payment) setRefunded(refunded money.Money, now time.Time) {
+	p.Refunded = refunded
+	switch {
+	case refunded.IsZero():
+		p.Status = PaymentStatusCompleted
+		p.RefundedAt = nil
+	case refunded.Cmp(p.Amount) < 0:
+		p.Status = PaymentStatusPartiallyRefunded
+		p.RefundedAt = nil
+	default:
+		p.Status = PaymentStatusRefunded
+		p.RefundedAt = &now
+	}
+}
+
+// MatchRefund finds the refund a provider's refund event is about: by the provider's
+// refund ID when the event has one, else by amount, preferring a refund still pending.
+// A refund whose call timed out has no ID yet and is matched by amount too. Wallet
+// refunds never reach a provider; failed refunds are over. Nil when the refund was
+// made at the provider (e.g. in its dashboard).
+func MatchRefund(refunds []*Refund, externalID string, amount money.Money) *Refund {
+	if externalID != "" {
+		for _, r := range refunds {
+			if r.ExternalID == externalID && !r.ToWallet {
+				return r
+			}
+		}
+	}
+	var settled *Refund
+	for _, r := range refunds {
+		if r.ToWallet || r.Status == RefundStatusFailed || r.Amount != amount {
+			continue
+		}
+		if externalID != "" && (r.ExternalID != "" || r.Status != RefundStatusPending) {
+			continue
+		}
+		if r.Status == RefundStatusPending {
+			return r
+		}
+		if settled == nil {
+			settled = r
+		}
+	}
+	return settled
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+func TestPayment_ReserveRefund(t *testing.T) {
+	now := time.Now()
+	p := &Payment{Amount: rub(50000), Currency: money.RUB, Status: PaymentStatusCompleted, Refunded: rub(0)}
+
+	steps := []struct {
+		amount   money.Money
+		err      error
+		status   string
+		refunded int64
+	}{
+		{rub(20000), nil, PaymentStatusPartiallyRefunded, 20000},
+		{rub(0), ErrInvalidAmount, PaymentStatusPartiallyRefunded, 20000},
+		{money.New(100, "USD"), ErrInvalidAmount, PaymentStatusPartiallyRefunded, 20000},
+		{rub(30001), ErrRefundExceedsBalance, PaymentStatusPartiallyRefunded, 20000},
+		{rub(10000), nil, PaymentStatusPartiallyRefunded, 30000},
+		{rub(20000), nil, PaymentStatusRefunded, 50000},
+		{rub(1), ErrRefundNotAllowed, PaymentStatusRefunded, 50000},
+	}
+	for i, s := range steps {
+		if err := p.ReserveRefund(s.amount, now); err != s.err {
+			t.Errorf("step %d: %v, want %v", i+1, err, s.err)
+		}
+		if p.Status != s.status || p.Refunded.Minor != s.refunded {
+			t.Errorf("step %d: %s, refunded %s", i+1, p.Status, p.Refunded)
+		}
+	}
+	if p.RefundedAt == nil || !p.Refundable().IsZero() {
+		t.Errorf("fully refunded: %+v", p)
+	}
+
+	// A failed refund makes its amount refundable again
+	p.ReleaseRefund(rub(20000), now)
+	if p.Status != PaymentStatusPartiallyRefunded || p.RefundedAt != nil || p.Refundable() != rub(20000) {
+		t.Errorf("after a release: %+v", p)
+	}
+	p.ReleaseRefund(rub(30000), now)
+	if p.Status != PaymentStatusCompleted || !p.Refunded.IsZero() {
+		t.Errorf("every refund failed: %+v", p)
+	}
+
+	pending := &Payment{Amount: rub(50000), Status: PaymentStatusPending}
+	if err := pending.ReserveRefund(rub(100), now); err != ErrRefundNotAllowed {
+		t.Errorf("unpaid payment: %v", err)
+	}
+}
+
+func TestMatchRefund(t *testing.T) {
+	refunds := []*Refund{
+		{ID: "wallet", Amount: rub(10000), ToWallet: true, Status: RefundStatusSucceeded},
+		{ID: "failed", Amount: rub(10000), Status: RefundStatusFailed},
+		{ID: "done", Amount: rub(10000), ExternalID: "re-1", Status: RefundStatusSucceeded},
+		{ID: "sent", Amount: rub(10000), ExternalID: "re-2", Status: RefundStatusPending},
+		{ID: "timed-out", Amount: rub(10000), Status: RefundStatusPending},
+		{ID: "small", Amount: rub(500), Status: RefundStatusSucceeded},
+	}
+	cases := []struct {
+		name       string
+		externalID string
+		amount     money.Money
+		want       string
+	}{
+		{"by refund id", "re-2", rub(10000), "sent"},
+		{"settled by refund id", "re-1", rub(10000), "done"},
+		{"unknown refund id: pending without one", "re-9", rub(10000), "timed-out"},
+		{"by amount, pending first", "", rub(10000), "sent"},
+		{"by amount, settled", "", rub(500), "small"},
+		{"made at the provider", "", rub(700), ""},
+		{"made at the provider, with its id", "re-9", rub(500), ""},
+	}
+	for _, c := range cases {
+		got := ""
+		if r := MatchRefund(refunds, c.externalID, c.amount); r != nil {
+			got = r.ID
+		}
+		if got != c.want {
+			t.Errorf("%s: %q, want %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+// Routing strategies: how the card providers of a rule are ordered
+const (
+	RoutePriority = "priority" // as listed (default)
+	RouteCost     = "cost"     // lowest fee for the amount first
+	RouteHealth   = "health"   // highest health score first
+)
+
+// Circuit breaker states of a gateway
+const (
+	CircuitClosed   = "closed"    // calls go through
+	CircuitOpen     = "open"      // calls fail fast until the cool-down ends
+	CircuitHalfOpen = "half_open" // one probe call decides whether it closes again
+)
+
+// Routing errors
+var (
+	ErrInvalidRoutingRule  = errors.New("routing rule needs card providers, a valid strategy and amount bounds")
+	ErrRoutingRuleNotFound = errors.New("routing rule not found")
+	ErrRoutingRuleExists   = errors.New("routing rule with this name already exists")
+	ErrNoCardProvider      = errors.New("no card provider available")
+)
+
+// IsCardProvider reports whether the provider takes card payments through a gateway
+func IsCardProvider(p string) bool {
+	return p == ProviderTinkoff || p == ProviderYooMoney || p == ProviderSber
+}
+
+// RoutingRule — which card providers take a payment, in what order. Rules are checked
+// by Priority (lowest first); the first one matching the amount and card brand applies.
+// Empty bounds and brand match any.
+type RoutingRule struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Priority  int          `json:"priority"`
+	MinAmount *money.Money `json:"min_amount,omitempty"` // inclusive
+	MaxAmount *money.Money `json:"max_amount,omitempty"` // exclusive
+	CardBrand string       `json:"card_brand,omitempty"` // visa | mastercard | mir
+	Providers []string     `json:"providers"`
+	Strategy  string       `json:"strategy"` // priority | cost | health
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// Validate checks providers, strategy and bounds; an empty strategy is priority
+func (r *RoutingRule) Validate() error {
+	if r.Strategy == "" {
+		r.Strategy = RoutePriority
+	}
+	if len(r.Providers) == 0 || !validStrategy(r.Strategy) {
+		return ErrInvalidRoutingRule
+	}
+	seen := make(map[string]bool, len(r.Providers))
+	for _, p := range r.Providers {
+		if !IsCardProvider(p) || seen[p] {
+			return ErrInvalidRoutingRule
+		}
+		seen[p] = true
+	}
+	if (r.MinAmount != nil && r.MinAmount.IsNegative()) ||
+		(r.MinAmount != nil && r.MaxAmount != nil && r.MinAmount.Cmp(*r.MaxAmount) >= 0) {
+		return ErrInvalidRoutingRule
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to a payment of the amount by a card of the
+// brand; a rule for a brand never matches an unknown one
+func (r *RoutingRule) Matches(amount money.Money, brand string) bool {
+	if r.MinAmount != nil && amount.Minor < r.MinAmount.Minor {
+		return false
+	}
+	if r.MaxAmount != nil && amount.Minor >= r.MaxAmount.Minor {
+		return false
+	}
+	return r.CardBrand == "" || r.CardBrand == brand
+}
+
+// MatchRoutingRule returns the first matching rule by priority, nil when none does
+func MatchRoutingRule(rules []*RoutingRule, amount money.Money, brand string) *RoutingRule {
+	var best *RoutingRule
+	for _, r := range rules {
+		if r.Matches(amount, brand) && (best == nil || r.Priority < best.Priority) {
+			best = r
+		}
+	}
+	return best
+}
+
+func validStrategy(s string) bool {
+	return s == RoutePriority || s == RouteCost || s == RouteHealth
+}
+
+// ProviderFee — what a provider charges per card payment
+type ProviderFee struct {
+	Rate  money.Rate  `json:"rate"`  // percent of the amount
+	Fixed money.Money `json:"fixed"` // on top, per payment
+}
+
+// Of returns the fee on the amount
+func (f ProviderFee) Of(amount money.Money) money.Money {
+	return f.Rate.Of(amount).Add(f.Fixed.In(amount.Currency))
+}
+
+// GatewayHealth — a card gateway as its circuit breaker sees it. Score is the
+// exponentially weighted share of successful calls, 1 for a gateway never called.
+// Declines count as successes: the provider answered.
+type GatewayHealth struct {
+	Provider            string     `json:"provider"`
+	State               string     `json:"state"` // closed | open | half_open
+	Score               float64    `json:"score"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Calls               int64      `json:"calls"`
+	Failures            int64      `json:"failures"`
+	LatencyMS           float64    `json:"latency_ms"` // weighted like Score
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	OpenUntil           *time.Time `json:"open_until,omitempty"`
+}
+
+// RoutingPolicy — routing when no rule matches, and what rules are ordered by
+type RoutingPolicy struct {
+	Providers []string               // default order of card providers
+	Strategy  string                 // default strategy
+	Fees      map[string]ProviderFee // by provider; providers without a fee sort last by cost
+	Failover  bool                   // try the next provider when one is unavailable
+}
+
+// RouteAttempt — a provider a payment was sent to
+type RouteAttempt struct {
+	Provider string    `json:"provider"`
+	Error    string    `json:"error,omitempty"` // empty: the provider took the payment
+	At       time.Time `json:"at"`
+}
+
+// RoutingDecision — why a card payment went to its provider; recorded on the payment
+type RoutingDecision struct {
+	RuleID     string         `json:"rule_id,omitempty"` // empty: the default policy
+	Rule       string         `json:"rule,omitempty"`
+	Strategy   string         `json:"strategy"`
+	CardBrand  string         `json:"card_brand,omitempty"`
+	Requested  string         `json:"requested,omitempty"` // provider asked for by the client
+	SavedCard  bool           `json:"saved_card,omitempty"`
+	Candidates []string       `json:"candidates"` // in the order they are tried
+	Attempts   []RouteAttempt `json:"attempts,omitempty"`
+}
+
+// Record adds an attempt at the provider; a nil err means the provider took the payment
+func (d *RoutingDecision) Record(provider string, err error, at time.Time) {
+	a := RouteAttempt{Provider: provider, At: at}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	d.Attempts = append(d.Attempts, a)
+}
+
+// Route orders the available card providers for a payment: those of the rule (else the
+// policy's) by the strategy, a requested provider first, providers whose circuit is open
+// last. A saved card is bound to its provider, so only that one is a candidate; without
+// failover only the first is.
+func Route(policy RoutingPolicy, rule *RoutingRule, amount money.Money, brand, requested string, savedCard bool, available []string, health map[string]GatewayHealth) *RoutingDecision {
+	d := &RoutingDecision{Strategy: policy.Strategy, CardBrand: brand, Requested: requested, SavedCard: savedCard}
+	providers := policy.Providers
+	if rule != nil {
+		d.RuleID, d.Rule, d.Strategy = rule.ID, rule.Name, rule.Strategy
+		providers = rule.Providers
+	}
+	if d.Strategy == "" {
+		d.Strategy = RoutePriority
+	}
+	if savedCard {
+		d.Candidates = []string{requested}
+		return d
+	}
+
+	registered := make(map[string]bool, len(available))
+	for _, p := range available {
+		registered[p] = IsCardProvider(p)
+	}
+	var candidates []string
+	for _, p := range providers {
+		if registered[p] {
+			candidates = append(candidates, p)
+		}
+	}
+
+	switch d.Strategy {
+	case RouteCost:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			fi, oki := policy.Fees[candidates[i]]
+			fj, okj := policy.Fees[candidates[j]]
+			if oki != okj {
+				return oki
+			}
+			return oki && fi.Of(amount).Cmp(fj.Of(amount)) < 0
+		})
+	case RouteHealth:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return score(health, candidates[i]) > score(health, candidates[j])
+		})
+	}
+
+	if requested != "" && registered[requested] {
+		ordered := []string{requested}
+		for _, p := range candidates {
+			if p != requested {
+				ordered = append(ordered, p)
+			}
+		}
+		candidates = ordered
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return !isOpen(health, candidates[i]) && isOpen(health, candidates[j])
+	})
+
+	if !policy.Failover && len(candidates) > 1 {
+		candidates = candidates[:1]
+	}
+	d.Candidates = candidates
+	return d
+}
+
+func score(health map[string]GatewayHealth, provider string) float64 {
+	if h, ok := health[provider]; ok {
+		return h.Score
+	}
+	return 1
+}
+
+func isOpen(health map[string]GatewayHealth, provider string) bool {
+	return health[provider].State == CircuitOpen
+}
@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+func TestRoutingRule(t *testing.T) {
+	low, high := rub(100000), rub(500000)
+	rules := []*RoutingRule{
+		{ID: "large", Priority: 20, MinAmount: &high, Providers: []string{ProviderSber}},
+		{ID: "mir", Priority: 10, CardBrand: "mir", Providers: []string{ProviderSber, ProviderTinkoff}},
+		{ID: "medium", Priority: 30, MinAmount: &low, MaxAmount: &high, Providers: []string{ProviderYooMoney}},
+	}
+	cases := []struct {
+		amount money.Money
+		brand  string
+		want   string
+	}{
+		{rub(99999), "", ""},
+		{rub(100000), "visa", "medium"},
+		{rub(499999), "", "medium"},
+		{rub(500000), "", "large"},
+		{rub(500000), "mir", "mir"}, // priority outranks the amount
+		{rub(100), "mir", "mir"},
+	}
+	for _, c := range cases {
+		got := ""
+		if r := MatchRoutingRule(rules, c.amount, c.brand); r != nil {
+			got = r.ID
+		}
+		if got != c.want {
+			t.Errorf("%s %q: rule %q, want %q", c.amount, c.brand, got, c.want)
+		}
+	}
+
+	valid := RoutingRule{Providers: []string{ProviderTinkoff}}
+	if err := valid.Validate(); err != nil || valid.Strategy != RoutePriority {
+		t.Errorf("valid rule: %v, strategy %q", err, valid.Strategy)
+	}
+	for name, r := range map[string]RoutingRule{
+		"no providers":  {},
+		"cash":          {Providers: []string{ProviderCash}},
+		"duplicate":     {Providers: []string{ProviderSber, ProviderSber}},
+		"strategy":      {Providers: []string{ProviderSber}, Strategy: "random"},
+		"empty range":   {Providers: []string{ProviderSber}, MinAmount: &high, MaxAmount: &low},
+		"negative from": {Providers: []string{ProviderSber}, MinAmount: &money.Money{Minor: -1, Currency: money.RUB}},
+	} {
+		if err := r.Validate(); err != ErrInvalidRoutingRule {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestRoute(t *testing.T) {
+	all := []string{ProviderCash, ProviderTinkoff, ProviderYooMoney, ProviderSber}
+	policy := RoutingPolicy{
+		Providers: []string{ProviderTinkoff, ProviderYooMoney, ProviderSber},
+		Fees: map[string]ProviderFee{
+			ProviderTinkoff:  {Rate: 250},                       // 2.5%
+			ProviderYooMoney: {Rate: 200, Fixed: rub(1500)},     // 2% + 15 ₽
+			ProviderSber:     {Rate: 300, Fixed: money.Money{}}, // 3%
+		},
+		Failover: true,
+	}
+	healthy := map[string]GatewayHealth{}
+
+	cases := []struct {
+		name      string
+		policy    func(p RoutingPolicy) RoutingPolicy
+		rule      *RoutingRule
+		amount    money.Money
+		requested string
+		available []string
+		health    map[string]GatewayHealth
+		want      []string
+	}{
+		{name: "policy order", want: []string{ProviderTinkoff, ProviderYooMoney, ProviderSber}},
+		{name: "unregistered skipped", available: []string{ProviderSber, ProviderTinkoff}, want: []string{ProviderTinkoff, ProviderSber}},
+		{name: "requested first", requested: ProviderSber, want: []string{ProviderSber, ProviderTinkoff, ProviderYooMoney}},
+		{name: "rule", rule: &RoutingRule{ID: "r1", Providers: []string{ProviderYooMoney, ProviderSber}},
+			want: []string{ProviderYooMoney, ProviderSber}},
+		{name: "cheapest for small amounts", rule: &RoutingRule{Providers: policy.Providers, Strategy: RouteCost},
+			amount: rub(100000), want: []string{ProviderTinkoff, ProviderSber, ProviderYooMoney}}, // 25, 30, 35 ₽
+		{name: "cheapest for large amounts", rule: &RoutingRule{Providers: policy.Providers, Strategy: RouteCost},
+			amount: rub(1000000), want: []string{ProviderYooMoney, ProviderTinkoff, ProviderSber}}, // 215, 250, 300 ₽
+		{name: "without a fee last", policy: func(p RoutingPolicy) RoutingPolicy {
+			p.Strategy, p.Fees = RouteCost, map[string]ProviderFee{ProviderSber: {Rate: 100}}
+			return p
+		}, want: []string{ProviderSber, ProviderTinkoff, ProviderYooMoney}},
+		{name: "healthiest first", policy: func(p RoutingPolicy) RoutingPolicy { p.Strategy = RouteHealth; return p },
+			health: map[string]GatewayHealth{ProviderTinkoff: {Score: 0.4}, ProviderYooMoney: {Score: 0.9}},
+			want:   []string{ProviderSber, ProviderYooMoney, ProviderTinkoff}},
+		{name: "open circuit last, even when requested", requested: ProviderTinkoff,
+			health: map[string]GatewayHealth{ProviderTinkoff: {State: CircuitOpen}, ProviderYooMoney: {State: CircuitHalfOpen}},
+			want:   []string{ProviderYooMoney, ProviderSber, ProviderTinkoff}},
+		{name: "no failover", policy: func(p RoutingPolicy) RoutingPolicy { p.Failover = false; return p },
+			health: map[string]GatewayHealth{ProviderTinkoff: {State: CircuitOpen}}, want: []string{ProviderYooMoney}},
+		{name: "none registered", available: []string{ProviderCash}, want: nil},
+	}
+	for _, c := range cases {
+		p := policy
+		if c.policy != nil {
+			p = c.policy(p)
+		}
+		available, health, amount := all, healthy, c.amount
+		if c.available != nil {
+			available = c.available
+		}
+		if c.health != nil {
+			health = c.health
+		}
+		if amount.Currency == "" {
+			amount = rub(50000)
+		}
+		d := Route(p, c.rule, amount, "visa", c.requested, false, available, health)
+		if !reflect.DeepEqual(d.Candidates, c.want) {
+			t.Errorf("%s: %v, want %v", c.name, d.Candidates, c.want)
+		}
+	}
+
+	rule := &RoutingRule{ID: "r1", Name: "yoomoney first", Strategy: RouteHealth, Providers: []string{ProviderYooMoney}}
+	d := Route(policy, rule, rub(50000), "mir", "", false, all, healthy)
+	if d.RuleID != "r1" || d.Rule != "yoomoney first" || d.Strategy != RouteHealth || d.CardBrand != "mir" {
+		t.Errorf("decision: %+v", d)
+	}
+	saved := Route(policy, nil, rub(50000), "visa", ProviderSber, true, all,
+		map[string]GatewayHealth{ProviderSber: {State: CircuitOpen}})
+	if !reflect.DeepEqual(saved.Candidates, []string{ProviderSber}) || !saved.SavedCard || saved.Strategy != RoutePriority {
+		t.Errorf("a saved card stays with its provider: %+v", saved)
+	}
+}
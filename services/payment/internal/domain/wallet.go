@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+// Platform accounts funding wallet credits
+const (
+	AccountReferralBudget = "platform:referrals"    // referral bonuses
+	AccountCompensation   = "platform:compensation" // goodwill credits after bad rides
+)
+
+// Journal entry kinds of the wallet
+const (
+	EntryWalletTopUp        = "wallet.topup"        // reference: top-up payment id
+	EntryWalletReferral     = "wallet.referral"     // reference: caller's id of the referral
+	EntryWalletCompensation = "wallet.compensation" // reference: caller's id of the case
+)
+
+// Wallet credit kinds
+const (
+	CreditReferral     = "referral"
+	CreditCompensation = "compensation"
+)
+
+// Wallet errors
+var (
+	ErrInsufficientFunds = errors.New("insufficient wallet balance")
+	ErrWalletCurrency    = errors.New("wallet holds a different currency")
+	ErrInvalidCredit     = errors.New("credit needs a kind (referral or compensation), a positive amount and a reference")
+	ErrCreditExists      = errors.New("credit with this reference already booked")
+)
+
+// WalletAccount — the passenger's wallet; credited by top-ups, refunds and credits,
+// debited by rides paid from the balance
+func WalletAccount(userID string) string { return AccountTypeWallet + ":" + userID }
+
+// Wallet — the passenger's in-app balance. The ledger is the source of truth; the
+// wallet row is its projection, locked while a spend is booked.
+type Wallet struct {
+	UserID    string      `json:"user_id"`
+	Balance   money.Money `json:"balance"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// WalletTransaction — one change of a wallet balance: the wallet's side of a journal entry
+type WalletTransaction struct {
+	ID           string      `json:"id"`
+	EntryID      string      `json:"entry_id"`
+	Kind         string      `json:"kind"`      // entry kind: wallet.topup, payment.captured, payment.refunded, ...
+	Reference    string      `json:"reference"` // entry reference
+	Amount       money.Money `json:"amount"`    // positive: into the wallet
+	BalanceAfter money.Money `json:"balance_after"`
+	Memo         string      `json:"memo,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+}
+
+// WalletStatement — balance and its history, newest first
+type WalletStatement struct {
+	Wallet       *Wallet              `json:"wallet"`
+	Transactions []*WalletTransaction `json:"transactions"`
+}
+
+// WalletCredit — money the platform puts on a wallet. Reference identifies the cause
+// (referral, support case) so a repeated credit books once.
+type WalletCredit struct {
+	UserID    string      `json:"user_id"`
+	Kind      string      `json:"kind"` // referral | compensation
+	Amount    money.Money `json:"amount"`
+	Reference string      `json:"reference"`
+	Reason    string      `json:"reason,omitempty"`
+}
+
+// Validate checks kind, amount and reference
+func (c *WalletCredit) Validate() error {
+	if c.UserID == "" || c.Reference == "" || !c.Amount.IsPositive() {
+		return ErrInvalidCredit
+	}
+	if c.Kind != CreditReferral && c.Kind != CreditCompensation {
+		return ErrInvalidCredit
+	}
+	return nil
+}
+
+// Entry books the credit from the platform account funding its kind
+func (c *WalletCredit) Entry() *JournalEntry {
+	kind, source := EntryWalletReferral, AccountReferralBudget
+	if c.Kind == CreditCompensation {
+		kind, source = EntryWalletCompensation, AccountCompensation
+	}
+	e := transfer(kind, c.Reference, source, WalletAccount(c.UserID), c.Amount)
+	e.Memo = c.Reason
+	return e
+}
+
+// WalletMismatch — a wallet whose stored balance differs from its ledger account
+type WalletMismatch struct {
+	UserID string      `json:"user_id"`
+	Wallet money.Money `json:"wallet"` // zero when the ledger account has no wallet row
+	Ledger money.Money `json:"ledger"`
+}
+
+// WalletReconciliation — stored wallet balances checked against the ledger
+type WalletReconciliation struct {
+	Wallets    int              `json:"wallets"`
+	Mismatches []WalletMismatch `json:"mismatches"`
+	Balanced   bool             `json:"balanced"`
+}
+
+// ReconcileWallets compares wallets with the balances of wallet:<user> accounts. A
+// wallet account is credited when money comes in, so the wallet holds -Balance.
+func ReconcileWallets(wallets []*Wallet, accounts []AccountBalance) *WalletReconciliation {
+	ledger := map[string]money.Money{}
+	for _, a := range accounts {
+		ledger[a.Account+"/"+a.Balance.Currency] = a.Balance.Neg()
+	}
+	rec := &WalletReconciliation{Wallets: len(wallets), Mismatches: []WalletMismatch{}}
+	for _, w := range wallets {
+		key := WalletAccount(w.UserID) + "/" + w.Balance.Currency
+		held, ok := ledger[key]
+		if !ok {
+			held = money.New(0, w.Balance.Currency)
+		}
+		delete(ledger, key)
+		if held.Cmp(w.Balance) != 0 {
+			rec.Mismatches = append(rec.Mismatches, WalletMismatch{UserID: w.UserID, Wallet: w.Balance, Ledger: held})
+		}
+	}
+	for _, a := range accounts {
+		held, ok := ledger[a.Account+"/"+a.Balance.Currency]
+		if !ok || held.IsZero() {
+			continue
+		}
+		userID := strings.TrimPrefix(a.Account, AccountTypeWallet+":")
+		rec.Mismatches = append(rec.Mismatches, WalletMismatch{UserID: userID, Wallet: money.New(0, held.Currency), Ledger: held})
+	}
+	rec.Balanced = len(rec.Mismatches) == 0
+	return rec
+}
@@ -0,0 +1,85 @@
+package domain
+
+import "testing"
+
+func TestWalletEntries(t *testing.T) {
+	topUp := &Payment{ID: "pay-1", UserID: "u1", Purpose: PurposeWalletTopUp, Provider: ProviderYooMoney, Amount: rub(100000)}
+	e := CaptureEntry(topUp)
+	if e.Kind != EntryWalletTopUp || e.Postings[0].Account != "clearing:yoomoney" ||
+		e.Postings[1].Account != "wallet:u1" || e.Postings[1].Amount.Minor != -100000 {
+		t.Errorf("top-up must credit the wallet: %+v", e)
+	}
+	if e := RefundEntry(topUp, rub(40000)); e.Postings[0].Account != "wallet:u1" || e.Postings[1].Account != "clearing:yoomoney" {
+		t.Errorf("refunded top-up must leave the wallet: %+v", e)
+	}
+
+	ride := &Payment{ID: "pay-2", UserID: "u1", Purpose: PurposeRide, Method: MethodWallet, Provider: ProviderWallet, Amount: rub(45000)}
+	e = CaptureEntry(ride)
+	if e.Kind != EntryPaymentCaptured || e.Postings[0].Account != "wallet:u1" || e.Postings[1].Account != "passenger:u1" {
+		t.Errorf("wallet ride must be paid from the wallet: %+v", e)
+	}
+	if e := RefundEntry(ride, rub(45000)); e.Postings[1].Account != "wallet:u1" {
+		t.Errorf("wallet ride refund must go back to the wallet: %+v", e)
+	}
+
+	card := &Payment{ID: "pay-3", UserID: "u1", Provider: ProviderTinkoff, Amount: rub(30000)}
+	e = RefundToWalletEntry(card, rub(10000))
+	if err := e.Validate(); err != nil || e.Postings[0].Account != "passenger:u1" || e.Postings[1].Account != "wallet:u1" {
+		t.Errorf("card refund to wallet: %+v %v", e, err)
+	}
+}
+
+func TestWalletCredit(t *testing.T) {
+	c := &WalletCredit{UserID: "u1", Kind: CreditCompensation, Amount: rub(20000), Reference: "case-7", Reason: "driver no-show"}
+	if err := c.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	e := c.Entry()
+	if e.Kind != EntryWalletCompensation || e.Reference != "case-7" || e.Memo != "driver no-show" ||
+		e.Postings[0].Account != AccountCompensation || e.Postings[1].Account != "wallet:u1" {
+		t.Errorf("compensation entry: %+v", e)
+	}
+	if e := (&WalletCredit{UserID: "u1", Kind: CreditReferral, Amount: rub(1), Reference: "ref-1"}).Entry(); e.Postings[0].Account != AccountReferralBudget {
+		t.Errorf("referral must be funded by the referral budget: %+v", e)
+	}
+
+	for name, bad := range map[string]*WalletCredit{
+		"no reference": {UserID: "u1", Kind: CreditReferral, Amount: rub(100)},
+		"zero amount":  {UserID: "u1", Kind: CreditReferral, Amount: rub(0), Reference: "r"},
+		"unknown kind": {UserID: "u1", Kind: "gift", Amount: rub(100), Reference: "r"},
+	} {
+		if bad.Validate() != ErrInvalidCredit {
+			t.Errorf("%s: expected ErrInvalidCredit", name)
+		}
+	}
+}
+
+func TestReconcileWallets(t *testing.T) {
+	wallets := []*Wallet{
+		{UserID: "u1", Balance: rub(5000)},
+		{UserID: "u2", Balance: rub(7000)},
+		{UserID: "u3", Balance: rub(0)},
+	}
+	accounts := []AccountBalance{
+		{Account: "wallet:u1", Balance: rub(-5000)},
+		{Account: "wallet:u2", Balance: rub(-6000)},
+		{Account: "wallet:u4", Balance: rub(-100)},
+	}
+	rec := ReconcileWallets(wallets, accounts)
+	if rec.Balanced || rec.Wallets != 3 || len(rec.Mismatches) != 2 {
+		t.Fatalf("expected u2 and u4 to mismatch: %+v", rec)
+	}
+	if m := rec.Mismatches[0]; m.UserID != "u2" || m.Wallet.Minor != 7000 || m.Ledger.Minor != 6000 {
+		t.Errorf("u2: %+v", m)
+	}
+	if m := rec.Mismatches[1]; m.UserID != "u4" || !m.Wallet.IsZero() || m.Ledger.Minor != 100 {
+		t.Errorf("ledger account without a wallet: %+v", m)
+	}
+
+	if rec := ReconcileWallets(wallets[:1], accounts[:1]); !rec.Balanced {
+		t.Errorf("matching wallet must reconcile: %+v", rec)
+	}
+	if rec := ReconcileWallets(nil, nil); !rec.Balanced || rec.Mismatches == nil {
+		t.Errorf("no wallets: %+v", rec)
+	}
+}
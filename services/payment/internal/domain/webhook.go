@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+// Webhook inbox statuses
+const (
+	WebhookPending   = "pending"   // stored, waiting for its (next) processing attempt
+	WebhookProcessed = "processed" // applied to its payment
+	WebhookFailed    = "failed"    // attempts exhausted: waits for an admin replay
+	WebhookRejected  = "rejected"  // signature did not verify or body unreadable: never applied
+)
+
+// Webhook inbox errors
+var (
+	ErrWebhookNotFound      = errors.New("webhook not found")
+	ErrWebhookNotReplayable = errors.New("only failed webhooks can be replayed")
+)
+
+// InboxWebhook — a provider callback as received, persisted before it is applied
+type InboxWebhook struct {
+	ID                string            `json:"id"`
+	Provider          string            `json:"provider"`
+	EventID           string            `json:"event_id"` // deduplication key within the provider
+	Headers           map[string]string `json:"headers"`
+	Body              string            `json:"body"`
+	SignatureVerified bool              `json:"signature_verified"`
+	EventType         string            `json:"event_type,omitempty"`
+	PaymentID         string            `json:"payment_id,omitempty"`  // ours, from the provider's metadata
+	ExternalID        string            `json:"external_id,omitempty"` // provider's payment ID
+	RefundID          string            `json:"refund_id,omitempty"`   // provider's refund ID, for refund events
+	Amount            money.Money       `json:"amount"`                // refund events: the amount refunded
+	Status            string            `json:"status"`
+	Attempts          int               `json:"attempts"`
+	LastError         string            `json:"last_error,omitempty"`
+	NextAttemptAt     time.Time         `json:"next_attempt_at"`
+	ProcessedAt       *time.Time        `json:"processed_at,omitempty"`
+	CreatedAt         time.Time         `json:"created_at"`
+}
+
+// NewInboxWebhook builds the inbox record of a parsed event; an event without a
+// provider identity is deduplicated by its body. A refund event without one is kept
+// per delivery: a second partial refund of the same amount repeats the body, so
+// redeliveries are told apart when applied, by the refunds already booked.
+func NewInboxWebhook(event *WebhookEvent, headers map[string]string, body []byte) *InboxWebhook {
+	eventID := event.EventID
+	if eventID == "" {
+		eventID = BodyEventID(body)
+		if event.EventType == "refund.succeeded" {
+			eventID += ":" + deliveryID()
+		}
+	}
+	return &InboxWebhook{
+		Provider:          event.Provider,
+		EventID:           eventID,
+		Headers:           headers,
+		Body:              string(body),
+		SignatureVerified: event.SignatureVerified,
+		EventType:         event.EventType,
+		PaymentID:         event.PaymentID,
+		ExternalID:        event.ExternalID,
+		RefundID:          event.RefundID,
+		Amount:            event.Amount,
+		Status:            WebhookPending,
+	}
+}
+
+// RejectedWebhook builds the inbox record of a callback that cannot be trusted or read
+func RejectedWebhook(provider string, headers map[string]string, body []byte, reason error) *InboxWebhook {
+	return &InboxWebhook{
+		Provider:  provider,
+		EventID:   BodyEventID(body),
+		Headers:   headers,
+		Body:      string(body),
+		Status:    WebhookRejected,
+		LastError: reason.Error(),
+	}
+}
+
+// BodyEventID — deduplication key derived from the raw body
+func BodyEventID(body []byte) string {
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// deliveryID tells apart deliveries of one body
+func deliveryID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// WebhookRun — what one pass of the inbox worker did
+type WebhookRun struct {
+	Processed int `json:"processed"`
+	Retrying  int `json:"retrying"`
+	Failed    int `json:"failed"`
+}
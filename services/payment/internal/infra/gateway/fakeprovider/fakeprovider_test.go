@@ -0,0 +1,278 @@
+package fakeprovider_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+	"github.com/ridehail/payment/internal/infra/gateway/fakeprovider"
+)
+
+// start runs the fake with credentials and a receiver that acknowledges every callback
+func start(t *testing.T) *fakeprovider.Server {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+	t.Cleanup(receiver.Close)
+	return fakeprovider.Start(t, fakeprovider.Config{
+		TinkoffTerminalKey:    "terminal",
+		TinkoffPassword:       "tinkoff-secret",
+		YooMoneyShopID:        "shop",
+		YooMoneySecretKey:     "yoo-secret",
+		YooMoneyWebhookSecret: "yoo-webhook-secret",
+		YooMoneyWebhookURL:    receiver.URL + "/webhooks/yoomoney",
+		SberUserName:          "merchant-api",
+		SberPassword:          "sber-secret",
+		SberCallbackURL:       receiver.URL + "/webhooks/sber",
+	})
+}
+
+// events parses the callbacks delivered for a payment with the provider's gateway
+func events(t *testing.T, fake *fakeprovider.Server, gw gateway.Gateway, externalID string) []*domain.WebhookEvent {
+	t.Helper()
+	var out []*domain.WebhookEvent
+	for _, cb := range fake.Callbacks() {
+		if cb.Provider != gw.Provider() || cb.PaymentID != externalID {
+			continue
+		}
+		if cb.Status != http.StatusOK {
+			t.Fatalf("callback not delivered: %+v", cb)
+		}
+		event, err := gw.ParseWebhook(context.Background(), []byte(cb.Body), cb.Header.Get("X-YooKassa-Signature"))
+		if err != nil || !event.SignatureVerified {
+			t.Fatalf("callback %s not verified by the gateway: %v", cb.Body, err)
+		}
+		out = append(out, event)
+	}
+	return out
+}
+
+func eventTypes(events []*domain.WebhookEvent) []string {
+	types := make([]string, len(events))
+	for i, e := range events {
+		types[i] = e.EventType
+	}
+	return types
+}
+
+func TestTinkoff(t *testing.T) {
+	fake := start(t)
+	ctx := context.Background()
+	gw := gateway.NewTinkoffGateway(gateway.TinkoffConfig{TerminalKey: "terminal", Password: "tinkoff-secret",
+		APIURL: fake.TinkoffURL(), NotifyURL: fake.TinkoffURL() + "/../../_fake/payments"})
+
+	// Checkout: the payer pays on the payment page, the card is saved
+	res, err := gw.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p1", Amount: money.New(50000, money.RUB),
+		Description: "Ride", SaveCard: true, Metadata: map[string]string{"user_id": "u1"}, UserEmail: "a@b.c"})
+	if err != nil || !res.RequiresRedirect || res.Status != domain.PaymentStatusPending {
+		t.Fatalf("Init = %+v, %v", res, err)
+	}
+	if err := fake.Pay(domain.ProviderTinkoff, res.ExternalID); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := gw.GetPaymentStatus(ctx, res.ExternalID); err != nil || status != domain.PaymentStatusCompleted {
+		t.Errorf("GetState = %s, %v", status, err)
+	}
+	card, err := gw.GetSavedCard(ctx, res.ExternalID)
+	if err != nil || card == nil || card.TokenID == "" || card.Last4 != "0777" {
+		t.Fatalf("saved card = %+v, %v", card, err)
+	}
+
+	// Partial refund
+	refund, err := gw.Refund(ctx, gateway.RefundInput{PaymentID: "p1", ExternalID: res.ExternalID, Amount: money.New(20000, money.RUB)})
+	if err != nil || refund.Amount != money.New(20000, money.RUB) || refund.Status != domain.RefundStatusSucceeded {
+		t.Errorf("Cancel (refund) = %+v, %v", refund, err)
+	}
+	if p, _ := fake.Payment(domain.ProviderTinkoff, res.ExternalID); p.Refunded != 20000 || p.Captured != 50000 {
+		t.Errorf("fake payment after refund: %+v", p)
+	}
+
+	// Two-stage with the saved card: hold, capture less, and a second hold voided
+	held, err := gw.Authorize(ctx, gateway.CreatePaymentInput{PaymentID: "p2", Amount: money.New(60000, money.RUB),
+		TokenID: card.TokenID, Metadata: map[string]string{"user_id": "u1"}})
+	if err != nil || held.Status != domain.PaymentStatusAuthorized {
+		t.Fatalf("Authorize = %+v, %v", held, err)
+	}
+	if err := gw.Capture(ctx, gateway.HoldInput{PaymentID: "p2", ExternalID: held.ExternalID, Amount: money.New(55000, money.RUB)}); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := fake.Payment(domain.ProviderTinkoff, held.ExternalID); p.State != fakeprovider.StateConfirmed || p.Captured != 55000 {
+		t.Errorf("captured: %+v", p)
+	}
+	voided, _ := gw.Authorize(ctx, gateway.CreatePaymentInput{PaymentID: "p3", Amount: money.New(1000, money.RUB),
+		TokenID: card.TokenID, Metadata: map[string]string{"user_id": "u1"}})
+	if err := gw.Void(ctx, gateway.HoldInput{PaymentID: "p3", ExternalID: voided.ExternalID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Capture(ctx, gateway.HoldInput{ExternalID: voided.ExternalID, Amount: money.New(1000, money.RUB)}); !errors.Is(err, gateway.ErrPaymentRejected) {
+		t.Errorf("capture of a voided hold: %v", err)
+	}
+
+	// Scripted decline of a saved card charge
+	fake.Script(domain.ProviderTinkoff, fakeprovider.Behavior{Outcome: fakeprovider.Decline})
+	if _, err := gw.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p4", Amount: money.New(1000, money.RUB),
+		TokenID: card.TokenID}); !errors.Is(err, gateway.ErrPaymentRejected) {
+		t.Errorf("declined charge: %v", err)
+	}
+
+	// A terminal with the wrong password is refused
+	wrong := gateway.NewTinkoffGateway(gateway.TinkoffConfig{TerminalKey: "terminal", Password: "guess", APIURL: fake.TinkoffURL()})
+	if _, err := wrong.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p5", Amount: money.New(1000, money.RUB)}); !errors.Is(err, gateway.ErrPaymentRejected) {
+		t.Errorf("wrong token: %v", err)
+	}
+}
+
+func TestTinkoff_Notifications(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer receiver.Close()
+	fake := fakeprovider.Start(t, fakeprovider.Config{TinkoffTerminalKey: "terminal", TinkoffPassword: "tinkoff-secret"})
+	gw := gateway.NewTinkoffGateway(gateway.TinkoffConfig{TerminalKey: "terminal", Password: "tinkoff-secret",
+		APIURL: fake.TinkoffURL(), NotifyURL: receiver.URL})
+
+	fake.Script(domain.ProviderTinkoff, fakeprovider.Behavior{Duplicates: 1})
+	res, err := gw.CreatePayment(context.Background(), gateway.CreatePaymentInput{PaymentID: "p1", Amount: money.New(50000, money.RUB)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.Pay(domain.ProviderTinkoff, res.ExternalID); err != nil {
+		t.Fatal(err)
+	}
+	got := events(t, fake, gw, res.ExternalID)
+	want := []string{"payment.authorized", "payment.authorized", "payment.succeeded", "payment.succeeded"}
+	if types := eventTypes(got); len(types) != len(want) || types[0] != want[0] || types[3] != want[3] {
+		t.Fatalf("notifications: %v, want %v", types, want)
+	}
+	if got[2].EventID != got[3].EventID || got[2].PaymentID != "p1" || got[2].Amount != money.New(50000, money.RUB) {
+		t.Errorf("a duplicate must repeat the event: %+v %+v", got[2], got[3])
+	}
+}
+
+func TestYooMoney(t *testing.T) {
+	fake := start(t)
+	ctx := context.Background()
+	gw := gateway.NewYooMoneyGateway(gateway.YooMoneyConfig{ShopID: "shop", SecretKey: "yoo-secret",
+		WebhookSecret: "yoo-webhook-secret", APIURL: fake.YooMoneyURL()})
+
+	res, err := gw.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p1", Amount: money.New(19999, money.RUB),
+		ReturnURL: "https://app/return", SaveCard: true})
+	if err != nil || !res.RequiresRedirect || res.Status != domain.PaymentStatusPending {
+		t.Fatalf("create = %+v, %v", res, err)
+	}
+	if err := fake.Pay(domain.ProviderYooMoney, res.ExternalID); err != nil {
+		t.Fatal(err)
+	}
+	if got := eventTypes(events(t, fake, gw, res.ExternalID)); len(got) != 1 || got[0] != "payment.succeeded" {
+		t.Errorf("notifications: %v", got)
+	}
+	card, err := gw.GetSavedCard(ctx, res.ExternalID)
+	if err != nil || card == nil || card.Brand != "mastercard" {
+		t.Fatalf("saved card = %+v, %v", card, err)
+	}
+	// Partial refunds: one idempotency key each; their notifications name the payment
+	for i, id := range []string{"r1", "r2"} {
+		refund, err := gw.Refund(ctx, gateway.RefundInput{PaymentID: "p1", RefundID: id, ExternalID: res.ExternalID,
+			Amount: money.New(9999, money.RUB)})
+		if err != nil || refund.RefundID == "" || refund.Status != domain.RefundStatusSucceeded {
+			t.Fatalf("refund %d = %+v, %v", i+1, refund, err)
+		}
+	}
+	refunds := events(t, fake, gw, res.ExternalID)[1:]
+	if len(refunds) != 2 || refunds[0].EventType != "refund.succeeded" || refunds[0].ExternalID != res.ExternalID ||
+		refunds[0].RefundID == "" || refunds[0].RefundID == refunds[1].RefundID || refunds[1].Amount != money.New(9999, money.RUB) {
+		t.Errorf("refund notifications: %+v", refunds)
+	}
+
+	// 3DS on a saved card: the payer confirms before the payment is held
+	fake.Script(domain.ProviderYooMoney, fakeprovider.Behavior{Outcome: fakeprovider.ThreeDS})
+	held, err := gw.Authorize(ctx, gateway.CreatePaymentInput{PaymentID: "p2", Amount: money.New(5000, money.RUB), TokenID: card.TokenID})
+	if err != nil || !held.RequiresRedirect || held.Status != domain.PaymentStatusPending {
+		t.Fatalf("3DS authorize = %+v, %v", held, err)
+	}
+	if err := fake.Pay(domain.ProviderYooMoney, held.ExternalID); err != nil {
+		t.Fatal(err)
+	}
+	if got := eventTypes(events(t, fake, gw, held.ExternalID)); len(got) != 1 || got[0] != "payment.authorized" {
+		t.Errorf("two-stage notifications: %v", got)
+	}
+	if err := gw.Void(ctx, gateway.HoldInput{PaymentID: "p2", ExternalID: held.ExternalID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A slow provider: the payment exists there although the call timed out
+	fake.Script(domain.ProviderYooMoney, fakeprovider.Behavior{Latency: time.Second, NoCallbacks: true})
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := gw.CreatePayment(short, gateway.CreatePaymentInput{PaymentID: "p3", Amount: money.New(100, money.RUB)}); err == nil {
+		t.Error("the call must time out")
+	}
+	if payments := fake.Payments(); len(payments) != 3 || payments[2].OrderID != "p3" {
+		t.Errorf("payments at the fake: %+v", payments)
+	}
+
+	wrong := gateway.NewYooMoneyGateway(gateway.YooMoneyConfig{ShopID: "shop", SecretKey: "guess", APIURL: fake.YooMoneyURL()})
+	if _, err := wrong.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p4", Amount: money.New(100, money.RUB)}); !errors.Is(err, gateway.ErrPaymentRejected) {
+		t.Errorf("wrong secret key: %v", err)
+	}
+}
+
+func TestSber(t *testing.T) {
+	fake := start(t)
+	ctx := context.Background()
+	gw := gateway.NewSberGateway(gateway.SberConfig{UserName: "merchant-api", Password: "sber-secret",
+		ReturnURL: "https://app/return", APIURL: fake.SberURL()})
+
+	res, err := gw.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p1", Amount: money.New(30000, money.RUB),
+		SaveCard: true, Metadata: map[string]string{"user_id": "u1"}})
+	if err != nil || !res.RequiresRedirect {
+		t.Fatalf("register = %+v, %v", res, err)
+	}
+	if err := fake.Pay(domain.ProviderSber, res.ExternalID); err != nil {
+		t.Fatal(err)
+	}
+	got := events(t, fake, gw, res.ExternalID)
+	if len(got) != 1 || got[0].EventType != "payment.succeeded" || got[0].PaymentID != "p1" {
+		t.Fatalf("callbacks: %+v", got)
+	}
+	card, err := gw.GetSavedCard(ctx, res.ExternalID)
+	if err != nil || card == nil || card.Brand != "mir" || card.ExpiryYear != 2030 {
+		t.Fatalf("binding = %+v, %v", card, err)
+	}
+
+	// Saved card: approved at once, declined, or sent to 3DS
+	meta := map[string]string{"user_id": "u1"}
+	if paid, err := gw.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p2", Amount: money.New(100, money.RUB), TokenID: card.TokenID, Metadata: meta}); err != nil || paid.Status != domain.PaymentStatusCompleted {
+		t.Errorf("binding payment = %+v, %v", paid, err)
+	}
+	fake.Script(domain.ProviderSber, fakeprovider.Behavior{Outcome: fakeprovider.Decline})
+	if _, err := gw.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p3", Amount: money.New(100, money.RUB), TokenID: card.TokenID, Metadata: meta}); !errors.Is(err, gateway.ErrPaymentRejected) {
+		t.Errorf("declined binding payment: %v", err)
+	}
+	fake.Script(domain.ProviderSber, fakeprovider.Behavior{Outcome: fakeprovider.ThreeDS})
+	acs, err := gw.Authorize(ctx, gateway.CreatePaymentInput{PaymentID: "p4", Amount: money.New(100, money.RUB), TokenID: card.TokenID, Metadata: meta})
+	if err != nil || acs.Status != domain.PaymentStatusProcessing || !acs.RequiresRedirect {
+		t.Errorf("3DS binding payment = %+v, %v", acs, err)
+	}
+
+	// Order numbers are unique at Sber
+	if _, err := gw.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p1", Amount: money.New(100, money.RUB)}); !errors.Is(err, gateway.ErrPaymentRejected) {
+		t.Errorf("repeated order number: %v", err)
+	}
+
+	// Outage: the next call fails without effect, then the provider is back
+	fake.Outage(domain.ProviderSber, 1)
+	if _, err := gw.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p5", Amount: money.New(100, money.RUB)}); err == nil {
+		t.Error("a call during an outage must fail")
+	}
+	if _, err := gw.CreatePayment(ctx, gateway.CreatePaymentInput{PaymentID: "p5", Amount: money.New(100, money.RUB)}); err != nil {
+		t.Errorf("after the outage: %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package fakeprovider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// Sber Acquiring REST API: form-encoded requests, JSON answers with errorCode
+func (s *Server) routeSber() {
+	for _, endpoint := range []string{"register.do", "registerPreAuth.do"} {
+		s.mux.HandleFunc("POST /sber/payment/rest/"+endpoint, s.sber(s.sberRegister))
+	}
+	s.mux.HandleFunc("POST /sber/payment/rest/paymentOrderBinding.do", s.sber(s.sberPayWithBinding))
+	s.mux.HandleFunc("POST /sber/payment/rest/getOrderStatusExtended.do", s.sber(s.sberOrderStatus))
+	s.mux.HandleFunc("POST /sber/payment/rest/deposit.do", s.sber(s.sberDeposit))
+	s.mux.HandleFunc("POST /sber/payment/rest/reverse.do", s.sber(s.sberReverse))
+	s.mux.HandleFunc("POST /sber/payment/rest/refund.do", s.sber(s.sberRefund))
+}
+
+// sberHandler answers an authenticated request; p is the payment to delay the answer for
+type sberHandler func(r *http.Request, form url.Values) (resp map[string]interface{}, p *Payment)
+
+// sber authenticates a request and runs h under the lock
+func (s *Server) sber(h sberHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.begin(w, domain.ProviderSber) {
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			writeJSON(w, http.StatusOK, sberError("4", "invalid request"))
+			return
+		}
+		if !s.sberAuthorized(r.PostForm) {
+			writeJSON(w, http.StatusOK, sberError("5", "Access denied"))
+			return
+		}
+		s.mu.Lock()
+		resp, p := h(r, r.PostForm)
+		var snapshot *Payment
+		if p != nil {
+			copied := *p
+			snapshot = &copied
+		}
+		s.mu.Unlock()
+		delay(r, snapshot)
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (s *Server) sberAuthorized(form url.Values) bool {
+	if s.cfg.SberToken != "" && form.Get("token") == s.cfg.SberToken {
+		return true
+	}
+	if s.cfg.SberUserName != "" {
+		return form.Get("userName") == s.cfg.SberUserName && form.Get("password") == s.cfg.SberPassword
+	}
+	return s.cfg.SberToken == ""
+}
+
+// sberRegister — register.do and registerPreAuth.do; order numbers are unique
+func (s *Server) sberRegister(r *http.Request, form url.Values) (map[string]interface{}, *Payment) {
+	orderNumber := form.Get("orderNumber")
+	amount, err := strconv.ParseInt(form.Get("amount"), 10, 64)
+	if orderNumber == "" || err != nil || amount <= 0 {
+		return sberError("4", "orderNumber and amount are required"), nil
+	}
+	for _, existing := range s.payments {
+		if existing.Provider == domain.ProviderSber && existing.OrderID == orderNumber {
+			return sberError("1", "Order with this number was already processed"), nil
+		}
+	}
+	p := &Payment{
+		Provider:  domain.ProviderSber,
+		OrderID:   orderNumber,
+		Amount:    amount,
+		Currency:  form.Get("currency"),
+		TwoStage:  strings.HasSuffix(r.URL.Path, "/registerPreAuth.do"),
+		Customer:  form.Get("clientId"),
+		SaveCard:  form.Get("clientId") != "",
+		ReturnURL: form.Get("returnUrl"),
+		NotifyURL: s.cfg.SberCallbackURL,
+	}
+	s.create(p, s.sberID())
+	return map[string]interface{}{
+		"orderId": p.ID,
+		"formUrl": s.cfg.BaseURL + "/_fake/pay/" + p.Provider + "/" + p.ID,
+	}, p
+}
+
+// sberPayWithBinding — pays a registered order with a saved card; 3DS answers with the
+// ACS redirect
+func (s *Server) sberPayWithBinding(r *http.Request, form url.Values) (map[string]interface{}, *Payment) {
+	p := s.payments[domain.ProviderSber+"/"+form.Get("mdOrder")]
+	if p == nil {
+		return sberError("6", "Order not found"), nil
+	}
+	if form.Get("bindingId") == "" {
+		return sberError("4", "bindingId is required"), p
+	}
+	if p.State != StateNew {
+		return sberError("7", "Wrong order state"), p
+	}
+	s.charge(p, form.Get("bindingId"))
+	switch p.State {
+	case StateRejected:
+		return sberError("2", "Payment declined"), p
+	case StateNew:
+		return map[string]interface{}{
+			"errorCode": "0",
+			"redirect":  s.cfg.BaseURL + "/_fake/pay/" + p.Provider + "/" + p.ID,
+			"info":      "3DS authentication required",
+		}, p
+	}
+	return map[string]interface{}{"errorCode": "0", "info": "Your order is proceeded"}, p
+}
+
+func (s *Server) sberOrderStatus(r *http.Request, form url.Values) (map[string]interface{}, *Payment) {
+	p := s.payments[domain.ProviderSber+"/"+form.Get("orderId")]
+	if p == nil {
+		return sberError("6", "Order not found"), nil
+	}
+	resp := map[string]interface{}{
+		"errorCode":   "0",
+		"orderNumber": p.OrderID,
+		"orderStatus": sberStatus(p),
+		"actionCode":  0,
+		"amount":      p.Amount,
+		"currency":    p.Currency,
+	}
+	if p.State == StateRejected {
+		resp["actionCode"] = 116
+		resp["actionCodeDescription"] = "Insufficient funds"
+	}
+	if p.CardToken != "" {
+		resp["cardAuthInfo"] = map[string]string{"pan": "220220**0004", "expiration": "203012", "cardholderName": "CARDHOLDER"}
+		if p.SaveCard {
+			resp["bindingInfo"] = map[string]string{"bindingId": p.CardToken, "clientId": p.Customer}
+		}
+	}
+	return resp, p
+}
+
+func (s *Server) sberDeposit(r *http.Request, form url.Values) (map[string]interface{}, *Payment) {
+	p := s.payments[domain.ProviderSber+"/"+form.Get("orderId")]
+	if p == nil {
+		return sberError("6", "Order not found"), nil
+	}
+	amount, _ := strconv.ParseInt(form.Get("amount"), 10, 64)
+	if err := s.capture(p, amount); err != nil {
+		return sberError("7", err.Error()), p
+	}
+	return map[string]interface{}{"errorCode": "0"}, p
+}
+
+func (s *Server) sberReverse(r *http.Request, form url.Values) (map[string]interface{}, *Payment) {
+	p := s.payments[domain.ProviderSber+"/"+form.Get("orderId")]
+	if p == nil {
+		return sberError("6", "Order not found"), nil
+	}
+	if err := s.cancel(p); err != nil {
+		return sberError("7", err.Error()), p
+	}
+	return map[string]interface{}{"errorCode": "0"}, p
+}
+
+func (s *Server) sberRefund(r *http.Request, form url.Values) (map[string]interface{}, *Payment) {
+	p := s.payments[domain.ProviderSber+"/"+form.Get("orderId")]
+	if p == nil {
+		return sberError("6", "Order not found"), nil
+	}
+	amount, err := strconv.ParseInt(form.Get("amount"), 10, 64)
+	if err != nil || amount <= 0 {
+		return sberError("4", "amount is required"), p
+	}
+	if err := s.refund(p, amount); err != nil {
+		return sberError("7", err.Error()), p
+	}
+	return map[string]interface{}{"errorCode": "0"}, p
+}
+
+// sberNotice — the callback of p's last transition, with the checksum the gateway
+// verifies; one-stage payments are not reported as approved
+func (s *Server) sberNotice(p *Payment, amount int64) *notice {
+	operation := ""
+	switch p.State {
+	case StateAuthorized:
+		if !p.TwoStage {
+			return nil
+		}
+		operation = "approved"
+	case StateConfirmed:
+		operation = "deposited"
+	case StateCancelled:
+		operation = "reversed"
+	case StateRefunded:
+		operation = "refunded"
+	case StateRejected:
+		operation = "declined"
+	default:
+		return nil
+	}
+	form := url.Values{}
+	form.Set("mdOrder", p.ID)
+	form.Set("orderNumber", p.OrderID)
+	form.Set("operation", operation)
+	form.Set("status", "1")
+	form.Set("amount", strconv.FormatInt(amount, 10))
+	if p.State == StateRejected {
+		form.Set("status", "0")
+	}
+	if s.cfg.SberPassword != "" {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s;%s;%d", p.ID, operation, amount) + s.cfg.SberPassword))
+		form.Set("checksum", strings.ToUpper(hex.EncodeToString(sum[:])))
+	}
+	return &notice{
+		url:    p.NotifyURL,
+		header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+		body:   []byte(form.Encode()),
+	}
+}
+
+// sberStatus — Sber's orderStatus of p
+func sberStatus(p *Payment) int {
+	switch p.State {
+	case StateAuthorized:
+		return 1
+	case StateConfirmed:
+		return 2
+	case StateCancelled:
+		return 3
+	case StateRefunded:
+		return 4
+	case StateRejected:
+		return 6
+	}
+	if p.CardToken != "" {
+		return 5 // waiting for the payer's 3DS confirmation
+	}
+	return 0
+}
+
+func (s *Server) sberID() string {
+	s.seq++
+	return fmt.Sprintf("%08x-0000-4000-8000-%012x", time.Now().Unix(), s.seq)
+}
+
+func sberError(code, message string) map[string]interface{} {
+	return map[string]interface{}{"errorCode": code, "errorMessage": message}
+}
@@ -0,0 +1,520 @@
+// Package fakeprovider — a local stand-in for the Tinkoff, YooMoney and Sber acquiring
+// APIs. It implements the parts of each API the gateways call, keeps payments in
+// memory and sends notifications signed the way the gateways verify them. Declines,
+// 3DS, slow answers, outages and duplicate or lost callbacks are scripted per payment.
+//
+// One server serves all three providers; point a gateway's APIURL at TinkoffURL,
+// YooMoneyURL or SberURL. In tests use Start; cmd/fakeprovider runs it standalone.
+package fakeprovider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// Outcomes of a scripted payment
+const (
+	Approve = "approve" // the card is charged, or held for two-stage payments (default)
+	Decline = "decline" // the issuer declines
+	ThreeDS = "3ds"     // the payer confirms on the payment page, even when paying with a saved card
+)
+
+// Payment states, common to the providers; each maps them to its own statuses
+const (
+	StateNew        = "new"        // registered, waiting for the payer (payment page, 3DS)
+	StateAuthorized = "authorized" // held, waiting for capture or void
+	StateConfirmed  = "confirmed"  // charged
+	StateRejected   = "rejected"   // declined
+	StateCancelled  = "cancelled"  // cancelled before the charge, or the hold voided
+	StateRefunded   = "refunded"   // partially or fully refunded
+)
+
+// ErrNotFound — no such payment at the fake
+var ErrNotFound = errors.New("fakeprovider: payment not found")
+
+// Behavior — how the fake treats one payment, scripted before it is created
+type Behavior struct {
+	Outcome     string        `json:"outcome"`      // Approve (default), Decline or ThreeDS
+	Latency     time.Duration `json:"latency"`      // every API call for the payment waits this long: past the client timeout it times out
+	Duplicates  int           `json:"duplicates"`   // each notification is delivered 1+Duplicates times
+	NoCallbacks bool          `json:"no_callbacks"` // notifications are never sent: a lost webhook
+}
+
+// Config — credentials the fake expects and where it sends notifications. Empty
+// credentials are not checked; an empty notification URL sends nothing.
+type Config struct {
+	BaseURL string // where the fake is reachable, for payment page links; Start sets it
+
+	TinkoffTerminalKey string
+	TinkoffPassword    string // checks request tokens and signs notifications
+	TinkoffNotifyURL   string // terminal default, for payments initialized without NotificationURL
+
+	YooMoneyShopID        string
+	YooMoneySecretKey     string
+	YooMoneyWebhookSecret string // HMAC key of X-YooKassa-Signature
+	YooMoneyWebhookURL    string
+
+	SberUserName    string
+	SberPassword    string // also the callback checksum key
+	SberToken       string
+	SberCallbackURL string
+}
+
+// Payment — a payment as the fake holds it
+type Payment struct {
+	Provider  string            `json:"provider"`
+	ID        string            `json:"id"`       // provider's payment ID
+	OrderID   string            `json:"order_id"` // merchant's payment ID
+	Amount    int64             `json:"amount"`   // minor units
+	Currency  string            `json:"currency"`
+	TwoStage  bool              `json:"two_stage"`
+	State     string            `json:"state"`
+	Captured  int64             `json:"captured"`
+	Refunded  int64             `json:"refunded"`
+	SaveCard  bool              `json:"save_card"`
+	Customer  string            `json:"customer,omitempty"`
+	CardToken string            `json:"card_token,omitempty"` // issued when the card is saved, or the one paid with
+	ReturnURL string            `json:"return_url,omitempty"`
+	NotifyURL string            `json:"notify_url,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Behavior  Behavior          `json:"behavior"`
+	CreatedAt time.Time         `json:"created_at"`
+	refundSeq int
+}
+
+// Callback — one notification delivery attempt
+type Callback struct {
+	Provider  string      `json:"provider"`
+	PaymentID string      `json:"payment_id"`
+	URL       string      `json:"url"`
+	Header    http.Header `json:"header"`
+	Body      string      `json:"body"`
+	Status    int         `json:"status"` // 0 when the receiver could not be reached
+	Error     string      `json:"error,omitempty"`
+}
+
+// notice — a notification waiting for delivery
+type notice struct {
+	provider  string
+	paymentID string
+	url       string
+	header    http.Header
+	body      []byte
+	copies    int
+}
+
+// Server — the fake provider; an http.Handler
+type Server struct {
+	cfg    Config
+	mux    *http.ServeMux
+	client *http.Client
+
+	mu       sync.Mutex
+	seq      int64
+	payments map[string]*Payment // provider/id
+	scripts  map[string][]Behavior
+	outages  map[string]int
+	replies  map[string]idempotentReply // YooMoney Idempotence-Key
+
+	cbMu      sync.Mutex
+	callbacks []Callback
+	queue     chan notice
+	pending   sync.WaitGroup
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New creates a fake provider; Close stops its notification delivery
+func New(cfg Config) *Server {
+	s := &Server{
+		cfg:      cfg,
+		mux:      http.NewServeMux(),
+		client:   &http.Client{Timeout: 10 * time.Second},
+		payments: make(map[string]*Payment),
+		scripts:  make(map[string][]Behavior),
+		outages:  make(map[string]int),
+		replies:  make(map[string]idempotentReply),
+		queue:    make(chan notice, 1024),
+		done:     make(chan struct{}),
+	}
+	s.routeTinkoff()
+	s.routeYooMoney()
+	s.routeSber()
+	s.mux.HandleFunc("GET /_fake/pay/{provider}/{id}", s.handlePay)
+	s.mux.HandleFunc("POST /_fake/pay/{provider}/{id}", s.handlePay)
+	s.mux.HandleFunc("POST /_fake/script", s.handleScript)
+	s.mux.HandleFunc("POST /_fake/outage", s.handleOutage)
+	s.mux.HandleFunc("GET /_fake/payments", s.handlePayments)
+	s.mux.HandleFunc("GET /_fake/callbacks", s.handleCallbacks)
+	go s.deliver()
+	return s
+}
+
+// Start runs a fake provider on a local port for the duration of the test
+func Start(t testing.TB, cfg Config) *Server {
+	t.Helper()
+	s := New(cfg)
+	srv := httptest.NewServer(s)
+	s.cfg.BaseURL = srv.URL
+	t.Cleanup(func() {
+		srv.Close()
+		s.Close()
+	})
+	return s
+}
+
+// ServeHTTP serves the provider APIs and the fake's control endpoints
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Close stops notification delivery; notifications still queued are dropped
+func (s *Server) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// TinkoffURL — APIURL for the Tinkoff gateway
+func (s *Server) TinkoffURL() string { return s.cfg.BaseURL + "/tinkoff/v2" }
+
+// YooMoneyURL — APIURL for the YooMoney gateway
+func (s *Server) YooMoneyURL() string { return s.cfg.BaseURL + "/yoomoney/v3" }
+
+// SberURL — APIURL for the Sber gateway
+func (s *Server) SberURL() string { return s.cfg.BaseURL + "/sber/payment/rest" }
+
+// Script queues the behavior of the next payment created at the provider
+func (s *Server) Script(provider string, b Behavior) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[provider] = append(s.scripts[provider], b)
+}
+
+// Outage makes the next calls to the provider's API fail with 503, without effect
+func (s *Server) Outage(provider string, calls int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outages[provider] = calls
+}
+
+// Pay completes the payment page of a payment, as the payer would
+func (s *Server) Pay(provider, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.payments[provider+"/"+id]
+	if !ok {
+		return ErrNotFound
+	}
+	if p.State != StateNew {
+		return fmt.Errorf("fakeprovider: payment %s is %s", id, p.State)
+	}
+	if p.Behavior.Outcome == Decline {
+		s.transition(p, StateRejected, p.Amount)
+		return nil
+	}
+	s.settle(p)
+	return nil
+}
+
+// Payment returns a copy of a payment
+func (s *Server) Payment(provider, id string) (Payment, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.payments[provider+"/"+id]
+	if !ok {
+		return Payment{}, false
+	}
+	return *p, true
+}
+
+// Payments returns copies of every payment, oldest first
+func (s *Server) Payments() []Payment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Payment, 0, len(s.payments))
+	for _, p := range s.payments {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// Flush waits until every queued notification was delivered
+func (s *Server) Flush() {
+	s.pending.Wait()
+}
+
+// Callbacks returns the delivery attempts so far, after Flush
+func (s *Server) Callbacks() []Callback {
+	s.Flush()
+	s.cbMu.Lock()
+	defer s.cbMu.Unlock()
+	return append([]Callback(nil), s.callbacks...)
+}
+
+// --- Payment lifecycle (s.mu held) ---
+
+// create registers p with the next scripted behavior of its provider
+func (s *Server) create(p *Payment, id string) {
+	if queued := s.scripts[p.Provider]; len(queued) > 0 {
+		p.Behavior = queued[0]
+		s.scripts[p.Provider] = queued[1:]
+	}
+	if p.Behavior.Outcome == "" {
+		p.Behavior.Outcome = Approve
+	}
+	p.ID = id
+	p.State = StateNew
+	p.CreatedAt = time.Now()
+	s.payments[p.Provider+"/"+id] = p
+}
+
+// charge pays p with a saved card; ThreeDS leaves it waiting for the payment page
+func (s *Server) charge(p *Payment, token string) {
+	p.CardToken = token
+	switch p.Behavior.Outcome {
+	case Decline:
+		s.transition(p, StateRejected, p.Amount)
+	case ThreeDS:
+	default:
+		s.settle(p)
+	}
+}
+
+// settle holds or charges p; one-stage payments are held first, as the providers report
+func (s *Server) settle(p *Payment) {
+	if p.SaveCard && p.CardToken == "" {
+		p.CardToken = s.nextID()
+	}
+	s.transition(p, StateAuthorized, p.Amount)
+	if !p.TwoStage {
+		p.Captured = p.Amount
+		s.transition(p, StateConfirmed, p.Amount)
+	}
+}
+
+// capture charges amount of a held payment; 0 captures all of it
+func (s *Server) capture(p *Payment, amount int64) error {
+	if p.State != StateAuthorized {
+		return fmt.Errorf("payment is %s, not authorized", p.State)
+	}
+	if amount == 0 {
+		amount = p.Amount
+	}
+	if amount < 0 || amount > p.Amount {
+		return fmt.Errorf("capture amount %d exceeds the hold %d", amount, p.Amount)
+	}
+	p.Captured = amount
+	s.transition(p, StateConfirmed, amount)
+	return nil
+}
+
+// cancel voids a hold or drops a payment nobody paid yet
+func (s *Server) cancel(p *Payment) error {
+	if p.State != StateAuthorized && p.State != StateNew {
+		return fmt.Errorf("payment is %s, cannot be cancelled", p.State)
+	}
+	s.transition(p, StateCancelled, p.Amount)
+	return nil
+}
+
+// refund returns amount of a charged payment; 0 refunds the rest
+func (s *Server) refund(p *Payment, amount int64) error {
+	if p.State != StateConfirmed && p.State != StateRefunded {
+		return fmt.Errorf("payment is %s, cannot be refunded", p.State)
+	}
+	left := p.Captured - p.Refunded
+	if amount == 0 {
+		amount = left
+	}
+	if amount <= 0 || amount > left {
+		return fmt.Errorf("refund amount %d exceeds the refundable %d", amount, left)
+	}
+	p.Refunded += amount
+	p.refundSeq++
+	s.transition(p, StateRefunded, amount)
+	return nil
+}
+
+// transition moves p and queues its notification; amount is the amount of the operation
+func (s *Server) transition(p *Payment, state string, amount int64) {
+	p.State = state
+	var n *notice
+	switch p.Provider {
+	case domain.ProviderTinkoff:
+		n = s.tinkoffNotice(p, amount)
+	case domain.ProviderYooMoney:
+		n = s.yooNotice(p, amount)
+	case domain.ProviderSber:
+		n = s.sberNotice(p, amount)
+	}
+	if n == nil || n.url == "" || p.Behavior.NoCallbacks {
+		return
+	}
+	n.provider = p.Provider
+	n.paymentID = p.ID
+	n.copies = 1 + p.Behavior.Duplicates
+	s.pending.Add(1)
+	select {
+	case s.queue <- *n:
+	default:
+		s.pending.Done() // queue full: the notification is lost
+	}
+}
+
+func (s *Server) nextID() string {
+	s.seq++
+	return fmt.Sprintf("%d", 1000000+s.seq)
+}
+
+// --- Request plumbing ---
+
+// begin applies a scripted outage; false when the call was answered with 503
+func (s *Server) begin(w http.ResponseWriter, provider string) bool {
+	s.mu.Lock()
+	down := s.outages[provider] > 0
+	if down {
+		s.outages[provider]--
+	}
+	s.mu.Unlock()
+	if down {
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// delay holds the answer for the payment's scripted latency, or until the client gives up
+func delay(r *http.Request, p *Payment) {
+	if p == nil || p.Behavior.Latency <= 0 {
+		return
+	}
+	select {
+	case <-time.After(p.Behavior.Latency):
+	case <-r.Context().Done():
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// deliver sends queued notifications in order
+func (s *Server) deliver() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case n := <-s.queue:
+			for i := 0; i < n.copies; i++ {
+				s.send(n)
+			}
+			s.pending.Done()
+		}
+	}
+}
+
+func (s *Server) send(n notice) {
+	cb := Callback{Provider: n.provider, PaymentID: n.paymentID, URL: n.url, Header: n.header, Body: string(n.body)}
+	req, err := http.NewRequest(http.MethodPost, n.url, bytes.NewReader(n.body))
+	if err == nil {
+		req.Header = n.header.Clone()
+		var resp *http.Response
+		if resp, err = s.client.Do(req); err == nil {
+			cb.Status = resp.StatusCode
+			resp.Body.Close()
+		}
+	}
+	if err != nil {
+		cb.Error = err.Error()
+	}
+	s.cbMu.Lock()
+	s.callbacks = append(s.callbacks, cb)
+	s.cbMu.Unlock()
+}
+
+// --- Control endpoints ---
+
+// handlePay — the payment page: visiting it pays (or declines) the payment
+func (s *Server) handlePay(w http.ResponseWriter, r *http.Request) {
+	provider, id := r.PathValue("provider"), r.PathValue("id")
+	if err := s.Pay(provider, id); err != nil {
+		status := http.StatusConflict
+		if errors.Is(err, ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	p, _ := s.Payment(provider, id)
+	if p.ReturnURL != "" && r.Method == http.MethodGet {
+		http.Redirect(w, r, p.ReturnURL, http.StatusFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+// handleScript — POST /_fake/script {"provider":"tinkoff","outcome":"decline","latency":"0s",...}
+func (s *Server) handleScript(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Provider    string `json:"provider"`
+		Outcome     string `json:"outcome"`
+		Latency     string `json:"latency"` // Go duration: 35s
+		Duplicates  int    `json:"duplicates"`
+		NoCallbacks bool   `json:"no_callbacks"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Provider == "" {
+		http.Error(w, "provider required", http.StatusBadRequest)
+		return
+	}
+	b := Behavior{Outcome: req.Outcome, Duplicates: req.Duplicates, NoCallbacks: req.NoCallbacks}
+	if req.Latency != "" {
+		d, err := time.ParseDuration(req.Latency)
+		if err != nil {
+			http.Error(w, "invalid latency", http.StatusBadRequest)
+			return
+		}
+		b.Latency = d
+	}
+	switch b.Outcome {
+	case "", Approve, Decline, ThreeDS:
+	default:
+		http.Error(w, "outcome must be approve, decline or 3ds", http.StatusBadRequest)
+		return
+	}
+	s.Script(req.Provider, b)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleOutage — POST /_fake/outage {"provider":"sber","calls":3}
+func (s *Server) handleOutage(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Provider string `json:"provider"`
+		Calls    int    `json:"calls"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Provider == "" {
+		http.Error(w, "provider required", http.StatusBadRequest)
+		return
+	}
+	s.Outage(req.Provider, req.Calls)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handlePayments(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Payments())
+}
+
+func (s *Server) handleCallbacks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Callbacks())
+}
@@ -0,0 +1,299 @@
+package fakeprovider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// Tinkoff Acquiring API v2: JSON requests signed with Token, answers with Success
+func (s *Server) routeTinkoff() {
+	s.mux.HandleFunc("POST /tinkoff/v2/Init", s.tinkoff(s.tinkoffInit))
+	s.mux.HandleFunc("POST /tinkoff/v2/Charge", s.tinkoff(s.tinkoffCharge))
+	s.mux.HandleFunc("POST /tinkoff/v2/GetState", s.tinkoff(s.tinkoffGetState))
+	s.mux.HandleFunc("POST /tinkoff/v2/Confirm", s.tinkoff(s.tinkoffConfirm))
+	s.mux.HandleFunc("POST /tinkoff/v2/Cancel", s.tinkoff(s.tinkoffCancel))
+}
+
+// tinkoffHandler answers a verified request; p is the payment to delay the answer for
+type tinkoffHandler func(req map[string]interface{}) (resp map[string]interface{}, p *Payment)
+
+// tinkoff decodes and verifies a request, runs h under the lock and answers
+func (s *Server) tinkoff(h tinkoffHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.begin(w, domain.ProviderTinkoff) {
+			return
+		}
+		var req map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusOK, tinkoffError("215", "invalid JSON"))
+			return
+		}
+		if errCode, msg := s.tinkoffVerify(req); errCode != "" {
+			writeJSON(w, http.StatusOK, tinkoffError(errCode, msg))
+			return
+		}
+		s.mu.Lock()
+		resp, p := h(req)
+		var snapshot *Payment
+		if p != nil {
+			copied := *p
+			snapshot = &copied
+		}
+		s.mu.Unlock()
+		delay(r, snapshot)
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// tinkoffVerify checks the terminal and the token; the password itself must never be sent
+func (s *Server) tinkoffVerify(req map[string]interface{}) (string, string) {
+	if _, sent := req["Password"]; sent {
+		return "204", "Password must not be sent, only the Token signed with it"
+	}
+	if s.cfg.TinkoffTerminalKey != "" && req["TerminalKey"] != s.cfg.TinkoffTerminalKey {
+		return "202", "Unknown terminal"
+	}
+	if s.cfg.TinkoffPassword == "" {
+		return "", ""
+	}
+	token, _ := req["Token"].(string)
+	if token != tinkoffToken(req, s.cfg.TinkoffPassword) {
+		return "204", "Invalid token"
+	}
+	return "", ""
+}
+
+func (s *Server) tinkoffInit(req map[string]interface{}) (map[string]interface{}, *Payment) {
+	amount, _ := req["Amount"].(float64)
+	orderID, _ := req["OrderId"].(string)
+	if amount <= 0 || orderID == "" {
+		return tinkoffError("9999", "Amount and OrderId are required"), nil
+	}
+	p := &Payment{
+		Provider: domain.ProviderTinkoff,
+		OrderID:  orderID,
+		Amount:   int64(amount),
+		Currency: "RUB",
+		TwoStage: req["PayType"] == "T",
+		SaveCard: req["Recurrent"] == "Y",
+	}
+	p.Customer, _ = req["CustomerKey"].(string)
+	p.ReturnURL, _ = req["SuccessURL"].(string)
+	p.NotifyURL, _ = req["NotificationURL"].(string)
+	if p.NotifyURL == "" {
+		p.NotifyURL = s.cfg.TinkoffNotifyURL
+	}
+	if data, ok := req["DATA"].(map[string]interface{}); ok {
+		p.Metadata = make(map[string]string, len(data))
+		for k, v := range data {
+			p.Metadata[k], _ = v.(string)
+		}
+	}
+	s.create(p, s.nextID())
+	return map[string]interface{}{
+		"Success":     true,
+		"ErrorCode":   "0",
+		"TerminalKey": req["TerminalKey"],
+		"Status":      "NEW",
+		"PaymentId":   p.ID,
+		"OrderId":     p.OrderID,
+		"Amount":      p.Amount,
+		"PaymentURL":  s.cfg.BaseURL + "/_fake/pay/" + p.Provider + "/" + p.ID,
+	}, p
+}
+
+func (s *Server) tinkoffCharge(req map[string]interface{}) (map[string]interface{}, *Payment) {
+	p := s.tinkoffPayment(req)
+	if p == nil {
+		return tinkoffError("7", "Payment not found"), nil
+	}
+	rebillID := tinkoffString(req["RebillId"])
+	if rebillID == "" {
+		return tinkoffError("9999", "RebillId is required"), p
+	}
+	if p.State != StateNew {
+		return tinkoffError("9", "Invalid payment state"), p
+	}
+	s.charge(p, rebillID)
+	if p.State == StateRejected {
+		resp := tinkoffError("1051", "Insufficient funds")
+		resp["Status"] = "REJECTED"
+		resp["PaymentId"] = p.ID
+		return resp, p
+	}
+	status := tinkoffStatus(p)
+	if p.State == StateNew {
+		status = "3DS_CHECKING"
+	}
+	return tinkoffOK(p, status), p
+}
+
+func (s *Server) tinkoffGetState(req map[string]interface{}) (map[string]interface{}, *Payment) {
+	p := s.tinkoffPayment(req)
+	if p == nil {
+		return tinkoffError("7", "Payment not found"), nil
+	}
+	resp := tinkoffOK(p, tinkoffStatus(p))
+	if p.SaveCard && p.CardToken != "" {
+		rebill, _ := strconv.ParseInt(p.CardToken, 10, 64)
+		resp["RebillId"] = rebill
+		resp["CardId"] = rebill
+		resp["Pan"] = "430000******0777"
+		resp["ExpDate"] = "1230"
+	}
+	return resp, p
+}
+
+func (s *Server) tinkoffConfirm(req map[string]interface{}) (map[string]interface{}, *Payment) {
+	p := s.tinkoffPayment(req)
+	if p == nil {
+		return tinkoffError("7", "Payment not found"), nil
+	}
+	amount, _ := req["Amount"].(float64)
+	if err := s.capture(p, int64(amount)); err != nil {
+		return tinkoffError("9", err.Error()), p
+	}
+	return tinkoffOK(p, tinkoffStatus(p)), p
+}
+
+// tinkoffCancel voids a hold or an unpaid payment, and refunds a charged one
+func (s *Server) tinkoffCancel(req map[string]interface{}) (map[string]interface{}, *Payment) {
+	p := s.tinkoffPayment(req)
+	if p == nil {
+		return tinkoffError("7", "Payment not found"), nil
+	}
+	original := p.Amount
+	var err error
+	if p.State == StateConfirmed || p.State == StateRefunded {
+		amount, _ := req["Amount"].(float64)
+		original = p.Captured
+		err = s.refund(p, int64(amount))
+	} else {
+		err = s.cancel(p)
+	}
+	if err != nil {
+		return tinkoffError("9", err.Error()), p
+	}
+	resp := tinkoffOK(p, tinkoffStatus(p))
+	resp["OriginalAmount"] = original
+	resp["NewAmount"] = original - p.Refunded
+	if p.State == StateCancelled {
+		resp["NewAmount"] = 0
+	}
+	return resp, p
+}
+
+// tinkoffNotice — the notification of p's current status, signed like requests
+func (s *Server) tinkoffNotice(p *Payment, amount int64) *notice {
+	n := map[string]interface{}{
+		"TerminalKey": s.cfg.TinkoffTerminalKey,
+		"OrderId":     p.OrderID,
+		"Success":     p.State != StateRejected,
+		"Status":      tinkoffStatus(p),
+		"PaymentId":   json.Number(p.ID),
+		"ErrorCode":   "0",
+		"Amount":      amount,
+	}
+	if p.State == StateRejected {
+		n["ErrorCode"] = "1051"
+	}
+	if p.SaveCard && p.CardToken != "" {
+		n["RebillId"] = json.Number(p.CardToken)
+		n["CardId"] = json.Number(p.CardToken)
+		n["Pan"] = "430000******0777"
+		n["ExpDate"] = "1230"
+	}
+	// Sign what the receiver decodes
+	body, _ := json.Marshal(n)
+	decoded := make(map[string]interface{})
+	json.Unmarshal(body, &decoded)
+	n["Token"] = tinkoffToken(decoded, s.cfg.TinkoffPassword)
+	body, _ = json.Marshal(n)
+	return &notice{url: p.NotifyURL, header: http.Header{"Content-Type": {"application/json"}}, body: body}
+}
+
+func (s *Server) tinkoffPayment(req map[string]interface{}) *Payment {
+	return s.payments[domain.ProviderTinkoff+"/"+tinkoffString(req["PaymentId"])]
+}
+
+func tinkoffOK(p *Payment, status string) map[string]interface{} {
+	return map[string]interface{}{
+		"Success":   true,
+		"ErrorCode": "0",
+		"Status":    status,
+		"PaymentId": p.ID,
+		"OrderId":   p.OrderID,
+		"Amount":    p.Amount,
+	}
+}
+
+func tinkoffError(code, message string) map[string]interface{} {
+	return map[string]interface{}{"Success": false, "ErrorCode": code, "Message": message}
+}
+
+// tinkoffStatus — Tinkoff's status of p
+func tinkoffStatus(p *Payment) string {
+	switch p.State {
+	case StateAuthorized:
+		return "AUTHORIZED"
+	case StateConfirmed:
+		return "CONFIRMED"
+	case StateRejected:
+		return "REJECTED"
+	case StateCancelled:
+		return "CANCELED"
+	case StateRefunded:
+		if p.Refunded < p.Captured {
+			return "PARTIAL_REFUNDED"
+		}
+		return "REFUNDED"
+	}
+	return "NEW"
+}
+
+// tinkoffString reads an ID sent as a string or a number
+func tinkoffString(v interface{}) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	}
+	return ""
+}
+
+// tinkoffToken — SHA-256 of the root-level scalar values and the password, ordered by key
+func tinkoffToken(params map[string]interface{}, password string) string {
+	keys := []string{"Password"}
+	for k := range params {
+		if k != "Token" && k != "Password" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		if k == "Password" {
+			sb.WriteString(password)
+			continue
+		}
+		switch v := params[k].(type) {
+		case string:
+			sb.WriteString(v)
+		case float64:
+			sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			sb.WriteString(strconv.FormatBool(v))
+		}
+	}
+	sum := sha256.Sum256([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
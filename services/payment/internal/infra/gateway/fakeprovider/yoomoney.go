@@ -0,0 +1,306 @@
+package fakeprovider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// idempotentReply — the answer to a request with an Idempotence-Key
+type idempotentReply struct {
+	request string
+	status  int
+	body    interface{}
+}
+
+// YooMoney (ЮKassa) API v3: basic auth, Idempotence-Key on every POST
+func (s *Server) routeYooMoney() {
+	s.mux.HandleFunc("POST /yoomoney/v3/payments", s.yoo(s.yooCreate))
+	s.mux.HandleFunc("GET /yoomoney/v3/payments/{id}", s.yoo(s.yooGet))
+	s.mux.HandleFunc("POST /yoomoney/v3/payments/{id}/capture", s.yoo(s.yooCapture))
+	s.mux.HandleFunc("POST /yoomoney/v3/payments/{id}/cancel", s.yoo(s.yooCancel))
+	s.mux.HandleFunc("POST /yoomoney/v3/refunds", s.yoo(s.yooRefund))
+}
+
+// yooHandler answers an authenticated request; p is the payment to delay the answer for
+type yooHandler func(r *http.Request, body []byte) (status int, resp interface{}, p *Payment)
+
+// yoo authenticates a request, replays answers to a repeated Idempotence-Key and runs h
+// under the lock
+func (s *Server) yoo(h yooHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.begin(w, domain.ProviderYooMoney) {
+			return
+		}
+		if shop, key, _ := r.BasicAuth(); s.cfg.YooMoneyShopID != "" &&
+			(shop != s.cfg.YooMoneyShopID || key != s.cfg.YooMoneySecretKey) {
+			writeJSON(w, http.StatusUnauthorized, yooError("invalid_credentials", "Authentication error"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		key := r.Header.Get("Idempotence-Key")
+		if r.Method == http.MethodPost && key == "" {
+			writeJSON(w, http.StatusBadRequest, yooError("invalid_request", "Idempotence-Key header is required"))
+			return
+		}
+
+		s.mu.Lock()
+		if reply, seen := s.replies[key]; seen && r.Method == http.MethodPost {
+			s.mu.Unlock()
+			if reply.request != r.URL.Path+" "+string(body) {
+				writeJSON(w, http.StatusBadRequest, yooError("invalid_request",
+					"Idempotence key duplicated with different request parameters"))
+				return
+			}
+			writeJSON(w, reply.status, reply.body)
+			return
+		}
+		status, resp, p := h(r, body)
+		if r.Method == http.MethodPost {
+			s.replies[key] = idempotentReply{request: r.URL.Path + " " + string(body), status: status, body: resp}
+		}
+		var snapshot *Payment
+		if p != nil {
+			copied := *p
+			snapshot = &copied
+		}
+		s.mu.Unlock()
+		delay(r, snapshot)
+		writeJSON(w, status, resp)
+	}
+}
+
+type yooAmount struct {
+	Value    string `json:"value"`
+	Currency string `json:"currency"`
+}
+
+func (s *Server) yooCreate(r *http.Request, body []byte) (int, interface{}, *Payment) {
+	var req struct {
+		Amount            yooAmount         `json:"amount"`
+		Description       string            `json:"description"`
+		Capture           bool              `json:"capture"`
+		SavePaymentMethod bool              `json:"save_payment_method"`
+		PaymentMethodID   string            `json:"payment_method_id"`
+		Metadata          map[string]string `json:"metadata"`
+		Confirmation      *struct {
+			Type      string `json:"type"`
+			ReturnURL string `json:"return_url"`
+		} `json:"confirmation"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, yooError("invalid_request", "invalid JSON"), nil
+	}
+	amount, err := money.Parse(req.Amount.Value, req.Amount.Currency)
+	if err != nil || !amount.IsPositive() {
+		return http.StatusBadRequest, yooError("invalid_request", "amount is invalid"), nil
+	}
+	if req.PaymentMethodID == "" && req.Confirmation == nil {
+		return http.StatusBadRequest, yooError("invalid_request", "confirmation or payment_method_id is required"), nil
+	}
+	p := &Payment{
+		Provider:  domain.ProviderYooMoney,
+		OrderID:   req.Metadata["payment_id"],
+		Amount:    amount.Minor,
+		Currency:  amount.Currency,
+		TwoStage:  !req.Capture,
+		SaveCard:  req.SavePaymentMethod,
+		Metadata:  req.Metadata,
+		NotifyURL: s.cfg.YooMoneyWebhookURL,
+	}
+	if req.Confirmation != nil {
+		p.ReturnURL = req.Confirmation.ReturnURL
+	}
+	s.create(p, s.yooID())
+	if req.PaymentMethodID != "" {
+		s.charge(p, req.PaymentMethodID)
+	}
+	return http.StatusOK, s.yooPayment(p), p
+}
+
+func (s *Server) yooGet(r *http.Request, body []byte) (int, interface{}, *Payment) {
+	p := s.payments[domain.ProviderYooMoney+"/"+r.PathValue("id")]
+	if p == nil {
+		return http.StatusNotFound, yooError("not_found", "Payment not found"), nil
+	}
+	return http.StatusOK, s.yooPayment(p), p
+}
+
+func (s *Server) yooCapture(r *http.Request, body []byte) (int, interface{}, *Payment) {
+	p := s.payments[domain.ProviderYooMoney+"/"+r.PathValue("id")]
+	if p == nil {
+		return http.StatusNotFound, yooError("not_found", "Payment not found"), nil
+	}
+	var req struct {
+		Amount *yooAmount `json:"amount"`
+	}
+	_ = json.Unmarshal(body, &req)
+	var amount int64
+	if req.Amount != nil {
+		m, err := money.Parse(req.Amount.Value, req.Amount.Currency)
+		if err != nil || m.Currency != p.Currency {
+			return http.StatusBadRequest, yooError("invalid_request", "amount is invalid"), p
+		}
+		amount = m.Minor
+	}
+	if err := s.capture(p, amount); err != nil {
+		return http.StatusBadRequest, yooError("invalid_request", err.Error()), p
+	}
+	return http.StatusOK, s.yooPayment(p), p
+}
+
+func (s *Server) yooCancel(r *http.Request, body []byte) (int, interface{}, *Payment) {
+	p := s.payments[domain.ProviderYooMoney+"/"+r.PathValue("id")]
+	if p == nil {
+		return http.StatusNotFound, yooError("not_found", "Payment not found"), nil
+	}
+	if err := s.cancel(p); err != nil {
+		return http.StatusBadRequest, yooError("invalid_request", err.Error()), p
+	}
+	return http.StatusOK, s.yooPayment(p), p
+}
+
+func (s *Server) yooRefund(r *http.Request, body []byte) (int, interface{}, *Payment) {
+	var req struct {
+		PaymentID   string    `json:"payment_id"`
+		Amount      yooAmount `json:"amount"`
+		Description string    `json:"description"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return http.StatusBadRequest, yooError("invalid_request", "invalid JSON"), nil
+	}
+	p := s.payments[domain.ProviderYooMoney+"/"+req.PaymentID]
+	if p == nil {
+		return http.StatusNotFound, yooError("not_found", "Payment not found"), nil
+	}
+	amount, err := money.Parse(req.Amount.Value, req.Amount.Currency)
+	if err != nil || amount.Currency != p.Currency {
+		return http.StatusBadRequest, yooError("invalid_request", "amount is invalid"), p
+	}
+	if err := s.refund(p, amount.Minor); err != nil {
+		return http.StatusBadRequest, yooError("invalid_request", err.Error()), p
+	}
+	return http.StatusOK, yooRefundObject(p, amount.Minor), p
+}
+
+// yooNotice — the notification of p's last transition; one-stage payments are not
+// reported as waiting_for_capture
+func (s *Server) yooNotice(p *Payment, amount int64) *notice {
+	event := ""
+	var object interface{} = s.yooPayment(p)
+	switch p.State {
+	case StateAuthorized:
+		if !p.TwoStage {
+			return nil
+		}
+		event = "payment.waiting_for_capture"
+	case StateConfirmed:
+		event = "payment.succeeded"
+	case StateRejected, StateCancelled:
+		event = "payment.canceled"
+	case StateRefunded:
+		event = "refund.succeeded"
+		object = yooRefundObject(p, amount)
+	default:
+		return nil
+	}
+	body, _ := json.Marshal(map[string]interface{}{"type": "notification", "event": event, "object": object})
+	header := http.Header{"Content-Type": {"application/json"}}
+	if s.cfg.YooMoneyWebhookSecret != "" {
+		mac := hmac.New(sha256.New, []byte(s.cfg.YooMoneyWebhookSecret))
+		mac.Write(body)
+		header.Set("X-YooKassa-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+	return &notice{url: p.NotifyURL, header: header, body: body}
+}
+
+// yooPayment — the payment object
+func (s *Server) yooPayment(p *Payment) map[string]interface{} {
+	amount := p.Amount
+	if p.State == StateConfirmed || p.State == StateRefunded {
+		amount = p.Captured
+	}
+	obj := map[string]interface{}{
+		"id":          p.ID,
+		"status":      yooStatus(p),
+		"amount":      yooMoney(amount, p.Currency),
+		"metadata":    p.Metadata,
+		"created_at":  p.CreatedAt.UTC().Format(time.RFC3339),
+		"paid":        p.State == StateAuthorized || p.State == StateConfirmed || p.State == StateRefunded,
+		"refundable":  p.State == StateConfirmed || (p.State == StateRefunded && p.Refunded < p.Captured),
+		"description": "",
+	}
+	if p.Refunded > 0 {
+		obj["refunded_amount"] = yooMoney(p.Refunded, p.Currency)
+	}
+	if p.State == StateNew {
+		obj["confirmation"] = map[string]string{
+			"type":             "redirect",
+			"confirmation_url": s.cfg.BaseURL + "/_fake/pay/" + p.Provider + "/" + p.ID,
+		}
+	}
+	if p.State == StateRejected {
+		obj["cancellation_details"] = map[string]string{"party": "payment_network", "reason": "insufficient_funds"}
+	}
+	if p.CardToken != "" {
+		obj["payment_method"] = map[string]interface{}{
+			"type":  "bank_card",
+			"id":    p.CardToken,
+			"saved": p.SaveCard,
+			"title": "Bank card *4477",
+			"card": map[string]string{
+				"first6":       "555555",
+				"last4":        "4477",
+				"expiry_month": "12",
+				"expiry_year":  "2030",
+				"card_type":    "MasterCard",
+			},
+		}
+	}
+	return obj
+}
+
+func yooRefundObject(p *Payment, amount int64) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         fmt.Sprintf("%s-r%d", p.ID, p.refundSeq),
+		"payment_id": p.ID,
+		"status":     "succeeded",
+		"amount":     yooMoney(amount, p.Currency),
+		"created_at": time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// yooStatus — YooMoney's status of p; refunds do not change a payment's status
+func yooStatus(p *Payment) string {
+	switch p.State {
+	case StateAuthorized:
+		return "waiting_for_capture"
+	case StateConfirmed, StateRefunded:
+		return "succeeded"
+	case StateRejected, StateCancelled:
+		return "canceled"
+	}
+	return "pending"
+}
+
+func (s *Server) yooID() string {
+	s.seq++
+	return fmt.Sprintf("%08x-000f-5000-9000-%012x", time.Now().Unix(), s.seq)
+}
+
+func yooMoney(minor int64, currency string) yooAmount {
+	return yooAmount{Value: money.New(minor, currency).Decimal(), Currency: currency}
+}
+
+func yooError(code, description string) map[string]string {
+	return map[string]string{"type": "error", "code": code, "description": description}
+}
@@ -4,6 +4,10 @@ package gateway
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
 
 	"github.com/ridehail/payment/internal/domain"
 )
@@ -14,13 +18,13 @@ var (
 	ErrInvalidCredentials  = errors.New("invalid provider credentials")
 	ErrPaymentRejected     = errors.New("payment rejected by provider")
 	ErrInvalidWebhook      = errors.New("invalid webhook signature")
+	ErrHoldNotSupported    = errors.New("provider does not support two-stage payments")
 )
 
 // CreatePaymentInput — input for creating payment
 type CreatePaymentInput struct {
-	PaymentID   string  // Our internal payment ID
-	Amount      float64
-	Currency    string
+	PaymentID   string      // Our internal payment ID
+	Amount      money.Money // each gateway converts it to its wire format
 	Description string
 	ReturnURL   string // URL to redirect after payment
 	UserEmail   string // Optional: for receipts
@@ -47,8 +51,9 @@ type ConfirmPaymentInput struct {
 // RefundInput — input for refund
 type RefundInput struct {
 	PaymentID  string
+	RefundID   string // ours; the idempotency key where the provider takes one
 	ExternalID string
-	Amount     float64 // 0 = full refund
+	Amount     money.Money
 	Reason     string
 }
 
@@ -56,8 +61,15 @@ type RefundInput struct {
 type RefundResult struct {
 	RefundID   string
 	ExternalID string
-	Amount     float64
-	Status     string
+	Amount     money.Money
+	Status     string // pending, succeeded; a refused refund is ErrPaymentRejected
+}
+
+// HoldInput — an authorized (held) payment to capture or void
+type HoldInput struct {
+	PaymentID  string
+	ExternalID string
+	Amount     money.Money // capture: the amount to charge, at most the authorized one
 }
 
 // CardInfo — saved card information from provider
@@ -77,6 +89,16 @@ type Gateway interface {
 	// CreatePayment initiates a payment
 	CreatePayment(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error)
 
+	// Authorize holds the amount on the card without charging it (two-stage payment);
+	// with TokenID the saved card is used, otherwise ConfirmURL leads to the payment form
+	Authorize(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error)
+
+	// Capture charges an authorized payment; the rest of the hold is released
+	Capture(ctx context.Context, input HoldInput) error
+
+	// Void releases the hold of an authorized payment without charging
+	Void(ctx context.Context, input HoldInput) error
+
 	// GetPaymentStatus gets current payment status
 	GetPaymentStatus(ctx context.Context, externalID string) (string, error)
 
@@ -90,6 +112,19 @@ type Gateway interface {
 	GetSavedCard(ctx context.Context, externalID string) (*CardInfo, error)
 }
 
+// HealthReporter — a gateway that tracks its health, such as ResilientGateway
+type HealthReporter interface {
+	Health() domain.GatewayHealth
+}
+
+// checkStatus — a 5xx answer means the provider is down, not that it declined
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("%w: status %d", ErrProviderUnavailable, resp.StatusCode)
+	}
+	return nil
+}
+
 // Manager manages multiple payment gateways
 type Manager struct {
 	gateways map[string]Gateway
@@ -121,3 +156,14 @@ func (m *Manager) Available() []string {
 	}
 	return providers
 }
+
+// Health returns the health of the gateways that track it, by provider
+func (m *Manager) Health() map[string]domain.GatewayHealth {
+	health := make(map[string]domain.GatewayHealth)
+	for p, g := range m.gateways {
+		if r, ok := g.(HealthReporter); ok {
+			health[p] = r.Health()
+		}
+	}
+	return health
+}
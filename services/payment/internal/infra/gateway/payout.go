@@ -0,0 +1,80 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// PayoutInput — a transfer of driver earnings
+type PayoutInput struct {
+	PayoutID    string // our payout id, the idempotency key
+	Amount      money.Money
+	Destination string // provider payout token or account
+	Description string
+}
+
+// PayoutResult — the provider's answer
+type PayoutResult struct {
+	ExternalID string
+	Status     string // processing | paid | failed
+	FailReason string
+}
+
+// PayoutGateway — pays out driver earnings. Transport errors and ErrProviderUnavailable
+// leave the payout to be retried; ErrPaymentRejected fails it.
+type PayoutGateway interface {
+	// Provider returns the provider name
+	Provider() string
+	// Payout sends the amount to the destination
+	Payout(ctx context.Context, input PayoutInput) (*PayoutResult, error)
+	// GetPayoutStatus returns the status of a processing payout
+	GetPayoutStatus(ctx context.Context, externalID string) (*PayoutResult, error)
+}
+
+// PayoutManager manages payout gateways
+type PayoutManager struct {
+	gateways map[string]PayoutGateway
+}
+
+// NewPayoutManager creates a payout gateway manager
+func NewPayoutManager() *PayoutManager {
+	return &PayoutManager{gateways: make(map[string]PayoutGateway)}
+}
+
+// Register registers a payout gateway
+func (m *PayoutManager) Register(g PayoutGateway) {
+	m.gateways[g.Provider()] = g
+}
+
+// Get returns payout gateway by provider name
+func (m *PayoutManager) Get(provider string) (PayoutGateway, bool) {
+	g, ok := m.gateways[provider]
+	return g, ok
+}
+
+// StubPayoutGateway — local development: every payout is paid at once
+type StubPayoutGateway struct{}
+
+// NewStubPayoutGateway creates the stub payout gateway
+func NewStubPayoutGateway() *StubPayoutGateway {
+	return &StubPayoutGateway{}
+}
+
+// Provider returns provider name
+func (g *StubPayoutGateway) Provider() string {
+	return domain.PayoutProviderStub
+}
+
+// Payout marks the payout paid
+func (g *StubPayoutGateway) Payout(ctx context.Context, input PayoutInput) (*PayoutResult, error) {
+	return &PayoutResult{ExternalID: fmt.Sprintf("stub_%s", input.PayoutID), Status: domain.PayoutStatusPaid}, nil
+}
+
+// GetPayoutStatus — stub payouts are always paid
+func (g *StubPayoutGateway) GetPayoutStatus(ctx context.Context, externalID string) (*PayoutResult, error) {
+	return &PayoutResult{ExternalID: externalID, Status: domain.PayoutStatusPaid}, nil
+}
@@ -0,0 +1,231 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// ErrCircuitOpen — the gateway failed too often; calls fail fast until the cool-down ends
+var ErrCircuitOpen = fmt.Errorf("%w: circuit open", ErrProviderUnavailable)
+
+// IdempotentGateway — a gateway whose write calls carry idempotency keys, so a call lost
+// in transit can be repeated without charging twice
+type IdempotentGateway interface {
+	Idempotent() bool
+}
+
+// IsProviderFailure reports whether err means the provider did not answer properly:
+// transport errors, timeouts, 5xx and unreadable answers. Declines, webhook and hold
+// errors are answers; a call cancelled by the caller says nothing about the provider.
+func IsProviderFailure(err error) bool {
+	return err != nil &&
+		!errors.Is(err, ErrPaymentRejected) &&
+		!errors.Is(err, ErrInvalidWebhook) &&
+		!errors.Is(err, ErrHoldNotSupported) &&
+		!errors.Is(err, context.Canceled)
+}
+
+// ResilienceConfig — circuit breaker and retries of a gateway
+type ResilienceConfig struct {
+	FailureThreshold int           // consecutive failures that open the circuit
+	OpenFor          time.Duration // cool-down before a probe call is let through
+	Retries          int           // repeats of a failed read, or of a write with idempotency keys
+	RetryBackoff     time.Duration // before the first repeat; doubles
+}
+
+// DefaultResilienceConfig — open after 5 failures for 30s, 2 retries from 200ms
+func DefaultResilienceConfig() ResilienceConfig {
+	return ResilienceConfig{FailureThreshold: 5, OpenFor: 30 * time.Second, Retries: 2, RetryBackoff: 200 * time.Millisecond}
+}
+
+// healthWeight — weight of the latest call in the health score and latency
+const healthWeight = 0.2
+
+// ResilientGateway wraps a gateway with a circuit breaker, bounded retries and health
+// scoring. Reads are retried; writes only when the gateway is idempotent. Webhooks are
+// parsed locally and pass through.
+type ResilientGateway struct {
+	Gateway
+	cfg        ResilienceConfig
+	idempotent bool
+	now        func() time.Time
+
+	mu       sync.Mutex
+	state    string
+	probing  bool // half-open: the probe call is in flight
+	openedAt time.Time
+	health   domain.GatewayHealth
+}
+
+// NewResilientGateway wraps g
+func NewResilientGateway(g Gateway, cfg ResilienceConfig) *ResilientGateway {
+	idem, ok := g.(IdempotentGateway)
+	return &ResilientGateway{
+		Gateway:    g,
+		cfg:        cfg,
+		idempotent: ok && idem.Idempotent(),
+		now:        time.Now,
+		state:      domain.CircuitClosed,
+		health:     domain.GatewayHealth{Provider: g.Provider(), Score: 1},
+	}
+}
+
+// CreatePayment initiates a payment
+func (g *ResilientGateway) CreatePayment(ctx context.Context, input CreatePaymentInput) (res *CreatePaymentResult, err error) {
+	err = g.call(ctx, g.idempotent, func(ctx context.Context) error {
+		res, err = g.Gateway.CreatePayment(ctx, input)
+		return err
+	})
+	return res, err
+}
+
+// Authorize holds the amount on the card
+func (g *ResilientGateway) Authorize(ctx context.Context, input CreatePaymentInput) (res *CreatePaymentResult, err error) {
+	err = g.call(ctx, g.idempotent, func(ctx context.Context) error {
+		res, err = g.Gateway.Authorize(ctx, input)
+		return err
+	})
+	return res, err
+}
+
+// Capture charges an authorized payment
+func (g *ResilientGateway) Capture(ctx context.Context, input HoldInput) error {
+	return g.call(ctx, g.idempotent, func(ctx context.Context) error {
+		return g.Gateway.Capture(ctx, input)
+	})
+}
+
+// Void releases the hold of an authorized payment
+func (g *ResilientGateway) Void(ctx context.Context, input HoldInput) error {
+	return g.call(ctx, g.idempotent, func(ctx context.Context) error {
+		return g.Gateway.Void(ctx, input)
+	})
+}
+
+// GetPaymentStatus gets current payment status
+func (g *ResilientGateway) GetPaymentStatus(ctx context.Context, externalID string) (status string, err error) {
+	err = g.call(ctx, true, func(ctx context.Context) error {
+		status, err = g.Gateway.GetPaymentStatus(ctx, externalID)
+		return err
+	})
+	return status, err
+}
+
+// Refund processes a refund
+func (g *ResilientGateway) Refund(ctx context.Context, input RefundInput) (res *RefundResult, err error) {
+	err = g.call(ctx, g.idempotent, func(ctx context.Context) error {
+		res, err = g.Gateway.Refund(ctx, input)
+		return err
+	})
+	return res, err
+}
+
+// GetSavedCard returns saved card info from successful payment
+func (g *ResilientGateway) GetSavedCard(ctx context.Context, externalID string) (card *CardInfo, err error) {
+	err = g.call(ctx, true, func(ctx context.Context) error {
+		card, err = g.Gateway.GetSavedCard(ctx, externalID)
+		return err
+	})
+	return card, err
+}
+
+// Health returns the breaker state and health of the gateway
+func (g *ResilientGateway) Health() domain.GatewayHealth {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	h := g.health
+	h.State = g.state
+	if g.state == domain.CircuitOpen {
+		until := g.openedAt.Add(g.cfg.OpenFor)
+		if g.now().Before(until) {
+			h.OpenUntil = &until
+		} else {
+			h.State = domain.CircuitHalfOpen // the next call probes
+		}
+	}
+	return h
+}
+
+// call runs fn through the breaker, repeating provider failures when retry is set
+func (g *ResilientGateway) call(ctx context.Context, retry bool, fn func(ctx context.Context) error) error {
+	backoff := g.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		if err := g.allow(); err != nil {
+			return err
+		}
+		start := g.now()
+		err := fn(ctx)
+		g.record(err, g.now().Sub(start))
+		if !IsProviderFailure(err) || !retry || attempt >= g.cfg.Retries || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// allow lets a call through a closed circuit, and one probe through an open one after
+// the cool-down
+func (g *ResilientGateway) allow() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch g.state {
+	case domain.CircuitOpen:
+		if g.now().Before(g.openedAt.Add(g.cfg.OpenFor)) {
+			return ErrCircuitOpen
+		}
+		g.state = domain.CircuitHalfOpen
+	case domain.CircuitHalfOpen:
+		if g.probing {
+			return ErrCircuitOpen
+		}
+	default:
+		return nil
+	}
+	g.probing = true
+	return nil
+}
+
+// record updates breaker and health with the outcome of a call
+func (g *ResilientGateway) record(err error, latency time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.probing = false
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	h := &g.health
+	h.Calls++
+	ms := float64(latency) / float64(time.Millisecond)
+	if h.Calls == 1 {
+		h.LatencyMS = ms
+	} else {
+		h.LatencyMS += healthWeight * (ms - h.LatencyMS)
+	}
+
+	if !IsProviderFailure(err) {
+		h.Score += healthWeight * (1 - h.Score)
+		h.ConsecutiveFailures = 0
+		g.state = domain.CircuitClosed
+		return
+	}
+	now := g.now()
+	h.Score -= healthWeight * h.Score
+	h.Failures++
+	h.ConsecutiveFailures++
+	h.LastError = err.Error()
+	h.LastFailureAt = &now
+	if g.state == domain.CircuitHalfOpen || h.ConsecutiveFailures >= g.cfg.FailureThreshold {
+		g.state = domain.CircuitOpen
+		g.openedAt = now
+	}
+}
@@ -0,0 +1,142 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// flakyGateway fails its calls with the queued errors, then succeeds
+type flakyGateway struct {
+	Gateway
+	errs       []error
+	calls      int
+	idempotent bool
+}
+
+func (g *flakyGateway) Provider() string { return domain.ProviderTinkoff }
+
+func (g *flakyGateway) Idempotent() bool { return g.idempotent }
+
+func (g *flakyGateway) next() error {
+	g.calls++
+	if len(g.errs) == 0 {
+		return nil
+	}
+	err := g.errs[0]
+	g.errs = g.errs[1:]
+	return err
+}
+
+func (g *flakyGateway) CreatePayment(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error) {
+	if err := g.next(); err != nil {
+		return nil, err
+	}
+	return &CreatePaymentResult{ExternalID: "ext-1", Status: domain.PaymentStatusPending}, nil
+}
+
+func (g *flakyGateway) GetPaymentStatus(ctx context.Context, externalID string) (string, error) {
+	return domain.PaymentStatusCompleted, g.next()
+}
+
+func repeat(err error, n int) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
+func TestResilientGateway_Retries(t *testing.T) {
+	ctx := context.Background()
+	cfg := ResilienceConfig{FailureThreshold: 10, OpenFor: time.Minute, Retries: 2, RetryBackoff: time.Millisecond}
+	down := errors.New("send request: connection refused")
+	input := CreatePaymentInput{PaymentID: "p1", Amount: money.New(100, money.RUB)}
+
+	// Reads are repeated, up to Retries times
+	gw := &flakyGateway{errs: repeat(down, 2)}
+	if status, err := NewResilientGateway(gw, cfg).GetPaymentStatus(ctx, "ext-1"); err != nil || status != domain.PaymentStatusCompleted || gw.calls != 3 {
+		t.Errorf("read: %s, %v after %d calls", status, err, gw.calls)
+	}
+	gw = &flakyGateway{errs: repeat(down, 3)}
+	if _, err := NewResilientGateway(gw, cfg).GetPaymentStatus(ctx, "ext-1"); !errors.Is(err, down) || gw.calls != 3 {
+		t.Errorf("read past the retries: %v after %d calls", err, gw.calls)
+	}
+
+	// Writes only with idempotency keys
+	gw = &flakyGateway{errs: []error{down}}
+	if _, err := NewResilientGateway(gw, cfg).CreatePayment(ctx, input); !errors.Is(err, down) || gw.calls != 1 {
+		t.Errorf("write without keys: %v after %d calls", err, gw.calls)
+	}
+	gw = &flakyGateway{errs: []error{down}, idempotent: true}
+	if _, err := NewResilientGateway(gw, cfg).CreatePayment(ctx, input); err != nil || gw.calls != 2 {
+		t.Errorf("idempotent write: %v after %d calls", err, gw.calls)
+	}
+
+	// A decline is an answer: never repeated
+	gw = &flakyGateway{errs: []error{ErrPaymentRejected}, idempotent: true}
+	if _, err := NewResilientGateway(gw, cfg).CreatePayment(ctx, input); !errors.Is(err, ErrPaymentRejected) || gw.calls != 1 {
+		t.Errorf("decline: %v after %d calls", err, gw.calls)
+	}
+}
+
+func TestResilientGateway_CircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	down := errors.New("status 503")
+	gw := &flakyGateway{errs: repeat(down, 3)}
+	r := NewResilientGateway(gw, ResilienceConfig{FailureThreshold: 3, OpenFor: 30 * time.Second})
+	r.now = func() time.Time { return now }
+
+	// Declines keep the circuit closed and the gateway healthy
+	gw.errs = append([]error{ErrPaymentRejected}, gw.errs...)
+	for i := 0; i < 4; i++ {
+		_, _ = r.GetPaymentStatus(ctx, "ext-1")
+	}
+	h := r.Health()
+	if h.State != domain.CircuitOpen || h.ConsecutiveFailures != 3 || h.Calls != 4 || h.Failures != 3 || h.LastError != "status 503" {
+		t.Fatalf("after 3 failures: %+v", h)
+	}
+	if h.OpenUntil == nil || !h.OpenUntil.Equal(now.Add(30*time.Second)) || h.Score >= 0.6 {
+		t.Errorf("open: %+v", h)
+	}
+
+	// Open: calls fail fast without reaching the provider
+	if _, err := r.GetPaymentStatus(ctx, "ext-1"); !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrProviderUnavailable) || gw.calls != 4 {
+		t.Errorf("open circuit: %v after %d calls", err, gw.calls)
+	}
+
+	// After the cool-down a failed probe opens it again
+	now = now.Add(31 * time.Second)
+	if h := r.Health(); h.State != domain.CircuitHalfOpen {
+		t.Errorf("after the cool-down: %s", h.State)
+	}
+	gw.errs = []error{down}
+	if _, err := r.GetPaymentStatus(ctx, "ext-1"); !errors.Is(err, down) || r.Health().State != domain.CircuitOpen {
+		t.Errorf("failed probe: %v, %s", err, r.Health().State)
+	}
+
+	// A successful probe closes it
+	now = now.Add(31 * time.Second)
+	if _, err := r.GetPaymentStatus(ctx, "ext-1"); err != nil {
+		t.Fatal(err)
+	}
+	if h := r.Health(); h.State != domain.CircuitClosed || h.ConsecutiveFailures != 0 || h.OpenUntil != nil {
+		t.Errorf("after a successful probe: %+v", h)
+	}
+}
+
+func TestManager_Health(t *testing.T) {
+	m := NewManager()
+	m.Register(NewCashGateway())
+	m.Register(NewResilientGateway(&flakyGateway{}, DefaultResilienceConfig()))
+	health := m.Health()
+	if len(health) != 1 || health[domain.ProviderTinkoff].State != domain.CircuitClosed || health[domain.ProviderTinkoff].Score != 1 {
+		t.Errorf("health: %+v", health)
+	}
+}
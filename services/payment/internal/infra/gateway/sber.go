@@ -15,6 +15,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alexevil1979/indrive/packages/money-go"
+
 	"github.com/ridehail/payment/internal/domain"
 )
 
@@ -31,6 +33,7 @@ type SberConfig struct {
 	TestMode  bool   // Use test API
 	ReturnURL string // Default return URL
 	FailURL   string // Default fail URL
+	APIURL    string // Overrides the API (e.g. a local fake provider)
 }
 
 // SberGateway — Sberbank Acquiring gateway
@@ -50,6 +53,9 @@ func NewSberGateway(cfg SberConfig) *SberGateway {
 	if cfg.TestMode {
 		apiURL = sberTestAPIURL
 	}
+	if cfg.APIURL != "" {
+		apiURL = cfg.APIURL
+	}
 	return &SberGateway{
 		userName:  cfg.UserName,
 		password:  cfg.Password,
@@ -109,8 +115,20 @@ type sberOrderParam struct {
 
 // CreatePayment initiates payment via Sberbank
 func (g *SberGateway) CreatePayment(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error) {
-	// Amount in kopecks
-	amountKopecks := int64(input.Amount * 100)
+	return g.register(ctx, input, "/register.do")
+}
+
+// Authorize registers a two-stage order (preAuth): the card is held until deposit.do
+func (g *SberGateway) Authorize(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error) {
+	return g.register(ctx, input, "/registerPreAuth.do")
+}
+
+// register — register.do or registerPreAuth.do (and payment with a binding for a saved card)
+func (g *SberGateway) register(ctx context.Context, input CreatePaymentInput, endpoint string) (*CreatePaymentResult, error) {
+	amount, currency, err := sberAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
 
 	returnURL := input.ReturnURL
 	if returnURL == "" {
@@ -124,8 +142,8 @@ func (g *SberGateway) CreatePayment(ctx context.Context, input CreatePaymentInpu
 	params := url.Values{}
 	g.addAuth(params)
 	params.Set("orderNumber", input.PaymentID)
-	params.Set("amount", strconv.FormatInt(amountKopecks, 10))
-	params.Set("currency", "643") // RUB
+	params.Set("amount", amount)
+	params.Set("currency", currency)
 	params.Set("returnUrl", returnURL)
 	params.Set("failUrl", failURL)
 	params.Set("description", input.Description)
@@ -137,7 +155,7 @@ func (g *SberGateway) CreatePayment(ctx context.Context, input CreatePaymentInpu
 
 	// Use saved card
 	if input.TokenID != "" {
-		return g.payWithBinding(ctx, input)
+		return g.payWithBinding(ctx, input, endpoint)
 	}
 
 	// Add metadata
@@ -151,7 +169,7 @@ func (g *SberGateway) CreatePayment(ctx context.Context, input CreatePaymentInpu
 	}
 
 	// Send request
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", g.apiURL+"/register.do", strings.NewReader(params.Encode()))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", g.apiURL+endpoint, strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -162,6 +180,9 @@ func (g *SberGateway) CreatePayment(ctx context.Context, input CreatePaymentInpu
 		return nil, fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -186,19 +207,22 @@ func (g *SberGateway) CreatePayment(ctx context.Context, input CreatePaymentInpu
 }
 
 // payWithBinding pays with saved card
-func (g *SberGateway) payWithBinding(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error) {
-	amountKopecks := int64(input.Amount * 100)
+func (g *SberGateway) payWithBinding(ctx context.Context, input CreatePaymentInput, endpoint string) (*CreatePaymentResult, error) {
+	amount, currency, err := sberAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
 
 	// First register order
 	params := url.Values{}
 	g.addAuth(params)
 	params.Set("orderNumber", input.PaymentID)
-	params.Set("amount", strconv.FormatInt(amountKopecks, 10))
-	params.Set("currency", "643")
+	params.Set("amount", amount)
+	params.Set("currency", currency)
 	params.Set("returnUrl", g.returnURL)
 	params.Set("clientId", input.Metadata["user_id"])
 
-	httpReq, _ := http.NewRequestWithContext(ctx, "POST", g.apiURL+"/register.do", strings.NewReader(params.Encode()))
+	httpReq, _ := http.NewRequestWithContext(ctx, "POST", g.apiURL+endpoint, strings.NewReader(params.Encode()))
 	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := g.client.Do(httpReq)
@@ -206,6 +230,9 @@ func (g *SberGateway) payWithBinding(ctx context.Context, input CreatePaymentInp
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 	var regResp sberRegisterResponse
@@ -229,6 +256,9 @@ func (g *SberGateway) payWithBinding(ctx context.Context, input CreatePaymentInp
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, _ = io.ReadAll(resp.Body)
 	var bindResp struct {
@@ -253,9 +283,13 @@ func (g *SberGateway) payWithBinding(ctx context.Context, input CreatePaymentInp
 		}, nil
 	}
 
+	status := domain.PaymentStatusCompleted
+	if endpoint == "/registerPreAuth.do" {
+		status = domain.PaymentStatusAuthorized
+	}
 	return &CreatePaymentResult{
 		ExternalID:       regResp.OrderId,
-		Status:           domain.PaymentStatusCompleted,
+		Status:           status,
 		RequiresRedirect: false,
 	}, nil
 }
@@ -274,6 +308,9 @@ func (g *SberGateway) GetPaymentStatus(ctx context.Context, externalID string) (
 		return "", err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 	var status sberOrderStatus
@@ -284,12 +321,15 @@ func (g *SberGateway) GetPaymentStatus(ctx context.Context, externalID string) (
 
 // Refund processes refund
 func (g *SberGateway) Refund(ctx context.Context, input RefundInput) (*RefundResult, error) {
-	amountKopecks := int64(input.Amount * 100)
+	amount, _, err := sberAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
 
 	params := url.Values{}
 	g.addAuth(params)
 	params.Set("orderId", input.ExternalID)
-	params.Set("amount", strconv.FormatInt(amountKopecks, 10))
+	params.Set("amount", amount)
 
 	httpReq, _ := http.NewRequestWithContext(ctx, "POST", g.apiURL+"/refund.do", strings.NewReader(params.Encode()))
 	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
@@ -299,6 +339,9 @@ func (g *SberGateway) Refund(ctx context.Context, input RefundInput) (*RefundRes
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 	var refundResp struct {
@@ -315,10 +358,60 @@ func (g *SberGateway) Refund(ctx context.Context, input RefundInput) (*RefundRes
 		RefundID:   input.ExternalID + "-refund",
 		ExternalID: input.ExternalID,
 		Amount:     input.Amount,
-		Status:     "succeeded",
+		Status:     domain.RefundStatusSucceeded,
 	}, nil
 }
 
+// Capture completes a preAuth order for input.Amount (at most the held amount)
+func (g *SberGateway) Capture(ctx context.Context, input HoldInput) error {
+	amount, _, err := sberAmount(input.Amount)
+	if err != nil {
+		return err
+	}
+	params := url.Values{}
+	params.Set("amount", amount)
+	return g.holdCall(ctx, "/deposit.do", input.ExternalID, params)
+}
+
+// Void reverses a preAuth order, releasing the hold
+func (g *SberGateway) Void(ctx context.Context, input HoldInput) error {
+	return g.holdCall(ctx, "/reverse.do", input.ExternalID, url.Values{})
+}
+
+// holdCall — deposit.do / reverse.do for an order
+func (g *SberGateway) holdCall(ctx context.Context, endpoint, orderID string, params url.Values) error {
+	g.addAuth(params)
+	params.Set("orderId", orderID)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", g.apiURL+endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	respBody, _ := io.ReadAll(resp.Body)
+	var holdResp struct {
+		ErrorCode    string `json:"errorCode"`
+		ErrorMessage string `json:"errorMessage"`
+	}
+	if err := json.Unmarshal(respBody, &holdResp); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	if holdResp.ErrorCode != "" && holdResp.ErrorCode != "0" {
+		return fmt.Errorf("%w: %s - %s", ErrPaymentRejected, holdResp.ErrorCode, holdResp.ErrorMessage)
+	}
+	return nil
+}
+
 // sberCallback — Sberbank callback notification
 type sberCallback struct {
 	MdOrder    string `json:"mdOrder"`
@@ -346,19 +439,23 @@ func (g *SberGateway) ParseWebhook(ctx context.Context, body []byte, signature s
 		wh.Checksum = values.Get("checksum")
 	}
 
-	// Verify checksum if password is set
-	if g.password != "" && wh.Checksum != "" {
+	// Verify checksum if password is set; a callback without one is then refused
+	verified := false
+	if g.password != "" {
 		data := fmt.Sprintf("%s;%s;%d", wh.MdOrder, wh.Operation, wh.Amount)
 		hash := sha256.Sum256([]byte(data + g.password))
 		expectedChecksum := hex.EncodeToString(hash[:])
-		if !strings.EqualFold(wh.Checksum, expectedChecksum) {
+		if wh.Checksum == "" || !strings.EqualFold(wh.Checksum, expectedChecksum) {
 			return nil, ErrInvalidWebhook
 		}
+		verified = true
 	}
 
 	eventType := "payment.unknown"
 	switch wh.Operation {
-	case "approved", "deposited":
+	case "approved":
+		eventType = "payment.authorized" // preAuth hold; deposited follows on capture
+	case "deposited":
 		eventType = "payment.succeeded"
 	case "reversed":
 		eventType = "payment.cancelled"
@@ -369,15 +466,21 @@ func (g *SberGateway) ParseWebhook(ctx context.Context, body []byte, signature s
 	}
 
 	status := mapSberStatus(wh.Status)
+	eventID := fmt.Sprintf("%s:%s:%d", wh.MdOrder, wh.Operation, wh.Amount)
+	if eventType == "refund.succeeded" {
+		eventID = "" // callbacks name no refund: equal partial refunds look alike
+	}
 
 	return &domain.WebhookEvent{
 		Provider:   domain.ProviderSber,
+		EventID:    eventID,
 		EventType:  eventType,
 		PaymentID:  wh.OrderNumber,
 		ExternalID: wh.MdOrder,
 		Status:     status,
-		Amount:     float64(wh.Amount) / 100,
+		Amount:     money.New(wh.Amount, money.RUB), // callbacks carry no currency; orders are registered in rubles
 		RawPayload: string(body),
+		SignatureVerified: verified,
 	}, nil
 }
 
@@ -395,6 +498,9 @@ func (g *SberGateway) GetSavedCard(ctx context.Context, externalID string) (*Car
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 	var status sberOrderStatus
@@ -442,13 +548,22 @@ func (g *SberGateway) addAuth(params url.Values) {
 	}
 }
 
+// sberAmount — Sber takes the amount in minor units and the ISO 4217 numeric currency code
+func sberAmount(m money.Money) (amount, currency string, err error) {
+	currency = money.NumericCode(m.Currency)
+	if currency == "" {
+		return "", "", fmt.Errorf("%w: unsupported currency %q", ErrPaymentRejected, m.Currency)
+	}
+	return strconv.FormatInt(m.Minor, 10), currency, nil
+}
+
 // mapSberStatus maps Sberbank status to domain status
 func mapSberStatus(status int) string {
 	switch status {
 	case 0:
 		return domain.PaymentStatusPending
 	case 1:
-		return domain.PaymentStatusProcessing // Pre-authorized
+		return domain.PaymentStatusAuthorized // Pre-authorized (held)
 	case 2:
 		return domain.PaymentStatusCompleted
 	case 3:
@@ -30,6 +30,21 @@ func (g *CashGateway) CreatePayment(ctx context.Context, input CreatePaymentInpu
 	}, nil
 }
 
+// Authorize for cash — nothing to hold
+func (g *CashGateway) Authorize(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error) {
+	return nil, ErrHoldNotSupported
+}
+
+// Capture for cash — not applicable
+func (g *CashGateway) Capture(ctx context.Context, input HoldInput) error {
+	return ErrHoldNotSupported
+}
+
+// Void for cash — not applicable
+func (g *CashGateway) Void(ctx context.Context, input HoldInput) error {
+	return ErrHoldNotSupported
+}
+
 // GetPaymentStatus for cash
 func (g *CashGateway) GetPaymentStatus(ctx context.Context, externalID string) (string, error) {
 	// Cash payments are always pending until driver confirms
@@ -16,6 +16,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alexevil1979/indrive/packages/money-go"
+
 	"github.com/ridehail/payment/internal/domain"
 )
 
@@ -30,6 +32,7 @@ type TinkoffConfig struct {
 	Password    string // Terminal password (for signature)
 	TestMode    bool   // Use test API
 	NotifyURL   string // Webhook URL
+	APIURL      string // Overrides the API (e.g. a local fake provider)
 }
 
 // TinkoffGateway — Tinkoff Acquiring gateway
@@ -47,6 +50,9 @@ func NewTinkoffGateway(cfg TinkoffConfig) *TinkoffGateway {
 	if cfg.TestMode {
 		apiURL = tinkoffTestAPIURL
 	}
+	if cfg.APIURL != "" {
+		apiURL = cfg.APIURL
+	}
 	return &TinkoffGateway{
 		terminalKey: cfg.TerminalKey,
 		password:    cfg.Password,
@@ -72,6 +78,7 @@ type tinkoffInitRequest struct {
 	NotificationURL string `json:"NotificationURL,omitempty"`
 	SuccessURL  string `json:"SuccessURL,omitempty"`
 	FailURL     string `json:"FailURL,omitempty"`
+	PayType     string `json:"PayType,omitempty"`   // O one-stage (terminal default), T two-stage
 	Recurrent   string `json:"Recurrent,omitempty"` // Y for save card
 	CustomerKey string `json:"CustomerKey,omitempty"`
 	Token       string `json:"Token"` // Signature
@@ -111,8 +118,20 @@ type tinkoffInitResponse struct {
 
 // CreatePayment initiates payment via Tinkoff
 func (g *TinkoffGateway) CreatePayment(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error) {
-	// Amount in kopecks
-	amountKopecks := int64(input.Amount * 100)
+	return g.initPayment(ctx, input, "")
+}
+
+// Authorize initiates a two-stage payment: the card is only held (AUTHORIZED) until Confirm
+func (g *TinkoffGateway) Authorize(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error) {
+	return g.initPayment(ctx, input, "T")
+}
+
+// initPayment — Init (and Charge for a saved card); payType is empty for the terminal's default
+func (g *TinkoffGateway) initPayment(ctx context.Context, input CreatePaymentInput, payType string) (*CreatePaymentResult, error) {
+	amountKopecks, err := tinkoffAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
 
 	req := tinkoffInitRequest{
 		TerminalKey:     g.terminalKey,
@@ -122,6 +141,7 @@ func (g *TinkoffGateway) CreatePayment(ctx context.Context, input CreatePaymentI
 		NotificationURL: g.notifyURL,
 		SuccessURL:      input.ReturnURL,
 		FailURL:         input.ReturnURL,
+		PayType:         payType,
 		DATA:            input.Metadata,
 	}
 
@@ -134,7 +154,7 @@ func (g *TinkoffGateway) CreatePayment(ctx context.Context, input CreatePaymentI
 	// Use saved card
 	if input.TokenID != "" {
 		// For recurring payment, use ChargeWithRebillId instead
-		return g.chargeRecurring(ctx, input)
+		return g.chargeRecurring(ctx, input, payType)
 	}
 
 	// Add receipt for 54-FZ
@@ -155,16 +175,18 @@ func (g *TinkoffGateway) CreatePayment(ctx context.Context, input CreatePaymentI
 		}
 	}
 
-	// Sign request
-	req.Token = g.signRequest(map[string]interface{}{
-		"TerminalKey": req.TerminalKey,
-		"Amount":      req.Amount,
-		"OrderId":     req.OrderId,
-		"Description": req.Description,
-	})
+	// Sign request: every root-level field is signed, nested DATA and Receipt are not
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	signed := make(map[string]interface{})
+	json.Unmarshal(body, &signed)
+	delete(signed, "Token")
+	req.Token = g.signRequest(signed)
 
 	// Send request
-	body, err := json.Marshal(req)
+	body, err = json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
@@ -180,6 +202,9 @@ func (g *TinkoffGateway) CreatePayment(ctx context.Context, input CreatePaymentI
 		return nil, fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -204,15 +229,18 @@ func (g *TinkoffGateway) CreatePayment(ctx context.Context, input CreatePaymentI
 }
 
 // chargeRecurring charges saved card
-func (g *TinkoffGateway) chargeRecurring(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error) {
+func (g *TinkoffGateway) chargeRecurring(ctx context.Context, input CreatePaymentInput, payType string) (*CreatePaymentResult, error) {
 	// First init payment
 	initReq := map[string]interface{}{
 		"TerminalKey": g.terminalKey,
-		"Amount":      int64(input.Amount * 100),
+		"Amount":      input.Amount.Minor, // currency checked by initPayment
 		"OrderId":     input.PaymentID,
 		"Description": input.Description,
 		"CustomerKey": input.Metadata["user_id"],
 	}
+	if payType != "" {
+		initReq["PayType"] = payType
+	}
 	initReq["Token"] = g.signRequest(initReq)
 
 	body, _ := json.Marshal(initReq)
@@ -224,6 +252,9 @@ func (g *TinkoffGateway) chargeRecurring(ctx context.Context, input CreatePaymen
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 	var initResp tinkoffInitResponse
@@ -250,6 +281,9 @@ func (g *TinkoffGateway) chargeRecurring(ctx context.Context, input CreatePaymen
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, _ = io.ReadAll(resp.Body)
 	var chargeResp struct {
@@ -289,6 +323,9 @@ func (g *TinkoffGateway) GetPaymentStatus(ctx context.Context, externalID string
 		return "", err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 	var stateResp struct {
@@ -302,7 +339,10 @@ func (g *TinkoffGateway) GetPaymentStatus(ctx context.Context, externalID string
 
 // Refund processes refund
 func (g *TinkoffGateway) Refund(ctx context.Context, input RefundInput) (*RefundResult, error) {
-	amountKopecks := int64(input.Amount * 100)
+	amountKopecks, err := tinkoffAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
 
 	req := map[string]interface{}{
 		"TerminalKey": g.terminalKey,
@@ -320,6 +360,9 @@ func (g *TinkoffGateway) Refund(ctx context.Context, input RefundInput) (*Refund
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 	var cancelResp struct {
@@ -340,11 +383,68 @@ func (g *TinkoffGateway) Refund(ctx context.Context, input RefundInput) (*Refund
 	return &RefundResult{
 		RefundID:   cancelResp.PaymentId,
 		ExternalID: input.ExternalID,
-		Amount:     float64(cancelResp.OriginalAmount-cancelResp.NewAmount) / 100,
-		Status:     mapTinkoffStatus(cancelResp.Status),
+		Amount:     money.New(cancelResp.OriginalAmount-cancelResp.NewAmount, money.RUB),
+		Status:     mapTinkoffRefundStatus(cancelResp.Status),
 	}, nil
 }
 
+// Capture confirms a two-stage payment for input.Amount (at most the authorized amount)
+func (g *TinkoffGateway) Capture(ctx context.Context, input HoldInput) error {
+	amountKopecks, err := tinkoffAmount(input.Amount)
+	if err != nil {
+		return err
+	}
+	return g.holdCall(ctx, "/Confirm", map[string]interface{}{
+		"TerminalKey": g.terminalKey,
+		"PaymentId":   input.ExternalID,
+		"Amount":      amountKopecks,
+	})
+}
+
+// Void cancels an authorized payment: the hold is released, nothing is charged
+func (g *TinkoffGateway) Void(ctx context.Context, input HoldInput) error {
+	return g.holdCall(ctx, "/Cancel", map[string]interface{}{
+		"TerminalKey": g.terminalKey,
+		"PaymentId":   input.ExternalID,
+	})
+}
+
+// holdCall signs and sends a Confirm/Cancel request, failing unless Success
+func (g *TinkoffGateway) holdCall(ctx context.Context, method string, req map[string]interface{}) error {
+	req["Token"] = g.signRequest(req)
+
+	body, _ := json.Marshal(req)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", g.apiURL+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	respBody, _ := io.ReadAll(resp.Body)
+	var holdResp struct {
+		Success   bool   `json:"Success"`
+		Status    string `json:"Status"`
+		ErrorCode string `json:"ErrorCode"`
+		Message   string `json:"Message"`
+	}
+	if err := json.Unmarshal(respBody, &holdResp); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	if !holdResp.Success {
+		return fmt.Errorf("%w: %s - %s", ErrPaymentRejected, holdResp.ErrorCode, holdResp.Message)
+	}
+	return nil
+}
+
 // tinkoffWebhook — Tinkoff notification
 type tinkoffWebhook struct {
 	TerminalKey string `json:"TerminalKey"`
@@ -380,7 +480,9 @@ func (g *TinkoffGateway) ParseWebhook(ctx context.Context, body []byte, signatur
 
 	eventType := "payment.unknown"
 	switch wh.Status {
-	case "CONFIRMED", "AUTHORIZED":
+	case "AUTHORIZED":
+		eventType = "payment.authorized" // two-stage hold; one-stage payments follow with CONFIRMED
+	case "CONFIRMED":
 		eventType = "payment.succeeded"
 	case "REJECTED", "DEADLINE_EXPIRED":
 		eventType = "payment.failed"
@@ -390,14 +492,21 @@ func (g *TinkoffGateway) ParseWebhook(ctx context.Context, body []byte, signatur
 		eventType = "payment.cancelled"
 	}
 
+	eventID := fmt.Sprintf("%d:%s:%d", wh.PaymentId, wh.Status, wh.Amount)
+	if eventType == "refund.succeeded" {
+		eventID = "" // notifications name no refund: equal partial refunds look alike
+	}
+
 	return &domain.WebhookEvent{
 		Provider:   domain.ProviderTinkoff,
+		EventID:    eventID,
 		EventType:  eventType,
 		PaymentID:  wh.OrderId,
 		ExternalID: strconv.FormatInt(wh.PaymentId, 10),
 		Status:     mapTinkoffStatus(wh.Status),
-		Amount:     float64(wh.Amount) / 100,
+		Amount:     money.New(wh.Amount, money.RUB),
 		RawPayload: string(body),
+		SignatureVerified: true, // the token is always checked
 	}, nil
 }
 
@@ -419,6 +528,9 @@ func (g *TinkoffGateway) GetSavedCard(ctx context.Context, externalID string) (*
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 	var stateResp struct {
@@ -458,22 +570,26 @@ func (g *TinkoffGateway) GetSavedCard(ctx context.Context, externalID string) (*
 	}, nil
 }
 
-// signRequest creates Tinkoff signature
+// signRequest creates Tinkoff signature; the password is signed but never added to params,
+// which are sent as the request body
 func (g *TinkoffGateway) signRequest(params map[string]interface{}) string {
-	// Add password
-	params["Password"] = g.password
-
-	// Sort keys
-	keys := make([]string, 0, len(params))
+	// Sort keys, with the password
+	keys := make([]string, 0, len(params)+1)
 	for k := range params {
-		keys = append(keys, k)
+		if k != "Password" {
+			keys = append(keys, k)
+		}
 	}
+	keys = append(keys, "Password")
 	sort.Strings(keys)
 
 	// Concatenate values
 	var sb strings.Builder
 	for _, k := range keys {
 		v := params[k]
+		if k == "Password" {
+			v = g.password
+		}
 		switch val := v.(type) {
 		case string:
 			sb.WriteString(val)
@@ -497,6 +613,24 @@ func (g *TinkoffGateway) signRequest(params map[string]interface{}) string {
 	return hex.EncodeToString(hash[:])
 }
 
+// tinkoffAmount — Tinkoff takes rubles only, as an integer number of kopecks
+func tinkoffAmount(m money.Money) (int64, error) {
+	if m.Currency != money.RUB {
+		return 0, fmt.Errorf("%w: tinkoff accepts RUB only, got %s", ErrPaymentRejected, m.Currency)
+	}
+	return m.Minor, nil
+}
+
+// mapTinkoffRefundStatus maps the payment status a Cancel (refund) leaves to the refund's
+func mapTinkoffRefundStatus(status string) string {
+	switch status {
+	case "REFUNDED", "PARTIAL_REFUNDED":
+		return domain.RefundStatusSucceeded
+	default: // QUEUED, REFUNDING: settled by the notification
+		return domain.RefundStatusPending
+	}
+}
+
 // mapTinkoffStatus maps Tinkoff status to domain status
 func mapTinkoffStatus(status string) string {
 	switch status {
@@ -504,7 +638,9 @@ func mapTinkoffStatus(status string) string {
 		return domain.PaymentStatusPending
 	case "AUTHORIZING", "3DS_CHECKING", "3DS_CHECKED", "PREAUTHORIZING":
 		return domain.PaymentStatusProcessing
-	case "AUTHORIZED", "CONFIRMED":
+	case "AUTHORIZED":
+		return domain.PaymentStatusAuthorized
+	case "CONFIRMED":
 		return domain.PaymentStatusCompleted
 	case "REJECTED", "DEADLINE_EXPIRED":
 		return domain.PaymentStatusFailed
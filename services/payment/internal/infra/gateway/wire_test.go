@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+func TestTinkoff_SendsExactKopecks(t *testing.T) {
+	var got tinkoffInitRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"Success":true,"Status":"NEW","PaymentId":"1","PaymentURL":"https://pay"}`))
+	}))
+	defer srv.Close()
+	g := NewTinkoffGateway(TinkoffConfig{TerminalKey: "t", Password: "p"})
+	g.apiURL = srv.URL
+
+	_, err := g.CreatePayment(context.Background(), CreatePaymentInput{
+		PaymentID: "p1", Amount: money.FromMajor(199.99, money.RUB), UserEmail: "a@b.c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Amount != 19999 || got.Receipt.Items[0].Amount != 19999 {
+		t.Errorf("Init amount %d, receipt %d; want 19999 kopecks", got.Amount, got.Receipt.Items[0].Amount)
+	}
+	if _, err := g.CreatePayment(context.Background(), CreatePaymentInput{Amount: money.New(100, "USD")}); err == nil {
+		t.Error("tinkoff must refuse non-ruble amounts")
+	}
+}
+
+func TestWireAmounts(t *testing.T) {
+	m := money.New(19999, money.RUB)
+	if a := toYooAmount(m); a.Value != "199.99" || a.Currency != "RUB" {
+		t.Errorf("yoomoney: %+v", a)
+	}
+	if back, err := fromYooAmount(yooAmount{Value: "199.99", Currency: "RUB"}); err != nil || back != m {
+		t.Errorf("yoomoney parse: %v, %v", back, err)
+	}
+	if amount, currency, err := sberAmount(m); err != nil || amount != "19999" || currency != "643" {
+		t.Errorf("sber: %s %s %v", amount, currency, err)
+	}
+	if _, _, err := sberAmount(money.New(1, "XXX")); err == nil {
+		t.Error("sber needs a known currency")
+	}
+}
+
+func TestTinkoff_TwoStage(t *testing.T) {
+	calls := map[string]map[string]interface{}{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		calls[r.URL.Path] = req
+		_, _ = w.Write([]byte(`{"Success":true,"Status":"AUTHORIZED","PaymentId":"42"}`))
+	}))
+	defer srv.Close()
+	g := NewTinkoffGateway(TinkoffConfig{TerminalKey: "t", Password: "p"})
+	g.apiURL = srv.URL
+	ctx := context.Background()
+
+	res, err := g.Authorize(ctx, CreatePaymentInput{PaymentID: "p1", Amount: money.New(60000, money.RUB), TokenID: "rebill-1"})
+	if err != nil || res.Status != "authorized" {
+		t.Fatalf("Authorize = %+v, %v", res, err)
+	}
+	if calls["/Init"]["PayType"] != "T" || calls["/Charge"]["RebillId"] != "rebill-1" {
+		t.Errorf("two-stage Init with the saved card expected: %v %v", calls["/Init"], calls["/Charge"])
+	}
+	if err := g.Capture(ctx, HoldInput{ExternalID: "42", Amount: money.New(58000, money.RUB)}); err != nil {
+		t.Fatal(err)
+	}
+	if c := calls["/Confirm"]; c["PaymentId"] != "42" || c["Amount"] != float64(58000) || c["Password"] != nil || c["Token"] == "" {
+		t.Errorf("Confirm request: %v", c)
+	}
+	if err := g.Void(ctx, HoldInput{ExternalID: "42"}); err != nil || calls["/Cancel"]["PaymentId"] != "42" {
+		t.Errorf("Void: %v %v", calls["/Cancel"], err)
+	}
+}
+
+func TestYooMoneyPayout(t *testing.T) {
+	var got yooPayoutRequest
+	var idemKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idemKey = r.Header.Get("Idempotence-Key")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if got.PayoutToken == "revoked" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"type":"error","code":"invalid_request","description":"payout_token"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"po-1","status":"pending"}`))
+	}))
+	defer srv.Close()
+	g := NewYooMoneyPayoutGateway(YooMoneyPayoutConfig{AgentID: "a", SecretKey: "s"})
+	g.apiURL = srv.URL
+	ctx := context.Background()
+
+	res, err := g.Payout(ctx, PayoutInput{PayoutID: "p1", Amount: money.New(123456, money.RUB), Destination: "410011758831136"})
+	if err != nil || res.ExternalID != "po-1" || res.Status != "processing" {
+		t.Fatalf("Payout = %+v, %v", res, err)
+	}
+	if got.Amount.Value != "1234.56" || got.PayoutDestinationData == nil || got.PayoutDestinationData.AccountNumber != "410011758831136" || idemKey != "p1" {
+		t.Errorf("wallet payout request: %+v (key %q)", got, idemKey)
+	}
+
+	got = yooPayoutRequest{}
+	if _, err := g.Payout(ctx, PayoutInput{PayoutID: "p2", Amount: money.New(100, money.RUB), Destination: "revoked"}); !errors.Is(err, ErrPaymentRejected) {
+		t.Errorf("rejected token: %v", err)
+	}
+	if got.PayoutToken != "revoked" || got.PayoutDestinationData != nil {
+		t.Errorf("token payout request: %+v", got)
+	}
+}
+
+func TestParseWebhook_RequiresSignatureWhenConfigured(t *testing.T) {
+	ctx := context.Background()
+	sberBody := []byte("mdOrder=ord-1&orderNumber=p1&operation=deposited&status=1&amount=30000")
+	if _, err := NewSberGateway(SberConfig{Password: "secret"}).ParseWebhook(ctx, sberBody, ""); !errors.Is(err, ErrInvalidWebhook) {
+		t.Errorf("sber callback without checksum: %v", err)
+	}
+	if event, err := NewSberGateway(SberConfig{}).ParseWebhook(ctx, sberBody, ""); err != nil || event.SignatureVerified {
+		t.Errorf("sber without password must accept, unverified: %+v, %v", event, err)
+	}
+
+	yooBody := []byte(`{"type":"notification","event":"payment.succeeded","object":{"id":"ext-1","status":"succeeded","amount":{"value":"300.00","currency":"RUB"}}}`)
+	if _, err := NewYooMoneyGateway(YooMoneyConfig{WebhookSecret: "secret"}).ParseWebhook(ctx, yooBody, ""); !errors.Is(err, ErrInvalidWebhook) {
+		t.Errorf("yoomoney callback without signature: %v", err)
+	}
+	if event, err := NewYooMoneyGateway(YooMoneyConfig{}).ParseWebhook(ctx, yooBody, ""); err != nil || event.SignatureVerified {
+		t.Errorf("yoomoney without secret must accept, unverified: %+v, %v", event, err)
+	}
+}
@@ -12,8 +12,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/alexevil1979/indrive/packages/money-go"
+
 	"github.com/ridehail/payment/internal/domain"
 )
 
@@ -25,6 +28,7 @@ type YooMoneyConfig struct {
 	SecretKey string // Secret API key
 	WebhookSecret string // Secret for webhook signature
 	ReturnURL string // Default return URL
+	APIURL    string // Overrides the API (e.g. a local fake provider)
 }
 
 // YooMoneyGateway — YooMoney (ЮКасса) gateway
@@ -33,16 +37,22 @@ type YooMoneyGateway struct {
 	secretKey     string
 	webhookSecret string
 	returnURL     string
+	apiURL        string
 	client        *http.Client
 }
 
 // NewYooMoneyGateway creates YooMoney gateway
 func NewYooMoneyGateway(cfg YooMoneyConfig) *YooMoneyGateway {
+	apiURL := yooMoneyAPIURL
+	if cfg.APIURL != "" {
+		apiURL = cfg.APIURL
+	}
 	return &YooMoneyGateway{
 		shopID:        cfg.ShopID,
 		secretKey:     cfg.SecretKey,
 		webhookSecret: cfg.WebhookSecret,
 		returnURL:     cfg.ReturnURL,
+		apiURL:        apiURL,
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -54,6 +64,11 @@ func (g *YooMoneyGateway) Provider() string {
 	return domain.ProviderYooMoney
 }
 
+// Idempotent — every POST carries an Idempotence-Key, so repeating one is safe
+func (g *YooMoneyGateway) Idempotent() bool {
+	return true
+}
+
 // yooAmount — YooMoney amount
 type yooAmount struct {
 	Value    string `json:"value"`    // "100.00"
@@ -106,6 +121,7 @@ type yooPayment struct {
 	Confirmation  *yooConfirmation `json:"confirmation,omitempty"`
 	PaymentMethod *yooPaymentMethod `json:"payment_method,omitempty"`
 	Metadata      map[string]string `json:"metadata,omitempty"`
+	PaymentID     string          `json:"payment_id,omitempty"` // refund objects: the refunded payment
 	CreatedAt     string          `json:"created_at"`
 	Paid          bool            `json:"paid"`
 	Refundable    bool            `json:"refundable"`
@@ -129,18 +145,24 @@ type yooCard struct {
 
 // CreatePayment initiates payment via YooMoney
 func (g *YooMoneyGateway) CreatePayment(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error) {
+	return g.createPayment(ctx, input, true)
+}
+
+// Authorize creates the payment with capture: false — it stops at waiting_for_capture
+func (g *YooMoneyGateway) Authorize(ctx context.Context, input CreatePaymentInput) (*CreatePaymentResult, error) {
+	return g.createPayment(ctx, input, false)
+}
+
+func (g *YooMoneyGateway) createPayment(ctx context.Context, input CreatePaymentInput, capture bool) (*CreatePaymentResult, error) {
 	returnURL := input.ReturnURL
 	if returnURL == "" {
 		returnURL = g.returnURL
 	}
 
 	req := yooCreateRequest{
-		Amount: yooAmount{
-			Value:    fmt.Sprintf("%.2f", input.Amount),
-			Currency: input.Currency,
-		},
+		Amount: toYooAmount(input.Amount),
 		Description:       input.Description,
-		Capture:           true,
+		Capture:           capture,
 		SavePaymentMethod: input.SaveCard,
 		Metadata:          input.Metadata,
 	}
@@ -173,10 +195,7 @@ func (g *YooMoneyGateway) CreatePayment(ctx context.Context, input CreatePayment
 				{
 					Description: input.Description,
 					Quantity:    "1",
-					Amount: yooAmount{
-						Value:    fmt.Sprintf("%.2f", input.Amount),
-						Currency: input.Currency,
-					},
+					Amount: toYooAmount(input.Amount),
 					VatCode: 1,
 				},
 			},
@@ -188,7 +207,7 @@ func (g *YooMoneyGateway) CreatePayment(ctx context.Context, input CreatePayment
 		return nil, fmt.Errorf("marshal request: %w", err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", yooMoneyAPIURL+"/payments", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", g.apiURL+"/payments", bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
@@ -201,6 +220,9 @@ func (g *YooMoneyGateway) CreatePayment(ctx context.Context, input CreatePayment
 		return nil, fmt.Errorf("send request: %w", err)
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
@@ -238,7 +260,7 @@ func (g *YooMoneyGateway) CreatePayment(ctx context.Context, input CreatePayment
 
 // GetPaymentStatus gets payment status
 func (g *YooMoneyGateway) GetPaymentStatus(ctx context.Context, externalID string) (string, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", yooMoneyAPIURL+"/payments/"+externalID, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", g.apiURL+"/payments/"+externalID, nil)
 	if err != nil {
 		return "", err
 	}
@@ -249,6 +271,9 @@ func (g *YooMoneyGateway) GetPaymentStatus(ctx context.Context, externalID strin
 		return "", err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return "", err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 	var payment yooPayment
@@ -261,22 +286,19 @@ func (g *YooMoneyGateway) GetPaymentStatus(ctx context.Context, externalID strin
 func (g *YooMoneyGateway) Refund(ctx context.Context, input RefundInput) (*RefundResult, error) {
 	req := map[string]interface{}{
 		"payment_id": input.ExternalID,
-		"amount": map[string]string{
-			"value":    fmt.Sprintf("%.2f", input.Amount),
-			"currency": "RUB",
-		},
+		"amount":     toYooAmount(input.Amount),
 	}
 	if input.Reason != "" {
 		req["description"] = input.Reason
 	}
 
 	body, _ := json.Marshal(req)
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", yooMoneyAPIURL+"/refunds", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", g.apiURL+"/refunds", bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Idempotence-Key", input.PaymentID+"-refund")
+	httpReq.Header.Set("Idempotence-Key", input.RefundID) // one per refund: partial refunds repeat the payment
 	httpReq.SetBasicAuth(g.shopID, g.secretKey)
 
 	resp, err := g.client.Do(httpReq)
@@ -284,6 +306,9 @@ func (g *YooMoneyGateway) Refund(ctx context.Context, input RefundInput) (*Refun
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 
@@ -298,10 +323,13 @@ func (g *YooMoneyGateway) Refund(ctx context.Context, input RefundInput) (*Refun
 
 	var refund struct {
 		ID     string    `json:"id"`
-		Status string    `json:"status"`
+		Status string    `json:"status"` // pending, succeeded, canceled
 		Amount yooAmount `json:"amount"`
 	}
 	json.Unmarshal(respBody, &refund)
+	if refund.Status == "canceled" {
+		return nil, fmt.Errorf("%w: refund canceled", ErrPaymentRejected)
+	}
 
 	return &RefundResult{
 		RefundID:   refund.ID,
@@ -311,6 +339,49 @@ func (g *YooMoneyGateway) Refund(ctx context.Context, input RefundInput) (*Refun
 	}, nil
 }
 
+// Capture captures input.Amount of a waiting_for_capture payment; the rest is released
+func (g *YooMoneyGateway) Capture(ctx context.Context, input HoldInput) error {
+	return g.holdCall(ctx, input.ExternalID, "capture", input.PaymentID+"-capture",
+		map[string]interface{}{"amount": toYooAmount(input.Amount)})
+}
+
+// Void cancels a waiting_for_capture payment
+func (g *YooMoneyGateway) Void(ctx context.Context, input HoldInput) error {
+	return g.holdCall(ctx, input.ExternalID, "cancel", input.PaymentID+"-void", map[string]interface{}{})
+}
+
+// holdCall — POST /payments/{id}/{action}
+func (g *YooMoneyGateway) holdCall(ctx context.Context, externalID, action, idempotenceKey string, req map[string]interface{}) error {
+	body, _ := json.Marshal(req)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", g.apiURL+"/payments/"+externalID+"/"+action, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Idempotence-Key", idempotenceKey)
+	httpReq.SetBasicAuth(g.shopID, g.secretKey)
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(resp.Body)
+		var errResp struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		}
+		json.Unmarshal(respBody, &errResp)
+		return fmt.Errorf("%w: %s - %s", ErrPaymentRejected, errResp.Code, errResp.Description)
+	}
+	return nil
+}
+
 // yooWebhook — YooMoney notification
 type yooWebhook struct {
 	Type   string `json:"type"` // notification
@@ -320,12 +391,12 @@ type yooWebhook struct {
 
 // ParseWebhook parses YooMoney webhook
 func (g *YooMoneyGateway) ParseWebhook(ctx context.Context, body []byte, signature string) (*domain.WebhookEvent, error) {
-	// Verify signature if webhook secret is set
-	if g.webhookSecret != "" && signature != "" {
+	// Verify signature if webhook secret is set; a callback without one is then refused
+	if g.webhookSecret != "" {
 		mac := hmac.New(sha256.New, []byte(g.webhookSecret))
 		mac.Write(body)
 		expectedSig := hex.EncodeToString(mac.Sum(nil))
-		if signature != expectedSig {
+		if signature == "" || !hmac.Equal([]byte(signature), []byte(expectedSig)) {
 			return nil, ErrInvalidWebhook
 		}
 	}
@@ -340,21 +411,31 @@ func (g *YooMoneyGateway) ParseWebhook(ctx context.Context, body []byte, signatu
 	if wh.Object.Metadata != nil {
 		paymentID = wh.Object.Metadata["payment_id"]
 	}
+	amount, err := fromYooAmount(wh.Object.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("webhook amount: %w", err)
+	}
 
-	return &domain.WebhookEvent{
+	event := &domain.WebhookEvent{
 		Provider:   domain.ProviderYooMoney,
-		EventType:  wh.Event,
+		EventID:    wh.Event + ":" + wh.Object.ID, // the object is the refund for refund events
+		EventType:  yooEventType(wh.Event),
 		PaymentID:  paymentID,
 		ExternalID: wh.Object.ID,
 		Status:     mapYooMoneyStatus(wh.Object.Status),
-		Amount:     parseAmount(wh.Object.Amount.Value),
+		Amount:     amount,
 		RawPayload: string(body),
-	}, nil
+		SignatureVerified: g.webhookSecret != "",
+	}
+	if strings.HasPrefix(wh.Event, "refund.") {
+		event.RefundID, event.ExternalID = wh.Object.ID, wh.Object.PaymentID
+	}
+	return event, nil
 }
 
 // GetSavedCard returns saved card info
 func (g *YooMoneyGateway) GetSavedCard(ctx context.Context, externalID string) (*CardInfo, error) {
-	httpReq, _ := http.NewRequestWithContext(ctx, "GET", yooMoneyAPIURL+"/payments/"+externalID, nil)
+	httpReq, _ := http.NewRequestWithContext(ctx, "GET", g.apiURL+"/payments/"+externalID, nil)
 	httpReq.SetBasicAuth(g.shopID, g.secretKey)
 
 	resp, err := g.client.Do(httpReq)
@@ -362,6 +443,9 @@ func (g *YooMoneyGateway) GetSavedCard(ctx context.Context, externalID string) (
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
 
 	respBody, _ := io.ReadAll(resp.Body)
 	var payment yooPayment
@@ -392,7 +476,7 @@ func mapYooMoneyStatus(status string) string {
 	case "pending":
 		return domain.PaymentStatusPending
 	case "waiting_for_capture":
-		return domain.PaymentStatusProcessing
+		return domain.PaymentStatusAuthorized
 	case "succeeded":
 		return domain.PaymentStatusCompleted
 	case "canceled":
@@ -402,6 +486,18 @@ func mapYooMoneyStatus(status string) string {
 	}
 }
 
+// yooEventType maps YooMoney events to ours; the rest share names
+func yooEventType(event string) string {
+	switch event {
+	case "payment.waiting_for_capture":
+		return "payment.authorized"
+	case "payment.canceled":
+		return "payment.cancelled"
+	default:
+		return event
+	}
+}
+
 // mapCardType maps YooMoney card type
 func mapCardType(cardType string) string {
 	switch cardType {
@@ -416,9 +512,13 @@ func mapCardType(cardType string) string {
 	}
 }
 
-// parseAmount parses string amount to float64
-func parseAmount(s string) float64 {
-	var f float64
-	fmt.Sscanf(s, "%f", &f)
-	return f
+// toYooAmount — YooMoney takes a decimal string in major units with the currency's
+// decimals ("199.99")
+func toYooAmount(m money.Money) yooAmount {
+	return yooAmount{Value: m.Decimal(), Currency: m.Currency}
+}
+
+// fromYooAmount reads a YooMoney amount exactly
+func fromYooAmount(a yooAmount) (money.Money, error) {
+	return money.Parse(a.Value, a.Currency)
 }
@@ -0,0 +1,164 @@
+// Package gateway — YooMoney Payouts (ЮKassa Выплаты): driver earnings to a bank card
+// payout token or a YooMoney wallet
+// Docs: https://yookassa.ru/developers/payouts
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+const yooMoneyPayoutsURL = "https://payouts.yookassa.ru/v3"
+
+// YooMoneyPayoutConfig — YooMoney Payouts gateway configuration
+type YooMoneyPayoutConfig struct {
+	AgentID   string // payouts gateway (agent) id
+	SecretKey string // payouts secret key
+}
+
+// YooMoneyPayoutGateway — YooMoney Payouts
+type YooMoneyPayoutGateway struct {
+	agentID   string
+	secretKey string
+	apiURL    string
+	client    *http.Client
+}
+
+// NewYooMoneyPayoutGateway creates YooMoney Payouts gateway
+func NewYooMoneyPayoutGateway(cfg YooMoneyPayoutConfig) *YooMoneyPayoutGateway {
+	return &YooMoneyPayoutGateway{
+		agentID:   cfg.AgentID,
+		secretKey: cfg.SecretKey,
+		apiURL:    yooMoneyPayoutsURL,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Provider returns provider name
+func (g *YooMoneyPayoutGateway) Provider() string {
+	return domain.PayoutProviderYooMoney
+}
+
+// yooPayoutRequest — POST /payouts
+type yooPayoutRequest struct {
+	Amount                yooAmount             `json:"amount"`
+	PayoutToken           string                `json:"payout_token,omitempty"`
+	PayoutDestinationData *yooPayoutDestination `json:"payout_destination_data,omitempty"`
+	Description           string                `json:"description,omitempty"`
+	Metadata              map[string]string     `json:"metadata,omitempty"`
+}
+
+type yooPayoutDestination struct {
+	Type          string `json:"type"` // yoo_money
+	AccountNumber string `json:"account_number"`
+}
+
+// yooPayout — payout object
+type yooPayout struct {
+	ID                  string `json:"id"`
+	Status              string `json:"status"` // pending, succeeded, canceled
+	CancellationDetails *struct {
+		Party  string `json:"party"`
+		Reason string `json:"reason"`
+	} `json:"cancellation_details,omitempty"`
+}
+
+// Payout creates a payout. A destination of digits only is a YooMoney wallet number,
+// anything else a card payout token from the payouts widget.
+func (g *YooMoneyPayoutGateway) Payout(ctx context.Context, input PayoutInput) (*PayoutResult, error) {
+	req := yooPayoutRequest{
+		Amount:      toYooAmount(input.Amount),
+		Description: input.Description,
+		Metadata:    map[string]string{"payout_id": input.PayoutID},
+	}
+	if isWalletNumber(input.Destination) {
+		req.PayoutDestinationData = &yooPayoutDestination{Type: "yoo_money", AccountNumber: input.Destination}
+	} else {
+		req.PayoutToken = input.Destination
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+	return g.call(ctx, "POST", "/payouts", input.PayoutID, body)
+}
+
+// GetPayoutStatus gets the payout
+func (g *YooMoneyPayoutGateway) GetPayoutStatus(ctx context.Context, externalID string) (*PayoutResult, error) {
+	return g.call(ctx, "GET", "/payouts/"+externalID, "", nil)
+}
+
+func (g *YooMoneyPayoutGateway) call(ctx context.Context, method, path, idempotenceKey string, body []byte) (*PayoutResult, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, g.apiURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if idempotenceKey != "" {
+		httpReq.Header.Set("Idempotence-Key", idempotenceKey)
+	}
+	httpReq.SetBasicAuth(g.agentID, g.secretKey)
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("%w: status %d", ErrProviderUnavailable, resp.StatusCode)
+	}
+	if resp.StatusCode >= 400 {
+		var errResp struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		}
+		json.Unmarshal(respBody, &errResp)
+		return nil, fmt.Errorf("%w: %s - %s", ErrPaymentRejected, errResp.Code, errResp.Description)
+	}
+
+	var payout yooPayout
+	if err := json.Unmarshal(respBody, &payout); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	result := &PayoutResult{ExternalID: payout.ID, Status: mapYooPayoutStatus(payout.Status)}
+	if payout.CancellationDetails != nil {
+		result.FailReason = payout.CancellationDetails.Party + ": " + payout.CancellationDetails.Reason
+	}
+	return result, nil
+}
+
+func mapYooPayoutStatus(status string) string {
+	switch status {
+	case "succeeded":
+		return domain.PayoutStatusPaid
+	case "canceled":
+		return domain.PayoutStatusFailed
+	default:
+		return domain.PayoutStatusProcessing
+	}
+}
+
+// isWalletNumber — YooMoney wallets are 11 to 20 digits
+func isWalletNumber(s string) bool {
+	if len(s) < 11 || len(s) > 20 {
+		return false
+	}
+	return strings.Trim(s, "0123456789") == ""
+}
@@ -0,0 +1,273 @@
+// Package kafka — ride events consumed by the payment service (2026)
+// Topics: ride.matched (hold the fare of card rides), ride.status.changed (capture and book the
+// driver's earning on completion, void on cancellation); both produced by the ride
+// service, keyed by ride id. Events that keep failing go to payment.ride.dead.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+const (
+	TopicRideMatched       = "ride.matched"
+	TopicRideStatusChanged = "ride.status.changed"
+	// TopicRideDeadLetters — ride events given up on after the retries, as received
+	// (same key and value) with the original topic, offset and last error in headers
+	TopicRideDeadLetters = "payment.ride.dead"
+
+	// GroupID — payment instances share the events: each hold is placed once
+	GroupID = "payment"
+)
+
+// Ride statuses the payment service acts on
+const (
+	rideStatusCompleted = "completed"
+	rideStatusCancelled = "cancelled"
+)
+
+// RidePayments — two-stage card payments of rides
+type RidePayments interface {
+	HoldRide(ctx context.Context, rideID, passengerID, cardID string, price money.Money) (*domain.Payment, error)
+	CaptureRide(ctx context.Context, rideID string, fare *domain.RideFare) (*domain.Payment, error)
+	VoidRide(ctx context.Context, rideID string) (*domain.Payment, error)
+}
+
+// RideEarnings — driver earnings of completed rides
+type RideEarnings interface {
+	CompleteRide(ctx context.Context, ride domain.RideCompletion) error
+	RecordRide(ctx context.Context, ride domain.RideCompletion) (*domain.RideEarning, error)
+}
+
+// Ride payment methods (ride.matched payment_method)
+const (
+	ridePaymentCard = "card" // card_id: the passenger's saved payment method
+)
+
+// rideMatched — ride.matched payload
+type rideMatched struct {
+	RideID        string      `json:"ride_id"`
+	PassengerID   string      `json:"passenger_id"`
+	Price         money.Money `json:"price"`
+	PaymentMethod string      `json:"payment_method"`
+	CardID        string      `json:"card_id"`
+}
+
+// rideStatusChanged — ride.status.changed payload; fare, driver, category and payment
+// method are set for completed rides
+type rideStatusChanged struct {
+	RideID        string    `json:"ride_id"`
+	Status        string    `json:"status"`
+	Fare          *rideFare `json:"fare"`
+	DriverID      string    `json:"driver_id"`
+	Category      string    `json:"category"`
+	PaymentMethod string    `json:"payment_method"`
+}
+
+// rideFare — fare of a completed ride; the ride service tracks no waiting, stops or
+// tips, so its price is the whole fare
+type rideFare struct {
+	Price money.Money `json:"price"`
+}
+
+func (f *rideFare) domain() *domain.RideFare {
+	if f == nil {
+		return nil
+	}
+	return &domain.RideFare{Price: f.Price}
+}
+
+// RetryPolicy — how a failing ride event is retried before it is dead-lettered: up to
+// Attempts handler calls, waiting Backoff after the first failure, doubling up to MaxBackoff
+type RetryPolicy struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy — about a minute of retries, enough to ride out a provider or
+// database blip
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{Attempts: 6, Backoff: time.Second, MaxBackoff: 30 * time.Second}
+}
+
+// RideConsumer reads ride events in the payment consumer group from the oldest
+// uncommitted offset. A message is marked once handled: failures are retried (the hold,
+// capture and earning calls are idempotent), then sent to TopicRideDeadLetters. When
+// neither succeeds the message stays unmarked and the session ends, so the group
+// resumes from it.
+type RideConsumer struct {
+	group    sarama.ConsumerGroup
+	dead     sarama.SyncProducer
+	payments RidePayments
+	earnings RideEarnings
+	retry    RetryPolicy
+}
+
+func NewRideConsumer(brokers []string, payments RidePayments, earnings RideEarnings, retry RetryPolicy) (*RideConsumer, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Return.Errors = true
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Producer.Return.Successes = true
+	group, err := sarama.NewConsumerGroup(brokers, GroupID, config)
+	if err != nil {
+		return nil, err
+	}
+	dead, err := sarama.NewSyncProducer(brokers, config)
+	if err != nil {
+		group.Close()
+		return nil, err
+	}
+	return &RideConsumer{group: group, dead: dead, payments: payments, earnings: earnings, retry: retry}, nil
+}
+
+// Run consumes in the background until ctx is cancelled
+func (c *RideConsumer) Run(ctx context.Context) {
+	go func() {
+		for err := range c.group.Errors() {
+			slog.Warn("kafka consume failed", "group", GroupID, "error", err)
+		}
+	}()
+	go func() {
+		topics := []string{TopicRideMatched, TopicRideStatusChanged}
+		for ctx.Err() == nil {
+			if err := c.group.Consume(ctx, topics, c); err != nil {
+				if errors.Is(err, sarama.ErrClosedConsumerGroup) {
+					return
+				}
+				slog.Warn("kafka consumer group session ended", "group", GroupID, "error", err)
+			}
+		}
+	}()
+}
+
+func (c *RideConsumer) Close() error {
+	err := c.group.Close()
+	if perr := c.dead.Close(); err == nil {
+		err = perr
+	}
+	return err
+}
+
+// Setup implements sarama.ConsumerGroupHandler
+func (c *RideConsumer) Setup(sarama.ConsumerGroupSession) error { return nil }
+
+// Cleanup implements sarama.ConsumerGroupHandler
+func (c *RideConsumer) Cleanup(sarama.ConsumerGroupSession) error { return nil }
+
+// ConsumeClaim implements sarama.ConsumerGroupHandler
+func (c *RideConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	for msg := range claim.Messages() {
+		if err := c.process(session.Context(), msg); err != nil {
+			return err // unmarked: the next session starts from this message
+		}
+		session.MarkMessage(msg, "")
+	}
+	return nil
+}
+
+// process handles msg, retrying failures per the policy, and dead-letters it when they
+// run out; an error means the message was neither handled nor dead-lettered
+func (c *RideConsumer) process(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	backoff := c.retry.Backoff
+	for attempt := 1; ; attempt++ {
+		err := c.handle(ctx, msg)
+		if err == nil {
+			return nil
+		}
+		slog.Warn("ride payment event failed", "topic", msg.Topic, "offset", msg.Offset, "attempt", attempt, "error", err)
+		if attempt >= c.retry.Attempts {
+			return c.deadLetter(msg, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, c.retry.MaxBackoff)
+	}
+}
+
+func (c *RideConsumer) deadLetter(msg *sarama.ConsumerMessage, cause error) error {
+	_, _, err := c.dead.SendMessage(&sarama.ProducerMessage{
+		Topic: TopicRideDeadLetters,
+		Key:   sarama.ByteEncoder(msg.Key),
+		Value: sarama.ByteEncoder(msg.Value),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte("topic"), Value: []byte(msg.Topic)},
+			{Key: []byte("partition"), Value: []byte(strconv.Itoa(int(msg.Partition)))},
+			{Key: []byte("offset"), Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+			{Key: []byte("error"), Value: []byte(cause.Error())},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("dead-letter %s offset %d: %w", msg.Topic, msg.Offset, err)
+	}
+	slog.Error("ride payment event dead-lettered", "topic", msg.Topic, "offset", msg.Offset, "error", cause)
+	return nil
+}
+
+func (c *RideConsumer) handle(ctx context.Context, msg *sarama.ConsumerMessage) error {
+	switch msg.Topic {
+	case TopicRideMatched:
+		var e rideMatched
+		if err := json.Unmarshal(msg.Value, &e); err != nil || e.RideID == "" || e.PassengerID == "" {
+			slog.Warn("kafka bad ride.matched event", "offset", msg.Offset, "error", err)
+			return nil
+		}
+		if e.PaymentMethod != ridePaymentCard || e.CardID == "" {
+			return nil // cash: nothing to hold
+		}
+		_, err := c.payments.HoldRide(ctx, e.RideID, e.PassengerID, e.CardID, e.Price)
+		return err
+	case TopicRideStatusChanged:
+		var e rideStatusChanged
+		if err := json.Unmarshal(msg.Value, &e); err != nil || e.RideID == "" {
+			slog.Warn("kafka bad ride.status.changed event", "offset", msg.Offset, "error", err)
+			return nil
+		}
+		var err error
+		switch e.Status {
+		case rideStatusCompleted:
+			ride := domain.RideCompletion{RideID: e.RideID, DriverID: e.DriverID, Category: e.Category,
+				PaymentMethod: e.PaymentMethod, Fare: e.Fare.domain()}
+			// Kept before the capture: whatever becomes of this event, the earning is
+			// recorded once the ride is paid
+			if ride.DriverID != "" {
+				if err := c.earnings.CompleteRide(ctx, ride); err != nil {
+					return err
+				}
+			}
+			_, err = c.payments.CaptureRide(ctx, e.RideID, e.Fare.domain())
+			if errors.Is(err, domain.ErrPaymentNotAuthorized) {
+				err = nil // paid some other way (cash, checkout)
+			}
+			if err != nil || ride.DriverID == "" {
+				return err
+			}
+			_, err = c.earnings.RecordRide(ctx, ride)
+			if errors.Is(err, domain.ErrRideNotPaid) {
+				return nil // recorded when the payment completes
+			}
+			return err
+		case rideStatusCancelled:
+			_, err = c.payments.VoidRide(ctx, e.RideID)
+		}
+		if errors.Is(err, domain.ErrPaymentNotAuthorized) {
+			return nil // paid some other way (cash, checkout)
+		}
+		return err
+	}
+	return nil
+}
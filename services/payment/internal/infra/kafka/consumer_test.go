@@ -0,0 +1,111 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// failingPayments fails the first fails holds
+type failingPayments struct {
+	RidePayments
+	fails int
+	calls int
+}
+
+func (p *failingPayments) HoldRide(ctx context.Context, rideID, passengerID, cardID string, price money.Money) (*domain.Payment, error) {
+	p.calls++
+	if p.calls <= p.fails {
+		return nil, errors.New("provider unavailable")
+	}
+	return &domain.Payment{RideID: rideID}, nil
+}
+
+// session records the marked offsets
+type session struct {
+	sarama.ConsumerGroupSession
+	marked []int64
+}
+
+func (s *session) Context() context.Context { return context.Background() }
+
+func (s *session) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+type claim struct {
+	sarama.ConsumerGroupClaim
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *claim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// deadLetters keeps what is sent, or fails with err
+type deadLetters struct {
+	sarama.SyncProducer
+	err  error
+	sent []*sarama.ProducerMessage
+}
+
+func (d *deadLetters) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	if d.err != nil {
+		return 0, 0, d.err
+	}
+	d.sent = append(d.sent, msg)
+	return 0, 0, nil
+}
+
+func consume(c *RideConsumer, offsets ...int64) (*session, error) {
+	messages := make(chan *sarama.ConsumerMessage, len(offsets))
+	for _, offset := range offsets {
+		messages <- &sarama.ConsumerMessage{Topic: TopicRideMatched, Offset: offset, Key: []byte("r1"),
+			Value: []byte(`{"ride_id":"r1","passenger_id":"p1","price":500,"payment_method":"card","card_id":"pm-1"}`)}
+	}
+	close(messages)
+	s := &session{}
+	return s, c.ConsumeClaim(s, &claim{messages: messages})
+}
+
+func TestRideConsumer_RetriesThenDeadLetters(t *testing.T) {
+	retry := RetryPolicy{Attempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	// A blip is retried and the message marked
+	payments := &failingPayments{fails: 2}
+	dead := &deadLetters{}
+	s, err := consume(&RideConsumer{dead: dead, payments: payments, retry: retry}, 7)
+	if err != nil || payments.calls != 3 || len(s.marked) != 1 || len(dead.sent) != 0 {
+		t.Errorf("retried: %v, %d calls, marked %v", err, payments.calls, s.marked)
+	}
+
+	// Out of retries: dead-lettered as received, then marked
+	payments = &failingPayments{fails: 5}
+	s, err = consume(&RideConsumer{dead: dead, payments: payments, retry: retry}, 7, 8)
+	if err != nil || payments.calls != 6 || len(s.marked) != 2 || len(dead.sent) != 1 {
+		t.Fatalf("dead-lettered: %v, %d calls, marked %v, %d sent", err, payments.calls, s.marked, len(dead.sent))
+	}
+	msg := dead.sent[0]
+	if msg.Topic != TopicRideDeadLetters || string(msg.Headers[0].Value) != TopicRideMatched ||
+		string(msg.Headers[2].Value) != "7" || string(msg.Headers[3].Value) != "provider unavailable" {
+		t.Errorf("dead letter: %+v", msg)
+	}
+
+	// Nowhere to put it: left unmarked for the next session
+	payments = &failingPayments{fails: 5}
+	s, err = consume(&RideConsumer{dead: &deadLetters{err: errors.New("broker down")}, payments: payments, retry: retry}, 7, 8)
+	if err == nil || len(s.marked) != 0 || payments.calls != 3 {
+		t.Errorf("undeliverable: %v, marked %v", err, s.marked)
+	}
+
+	// Cash rides are not held
+	payments = &failingPayments{}
+	cash := &sarama.ConsumerMessage{Topic: TopicRideMatched, Value: []byte(`{"ride_id":"r2","passenger_id":"p1","price":500,"payment_method":"cash"}`)}
+	if err := (&RideConsumer{payments: payments, retry: retry}).handle(context.Background(), cash); err != nil || payments.calls != 0 {
+		t.Errorf("cash ride: %v, %d holds", err, payments.calls)
+	}
+}
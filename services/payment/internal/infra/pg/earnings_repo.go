@@ -0,0 +1,445 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// EarningsRepo — commission rules, driver profiles, ride earnings and payouts. Driver
+// balances are read from the ledger; earnings and payouts are booked with their entries.
+type EarningsRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewEarningsRepo creates earnings repository
+func NewEarningsRepo(pool *pgxpool.Pool) *EarningsRepo {
+	return &EarningsRepo{pool: pool}
+}
+
+// --- Commission rules ---
+
+// ListCommissionRules returns all rules
+func (r *EarningsRepo) ListCommissionRules(ctx context.Context) ([]*domain.CommissionRule, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, COALESCE(city, ''), COALESCE(category, ''), COALESCE(tier, ''), rate_bp, created_at, updated_at
+		 FROM commission_rules ORDER BY city NULLS FIRST, category NULLS FIRST, tier NULLS FIRST`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*domain.CommissionRule
+	for rows.Next() {
+		var rule domain.CommissionRule
+		var rate int64
+		if err := rows.Scan(&rule.ID, &rule.City, &rule.Category, &rule.Tier, &rate, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rule.Rate = money.Rate(rate)
+		rules = append(rules, &rule)
+	}
+	return rules, rows.Err()
+}
+
+// CreateCommissionRule inserts a rule; domain.ErrRuleExists for a taken city, category and tier
+func (r *EarningsRepo) CreateCommissionRule(ctx context.Context, rule *domain.CommissionRule) error {
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO commission_rules (city, category, tier, rate_bp) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT DO NOTHING
+		 RETURNING id, created_at, updated_at`,
+		nullStr(rule.City), nullStr(rule.Category), nullStr(rule.Tier), int64(rule.Rate),
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrRuleExists
+	}
+	return err
+}
+
+// UpdateCommissionRule updates a rule
+func (r *EarningsRepo) UpdateCommissionRule(ctx context.Context, rule *domain.CommissionRule) error {
+	err := r.pool.QueryRow(ctx,
+		`UPDATE commission_rules SET city = $1, category = $2, tier = $3, rate_bp = $4, updated_at = now()
+		 WHERE id = $5 RETURNING created_at, updated_at`,
+		nullStr(rule.City), nullStr(rule.Category), nullStr(rule.Tier), int64(rule.Rate), rule.ID,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrRuleNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return domain.ErrRuleExists
+	}
+	return err
+}
+
+// DeleteCommissionRule removes a rule
+func (r *EarningsRepo) DeleteCommissionRule(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM commission_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrRuleNotFound
+	}
+	return nil
+}
+
+// --- Driver profiles ---
+
+// GetDriverProfile returns the driver's profile, nil when none was saved
+func (r *EarningsRepo) GetDriverProfile(ctx context.Context, driverID string) (*domain.DriverProfile, error) {
+	var p domain.DriverProfile
+	err := r.pool.QueryRow(ctx,
+		`SELECT driver_id, COALESCE(city, ''), tier, COALESCE(payout_provider, ''), COALESCE(payout_destination, ''), updated_at
+		 FROM driver_profiles WHERE driver_id = $1`,
+		driverID,
+	).Scan(&p.DriverID, &p.City, &p.Tier, &p.PayoutProvider, &p.PayoutDestination, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SaveDriverProfile inserts or replaces the driver's profile
+func (r *EarningsRepo) SaveDriverProfile(ctx context.Context, p *domain.DriverProfile) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO driver_profiles (driver_id, city, tier, payout_provider, payout_destination)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (driver_id) DO UPDATE SET city = EXCLUDED.city, tier = EXCLUDED.tier,
+		     payout_provider = EXCLUDED.payout_provider, payout_destination = EXCLUDED.payout_destination,
+		     updated_at = now()
+		 RETURNING updated_at`,
+		p.DriverID, nullStr(p.City), p.Tier, nullStr(p.PayoutProvider), nullStr(p.PayoutDestination),
+	).Scan(&p.UpdatedAt)
+}
+
+// --- Earnings ---
+
+// RecordEarning stores the ride's earning with its ledger entries in one transaction.
+// Returns false when the ride was already recorded.
+func (r *EarningsRepo) RecordEarning(ctx context.Context, e *domain.RideEarning, entries ...*domain.JournalEntry) (bool, error) {
+	recorded := false
+	err := inTx(ctx, r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO driver_earnings (ride_id, driver_id, method, city, category, tier, currency,
+			                              fare_minor, tip_minor, rate_bp, rule_id, commission_minor, net_minor)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			 ON CONFLICT (ride_id) DO NOTHING
+			 RETURNING id, created_at`,
+			e.RideID, e.DriverID, e.Method, nullStr(e.City), nullStr(e.Category), e.Tier, e.Fare.Currency,
+			e.Fare.Minor, e.Tip.Minor, int64(e.Rate), nullStr(e.RuleID), e.Commission.Minor, e.Net.Minor,
+		).Scan(&e.ID, &e.CreatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		recorded = true
+		return insertEntries(ctx, tx, e.RideID, entries)
+	})
+	return recorded, err
+}
+
+// SaveRideCompletion stores a completed ride until its earning is recorded; a ride
+// already stored is kept as is
+func (r *EarningsRepo) SaveRideCompletion(ctx context.Context, c *domain.RideCompletion) error {
+	var fare []byte
+	if c.Fare != nil {
+		var err error
+		if fare, err = json.Marshal(c.Fare); err != nil {
+			return err
+		}
+	}
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO ride_completions (ride_id, driver_id, category, payment_method, fare)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (ride_id) DO NOTHING`,
+		c.RideID, c.DriverID, nullStr(c.Category), nullStr(c.PaymentMethod), fare,
+	)
+	return err
+}
+
+// GetRideCompletion returns the completion of a ride whose earning is not recorded yet,
+// nil when there is none
+func (r *EarningsRepo) GetRideCompletion(ctx context.Context, rideID string) (*domain.RideCompletion, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+rideCompletionColumns+` FROM ride_completions c
+		 WHERE c.ride_id = $1 AND NOT EXISTS (SELECT 1 FROM driver_earnings e WHERE e.ride_id = c.ride_id)`,
+		rideID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	completions, err := scanRideCompletions(rows)
+	if err != nil || len(completions) == 0 {
+		return nil, err
+	}
+	return completions[0], nil
+}
+
+// ListPendingCompletions returns completed rides whose earning is not recorded yet,
+// oldest first
+func (r *EarningsRepo) ListPendingCompletions(ctx context.Context, limit, offset int) ([]*domain.RideCompletion, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+rideCompletionColumns+` FROM ride_completions c
+		 WHERE NOT EXISTS (SELECT 1 FROM driver_earnings e WHERE e.ride_id = c.ride_id)
+		 ORDER BY c.created_at, c.ride_id LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanRideCompletions(rows)
+}
+
+const rideCompletionColumns = `c.ride_id, c.driver_id, COALESCE(c.category, ''), COALESCE(c.payment_method, ''), c.fare, c.created_at`
+
+func scanRideCompletions(rows pgx.Rows) ([]*domain.RideCompletion, error) {
+	defer rows.Close()
+
+	var completions []*domain.RideCompletion
+	for rows.Next() {
+		var c domain.RideCompletion
+		var fare []byte
+		if err := rows.Scan(&c.RideID, &c.DriverID, &c.Category, &c.PaymentMethod, &fare, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		if fare != nil {
+			c.Fare = &domain.RideFare{}
+			if err := json.Unmarshal(fare, c.Fare); err != nil {
+				return nil, err
+			}
+		}
+		completions = append(completions, &c)
+	}
+	return completions, rows.Err()
+}
+
+// ListEarnings returns the driver's ride earnings created in [from, to), newest first;
+// zero bounds are open
+func (r *EarningsRepo) ListEarnings(ctx context.Context, driverID string, from, to time.Time, limit, offset int) ([]*domain.RideEarning, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, ride_id, driver_id, method, COALESCE(city, ''), COALESCE(category, ''), tier, currency,
+		        fare_minor, tip_minor, rate_bp, COALESCE(rule_id::text, ''), commission_minor, net_minor, created_at
+		 FROM driver_earnings
+		 WHERE driver_id = $1 AND ($2::timestamptz IS NULL OR created_at >= $2) AND ($3::timestamptz IS NULL OR created_at < $3)
+		 ORDER BY created_at DESC LIMIT $4 OFFSET $5`,
+		driverID, nullTime(from), nullTime(to), limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	earnings := []*domain.RideEarning{}
+	for rows.Next() {
+		var e domain.RideEarning
+		var currency string
+		var rate int64
+		if err := rows.Scan(&e.ID, &e.RideID, &e.DriverID, &e.Method, &e.City, &e.Category, &e.Tier, &currency,
+			&e.Fare.Minor, &e.Tip.Minor, &rate, &e.RuleID, &e.Commission.Minor, &e.Net.Minor, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Rate = money.Rate(rate)
+		e.Fare.Currency, e.Tip.Currency, e.Commission.Currency, e.Net.Currency = currency, currency, currency, currency
+		earnings = append(earnings, &e)
+	}
+	return earnings, rows.Err()
+}
+
+// DriverBalance returns what the platform owes the driver in currency: the credit
+// balance of their ledger account
+func (r *EarningsRepo) DriverBalance(ctx context.Context, driverID, currency string) (money.Money, error) {
+	balance := money.New(0, currency)
+	err := r.pool.QueryRow(ctx,
+		`SELECT COALESCE(-SUM(amount_minor), 0) FROM ledger_postings WHERE account = $1 AND currency = $2`,
+		domain.DriverAccount(driverID), currency,
+	).Scan(&balance.Minor)
+	return balance, err
+}
+
+// --- Payouts ---
+
+// CreatePayoutBatch creates the batch of the period and, in the same transaction, a
+// pending payout with its ledger entry for every driver with a payout destination whose
+// balance at the period end is at least min. domain.ErrBatchExists when the period has one.
+func (r *EarningsRepo) CreatePayoutBatch(ctx context.Context, start, end time.Time, min money.Money) (*domain.PayoutBatch, []*domain.Payout, error) {
+	batch := &domain.PayoutBatch{PeriodStart: start, PeriodEnd: end, Total: money.New(0, min.Currency)}
+	var payouts []*domain.Payout
+	err := inTx(ctx, r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx,
+			`INSERT INTO payout_batches (period_start, period_end) VALUES ($1, $2)
+			 ON CONFLICT (period_start) DO NOTHING
+			 RETURNING id, created_at`,
+			start, end,
+		).Scan(&batch.ID, &batch.CreatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrBatchExists
+		}
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(ctx,
+			`SELECT d.driver_id, d.payout_provider, d.payout_destination, -SUM(lp.amount_minor)
+			 FROM driver_profiles d
+			 JOIN ledger_postings lp ON lp.account = 'driver:' || d.driver_id::text AND lp.currency = $1
+			 JOIN ledger_entries le ON le.id = lp.entry_id AND le.created_at < $2
+			 WHERE d.payout_provider IS NOT NULL AND d.payout_destination IS NOT NULL
+			 GROUP BY d.driver_id, d.payout_provider, d.payout_destination
+			 HAVING -SUM(lp.amount_minor) >= GREATEST($3::bigint, 1)`,
+			min.Currency, end, min.Minor,
+		)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			p := &domain.Payout{BatchID: batch.ID, Status: domain.PayoutStatusPending, PeriodStart: start, PeriodEnd: end}
+			p.Amount.Currency = min.Currency
+			if err := rows.Scan(&p.DriverID, &p.Provider, &p.Destination, &p.Amount.Minor); err != nil {
+				rows.Close()
+				return err
+			}
+			payouts = append(payouts, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, p := range payouts {
+			err := tx.QueryRow(ctx,
+				`INSERT INTO payouts (batch_id, driver_id, amount_minor, currency, provider, destination)
+				 VALUES ($1, $2, $3, $4, $5, $6)
+				 RETURNING id, created_at`,
+				p.BatchID, p.DriverID, p.Amount.Minor, p.Amount.Currency, p.Provider, p.Destination,
+			).Scan(&p.ID, &p.CreatedAt)
+			if err != nil {
+				return err
+			}
+			if err := insertEntries(ctx, tx, p.ID, []*domain.JournalEntry{p.SentEntry()}); err != nil {
+				return err
+			}
+			batch.Count++
+			batch.Total = batch.Total.Add(p.Amount)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return batch, payouts, nil
+}
+
+const payoutColumns = `p.id, p.batch_id, p.driver_id, p.amount_minor, p.currency, p.provider, p.destination, p.status,
+	COALESCE(p.external_id, ''), COALESCE(p.fail_reason, ''), b.period_start, b.period_end, p.created_at, p.paid_at`
+
+func scanPayout(row pgx.Row) (*domain.Payout, error) {
+	var p domain.Payout
+	err := row.Scan(&p.ID, &p.BatchID, &p.DriverID, &p.Amount.Minor, &p.Amount.Currency, &p.Provider, &p.Destination, &p.Status,
+		&p.ExternalID, &p.FailReason, &p.PeriodStart, &p.PeriodEnd, &p.CreatedAt, &p.PaidAt)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *EarningsRepo) queryPayouts(ctx context.Context, where string, args ...interface{}) ([]*domain.Payout, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+payoutColumns+` FROM payouts p JOIN payout_batches b ON b.id = p.batch_id `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payouts := []*domain.Payout{}
+	for rows.Next() {
+		p, err := scanPayout(rows)
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, p)
+	}
+	return payouts, rows.Err()
+}
+
+// GetPayout returns a payout, nil when not found
+func (r *EarningsRepo) GetPayout(ctx context.Context, id string) (*domain.Payout, error) {
+	p, err := scanPayout(r.pool.QueryRow(ctx,
+		`SELECT `+payoutColumns+` FROM payouts p JOIN payout_batches b ON b.id = p.batch_id WHERE p.id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return p, err
+}
+
+// ListPayouts returns the driver's payouts, newest first
+func (r *EarningsRepo) ListPayouts(ctx context.Context, driverID string, limit, offset int) ([]*domain.Payout, error) {
+	return r.queryPayouts(ctx, `WHERE p.driver_id = $1 ORDER BY p.created_at DESC LIMIT $2 OFFSET $3`, driverID, limit, offset)
+}
+
+// ListOpenPayouts returns payouts not sent yet or awaiting the provider, oldest first
+func (r *EarningsRepo) ListOpenPayouts(ctx context.Context) ([]*domain.Payout, error) {
+	return r.queryPayouts(ctx, `WHERE p.status IN ('pending', 'processing') ORDER BY p.created_at`)
+}
+
+// UpdatePayout saves the payout's status with ledger entries in one transaction
+func (r *EarningsRepo) UpdatePayout(ctx context.Context, p *domain.Payout, entries ...*domain.JournalEntry) error {
+	return inTx(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`UPDATE payouts SET status = $1, external_id = $2, fail_reason = $3, paid_at = $4, updated_at = now()
+			 WHERE id = $5`,
+			p.Status, nullStr(p.ExternalID), nullStr(p.FailReason), p.PaidAt, p.ID,
+		)
+		if err != nil {
+			return err
+		}
+		return insertEntries(ctx, tx, p.ID, entries)
+	})
+}
+
+// ListPayoutBatches returns batches with their payout counts and totals, newest first
+func (r *EarningsRepo) ListPayoutBatches(ctx context.Context, limit, offset int) ([]*domain.PayoutBatch, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT b.id, b.period_start, b.period_end, b.created_at, COUNT(p.id), COALESCE(SUM(p.amount_minor), 0)
+		 FROM payout_batches b LEFT JOIN payouts p ON p.batch_id = b.id
+		 GROUP BY b.id ORDER BY b.period_start DESC LIMIT $1 OFFSET $2`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	batches := []*domain.PayoutBatch{}
+	for rows.Next() {
+		b := &domain.PayoutBatch{}
+		if err := rows.Scan(&b.ID, &b.PeriodStart, &b.PeriodEnd, &b.CreatedAt, &b.Count, &b.Total.Minor); err != nil {
+			return nil, err
+		}
+		b.Total.Currency = money.RUB
+		batches = append(batches, b)
+	}
+	return batches, rows.Err()
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
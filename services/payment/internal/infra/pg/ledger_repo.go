@@ -0,0 +1,180 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// LedgerRepo — reads of the double-entry ledger; entries are written by the payment
+// and promo repositories in the transaction of the state change they book
+type LedgerRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewLedgerRepo creates ledger repository
+func NewLedgerRepo(pool *pgxpool.Pool) *LedgerRepo {
+	return &LedgerRepo{pool: pool}
+}
+
+// Balances returns per-currency totals of accounts starting with prefix (all when empty)
+func (r *LedgerRepo) Balances(ctx context.Context, prefix string) ([]domain.AccountBalance, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT account, currency,
+		        COALESCE(SUM(amount_minor) FILTER (WHERE amount_minor > 0), 0),
+		        COALESCE(-SUM(amount_minor) FILTER (WHERE amount_minor < 0), 0)
+		 FROM ledger_postings
+		 WHERE account LIKE $1
+		 GROUP BY account, currency
+		 ORDER BY account, currency`,
+		likePrefix(prefix),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []domain.AccountBalance
+	for rows.Next() {
+		var b domain.AccountBalance
+		var currency string
+		if err := rows.Scan(&b.Account, &currency, &b.Debits.Minor, &b.Credits.Minor); err != nil {
+			return nil, err
+		}
+		b.Debits.Currency, b.Credits.Currency = currency, currency
+		b.Balance = b.Debits.Sub(b.Credits)
+		balances = append(balances, b)
+	}
+	return balances, rows.Err()
+}
+
+// ListEntries returns journal entries with their postings, newest first
+func (r *LedgerRepo) ListEntries(ctx context.Context, f domain.LedgerFilter) ([]*domain.JournalEntry, error) {
+	var where []string
+	var args []interface{}
+	if f.Account != "" {
+		args = append(args, f.Account)
+		where = append(where, fmt.Sprintf("e.id IN (SELECT entry_id FROM ledger_postings WHERE account = $%d)", len(args)))
+	}
+	if f.Reference != "" {
+		args = append(args, f.Reference)
+		where = append(where, fmt.Sprintf("e.reference = $%d", len(args)))
+	}
+	if f.Kind != "" {
+		args = append(args, f.Kind)
+		where = append(where, fmt.Sprintf("e.kind = $%d", len(args)))
+	}
+	query := `SELECT e.id, e.kind, e.reference, COALESCE(e.memo, ''), e.created_at FROM ledger_entries e`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, f.Limit, f.Offset)
+	query += fmt.Sprintf(" ORDER BY e.created_at DESC, e.id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*domain.JournalEntry
+	byID := map[string]*domain.JournalEntry{}
+	var ids []string
+	for rows.Next() {
+		e := &domain.JournalEntry{}
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Reference, &e.Memo, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+		byID[e.ID] = e
+		ids = append(ids, e.ID)
+	}
+	if err := rows.Err(); err != nil || len(ids) == 0 {
+		return entries, err
+	}
+
+	postings, err := r.pool.Query(ctx,
+		`SELECT entry_id, account, amount_minor, currency FROM ledger_postings
+		 WHERE entry_id = ANY($1::uuid[]) ORDER BY id`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer postings.Close()
+	for postings.Next() {
+		var entryID string
+		var p domain.Posting
+		if err := postings.Scan(&entryID, &p.Account, &p.Amount.Minor, &p.Amount.Currency); err != nil {
+			return nil, err
+		}
+		byID[entryID].Postings = append(byID[entryID].Postings, p)
+	}
+	return entries, postings.Err()
+}
+
+// --- Writing (used by other repositories) ---
+
+// inTx runs fn in a transaction, committed when fn succeeds
+func inTx(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// insertEntries books journal entries in tx; entries without a reference get ref. An
+// entry already booked for its kind and reference is skipped, so replays book once.
+// Postings to wallet accounts update the wallets in the same transaction.
+func insertEntries(ctx context.Context, tx pgx.Tx, ref string, entries []*domain.JournalEntry) error {
+	for _, e := range entries {
+		if e.Reference == "" {
+			e.Reference = ref
+		}
+		if err := e.Validate(); err != nil {
+			return fmt.Errorf("%s %s: %w", e.Kind, e.Reference, err)
+		}
+		err := tx.QueryRow(ctx,
+			`INSERT INTO ledger_entries (kind, reference, memo) VALUES ($1, $2, $3)
+			 ON CONFLICT (kind, reference) DO NOTHING
+			 RETURNING id, created_at`,
+			e.Kind, e.Reference, nullStr(e.Memo),
+		).Scan(&e.ID, &e.CreatedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, p := range e.Postings {
+			_, err := tx.Exec(ctx,
+				`INSERT INTO ledger_postings (entry_id, account, amount_minor, currency) VALUES ($1, $2, $3, $4)`,
+				e.ID, p.Account, p.Amount.Minor, p.Amount.Currency,
+			)
+			if err != nil {
+				return err
+			}
+		}
+		if err := applyWalletPostings(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// likePrefix — LIKE pattern matching account names that start with prefix
+func likePrefix(prefix string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(prefix) + "%"
+}
@@ -0,0 +1,8 @@
+-- Shared trips: one payment per seat booking. ride_id holds the trip id, booking_id the
+-- passenger's booking; regular rides keep one payment per ride (booking_id NULL).
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS booking_id UUID;
+
+DROP INDEX IF EXISTS idx_payments_ride;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_ride ON payments (ride_id) WHERE booking_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_booking ON payments (booking_id) WHERE booking_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_payments_trip ON payments (ride_id) WHERE booking_id IS NOT NULL;
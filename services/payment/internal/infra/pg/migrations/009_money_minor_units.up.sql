@@ -0,0 +1,33 @@
+-- Exact money: amounts in integer minor units (kopecks) instead of DOUBLE PRECISION /
+-- DECIMAL rubles. Existing rows are rubles (two decimals), rounded half away from zero.
+ALTER TABLE payments ALTER COLUMN amount TYPE BIGINT USING ROUND(amount::numeric * 100)::bigint;
+ALTER TABLE payments RENAME COLUMN amount TO amount_minor;
+
+ALTER TABLE refunds ALTER COLUMN amount TYPE BIGINT USING ROUND(amount::numeric * 100)::bigint;
+ALTER TABLE refunds RENAME COLUMN amount TO amount_minor;
+
+-- Promos: the mixed "value" (percent or rubles) splits into percent_bp (basis points,
+-- 1050 = 10.5%) and amount_off_minor; all amounts in the promo's currency
+ALTER TABLE promos ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'RUB';
+ALTER TABLE promos ADD COLUMN IF NOT EXISTS percent_bp INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE promos ADD COLUMN IF NOT EXISTS amount_off_minor BIGINT NOT NULL DEFAULT 0;
+UPDATE promos SET percent_bp = ROUND(value * 100) WHERE type = 'percent';
+UPDATE promos SET amount_off_minor = ROUND(value * 100) WHERE type = 'fixed';
+ALTER TABLE promos DROP COLUMN value;
+ALTER TABLE promos ADD CONSTRAINT promos_discount_check CHECK (
+    (type = 'percent' AND percent_bp BETWEEN 1 AND 10000 AND amount_off_minor = 0) OR
+    (type = 'fixed' AND amount_off_minor > 0 AND percent_bp = 0)
+);
+
+ALTER TABLE promos ALTER COLUMN min_order_value DROP DEFAULT;
+ALTER TABLE promos ALTER COLUMN min_order_value TYPE BIGINT USING ROUND(min_order_value * 100)::bigint;
+ALTER TABLE promos ALTER COLUMN min_order_value SET DEFAULT 0;
+ALTER TABLE promos RENAME COLUMN min_order_value TO min_order_minor;
+
+ALTER TABLE promos ALTER COLUMN max_discount DROP DEFAULT;
+ALTER TABLE promos ALTER COLUMN max_discount TYPE BIGINT USING ROUND(max_discount * 100)::bigint;
+ALTER TABLE promos ALTER COLUMN max_discount SET DEFAULT 0;
+ALTER TABLE promos RENAME COLUMN max_discount TO max_discount_minor;
+
+ALTER TABLE user_promos ALTER COLUMN discount TYPE BIGINT USING ROUND(discount * 100)::bigint;
+ALTER TABLE user_promos RENAME COLUMN discount TO discount_minor;
@@ -0,0 +1,11 @@
+-- Two-stage card payments: the agreed price is held (authorized) at match and the
+-- final fare captured at completion; amount_minor becomes the captured amount.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS authorized_minor BIGINT;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS authorized_at TIMESTAMPTZ;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'processing', 'authorized', 'completed', 'failed', 'cancelled', 'refunded'));
+
+-- Holds still open, looked up when the ride completes or is cancelled
+CREATE INDEX IF NOT EXISTS idx_payments_authorized ON payments (ride_id) WHERE status = 'authorized';
@@ -0,0 +1,64 @@
+-- Double-entry ledger: every money movement is a journal entry of postings that sum to
+-- zero per currency (debit positive, credit negative). Append-only.
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind       TEXT NOT NULL,  -- payment.captured, payment.refunded, promo.discount
+    reference  TEXT NOT NULL,  -- payment, refund or promo usage id
+    memo       TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- One entry per business event: repeated webhooks and events do not book twice
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_kind_ref ON ledger_entries (kind, reference);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_created ON ledger_entries (created_at);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id           BIGSERIAL PRIMARY KEY,
+    entry_id     UUID NOT NULL REFERENCES ledger_entries(id),
+    account      TEXT NOT NULL,  -- passenger:<id>, driver:<id>, platform:commission, platform:promo_budget, clearing:<provider>
+    amount_minor BIGINT NOT NULL CHECK (amount_minor <> 0),
+    currency     TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account ON ledger_postings (account, currency);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_entry ON ledger_postings (entry_id);
+
+-- Append-only: no updates, deletes or truncates
+CREATE OR REPLACE FUNCTION ledger_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'ledger is append-only: % on % is not allowed', TG_OP, TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_ledger_entries_append_only
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+CREATE TRIGGER trg_ledger_entries_no_truncate
+    BEFORE TRUNCATE ON ledger_entries
+    FOR EACH STATEMENT EXECUTE FUNCTION ledger_append_only();
+CREATE TRIGGER trg_ledger_postings_append_only
+    BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+CREATE TRIGGER trg_ledger_postings_no_truncate
+    BEFORE TRUNCATE ON ledger_postings
+    FOR EACH STATEMENT EXECUTE FUNCTION ledger_append_only();
+
+-- Balanced entries, checked at commit once all postings of the entry are in
+CREATE OR REPLACE FUNCTION ledger_check_balanced()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM ledger_postings WHERE entry_id = NEW.entry_id
+        GROUP BY currency HAVING SUM(amount_minor) <> 0
+    ) THEN
+        RAISE EXCEPTION 'ledger entry % is not balanced', NEW.entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER trg_ledger_postings_balanced
+    AFTER INSERT ON ledger_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION ledger_check_balanced();
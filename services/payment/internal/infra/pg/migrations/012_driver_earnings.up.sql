@@ -0,0 +1,73 @@
+-- Driver earnings, platform commission and weekly payouts. Balances live in the
+-- ledger (driver:<id> accounts); these tables keep what the statements show.
+
+-- Commission rules; NULL city, category or tier matches any
+CREATE TABLE IF NOT EXISTS commission_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    city VARCHAR(100),
+    category VARCHAR(20),
+    tier VARCHAR(20),
+    rate_bp INTEGER NOT NULL CHECK (rate_bp BETWEEN 0 AND 10000), -- basis points: 1500 = 15%
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_commission_rules_key
+    ON commission_rules (COALESCE(city, ''), COALESCE(category, ''), COALESCE(tier, ''));
+
+-- City and tier (admin), payout destination (driver)
+CREATE TABLE IF NOT EXISTS driver_profiles (
+    driver_id UUID PRIMARY KEY,
+    city VARCHAR(100),
+    tier VARCHAR(20) NOT NULL DEFAULT 'standard',
+    payout_provider VARCHAR(20),
+    payout_destination VARCHAR(100),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- One row per completed ride
+CREATE TABLE IF NOT EXISTS driver_earnings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ride_id UUID NOT NULL UNIQUE,
+    driver_id UUID NOT NULL,
+    method VARCHAR(10) NOT NULL CHECK (method IN ('card', 'cash')),
+    city VARCHAR(100),
+    category VARCHAR(20),
+    tier VARCHAR(20) NOT NULL,
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    fare_minor BIGINT NOT NULL,
+    tip_minor BIGINT NOT NULL DEFAULT 0,
+    rate_bp INTEGER NOT NULL,
+    rule_id UUID,
+    commission_minor BIGINT NOT NULL,
+    net_minor BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_driver_earnings_driver ON driver_earnings (driver_id, created_at DESC);
+
+-- Weekly batches; one per period
+CREATE TABLE IF NOT EXISTS payout_batches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    period_start TIMESTAMPTZ NOT NULL UNIQUE,
+    period_end TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS payouts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    batch_id UUID NOT NULL REFERENCES payout_batches(id),
+    driver_id UUID NOT NULL,
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    provider VARCHAR(20) NOT NULL,
+    destination VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'processing', 'paid', 'failed')),
+    external_id VARCHAR(255),
+    fail_reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    paid_at TIMESTAMPTZ,
+    UNIQUE (batch_id, driver_id)
+);
+CREATE INDEX IF NOT EXISTS idx_payouts_driver ON payouts (driver_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payouts_open ON payouts (status) WHERE status IN ('pending', 'processing');
@@ -0,0 +1,39 @@
+-- In-app wallet: top-ups are payments without a ride, rides can be paid from the
+-- balance. The ledger (wallet:<user> accounts) is the source of truth; wallets and
+-- wallet_transactions are its projection, written in the same transaction.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS purpose TEXT NOT NULL DEFAULT 'ride';
+ALTER TABLE payments ALTER COLUMN ride_id DROP NOT NULL;
+ALTER TABLE payments ADD CONSTRAINT payments_purpose_check
+    CHECK (purpose IN ('ride', 'wallet_topup') AND (purpose = 'wallet_topup' OR ride_id IS NOT NULL));
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_method_check CHECK (method IN ('cash', 'card', 'wallet'));
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_provider_check;
+ALTER TABLE payments ADD CONSTRAINT payments_provider_check
+    CHECK (provider IN ('cash', 'tinkoff', 'yoomoney', 'sber', 'wallet'));
+
+ALTER TABLE driver_earnings DROP CONSTRAINT IF EXISTS driver_earnings_method_check;
+ALTER TABLE driver_earnings ADD CONSTRAINT driver_earnings_method_check CHECK (method IN ('card', 'wallet', 'cash'));
+
+-- Row locked while a posting is applied: concurrent spends of one wallet serialize
+CREATE TABLE IF NOT EXISTS wallets (
+    user_id UUID PRIMARY KEY,
+    currency CHAR(3) NOT NULL DEFAULT 'RUB',
+    balance_minor BIGINT NOT NULL DEFAULT 0 CHECK (balance_minor >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- The wallet's side of every journal entry touching it
+CREATE TABLE IF NOT EXISTS wallet_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES wallets(user_id),
+    entry_id UUID NOT NULL REFERENCES ledger_entries(id),
+    kind TEXT NOT NULL,
+    reference TEXT NOT NULL,
+    amount_minor BIGINT NOT NULL CHECK (amount_minor <> 0), -- positive: into the wallet
+    currency CHAR(3) NOT NULL,
+    balance_after_minor BIGINT NOT NULL,
+    memo TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_user ON wallet_transactions (user_id, created_at DESC);
@@ -0,0 +1,19 @@
+-- Reconciler: payments stuck in pending/processing (lost webhooks) are polled at the
+-- provider; reconciled_at spreads the polling so every stuck payment gets its turn
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS reconciled_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_payments_in_flight
+    ON payments (COALESCE(reconciled_at, created_at)) WHERE status IN ('pending', 'processing');
+
+-- Payments whose status differed from the provider's; one row per payment, outcome
+-- and provider status, so a payment stuck unresolved is reported once
+CREATE TABLE IF NOT EXISTS payment_reconciliations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id),
+    provider TEXT NOT NULL,
+    local_status TEXT NOT NULL,
+    provider_status TEXT NOT NULL DEFAULT '',
+    outcome TEXT NOT NULL CHECK (outcome IN ('applied', 'expired', 'unresolved')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (payment_id, outcome, provider_status)
+);
+CREATE INDEX IF NOT EXISTS idx_payment_reconciliations_created ON payment_reconciliations (created_at DESC);
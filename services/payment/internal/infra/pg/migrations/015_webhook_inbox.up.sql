@@ -0,0 +1,22 @@
+-- Webhook inbox: every provider callback is stored as received before it is applied;
+-- redeliveries of the same provider event are dropped by the unique key
+CREATE TABLE IF NOT EXISTS webhook_inbox (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    body TEXT NOT NULL,
+    signature_verified BOOLEAN NOT NULL DEFAULT false,
+    event_type TEXT NOT NULL DEFAULT '',
+    payment_id TEXT NOT NULL DEFAULT '',
+    external_id TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL CHECK (status IN ('pending', 'processed', 'failed', 'rejected')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    processed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (provider, event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_inbox_due ON webhook_inbox (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_inbox_created ON webhook_inbox (created_at DESC);
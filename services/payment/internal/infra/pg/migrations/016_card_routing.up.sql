@@ -0,0 +1,17 @@
+-- Card routing: why a card payment went to its provider (rule, candidates, attempts)
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS routing JSONB;
+
+-- Admin routing rules; checked by priority, the first matching amount and brand applies.
+-- NULL bounds and brand match any; amounts in kopecks
+CREATE TABLE IF NOT EXISTS card_routing_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL UNIQUE,
+    priority INTEGER NOT NULL DEFAULT 0,
+    min_amount_minor BIGINT,
+    max_amount_minor BIGINT,
+    card_brand VARCHAR(20),
+    providers TEXT[] NOT NULL,
+    strategy VARCHAR(20) NOT NULL DEFAULT 'priority' CHECK (strategy IN ('priority', 'cost', 'health')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,24 @@
+-- Partial refunds: a payment keeps the total of its refunds that have not failed and is
+-- partially_refunded until the total reaches its amount
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_minor BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS to_wallet BOOLEAN NOT NULL DEFAULT false;
+
+-- Totals of past refunds; their sum could exceed the amount before
+UPDATE payments p SET refunded_minor = LEAST(r.total, p.amount_minor)
+FROM (SELECT payment_id, SUM(amount_minor) AS total FROM refunds WHERE status <> 'failed' GROUP BY payment_id) r
+WHERE r.payment_id = p.id;
+-- Refunded at the provider without a refund row here
+UPDATE payments SET refunded_minor = amount_minor WHERE status = 'refunded' AND refunded_minor = 0;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('pending', 'processing', 'authorized', 'completed', 'partially_refunded', 'failed', 'cancelled', 'refunded'));
+-- A partial refund used to mark the whole payment refunded
+UPDATE payments SET status = CASE WHEN refunded_minor < amount_minor THEN 'partially_refunded' ELSE 'refunded' END
+WHERE status IN ('completed', 'refunded') AND refunded_minor > 0;
+ALTER TABLE payments ADD CONSTRAINT payments_refunded_check CHECK (refunded_minor BETWEEN 0 AND amount_minor);
+
+-- Refund webhooks are matched to their refund by the provider's refund ID or the amount
+ALTER TABLE webhook_inbox ADD COLUMN IF NOT EXISTS refund_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE webhook_inbox ADD COLUMN IF NOT EXISTS amount_minor BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE webhook_inbox ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT '';
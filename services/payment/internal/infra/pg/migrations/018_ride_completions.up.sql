@@ -0,0 +1,12 @@
+-- Completed rides as reported by the ride service, kept until the driver's earning is
+-- recorded: card rides wait for their payment, failed recordings are retried. A ride
+-- is pending while it has no driver_earnings row.
+CREATE TABLE IF NOT EXISTS ride_completions (
+    ride_id UUID PRIMARY KEY,
+    driver_id UUID NOT NULL,
+    category VARCHAR(20),
+    payment_method VARCHAR(10),
+    fare JSONB, -- domain.RideFare
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_ride_completions_created ON ride_completions (created_at);
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"time"
 
+	"github.com/alexevil1979/indrive/packages/money-go"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
@@ -24,16 +25,19 @@ func NewPaymentRepo(pool *pgxpool.Pool) *PaymentRepo {
 	return &PaymentRepo{pool: pool}
 }
 
-// Create creates a new payment
+// Create creates a new payment; the purpose defaults to a ride
 func (r *PaymentRepo) Create(ctx context.Context, p *domain.Payment) error {
+	if p.Purpose == "" {
+		p.Purpose = domain.PurposeRide
+	}
 	row := r.pool.QueryRow(ctx,
-		`INSERT INTO payments (ride_id, user_id, amount, currency, method, provider, status, external_id, 
-		                       confirm_url, description, metadata, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, now(), now())
-		 ON CONFLICT (ride_id) DO NOTHING
+		`INSERT INTO payments (ride_id, purpose, booking_id, user_id, amount_minor, currency, method, provider, status, external_id, 
+		                       confirm_url, description, metadata, routing, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, now(), now())
+		 ON CONFLICT DO NOTHING
 		 RETURNING id, created_at, updated_at`,
-		p.RideID, nullStr(p.UserID), p.Amount, p.Currency, p.Method, p.Provider, p.Status,
-		nullStr(p.ExternalID), nullStr(p.ConfirmURL), nullStr(p.Description), nullStr(p.Metadata),
+		nullStr(p.RideID), p.Purpose, nullStr(p.BookingID), nullStr(p.UserID), p.Amount.Minor, p.Currency, p.Method, p.Provider, p.Status,
+		nullStr(p.ExternalID), nullStr(p.ConfirmURL), nullStr(p.Description), nullStr(p.Metadata), p.Routing,
 	)
 	err := row.Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
@@ -48,30 +52,69 @@ func (r *PaymentRepo) Create(ctx context.Context, p *domain.Payment) error {
 // GetByID returns payment by ID
 func (r *PaymentRepo) GetByID(ctx context.Context, id string) (*domain.Payment, error) {
 	row := r.pool.QueryRow(ctx,
-		`SELECT id, ride_id, user_id, amount, currency, method, provider, status, external_id,
-		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at
+		`SELECT id, COALESCE(ride_id::text, ''), purpose, COALESCE(booking_id::text, ''), user_id, amount_minor, currency, method, provider, status, external_id,
+		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at,
+		        authorized_minor, authorized_at, routing, refunded_minor
 		 FROM payments WHERE id = $1`,
 		id,
 	)
 	return scanPayment(row)
 }
 
-// GetByRideID returns payment by ride ID
+// GetByRideID returns payment of a regular ride (shared trip bookings excluded)
 func (r *PaymentRepo) GetByRideID(ctx context.Context, rideID string) (*domain.Payment, error) {
 	row := r.pool.QueryRow(ctx,
-		`SELECT id, ride_id, user_id, amount, currency, method, provider, status, external_id,
-		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at
-		 FROM payments WHERE ride_id = $1`,
+		`SELECT id, COALESCE(ride_id::text, ''), purpose, COALESCE(booking_id::text, ''), user_id, amount_minor, currency, method, provider, status, external_id,
+		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at,
+		        authorized_minor, authorized_at, routing, refunded_minor
+		 FROM payments WHERE ride_id = $1 AND booking_id IS NULL`,
 		rideID,
 	)
 	return scanPayment(row)
 }
 
+// ListByTrip returns per-booking payments of a shared trip
+func (r *PaymentRepo) ListByTrip(ctx context.Context, tripID string) ([]*domain.Payment, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, COALESCE(ride_id::text, ''), purpose, COALESCE(booking_id::text, ''), user_id, amount_minor, currency, method, provider, status, external_id,
+		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at,
+		        authorized_minor, authorized_at, routing, refunded_minor
+		 FROM payments WHERE ride_id = $1 AND booking_id IS NOT NULL ORDER BY created_at`,
+		tripID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*domain.Payment
+	for rows.Next() {
+		p, err := scanPaymentRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+// GetRideDriver returns the driver of a completed ride, "" when its completion has not
+// arrived yet
+func (r *PaymentRepo) GetRideDriver(ctx context.Context, rideID string) (string, error) {
+	var driverID string
+	err := r.pool.QueryRow(ctx, `SELECT driver_id FROM ride_completions WHERE ride_id = $1`, rideID).Scan(&driverID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return driverID, err
+}
+
 // GetByExternalID returns payment by external provider ID
 func (r *PaymentRepo) GetByExternalID(ctx context.Context, externalID string) (*domain.Payment, error) {
 	row := r.pool.QueryRow(ctx,
-		`SELECT id, ride_id, user_id, amount, currency, method, provider, status, external_id,
-		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at
+		`SELECT id, COALESCE(ride_id::text, ''), purpose, COALESCE(booking_id::text, ''), user_id, amount_minor, currency, method, provider, status, external_id,
+		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at,
+		        authorized_minor, authorized_at, routing, refunded_minor
 		 FROM payments WHERE external_id = $1`,
 		externalID,
 	)
@@ -87,23 +130,31 @@ func (r *PaymentRepo) UpdateStatus(ctx context.Context, id, status, externalID s
 	return err
 }
 
-// UpdatePayment updates payment fields
-func (r *PaymentRepo) UpdatePayment(ctx context.Context, p *domain.Payment) error {
-	_, err := r.pool.Exec(ctx,
-		`UPDATE payments SET status = $1, external_id = $2, confirm_url = $3, fail_reason = $4, 
-		        paid_at = $5, refunded_at = $6, updated_at = now()
-		 WHERE id = $7`,
-		p.Status, nullStr(p.ExternalID), nullStr(p.ConfirmURL), nullStr(p.FailReason),
-		p.PaidAt, p.RefundedAt, p.ID,
-	)
-	return err
+// UpdatePayment updates payment fields and books entries (reference: the payment) in the same transaction
+func (r *PaymentRepo) UpdatePayment(ctx context.Context, p *domain.Payment, entries ...*domain.JournalEntry) error {
+	return inTx(ctx, r.pool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`UPDATE payments SET status = $1, external_id = $2, confirm_url = $3, fail_reason = $4, 
+		        paid_at = $5, refunded_at = $6, amount_minor = $7, authorized_minor = $8, authorized_at = $9,
+		        provider = $10, routing = $11, updated_at = now()
+		 WHERE id = $12`,
+			p.Status, nullStr(p.ExternalID), nullStr(p.ConfirmURL), nullStr(p.FailReason),
+			p.PaidAt, p.RefundedAt, p.Amount.Minor, authorizedMinor(p), p.AuthorizedAt,
+			p.Provider, p.Routing, p.ID,
+		)
+		if err != nil {
+			return err
+		}
+		return insertEntries(ctx, tx, p.ID, entries)
+	})
 }
 
 // ListByUser returns user's payments
 func (r *PaymentRepo) ListByUser(ctx context.Context, userID string, limit, offset int) ([]*domain.Payment, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, ride_id, user_id, amount, currency, method, provider, status, external_id,
-		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at
+		`SELECT id, COALESCE(ride_id::text, ''), purpose, COALESCE(booking_id::text, ''), user_id, amount_minor, currency, method, provider, status, external_id,
+		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at,
+		        authorized_minor, authorized_at, routing, refunded_minor
 		 FROM payments WHERE user_id = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3`,
 		userID, limit, offset,
 	)
@@ -205,41 +256,213 @@ func (r *PaymentRepo) SetDefaultPaymentMethod(ctx context.Context, id, userID st
 
 // --- Refunds ---
 
-// CreateRefund creates a refund record
-func (r *PaymentRepo) CreateRefund(ctx context.Context, paymentID string, amount float64, reason, externalID string) (string, error) {
-	var id string
-	err := r.pool.QueryRow(ctx,
-		`INSERT INTO refunds (payment_id, amount, reason, external_id, status)
-		 VALUES ($1, $2, $3, $4, 'pending')
-		 RETURNING id`,
-		paymentID, amount, reason, nullStr(externalID),
-	).Scan(&id)
-	return id, err
+// CreateRefund stores a refund and counts it toward the refunded total of its payment
+// (domain.Payment.ReserveRefund) under the payment's row lock, booking entries
+// (reference: the refund) in the same transaction
+func (r *PaymentRepo) CreateRefund(ctx context.Context, refund *domain.Refund, entries ...*domain.JournalEntry) error {
+	return inTx(ctx, r.pool, func(tx pgx.Tx) error {
+		p, err := lockPayment(ctx, tx, refund.PaymentID)
+		if err != nil {
+			return err
+		}
+		if err := p.ReserveRefund(refund.Amount, time.Now()); err != nil {
+			return err
+		}
+		err = tx.QueryRow(ctx,
+			`INSERT INTO refunds (payment_id, amount_minor, reason, to_wallet, external_id, status, processed_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7)
+			 RETURNING id, created_at`,
+			refund.PaymentID, refund.Amount.Minor, nullStr(refund.Reason), refund.ToWallet, nullStr(refund.ExternalID),
+			refund.Status, refund.ProcessedAt,
+		).Scan(&refund.ID, &refund.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := updateRefunded(ctx, tx, p); err != nil {
+			return err
+		}
+		return insertEntries(ctx, tx, refund.ID, entries)
+	})
 }
 
-// UpdateRefundStatus updates refund status
-func (r *PaymentRepo) UpdateRefundStatus(ctx context.Context, id, status string) error {
-	processedAt := (*time.Time)(nil)
-	if status == "succeeded" || status == "failed" {
-		now := time.Now()
-		processedAt = &now
+// UpdateRefund stores the outcome of a refund and books entries (reference: the refund)
+// in the same transaction; a refund that failed leaves the refunded total of its payment
+func (r *PaymentRepo) UpdateRefund(ctx context.Context, refund *domain.Refund, entries ...*domain.JournalEntry) error {
+	return inTx(ctx, r.pool, func(tx pgx.Tx) error {
+		p, err := lockPayment(ctx, tx, refund.PaymentID)
+		if err != nil {
+			return err
+		}
+		var status string
+		err = tx.QueryRow(ctx, `SELECT status FROM refunds WHERE id = $1 FOR UPDATE`, refund.ID).Scan(&status)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.ErrRefundNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if status == domain.RefundStatusFailed {
+			return nil // settled
+		}
+		_, err = tx.Exec(ctx,
+			`UPDATE refunds SET status = $2, external_id = $3, processed_at = $4 WHERE id = $1`,
+			refund.ID, refund.Status, nullStr(refund.ExternalID), refund.ProcessedAt,
+		)
+		if err != nil {
+			return err
+		}
+		if refund.Status == domain.RefundStatusFailed {
+			p.ReleaseRefund(refund.Amount, time.Now())
+			if err := updateRefunded(ctx, tx, p); err != nil {
+				return err
+			}
+		}
+		return insertEntries(ctx, tx, refund.ID, entries)
+	})
+}
+
+// ListRefunds returns the refunds of a payment, oldest first
+func (r *PaymentRepo) ListRefunds(ctx context.Context, paymentID string) ([]*domain.Refund, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT r.id, r.payment_id, r.amount_minor, p.currency, COALESCE(r.reason, ''), r.to_wallet,
+		        COALESCE(r.external_id, ''), r.status, r.created_at, r.processed_at
+		 FROM refunds r JOIN payments p ON p.id = r.payment_id
+		 WHERE r.payment_id = $1
+		 ORDER BY r.created_at, r.id`,
+		paymentID,
+	)
+	if err != nil {
+		return nil, err
 	}
-	_, err := r.pool.Exec(ctx,
-		`UPDATE refunds SET status = $1, processed_at = $2 WHERE id = $3`,
-		status, processedAt, id,
+	defer rows.Close()
+
+	var refunds []*domain.Refund
+	for rows.Next() {
+		var f domain.Refund
+		err := rows.Scan(&f.ID, &f.PaymentID, &f.Amount.Minor, &f.Currency, &f.Reason, &f.ToWallet,
+			&f.ExternalID, &f.Status, &f.CreatedAt, &f.ProcessedAt)
+		if err != nil {
+			return nil, err
+		}
+		f.Amount.Currency = f.Currency
+		refunds = append(refunds, &f)
+	}
+	return refunds, rows.Err()
+}
+
+// lockPayment reads a payment for update; refunds of one payment serialize on its row
+func lockPayment(ctx context.Context, tx pgx.Tx, id string) (*domain.Payment, error) {
+	p, err := scanPayment(tx.QueryRow(ctx,
+		`SELECT id, COALESCE(ride_id::text, ''), purpose, COALESCE(booking_id::text, ''), user_id, amount_minor, currency, method, provider, status, external_id,
+		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at,
+		        authorized_minor, authorized_at, routing, refunded_minor
+		 FROM payments WHERE id = $1 FOR UPDATE`,
+		id,
+	))
+	if err == nil && p == nil {
+		err = domain.ErrPaymentNotFound
+	}
+	return p, err
+}
+
+// updateRefunded stores the refunded total of a payment and the status it gives it
+func updateRefunded(ctx context.Context, tx pgx.Tx, p *domain.Payment) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE payments SET refunded_minor = $2, status = $3, refunded_at = $4, updated_at = now() WHERE id = $1`,
+		p.ID, p.Refunded.Minor, p.Status, p.RefundedAt,
 	)
 	return err
 }
 
+// --- Reconciliation ---
+
+// ListInFlight returns gateway payments still pending or processing that were not
+// checked since before, least recently checked first
+func (r *PaymentRepo) ListInFlight(ctx context.Context, before time.Time, limit int) ([]*domain.Payment, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, COALESCE(ride_id::text, ''), purpose, COALESCE(booking_id::text, ''), user_id, amount_minor, currency, method, provider, status, external_id,
+		        confirm_url, description, metadata, fail_reason, refunded_at, paid_at, created_at, updated_at,
+		        authorized_minor, authorized_at, routing, refunded_minor
+		 FROM payments
+		 WHERE status IN ('pending', 'processing') AND provider NOT IN ('cash', 'wallet')
+		   AND COALESCE(reconciled_at, created_at) < $1
+		 ORDER BY COALESCE(reconciled_at, created_at) LIMIT $2`,
+		before, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*domain.Payment
+	for rows.Next() {
+		p, err := scanPaymentRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+// MarkReconciled records that the payment was checked at the provider
+func (r *PaymentRepo) MarkReconciled(ctx context.Context, id string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE payments SET reconciled_at = now() WHERE id = $1`, id)
+	return err
+}
+
+// RecordMismatch stores a mismatch; one already recorded for the payment, outcome and
+// provider status is skipped
+func (r *PaymentRepo) RecordMismatch(ctx context.Context, m *domain.PaymentMismatch) error {
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO payment_reconciliations (payment_id, provider, local_status, provider_status, outcome)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (payment_id, outcome, provider_status) DO NOTHING
+		 RETURNING id, created_at`,
+		m.PaymentID, m.Provider, m.LocalStatus, m.ProviderStatus, m.Outcome,
+	).Scan(&m.ID, &m.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+// ListMismatches returns recorded mismatches, newest first; outcome filters when set
+func (r *PaymentRepo) ListMismatches(ctx context.Context, outcome string, limit, offset int) ([]*domain.PaymentMismatch, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, payment_id, provider, local_status, provider_status, outcome, created_at
+		 FROM payment_reconciliations
+		 WHERE $1 = '' OR outcome = $1
+		 ORDER BY created_at DESC, id LIMIT $2 OFFSET $3`,
+		outcome, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mismatches []*domain.PaymentMismatch
+	for rows.Next() {
+		var m domain.PaymentMismatch
+		if err := rows.Scan(&m.ID, &m.PaymentID, &m.Provider, &m.LocalStatus, &m.ProviderStatus, &m.Outcome, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, &m)
+	}
+	return mismatches, rows.Err()
+}
+
 // --- Helpers ---
 
 func scanPayment(row pgx.Row) (*domain.Payment, error) {
 	var p domain.Payment
 	var userID, extID, confirmURL, desc, metadata, failReason *string
 	var refundedAt, paidAt *time.Time
+	var authorized *int64
 
-	err := row.Scan(&p.ID, &p.RideID, &userID, &p.Amount, &p.Currency, &p.Method, &p.Provider, &p.Status, &extID,
-		&confirmURL, &desc, &metadata, &failReason, &refundedAt, &paidAt, &p.CreatedAt, &p.UpdatedAt)
+	err := row.Scan(&p.ID, &p.RideID, &p.Purpose, &p.BookingID, &userID, &p.Amount.Minor, &p.Currency, &p.Method, &p.Provider, &p.Status, &extID,
+		&confirmURL, &desc, &metadata, &failReason, &refundedAt, &paidAt, &p.CreatedAt, &p.UpdatedAt,
+		&authorized, &p.AuthorizedAt, &p.Routing, &p.Refunded.Minor)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -267,6 +490,12 @@ func scanPayment(row pgx.Row) (*domain.Payment, error) {
 	}
 	p.RefundedAt = refundedAt
 	p.PaidAt = paidAt
+	p.Amount.Currency = p.Currency
+	p.Refunded.Currency = p.Currency
+	if authorized != nil {
+		held := money.New(*authorized, p.Currency)
+		p.Authorized = &held
+	}
 
 	return &p, nil
 }
@@ -275,9 +504,11 @@ func scanPaymentRow(rows pgx.Rows) (*domain.Payment, error) {
 	var p domain.Payment
 	var userID, extID, confirmURL, desc, metadata, failReason *string
 	var refundedAt, paidAt *time.Time
+	var authorized *int64
 
-	err := rows.Scan(&p.ID, &p.RideID, &userID, &p.Amount, &p.Currency, &p.Method, &p.Provider, &p.Status, &extID,
-		&confirmURL, &desc, &metadata, &failReason, &refundedAt, &paidAt, &p.CreatedAt, &p.UpdatedAt)
+	err := rows.Scan(&p.ID, &p.RideID, &p.Purpose, &p.BookingID, &userID, &p.Amount.Minor, &p.Currency, &p.Method, &p.Provider, &p.Status, &extID,
+		&confirmURL, &desc, &metadata, &failReason, &refundedAt, &paidAt, &p.CreatedAt, &p.UpdatedAt,
+		&authorized, &p.AuthorizedAt, &p.Routing, &p.Refunded.Minor)
 	if err != nil {
 		return nil, err
 	}
@@ -302,6 +533,12 @@ func scanPaymentRow(rows pgx.Rows) (*domain.Payment, error) {
 	}
 	p.RefundedAt = refundedAt
 	p.PaidAt = paidAt
+	p.Amount.Currency = p.Currency
+	p.Refunded.Currency = p.Currency
+	if authorized != nil {
+		held := money.New(*authorized, p.Currency)
+		p.Authorized = &held
+	}
 
 	return &p, nil
 }
@@ -319,6 +556,14 @@ func scanPaymentMethod(row pgx.Row) (*domain.PaymentMethod, error) {
 	return pm, nil
 }
 
+// authorizedMinor — the hold in minor units, NULL for one-stage payments
+func authorizedMinor(p *domain.Payment) *int64 {
+	if p.Authorized == nil {
+		return nil
+	}
+	return &p.Authorized.Minor
+}
+
 func nullStr(s string) *string {
 	if s == "" {
 		return nil
@@ -5,6 +5,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/alexevil1979/indrive/packages/money-go"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
@@ -24,9 +25,9 @@ func NewPromoRepo(pool *pgxpool.Pool) *PromoRepo {
 // Create inserts a new promo code
 func (r *PromoRepo) Create(ctx context.Context, promo *domain.Promo) error {
 	query := `
-		INSERT INTO promos (code, description, type, value, min_order_value, max_discount, 
-		                    usage_limit, per_user_limit, is_active, starts_at, expires_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		INSERT INTO promos (code, description, type, percent_bp, amount_off_minor, currency, min_order_minor,
+		                    max_discount_minor, usage_limit, per_user_limit, is_active, starts_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 		RETURNING id, created_at, updated_at
 	`
 	var expiresAt *time.Time
@@ -38,9 +39,11 @@ func (r *PromoRepo) Create(ctx context.Context, promo *domain.Promo) error {
 		strings.ToUpper(promo.Code),
 		promo.Description,
 		promo.Type,
-		promo.Value,
-		promo.MinOrderValue,
-		promo.MaxDiscount,
+		int64(promo.Percent),
+		promo.AmountOff.Minor,
+		promo.Currency,
+		promo.MinOrderValue.Minor,
+		promo.MaxDiscount.Minor,
 		promo.UsageLimit,
 		promo.PerUserLimit,
 		promo.IsActive,
@@ -52,49 +55,23 @@ func (r *PromoRepo) Create(ctx context.Context, promo *domain.Promo) error {
 // GetByID retrieves a promo by ID
 func (r *PromoRepo) GetByID(ctx context.Context, id string) (*domain.Promo, error) {
 	query := `
-		SELECT id, code, description, type, value, min_order_value, max_discount,
-		       usage_limit, usage_count, per_user_limit, is_active, starts_at, 
+		SELECT id, code, description, type, percent_bp, amount_off_minor, currency, min_order_minor,
+		       max_discount_minor, usage_limit, usage_count, per_user_limit, is_active, starts_at, 
 		       COALESCE(expires_at, '0001-01-01'::timestamptz), created_at, updated_at
 		FROM promos WHERE id = $1
 	`
-	var promo domain.Promo
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&promo.ID, &promo.Code, &promo.Description, &promo.Type, &promo.Value,
-		&promo.MinOrderValue, &promo.MaxDiscount, &promo.UsageLimit, &promo.UsageCount,
-		&promo.PerUserLimit, &promo.IsActive, &promo.StartsAt, &promo.ExpiresAt,
-		&promo.CreatedAt, &promo.UpdatedAt,
-	)
-	if err == pgx.ErrNoRows {
-		return nil, err
-	}
-	if err != nil {
-		return nil, err
-	}
-	return &promo, nil
+	return scanPromo(r.pool.QueryRow(ctx, query, id))
 }
 
 // GetByCode retrieves a promo by code (case-insensitive)
 func (r *PromoRepo) GetByCode(ctx context.Context, code string) (*domain.Promo, error) {
 	query := `
-		SELECT id, code, description, type, value, min_order_value, max_discount,
-		       usage_limit, usage_count, per_user_limit, is_active, starts_at, 
+		SELECT id, code, description, type, percent_bp, amount_off_minor, currency, min_order_minor,
+		       max_discount_minor, usage_limit, usage_count, per_user_limit, is_active, starts_at, 
 		       COALESCE(expires_at, '0001-01-01'::timestamptz), created_at, updated_at
 		FROM promos WHERE UPPER(code) = UPPER($1)
 	`
-	var promo domain.Promo
-	err := r.pool.QueryRow(ctx, query, code).Scan(
-		&promo.ID, &promo.Code, &promo.Description, &promo.Type, &promo.Value,
-		&promo.MinOrderValue, &promo.MaxDiscount, &promo.UsageLimit, &promo.UsageCount,
-		&promo.PerUserLimit, &promo.IsActive, &promo.StartsAt, &promo.ExpiresAt,
-		&promo.CreatedAt, &promo.UpdatedAt,
-	)
-	if err == pgx.ErrNoRows {
-		return nil, err
-	}
-	if err != nil {
-		return nil, err
-	}
-	return &promo, nil
+	return scanPromo(r.pool.QueryRow(ctx, query, code))
 }
 
 // Update updates a promo
@@ -103,14 +80,16 @@ func (r *PromoRepo) Update(ctx context.Context, promo *domain.Promo) error {
 		UPDATE promos SET
 			description = $2,
 			type = $3,
-			value = $4,
-			min_order_value = $5,
-			max_discount = $6,
-			usage_limit = $7,
-			per_user_limit = $8,
-			is_active = $9,
-			starts_at = $10,
-			expires_at = $11,
+			percent_bp = $4,
+			amount_off_minor = $5,
+			currency = $6,
+			min_order_minor = $7,
+			max_discount_minor = $8,
+			usage_limit = $9,
+			per_user_limit = $10,
+			is_active = $11,
+			starts_at = $12,
+			expires_at = $13,
 			updated_at = NOW()
 		WHERE id = $1
 		RETURNING updated_at
@@ -124,9 +103,11 @@ func (r *PromoRepo) Update(ctx context.Context, promo *domain.Promo) error {
 		promo.ID,
 		promo.Description,
 		promo.Type,
-		promo.Value,
-		promo.MinOrderValue,
-		promo.MaxDiscount,
+		int64(promo.Percent),
+		promo.AmountOff.Minor,
+		promo.Currency,
+		promo.MinOrderValue.Minor,
+		promo.MaxDiscount.Minor,
 		promo.UsageLimit,
 		promo.PerUserLimit,
 		promo.IsActive,
@@ -155,8 +136,8 @@ func (r *PromoRepo) List(ctx context.Context, limit, offset int, activeOnly bool
 
 	// Get promos
 	query := `
-		SELECT id, code, description, type, value, min_order_value, max_discount,
-		       usage_limit, usage_count, per_user_limit, is_active, starts_at, 
+		SELECT id, code, description, type, percent_bp, amount_off_minor, currency, min_order_minor,
+		       max_discount_minor, usage_limit, usage_count, per_user_limit, is_active, starts_at, 
 		       COALESCE(expires_at, '0001-01-01'::timestamptz), created_at, updated_at
 		FROM promos
 	`
@@ -173,16 +154,11 @@ func (r *PromoRepo) List(ctx context.Context, limit, offset int, activeOnly bool
 
 	var promos []domain.Promo
 	for rows.Next() {
-		var promo domain.Promo
-		if err := rows.Scan(
-			&promo.ID, &promo.Code, &promo.Description, &promo.Type, &promo.Value,
-			&promo.MinOrderValue, &promo.MaxDiscount, &promo.UsageLimit, &promo.UsageCount,
-			&promo.PerUserLimit, &promo.IsActive, &promo.StartsAt, &promo.ExpiresAt,
-			&promo.CreatedAt, &promo.UpdatedAt,
-		); err != nil {
+		promo, err := scanPromo(rows)
+		if err != nil {
 			return nil, 0, err
 		}
-		promos = append(promos, promo)
+		promos = append(promos, *promo)
 	}
 	return promos, total, rows.Err()
 }
@@ -195,10 +171,10 @@ func (r *PromoRepo) GetUserPromoCount(ctx context.Context, userID, promoID strin
 	return count, err
 }
 
-// RecordUsage records promo usage by a user
-func (r *PromoRepo) RecordUsage(ctx context.Context, usage *domain.UserPromo) error {
+// RecordUsage records promo usage by a user and books entries (reference: the usage) in the same transaction
+func (r *PromoRepo) RecordUsage(ctx context.Context, usage *domain.UserPromo, entries ...*domain.JournalEntry) error {
 	query := `
-		INSERT INTO user_promos (user_id, promo_id, ride_id, discount)
+		INSERT INTO user_promos (user_id, promo_id, ride_id, discount_minor)
 		VALUES ($1, $2, $3, $4)
 		RETURNING id, used_at
 	`
@@ -207,19 +183,26 @@ func (r *PromoRepo) RecordUsage(ctx context.Context, usage *domain.UserPromo) er
 		rideID = &usage.RideID
 	}
 
-	return r.pool.QueryRow(ctx, query,
-		usage.UserID,
-		usage.PromoID,
-		rideID,
-		usage.Discount,
-	).Scan(&usage.ID, &usage.UsedAt)
+	return inTx(ctx, r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			usage.UserID,
+			usage.PromoID,
+			rideID,
+			usage.Discount.Minor,
+		).Scan(&usage.ID, &usage.UsedAt)
+		if err != nil {
+			return err
+		}
+		return insertEntries(ctx, tx, usage.ID, entries)
+	})
 }
 
 // GetUserPromos returns user's promo usage history
 func (r *PromoRepo) GetUserPromos(ctx context.Context, userID string, limit int) ([]domain.UserPromo, error) {
 	query := `
-		SELECT up.id, up.user_id, up.promo_id, COALESCE(up.ride_id::text, ''), up.discount, up.used_at
+		SELECT up.id, up.user_id, up.promo_id, COALESCE(up.ride_id::text, ''), up.discount_minor, p.currency, up.used_at
 		FROM user_promos up
+		JOIN promos p ON p.id = up.promo_id
 		WHERE up.user_id = $1
 		ORDER BY up.used_at DESC
 		LIMIT $2
@@ -233,7 +216,7 @@ func (r *PromoRepo) GetUserPromos(ctx context.Context, userID string, limit int)
 	var usages []domain.UserPromo
 	for rows.Next() {
 		var u domain.UserPromo
-		if err := rows.Scan(&u.ID, &u.UserID, &u.PromoID, &u.RideID, &u.Discount, &u.UsedAt); err != nil {
+		if err := rows.Scan(&u.ID, &u.UserID, &u.PromoID, &u.RideID, &u.Discount.Minor, &u.Discount.Currency, &u.UsedAt); err != nil {
 			return nil, err
 		}
 		usages = append(usages, u)
@@ -248,3 +231,23 @@ func (r *PromoRepo) CheckPromoUsedForRide(ctx context.Context, rideID string) (b
 	err := r.pool.QueryRow(ctx, query, rideID).Scan(&exists)
 	return exists, err
 }
+
+// scanPromo reads a promo row; amounts take the promo's currency
+func scanPromo(row pgx.Row) (*domain.Promo, error) {
+	var promo domain.Promo
+	var percent int64
+	err := row.Scan(
+		&promo.ID, &promo.Code, &promo.Description, &promo.Type, &percent, &promo.AmountOff.Minor, &promo.Currency,
+		&promo.MinOrderValue.Minor, &promo.MaxDiscount.Minor, &promo.UsageLimit, &promo.UsageCount,
+		&promo.PerUserLimit, &promo.IsActive, &promo.StartsAt, &promo.ExpiresAt,
+		&promo.CreatedAt, &promo.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	promo.Percent = money.Rate(percent)
+	promo.AmountOff.Currency = promo.Currency
+	promo.MinOrderValue.Currency = promo.Currency
+	promo.MaxDiscount.Currency = promo.Currency
+	return &promo, nil
+}
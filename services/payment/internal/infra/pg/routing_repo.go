@@ -0,0 +1,112 @@
+package pg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// RoutingRepo — card routing rules
+type RoutingRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewRoutingRepo creates routing rule repository
+func NewRoutingRepo(pool *pgxpool.Pool) *RoutingRepo {
+	return &RoutingRepo{pool: pool}
+}
+
+const routingRuleColumns = `id, name, priority, min_amount_minor, max_amount_minor, COALESCE(card_brand, ''),
+	providers, strategy, created_at, updated_at`
+
+// ListRoutingRules returns all rules by priority
+func (r *RoutingRepo) ListRoutingRules(ctx context.Context) ([]*domain.RoutingRule, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+routingRuleColumns+` FROM card_routing_rules ORDER BY priority, created_at`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*domain.RoutingRule
+	for rows.Next() {
+		var rule domain.RoutingRule
+		var minAmount, maxAmount *int64
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.Priority, &minAmount, &maxAmount, &rule.CardBrand,
+			&rule.Providers, &rule.Strategy, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rule.MinAmount, rule.MaxAmount = rubles(minAmount), rubles(maxAmount)
+		rules = append(rules, &rule)
+	}
+	return rules, rows.Err()
+}
+
+// CreateRoutingRule inserts a rule; domain.ErrRoutingRuleExists for a taken name
+func (r *RoutingRepo) CreateRoutingRule(ctx context.Context, rule *domain.RoutingRule) error {
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO card_routing_rules (name, priority, min_amount_minor, max_amount_minor, card_brand, providers, strategy)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT DO NOTHING
+		 RETURNING id, created_at, updated_at`,
+		rule.Name, rule.Priority, minorOf(rule.MinAmount), minorOf(rule.MaxAmount), nullStr(rule.CardBrand),
+		rule.Providers, rule.Strategy,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrRoutingRuleExists
+	}
+	return err
+}
+
+// UpdateRoutingRule updates a rule
+func (r *RoutingRepo) UpdateRoutingRule(ctx context.Context, rule *domain.RoutingRule) error {
+	err := r.pool.QueryRow(ctx,
+		`UPDATE card_routing_rules SET name = $1, priority = $2, min_amount_minor = $3, max_amount_minor = $4,
+		        card_brand = $5, providers = $6, strategy = $7, updated_at = now()
+		 WHERE id = $8 RETURNING created_at, updated_at`,
+		rule.Name, rule.Priority, minorOf(rule.MinAmount), minorOf(rule.MaxAmount), nullStr(rule.CardBrand),
+		rule.Providers, rule.Strategy, rule.ID,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return domain.ErrRoutingRuleNotFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return domain.ErrRoutingRuleExists
+	}
+	return err
+}
+
+// DeleteRoutingRule removes a rule
+func (r *RoutingRepo) DeleteRoutingRule(ctx context.Context, id string) error {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM card_routing_rules WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return domain.ErrRoutingRuleNotFound
+	}
+	return nil
+}
+
+func rubles(minor *int64) *money.Money {
+	if minor == nil {
+		return nil
+	}
+	m := money.New(*minor, money.RUB)
+	return &m
+}
+
+func minorOf(m *money.Money) *int64 {
+	if m == nil {
+		return nil
+	}
+	return &m.Minor
+}
@@ -0,0 +1,148 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// WalletRepo — passenger wallets. Balances change only through journal entries
+// (insertEntries), so every repository booking a wallet posting keeps them in step.
+type WalletRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewWalletRepo creates wallet repository
+func NewWalletRepo(pool *pgxpool.Pool) *WalletRepo {
+	return &WalletRepo{pool: pool}
+}
+
+// GetWallet returns the user's wallet, nil when nothing was ever booked to it
+func (r *WalletRepo) GetWallet(ctx context.Context, userID string) (*domain.Wallet, error) {
+	var w domain.Wallet
+	err := r.pool.QueryRow(ctx,
+		`SELECT user_id, balance_minor, currency, updated_at FROM wallets WHERE user_id = $1`,
+		userID,
+	).Scan(&w.UserID, &w.Balance.Minor, &w.Balance.Currency, &w.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ListWallets returns every wallet (reconciliation)
+func (r *WalletRepo) ListWallets(ctx context.Context) ([]*domain.Wallet, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT user_id, balance_minor, currency, updated_at FROM wallets ORDER BY user_id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wallets []*domain.Wallet
+	for rows.Next() {
+		var w domain.Wallet
+		if err := rows.Scan(&w.UserID, &w.Balance.Minor, &w.Balance.Currency, &w.UpdatedAt); err != nil {
+			return nil, err
+		}
+		wallets = append(wallets, &w)
+	}
+	return wallets, rows.Err()
+}
+
+// ListTransactions returns the user's wallet history, newest first
+func (r *WalletRepo) ListTransactions(ctx context.Context, userID string, limit, offset int) ([]*domain.WalletTransaction, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, entry_id, kind, reference, amount_minor, balance_after_minor, currency, COALESCE(memo, ''), created_at
+		 FROM wallet_transactions WHERE user_id = $1
+		 ORDER BY created_at DESC, id LIMIT $2 OFFSET $3`,
+		userID, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []*domain.WalletTransaction
+	for rows.Next() {
+		var t domain.WalletTransaction
+		var currency string
+		err := rows.Scan(&t.ID, &t.EntryID, &t.Kind, &t.Reference, &t.Amount.Minor, &t.BalanceAfter.Minor,
+			&currency, &t.Memo, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		t.Amount.Currency, t.BalanceAfter.Currency = currency, currency
+		txs = append(txs, &t)
+	}
+	return txs, rows.Err()
+}
+
+// BookCredit books a platform credit; false when its reference was already booked
+func (r *WalletRepo) BookCredit(ctx context.Context, entry *domain.JournalEntry) (bool, error) {
+	err := inTx(ctx, r.pool, func(tx pgx.Tx) error {
+		return insertEntries(ctx, tx, "", []*domain.JournalEntry{entry})
+	})
+	return entry.ID != "", err
+}
+
+// applyWalletPostings applies the wallet postings of a booked entry. The wallet row is
+// locked first, so concurrent spends of one wallet serialize and a spend beyond the
+// balance fails the whole transaction with domain.ErrInsufficientFunds.
+func applyWalletPostings(ctx context.Context, tx pgx.Tx, e *domain.JournalEntry) error {
+	for _, p := range e.Postings {
+		typ, userID, _ := strings.Cut(p.Account, ":")
+		if typ != domain.AccountTypeWallet {
+			continue
+		}
+		_, err := tx.Exec(ctx,
+			`INSERT INTO wallets (user_id, currency) VALUES ($1, $2) ON CONFLICT (user_id) DO NOTHING`,
+			userID, p.Amount.Currency,
+		)
+		if err != nil {
+			return err
+		}
+		var balance int64
+		var currency string
+		err = tx.QueryRow(ctx,
+			`SELECT balance_minor, currency FROM wallets WHERE user_id = $1 FOR UPDATE`,
+			userID,
+		).Scan(&balance, &currency)
+		if err != nil {
+			return err
+		}
+		if currency != p.Amount.Currency {
+			return domain.ErrWalletCurrency
+		}
+		// credit (negative posting) adds to the wallet
+		balance -= p.Amount.Minor
+		if balance < 0 {
+			return domain.ErrInsufficientFunds
+		}
+		_, err = tx.Exec(ctx,
+			`UPDATE wallets SET balance_minor = $2, updated_at = now() WHERE user_id = $1`,
+			userID, balance,
+		)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx,
+			`INSERT INTO wallet_transactions (user_id, entry_id, kind, reference, amount_minor, currency, balance_after_minor, memo)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+			userID, e.ID, e.Kind, e.Reference, -p.Amount.Minor, currency, balance, nullStr(e.Memo),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// WebhookRepo — the webhook inbox
+type WebhookRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewWebhookRepo creates webhook repository
+func NewWebhookRepo(pool *pgxpool.Pool) *WebhookRepo {
+	return &WebhookRepo{pool: pool}
+}
+
+const webhookColumns = `id, provider, event_id, headers, body, signature_verified, event_type, payment_id, external_id,
+	refund_id, amount_minor, currency, status, attempts, last_error, next_attempt_at, processed_at, created_at`
+
+// SaveWebhook stores a received webhook; false when the provider's event is already in
+// the inbox
+func (r *WebhookRepo) SaveWebhook(ctx context.Context, w *domain.InboxWebhook) (bool, error) {
+	headers := w.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	err := r.pool.QueryRow(ctx,
+		`INSERT INTO webhook_inbox (provider, event_id, headers, body, signature_verified, event_type, payment_id,
+		                            external_id, refund_id, amount_minor, currency, status, last_error, next_attempt_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		 ON CONFLICT (provider, event_id) DO NOTHING
+		 RETURNING id, created_at`,
+		w.Provider, w.EventID, headers, w.Body, w.SignatureVerified, w.EventType, w.PaymentID,
+		w.ExternalID, w.RefundID, w.Amount.Minor, w.Amount.Currency, w.Status, w.LastError, w.NextAttemptAt,
+	).Scan(&w.ID, &w.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ClaimWebhooks returns pending webhooks due by now, oldest first, and moves their next
+// attempt to leaseUntil; rows claimed by a concurrent worker are skipped
+func (r *WebhookRepo) ClaimWebhooks(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*domain.InboxWebhook, error) {
+	rows, err := r.pool.Query(ctx,
+		`UPDATE webhook_inbox SET next_attempt_at = $2
+		 WHERE id IN (
+		     SELECT id FROM webhook_inbox
+		     WHERE status = 'pending' AND next_attempt_at <= $1
+		     ORDER BY next_attempt_at LIMIT $3
+		     FOR UPDATE SKIP LOCKED)
+		 RETURNING `+webhookColumns,
+		now, leaseUntil, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhooks(rows)
+}
+
+// UpdateWebhook stores the outcome of a processing attempt
+func (r *WebhookRepo) UpdateWebhook(ctx context.Context, w *domain.InboxWebhook) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE webhook_inbox
+		 SET status = $2, attempts = $3, last_error = $4, next_attempt_at = $5, processed_at = $6
+		 WHERE id = $1`,
+		w.ID, w.Status, w.Attempts, w.LastError, w.NextAttemptAt, w.ProcessedAt,
+	)
+	return err
+}
+
+// GetWebhook returns a webhook by ID, nil when not found
+func (r *WebhookRepo) GetWebhook(ctx context.Context, id string) (*domain.InboxWebhook, error) {
+	rows, err := r.pool.Query(ctx, `SELECT `+webhookColumns+` FROM webhook_inbox WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := scanWebhooks(rows)
+	if err != nil || len(webhooks) == 0 {
+		return nil, err
+	}
+	return webhooks[0], nil
+}
+
+// ListWebhooks returns webhooks, newest first; provider and status filter when set
+func (r *WebhookRepo) ListWebhooks(ctx context.Context, provider, status string, limit, offset int) ([]*domain.InboxWebhook, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+webhookColumns+`
+		 FROM webhook_inbox
+		 WHERE ($1 = '' OR provider = $1) AND ($2 = '' OR status = $2)
+		 ORDER BY created_at DESC, id LIMIT $3 OFFSET $4`,
+		provider, status, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanWebhooks(rows)
+}
+
+func scanWebhooks(rows pgx.Rows) ([]*domain.InboxWebhook, error) {
+	defer rows.Close()
+
+	var webhooks []*domain.InboxWebhook
+	for rows.Next() {
+		var w domain.InboxWebhook
+		err := rows.Scan(&w.ID, &w.Provider, &w.EventID, &w.Headers, &w.Body, &w.SignatureVerified, &w.EventType,
+			&w.PaymentID, &w.ExternalID, &w.RefundID, &w.Amount.Minor, &w.Amount.Currency,
+			&w.Status, &w.Attempts, &w.LastError, &w.NextAttemptAt, &w.ProcessedAt, &w.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, &w)
+	}
+	return webhooks, rows.Err()
+}
@@ -0,0 +1,407 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+)
+
+// EarningsRepository — commission rules, driver profiles, earnings and payouts
+type EarningsRepository interface {
+	ListCommissionRules(ctx context.Context) ([]*domain.CommissionRule, error)
+	CreateCommissionRule(ctx context.Context, rule *domain.CommissionRule) error
+	UpdateCommissionRule(ctx context.Context, rule *domain.CommissionRule) error
+	DeleteCommissionRule(ctx context.Context, id string) error
+
+	GetDriverProfile(ctx context.Context, driverID string) (*domain.DriverProfile, error)
+	SaveDriverProfile(ctx context.Context, p *domain.DriverProfile) error
+
+	// SaveRideCompletion keeps a completed ride until its earning is recorded; idempotent
+	SaveRideCompletion(ctx context.Context, c *domain.RideCompletion) error
+	// GetRideCompletion returns the ride's completion while its earning is not recorded, else nil
+	GetRideCompletion(ctx context.Context, rideID string) (*domain.RideCompletion, error)
+	// ListPendingCompletions returns completions without a recorded earning, oldest first
+	ListPendingCompletions(ctx context.Context, limit, offset int) ([]*domain.RideCompletion, error)
+	// RecordEarning books entries in the same transaction; false when the ride was recorded before
+	RecordEarning(ctx context.Context, e *domain.RideEarning, entries ...*domain.JournalEntry) (bool, error)
+	ListEarnings(ctx context.Context, driverID string, from, to time.Time, limit, offset int) ([]*domain.RideEarning, error)
+	DriverBalance(ctx context.Context, driverID, currency string) (money.Money, error)
+
+	CreatePayoutBatch(ctx context.Context, start, end time.Time, min money.Money) (*domain.PayoutBatch, []*domain.Payout, error)
+	GetPayout(ctx context.Context, id string) (*domain.Payout, error)
+	ListPayouts(ctx context.Context, driverID string, limit, offset int) ([]*domain.Payout, error)
+	ListOpenPayouts(ctx context.Context) ([]*domain.Payout, error)
+	// UpdatePayout books entries in the same transaction
+	UpdatePayout(ctx context.Context, p *domain.Payout, entries ...*domain.JournalEntry) error
+	ListPayoutBatches(ctx context.Context, limit, offset int) ([]*domain.PayoutBatch, error)
+}
+
+// RidePaymentFinder — the payment of a ride, to tell card rides from cash ones
+type RidePaymentFinder interface {
+	GetByRideID(ctx context.Context, rideID string) (*domain.Payment, error)
+}
+
+// EarningsConfig — commission and payouts
+type EarningsConfig struct {
+	DefaultRate money.Rate  // commission when no rule matches
+	MinPayout   money.Money // smaller balances wait for the next week
+}
+
+// DefaultEarningsConfig — 15% commission, payouts from 100 ₽
+func DefaultEarningsConfig() EarningsConfig {
+	return EarningsConfig{DefaultRate: 1500, MinPayout: money.New(10000, money.RUB)}
+}
+
+// EarningsUseCase — driver earnings: commission on completed rides, balances kept in the
+// ledger, weekly payouts of positive balances
+type EarningsUseCase struct {
+	repo     EarningsRepository
+	payments RidePaymentFinder
+	payouts  *gateway.PayoutManager
+	cfg      EarningsConfig
+	now      func() time.Time
+}
+
+// NewEarningsUseCase creates earnings use case
+func NewEarningsUseCase(repo EarningsRepository, payments RidePaymentFinder, payouts *gateway.PayoutManager, cfg EarningsConfig) *EarningsUseCase {
+	return &EarningsUseCase{repo: repo, payments: payments, payouts: payouts, cfg: cfg, now: time.Now}
+}
+
+// CompleteRide keeps the completion of a ride until its earning is recorded, so a card
+// ride paid later, or a recording that failed, is booked by RidePaid or RecordPending
+func (uc *EarningsUseCase) CompleteRide(ctx context.Context, ride domain.RideCompletion) error {
+	if ride.RideID == "" || ride.DriverID == "" {
+		return errors.New("ride and driver are required")
+	}
+	return uc.repo.SaveRideCompletion(ctx, &ride)
+}
+
+// RecordRide books the driver's earning of a completed ride. A ride with a completed card
+// or wallet payment is paid to the platform and its captured amount is the fare; a card
+// ride without one returns ErrRideNotPaid; any other ride was paid in cash at the ride's
+// price. Repeated calls return nil, nil.
+func (uc *EarningsUseCase) RecordRide(ctx context.Context, ride domain.RideCompletion) (*domain.RideEarning, error) {
+	if ride.RideID == "" || ride.DriverID == "" {
+		return nil, errors.New("ride and driver are required")
+	}
+	e := &domain.RideEarning{RideID: ride.RideID, DriverID: ride.DriverID, Category: ride.Category, Method: domain.MethodCash}
+
+	p, err := uc.payments.GetByRideID(ctx, ride.RideID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p != nil && domain.PaidToPlatform(p.Method):
+		if p.Status != domain.PaymentStatusCompleted {
+			return nil, domain.ErrRideNotPaid
+		}
+		e.Method = p.Method
+		e.PassengerID = p.UserID
+		e.Fare = p.Amount
+		if ride.Fare != nil {
+			e.Tip = money.Min(ride.Fare.Tip.In(p.Currency), p.Amount)
+		}
+	case ride.PaymentMethod == domain.MethodCard:
+		return nil, domain.ErrRideNotPaid // the passenger has not paid yet
+	case ride.Fare != nil:
+		fare := ride.Fare.In(money.RUB)
+		e.Fare = fare.Total()
+		e.Tip = fare.Tip
+	default:
+		return nil, domain.ErrNoFare
+	}
+
+	profile, err := uc.repo.GetDriverProfile(ctx, ride.DriverID)
+	if err != nil {
+		return nil, err
+	}
+	e.Tier = domain.DriverTierStandard
+	if profile != nil {
+		e.City, e.Tier = profile.City, profile.Tier
+	}
+	rules, err := uc.repo.ListCommissionRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	e.Rate = uc.cfg.DefaultRate
+	if rule := domain.MatchCommissionRule(rules, e.City, e.Category, e.Tier); rule != nil {
+		e.Rate, e.RuleID = rule.Rate, rule.ID
+	}
+	e.Settle()
+
+	var entries []*domain.JournalEntry
+	if entry := e.LedgerEntry(); entry != nil {
+		entries = append(entries, entry)
+	}
+	recorded, err := uc.repo.RecordEarning(ctx, e, entries...)
+	if err != nil || !recorded {
+		return nil, err
+	}
+	return e, nil
+}
+
+// RidePaid records the earning of the ride p pays for, when the ride was completed before
+// its payment; called once a payment completes. Failures are left to RecordPending.
+func (uc *EarningsUseCase) RidePaid(ctx context.Context, p *domain.Payment) {
+	if p.RideID == "" || p.BookingID != "" {
+		return
+	}
+	ride, err := uc.repo.GetRideCompletion(ctx, p.RideID)
+	if err == nil && ride != nil {
+		_, err = uc.RecordRide(ctx, *ride)
+	}
+	if err != nil {
+		slog.Warn("ride earning not recorded on payment", "ride_id", p.RideID, "error", err)
+	}
+}
+
+// pendingBatch — completions read per page by RecordPending
+const pendingBatch = 100
+
+// RecordPending retries the completed rides whose earning is not recorded yet and
+// returns how many got recorded; card rides still unpaid are left for later
+func (uc *EarningsUseCase) RecordPending(ctx context.Context) (int, error) {
+	recorded, skipped := 0, 0
+	for {
+		// Recorded rides leave the pending list, so the next page starts after the skipped ones
+		rides, err := uc.repo.ListPendingCompletions(ctx, pendingBatch, skipped)
+		if err != nil {
+			return recorded, err
+		}
+		for _, ride := range rides {
+			e, err := uc.RecordRide(ctx, *ride)
+			switch {
+			case errors.Is(err, domain.ErrRideNotPaid):
+				skipped++
+			case err != nil:
+				skipped++
+				slog.Warn("ride earning not recorded", "ride_id", ride.RideID, "error", err)
+			case e != nil:
+				recorded++
+			}
+		}
+		if len(rides) < pendingBatch {
+			return recorded, nil
+		}
+	}
+}
+
+// Summary returns the driver's balance and ride earnings, newest first
+func (uc *EarningsUseCase) Summary(ctx context.Context, driverID string, limit, offset int) (*domain.EarningsSummary, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	balance, err := uc.repo.DriverBalance(ctx, driverID, money.RUB)
+	if err != nil {
+		return nil, err
+	}
+	rides, err := uc.repo.ListEarnings(ctx, driverID, time.Time{}, time.Time{}, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.EarningsSummary{Balance: balance, Rides: rides}, nil
+}
+
+// ListPayouts returns the driver's payouts, newest first
+func (uc *EarningsUseCase) ListPayouts(ctx context.Context, driverID string, limit, offset int) ([]*domain.Payout, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return uc.repo.ListPayouts(ctx, driverID, limit, offset)
+}
+
+// maxStatementRides — rides listed on one weekly statement
+const maxStatementRides = 1000
+
+// Statement returns the driver's payout with the rides of its week
+func (uc *EarningsUseCase) Statement(ctx context.Context, driverID, payoutID string) (*domain.PayoutStatement, error) {
+	p, err := uc.repo.GetPayout(ctx, payoutID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || p.DriverID != driverID {
+		return nil, domain.ErrPayoutNotFound
+	}
+	rides, err := uc.repo.ListEarnings(ctx, driverID, p.PeriodStart, p.PeriodEnd, maxStatementRides, 0)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewPayoutStatement(p, rides), nil
+}
+
+// Profile returns the driver's profile; drivers nobody set up get the standard tier
+func (uc *EarningsUseCase) Profile(ctx context.Context, driverID string) (*domain.DriverProfile, error) {
+	p, err := uc.repo.GetDriverProfile(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		p = &domain.DriverProfile{DriverID: driverID, Tier: domain.DriverTierStandard}
+	}
+	return p, nil
+}
+
+// SetPayoutDestination saves where the driver's payouts go
+func (uc *EarningsUseCase) SetPayoutDestination(ctx context.Context, driverID, provider, destination string) (*domain.DriverProfile, error) {
+	destination = strings.TrimSpace(destination)
+	if _, ok := uc.payouts.Get(provider); !ok || destination == "" || len(destination) > 100 {
+		return nil, domain.ErrInvalidPayoutSetup
+	}
+	p, err := uc.Profile(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	p.PayoutProvider, p.PayoutDestination = provider, destination
+	if err := uc.repo.SaveDriverProfile(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetDriverProfile sets the driver's city and tier (admin); an empty tier is standard
+func (uc *EarningsUseCase) SetDriverProfile(ctx context.Context, driverID, city, tier string) (*domain.DriverProfile, error) {
+	p, err := uc.Profile(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	p.City = strings.TrimSpace(city)
+	p.Tier = strings.TrimSpace(tier)
+	if p.Tier == "" {
+		p.Tier = domain.DriverTierStandard
+	}
+	if err := uc.repo.SaveDriverProfile(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// --- Commission rules (admin) ---
+
+// ListRules returns commission rules
+func (uc *EarningsUseCase) ListRules(ctx context.Context) ([]*domain.CommissionRule, error) {
+	rules, err := uc.repo.ListCommissionRules(ctx)
+	if rules == nil && err == nil {
+		rules = []*domain.CommissionRule{}
+	}
+	return rules, err
+}
+
+// CreateRule adds a commission rule
+func (uc *EarningsUseCase) CreateRule(ctx context.Context, rule *domain.CommissionRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	return uc.repo.CreateCommissionRule(ctx, rule)
+}
+
+// UpdateRule changes a commission rule; rides already recorded keep their commission
+func (uc *EarningsUseCase) UpdateRule(ctx context.Context, rule *domain.CommissionRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	return uc.repo.UpdateCommissionRule(ctx, rule)
+}
+
+// DeleteRule removes a commission rule
+func (uc *EarningsUseCase) DeleteRule(ctx context.Context, id string) error {
+	return uc.repo.DeleteCommissionRule(ctx, id)
+}
+
+// --- Payouts ---
+
+// ListBatches returns payout batches, newest first
+func (uc *EarningsUseCase) ListBatches(ctx context.Context, limit, offset int) ([]*domain.PayoutBatch, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return uc.repo.ListPayoutBatches(ctx, limit, offset)
+}
+
+// RunPayouts creates the batch of the last full week unless it exists, then sends open
+// payouts. Safe to run often: it is what the hourly scheduler calls. Returns the new
+// batch, nil when the week already had one.
+func (uc *EarningsUseCase) RunPayouts(ctx context.Context) (*domain.PayoutBatch, error) {
+	start, end := domain.PayoutWeek(uc.now())
+	batch, _, err := uc.repo.CreatePayoutBatch(ctx, start, end, uc.cfg.MinPayout)
+	if errors.Is(err, domain.ErrBatchExists) {
+		batch, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	uc.SendPayouts(ctx)
+	return batch, nil
+}
+
+// SendPayouts sends pending payouts and polls processing ones. A rejected payout fails
+// and its amount goes back to the driver's balance; unavailable providers are retried
+// on the next run. Returns the number of payouts that changed status.
+func (uc *EarningsUseCase) SendPayouts(ctx context.Context) int {
+	open, err := uc.repo.ListOpenPayouts(ctx)
+	if err != nil {
+		slog.Warn("list open payouts failed", "error", err)
+		return 0
+	}
+	changed := 0
+	for _, p := range open {
+		ok, err := uc.sendPayout(ctx, p)
+		if err != nil {
+			slog.Warn("payout failed", "payout_id", p.ID, "driver_id", p.DriverID, "error", err)
+		}
+		if ok {
+			changed++
+		}
+	}
+	return changed
+}
+
+func (uc *EarningsUseCase) sendPayout(ctx context.Context, p *domain.Payout) (bool, error) {
+	gw, ok := uc.payouts.Get(p.Provider)
+	if !ok {
+		return false, fmt.Errorf("payout provider %q is not configured", p.Provider)
+	}
+	var result *gateway.PayoutResult
+	var err error
+	if p.Status == domain.PayoutStatusPending {
+		result, err = gw.Payout(ctx, gateway.PayoutInput{
+			PayoutID:    p.ID,
+			Amount:      p.Amount,
+			Destination: p.Destination,
+			Description: "Driver earnings " + p.PeriodStart.Format("2006-01-02") + " – " + p.PeriodEnd.AddDate(0, 0, -1).Format("2006-01-02"),
+		})
+		if errors.Is(err, gateway.ErrPaymentRejected) {
+			result, err = &gateway.PayoutResult{Status: domain.PayoutStatusFailed, FailReason: err.Error()}, nil
+		}
+	} else {
+		result, err = gw.GetPayoutStatus(ctx, p.ExternalID)
+	}
+	if err != nil {
+		return false, err
+	}
+	if result.Status == p.Status {
+		return false, nil
+	}
+
+	if result.ExternalID != "" {
+		p.ExternalID = result.ExternalID
+	}
+	p.Status = result.Status
+	var entries []*domain.JournalEntry
+	switch p.Status {
+	case domain.PayoutStatusPaid:
+		now := uc.now()
+		p.PaidAt = &now
+	case domain.PayoutStatusFailed:
+		p.FailReason = result.FailReason
+		entries = append(entries, p.FailedEntry())
+	}
+	return true, uc.repo.UpdatePayout(ctx, p, entries...)
+}
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+)
+
+// rejectingPayouts fails every payout
+type rejectingPayouts struct{}
+
+func (rejectingPayouts) Provider() string { return domain.PayoutProviderYooMoney }
+
+func (rejectingPayouts) Payout(ctx context.Context, input gateway.PayoutInput) (*gateway.PayoutResult, error) {
+	return nil, fmt.Errorf("%w: invalid_payout_token", gateway.ErrPaymentRejected)
+}
+
+func (rejectingPayouts) GetPayoutStatus(ctx context.Context, externalID string) (*gateway.PayoutResult, error) {
+	return nil, gateway.ErrProviderUnavailable
+}
+
+func newEarningsTest() (*EarningsUseCase, *earningsStore, *paymentStore) {
+	store := &earningsStore{
+		rules:    []*domain.CommissionRule{{ID: "comfort", Category: "comfort", Rate: 2000}},
+		profiles: map[string]*domain.DriverProfile{},
+		earnings: map[string]*domain.RideEarning{},
+	}
+	payments := newPaymentStore()
+	payouts := gateway.NewPayoutManager()
+	payouts.Register(gateway.NewStubPayoutGateway())
+	payouts.Register(rejectingPayouts{})
+	uc := NewEarningsUseCase(store, payments, payouts, DefaultEarningsConfig())
+	uc.now = func() time.Time { return time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC) }
+	return uc, store, payments
+}
+
+func TestEarnings_RecordRide(t *testing.T) {
+	uc, store, payments := newEarningsTest()
+	ctx := context.Background()
+	payments.add(&domain.Payment{ID: "pay-1", RideID: "r1", UserID: "p1", Method: domain.MethodCard,
+		Status: domain.PaymentStatusCompleted, Amount: money.New(100000, money.RUB), Currency: money.RUB})
+
+	card, err := uc.RecordRide(ctx, domain.RideCompletion{RideID: "r1", DriverID: "d1", Category: "comfort"})
+	if err != nil {
+		t.Fatalf("card ride: %v", err)
+	}
+	if card.Method != domain.MethodCard || card.RuleID != "comfort" || card.Commission.Minor != 20000 || card.Net.Minor != 80000 {
+		t.Errorf("card ride: %+v", card)
+	}
+	if again, err := uc.RecordRide(ctx, domain.RideCompletion{RideID: "r1", DriverID: "d1", Category: "comfort"}); again != nil || err != nil {
+		t.Errorf("repeated event must not book twice: %v %v", again, err)
+	}
+
+	cash, err := uc.RecordRide(ctx, domain.RideCompletion{RideID: "r2", DriverID: "d1", Category: "economy",
+		Fare: &domain.RideFare{Price: money.New(40000, "")}})
+	if err != nil || cash.Method != domain.MethodCash || cash.Rate != 1500 || cash.Net.Minor != -6000 {
+		t.Fatalf("cash ride at the default rate: %+v %v", cash, err)
+	}
+	if balance, _ := store.DriverBalance(ctx, "d1", money.RUB); balance.Minor != 74000 {
+		t.Errorf("balance %s, want 740.00", balance)
+	}
+
+	payments.add(&domain.Payment{ID: "pay-3", RideID: "r3", Method: domain.MethodCard, Status: domain.PaymentStatusAuthorized})
+	if _, err := uc.RecordRide(ctx, domain.RideCompletion{RideID: "r3", DriverID: "d1"}); err != domain.ErrRideNotPaid {
+		t.Errorf("uncaptured card ride: %v", err)
+	}
+	if _, err := uc.RecordRide(ctx, domain.RideCompletion{RideID: "r4", DriverID: "d1"}); err != domain.ErrNoFare {
+		t.Errorf("cash ride without a price: %v", err)
+	}
+}
+
+func TestEarnings_CardRidePaidAfterCompletion(t *testing.T) {
+	uc, store, payments := newEarningsTest()
+	ctx := context.Background()
+	fare := &domain.RideFare{Price: money.New(50000, money.RUB)}
+	for _, ride := range []string{"r1", "r2"} {
+		if err := uc.CompleteRide(ctx, domain.RideCompletion{RideID: ride, DriverID: "d1", PaymentMethod: domain.MethodCard, Fare: fare}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := uc.RecordRide(ctx, *store.rides[0]); err != domain.ErrRideNotPaid {
+		t.Errorf("a card ride without its payment must wait, not be booked as cash: %v", err)
+	}
+	if n, err := uc.RecordPending(ctx); n != 0 || err != nil || len(store.earnings) != 0 {
+		t.Errorf("nothing is paid yet: %d, %v", n, err)
+	}
+
+	// Paid through checkout: the payment's completion records the earning
+	payments.add(&domain.Payment{ID: "pay-1", RideID: "r1", UserID: "p1", Method: domain.MethodCard,
+		Status: domain.PaymentStatusCompleted, Amount: money.New(50000, money.RUB), Currency: money.RUB})
+	uc.RidePaid(ctx, payments.ride("r1"))
+	if e := store.earnings["r1"]; e == nil || e.Method != domain.MethodCard || e.Net.Minor != 42500 {
+		t.Errorf("paid card ride: %+v", e)
+	}
+
+	// Missed by the trigger: the sweep records it, once
+	payments.add(&domain.Payment{ID: "pay-2", RideID: "r2", UserID: "p1", Method: domain.MethodCard,
+		Status: domain.PaymentStatusCompleted, Amount: money.New(50000, money.RUB), Currency: money.RUB})
+	if n, err := uc.RecordPending(ctx); n != 1 || err != nil || store.earnings["r2"] == nil {
+		t.Errorf("RecordPending: %d, %v", n, err)
+	}
+	if n, _ := uc.RecordPending(ctx); n != 0 || len(store.entries) != 2 {
+		t.Errorf("recorded rides must not be booked again: %d, %d entries", n, len(store.entries))
+	}
+}
+
+func TestEarnings_RunPayouts(t *testing.T) {
+	uc, store, payments := newEarningsTest()
+	ctx := context.Background()
+	for i, driver := range []string{"d1", "d2", "d3"} {
+		ride := fmt.Sprintf("r%d", i)
+		payments.add(&domain.Payment{ID: "pay-" + ride, RideID: ride, UserID: "p1", Method: domain.MethodCard,
+			Status: domain.PaymentStatusCompleted, Amount: money.New(50000, money.RUB), Currency: money.RUB})
+		if _, err := uc.RecordRide(ctx, domain.RideCompletion{RideID: ride, DriverID: driver}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store.profiles["d1"] = &domain.DriverProfile{DriverID: "d1", PayoutProvider: domain.PayoutProviderStub, PayoutDestination: "x"}
+	store.profiles["d2"] = &domain.DriverProfile{DriverID: "d2", PayoutProvider: domain.PayoutProviderYooMoney, PayoutDestination: "bad"}
+	// d3 has no payout destination and keeps the balance
+
+	batch, err := uc.RunPayouts(ctx)
+	if err != nil || batch.Count != 2 || batch.PeriodEnd.Day() != 12 {
+		t.Fatalf("batch %+v: %v", batch, err)
+	}
+	statuses := map[string]string{}
+	for _, p := range store.payouts {
+		statuses[p.DriverID] = p.Status
+	}
+	if statuses["d1"] != domain.PayoutStatusPaid || statuses["d2"] != domain.PayoutStatusFailed {
+		t.Errorf("payout statuses %v", statuses)
+	}
+	for driver, want := range map[string]int64{"d1": 0, "d2": 42500, "d3": 42500} {
+		if balance, _ := store.DriverBalance(ctx, driver, money.RUB); balance.Minor != want {
+			t.Errorf("%s balance %s, want %d", driver, balance, want)
+		}
+	}
+}
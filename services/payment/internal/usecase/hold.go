@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+	"github.com/ridehail/payment/internal/infra/pg"
+)
+
+// HoldRepository — what two-stage ride payments need from storage
+type HoldRepository interface {
+	Create(ctx context.Context, p *domain.Payment) error
+	GetByRideID(ctx context.Context, rideID string) (*domain.Payment, error)
+	UpdatePayment(ctx context.Context, p *domain.Payment, entries ...*domain.JournalEntry) error
+	ListPaymentMethods(ctx context.Context, userID string) ([]*domain.PaymentMethod, error)
+}
+
+// HoldConfig — two-stage card payments of rides
+type HoldConfig struct {
+	Buffer money.Rate // held on top of the agreed price, so waiting, stops and tips fit
+}
+
+// DefaultHoldConfig — hold the price plus 20%
+func DefaultHoldConfig() HoldConfig {
+	return HoldConfig{Buffer: 2000}
+}
+
+// HoldUseCase — card payments of rides in two stages: the agreed price is held at match on
+// the saved card the passenger chose for the ride, the final fare captured at completion
+// and the hold voided when the ride is cancelled. Cash rides are not held.
+type HoldUseCase struct {
+	repo     HoldRepository
+	gateways *gateway.Manager
+	cfg      HoldConfig
+	now      func() time.Time
+}
+
+// NewHoldUseCase creates the two-stage payment use case
+func NewHoldUseCase(repo HoldRepository, gateways *gateway.Manager, cfg HoldConfig) *HoldUseCase {
+	return &HoldUseCase{repo: repo, gateways: gateways, cfg: cfg, now: time.Now}
+}
+
+// HoldRide authorizes price plus the buffer on the passenger's saved card cardID.
+// Payment.Amount is the agreed price, Payment.Authorized the hold. Returns nil when the
+// passenger no longer has the card (the ride is then paid through checkout); a repeated
+// event returns the existing payment.
+func (uc *HoldUseCase) HoldRide(ctx context.Context, rideID, passengerID, cardID string, price money.Money) (*domain.Payment, error) {
+	price = price.In(money.RUB)
+	if !price.IsPositive() {
+		return nil, domain.ErrInvalidAmount
+	}
+	methods, err := uc.repo.ListPaymentMethods(ctx, passengerID)
+	if err != nil {
+		return nil, err
+	}
+	var card *domain.PaymentMethod
+	for _, m := range methods {
+		if m.ID == cardID {
+			card = m
+			break
+		}
+	}
+	if card == nil {
+		slog.Warn("ride card not saved, not held", "ride_id", rideID, "card_id", cardID)
+		return nil, nil
+	}
+	gw, ok := uc.gateways.Get(card.Provider)
+	if !ok {
+		return nil, domain.ErrInvalidProvider
+	}
+
+	hold := domain.HoldAmount(price, uc.cfg.Buffer)
+	metadata := map[string]string{"user_id": passengerID, "ride_id": rideID}
+	metadataJSON, _ := json.Marshal(metadata)
+	p := &domain.Payment{
+		RideID:      rideID,
+		UserID:      passengerID,
+		Amount:      price,
+		Currency:    price.Currency,
+		Method:      domain.MethodCard,
+		Provider:    card.Provider,
+		Status:      domain.PaymentStatusPending,
+		Description: "Ride " + rideID,
+		Metadata:    string(metadataJSON),
+		Routing:     domain.Route(domain.RoutingPolicy{}, nil, hold, card.Brand, card.Provider, true, nil, nil),
+	}
+	if err := uc.repo.Create(ctx, p); err != nil {
+		if errors.Is(err, pg.ErrPaymentExists) {
+			return uc.repo.GetByRideID(ctx, rideID)
+		}
+		return nil, err
+	}
+
+	result, err := gw.Authorize(ctx, gateway.CreatePaymentInput{
+		PaymentID:   p.ID,
+		Amount:      hold,
+		Description: p.Description,
+		Metadata:    metadata,
+		TokenID:     card.TokenID,
+	})
+	p.Routing.Record(card.Provider, err, uc.now())
+	if err != nil {
+		p.Status = domain.PaymentStatusFailed
+		p.FailReason = err.Error()
+		uc.repo.UpdatePayment(ctx, p)
+		return nil, err
+	}
+
+	p.ExternalID = result.ExternalID
+	p.ConfirmURL = result.ConfirmURL // 3DS: the hold completes by webhook
+	p.Status = result.Status
+	p.Authorized = &hold
+	if p.Status == domain.PaymentStatusAuthorized {
+		now := uc.now()
+		p.AuthorizedAt = &now
+	}
+	if err := uc.repo.UpdatePayment(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// CaptureRide charges the final fare of a completed ride from its hold; without a fare
+// the agreed price is captured. Fares above the hold are captured up to it. Returns nil
+// when the ride has no card payment; a captured payment is returned as is.
+func (uc *HoldUseCase) CaptureRide(ctx context.Context, rideID string, fare *domain.RideFare) (*domain.Payment, error) {
+	p, err := uc.repo.GetByRideID(ctx, rideID)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	if p.Status == domain.PaymentStatusCompleted {
+		return p, nil
+	}
+	if p.Status != domain.PaymentStatusAuthorized || p.Authorized == nil {
+		return nil, domain.ErrPaymentNotAuthorized
+	}
+	final := domain.RideFare{Price: p.Amount}
+	if fare != nil {
+		final = fare.In(p.Currency)
+	}
+	amount, short := domain.CaptureAmount(final, *p.Authorized)
+	if !amount.IsPositive() {
+		return uc.VoidRide(ctx, rideID)
+	}
+
+	gw, ok := uc.gateways.Get(p.Provider)
+	if !ok {
+		return nil, domain.ErrInvalidProvider
+	}
+	if err := gw.Capture(ctx, gateway.HoldInput{PaymentID: p.ID, ExternalID: p.ExternalID, Amount: amount}); err != nil {
+		return nil, err // the hold stays; the event can be retried
+	}
+	if short.IsPositive() {
+		slog.Warn("ride fare exceeds the hold, captured the hold", "ride_id", rideID, "payment_id", p.ID, "uncaptured", short.String())
+	}
+
+	now := uc.now()
+	p.Amount = amount
+	p.Status = domain.PaymentStatusCompleted
+	p.PaidAt = &now
+	if err := uc.repo.UpdatePayment(ctx, p, domain.CaptureEntry(p)); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// VoidRide releases the hold of a cancelled ride. Holds still waiting for 3DS are voided
+// too. Returns nil when the ride has no card payment.
+func (uc *HoldUseCase) VoidRide(ctx context.Context, rideID string) (*domain.Payment, error) {
+	p, err := uc.repo.GetByRideID(ctx, rideID)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	switch p.Status {
+	case domain.PaymentStatusCancelled:
+		return p, nil
+	case domain.PaymentStatusAuthorized, domain.PaymentStatusProcessing:
+	default:
+		return nil, domain.ErrPaymentNotAuthorized
+	}
+	if p.Authorized == nil || p.ExternalID == "" {
+		return nil, domain.ErrPaymentNotAuthorized
+	}
+
+	gw, ok := uc.gateways.Get(p.Provider)
+	if !ok {
+		return nil, domain.ErrInvalidProvider
+	}
+	if err := gw.Void(ctx, gateway.HoldInput{PaymentID: p.ID, ExternalID: p.ExternalID, Amount: *p.Authorized}); err != nil {
+		return nil, err
+	}
+	p.Status = domain.PaymentStatusCancelled
+	if err := uc.repo.UpdatePayment(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
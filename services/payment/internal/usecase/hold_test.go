@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+)
+
+// holdGateway records two-stage calls
+type holdGateway struct {
+	gateway.Gateway
+	authorized, captured money.Money
+	voided               int
+	failCapture          bool
+}
+
+func (g *holdGateway) Provider() string { return domain.ProviderTinkoff }
+
+func (g *holdGateway) Authorize(ctx context.Context, input gateway.CreatePaymentInput) (*gateway.CreatePaymentResult, error) {
+	if input.TokenID != "rebill-1" {
+		return nil, errors.New("saved card expected")
+	}
+	g.authorized = input.Amount
+	return &gateway.CreatePaymentResult{ExternalID: "ext-1", Status: domain.PaymentStatusAuthorized}, nil
+}
+
+func (g *holdGateway) Capture(ctx context.Context, input gateway.HoldInput) error {
+	if g.failCapture {
+		return gateway.ErrProviderUnavailable
+	}
+	g.captured = input.Amount
+	return nil
+}
+
+func (g *holdGateway) Void(ctx context.Context, input gateway.HoldInput) error {
+	g.voided++
+	return nil
+}
+
+func newHoldTest() (*HoldUseCase, *paymentStore, *holdGateway) {
+	repo := newPaymentStore()
+	repo.cards = []*domain.PaymentMethod{
+		{ID: "pm-default", Provider: domain.ProviderTinkoff, TokenID: "rebill-0", IsDefault: true},
+		{ID: "pm-1", Provider: domain.ProviderTinkoff, TokenID: "rebill-1"},
+	}
+	gw := &holdGateway{}
+	gateways := gateway.NewManager()
+	gateways.Register(gw)
+	return NewHoldUseCase(repo, gateways, DefaultHoldConfig()), repo, gw
+}
+
+func TestHoldUseCase_HoldAndCapture(t *testing.T) {
+	uc, repo, gw := newHoldTest()
+	ctx := context.Background()
+
+	p, err := uc.HoldRide(ctx, "r1", "p1", "pm-1", money.New(50000, ""))
+	if err != nil {
+		t.Fatalf("HoldRide: %v", err)
+	}
+	if gw.authorized.Minor != 60000 || p.Status != domain.PaymentStatusAuthorized || p.Amount.Minor != 50000 {
+		t.Fatalf("expected 600.00 held for a 500.00 ride, got %s (%+v)", gw.authorized, p)
+	}
+	if again, err := uc.HoldRide(ctx, "r1", "p1", "pm-1", money.New(50000, "")); err != nil || again != p {
+		t.Errorf("redelivered ride.matched must return the hold, got %v %v", again, err)
+	}
+
+	gw.failCapture = true
+	if _, err := uc.CaptureRide(ctx, "r1", nil); err == nil || repo.ride("r1").Status != domain.PaymentStatusAuthorized {
+		t.Fatalf("failed capture must keep the hold, got %v", err)
+	}
+	gw.failCapture = false
+	fare := &domain.RideFare{Price: money.New(50000, ""), Waiting: money.New(3000, ""), Tip: money.New(5000, "")}
+	p, err = uc.CaptureRide(ctx, "r1", fare)
+	if err != nil {
+		t.Fatalf("CaptureRide: %v", err)
+	}
+	if gw.captured.Minor != 58000 || p.Amount.Minor != 58000 || p.Status != domain.PaymentStatusCompleted || p.PaidAt == nil {
+		t.Errorf("expected 580.00 captured, got %s (%+v)", gw.captured, p)
+	}
+	if len(repo.entries) != 1 || repo.entries[0].Kind != domain.EntryPaymentCaptured || repo.entries[0].Postings[0].Amount.Minor != 58000 {
+		t.Errorf("capture must book the captured amount, got %+v", repo.entries)
+	}
+	if _, err := uc.VoidRide(ctx, "r1"); err != domain.ErrPaymentNotAuthorized {
+		t.Errorf("captured payment cannot be voided, got %v", err)
+	}
+}
+
+func TestHoldUseCase_CaptureCappedAtHold(t *testing.T) {
+	uc, _, gw := newHoldTest()
+	ctx := context.Background()
+	uc.HoldRide(ctx, "r1", "p1", "pm-1", money.New(50000, money.RUB))
+
+	p, err := uc.CaptureRide(ctx, "r1", &domain.RideFare{Price: money.New(50000, ""), Tip: money.New(20000, "")})
+	if err != nil || gw.captured.Minor != 60000 || p.Amount.Minor != 60000 {
+		t.Errorf("capture must stop at the hold: %s, %v", gw.captured, err)
+	}
+}
+
+func TestHoldUseCase_VoidOnCancel(t *testing.T) {
+	uc, repo, gw := newHoldTest()
+	ctx := context.Background()
+	uc.HoldRide(ctx, "r1", "p1", "pm-1", money.New(50000, money.RUB))
+
+	p, err := uc.VoidRide(ctx, "r1")
+	if err != nil || gw.voided != 1 || p.Status != domain.PaymentStatusCancelled {
+		t.Fatalf("VoidRide = %+v, %v (voided %d)", p, err, gw.voided)
+	}
+	if _, err := uc.VoidRide(ctx, "r1"); err != nil || gw.voided != 1 {
+		t.Errorf("repeated cancel must not void twice, got %v", err)
+	}
+	if _, err := uc.CaptureRide(ctx, "r1", nil); err != domain.ErrPaymentNotAuthorized {
+		t.Errorf("voided hold cannot be captured, got %v", err)
+	}
+
+	if p, err := uc.HoldRide(ctx, "r2", "p2", "pm-deleted", money.New(50000, money.RUB)); p != nil || err != nil || repo.ride("r2") != nil {
+		t.Errorf("without the chosen card nothing is held, got %v %v", p, err)
+	}
+	if p, err := uc.CaptureRide(ctx, "r2", nil); p != nil || err != nil {
+		t.Errorf("ride without a payment: got %v %v", p, err)
+	}
+}
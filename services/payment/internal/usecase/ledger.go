@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// LedgerRepository — reads of the double-entry ledger
+type LedgerRepository interface {
+	Balances(ctx context.Context, prefix string) ([]domain.AccountBalance, error)
+	ListEntries(ctx context.Context, f domain.LedgerFilter) ([]*domain.JournalEntry, error)
+}
+
+// LedgerUseCase — balances and audit trail of the ledger for admins
+type LedgerUseCase struct {
+	repo LedgerRepository
+}
+
+// NewLedgerUseCase creates ledger use case
+func NewLedgerUseCase(repo LedgerRepository) *LedgerUseCase {
+	return &LedgerUseCase{repo: repo}
+}
+
+// Balances returns the trial balance of accounts starting with prefix (whole ledger when empty)
+func (uc *LedgerUseCase) Balances(ctx context.Context, prefix string) (*domain.TrialBalance, error) {
+	accounts, err := uc.repo.Balances(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if accounts == nil {
+		accounts = []domain.AccountBalance{}
+	}
+	return domain.NewTrialBalance(accounts), nil
+}
+
+// Entries returns the audit trail, newest first
+func (uc *LedgerUseCase) Entries(ctx context.Context, f domain.LedgerFilter) ([]*domain.JournalEntry, error) {
+	if f.Limit <= 0 || f.Limit > 200 {
+		f.Limit = 50
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+	entries, err := uc.repo.ListEntries(ctx, f)
+	if entries == nil && err == nil {
+		entries = []*domain.JournalEntry{}
+	}
+	return entries, err
+}
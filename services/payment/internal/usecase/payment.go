@@ -4,8 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/alexevil1979/indrive/packages/money-go"
+
 	"github.com/ridehail/payment/internal/domain"
 	"github.com/ridehail/payment/internal/infra/gateway"
 	"github.com/ridehail/payment/internal/infra/pg"
@@ -16,10 +20,14 @@ type PaymentRepository interface {
 	Create(ctx context.Context, p *domain.Payment) error
 	GetByID(ctx context.Context, id string) (*domain.Payment, error)
 	GetByRideID(ctx context.Context, rideID string) (*domain.Payment, error)
+	ListByTrip(ctx context.Context, tripID string) ([]*domain.Payment, error)
 	GetByExternalID(ctx context.Context, externalID string) (*domain.Payment, error)
 	UpdateStatus(ctx context.Context, id, status, externalID string) error
-	UpdatePayment(ctx context.Context, p *domain.Payment) error
+	// UpdatePayment books entries in the same transaction
+	UpdatePayment(ctx context.Context, p *domain.Payment, entries ...*domain.JournalEntry) error
 	ListByUser(ctx context.Context, userID string, limit, offset int) ([]*domain.Payment, error)
+	// GetRideDriver returns the driver of a completed ride, "" before its completion is known
+	GetRideDriver(ctx context.Context, rideID string) (string, error)
 
 	CreatePaymentMethod(ctx context.Context, pm *domain.PaymentMethod) error
 	GetPaymentMethod(ctx context.Context, id string) (*domain.PaymentMethod, error)
@@ -27,32 +35,61 @@ type PaymentRepository interface {
 	DeletePaymentMethod(ctx context.Context, id, userID string) error
 	SetDefaultPaymentMethod(ctx context.Context, id, userID string) error
 
-	CreateRefund(ctx context.Context, paymentID string, amount float64, reason, externalID string) (string, error)
-	UpdateRefundStatus(ctx context.Context, id, status string) error
+	// CreateRefund counts the refund toward the payment's refunded total under the
+	// payment's lock (domain.Payment.ReserveRefund) and books entries in the same
+	// transaction; their reference is the new refund
+	CreateRefund(ctx context.Context, r *domain.Refund, entries ...*domain.JournalEntry) error
+	// UpdateRefund books entries in the same transaction; a failed refund is taken off
+	// the payment's refunded total
+	UpdateRefund(ctx context.Context, r *domain.Refund, entries ...*domain.JournalEntry) error
+	ListRefunds(ctx context.Context, paymentID string) ([]*domain.Refund, error)
+}
+
+// WalletReader — balance check before a wallet payment or a top-up refund
+type WalletReader interface {
+	GetWallet(ctx context.Context, userID string) (*domain.Wallet, error)
 }
 
 // PaymentUseCase — payment business logic
 type PaymentUseCase struct {
 	repo     PaymentRepository
 	gateways *gateway.Manager
+	wallets  WalletReader
+	routing  *RoutingUseCase
+	paid     func(ctx context.Context, p *domain.Payment) // optional
 }
 
-// NewPaymentUseCase creates payment use case
-func NewPaymentUseCase(repo PaymentRepository, gateways *gateway.Manager) *PaymentUseCase {
+// NewPaymentUseCase creates payment use case; without routing, card payments go to the
+// requested provider (Tinkoff by default) only. paid, when set, is called with every
+// payment that has just completed (the driver's earning of a ride paid after it ended).
+func NewPaymentUseCase(repo PaymentRepository, gateways *gateway.Manager, wallets WalletReader, routing *RoutingUseCase,
+	paid func(ctx context.Context, p *domain.Payment)) *PaymentUseCase {
 	return &PaymentUseCase{
 		repo:     repo,
 		gateways: gateways,
+		wallets:  wallets,
+		routing:  routing,
+		paid:     paid,
+	}
+}
+
+// completed passes a payment that has just completed to the paid callback
+func (uc *PaymentUseCase) completed(ctx context.Context, p *domain.Payment) {
+	if uc.paid != nil && p.Status == domain.PaymentStatusCompleted {
+		uc.paid(ctx, p)
 	}
 }
 
 // CreatePaymentInput — input for creating payment
 type CreatePaymentInput struct {
-	RideID      string
+	RideID      string // ride, or shared trip id when BookingID is set; empty for top-ups
+	BookingID   string // shared trip seat booking: one payment per passenger
+	Purpose     string // ride (default) | wallet_topup
 	UserID      string
-	Amount      float64
-	Currency    string
-	Method      string  // cash | card
-	Provider    string  // cash | tinkoff | yoomoney | sber
+	Amount      money.Money // currency defaults to RUB
+	Method      string  // cash | card | wallet
+	Provider    string  // cash | tinkoff | yoomoney | sber | wallet; card: empty lets routing choose
+	CardBrand   string  // card: visa | mastercard | mir when known, for routing rules
 	Description string
 	ReturnURL   string
 	SaveCard    bool
@@ -64,48 +101,85 @@ type CreatePaymentInput struct {
 // CreatePayment creates a new payment
 func (uc *PaymentUseCase) CreatePayment(ctx context.Context, input CreatePaymentInput) (*domain.PaymentIntent, error) {
 	// Validate
-	if input.Amount <= 0 {
+	if !input.Amount.IsPositive() {
 		return nil, domain.ErrInvalidAmount
 	}
 	if !domain.IsValidMethod(input.Method) {
 		return nil, domain.ErrInvalidMethod
 	}
 
-	// Default provider based on method
+	if input.Purpose == "" {
+		input.Purpose = domain.PurposeRide
+	}
+	// Top-ups come from a card: cash or the wallet itself would move nothing
+	if input.Purpose == domain.PurposeWalletTopUp && input.Method != domain.MethodCard {
+		return nil, domain.ErrInvalidMethod
+	}
+
+	// Currency default
+	input.Amount = input.Amount.In(money.RUB)
+
+	// Card payments are routed; the others default by method
+	var routing *domain.RoutingDecision
+	if input.Method == domain.MethodCard && (input.Provider == "" || domain.IsCardProvider(input.Provider)) {
+		routing = uc.route(ctx, &input)
+		if len(routing.Candidates) == 0 {
+			return nil, domain.ErrNoCardProvider
+		}
+		input.Provider = routing.Candidates[0]
+	}
 	if input.Provider == "" {
-		if input.Method == domain.MethodCash {
+		switch input.Method {
+		case domain.MethodCash:
 			input.Provider = domain.ProviderCash
-		} else {
-			input.Provider = domain.ProviderTinkoff // Default card provider
+		case domain.MethodWallet:
+			input.Provider = domain.ProviderWallet
 		}
 	}
 
 	if !domain.IsValidProvider(input.Provider) {
 		return nil, domain.ErrInvalidProvider
 	}
+	if (input.Method == domain.MethodWallet) != (input.Provider == domain.ProviderWallet) {
+		return nil, domain.ErrInvalidProvider
+	}
+	if input.Purpose == domain.PurposeWalletTopUp && !domain.RequiresGateway(input.Provider) {
+		return nil, domain.ErrInvalidProvider
+	}
 
-	// Currency default
-	if input.Currency == "" {
-		input.Currency = "RUB"
+	// Fail early on a short balance; the spend itself is checked under the wallet lock
+	if input.Provider == domain.ProviderWallet {
+		if err := uc.checkBalance(ctx, input.UserID, input.Amount); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create payment record
 	metadata := map[string]string{
 		"user_id": input.UserID,
-		"ride_id": input.RideID,
+		"purpose": input.Purpose,
+	}
+	if input.RideID != "" {
+		metadata["ride_id"] = input.RideID
+	}
+	if input.BookingID != "" {
+		metadata["booking_id"] = input.BookingID
 	}
 	metadataJSON, _ := json.Marshal(metadata)
 
 	p := &domain.Payment{
 		RideID:      input.RideID,
+		Purpose:     input.Purpose,
+		BookingID:   input.BookingID,
 		UserID:      input.UserID,
 		Amount:      input.Amount,
-		Currency:    input.Currency,
+		Currency:    input.Amount.Currency,
 		Method:      input.Method,
 		Provider:    input.Provider,
 		Status:      domain.PaymentStatusPending,
 		Description: input.Description,
 		Metadata:    string(metadataJSON),
+		Routing:     routing,
 	}
 
 	if err := uc.repo.Create(ctx, p); err != nil {
@@ -115,28 +189,36 @@ func (uc *PaymentUseCase) CreatePayment(ctx context.Context, input CreatePayment
 		return nil, err
 	}
 
-	// Cash payment — no gateway needed
-	if input.Provider == domain.ProviderCash {
+	// Wallet payment — debited from the balance right away
+	if input.Provider == domain.ProviderWallet {
+		now := time.Now()
+		p.Status = domain.PaymentStatusCompleted
+		p.PaidAt = &now
+		if err := uc.repo.UpdatePayment(ctx, p, domain.CaptureEntry(p)); err != nil {
+			p.Status = domain.PaymentStatusFailed
+			p.PaidAt = nil
+			p.FailReason = err.Error()
+			uc.repo.UpdatePayment(ctx, p)
+			return nil, err
+		}
+		uc.completed(ctx, p)
+	}
+
+	// Cash and wallet payments — no gateway needed
+	if !domain.RequiresGateway(input.Provider) {
 		return &domain.PaymentIntent{
 			PaymentID:   p.ID,
 			Provider:    input.Provider,
 			Amount:      input.Amount,
-			Currency:    input.Currency,
+			Currency:    input.Amount.Currency,
 			Description: input.Description,
 		}, nil
 	}
 
-	// Get gateway
-	gw, ok := uc.gateways.Get(input.Provider)
-	if !ok {
-		return nil, domain.ErrInvalidProvider
-	}
-
 	// Create payment via gateway
 	gwInput := gateway.CreatePaymentInput{
 		PaymentID:   p.ID,
 		Amount:      input.Amount,
-		Currency:    input.Currency,
 		Description: input.Description,
 		ReturnURL:   input.ReturnURL,
 		UserEmail:   input.UserEmail,
@@ -146,7 +228,7 @@ func (uc *PaymentUseCase) CreatePayment(ctx context.Context, input CreatePayment
 		TokenID:     input.TokenID,
 	}
 
-	result, err := gw.CreatePayment(ctx, gwInput)
+	result, err := uc.initiate(ctx, p, gwInput)
 	if err != nil {
 		// Mark as failed
 		p.Status = domain.PaymentStatusFailed
@@ -159,23 +241,101 @@ func (uc *PaymentUseCase) CreatePayment(ctx context.Context, input CreatePayment
 	p.ExternalID = result.ExternalID
 	p.ConfirmURL = result.ConfirmURL
 	p.Status = result.Status
-	uc.repo.UpdatePayment(ctx, p)
+	var entries []*domain.JournalEntry
+	if p.Status == domain.PaymentStatusCompleted { // saved card charged without 3DS
+		now := time.Now()
+		p.PaidAt = &now
+		entries = append(entries, domain.CaptureEntry(p))
+	}
+	if err := uc.repo.UpdatePayment(ctx, p, entries...); err == nil && len(entries) > 0 {
+		uc.completed(ctx, p)
+	}
 
 	return &domain.PaymentIntent{
 		PaymentID:   p.ID,
 		ConfirmURL:  result.ConfirmURL,
-		Provider:    input.Provider,
+		Provider:    p.Provider,
 		Amount:      input.Amount,
-		Currency:    input.Currency,
+		Currency:    input.Amount.Currency,
 		Description: input.Description,
 	}, nil
 }
 
+// route plans the card providers of a payment. A saved card brings its provider and
+// brand along.
+func (uc *PaymentUseCase) route(ctx context.Context, input *CreatePaymentInput) *domain.RoutingDecision {
+	if input.TokenID != "" {
+		methods, _ := uc.repo.ListPaymentMethods(ctx, input.UserID)
+		for _, pm := range methods {
+			if pm.TokenID == input.TokenID && (input.Provider == "" || input.Provider == pm.Provider) {
+				input.Provider, input.CardBrand = pm.Provider, pm.Brand
+				break
+			}
+		}
+	}
+	if uc.routing == nil {
+		provider := input.Provider
+		if provider == "" {
+			provider = domain.ProviderTinkoff // Default card provider
+		}
+		return &domain.RoutingDecision{Strategy: domain.RoutePriority, CardBrand: input.CardBrand, Requested: input.Provider,
+			SavedCard: input.TokenID != "", Candidates: []string{provider}}
+	}
+	if input.TokenID != "" && input.Provider == "" {
+		input.Provider = domain.ProviderTinkoff
+	}
+	return uc.routing.Plan(ctx, input.Amount, input.CardBrand, input.Provider, input.TokenID != "")
+}
+
+// initiate sends the payment to its routing candidates in turn until one takes it. Only
+// an unavailable provider passes it on: a decline is final. A provider that failed may
+// still have opened a payment form nobody will pay, which expires there. The payment
+// keeps the provider that took it, or the last one tried; when all were unavailable the
+// error is domain.ErrProviderError.
+func (uc *PaymentUseCase) initiate(ctx context.Context, p *domain.Payment, input gateway.CreatePaymentInput) (*gateway.CreatePaymentResult, error) {
+	candidates := []string{p.Provider}
+	if p.Routing != nil {
+		candidates = p.Routing.Candidates
+	}
+	err := domain.ErrInvalidProvider
+	for i, provider := range candidates {
+		gw, ok := uc.gateways.Get(provider)
+		if !ok {
+			continue
+		}
+		p.Provider = provider
+		var result *gateway.CreatePaymentResult
+		result, err = gw.CreatePayment(ctx, input)
+		if p.Routing != nil {
+			p.Routing.Record(provider, err, time.Now())
+		}
+		if err == nil {
+			return result, nil
+		}
+		if !gateway.IsProviderFailure(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		if i < len(candidates)-1 {
+			slog.Warn("card provider unavailable, failing over", "payment_id", p.ID, "provider", provider,
+				"next", candidates[i+1], "error", err)
+		}
+	}
+	if gateway.IsProviderFailure(err) && !errors.Is(err, domain.ErrInvalidProvider) {
+		return nil, fmt.Errorf("%w: %v", domain.ErrProviderError, err)
+	}
+	return nil, err
+}
+
 // GetByID returns payment by ID
 func (uc *PaymentUseCase) GetByID(ctx context.Context, id string) (*domain.Payment, error) {
 	return uc.repo.GetByID(ctx, id)
 }
 
+// ListByTrip returns per-passenger payments of a shared trip
+func (uc *PaymentUseCase) ListByTrip(ctx context.Context, tripID string) ([]*domain.Payment, error) {
+	return uc.repo.ListByTrip(ctx, tripID)
+}
+
 // GetByRideID returns payment by ride ID
 func (uc *PaymentUseCase) GetByRideID(ctx context.Context, rideID string) (*domain.Payment, error) {
 	return uc.repo.GetByRideID(ctx, rideID)
@@ -189,12 +349,26 @@ func (uc *PaymentUseCase) ListByUser(ctx context.Context, userID string, limit,
 	return uc.repo.ListByUser(ctx, userID, limit, offset)
 }
 
-// ConfirmPayment confirms cash payment (driver marks as received)
-func (uc *PaymentUseCase) ConfirmPayment(ctx context.Context, id string) (*domain.Payment, error) {
+// ConfirmPayment confirms cash payment (driver marks as received). Only cash ride
+// payments are confirmed by hand, by the ride's driver once the ride has completed, or
+// by admins and support; card, wallet and top-up payments complete at the provider.
+func (uc *PaymentUseCase) ConfirmPayment(ctx context.Context, id, userID, role string) (*domain.Payment, error) {
 	p, err := uc.repo.GetByID(ctx, id)
 	if err != nil || p == nil {
 		return nil, domain.ErrPaymentNotFound
 	}
+	if p.Purpose != domain.PurposeRide || p.Method != domain.MethodCash || p.Provider != domain.ProviderCash {
+		return nil, domain.ErrConfirmNotAllowed
+	}
+	if role != "admin" && role != "support" {
+		driverID, err := uc.repo.GetRideDriver(ctx, p.RideID)
+		if err != nil {
+			return nil, err
+		}
+		if driverID == "" || driverID != userID {
+			return nil, domain.ErrNotRideDriver
+		}
+	}
 	if p.Status == domain.PaymentStatusCompleted {
 		return p, nil
 	}
@@ -205,48 +379,32 @@ func (uc *PaymentUseCase) ConfirmPayment(ctx context.Context, id string) (*domai
 	now := time.Now()
 	p.Status = domain.PaymentStatusCompleted
 	p.PaidAt = &now
-	if err := uc.repo.UpdatePayment(ctx, p); err != nil {
+	if err := uc.repo.UpdatePayment(ctx, p, domain.CaptureEntry(p)); err != nil {
 		return nil, err
 	}
+	uc.completed(ctx, p)
 	return uc.repo.GetByID(ctx, id)
 }
 
-// ProcessWebhook handles webhook from payment provider
-func (uc *PaymentUseCase) ProcessWebhook(ctx context.Context, provider string, body []byte, signature string) error {
-	gw, ok := uc.gateways.Get(provider)
-	if !ok {
-		return domain.ErrInvalidProvider
-	}
-
-	event, err := gw.ParseWebhook(ctx, body, signature)
-	if err != nil {
-		return err
-	}
-
-	// Find payment
-	var p *domain.Payment
-	if event.PaymentID != "" {
-		p, _ = uc.repo.GetByID(ctx, event.PaymentID)
-	}
-	if p == nil && event.ExternalID != "" {
-		p, _ = uc.repo.GetByExternalID(ctx, event.ExternalID)
-	}
-	if p == nil {
-		return domain.ErrPaymentNotFound
-	}
-
-	// Update payment based on event
-	switch event.EventType {
+// applyEvent moves p by a provider event (webhook, or a status found by the reconciler);
+// an event the payment already reflects has no effect, so redeliveries are harmless
+func (uc *PaymentUseCase) applyEvent(ctx context.Context, gw gateway.Gateway, p *domain.Payment, eventType, externalID string) error {
+	var entries []*domain.JournalEntry
+	switch eventType {
 	case "payment.succeeded":
+		if domain.IsPaid(p.Status) {
+			return nil
+		}
+		entries = append(entries, domain.CaptureEntry(p))
 		now := time.Now()
 		p.Status = domain.PaymentStatusCompleted
 		p.PaidAt = &now
 
 		// Try to save card if available
-		if cardInfo, err := gw.GetSavedCard(ctx, event.ExternalID); err == nil && cardInfo != nil {
+		if cardInfo, err := gw.GetSavedCard(ctx, externalID); err == nil && cardInfo != nil {
 			pm := &domain.PaymentMethod{
 				UserID:      p.UserID,
-				Provider:    provider,
+				Provider:    gw.Provider(),
 				Type:        "card",
 				Last4:       cardInfo.Last4,
 				Brand:       cardInfo.Brand,
@@ -257,42 +415,100 @@ func (uc *PaymentUseCase) ProcessWebhook(ctx context.Context, provider string, b
 			uc.repo.CreatePaymentMethod(ctx, pm)
 		}
 
-	case "payment.failed":
-		p.Status = domain.PaymentStatusFailed
-
-	case "payment.cancelled":
-		p.Status = domain.PaymentStatusCancelled
-
-	case "refund.succeeded":
+	case "payment.authorized":
+		// Two-stage hold confirmed (e.g. after 3DS); one-stage payments go on to succeeded
+		if p.Status != domain.PaymentStatusPending && p.Status != domain.PaymentStatusProcessing {
+			return nil
+		}
 		now := time.Now()
-		p.Status = domain.PaymentStatusRefunded
-		p.RefundedAt = &now
+		p.Status = domain.PaymentStatusAuthorized
+		p.AuthorizedAt = &now
+		if p.Authorized == nil {
+			held := p.Amount
+			p.Authorized = &held
+		}
+
+	case "payment.failed", "payment.cancelled":
+		// A late or replayed failure must not undo a payment that went through
+		if domain.IsPaid(p.Status) {
+			return nil
+		}
+		p.Status = domain.PaymentStatusFailed
+		if eventType == "payment.cancelled" {
+			p.Status = domain.PaymentStatusCancelled
+		}
 	}
 
-	return uc.repo.UpdatePayment(ctx, p)
+	if err := uc.repo.UpdatePayment(ctx, p, entries...); err != nil {
+		return err
+	}
+	if eventType == "payment.succeeded" {
+		uc.completed(ctx, p)
+	}
+	return nil
 }
 
-// RefundPayment processes a refund
+// RefundPayment refunds a paid payment, in full or in part. The refund is counted
+// toward the payment's refunded total before the provider is asked, so refunds never
+// add up to more than the amount; zero refunds what is left. Staff cannot refund their
+// own payments.
 func (uc *PaymentUseCase) RefundPayment(ctx context.Context, req domain.RefundRequest) (*domain.RefundResult, error) {
 	p, err := uc.repo.GetByID(ctx, req.PaymentID)
 	if err != nil || p == nil {
 		return nil, domain.ErrPaymentNotFound
 	}
+	if req.RequestedBy != "" && req.RequestedBy == p.UserID {
+		return nil, domain.ErrOwnPaymentRefund
+	}
 
-	// Can only refund completed payments
-	if p.Status != domain.PaymentStatusCompleted {
+	// Only paid payments with something left to refund
+	if !domain.IsRefundable(p.Status) {
 		return nil, domain.ErrRefundNotAllowed
 	}
 
-	// Cash payments cannot be refunded via gateway
-	if p.Provider == domain.ProviderCash {
+	// Wallet payments always go back to the wallet; top-ups only to their card
+	toWallet := req.ToWallet || p.Provider == domain.ProviderWallet
+	if toWallet && p.Purpose == domain.PurposeWalletTopUp {
+		return nil, domain.ErrRefundNotAllowed
+	}
+
+	// Cash never reached the platform: only compensation to the wallet
+	if p.Provider == domain.ProviderCash && !(toWallet && req.Compensation) {
 		return nil, domain.ErrRefundNotAllowed
 	}
 
 	// Refund amount
-	amount := req.Amount
-	if amount <= 0 {
-		amount = p.Amount // Full refund
+	amount := req.Amount.In(p.Currency)
+	if amount.IsZero() {
+		amount = p.Refundable() // the rest
+	}
+	if !amount.IsPositive() || !amount.SameCurrency(p.Amount) {
+		return nil, domain.ErrInvalidAmount
+	}
+	if amount.Cmp(p.Refundable()) > 0 {
+		return nil, domain.ErrRefundExceedsBalance
+	}
+
+	r := &domain.Refund{PaymentID: p.ID, Amount: amount, Currency: amount.Currency, Reason: req.Reason,
+		ToWallet: toWallet, Status: domain.RefundStatusPending}
+	if toWallet {
+		// No gateway: the refund is booked onto the wallet
+		entry := domain.RefundToWalletEntry(p, amount)
+		if p.Provider == domain.ProviderWallet {
+			entry = domain.RefundEntry(p, amount)
+		}
+		r.Settle(domain.RefundStatusSucceeded, time.Now())
+		if err := uc.repo.CreateRefund(ctx, r, entry); err != nil {
+			return nil, err
+		}
+		return refundResult(r), nil
+	}
+
+	// A refunded top-up leaves the wallet: the money must still be there
+	if p.Purpose == domain.PurposeWalletTopUp {
+		if err := uc.checkBalance(ctx, p.UserID, amount); err != nil {
+			return nil, err
+		}
 	}
 
 	// Get gateway
@@ -301,43 +517,106 @@ func (uc *PaymentUseCase) RefundPayment(ctx context.Context, req domain.RefundRe
 		return nil, domain.ErrInvalidProvider
 	}
 
-	// Process refund
-	refundInput := gateway.RefundInput{
+	// Reserve, then process the refund
+	if err := uc.repo.CreateRefund(ctx, r); err != nil {
+		return nil, err
+	}
+	result, err := gw.Refund(ctx, gateway.RefundInput{
 		PaymentID:  p.ID,
+		RefundID:   r.ID,
 		ExternalID: p.ExternalID,
 		Amount:     amount,
 		Reason:     req.Reason,
-	}
-
-	result, err := gw.Refund(ctx, refundInput)
+	})
 	if err != nil {
+		// Refused, or never sent: the amount is refundable again. Any other failure
+		// (a timeout) may have refunded at the provider, so the refund stays pending
+		// until the provider's refund webhook settles it.
+		if !gateway.IsProviderFailure(err) || errors.Is(err, gateway.ErrCircuitOpen) {
+			r.Settle(domain.RefundStatusFailed, time.Now())
+			if uerr := uc.repo.UpdateRefund(ctx, r); uerr != nil {
+				slog.Warn("release failed refund", "refund_id", r.ID, "payment_id", p.ID, "error", uerr)
+			}
+		} else {
+			slog.Warn("refund outcome unknown, left pending", "refund_id", r.ID, "payment_id", p.ID, "error", err)
+		}
 		return nil, err
 	}
 
-	// Create refund record
-	refundID, err := uc.repo.CreateRefund(ctx, p.ID, amount, req.Reason, result.RefundID)
-	if err != nil {
+	// Accepted by the provider: booked, even while it settles
+	r.ExternalID = result.RefundID
+	r.Settle(result.Status, time.Now())
+	if err := uc.repo.UpdateRefund(ctx, r, domain.RefundEntry(p, amount)); err != nil {
 		return nil, err
 	}
+	return refundResult(r), nil
+}
 
-	// Update refund status
-	uc.repo.UpdateRefundStatus(ctx, refundID, result.Status)
+func refundResult(r *domain.Refund) *domain.RefundResult {
+	return &domain.RefundResult{
+		RefundID:   r.ID,
+		PaymentID:  r.PaymentID,
+		Amount:     r.Amount,
+		Status:     r.Status,
+		RefundedAt: r.CreatedAt,
+	}
+}
 
-	// Update payment if fully refunded
-	if amount >= p.Amount {
-		now := time.Now()
-		p.Status = domain.PaymentStatusRefunded
-		p.RefundedAt = &now
-		uc.repo.UpdatePayment(ctx, p)
+// ListRefunds returns the refunds of a payment, oldest first
+func (uc *PaymentUseCase) ListRefunds(ctx context.Context, paymentID string) ([]*domain.Refund, error) {
+	p, err := uc.repo.GetByID(ctx, paymentID)
+	if err != nil || p == nil {
+		return nil, domain.ErrPaymentNotFound
 	}
+	refunds, err := uc.repo.ListRefunds(ctx, p.ID)
+	if refunds == nil && err == nil {
+		refunds = []*domain.Refund{}
+	}
+	return refunds, err
+}
 
-	return &domain.RefundResult{
-		RefundID:   refundID,
-		PaymentID:  p.ID,
-		Amount:     amount,
-		Status:     result.Status,
-		RefundedAt: time.Now(),
-	}, nil
+// applyRefund settles the refund a provider's refund event is about. A refund made at
+// the provider (e.g. in its dashboard) is recorded as a new refund, unless nothing is
+// left to refund here.
+func (uc *PaymentUseCase) applyRefund(ctx context.Context, p *domain.Payment, refundID string, amount money.Money) error {
+	refunds, err := uc.repo.ListRefunds(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	r := domain.MatchRefund(refunds, refundID, amount)
+	if r == nil {
+		r = &domain.Refund{PaymentID: p.ID, Amount: amount, Currency: amount.Currency, Reason: "refunded at the provider",
+			ExternalID: refundID}
+		r.Settle(domain.RefundStatusSucceeded, now)
+		err := uc.repo.CreateRefund(ctx, r, domain.RefundEntry(p, amount))
+		if errors.Is(err, domain.ErrRefundExceedsBalance) || errors.Is(err, domain.ErrRefundNotAllowed) || errors.Is(err, domain.ErrInvalidAmount) {
+			slog.Warn("provider refund beyond the refundable balance", "payment_id", p.ID, "refund_id", refundID,
+				"amount", amount.String(), "error", err)
+			return nil
+		}
+		return err
+	}
+	if r.Status == domain.RefundStatusSucceeded {
+		return nil
+	}
+	if r.ExternalID == "" {
+		r.ExternalID = refundID
+	}
+	r.Settle(domain.RefundStatusSucceeded, now)
+	return uc.repo.UpdateRefund(ctx, r, domain.RefundEntry(p, r.Amount))
+}
+
+// checkBalance returns domain.ErrInsufficientFunds when the user's wallet holds less than amount
+func (uc *PaymentUseCase) checkBalance(ctx context.Context, userID string, amount money.Money) error {
+	w, err := uc.wallets.GetWallet(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if w == nil || w.Balance.Currency != amount.Currency || w.Balance.Cmp(amount) < 0 {
+		return domain.ErrInsufficientFunds
+	}
+	return nil
 }
 
 // --- Payment Methods ---
@@ -359,7 +638,7 @@ func (uc *PaymentUseCase) SetDefaultPaymentMethod(ctx context.Context, id, userI
 
 // GetAvailableProviders returns list of available payment providers
 func (uc *PaymentUseCase) GetAvailableProviders() []string {
-	providers := []string{domain.ProviderCash}
+	providers := []string{domain.ProviderCash, domain.ProviderWallet}
 	providers = append(providers, uc.gateways.Available()...)
 	return providers
 }
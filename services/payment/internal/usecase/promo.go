@@ -6,6 +6,8 @@ import (
 	"errors"
 	"strings"
 
+	"github.com/alexevil1979/indrive/packages/money-go"
+
 	"github.com/ridehail/payment/internal/domain"
 )
 
@@ -18,7 +20,8 @@ type PromoRepo interface {
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, limit, offset int, activeOnly bool) ([]domain.Promo, int, error)
 	GetUserPromoCount(ctx context.Context, userID, promoID string) (int, error)
-	RecordUsage(ctx context.Context, usage *domain.UserPromo) error
+	// RecordUsage books entries in the same transaction; their reference is the new usage
+	RecordUsage(ctx context.Context, usage *domain.UserPromo, entries ...*domain.JournalEntry) error
 	GetUserPromos(ctx context.Context, userID string, limit int) ([]domain.UserPromo, error)
 	CheckPromoUsedForRide(ctx context.Context, rideID string) (bool, error)
 }
@@ -34,7 +37,7 @@ func NewPromoUseCase(repo PromoRepo) *PromoUseCase {
 }
 
 // ValidatePromo checks if a promo code is valid for a user and order
-func (uc *PromoUseCase) ValidatePromo(ctx context.Context, code, userID string, orderAmount float64) (*domain.PromoResult, error) {
+func (uc *PromoUseCase) ValidatePromo(ctx context.Context, code, userID string, order money.Money) (*domain.PromoResult, error) {
 	code = strings.TrimSpace(strings.ToUpper(code))
 	if code == "" {
 		return &domain.PromoResult{Valid: false, Error: "Промокод не указан"}, nil
@@ -53,8 +56,12 @@ func (uc *PromoUseCase) ValidatePromo(ctx context.Context, code, userID string,
 		return &domain.PromoResult{Valid: false, Error: "Промокод недействителен или истёк"}, nil
 	}
 
+	if order.Currency != promo.Currency {
+		return &domain.PromoResult{Valid: false, Error: "Промокод недоступен для этой валюты"}, nil
+	}
+
 	// Check minimum order value
-	if orderAmount < promo.MinOrderValue {
+	if order.Cmp(promo.MinOrderValue) < 0 {
 		return &domain.PromoResult{
 			Valid: false,
 			Error: "Минимальная сумма заказа для этого промокода: " + formatPrice(promo.MinOrderValue),
@@ -73,8 +80,8 @@ func (uc *PromoUseCase) ValidatePromo(ctx context.Context, code, userID string,
 	}
 
 	// Calculate discount
-	discount := promo.CalculateDiscount(orderAmount)
-	finalPrice := orderAmount - discount
+	discount := promo.CalculateDiscount(order)
+	finalPrice := order.Sub(discount)
 
 	return &domain.PromoResult{
 		Valid:      true,
@@ -85,9 +92,9 @@ func (uc *PromoUseCase) ValidatePromo(ctx context.Context, code, userID string,
 }
 
 // ApplyPromo validates and applies a promo code to a ride
-func (uc *PromoUseCase) ApplyPromo(ctx context.Context, code, userID, rideID string, orderAmount float64) (*domain.PromoResult, error) {
+func (uc *PromoUseCase) ApplyPromo(ctx context.Context, code, userID, rideID string, order money.Money) (*domain.PromoResult, error) {
 	// First validate
-	result, err := uc.ValidatePromo(ctx, code, userID, orderAmount)
+	result, err := uc.ValidatePromo(ctx, code, userID, order)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +122,11 @@ func (uc *PromoUseCase) ApplyPromo(ctx context.Context, code, userID, rideID str
 		Discount: result.Discount,
 	}
 
-	if err := uc.repo.RecordUsage(ctx, usage); err != nil {
+	var entries []*domain.JournalEntry
+	if usage.Discount.IsPositive() {
+		entries = append(entries, domain.PromoDiscountEntry(userID, usage.Discount))
+	}
+	if err := uc.repo.RecordUsage(ctx, usage, entries...); err != nil {
 		return nil, err
 	}
 
@@ -128,14 +139,8 @@ func (uc *PromoUseCase) CreatePromo(ctx context.Context, promo *domain.Promo) er
 	if promo.Code == "" {
 		return errors.New("code is required")
 	}
-	if promo.Value <= 0 {
-		return errors.New("value must be positive")
-	}
-	if promo.Type != domain.PromoTypePercent && promo.Type != domain.PromoTypeFixed {
-		return errors.New("type must be 'percent' or 'fixed'")
-	}
-	if promo.Type == domain.PromoTypePercent && promo.Value > 100 {
-		return errors.New("percent discount cannot exceed 100")
+	if err := validatePromo(promo); err != nil {
+		return err
 	}
 
 	return uc.repo.Create(ctx, promo)
@@ -143,6 +148,9 @@ func (uc *PromoUseCase) CreatePromo(ctx context.Context, promo *domain.Promo) er
 
 // UpdatePromo updates a promo code (admin)
 func (uc *PromoUseCase) UpdatePromo(ctx context.Context, promo *domain.Promo) error {
+	if err := validatePromo(promo); err != nil {
+		return err
+	}
 	return uc.repo.Update(ctx, promo)
 }
 
@@ -175,10 +183,44 @@ func (uc *PromoUseCase) GetUserPromos(ctx context.Context, userID string, limit
 	return uc.repo.GetUserPromos(ctx, userID, limit)
 }
 
-// helper
-func formatPrice(amount float64) string {
-	if amount == float64(int(amount)) {
-		return string(rune(int(amount))) + " ₽"
+// validatePromo checks the discount of the promo's type and puts all amounts in its currency
+func validatePromo(promo *domain.Promo) error {
+	if promo.Currency == "" {
+		promo.Currency = money.RUB
+	}
+	switch promo.Type {
+	case domain.PromoTypePercent:
+		if promo.Percent <= 0 {
+			return errors.New("value must be positive")
+		}
+		if promo.Percent > money.Percent100 {
+			return errors.New("percent discount cannot exceed 100")
+		}
+		promo.AmountOff = money.New(0, promo.Currency)
+	case domain.PromoTypeFixed:
+		if !promo.AmountOff.IsPositive() {
+			return errors.New("value must be positive")
+		}
+		promo.Percent = 0
+	default:
+		return errors.New("type must be 'percent' or 'fixed'")
+	}
+	if promo.MinOrderValue.IsNegative() || promo.MaxDiscount.IsNegative() {
+		return errors.New("amounts cannot be negative")
+	}
+	for _, m := range []*money.Money{&promo.AmountOff, &promo.MinOrderValue, &promo.MaxDiscount} {
+		if !m.SameCurrency(money.New(0, promo.Currency)) {
+			return errors.New("amounts must be in the promo currency")
+		}
+		*m = m.In(promo.Currency)
+	}
+	return nil
+}
+
+// formatPrice — "300.00 ₽" for rubles, "300.00 USD" otherwise
+func formatPrice(m money.Money) string {
+	if m.Currency == money.RUB {
+		return m.Decimal() + " ₽"
 	}
-	return string(rune(int(amount*100)/100)) + " ₽"
+	return m.String()
 }
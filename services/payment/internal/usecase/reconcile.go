@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// ReconcileRepository — payments waiting for their provider and recorded mismatches
+type ReconcileRepository interface {
+	ListInFlight(ctx context.Context, before time.Time, limit int) ([]*domain.Payment, error)
+	MarkReconciled(ctx context.Context, id string) error
+	RecordMismatch(ctx context.Context, m *domain.PaymentMismatch) error
+	ListMismatches(ctx context.Context, outcome string, limit, offset int) ([]*domain.PaymentMismatch, error)
+}
+
+// ReconcileConfig — how often and how patiently stuck payments are polled
+type ReconcileConfig struct {
+	Interval    time.Duration // between passes
+	StuckAfter  time.Duration // in flight this long without a webhook: ask the provider
+	ExpireAfter time.Duration // not confirmed this long after creation: cancel the intent
+	BatchSize   int           // payments polled per pass
+}
+
+// DefaultReconcileConfig — every 5 minutes, payments stuck for 15 minutes, intents expire after a day
+func DefaultReconcileConfig() ReconcileConfig {
+	return ReconcileConfig{
+		Interval:    5 * time.Minute,
+		StuckAfter:  15 * time.Minute,
+		ExpireAfter: 24 * time.Hour,
+		BatchSize:   100,
+	}
+}
+
+// ReconcileObserver receives the outcome of every polled payment that was not in step (metrics)
+type ReconcileObserver func(provider, outcome string)
+
+// ReconcileUseCase — finds payments whose webhook was lost: polls the provider and
+// applies its status the way its webhook would, and cancels abandoned intents
+type ReconcileUseCase struct {
+	repo     ReconcileRepository
+	payments *PaymentUseCase
+	cfg      ReconcileConfig
+	observe  ReconcileObserver
+}
+
+// NewReconcileUseCase creates reconcile use case; observe may be nil
+func NewReconcileUseCase(repo ReconcileRepository, payments *PaymentUseCase, cfg ReconcileConfig, observe ReconcileObserver) *ReconcileUseCase {
+	if observe == nil {
+		observe = func(provider, outcome string) {}
+	}
+	return &ReconcileUseCase{repo: repo, payments: payments, cfg: cfg, observe: observe}
+}
+
+// Interval — time between passes of the background reconciler
+func (uc *ReconcileUseCase) Interval() time.Duration {
+	return uc.cfg.Interval
+}
+
+// Run polls one batch of stuck payments
+func (uc *ReconcileUseCase) Run(ctx context.Context) (*domain.ReconcileRun, error) {
+	now := time.Now()
+	stuck, err := uc.repo.ListInFlight(ctx, now.Add(-uc.cfg.StuckAfter), uc.cfg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	run := &domain.ReconcileRun{}
+	for _, p := range stuck {
+		if ctx.Err() != nil {
+			break
+		}
+		run.Checked++
+		outcome := uc.reconcile(ctx, p, now)
+		if outcome == "" {
+			continue
+		}
+		run.Add(outcome)
+		uc.observe(p.Provider, outcome)
+	}
+	return run, nil
+}
+
+// reconcile brings one payment in step with its provider; the outcome is empty when it
+// already was (still in flight on both sides)
+func (uc *ReconcileUseCase) reconcile(ctx context.Context, p *domain.Payment, now time.Time) string {
+	if err := uc.repo.MarkReconciled(ctx, p.ID); err != nil {
+		slog.Warn("mark payment reconciled failed", "payment_id", p.ID, "error", err)
+		return domain.ReconcileFailed
+	}
+
+	gw, ok := uc.payments.gateways.Get(p.Provider)
+	if !ok {
+		slog.Warn("payment provider not registered", "payment_id", p.ID, "provider", p.Provider)
+		return domain.ReconcileFailed
+	}
+
+	// Without an external ID the gateway never accepted the payment: nothing to ask
+	status := ""
+	if p.ExternalID != "" {
+		var err error
+		status, err = gw.GetPaymentStatus(ctx, p.ExternalID)
+		if err != nil {
+			slog.Warn("payment status poll failed", "payment_id", p.ID, "provider", p.Provider, "error", err)
+			return domain.ReconcileFailed
+		}
+	}
+
+	mismatch := &domain.PaymentMismatch{PaymentID: p.ID, Provider: p.Provider, LocalStatus: p.Status, ProviderStatus: status}
+	switch event, applicable := domain.StatusEvent(status); {
+	case applicable:
+		if err := uc.payments.applyEvent(ctx, gw, p, event, p.ExternalID); err != nil {
+			slog.Warn("apply provider status failed", "payment_id", p.ID, "status", status, "error", err)
+			return domain.ReconcileFailed
+		}
+		mismatch.Outcome = domain.ReconcileApplied
+	case status != "" && !domain.IsInFlight(status):
+		mismatch.Outcome = domain.ReconcileUnresolved
+	case now.Sub(p.CreatedAt) >= uc.cfg.ExpireAfter:
+		p.Status = domain.PaymentStatusCancelled
+		p.FailReason = domain.FailReasonExpired
+		if err := uc.payments.repo.UpdatePayment(ctx, p); err != nil {
+			slog.Warn("expire payment failed", "payment_id", p.ID, "error", err)
+			return domain.ReconcileFailed
+		}
+		mismatch.Outcome = domain.ReconcileExpired
+	default:
+		return ""
+	}
+
+	if err := uc.repo.RecordMismatch(ctx, mismatch); err != nil {
+		slog.Warn("record payment mismatch failed", "payment_id", p.ID, "error", err)
+	}
+	return mismatch.Outcome
+}
+
+// Mismatches returns recorded mismatches, newest first
+func (uc *ReconcileUseCase) Mismatches(ctx context.Context, outcome string, limit, offset int) ([]*domain.PaymentMismatch, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	mismatches, err := uc.repo.ListMismatches(ctx, outcome, limit, offset)
+	if mismatches == nil && err == nil {
+		mismatches = []*domain.PaymentMismatch{}
+	}
+	return mismatches, err
+}
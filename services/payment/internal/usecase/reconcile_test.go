@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+)
+
+// statusGateway answers GetPaymentStatus from a map keyed by external ID
+type statusGateway struct {
+	gateway.Gateway
+	statuses map[string]string
+}
+
+func (g *statusGateway) Provider() string { return domain.ProviderYooMoney }
+
+func (g *statusGateway) GetPaymentStatus(ctx context.Context, externalID string) (string, error) {
+	status, ok := g.statuses[externalID]
+	if !ok {
+		return "", gateway.ErrProviderUnavailable
+	}
+	return status, nil
+}
+
+func (g *statusGateway) GetSavedCard(ctx context.Context, externalID string) (*gateway.CardInfo, error) {
+	return nil, errors.New("no card")
+}
+
+func TestReconcileUseCase_Run(t *testing.T) {
+	now := time.Now()
+	stuck := func(id, externalID string, age time.Duration) *domain.Payment {
+		return &domain.Payment{ID: id, UserID: "u1", Provider: domain.ProviderYooMoney, Status: domain.PaymentStatusPending,
+			ExternalID: externalID, Amount: money.New(50000, money.RUB), Currency: money.RUB, CreatedAt: now.Add(-age)}
+	}
+	inFlight := []*domain.Payment{
+		stuck("paid", "ext-paid", time.Hour),
+		stuck("waiting", "ext-waiting", time.Hour),
+		stuck("abandoned", "ext-abandoned", 48*time.Hour),
+		stuck("never-sent", "", 48*time.Hour),
+		stuck("refunded", "ext-refunded", time.Hour),
+		stuck("unreachable", "ext-down", 48*time.Hour),
+		stuck("fresh", "ext-fresh", time.Minute),
+	}
+	repo := newPaymentStore(inFlight...)
+	gw := &statusGateway{statuses: map[string]string{
+		"ext-paid":      domain.PaymentStatusCompleted,
+		"ext-waiting":   domain.PaymentStatusProcessing,
+		"ext-abandoned": domain.PaymentStatusPending,
+		"ext-refunded":  domain.PaymentStatusRefunded,
+		"ext-fresh":     domain.PaymentStatusCompleted,
+	}}
+	gateways := gateway.NewManager()
+	gateways.Register(gw)
+	observed := map[string]int{}
+	uc := NewReconcileUseCase(repo, NewPaymentUseCase(repo, gateways, nil, nil, nil), DefaultReconcileConfig(),
+		func(provider, outcome string) { observed[provider+"/"+outcome]++ })
+
+	run, err := uc.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	want := domain.ReconcileRun{Checked: 6, Applied: 1, Expired: 2, Unresolved: 1, Failed: 1}
+	if *run != want {
+		t.Errorf("run: got %+v, want %+v", *run, want)
+	}
+	if observed["yoomoney/expired"] != 2 || observed["yoomoney/failed"] != 1 {
+		t.Errorf("outcomes must be observed per provider: %v", observed)
+	}
+	if repo.checked["fresh"] {
+		t.Error("payments in flight for less than StuckAfter are left to their webhook")
+	}
+
+	paid := inFlight[0]
+	if paid.Status != domain.PaymentStatusCompleted || paid.PaidAt == nil ||
+		len(repo.entries) != 1 || repo.entries[0].Kind != domain.EntryPaymentCaptured {
+		t.Errorf("a lost payment.succeeded must complete and book the payment: %+v %+v", paid, repo.entries)
+	}
+	if p := inFlight[1]; p.Status != domain.PaymentStatusPending {
+		t.Errorf("a payment still processing at the provider is left alone: %+v", p)
+	}
+	for _, p := range inFlight[2:4] {
+		if p.Status != domain.PaymentStatusCancelled || p.FailReason != domain.FailReasonExpired {
+			t.Errorf("abandoned intent must expire: %+v", p)
+		}
+	}
+	if p := inFlight[5]; p.Status != domain.PaymentStatusPending {
+		t.Errorf("a payment the provider cannot be asked about must not expire: %+v", p)
+	}
+	if len(repo.mismatches) != 4 || repo.mismatches[2].Outcome != domain.ReconcileExpired || repo.mismatches[2].ProviderStatus != "" {
+		t.Errorf("mismatches: %+v", repo.mismatches)
+	}
+}
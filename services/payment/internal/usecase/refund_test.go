@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+)
+
+// refundGateway answers refunds with the queued errors, then with status
+type refundGateway struct {
+	callbackGateway
+	errs   []error
+	status string
+	inputs []gateway.RefundInput
+}
+
+func (g *refundGateway) Refund(ctx context.Context, input gateway.RefundInput) (*gateway.RefundResult, error) {
+	g.inputs = append(g.inputs, input)
+	if len(g.errs) > 0 {
+		err := g.errs[0]
+		g.errs = g.errs[1:]
+		return nil, err
+	}
+	return &gateway.RefundResult{RefundID: "re-" + input.RefundID, ExternalID: input.ExternalID, Amount: input.Amount,
+		Status: g.status}, nil
+}
+
+func TestPaymentUseCase_PartialRefunds(t *testing.T) {
+	ctx := context.Background()
+	book := newPaymentStore()
+	book.add(&domain.Payment{ID: "pay-card", RideID: "r1", Purpose: domain.PurposeRide, UserID: "u1",
+		Method: domain.MethodCard, Provider: domain.ProviderYooMoney, Status: domain.PaymentStatusCompleted,
+		ExternalID: "ext-1", Amount: money.New(50000, money.RUB), Currency: money.RUB})
+	gw := &refundGateway{status: domain.RefundStatusSucceeded}
+	gateways := gateway.NewManager()
+	gateways.Register(gw)
+	payments := NewPaymentUseCase(book, gateways, book, nil, nil)
+	refund := func(amount int64) (*domain.RefundResult, error) {
+		return payments.RefundPayment(ctx, domain.RefundRequest{PaymentID: "pay-card", Amount: money.New(amount, "")})
+	}
+	payment := func() *domain.Payment { return book.payments["pay-card"] }
+
+	if _, err := payments.RefundPayment(ctx, domain.RefundRequest{PaymentID: "pay-card", RequestedBy: "u1"}); !errors.Is(err, domain.ErrOwnPaymentRefund) {
+		t.Errorf("staff refunding their own payment: %v", err)
+	}
+
+	res, err := refund(20000)
+	if err != nil || res.Status != domain.RefundStatusSucceeded || res.RefundID != "refund-1" {
+		t.Fatalf("first refund: %+v, %v", res, err)
+	}
+	if p := payment(); p.Status != domain.PaymentStatusPartiallyRefunded || p.Refunded.Minor != 20000 || p.RefundedAt != nil {
+		t.Errorf("after a partial refund: %+v", p)
+	}
+	if gw.inputs[0].RefundID != "refund-1" || book.refunds[0].ExternalID != "re-refund-1" || !book.booked[domain.EntryPaymentRefunded+"/refund-1"] {
+		t.Errorf("the refund must be sent under its own ID and booked: %+v %+v", gw.inputs[0], book.refunds[0])
+	}
+
+	if _, err := refund(30001); !errors.Is(err, domain.ErrRefundExceedsBalance) || len(gw.inputs) != 1 {
+		t.Errorf("refunds beyond the amount in total: %v", err)
+	}
+
+	// Refused: the amount is refundable again
+	gw.errs = []error{gateway.ErrPaymentRejected}
+	if _, err := refund(10000); !errors.Is(err, gateway.ErrPaymentRejected) {
+		t.Errorf("refused refund: %v", err)
+	}
+	if r := book.refunds[1]; r.Status != domain.RefundStatusFailed || r.ProcessedAt == nil || payment().Refunded.Minor != 20000 {
+		t.Errorf("refused refund must fail and be released: %+v, refunded %s", r, payment().Refunded)
+	}
+
+	// Timed out: it may have been made, so the amount stays reserved
+	timeout := errors.New("send request: context deadline exceeded")
+	gw.errs = []error{timeout}
+	if _, err := refund(10000); !errors.Is(err, timeout) {
+		t.Errorf("timed out refund: %v", err)
+	}
+	if r := book.refunds[2]; r.Status != domain.RefundStatusPending || payment().Refunded.Minor != 30000 ||
+		book.booked[domain.EntryPaymentRefunded+"/refund-3"] {
+		t.Errorf("refund of unknown outcome must stay pending and unbooked: %+v, refunded %s", r, payment().Refunded)
+	}
+
+	// Refund webhooks settle the refund they are about, or record a refund made at the provider
+	webhooks := NewWebhookUseCase(book, payments, DefaultWebhookConfig())
+	for _, body := range []string{
+		`{"event_id":"refund.succeeded:1","event_type":"refund.succeeded","payment_id":"pay-card","external_id":"ext-1","amount":100}`,
+		`{"event_id":"refund.succeeded:2","event_type":"refund.succeeded","payment_id":"pay-card","external_id":"ext-1","refund_id":"re-dash","amount":50}`,
+		`{"event_id":"refund.succeeded:3","event_type":"refund.succeeded","payment_id":"pay-card","external_id":"ext-1","refund_id":"re-refund-1","amount":200}`,
+	} {
+		if err := webhooks.Receive(ctx, domain.ProviderYooMoney, nil, []byte(body), "valid"); err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+	}
+	if run, err := webhooks.Run(ctx); err != nil || *run != (domain.WebhookRun{Processed: 3}) {
+		t.Fatalf("Run: %+v, %v", run, err)
+	}
+	if r := book.refunds[2]; r.Status != domain.RefundStatusSucceeded || !book.booked[domain.EntryPaymentRefunded+"/refund-3"] {
+		t.Errorf("the timed out refund must be settled by its webhook: %+v", r)
+	}
+	if len(book.refunds) != 4 || book.refunds[3].ExternalID != "re-dash" || book.refunds[3].Amount.Minor != 5000 ||
+		payment().Refunded.Minor != 35000 {
+		t.Errorf("a refund made at the provider must be recorded: %d refunds, refunded %s", len(book.refunds), payment().Refunded)
+	}
+
+	// Zero refunds the rest
+	if res, err := refund(0); err != nil || res.Amount.Minor != 15000 {
+		t.Fatalf("refund of the rest: %+v, %v", res, err)
+	}
+	if p := payment(); p.Status != domain.PaymentStatusRefunded || p.RefundedAt == nil || !p.Refundable().IsZero() {
+		t.Errorf("fully refunded: %+v", p)
+	}
+	if _, err := refund(100); !errors.Is(err, domain.ErrRefundNotAllowed) {
+		t.Errorf("refund of a refunded payment: %v", err)
+	}
+
+	refunds, err := payments.ListRefunds(ctx, "pay-card")
+	if err != nil || len(refunds) != 5 {
+		t.Errorf("ListRefunds: %d, %v", len(refunds), err)
+	}
+	if _, err := payments.ListRefunds(ctx, "missing"); !errors.Is(err, domain.ErrPaymentNotFound) {
+		t.Errorf("refunds of a missing payment: %v", err)
+	}
+}
+
+func TestPaymentUseCase_CashRefundIsCompensation(t *testing.T) {
+	ctx := context.Background()
+	book := newPaymentStore()
+	book.add(&domain.Payment{ID: "pay-cash", RideID: "r1", Purpose: domain.PurposeRide, UserID: "u1",
+		Method: domain.MethodCash, Provider: domain.ProviderCash, Status: domain.PaymentStatusCompleted,
+		Amount: money.New(50000, money.RUB), Currency: money.RUB})
+	payments := NewPaymentUseCase(book, gateway.NewManager(), book, nil, nil)
+
+	for _, req := range []domain.RefundRequest{
+		{PaymentID: "pay-cash"},
+		{PaymentID: "pay-cash", ToWallet: true},
+		{PaymentID: "pay-cash", Compensation: true},
+	} {
+		if _, err := payments.RefundPayment(ctx, req); !errors.Is(err, domain.ErrRefundNotAllowed) {
+			t.Errorf("%+v: %v", req, err)
+		}
+	}
+	res, err := payments.RefundPayment(ctx, domain.RefundRequest{PaymentID: "pay-cash", ToWallet: true, Compensation: true,
+		Amount: money.New(10000, ""), RequestedBy: "admin-1"})
+	if err != nil || res.Status != domain.RefundStatusSucceeded || !book.refunds[0].ToWallet {
+		t.Errorf("compensation to the wallet: %+v, %v", res, err)
+	}
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+)
+
+// RoutingRepository — admin card routing rules
+type RoutingRepository interface {
+	ListRoutingRules(ctx context.Context) ([]*domain.RoutingRule, error)
+	CreateRoutingRule(ctx context.Context, rule *domain.RoutingRule) error
+	UpdateRoutingRule(ctx context.Context, rule *domain.RoutingRule) error
+	DeleteRoutingRule(ctx context.Context, id string) error
+}
+
+// DefaultRoutingPolicy — Tinkoff, then YooMoney, then Sber, failing over
+func DefaultRoutingPolicy() domain.RoutingPolicy {
+	return domain.RoutingPolicy{
+		Providers: []string{domain.ProviderTinkoff, domain.ProviderYooMoney, domain.ProviderSber},
+		Strategy:  domain.RoutePriority,
+		Failover:  true,
+	}
+}
+
+// RoutingUseCase — chooses the card providers of a payment from the admin rules, the
+// policy and the health of the gateways
+type RoutingUseCase struct {
+	repo     RoutingRepository
+	gateways *gateway.Manager
+	policy   domain.RoutingPolicy
+}
+
+// NewRoutingUseCase creates routing use case
+func NewRoutingUseCase(repo RoutingRepository, gateways *gateway.Manager, policy domain.RoutingPolicy) *RoutingUseCase {
+	return &RoutingUseCase{repo: repo, gateways: gateways, policy: policy}
+}
+
+// Plan orders the card providers a payment is tried at. A saved card stays with its
+// provider. Without the rules (database down) the policy still routes the payment.
+func (uc *RoutingUseCase) Plan(ctx context.Context, amount money.Money, brand, requested string, savedCard bool) *domain.RoutingDecision {
+	var rule *domain.RoutingRule
+	if !savedCard {
+		rules, err := uc.repo.ListRoutingRules(ctx)
+		if err != nil {
+			slog.Warn("routing rules unavailable, using the default policy", "error", err)
+		}
+		rule = domain.MatchRoutingRule(rules, amount, brand)
+	}
+	return domain.Route(uc.policy, rule, amount, brand, requested, savedCard, uc.gateways.Available(), uc.gateways.Health())
+}
+
+// Health returns the circuit breaker state and health of the gateways, by provider
+func (uc *RoutingUseCase) Health() []domain.GatewayHealth {
+	health := make([]domain.GatewayHealth, 0)
+	for _, h := range uc.gateways.Health() {
+		health = append(health, h)
+	}
+	sort.Slice(health, func(i, j int) bool { return health[i].Provider < health[j].Provider })
+	return health
+}
+
+// ListRules returns the routing rules by priority
+func (uc *RoutingUseCase) ListRules(ctx context.Context) ([]*domain.RoutingRule, error) {
+	rules, err := uc.repo.ListRoutingRules(ctx)
+	if rules == nil && err == nil {
+		rules = []*domain.RoutingRule{}
+	}
+	return rules, err
+}
+
+// CreateRule adds a routing rule
+func (uc *RoutingUseCase) CreateRule(ctx context.Context, rule *domain.RoutingRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	return uc.repo.CreateRoutingRule(ctx, rule)
+}
+
+// UpdateRule changes a routing rule; payments already routed keep their decision
+func (uc *RoutingUseCase) UpdateRule(ctx context.Context, rule *domain.RoutingRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	return uc.repo.UpdateRoutingRule(ctx, rule)
+}
+
+// DeleteRule removes a routing rule
+func (uc *RoutingUseCase) DeleteRule(ctx context.Context, id string) error {
+	return uc.repo.DeleteRoutingRule(ctx, id)
+}
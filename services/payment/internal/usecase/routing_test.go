@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+)
+
+// cardGateway answers CreatePayment with err, or a pending checkout
+type cardGateway struct {
+	gateway.Gateway
+	provider string
+	err      error
+	calls    int
+}
+
+func (g *cardGateway) Provider() string { return g.provider }
+
+func (g *cardGateway) CreatePayment(ctx context.Context, input gateway.CreatePaymentInput) (*gateway.CreatePaymentResult, error) {
+	g.calls++
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &gateway.CreatePaymentResult{ExternalID: g.provider + "-1", ConfirmURL: "https://pay/" + g.provider,
+		Status: domain.PaymentStatusPending}, nil
+}
+
+func newRoutingTest(errs map[string]error) (*PaymentUseCase, *paymentStore, *routingRules, map[string]*cardGateway) {
+	repo := newPaymentStore()
+	rules := &routingRules{}
+	gateways := gateway.NewManager()
+	cards := map[string]*cardGateway{}
+	for _, provider := range []string{domain.ProviderTinkoff, domain.ProviderYooMoney, domain.ProviderSber} {
+		cards[provider] = &cardGateway{provider: provider, err: errs[provider]}
+		gateways.Register(cards[provider])
+	}
+	routing := NewRoutingUseCase(rules, gateways, DefaultRoutingPolicy())
+	return NewPaymentUseCase(repo, gateways, nil, routing, nil), repo, rules, cards
+}
+
+func attempted(d *domain.RoutingDecision) []string {
+	var providers []string
+	for _, a := range d.Attempts {
+		providers = append(providers, a.Provider)
+	}
+	return providers
+}
+
+func TestPaymentUseCase_CardFailover(t *testing.T) {
+	ctx := context.Background()
+	input := CreatePaymentInput{UserID: "u1", RideID: "r1", Amount: money.New(50000, money.RUB), Method: domain.MethodCard}
+
+	// Tinkoff is down: the checkout opens at YooMoney
+	uc, repo, _, cards := newRoutingTest(map[string]error{domain.ProviderTinkoff: gateway.ErrProviderUnavailable})
+	intent, err := uc.CreatePayment(ctx, input)
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	p := repo.last
+	if intent.Provider != domain.ProviderYooMoney || intent.ConfirmURL != "https://pay/yoomoney" ||
+		p.Provider != domain.ProviderYooMoney || p.ExternalID != "yoomoney-1" || p.Status != domain.PaymentStatusPending {
+		t.Errorf("failover: %+v %+v", intent, p)
+	}
+	if got := attempted(p.Routing); !reflect.DeepEqual(got, []string{domain.ProviderTinkoff, domain.ProviderYooMoney}) ||
+		p.Routing.Attempts[0].Error == "" || p.Routing.Attempts[1].Error != "" {
+		t.Errorf("attempts: %+v", p.Routing.Attempts)
+	}
+	if cards[domain.ProviderSber].calls != 0 {
+		t.Error("providers after the one that took the payment are not tried")
+	}
+
+	// A decline is the bank's answer, another provider would not change it
+	uc, repo, _, cards = newRoutingTest(map[string]error{domain.ProviderTinkoff: gateway.ErrPaymentRejected})
+	if _, err := uc.CreatePayment(ctx, input); !errors.Is(err, gateway.ErrPaymentRejected) {
+		t.Errorf("decline: %v", err)
+	}
+	if cards[domain.ProviderYooMoney].calls != 0 || repo.last.Status != domain.PaymentStatusFailed {
+		t.Errorf("a decline must not fail over: %+v", repo.last)
+	}
+
+	// Every provider down
+	down := errors.New("send request: connection refused")
+	uc, repo, _, _ = newRoutingTest(map[string]error{
+		domain.ProviderTinkoff: down, domain.ProviderYooMoney: down, domain.ProviderSber: gateway.ErrCircuitOpen,
+	})
+	if _, err := uc.CreatePayment(ctx, input); !errors.Is(err, domain.ErrProviderError) {
+		t.Errorf("all down: %v", err)
+	}
+	if len(repo.last.Routing.Attempts) != 3 || repo.last.Status != domain.PaymentStatusFailed {
+		t.Errorf("all down: %+v", repo.last)
+	}
+
+	// A rule for Mir cards sends them to Sber
+	uc, repo, rules, _ := newRoutingTest(nil)
+	rules.rules = []*domain.RoutingRule{{ID: "mir", Name: "mir to sber", CardBrand: "mir", Providers: []string{domain.ProviderSber}}}
+	mir := input
+	mir.CardBrand = "mir"
+	if intent, err := uc.CreatePayment(ctx, mir); err != nil || intent.Provider != domain.ProviderSber || repo.last.Routing.RuleID != "mir" {
+		t.Errorf("rule: %+v, %v", intent, err)
+	}
+}
+
+func TestPaymentUseCase_SavedCardStaysWithProvider(t *testing.T) {
+	uc, repo, _, cards := newRoutingTest(map[string]error{domain.ProviderSber: gateway.ErrProviderUnavailable})
+	repo.cards = []*domain.PaymentMethod{{Provider: domain.ProviderSber, TokenID: "binding-1", Brand: "mir"}}
+
+	_, err := uc.CreatePayment(context.Background(), CreatePaymentInput{UserID: "u1", RideID: "r1",
+		Amount: money.New(50000, money.RUB), Method: domain.MethodCard, TokenID: "binding-1"})
+	if !errors.Is(err, domain.ErrProviderError) {
+		t.Errorf("saved card at a provider that is down: %v", err)
+	}
+	d := repo.last.Routing
+	if !d.SavedCard || d.CardBrand != "mir" || !reflect.DeepEqual(d.Candidates, []string{domain.ProviderSber}) {
+		t.Errorf("decision: %+v", d)
+	}
+	if cards[domain.ProviderTinkoff].calls != 0 || cards[domain.ProviderYooMoney].calls != 0 {
+		t.Error("a saved card can only be charged at the provider that saved it")
+	}
+}
@@ -0,0 +1,374 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/pg"
+)
+
+// paymentStore — payments, saved cards, refunds, wallets, the webhook inbox and
+// reconciliation records in memory. Booked entries move wallet balances like the pg
+// repositories do, failing a spend beyond the balance; an entry already booked under
+// its kind and reference is not booked again. entries keeps every entry handed over,
+// so a test can tell an attempt to book twice.
+type paymentStore struct {
+	PaymentRepository
+	payments   map[string]*domain.Payment
+	order      []string
+	last       *domain.Payment
+	cards      []*domain.PaymentMethod
+	refunds    []*domain.Refund
+	balances   map[string]int64
+	booked     map[string]bool
+	entries    []*domain.JournalEntry
+	webhooks   []*domain.InboxWebhook
+	checked    map[string]bool
+	mismatches []*domain.PaymentMismatch
+}
+
+func newPaymentStore(payments ...*domain.Payment) *paymentStore {
+	s := &paymentStore{payments: map[string]*domain.Payment{}, balances: map[string]int64{}, booked: map[string]bool{},
+		checked: map[string]bool{}}
+	for _, p := range payments {
+		s.add(p)
+	}
+	return s
+}
+
+// add stores p under its own ID
+func (s *paymentStore) add(p *domain.Payment) {
+	if _, ok := s.payments[p.ID]; !ok {
+		s.order = append(s.order, p.ID)
+	}
+	s.payments[p.ID] = p
+}
+
+// ride returns the payment of rideID
+func (s *paymentStore) ride(rideID string) *domain.Payment {
+	p, _ := s.GetByRideID(context.Background(), rideID)
+	return p
+}
+
+func (s *paymentStore) book(ref string, entries []*domain.JournalEntry) error {
+	s.entries = append(s.entries, entries...)
+	balances := map[string]int64{}
+	for k, v := range s.balances {
+		balances[k] = v
+	}
+	for _, e := range entries {
+		if e.Reference == "" {
+			e.Reference = ref
+		}
+		if s.booked[e.Kind+"/"+e.Reference] {
+			continue
+		}
+		for _, p := range e.Postings {
+			if user, ok := strings.CutPrefix(p.Account, domain.AccountTypeWallet+":"); ok {
+				balances[user] -= p.Amount.Minor
+				if balances[user] < 0 {
+					return domain.ErrInsufficientFunds
+				}
+			}
+		}
+	}
+	s.balances = balances
+	for _, e := range entries {
+		if !s.booked[e.Kind+"/"+e.Reference] {
+			s.booked[e.Kind+"/"+e.Reference] = true
+			e.ID = "entry-" + e.Reference
+		}
+	}
+	return nil
+}
+
+func (s *paymentStore) Create(ctx context.Context, p *domain.Payment) error {
+	if p.RideID != "" {
+		if held, _ := s.GetByRideID(ctx, p.RideID); held != nil && held.Purpose == p.Purpose {
+			return pg.ErrPaymentExists
+		}
+	}
+	p.ID = fmt.Sprintf("pay-%d", len(s.order)+1)
+	s.add(p)
+	s.last = p
+	return nil
+}
+
+func (s *paymentStore) GetByID(ctx context.Context, id string) (*domain.Payment, error) {
+	return s.payments[id], nil
+}
+
+func (s *paymentStore) GetByRideID(ctx context.Context, rideID string) (*domain.Payment, error) {
+	for _, id := range s.order {
+		if p := s.payments[id]; p.RideID == rideID {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *paymentStore) GetByExternalID(ctx context.Context, externalID string) (*domain.Payment, error) {
+	for _, id := range s.order {
+		if p := s.payments[id]; p.ExternalID != "" && p.ExternalID == externalID {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *paymentStore) UpdatePayment(ctx context.Context, p *domain.Payment, entries ...*domain.JournalEntry) error {
+	if err := s.book(p.ID, entries); err != nil {
+		return err
+	}
+	s.add(p)
+	s.last = p
+	return nil
+}
+
+func (s *paymentStore) CreatePaymentMethod(ctx context.Context, pm *domain.PaymentMethod) error {
+	s.cards = append(s.cards, pm)
+	return nil
+}
+
+func (s *paymentStore) ListPaymentMethods(ctx context.Context, userID string) ([]*domain.PaymentMethod, error) {
+	return s.cards, nil
+}
+
+func (s *paymentStore) CreateRefund(ctx context.Context, r *domain.Refund, entries ...*domain.JournalEntry) error {
+	p := *s.payments[r.PaymentID]
+	if err := p.ReserveRefund(r.Amount, time.Now()); err != nil {
+		return err
+	}
+	r.ID = fmt.Sprintf("refund-%d", len(s.refunds)+1)
+	if err := s.book(r.ID, entries); err != nil {
+		return err
+	}
+	s.payments[p.ID] = &p
+	s.refunds = append(s.refunds, r)
+	return nil
+}
+
+func (s *paymentStore) UpdateRefund(ctx context.Context, r *domain.Refund, entries ...*domain.JournalEntry) error {
+	if r.Status == domain.RefundStatusFailed {
+		s.payments[r.PaymentID].ReleaseRefund(r.Amount, time.Now())
+	}
+	return s.book(r.ID, entries)
+}
+
+func (s *paymentStore) ListRefunds(ctx context.Context, paymentID string) ([]*domain.Refund, error) {
+	var refunds []*domain.Refund
+	for _, r := range s.refunds {
+		if r.PaymentID == paymentID {
+			refunds = append(refunds, r)
+		}
+	}
+	return refunds, nil
+}
+
+func (s *paymentStore) GetWallet(ctx context.Context, userID string) (*domain.Wallet, error) {
+	b, ok := s.balances[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &domain.Wallet{UserID: userID, Balance: money.New(b, money.RUB)}, nil
+}
+
+func (s *paymentStore) ListWallets(ctx context.Context) ([]*domain.Wallet, error) {
+	var wallets []*domain.Wallet
+	for user := range s.balances {
+		w, _ := s.GetWallet(ctx, user)
+		wallets = append(wallets, w)
+	}
+	return wallets, nil
+}
+
+func (s *paymentStore) ListTransactions(ctx context.Context, userID string, limit, offset int) ([]*domain.WalletTransaction, error) {
+	return nil, nil
+}
+
+func (s *paymentStore) BookCredit(ctx context.Context, entry *domain.JournalEntry) (bool, error) {
+	if s.booked[entry.Kind+"/"+entry.Reference] {
+		return false, nil
+	}
+	return true, s.book("", []*domain.JournalEntry{entry})
+}
+
+func (s *paymentStore) SaveWebhook(ctx context.Context, w *domain.InboxWebhook) (bool, error) {
+	for _, stored := range s.webhooks {
+		if stored.Provider == w.Provider && stored.EventID == w.EventID {
+			return false, nil
+		}
+	}
+	w.ID = w.EventID
+	s.webhooks = append(s.webhooks, w)
+	return true, nil
+}
+
+func (s *paymentStore) ClaimWebhooks(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*domain.InboxWebhook, error) {
+	var due []*domain.InboxWebhook
+	for _, w := range s.webhooks {
+		if w.Status == domain.WebhookPending && !w.NextAttemptAt.After(now) && len(due) < limit {
+			w.NextAttemptAt = leaseUntil
+			due = append(due, w)
+		}
+	}
+	return due, nil
+}
+
+func (s *paymentStore) UpdateWebhook(ctx context.Context, w *domain.InboxWebhook) error { return nil }
+
+func (s *paymentStore) GetWebhook(ctx context.Context, id string) (*domain.InboxWebhook, error) {
+	for _, w := range s.webhooks {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *paymentStore) ListWebhooks(ctx context.Context, provider, status string, limit, offset int) ([]*domain.InboxWebhook, error) {
+	return s.webhooks, nil
+}
+
+func (s *paymentStore) ListInFlight(ctx context.Context, before time.Time, limit int) ([]*domain.Payment, error) {
+	var out []*domain.Payment
+	for _, id := range s.order {
+		if p := s.payments[id]; domain.IsInFlight(p.Status) && p.CreatedAt.Before(before) && len(out) < limit {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (s *paymentStore) MarkReconciled(ctx context.Context, id string) error {
+	s.checked[id] = true
+	return nil
+}
+
+func (s *paymentStore) RecordMismatch(ctx context.Context, m *domain.PaymentMismatch) error {
+	s.mismatches = append(s.mismatches, m)
+	return nil
+}
+
+func (s *paymentStore) ListMismatches(ctx context.Context, outcome string, limit, offset int) ([]*domain.PaymentMismatch, error) {
+	return s.mismatches, nil
+}
+
+// routingRules — admin rules kept in memory
+type routingRules struct {
+	RoutingRepository
+	rules []*domain.RoutingRule
+}
+
+func (f *routingRules) ListRoutingRules(ctx context.Context) ([]*domain.RoutingRule, error) {
+	return f.rules, nil
+}
+
+// earningsStore — earnings, profiles and payouts kept in memory; the driver balance is
+// summed from the booked entries like the ledger does
+type earningsStore struct {
+	EarningsRepository
+	rules    []*domain.CommissionRule
+	profiles map[string]*domain.DriverProfile
+	earnings map[string]*domain.RideEarning
+	payouts  []*domain.Payout
+	entries  []*domain.JournalEntry
+	rides    []*domain.RideCompletion
+}
+
+func (s *earningsStore) SaveRideCompletion(ctx context.Context, c *domain.RideCompletion) error {
+	for _, ride := range s.rides {
+		if ride.RideID == c.RideID {
+			return nil
+		}
+	}
+	s.rides = append(s.rides, c)
+	return nil
+}
+
+func (s *earningsStore) GetRideCompletion(ctx context.Context, rideID string) (*domain.RideCompletion, error) {
+	for _, ride := range s.rides {
+		if _, recorded := s.earnings[ride.RideID]; ride.RideID == rideID && !recorded {
+			return ride, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *earningsStore) ListPendingCompletions(ctx context.Context, limit, offset int) ([]*domain.RideCompletion, error) {
+	var pending []*domain.RideCompletion
+	for _, ride := range s.rides {
+		if _, recorded := s.earnings[ride.RideID]; !recorded {
+			pending = append(pending, ride)
+		}
+	}
+	if offset >= len(pending) {
+		return nil, nil
+	}
+	return pending[offset:min(offset+limit, len(pending))], nil
+}
+
+func (s *earningsStore) ListCommissionRules(ctx context.Context) ([]*domain.CommissionRule, error) {
+	return s.rules, nil
+}
+
+func (s *earningsStore) GetDriverProfile(ctx context.Context, driverID string) (*domain.DriverProfile, error) {
+	return s.profiles[driverID], nil
+}
+
+func (s *earningsStore) RecordEarning(ctx context.Context, e *domain.RideEarning, entries ...*domain.JournalEntry) (bool, error) {
+	if _, ok := s.earnings[e.RideID]; ok {
+		return false, nil
+	}
+	s.earnings[e.RideID] = e
+	s.entries = append(s.entries, entries...)
+	return true, nil
+}
+
+func (s *earningsStore) DriverBalance(ctx context.Context, driverID, currency string) (money.Money, error) {
+	balance := money.New(0, currency)
+	for _, e := range s.entries {
+		for _, p := range e.Postings {
+			if p.Account == domain.DriverAccount(driverID) {
+				balance = balance.Sub(p.Amount)
+			}
+		}
+	}
+	return balance, nil
+}
+
+func (s *earningsStore) CreatePayoutBatch(ctx context.Context, start, end time.Time, min money.Money) (*domain.PayoutBatch, []*domain.Payout, error) {
+	batch := &domain.PayoutBatch{ID: "b1", PeriodStart: start, PeriodEnd: end}
+	for id, p := range s.profiles {
+		balance, _ := s.DriverBalance(ctx, id, min.Currency)
+		if !p.CanBePaidOut() || balance.Cmp(min) < 0 {
+			continue
+		}
+		po := &domain.Payout{ID: fmt.Sprintf("po%d", len(s.payouts)+1), BatchID: batch.ID, DriverID: id, Amount: balance,
+			Provider: p.PayoutProvider, Destination: p.PayoutDestination, Status: domain.PayoutStatusPending}
+		s.payouts = append(s.payouts, po)
+		s.entries = append(s.entries, po.SentEntry())
+		batch.Count++
+	}
+	return batch, s.payouts, nil
+}
+
+func (s *earningsStore) ListOpenPayouts(ctx context.Context) ([]*domain.Payout, error) {
+	var open []*domain.Payout
+	for _, p := range s.payouts {
+		if p.Status == domain.PayoutStatusPending || p.Status == domain.PayoutStatusProcessing {
+			open = append(open, p)
+		}
+	}
+	return open, nil
+}
+
+func (s *earningsStore) UpdatePayout(ctx context.Context, p *domain.Payout, entries ...*domain.JournalEntry) error {
+	s.entries = append(s.entries, entries...)
+	return nil
+}
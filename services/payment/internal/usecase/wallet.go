@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// WalletRepository — wallets and their history; balances change through journal entries
+type WalletRepository interface {
+	GetWallet(ctx context.Context, userID string) (*domain.Wallet, error)
+	ListWallets(ctx context.Context) ([]*domain.Wallet, error)
+	ListTransactions(ctx context.Context, userID string, limit, offset int) ([]*domain.WalletTransaction, error)
+	// BookCredit returns false when the entry's kind and reference were already booked
+	BookCredit(ctx context.Context, entry *domain.JournalEntry) (bool, error)
+}
+
+// TopUpPayments — creates the card payment behind a top-up
+type TopUpPayments interface {
+	CreatePayment(ctx context.Context, input CreatePaymentInput) (*domain.PaymentIntent, error)
+}
+
+// WalletUseCase — passenger wallets: top-ups, credits, statements and reconciliation.
+// Rides are paid from the wallet through PaymentUseCase with the wallet method.
+type WalletUseCase struct {
+	repo     WalletRepository
+	payments TopUpPayments
+	ledger   LedgerRepository
+}
+
+// NewWalletUseCase creates wallet use case
+func NewWalletUseCase(repo WalletRepository, payments TopUpPayments, ledger LedgerRepository) *WalletUseCase {
+	return &WalletUseCase{repo: repo, payments: payments, ledger: ledger}
+}
+
+// Wallet returns the user's wallet; a zero RUB balance before the first top-up
+func (uc *WalletUseCase) Wallet(ctx context.Context, userID string) (*domain.Wallet, error) {
+	w, err := uc.repo.GetWallet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		w = &domain.Wallet{UserID: userID, Balance: money.New(0, money.RUB)}
+	}
+	return w, nil
+}
+
+// TopUpInput — money added to the wallet from a card
+type TopUpInput struct {
+	UserID    string
+	Amount    money.Money // currency defaults to RUB
+	Provider  string      // tinkoff | yoomoney | sber; default tinkoff
+	ReturnURL string
+	SaveCard  bool
+	TokenID   string // Use saved card
+	UserEmail string
+	UserPhone string
+}
+
+// TopUp starts a top-up payment; the wallet is credited when the gateway confirms it
+func (uc *WalletUseCase) TopUp(ctx context.Context, input TopUpInput) (*domain.PaymentIntent, error) {
+	return uc.payments.CreatePayment(ctx, CreatePaymentInput{
+		Purpose:     domain.PurposeWalletTopUp,
+		UserID:      input.UserID,
+		Amount:      input.Amount,
+		Method:      domain.MethodCard,
+		Provider:    input.Provider,
+		Description: "Wallet top-up",
+		ReturnURL:   input.ReturnURL,
+		SaveCard:    input.SaveCard,
+		TokenID:     input.TokenID,
+		UserEmail:   input.UserEmail,
+		UserPhone:   input.UserPhone,
+	})
+}
+
+// Statement returns the balance and its history, newest first
+func (uc *WalletUseCase) Statement(ctx context.Context, userID string, limit, offset int) (*domain.WalletStatement, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	w, err := uc.Wallet(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	txs, err := uc.repo.ListTransactions(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if txs == nil {
+		txs = []*domain.WalletTransaction{}
+	}
+	return &domain.WalletStatement{Wallet: w, Transactions: txs}, nil
+}
+
+// Credit puts a referral bonus or compensation on the wallet;
+// domain.ErrCreditExists when its reference was already credited
+func (uc *WalletUseCase) Credit(ctx context.Context, c *domain.WalletCredit) error {
+	c.Amount = c.Amount.In(money.RUB)
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	booked, err := uc.repo.BookCredit(ctx, c.Entry())
+	if err != nil {
+		return err
+	}
+	if !booked {
+		return domain.ErrCreditExists
+	}
+	return nil
+}
+
+// Reconcile checks every stored wallet balance against its ledger account
+func (uc *WalletUseCase) Reconcile(ctx context.Context) (*domain.WalletReconciliation, error) {
+	wallets, err := uc.repo.ListWallets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := uc.ledger.Balances(ctx, domain.AccountTypeWallet+":")
+	if err != nil {
+		return nil, err
+	}
+	return domain.ReconcileWallets(wallets, accounts), nil
+}
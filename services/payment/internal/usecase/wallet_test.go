@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+)
+
+// refusingGateway fails the test if a wallet flow reaches a card gateway
+type refusingGateway struct {
+	gateway.Gateway
+	t *testing.T
+}
+
+func (g *refusingGateway) Provider() string { return domain.ProviderTinkoff }
+
+func (g *refusingGateway) Refund(ctx context.Context, input gateway.RefundInput) (*gateway.RefundResult, error) {
+	g.t.Error("refund to the wallet must not call the gateway")
+	return nil, errors.New("unexpected refund")
+}
+
+func newWalletTest(t *testing.T) (*PaymentUseCase, *WalletUseCase, *paymentStore) {
+	book := newPaymentStore()
+	gateways := gateway.NewManager()
+	gateways.Register(&refusingGateway{t: t})
+	payments := NewPaymentUseCase(book, gateways, book, nil, nil)
+	return payments, NewWalletUseCase(book, payments, nil), book
+}
+
+func TestWallet_PayFromBalance(t *testing.T) {
+	payments, wallets, book := newWalletTest(t)
+	ctx := context.Background()
+
+	credit := &domain.WalletCredit{UserID: "u1", Kind: domain.CreditReferral, Amount: money.New(50000, ""), Reference: "ref-1"}
+	if err := wallets.Credit(ctx, credit); err != nil {
+		t.Fatalf("Credit: %v", err)
+	}
+	if err := wallets.Credit(ctx, credit); err != domain.ErrCreditExists {
+		t.Errorf("repeated credit must book once, got %v", err)
+	}
+
+	_, err := payments.CreatePayment(ctx, CreatePaymentInput{RideID: "r1", UserID: "u1", Amount: money.New(60000, ""), Method: domain.MethodWallet})
+	if err != domain.ErrInsufficientFunds || len(book.payments) != 0 {
+		t.Fatalf("short balance must be refused before a payment is created, got %v", err)
+	}
+
+	intent, err := payments.CreatePayment(ctx, CreatePaymentInput{RideID: "r1", UserID: "u1", Amount: money.New(45000, ""), Method: domain.MethodWallet})
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	p := book.payments[intent.PaymentID]
+	if p.Provider != domain.ProviderWallet || p.Status != domain.PaymentStatusCompleted || p.PaidAt == nil || book.balances["u1"] != 5000 {
+		t.Errorf("wallet ride must be paid at once: %+v, balance %d", p, book.balances["u1"])
+	}
+
+	if _, err := payments.CreatePayment(ctx, CreatePaymentInput{RideID: "r2", UserID: "u1", Amount: money.New(100, ""), Method: domain.MethodWallet, Provider: domain.ProviderTinkoff}); err != domain.ErrInvalidProvider {
+		t.Errorf("wallet method takes the wallet provider only, got %v", err)
+	}
+
+	if _, err := payments.RefundPayment(ctx, domain.RefundRequest{PaymentID: p.ID, Amount: money.New(20000, "")}); err != nil {
+		t.Fatalf("RefundPayment: %v", err)
+	}
+	if book.balances["u1"] != 25000 {
+		t.Errorf("wallet ride refund must go back to the wallet, balance %d", book.balances["u1"])
+	}
+}
+
+func TestWallet_RefundCardToWallet(t *testing.T) {
+	payments, wallets, book := newWalletTest(t)
+	ctx := context.Background()
+	book.add(&domain.Payment{ID: "pay-card", RideID: "r1", Purpose: domain.PurposeRide, UserID: "u1",
+		Method: domain.MethodCard, Provider: domain.ProviderTinkoff, Status: domain.PaymentStatusCompleted,
+		Amount: money.New(30000, money.RUB), Currency: money.RUB})
+
+	res, err := payments.RefundPayment(ctx, domain.RefundRequest{PaymentID: "pay-card", ToWallet: true})
+	if err != nil || res.Status != "succeeded" {
+		t.Fatalf("RefundPayment: %+v %v", res, err)
+	}
+	w, _ := wallets.Wallet(ctx, "u1")
+	if w.Balance.Minor != 30000 || book.payments["pay-card"].Status != domain.PaymentStatusRefunded {
+		t.Errorf("full refund to the wallet: balance %s, %+v", w.Balance, book.payments["pay-card"])
+	}
+
+	book.add(&domain.Payment{ID: "pay-topup", Purpose: domain.PurposeWalletTopUp, UserID: "u1",
+		Method: domain.MethodCard, Provider: domain.ProviderTinkoff, Status: domain.PaymentStatusCompleted,
+		Amount: money.New(10000, money.RUB), Currency: money.RUB})
+	if _, err := payments.RefundPayment(ctx, domain.RefundRequest{PaymentID: "pay-topup", ToWallet: true}); err != domain.ErrRefundNotAllowed {
+		t.Errorf("a top-up cannot be refunded to the wallet, got %v", err)
+	}
+}
+
+func TestWallet_TopUpNeedsCard(t *testing.T) {
+	_, wallets, _ := newWalletTest(t)
+	_, err := wallets.TopUp(context.Background(), TopUpInput{UserID: "u1", Amount: money.New(100000, ""), Provider: domain.ProviderCash})
+	if err != domain.ErrInvalidProvider {
+		t.Errorf("top-ups go through a card gateway, got %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/ridehail/payment/internal/domain"
+)
+
+// WebhookRepository — the durable inbox of provider callbacks
+type WebhookRepository interface {
+	// SaveWebhook stores w; false when the provider's event was already received
+	SaveWebhook(ctx context.Context, w *domain.InboxWebhook) (bool, error)
+	// ClaimWebhooks returns pending webhooks due by now and pushes their next attempt to
+	// leaseUntil, so a worker that dies mid-batch leaves them to the next pass
+	ClaimWebhooks(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*domain.InboxWebhook, error)
+	UpdateWebhook(ctx context.Context, w *domain.InboxWebhook) error
+	GetWebhook(ctx context.Context, id string) (*domain.InboxWebhook, error)
+	ListWebhooks(ctx context.Context, provider, status string, limit, offset int) ([]*domain.InboxWebhook, error)
+}
+
+// WebhookConfig — how the inbox is drained and how failures are retried
+type WebhookConfig struct {
+	Interval    time.Duration // between passes of the worker
+	BatchSize   int           // webhooks claimed per pass
+	Lease       time.Duration // a claimed webhook is not claimed again before this
+	MaxAttempts int           // then the webhook is failed until replayed
+	RetryBase   time.Duration // delay after the first failure, doubled after each next one
+	RetryMax    time.Duration
+}
+
+// DefaultWebhookConfig — drained every 5 seconds; 8 attempts over about an hour
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Interval:    5 * time.Second,
+		BatchSize:   50,
+		Lease:       2 * time.Minute,
+		MaxAttempts: 8,
+		RetryBase:   30 * time.Second,
+		RetryMax:    time.Hour,
+	}
+}
+
+// WebhookUseCase — provider callbacks: persisted and deduplicated on receipt, applied
+// to their payments asynchronously with retries
+type WebhookUseCase struct {
+	repo     WebhookRepository
+	payments *PaymentUseCase
+	cfg      WebhookConfig
+}
+
+// NewWebhookUseCase creates webhook use case
+func NewWebhookUseCase(repo WebhookRepository, payments *PaymentUseCase, cfg WebhookConfig) *WebhookUseCase {
+	return &WebhookUseCase{repo: repo, payments: payments, cfg: cfg}
+}
+
+// Interval — time between passes of the inbox worker
+func (uc *WebhookUseCase) Interval() time.Duration {
+	return uc.cfg.Interval
+}
+
+// Receive verifies and stores a callback. Callbacks that fail verification are stored
+// as rejected; redeliveries of a stored event are dropped. An error means nothing was
+// stored and the provider should deliver again.
+func (uc *WebhookUseCase) Receive(ctx context.Context, provider string, headers map[string]string, body []byte, signature string) error {
+	var w *domain.InboxWebhook
+	if gw, ok := uc.payments.gateways.Get(provider); !ok {
+		w = domain.RejectedWebhook(provider, headers, body, domain.ErrInvalidProvider)
+	} else if event, err := gw.ParseWebhook(ctx, body, signature); err != nil {
+		w = domain.RejectedWebhook(provider, headers, body, err)
+	} else {
+		w = domain.NewInboxWebhook(event, headers, body)
+	}
+	w.NextAttemptAt = time.Now()
+
+	stored, err := uc.repo.SaveWebhook(ctx, w)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !stored:
+		slog.Info("duplicate webhook dropped", "provider", provider, "event_id", w.EventID)
+	case w.Status == domain.WebhookRejected:
+		slog.Warn("webhook rejected", "provider", provider, "webhook_id", w.ID, "error", w.LastError)
+	}
+	return nil
+}
+
+// Run applies one batch of due webhooks
+func (uc *WebhookUseCase) Run(ctx context.Context) (*domain.WebhookRun, error) {
+	now := time.Now()
+	due, err := uc.repo.ClaimWebhooks(ctx, now, now.Add(uc.cfg.Lease), uc.cfg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	run := &domain.WebhookRun{}
+	for _, w := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		switch uc.process(ctx, w) {
+		case domain.WebhookProcessed:
+			run.Processed++
+		case domain.WebhookFailed:
+			run.Failed++
+		default:
+			run.Retrying++
+		}
+	}
+	return run, nil
+}
+
+// Replay applies a failed webhook again, with a fresh set of attempts
+func (uc *WebhookUseCase) Replay(ctx context.Context, id string) (*domain.InboxWebhook, error) {
+	w, err := uc.repo.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if w == nil {
+		return nil, domain.ErrWebhookNotFound
+	}
+	if w.Status != domain.WebhookFailed {
+		return nil, domain.ErrWebhookNotReplayable
+	}
+	w.Status = domain.WebhookPending
+	w.Attempts = 0
+	uc.process(ctx, w)
+	return w, nil
+}
+
+// List returns inbox webhooks, newest first; provider and status filter when set
+func (uc *WebhookUseCase) List(ctx context.Context, provider, status string, limit, offset int) ([]*domain.InboxWebhook, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	webhooks, err := uc.repo.ListWebhooks(ctx, provider, status, limit, offset)
+	if webhooks == nil && err == nil {
+		webhooks = []*domain.InboxWebhook{}
+	}
+	return webhooks, err
+}
+
+// process makes one attempt at w and stores its outcome; it returns the new status
+func (uc *WebhookUseCase) process(ctx context.Context, w *domain.InboxWebhook) string {
+	now := time.Now()
+	w.Attempts++
+	if err := uc.apply(ctx, w); err != nil {
+		w.LastError = err.Error()
+		if w.Attempts >= uc.cfg.MaxAttempts {
+			w.Status = domain.WebhookFailed
+		} else {
+			w.NextAttemptAt = now.Add(uc.retryDelay(w.Attempts))
+		}
+		slog.Warn("webhook processing failed", "webhook_id", w.ID, "provider", w.Provider,
+			"attempts", w.Attempts, "status", w.Status, "error", err)
+	} else {
+		w.Status = domain.WebhookProcessed
+		w.LastError = ""
+		w.ProcessedAt = &now
+	}
+	if err := uc.repo.UpdateWebhook(ctx, w); err != nil {
+		// The lease expires and the webhook is applied again, which has no further effect
+		slog.Warn("update webhook failed", "webhook_id", w.ID, "error", err)
+	}
+	return w.Status
+}
+
+// apply moves the webhook's payment; the payment may not be committed yet when its
+// first callback arrives, so a missing payment is retried like any other failure
+func (uc *WebhookUseCase) apply(ctx context.Context, w *domain.InboxWebhook) error {
+	gw, ok := uc.payments.gateways.Get(w.Provider)
+	if !ok {
+		return domain.ErrInvalidProvider
+	}
+
+	var p *domain.Payment
+	if w.PaymentID != "" {
+		p, _ = uc.payments.repo.GetByID(ctx, w.PaymentID)
+	}
+	if p == nil && w.ExternalID != "" {
+		p, _ = uc.payments.repo.GetByExternalID(ctx, w.ExternalID)
+	}
+	if p == nil {
+		return domain.ErrPaymentNotFound
+	}
+	if p.Provider != w.Provider {
+		// A provider the payment failed over from, e.g. its unpaid form expiring there
+		slog.Info("webhook from a provider the payment left", "webhook_id", w.ID, "payment_id", p.ID,
+			"provider", w.Provider, "payment_provider", p.Provider)
+		return nil
+	}
+	if w.EventType == "refund.succeeded" {
+		return uc.payments.applyRefund(ctx, p, w.RefundID, w.Amount.In(p.Currency))
+	}
+	return uc.payments.applyEvent(ctx, gw, p, w.EventType, w.ExternalID)
+}
+
+// retryDelay — exponential backoff after the given number of failed attempts
+func (uc *WebhookUseCase) retryDelay(attempts int) time.Duration {
+	delay := uc.cfg.RetryBase
+	for i := 1; i < attempts && delay < uc.cfg.RetryMax; i++ {
+		delay *= 2
+	}
+	if delay > uc.cfg.RetryMax {
+		delay = uc.cfg.RetryMax
+	}
+	return delay
+}
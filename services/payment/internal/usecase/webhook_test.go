@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+
+	"github.com/ridehail/payment/internal/domain"
+	"github.com/ridehail/payment/internal/infra/gateway"
+)
+
+// callbackGateway reads webhooks as JSON events; the signature must be "valid"
+type callbackGateway struct {
+	gateway.Gateway
+}
+
+func (g *callbackGateway) Provider() string { return domain.ProviderYooMoney }
+
+func (g *callbackGateway) ParseWebhook(ctx context.Context, body []byte, signature string) (*domain.WebhookEvent, error) {
+	if signature != "valid" {
+		return nil, gateway.ErrInvalidWebhook
+	}
+	var event domain.WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	event.Provider, event.SignatureVerified = domain.ProviderYooMoney, true
+	return &event, nil
+}
+
+func (g *callbackGateway) GetSavedCard(ctx context.Context, externalID string) (*gateway.CardInfo, error) {
+	return &gateway.CardInfo{TokenID: "tok-" + externalID, Last4: "4242"}, nil
+}
+
+func TestWebhookUseCase(t *testing.T) {
+	ctx := context.Background()
+	inbox := newPaymentStore(&domain.Payment{ID: "p1", UserID: "u1", Provider: domain.ProviderYooMoney,
+		Status: domain.PaymentStatusPending, ExternalID: "ext-1", Amount: money.New(50000, money.RUB), Currency: money.RUB})
+	gateways := gateway.NewManager()
+	gateways.Register(&callbackGateway{})
+	cfg := DefaultWebhookConfig()
+	cfg.MaxAttempts = 2
+	var paid []string
+	uc := NewWebhookUseCase(inbox, NewPaymentUseCase(inbox, gateways, nil, nil,
+		func(ctx context.Context, p *domain.Payment) { paid = append(paid, p.ID) }), cfg)
+
+	receive := func(body, signature string) {
+		t.Helper()
+		if err := uc.Receive(ctx, domain.ProviderYooMoney, map[string]string{"Content-Type": "application/json"},
+			[]byte(body), signature); err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+	}
+	succeeded := `{"event_id":"payment.succeeded:ext-1","event_type":"payment.succeeded","payment_id":"p1","external_id":"ext-1"}`
+	receive(succeeded, "valid")
+	receive(succeeded, "valid") // redelivery
+	receive(`{"event_id":"forged","event_type":"payment.succeeded","payment_id":"p1"}`, "forged")
+	receive(`{"event_id":"payment.succeeded:ext-2","event_type":"payment.succeeded","payment_id":"p2","external_id":"ext-2"}`, "valid")
+
+	if len(inbox.webhooks) != 3 {
+		t.Fatalf("a redelivered event must be dropped: %d stored", len(inbox.webhooks))
+	}
+	if forged := inbox.webhooks[1]; forged.Status != domain.WebhookRejected || forged.SignatureVerified ||
+		forged.LastError != gateway.ErrInvalidWebhook.Error() {
+		t.Errorf("a callback failing verification must be stored rejected: %+v", forged)
+	}
+
+	run, err := uc.Run(ctx)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if *run != (domain.WebhookRun{Processed: 1, Retrying: 1}) {
+		t.Errorf("first run: %+v", *run)
+	}
+	if p := inbox.payments["p1"]; p.Status != domain.PaymentStatusCompleted || len(inbox.entries) != 1 || len(inbox.cards) != 1 {
+		t.Errorf("payment.succeeded must complete the payment once: %+v entries=%d cards=%d", p, len(inbox.entries), len(inbox.cards))
+	}
+	early := inbox.webhooks[2]
+	if early.Status != domain.WebhookPending || early.Attempts != 1 || !early.NextAttemptAt.After(time.Now()) ||
+		early.LastError != domain.ErrPaymentNotFound.Error() {
+		t.Errorf("a webhook ahead of its payment must be retried later: %+v", early)
+	}
+
+	// The same outcome under another event ID has no further effect
+	receive(`{"event_id":"payment.succeeded:ext-1:again","event_type":"payment.succeeded","payment_id":"p1","external_id":"ext-1"}`, "valid")
+	early.NextAttemptAt = time.Now()
+	if run, _ = uc.Run(ctx); *run != (domain.WebhookRun{Processed: 1, Failed: 1}) {
+		t.Errorf("second run: %+v", *run)
+	}
+	if len(inbox.entries) != 1 || len(inbox.cards) != 1 {
+		t.Errorf("a repeated payment.succeeded must not book or save the card again: entries=%d cards=%d", len(inbox.entries), len(inbox.cards))
+	}
+	if early.Status != domain.WebhookFailed {
+		t.Fatalf("attempts exhausted: %+v", early)
+	}
+	if len(paid) != 1 || paid[0] != "p1" {
+		t.Errorf("completion must be reported once: %v", paid)
+	}
+
+	// A late failure or cancellation leaves a paid payment paid
+	receive(`{"event_id":"payment.failed:ext-1","event_type":"payment.failed","payment_id":"p1","external_id":"ext-1"}`, "valid")
+	receive(`{"event_id":"payment.cancelled:ext-1","event_type":"payment.cancelled","payment_id":"p1","external_id":"ext-1"}`, "valid")
+	if run, _ = uc.Run(ctx); *run != (domain.WebhookRun{Processed: 2}) || inbox.payments["p1"].Status != domain.PaymentStatusCompleted {
+		t.Errorf("late failure of a paid payment: %+v, %s", *run, inbox.payments["p1"].Status)
+	}
+
+	if _, err := uc.Replay(ctx, inbox.webhooks[0].ID); !errors.Is(err, domain.ErrWebhookNotReplayable) {
+		t.Errorf("replay of a processed webhook: %v", err)
+	}
+	if _, err := uc.Replay(ctx, "missing"); !errors.Is(err, domain.ErrWebhookNotFound) {
+		t.Errorf("replay of a missing webhook: %v", err)
+	}
+	inbox.add(&domain.Payment{ID: "p2", UserID: "u1", Provider: domain.ProviderYooMoney,
+		Status: domain.PaymentStatusPending, ExternalID: "ext-2", Amount: money.New(30000, money.RUB), Currency: money.RUB})
+	replayed, err := uc.Replay(ctx, early.ID)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if replayed.Status != domain.WebhookProcessed || replayed.Attempts != 1 || replayed.ProcessedAt == nil ||
+		inbox.payments["p2"].Status != domain.PaymentStatusCompleted {
+		t.Errorf("replayed webhook must be applied: %+v", replayed)
+	}
+}
+
+func TestWebhookUseCase_EqualPartialRefunds(t *testing.T) {
+	ctx := context.Background()
+	inbox := newPaymentStore(&domain.Payment{ID: "p1", UserID: "u1", Provider: domain.ProviderYooMoney,
+		Status: domain.PaymentStatusCompleted, ExternalID: "ext-1", Amount: money.New(50000, money.RUB), Currency: money.RUB})
+	// Two refunds of the same amount whose calls timed out: both wait for the provider
+	for _, id := range []string{"r1", "r2"} {
+		inbox.refunds = append(inbox.refunds, &domain.Refund{ID: id, PaymentID: "p1", Amount: money.New(10000, money.RUB),
+			Currency: money.RUB, Status: domain.RefundStatusPending})
+	}
+	gateways := gateway.NewManager()
+	gateways.Register(&callbackGateway{})
+	uc := NewWebhookUseCase(inbox, NewPaymentUseCase(inbox, gateways, nil, nil, nil), DefaultWebhookConfig())
+
+	// Without a refund identity the notifications of both refunds are alike
+	refunded := `{"event_type":"refund.succeeded","payment_id":"p1","external_id":"ext-1","amount":"100.00"}`
+	for i := 0; i < 2; i++ {
+		if err := uc.Receive(ctx, domain.ProviderYooMoney, nil, []byte(refunded), "valid"); err != nil {
+			t.Fatalf("Receive: %v", err)
+		}
+	}
+	if run, err := uc.Run(ctx); err != nil || *run != (domain.WebhookRun{Processed: 2}) {
+		t.Fatalf("Run: %+v, %v", run, err)
+	}
+	for _, r := range inbox.refunds {
+		if r.Status != domain.RefundStatusSucceeded {
+			t.Errorf("each notification must settle a refund: %+v", r)
+		}
+	}
+
+	// A redelivery finds both refunds settled
+	if err := uc.Receive(ctx, domain.ProviderYooMoney, nil, []byte(refunded), "valid"); err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if run, _ := uc.Run(ctx); run.Processed != 1 || len(inbox.refunds) != 2 || len(inbox.entries) != 2 {
+		t.Errorf("redelivery must not book again: %+v, %d refunds, %d entries", *run, len(inbox.refunds), len(inbox.entries))
+	}
+}
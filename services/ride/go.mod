@@ -3,6 +3,7 @@ module github.com/ridehail/ride
 go 1.23
 
 require (
+	github.com/alexevil1979/indrive/packages/money-go v0.0.0
 	github.com/alexevil1979/indrive/packages/otel-go v0.0.0
 	github.com/IBM/sarama v1.43.3
 	github.com/golang-jwt/jwt/v5 v5.2.1
@@ -10,4 +11,6 @@ require (
 	github.com/labstack/echo/v4 v4.12.0
 )
 
+replace github.com/alexevil1979/indrive/packages/money-go => ../../packages/money-go
+
 replace github.com/alexevil1979/indrive/packages/otel-go => ../../packages/otel-go
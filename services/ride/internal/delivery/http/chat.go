@@ -0,0 +1,104 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/usecase"
+)
+
+type ChatUseCase interface {
+	SendMessage(ctx context.Context, rideID, userID, text, quickReply string) (*domain.ChatMessage, error)
+	ListMessages(ctx context.Context, rideID, userID, userRole string, since time.Time) ([]*domain.ChatMessage, error)
+	MarkRead(ctx context.Context, rideID, userID string) (int64, error)
+	QuickReplies(role string) []domain.QuickReply
+}
+
+// SendChatMessageRequest — POST /api/v1/rides/:id/chat — text or quick_reply code
+type SendChatMessageRequest struct {
+	Text       string `json:"text"`
+	QuickReply string `json:"quick_reply"`
+}
+
+// SendChatMessage — message to the other participant while the ride is matched or in
+// progress; delivered as chat.message on the ride event stream and the driver's WebSocket
+func SendChatMessage(uc ChatUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req SendChatMessageRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		}
+		m, err := uc.SendMessage(c.Request().Context(), c.Param("id"), c.Get(UserIDKey).(string), req.Text, req.QuickReply)
+		if err != nil {
+			if err == domain.ErrInvalidChatMessage {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+			if err == usecase.ErrChatClosed {
+				return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+			}
+			return participantError(c, err, "failed to send message")
+		}
+		return c.JSON(http.StatusCreated, m)
+	}
+}
+
+// ListChatMessages — GET /api/v1/rides/:id/chat?since=RFC3339 — history, oldest first
+// (participants; admins for support review)
+func ListChatMessages(uc ChatUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var since time.Time
+		if s := c.QueryParam("since"); s != "" {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "since must be RFC3339"})
+			}
+			since = t
+		}
+		msgs, err := uc.ListMessages(c.Request().Context(), c.Param("id"), c.Get(UserIDKey).(string), c.Get(UserRoleKey).(string), since)
+		if err != nil {
+			return participantError(c, err, "failed to list messages")
+		}
+		if msgs == nil {
+			msgs = []*domain.ChatMessage{}
+		}
+		return c.JSON(http.StatusOK, msgs)
+	}
+}
+
+// MarkChatRead — POST /api/v1/rides/:id/chat/read — read receipt for all messages
+// received so far; the sender gets chat.read
+func MarkChatRead(uc ChatUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		n, err := uc.MarkRead(c.Request().Context(), c.Param("id"), c.Get(UserIDKey).(string))
+		if err != nil {
+			return participantError(c, err, "failed to mark messages read")
+		}
+		return c.JSON(http.StatusOK, map[string]int64{"marked": n})
+	}
+}
+
+// ListQuickReplies — GET /api/v1/chat/quick-replies — predefined messages of the caller's role
+func ListQuickReplies(uc ChatUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		replies := uc.QuickReplies(c.Get(UserRoleKey).(string))
+		if replies == nil {
+			replies = []domain.QuickReply{}
+		}
+		return c.JSON(http.StatusOK, replies)
+	}
+}
+
+// participantError maps errors of ride participant checks; anything else is a 500 with fallback
+func participantError(c echo.Context, err error, fallback string) error {
+	switch err {
+	case usecase.ErrRideNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "ride not found"})
+	case usecase.ErrNotRideParticipant:
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": fallback})
+}
@@ -0,0 +1,112 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/usecase"
+)
+
+type DestinationUseCase interface {
+	GetDestination(ctx context.Context, driverID string) (*domain.DriverDestination, error)
+	SetDestination(ctx context.Context, driverID string, point domain.Point) (*domain.DriverDestination, error)
+	ClearDestination(ctx context.Context, driverID string) error
+	ListOpenRides(ctx context.Context, driverID string, origin *domain.Point, limit int) ([]*domain.Ride, error)
+	FilterDispatch(ctx context.Context, rideID string, candidates []usecase.DispatchCandidate) ([]usecase.DispatchCandidate, error)
+}
+
+// GetDestination — GET /api/v1/drivers/me/destination (driver only)
+func GetDestination(uc DestinationUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get(UserRoleKey).(string) != "driver" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "driver only"})
+		}
+		d, err := uc.GetDestination(c.Request().Context(), c.Get(UserIDKey).(string))
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get destination"})
+		}
+		return c.JSON(http.StatusOK, d)
+	}
+}
+
+// SetDestination — PUT /api/v1/drivers/me/destination — {"lat":55.7,"lng":37.6,"address":"..."}
+func SetDestination(uc DestinationUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get(UserRoleKey).(string) != "driver" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "driver only"})
+		}
+		var req domain.Point
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		}
+		d, err := uc.SetDestination(c.Request().Context(), c.Get(UserIDKey).(string), req)
+		if err != nil {
+			if err == usecase.ErrInvalidDestination {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+			if err == usecase.ErrDestinationLimitReached {
+				return c.JSON(http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to set destination"})
+		}
+		return c.JSON(http.StatusOK, d)
+	}
+}
+
+// ClearDestination — DELETE /api/v1/drivers/me/destination
+func ClearDestination(uc DestinationUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get(UserRoleKey).(string) != "driver" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "driver only"})
+		}
+		if err := uc.ClearDestination(c.Request().Context(), c.Get(UserIDKey).(string)); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to clear destination"})
+		}
+		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
+	}
+}
+
+// DispatchFilterRequest — POST /api/v1/rides/:id/dispatch/filter
+type DispatchFilterRequest struct {
+	Drivers []usecase.DispatchCandidate `json:"drivers"`
+}
+
+// FilterDispatch — admin/service only: narrows nearest drivers (from geolocation) to those
+// the ride suits before push dispatch via notification service
+func FilterDispatch(uc DestinationUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get(UserRoleKey).(string) != "admin" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin only"})
+		}
+		var req DispatchFilterRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		}
+		drivers, err := uc.FilterDispatch(c.Request().Context(), c.Param("id"), req.Drivers)
+		if err != nil {
+			if err == usecase.ErrRideNotFound {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "ride not found"})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to filter drivers"})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"drivers": drivers})
+	}
+}
+
+// queryPoint parses optional ?lat=&lng= (driver's current position)
+func queryPoint(c echo.Context) *domain.Point {
+	lat, errLat := strconv.ParseFloat(c.QueryParam("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.QueryParam("lng"), 64)
+	if errLat != nil || errLng != nil {
+		return nil
+	}
+	p := domain.Point{Lat: lat, Lng: lng}
+	if !domain.ValidPoint(p) {
+		return nil
+	}
+	return &p
+}
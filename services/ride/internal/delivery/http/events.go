@@ -0,0 +1,92 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/stream"
+	"github.com/ridehail/ride/internal/usecase"
+)
+
+// RideEventHub — local fan-out of ride stream events (see stream.Hub)
+type RideEventHub interface {
+	Subscribe(rideID string, lastID int64, resume bool) ([]domain.RideEvent, bool, *stream.Subscription)
+}
+
+// sseHeartbeat keeps proxies from closing idle streams
+const sseHeartbeat = 25 * time.Second
+
+// RideEvents — GET /api/v1/rides/:id/events — Server-Sent Events for the ride's passenger
+// and matched driver: bid.placed, bid.updated, bid.withdrawn, bid.countered, ride.matched,
+// ride.status, chat.message, chat.read. Resume with the Last-Event-ID header; when the
+// events after it are no longer buffered a stream.reset event tells the client to refetch
+// the ride, bids and chat.
+func RideEvents(uc RideUseCase, hub RideEventHub) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		userID := c.Get(UserIDKey).(string)
+		ride, err := uc.StreamRide(c.Request().Context(), c.Param("id"), userID)
+		if err != nil {
+			if err == usecase.ErrRideNotFound {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "ride not found"})
+			}
+			if err == usecase.ErrNotRideParticipant {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to open stream"})
+		}
+		lastID, parseErr := strconv.ParseInt(c.Request().Header.Get("Last-Event-ID"), 10, 64)
+		resume := parseErr == nil
+		replay, complete, sub := hub.Subscribe(ride.ID, lastID, resume)
+		defer sub.Close()
+
+		w := c.Response()
+		w.Header().Set(echo.HeaderContentType, "text/event-stream")
+		w.Header().Set(echo.HeaderCacheControl, "no-cache")
+		w.Header().Set(echo.HeaderConnection, "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if !complete {
+			fmt.Fprintf(w, "event: %s\ndata: {\"ride_id\":%q}\n\n", domain.EventStreamReset, ride.ID)
+		}
+		for _, e := range replay {
+			writeRideEvent(w, e, userID, ride)
+		}
+		w.Flush()
+
+		heartbeat := time.NewTicker(sseHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-c.Request().Context().Done():
+				return nil
+			case e, ok := <-sub.C:
+				if !ok {
+					return nil // too slow or shutting down: the client reconnects with Last-Event-ID
+				}
+				writeRideEvent(w, e, userID, ride)
+				w.Flush()
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+				w.Flush()
+			}
+		}
+	}
+}
+
+func writeRideEvent(w *echo.Response, e domain.RideEvent, userID string, ride *domain.Ride) {
+	if !e.VisibleTo(userID, ride) {
+		return
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, body)
+}
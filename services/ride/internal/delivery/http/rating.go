@@ -54,6 +54,54 @@ func (h *RatingHandler) SubmitRating(c echo.Context) error {
 	return c.JSON(http.StatusCreated, rating)
 }
 
+// SubmitTripRatingRequest — rating of a shared trip participant (to_user_id: passenger
+// rated by the driver; passengers rate the driver and may omit it)
+type SubmitTripRatingRequest struct {
+	ToUserID string   `json:"to_user_id,omitempty"`
+	Score    int      `json:"score"`
+	Comment  string   `json:"comment,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// SubmitTripRating handles POST /api/v1/trips/:id/rating
+func (h *RatingHandler) SubmitTripRating(c echo.Context) error {
+	userID, ok := c.Get(UserIDKey).(string)
+	if !ok || userID == "" {
+		return c.JSON(http.StatusUnauthorized, errorResponse("unauthorized"))
+	}
+
+	var req SubmitTripRatingRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, errorResponse("invalid request body"))
+	}
+
+	rating, err := h.uc.SubmitTripRating(c.Request().Context(), usecase.SubmitTripRatingInput{
+		TripID:     c.Param("id"),
+		FromUserID: userID,
+		ToUserID:   req.ToUserID,
+		Score:      req.Score,
+		Comment:    req.Comment,
+		Tags:       req.Tags,
+	})
+	if err != nil {
+		if err == usecase.ErrTripNotFound {
+			return c.JSON(http.StatusNotFound, errorResponse(err.Error()))
+		}
+		return c.JSON(http.StatusBadRequest, errorResponse(err.Error()))
+	}
+
+	return c.JSON(http.StatusCreated, rating)
+}
+
+// GetTripRatings handles GET /api/v1/trips/:id/ratings
+func (h *RatingHandler) GetTripRatings(c echo.Context) error {
+	ratings, err := h.uc.GetTripRatings(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, errorResponse(err.Error()))
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"ratings": ratings})
+}
+
 // GetRideRatings handles GET /api/v1/rides/:id/ratings
 func (h *RatingHandler) GetRideRatings(c echo.Context) error {
 	rideID := c.Param("id")
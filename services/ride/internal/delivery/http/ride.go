@@ -2,8 +2,10 @@ package http
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
@@ -12,12 +14,17 @@ import (
 )
 
 type RideUseCase interface {
-	CreateRide(ctx context.Context, passengerID string, from, to domain.Point) (*domain.Ride, error)
+	CreateRide(ctx context.Context, in usecase.CreateRideInput) (*domain.Ride, error)
+	Categories() []domain.CategoryRules
+	ConfirmDelivery(ctx context.Context, rideID, driverID, code string) (*domain.Ride, error)
 	GetRide(ctx context.Context, id string) (*domain.Ride, error)
-	PlaceBid(ctx context.Context, rideID, driverID string, price float64) (*domain.Bid, error)
-	ListBids(ctx context.Context, rideID string) ([]*domain.Bid, error)
+	PlaceBid(ctx context.Context, rideID, driverID, userRole string, price float64) (*domain.Bid, error)
+	ListBids(ctx context.Context, rideID string) ([]*domain.BidDetails, error)
+	WithdrawBid(ctx context.Context, rideID, bidID, driverID string) (*domain.Bid, error)
+	CounterBid(ctx context.Context, rideID, bidID, passengerID string, price float64) (*domain.Bid, error)
+	StreamRide(ctx context.Context, rideID, userID string) (*domain.Ride, error)
 	AcceptBid(ctx context.Context, rideID, bidID, passengerID string) (*domain.Ride, error)
-	UpdateStatus(ctx context.Context, rideID, status, userID, userRole string) (*domain.Ride, error)
+	UpdateStatus(ctx context.Context, rideID, status, userID, userRole, pin string) (*domain.Ride, error)
 	ListRidesByPassenger(ctx context.Context, passengerID string, limit int) ([]*domain.Ride, error)
 	ListRidesByDriver(ctx context.Context, driverID string, limit int) ([]*domain.Ride, error)
 	ListOpenRides(ctx context.Context, limit int) ([]*domain.Ride, error)
@@ -25,9 +32,23 @@ type RideUseCase interface {
 }
 
 // CreateRideRequest — POST /api/v1/rides
+// options: {"vehicle_class":"comfort","features":["child_seat"]} — only matching drivers see and bid
+// category: economy (default), comfort, cargo, courier, intercity — see GET /rides/categories
+// delivery (courier only): {"sender":{"name","phone"},"recipient":{"name","phone"},"comment"}
+// auto_accept: {"max_price":600,"min_rating":4.5,"max_eta_minutes":7} — first qualifying bid is accepted
+// pickup_pin: true — the driver starts the ride with the 4-digit PIN shown to the passenger
+// payment: {"method":"card","card_id":"..."} — a saved card (payment service), held at match; default cash
 type CreateRideRequest struct {
-	From domain.Point `json:"from"`
-	To   domain.Point `json:"to"`
+	From        domain.Point       `json:"from"`
+	To          domain.Point       `json:"to"`
+	Options     domain.RideOptions `json:"options"`
+	Category    string             `json:"category"`
+	Seats       int                `json:"seats"`
+	ScheduledAt *time.Time         `json:"scheduled_at"`
+	Delivery    *domain.Delivery   `json:"delivery"`
+	AutoAccept  *domain.AutoAccept `json:"auto_accept"`
+	PickupPIN   bool               `json:"pickup_pin"`
+	Payment     domain.RidePayment `json:"payment"`
 }
 
 func CreateRide(uc RideUseCase) echo.HandlerFunc {
@@ -37,10 +58,26 @@ func CreateRide(uc RideUseCase) echo.HandlerFunc {
 		if err := c.Bind(&req); err != nil {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
 		}
-		ride, err := uc.CreateRide(c.Request().Context(), userID, req.From, req.To)
+		ride, err := uc.CreateRide(c.Request().Context(), usecase.CreateRideInput{
+			PassengerID: userID,
+			From:        req.From,
+			To:          req.To,
+			Options:     req.Options,
+			Category:    req.Category,
+			Seats:       req.Seats,
+			ScheduledAt: req.ScheduledAt,
+			Delivery:    req.Delivery,
+			AutoAccept:  req.AutoAccept,
+			PickupPIN:   req.PickupPIN,
+			Payment:     req.Payment,
+		})
 		if err != nil {
-			if err == usecase.ErrInvalidStatus {
+			switch err {
+			case usecase.ErrInvalidStatus:
 				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid coordinates"})
+			case domain.ErrInvalidRideOptions, domain.ErrUnknownCategory, domain.ErrInvalidContacts,
+				usecase.ErrInvalidSeats, usecase.ErrInvalidSchedule, usecase.ErrDeliveryRequired, usecase.ErrDeliveryNotAllowed, domain.ErrInvalidAutoAccept, domain.ErrInvalidPayment:
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 			}
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to create ride"})
 		}
@@ -48,6 +85,13 @@ func CreateRide(uc RideUseCase) echo.HandlerFunc {
 	}
 }
 
+// ListCategories — GET /api/v1/rides/categories (per-category rules for apps)
+func ListCategories(uc RideUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]interface{}{"categories": uc.Categories()})
+	}
+}
+
 func GetRide(uc RideUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		rideID := c.Param("id")
@@ -55,7 +99,42 @@ func GetRide(uc RideUseCase) echo.HandlerFunc {
 		if err != nil || ride == nil {
 			return c.JSON(http.StatusNotFound, map[string]string{"error": "ride not found"})
 		}
-		return c.JSON(http.StatusOK, ride)
+		return c.JSON(http.StatusOK, visibleRide(c, ride))
+	}
+}
+
+// ConfirmDeliveryRequest — POST /api/v1/rides/:id/delivery/confirm
+type ConfirmDeliveryRequest struct {
+	Code string `json:"code"`
+}
+
+// ConfirmDelivery — driver completes a courier ride with the recipient's code
+func ConfirmDelivery(uc RideUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get(UserRoleKey).(string) != "driver" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "driver only"})
+		}
+		var req ConfirmDeliveryRequest
+		if err := c.Bind(&req); err != nil || req.Code == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "code required"})
+		}
+		ride, err := uc.ConfirmDelivery(c.Request().Context(), c.Param("id"), c.Get(UserIDKey).(string), req.Code)
+		if err != nil {
+			switch err {
+			case usecase.ErrRideNotFound:
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "ride not found"})
+			case usecase.ErrNotDriver:
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "not the ride driver"})
+			case usecase.ErrInvalidStatus:
+				return c.JSON(http.StatusConflict, map[string]string{"error": "ride is not an in-progress delivery"})
+			case usecase.ErrInvalidDeliveryCode:
+				return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			case usecase.ErrDeliveryLocked:
+				return c.JSON(http.StatusLocked, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to confirm delivery"})
+		}
+		return c.JSON(http.StatusOK, visibleRide(c, ride))
 	}
 }
 
@@ -64,6 +143,9 @@ type PlaceBidRequest struct {
 	Price float64 `json:"price"`
 }
 
+// PlaceBid — verified drivers only; 403 {"error","reason"} with reason not_driver,
+// verification_missing, verification_pending, verification_rejected, documents_missing
+// or documents_expired
 func PlaceBid(uc RideUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		rideID := c.Param("id")
@@ -72,20 +154,90 @@ func PlaceBid(uc RideUseCase) echo.HandlerFunc {
 		if err := c.Bind(&req); err != nil {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
 		}
-		bid, err := uc.PlaceBid(c.Request().Context(), rideID, driverID, req.Price)
+		bid, err := uc.PlaceBid(c.Request().Context(), rideID, driverID, c.Get(UserRoleKey).(string), req.Price)
 		if err != nil {
+			var ineligible *usecase.DriverIneligibleError
+			if errors.As(err, &ineligible) {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"error":  usecase.ErrDriverIneligible.Error(),
+					"reason": ineligible.Reason,
+				})
+			}
 			if err == usecase.ErrRideNotFound {
 				return c.JSON(http.StatusNotFound, map[string]string{"error": "ride not found"})
 			}
-			if err == usecase.ErrRideNotBidding {
+			if err == usecase.ErrRideNotBidding || err == usecase.ErrBidNotPending {
 				return c.JSON(http.StatusConflict, map[string]string{"error": "ride is not accepting bids"})
 			}
+			if err == usecase.ErrVehicleMismatch || err == usecase.ErrCategoryNotAllowed {
+				return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+			}
+			var rangeErr *usecase.BidRangeError
+			if errors.As(err, &rangeErr) {
+				return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+					"error":   err.Error(),
+					"floor":   rangeErr.Floor,
+					"ceiling": rangeErr.Ceiling,
+				})
+			}
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to place bid"})
 		}
 		return c.JSON(http.StatusCreated, bid)
 	}
 }
 
+// WithdrawBid — DELETE /api/v1/rides/:id/bids/:bid_id — the bidding driver takes a pending bid back
+func WithdrawBid(uc RideUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		bid, err := uc.WithdrawBid(c.Request().Context(), c.Param("id"), c.Param("bid_id"), c.Get(UserIDKey).(string))
+		if err != nil {
+			return bidNegotiationError(c, err)
+		}
+		return c.JSON(http.StatusOK, bid)
+	}
+}
+
+// CounterBidRequest — POST /api/v1/rides/:id/bids/:bid_id/counter
+type CounterBidRequest struct {
+	Price float64 `json:"price"`
+}
+
+// CounterBid — passenger's counter-offer on a pending bid; the driver answers with
+// POST /rides/:id/bids (updates their bid) or withdraws
+func CounterBid(uc RideUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req CounterBidRequest
+		if err := c.Bind(&req); err != nil || req.Price <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "price required"})
+		}
+		bid, err := uc.CounterBid(c.Request().Context(), c.Param("id"), c.Param("bid_id"), c.Get(UserIDKey).(string), req.Price)
+		if err != nil {
+			var rangeErr *usecase.BidRangeError
+			if errors.As(err, &rangeErr) {
+				return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+					"error":   err.Error(),
+					"floor":   rangeErr.Floor,
+					"ceiling": rangeErr.Ceiling,
+				})
+			}
+			return bidNegotiationError(c, err)
+		}
+		return c.JSON(http.StatusOK, bid)
+	}
+}
+
+func bidNegotiationError(c echo.Context, err error) error {
+	switch err {
+	case usecase.ErrRideNotFound, usecase.ErrBidNotFound:
+		return c.JSON(http.StatusNotFound, map[string]string{"error": "ride or bid not found"})
+	case usecase.ErrNotPassenger:
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	case usecase.ErrRideNotBidding, usecase.ErrBidNotPending:
+		return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update bid"})
+}
+
 func ListBids(uc RideUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		rideID := c.Param("id")
@@ -118,15 +270,23 @@ func AcceptBid(uc RideUseCase) echo.HandlerFunc {
 			if err == usecase.ErrNotPassenger {
 				return c.JSON(http.StatusForbidden, map[string]string{"error": "not the ride passenger"})
 			}
+			if err == usecase.ErrInvalidStatus {
+				return c.JSON(http.StatusConflict, map[string]string{"error": "ride is no longer open for bids"})
+			}
+			if err == usecase.ErrBidNotPending {
+				return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+			}
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to accept bid"})
 		}
 		return c.JSON(http.StatusOK, ride)
 	}
 }
 
-// UpdateStatusRequest — PATCH /api/v1/rides/:id/status
+// UpdateStatusRequest — PATCH /api/v1/rides/:id/status; pin — the passenger's pickup PIN
+// when the driver starts a PIN-protected ride
 type UpdateStatusRequest struct {
 	Status string `json:"status"`
+	PIN    string `json:"pin"`
 }
 
 func UpdateRideStatus(uc RideUseCase) echo.HandlerFunc {
@@ -138,7 +298,7 @@ func UpdateRideStatus(uc RideUseCase) echo.HandlerFunc {
 		if err := c.Bind(&req); err != nil || req.Status == "" {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "status required"})
 		}
-		ride, err := uc.UpdateStatus(c.Request().Context(), rideID, req.Status, userID, userRole)
+		ride, err := uc.UpdateStatus(c.Request().Context(), rideID, req.Status, userID, userRole, req.PIN)
 		if err != nil {
 			if err == usecase.ErrRideNotFound {
 				return c.JSON(http.StatusNotFound, map[string]string{"error": "ride not found"})
@@ -149,9 +309,18 @@ func UpdateRideStatus(uc RideUseCase) echo.HandlerFunc {
 			if err == usecase.ErrInvalidStatus {
 				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid status"})
 			}
+			if err == usecase.ErrDeliveryCodeRequired || err == usecase.ErrPickupPINRequired {
+				return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+			}
+			if err == usecase.ErrInvalidPickupPIN {
+				return c.JSON(http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			}
+			if err == usecase.ErrPickupPINLocked {
+				return c.JSON(http.StatusLocked, map[string]string{"error": err.Error()})
+			}
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update status"})
 		}
-		return c.JSON(http.StatusOK, ride)
+		return c.JSON(http.StatusOK, visibleRide(c, ride))
 	}
 }
 
@@ -170,23 +339,24 @@ func ListMyRides(uc RideUseCase) echo.HandlerFunc {
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list rides"})
 		}
-		return c.JSON(http.StatusOK, map[string]interface{}{"rides": rides})
+		return c.JSON(http.StatusOK, map[string]interface{}{"rides": visibleRides(c, rides)})
 	}
 }
 
-// ListAvailableRides — GET /api/v1/rides/available (driver only: open rides to bid)
-func ListAvailableRides(uc RideUseCase) echo.HandlerFunc {
+// ListAvailableRides — GET /api/v1/rides/available?lat=&lng= (driver only: open rides to bid,
+// filtered by the driver's destination when destination mode is on)
+func ListAvailableRides(uc DestinationUseCase) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		userRole := c.Get(UserRoleKey).(string)
 		if userRole != "driver" {
 			return c.JSON(http.StatusForbidden, map[string]string{"error": "driver only"})
 		}
 		limit, _ := strconv.Atoi(c.QueryParam("limit"))
-		rides, err := uc.ListOpenRides(c.Request().Context(), limit)
+		rides, err := uc.ListOpenRides(c.Request().Context(), c.Get(UserIDKey).(string), queryPoint(c), limit)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list available rides"})
 		}
-		return c.JSON(http.StatusOK, map[string]interface{}{"rides": rides})
+		return c.JSON(http.StatusOK, map[string]interface{}{"rides": visibleRides(c, rides)})
 	}
 }
 
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/usecase"
+)
+
+type SafetyUseCase interface {
+	RaiseSOS(ctx context.Context, rideID, userID, message string, location *domain.Point) (*domain.SOSAlert, error)
+	ListAlerts(ctx context.Context, activeOnly bool, limit int) ([]*domain.SOSAlert, error)
+	UpdateAlert(ctx context.Context, alertID, status, adminID string) (*domain.SOSAlert, error)
+	CreateShareLink(ctx context.Context, rideID, userID string) (*domain.ShareLink, error)
+	ListShareLinks(ctx context.Context, rideID, userID string) ([]*domain.ShareLink, error)
+	RevokeShareLink(ctx context.Context, rideID, linkID, userID string) error
+	ViewSharedTrip(ctx context.Context, token string) (*domain.TripShareView, error)
+}
+
+// SOSRequest — POST /api/v1/rides/:id/sos — {"message":"...","location":{"lat":55.7,"lng":37.6}}
+type SOSRequest struct {
+	Message  string        `json:"message"`
+	Location *domain.Point `json:"location"`
+}
+
+// RaiseSOS — passenger or matched driver raises an emergency; admins get a high-priority alert
+func RaiseSOS(uc SafetyUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req SOSRequest
+		_ = c.Bind(&req) // an empty or malformed body still raises the alert
+		alert, err := uc.RaiseSOS(c.Request().Context(), c.Param("id"), c.Get(UserIDKey).(string), req.Message, req.Location)
+		if err != nil {
+			return participantError(c, err, "failed to raise SOS")
+		}
+		return c.JSON(http.StatusCreated, alert)
+	}
+}
+
+// ListSOSAlerts — GET /api/v1/admin/sos?all=true&limit=50 (admin only; open and acknowledged by default)
+func ListSOSAlerts(uc SafetyUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get(UserRoleKey).(string) != "admin" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin only"})
+		}
+		limit, _ := strconv.Atoi(c.QueryParam("limit"))
+		alerts, err := uc.ListAlerts(c.Request().Context(), c.QueryParam("all") != "true", limit)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list alerts"})
+		}
+		if alerts == nil {
+			alerts = []*domain.SOSAlert{}
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"alerts": alerts})
+	}
+}
+
+// UpdateSOSAlertRequest — PATCH /api/v1/admin/sos/:id — {"status":"acknowledged|resolved"}
+type UpdateSOSAlertRequest struct {
+	Status string `json:"status"`
+}
+
+// UpdateSOSAlert — admin acknowledges or resolves an alert
+func UpdateSOSAlert(uc SafetyUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get(UserRoleKey).(string) != "admin" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin only"})
+		}
+		var req UpdateSOSAlertRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		}
+		alert, err := uc.UpdateAlert(c.Request().Context(), c.Param("id"), req.Status, c.Get(UserIDKey).(string))
+		if err != nil {
+			if err == usecase.ErrInvalidAlertState {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+			if err == usecase.ErrAlertNotFound {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update alert"})
+		}
+		return c.JSON(http.StatusOK, alert)
+	}
+}
+
+// CreateShareLink — POST /api/v1/rides/:id/share — public link for a trusted contact;
+// the token is only returned here
+func CreateShareLink(uc SafetyUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		link, err := uc.CreateShareLink(c.Request().Context(), c.Param("id"), c.Get(UserIDKey).(string))
+		if err != nil {
+			if err == usecase.ErrRideEnded {
+				return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+			}
+			return participantError(c, err, "failed to create link")
+		}
+		return c.JSON(http.StatusCreated, link)
+	}
+}
+
+// ListShareLinks — GET /api/v1/rides/:id/share — the caller's links for the ride
+func ListShareLinks(uc SafetyUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		links, err := uc.ListShareLinks(c.Request().Context(), c.Param("id"), c.Get(UserIDKey).(string))
+		if err != nil {
+			return participantError(c, err, "failed to list links")
+		}
+		if links == nil {
+			links = []*domain.ShareLink{}
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"links": links})
+	}
+}
+
+// RevokeShareLink — DELETE /api/v1/rides/:id/share/:link_id
+func RevokeShareLink(uc SafetyUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := uc.RevokeShareLink(c.Request().Context(), c.Param("id"), c.Param("link_id"), c.Get(UserIDKey).(string))
+		if err != nil {
+			if err == usecase.ErrShareLinkNotFound {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to revoke link"})
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+}
+
+// ViewSharedTrip — GET /api/v1/public/trips/:token (no auth) — live trip status and
+// driver position for a trusted contact; 404 once the link is revoked or expired
+func ViewSharedTrip(uc SafetyUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		view, err := uc.ViewSharedTrip(c.Request().Context(), c.Param("token"))
+		if err != nil {
+			if err == usecase.ErrShareLinkNotFound {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to load trip"})
+		}
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+		return c.JSON(http.StatusOK, view)
+	}
+}
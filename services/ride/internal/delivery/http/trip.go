@@ -0,0 +1,217 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/usecase"
+)
+
+type TripUseCase interface {
+	PublishTrip(ctx context.Context, in usecase.PublishTripInput) (*domain.SharedTrip, error)
+	GetTrip(ctx context.Context, id, userID, userRole string) (*domain.SharedTrip, error)
+	SearchTrips(ctx context.Context, in usecase.SearchTripsInput) ([]domain.TripMatch, error)
+	BookSeats(ctx context.Context, in usecase.BookSeatsInput) (*domain.SeatBooking, error)
+	CancelBooking(ctx context.Context, tripID, bookingID, passengerID string) error
+	UpdateTripStatus(ctx context.Context, tripID, status, userID, userRole string) (*domain.SharedTrip, error)
+	ListMyTrips(ctx context.Context, userID, userRole string, limit int) ([]*domain.SharedTrip, error)
+}
+
+// PublishTripRequest — POST /api/v1/trips
+// route: origin, optional stops, destination; seat_price is per seat for the whole route
+type PublishTripRequest struct {
+	Category  string         `json:"category"`
+	Route     []domain.Point `json:"route"`
+	DepartAt  time.Time      `json:"depart_at"`
+	Capacity  int            `json:"capacity"`
+	SeatPrice float64        `json:"seat_price"`
+}
+
+// PublishTrip — driver only
+func PublishTrip(uc TripUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get(UserRoleKey).(string) != "driver" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "driver only"})
+		}
+		var req PublishTripRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		}
+		trip, err := uc.PublishTrip(c.Request().Context(), usecase.PublishTripInput{
+			DriverID:  c.Get(UserIDKey).(string),
+			Category:  req.Category,
+			Route:     req.Route,
+			DepartAt:  req.DepartAt,
+			Capacity:  req.Capacity,
+			SeatPrice: req.SeatPrice,
+		})
+		if err != nil {
+			var rangeErr *usecase.BidRangeError
+			switch {
+			case errors.As(err, &rangeErr):
+				return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+					"error":   "seat price outside the allowed range",
+					"floor":   rangeErr.Floor,
+					"ceiling": rangeErr.Ceiling,
+				})
+			case err == usecase.ErrInvalidTrip || err == usecase.ErrTripsNotAllowed || err == domain.ErrUnknownCategory:
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			case err == usecase.ErrCategoryNotAllowed:
+				return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to publish trip"})
+		}
+		return c.JSON(http.StatusCreated, trip)
+	}
+}
+
+// SearchTrips — GET /api/v1/trips/search?from_lat=&from_lng=&to_lat=&to_lng=&seats=&after=&before=&category=
+func SearchTrips(uc TripUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		from, errFrom := queryCoords(c, "from_lat", "from_lng")
+		to, errTo := queryCoords(c, "to_lat", "to_lng")
+		if errFrom != nil || errTo != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "from_lat, from_lng, to_lat, to_lng required"})
+		}
+		in := usecase.SearchTripsInput{From: from, To: to, Category: c.QueryParam("category")}
+		in.Seats, _ = strconv.Atoi(c.QueryParam("seats"))
+		in.Limit, _ = strconv.Atoi(c.QueryParam("limit"))
+		in.After, _ = time.Parse(time.RFC3339, c.QueryParam("after"))
+		in.Before, _ = time.Parse(time.RFC3339, c.QueryParam("before"))
+		matches, err := uc.SearchTrips(c.Request().Context(), in)
+		if err != nil {
+			if err == usecase.ErrInvalidStatus || err == usecase.ErrTripsNotAllowed || err == domain.ErrUnknownCategory {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to search trips"})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"trips": matches})
+	}
+}
+
+// GetTrip — GET /api/v1/trips/:id (bookings of others hidden from passengers)
+func GetTrip(uc TripUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		trip, err := uc.GetTrip(c.Request().Context(), c.Param("id"), c.Get(UserIDKey).(string), c.Get(UserRoleKey).(string))
+		if err != nil {
+			if err == usecase.ErrTripNotFound {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "trip not found"})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get trip"})
+		}
+		return c.JSON(http.StatusOK, trip)
+	}
+}
+
+// ListMyTrips — GET /api/v1/trips?limit= (driver: published; passenger: booked)
+func ListMyTrips(uc TripUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		limit, _ := strconv.Atoi(c.QueryParam("limit"))
+		trips, err := uc.ListMyTrips(c.Request().Context(), c.Get(UserIDKey).(string), c.Get(UserRoleKey).(string), limit)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to list trips"})
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"trips": trips})
+	}
+}
+
+// BookSeatsRequest — POST /api/v1/trips/:id/bookings
+type BookSeatsRequest struct {
+	Seats   int          `json:"seats"`
+	Pickup  domain.Point `json:"pickup"`
+	Dropoff domain.Point `json:"dropoff"`
+}
+
+// BookSeats — passenger only
+func BookSeats(uc TripUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if c.Get(UserRoleKey).(string) != "passenger" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "passenger only"})
+		}
+		var req BookSeatsRequest
+		if err := c.Bind(&req); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		}
+		booking, err := uc.BookSeats(c.Request().Context(), usecase.BookSeatsInput{
+			TripID:      c.Param("id"),
+			PassengerID: c.Get(UserIDKey).(string),
+			Seats:       req.Seats,
+			Pickup:      req.Pickup,
+			Dropoff:     req.Dropoff,
+		})
+		if err != nil {
+			switch err {
+			case usecase.ErrTripNotFound:
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "trip not found"})
+			case usecase.ErrInvalidStatus:
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid coordinates"})
+			case usecase.ErrInvalidSeats, usecase.ErrOffRoute, usecase.ErrOwnTrip:
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			case usecase.ErrTripNotOpen, usecase.ErrNotEnoughSeats, usecase.ErrAlreadyBooked:
+				return c.JSON(http.StatusConflict, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to book seats"})
+		}
+		return c.JSON(http.StatusCreated, booking)
+	}
+}
+
+// CancelBooking — DELETE /api/v1/trips/:id/bookings/:booking_id (booking passenger, before departure)
+func CancelBooking(uc TripUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		err := uc.CancelBooking(c.Request().Context(), c.Param("id"), c.Param("booking_id"), c.Get(UserIDKey).(string))
+		if err != nil {
+			switch err {
+			case usecase.ErrTripNotFound, usecase.ErrBookingNotFound:
+				return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			case usecase.ErrTripNotOpen:
+				return c.JSON(http.StatusConflict, map[string]string{"error": "booking can no longer be cancelled"})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to cancel booking"})
+		}
+		return c.JSON(http.StatusOK, map[string]string{"status": "cancelled"})
+	}
+}
+
+// UpdateTripStatus — PATCH /api/v1/trips/:id/status — {"status":"in_progress|completed|cancelled"} (trip driver or admin)
+func UpdateTripStatus(uc TripUseCase) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var req UpdateStatusRequest
+		if err := c.Bind(&req); err != nil || req.Status == "" {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "status required"})
+		}
+		trip, err := uc.UpdateTripStatus(c.Request().Context(), c.Param("id"), req.Status,
+			c.Get(UserIDKey).(string), c.Get(UserRoleKey).(string))
+		if err != nil {
+			switch err {
+			case usecase.ErrTripNotFound:
+				return c.JSON(http.StatusNotFound, map[string]string{"error": "trip not found"})
+			case usecase.ErrNotDriver:
+				return c.JSON(http.StatusForbidden, map[string]string{"error": "not the trip driver"})
+			case usecase.ErrInvalidStatus:
+				return c.JSON(http.StatusConflict, map[string]string{"error": "invalid status transition"})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to update trip"})
+		}
+		return c.JSON(http.StatusOK, trip)
+	}
+}
+
+// queryCoords parses a required lat/lng query pair
+func queryCoords(c echo.Context, latKey, lngKey string) (domain.Point, error) {
+	lat, err := strconv.ParseFloat(c.QueryParam(latKey), 64)
+	if err != nil {
+		return domain.Point{}, err
+	}
+	lng, err := strconv.ParseFloat(c.QueryParam(lngKey), 64)
+	if err != nil {
+		return domain.Point{}, err
+	}
+	return domain.Point{Lat: lat, Lng: lng}, nil
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+// Ride field visibility. What a viewer sees of a ride depends on their relation to it:
+//
+//	owner (passenger, admin)  everything
+//	matched driver            precise pickup and dropoff; no delivery code, PIN or auto-accept rules
+//	anyone else               as the matched driver, with pickup and dropoff coarsened and
+//	                          no courier sender and recipient contacts
+//
+// Drivers bidding on an open ride see only the area: coordinates rounded to
+// coarsePrecision decimals and the address reduced to its district-level parts.
+type rideVisibility int
+
+const (
+	visibilityPublic rideVisibility = iota
+	visibilityMatchedDriver
+	visibilityOwner
+)
+
+// coarsePrecision — decimals kept of coarsened coordinates (0.01° ≈ 1.1 km)
+const coarsePrecision = 2
+
+func rideVisibilityFor(c echo.Context, ride *domain.Ride) rideVisibility {
+	userID := c.Get(UserIDKey)
+	switch {
+	case c.Get(UserRoleKey) == "admin" || userID == ride.PassengerID:
+		return visibilityOwner
+	case ride.DriverID != "" && userID == ride.DriverID:
+		return visibilityMatchedDriver
+	}
+	return visibilityPublic
+}
+
+// visibleRide applies the visibility policy for the requesting user
+func visibleRide(c echo.Context, ride *domain.Ride) *domain.Ride {
+	userID, _ := c.Get(UserIDKey).(string)
+	switch rideVisibilityFor(c, ride) {
+	case visibilityOwner:
+		return ride
+	case visibilityMatchedDriver:
+		return ride.Redacted(userID)
+	}
+	out := ride.Redacted(userID)
+	out.From, out.To = coarsePoint(ride.From), coarsePoint(ride.To)
+	return out
+}
+
+func visibleRides(c echo.Context, rides []*domain.Ride) []*domain.Ride {
+	out := make([]*domain.Ride, len(rides))
+	for i, r := range rides {
+		out[i] = visibleRide(c, r)
+	}
+	return out
+}
+
+func coarsePoint(p domain.Point) domain.Point {
+	scale := math.Pow(10, coarsePrecision)
+	return domain.Point{
+		Lat:     math.Round(p.Lat*scale) / scale,
+		Lng:     math.Round(p.Lng*scale) / scale,
+		Address: coarseAddress(p.Address),
+	}
+}
+
+// coarseAddress keeps the district-level parts of a comma-separated address: parts
+// with digits (house numbers, postcodes) are dropped, then the street — the first
+// remaining part — when more than district and city are left.
+// "Tverskaya St 7, Tverskoy District, Moscow" → "Tverskoy District, Moscow".
+func coarseAddress(addr string) string {
+	var parts []string
+	for _, p := range strings.Split(addr, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" || strings.IndexFunc(p, unicode.IsDigit) >= 0 {
+			continue
+		}
+		parts = append(parts, p)
+	}
+	if len(parts) > 2 {
+		parts = parts[1:]
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+func viewAs(userID, role string) echo.Context {
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Set(UserIDKey, userID)
+	c.Set(UserRoleKey, role)
+	return c
+}
+
+func TestVisibleRide_CoarsensPickupUntilMatched(t *testing.T) {
+	ride := &domain.Ride{
+		ID: "r1", PassengerID: "p1", Status: domain.StatusBidding,
+		From:      domain.Point{Lat: 55.757812, Lng: 37.615123, Address: "Tverskaya St 7, Tverskoy District, Moscow, 125009"},
+		To:        domain.Point{Lat: 55.729011, Lng: 37.601456, Address: "Gorky Park, Moscow"},
+		PickupPIN: &domain.PickupPIN{Code: "0427"},
+	}
+
+	if got := visibleRide(viewAs("p1", "passenger"), ride); got != ride {
+		t.Error("the passenger sees the ride as is")
+	}
+	if got := visibleRide(viewAs("a1", "admin"), ride); got != ride {
+		t.Error("admins see the ride as is")
+	}
+
+	got := visibleRide(viewAs("d1", "driver"), ride)
+	if got.From.Lat != 55.76 || got.From.Lng != 37.62 || got.From.Address != "Tverskoy District, Moscow" {
+		t.Errorf("pickup must be coarse, got %+v", got.From)
+	}
+	if got.To.Address != "Gorky Park, Moscow" {
+		t.Errorf("dropoff: %+v", got.To)
+	}
+	if got.PickupPIN.Code != "" || ride.From.Lat != 55.757812 {
+		t.Error("the view must be redacted without touching the ride")
+	}
+
+	ride.DriverID, ride.Status = "d1", domain.StatusMatched
+	if got := visibleRide(viewAs("d1", "driver"), ride); got.From != ride.From || got.PickupPIN.Code != "" {
+		t.Errorf("the matched driver gets the precise pickup only: %+v", got)
+	}
+	if got := visibleRide(viewAs("d2", "driver"), ride); got.From.Lat != 55.76 {
+		t.Error("other drivers keep the coarse pickup")
+	}
+}
+
+func TestVisibleRide_HidesCourierContactsUntilMatched(t *testing.T) {
+	ride := &domain.Ride{
+		ID: "r1", PassengerID: "p1", Status: domain.StatusBidding, Category: domain.CategoryCourier,
+		Delivery: &domain.Delivery{
+			Sender:    domain.Contact{Name: "Anna", Phone: "+79991234567"},
+			Recipient: domain.Contact{Name: "Oleg", Phone: "+79991234568"},
+			Comment:   "3rd floor", Code: "4821",
+		},
+	}
+
+	got := visibleRide(viewAs("d1", "driver"), ride).Delivery
+	if got.Sender != (domain.Contact{}) || got.Recipient != (domain.Contact{}) || got.Code != "" || got.Comment != "3rd floor" {
+		t.Errorf("drivers browsing open rides must not see the contacts: %+v", got)
+	}
+	if ride.Delivery.Sender.Phone == "" {
+		t.Error("the view must be redacted without touching the ride")
+	}
+
+	ride.DriverID, ride.Status = "d1", domain.StatusMatched
+	if got := visibleRide(viewAs("d1", "driver"), ride).Delivery; got.Sender.Phone != "+79991234567" ||
+		got.Recipient.Name != "Oleg" || got.Code != "" {
+		t.Errorf("the matched driver gets the contacts, not the code: %+v", got)
+	}
+	if got := visibleRide(viewAs("d2", "driver"), ride).Delivery; got.Recipient != (domain.Contact{}) {
+		t.Error("other drivers still see no contacts")
+	}
+}
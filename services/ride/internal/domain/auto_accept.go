@@ -0,0 +1,40 @@
+package domain
+
+import "errors"
+
+var ErrInvalidAutoAccept = errors.New("invalid auto-accept rules")
+
+// AutoAccept — passenger's rules for accepting the first qualifying bid without review.
+// Zero fields are not checked; at least one rule must be set.
+type AutoAccept struct {
+	MaxPrice      float64 `json:"max_price,omitempty"`       // total fare (all seats)
+	MinRating     float64 `json:"min_rating,omitempty"`      // driver's average score, 1..5
+	MaxETAMinutes int     `json:"max_eta_minutes,omitempty"` // driver to pickup
+}
+
+// Validate checks the rules are set and within range
+func (a AutoAccept) Validate() error {
+	if a.MaxPrice < 0 || a.MinRating < 0 || a.MinRating > 5 || a.MaxETAMinutes < 0 {
+		return ErrInvalidAutoAccept
+	}
+	if a.MaxPrice == 0 && a.MinRating == 0 && a.MaxETAMinutes == 0 {
+		return ErrInvalidAutoAccept
+	}
+	return nil
+}
+
+// Qualifies reports whether a bid passes every rule: total is the fare the passenger
+// would pay, rating and etaMinutes may be nil when unknown — an unknown value fails
+// the rule that needs it (drivers without ratings never pass a rating minimum).
+func (a AutoAccept) Qualifies(total float64, rating *UserRating, etaMinutes *int) bool {
+	if a.MaxPrice > 0 && total > a.MaxPrice {
+		return false
+	}
+	if a.MinRating > 0 && (rating == nil || rating.TotalRatings == 0 || rating.AverageScore < a.MinRating) {
+		return false
+	}
+	if a.MaxETAMinutes > 0 && (etaMinutes == nil || *etaMinutes > a.MaxETAMinutes) {
+		return false
+	}
+	return true
+}
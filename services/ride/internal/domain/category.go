@@ -0,0 +1,208 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/alexevil1979/indrive/packages/money-go"
+)
+
+// Ride categories
+const (
+	CategoryEconomy   = "economy"
+	CategoryComfort   = "comfort"
+	CategoryCargo     = "cargo"
+	CategoryCourier   = "courier"
+	CategoryIntercity = "intercity"
+	CategoryCommuter  = "commuter"
+)
+
+// Driver verification document types (user service) a category may require
+const (
+	DocLicense      = "license"
+	DocPassport     = "passport"
+	DocVehicleReg   = "vehicle_reg"
+	DocInsurance    = "insurance"
+	DocPhoto        = "photo"
+	DocVehiclePhoto = "vehicle_photo"
+)
+
+var ErrUnknownCategory = errors.New("unknown ride category")
+
+// CategoryRules — per-category ride rules. For seat-priced categories fares and bid
+// bounds are per seat; the ride price is the per-seat price times seats.
+type CategoryRules struct {
+	Category        string   `json:"category"`
+	VehicleClasses  []string `json:"vehicle_classes"`  // driver vehicle classes allowed to take the ride
+	RequiredDocs    []string `json:"required_docs"`    // approved verification documents a driver needs
+	MinFare         float64  `json:"min_fare"`         // lowest bid accepted
+	BidFloorPerKM   float64  `json:"bid_floor_per_km"` // bid floor = max(min_fare, km × floor)
+	BidCeilingPerKM float64  `json:"bid_ceiling_per_km"`
+	MaxPrebookHours int      `json:"max_prebook_hours"` // how far ahead a ride may be scheduled (0 = immediate only)
+	SeatPricing     bool     `json:"seat_pricing"`
+	MaxSeats        int      `json:"max_seats"`
+	Delivery        bool     `json:"delivery"`     // parcel: sender/recipient contacts + delivery code
+	SharedTrips     bool     `json:"shared_trips"` // drivers may publish trips with seats for booking
+}
+
+// passengerClasses — passenger car classes (cargo vans never take passenger rides)
+var passengerClasses = []string{VehicleClassEconomy, VehicleClassComfort, VehicleClassBusiness}
+
+var categoryRules = map[string]CategoryRules{
+	CategoryEconomy: {
+		Category:        CategoryEconomy,
+		VehicleClasses:  passengerClasses,
+		RequiredDocs:    []string{DocLicense, DocPhoto},
+		MinFare:         150,
+		BidFloorPerKM:   8,
+		BidCeilingPerKM: 60,
+		MaxPrebookHours: 24,
+		MaxSeats:        1,
+	},
+	CategoryComfort: {
+		Category:        CategoryComfort,
+		VehicleClasses:  []string{VehicleClassComfort, VehicleClassBusiness},
+		RequiredDocs:    []string{DocLicense, DocPhoto, DocVehiclePhoto},
+		MinFare:         250,
+		BidFloorPerKM:   12,
+		BidCeilingPerKM: 90,
+		MaxPrebookHours: 24,
+		MaxSeats:        1,
+	},
+	CategoryCargo: {
+		Category:        CategoryCargo,
+		VehicleClasses:  []string{VehicleClassCargo},
+		RequiredDocs:    []string{DocLicense, DocPhoto, DocVehicleReg, DocInsurance},
+		MinFare:         600,
+		BidFloorPerKM:   20,
+		BidCeilingPerKM: 150,
+		MaxPrebookHours: 72,
+		MaxSeats:        1,
+	},
+	CategoryCourier: {
+		Category:        CategoryCourier,
+		VehicleClasses:  append(append([]string{}, passengerClasses...), VehicleClassCargo),
+		RequiredDocs:    []string{DocLicense, DocPhoto, DocPassport},
+		MinFare:         120,
+		BidFloorPerKM:   6,
+		BidCeilingPerKM: 50,
+		MaxSeats:        1,
+		Delivery:        true,
+	},
+	CategoryIntercity: {
+		Category:        CategoryIntercity,
+		VehicleClasses:  passengerClasses,
+		RequiredDocs:    []string{DocLicense, DocPhoto, DocVehicleReg, DocInsurance},
+		MinFare:         500,
+		BidFloorPerKM:   2,
+		BidCeilingPerKM: 15,
+		MaxPrebookHours: 14 * 24,
+		SeatPricing:     true,
+		MaxSeats:        4,
+		SharedTrips:     true,
+	},
+	CategoryCommuter: {
+		Category:        CategoryCommuter,
+		VehicleClasses:  passengerClasses,
+		RequiredDocs:    []string{DocLicense, DocPhoto},
+		MinFare:         80,
+		BidFloorPerKM:   3,
+		BidCeilingPerKM: 20,
+		MaxPrebookHours: 7 * 24,
+		SeatPricing:     true,
+		MaxSeats:        4,
+		SharedTrips:     true,
+	},
+}
+
+// RulesFor returns rules of a category (empty = economy)
+func RulesFor(category string) (CategoryRules, error) {
+	if category == "" {
+		category = CategoryEconomy
+	}
+	r, ok := categoryRules[category]
+	if !ok {
+		return CategoryRules{}, ErrUnknownCategory
+	}
+	return r, nil
+}
+
+// AllCategoryRules — rules of every category, sorted by name (for apps)
+func AllCategoryRules() []CategoryRules {
+	out := make([]CategoryRules, 0, len(categoryRules))
+	for _, r := range categoryRules {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Category < out[j].Category })
+	return out
+}
+
+// PrebookWindow — how far ahead a ride of the category may be scheduled
+func (r CategoryRules) PrebookWindow() time.Duration {
+	return time.Duration(r.MaxPrebookHours) * time.Hour
+}
+
+// BidRange — accepted bid bounds (per seat for seat-priced categories) for a trip distance
+func (r CategoryRules) BidRange(distanceKM float64) (floor, ceiling float64) {
+	floor = distanceKM * r.BidFloorPerKM
+	if floor < r.MinFare {
+		floor = r.MinFare
+	}
+	ceiling = r.MinFare + distanceKM*r.BidCeilingPerKM
+	return RoundFare(floor), RoundFare(ceiling)
+}
+
+// Total — ride price for an accepted bid
+func (r CategoryRules) Total(bidPrice float64, seats int) float64 {
+	if r.SeatPricing && seats > 1 {
+		return money.FromMajor(bidPrice, FareCurrency).Mul(int64(seats)).Major()
+	}
+	return bidPrice
+}
+
+// AllowsClass reports whether a vehicle class may take rides of the category
+func (r CategoryRules) AllowsClass(class string) bool {
+	return contains(r.VehicleClasses, class)
+}
+
+// Eligible reports whether the driver's approved vehicle may take rides of the category:
+// allowed class and every required document approved. nil vehicle = not eligible.
+func (r CategoryRules) Eligible(v *Vehicle) bool {
+	if v == nil {
+		return false
+	}
+	class := v.Class
+	if class == "" {
+		class = VehicleClassEconomy
+	}
+	if !r.AllowsClass(class) {
+		return false
+	}
+	for _, doc := range r.RequiredDocs {
+		if !contains(v.Documents, doc) {
+			return false
+		}
+	}
+	return true
+}
+
+// EligibleCategories — categories the driver's vehicle may take, sorted
+func EligibleCategories(v *Vehicle) []string {
+	out := []string{}
+	for _, r := range AllCategoryRules() {
+		if r.Eligible(v) {
+			out = append(out, r.Category)
+		}
+	}
+	return out
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
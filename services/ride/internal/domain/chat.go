@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxChatMessageLen — characters per chat message
+const MaxChatMessageLen = 1000
+
+var ErrInvalidChatMessage = errors.New("message needs text (up to 1000 characters) or a known quick reply")
+
+// ChatMessage — message between the ride's passenger and matched driver
+type ChatMessage struct {
+	ID          string     `json:"id"`
+	RideID      string     `json:"ride_id"`
+	SenderID    string     `json:"sender_id"`
+	SenderRole  string     `json:"sender_role"` // passenger or driver
+	RecipientID string     `json:"recipient_id"`
+	Text        string     `json:"text"`
+	QuickReply  string     `json:"quick_reply,omitempty"` // code of a predefined reply; Text holds its text
+	CreatedAt   time.Time  `json:"created_at"`
+	ReadAt      *time.Time `json:"read_at,omitempty"` // read receipt
+}
+
+// QuickReply — predefined message offered to one side of the chat
+type QuickReply struct {
+	Code string `json:"code"`
+	Text string `json:"text"`
+	Role string `json:"role"` // who may send it: passenger or driver
+}
+
+// QuickReplies — predefined messages per role
+var QuickReplies = []QuickReply{
+	{Code: "im_here", Text: "I'm here", Role: "driver"},
+	{Code: "five_minutes", Text: "5 minutes", Role: "driver"},
+	{Code: "stuck_in_traffic", Text: "Stuck in traffic, running late", Role: "driver"},
+	{Code: "cant_find_you", Text: "I can't find you, please call", Role: "driver"},
+	{Code: "coming_out", Text: "Coming out", Role: "passenger"},
+	{Code: "five_minutes", Text: "5 minutes", Role: "passenger"},
+	{Code: "where_are_you", Text: "Where are you?", Role: "passenger"},
+	{Code: "please_wait", Text: "Please wait for me", Role: "passenger"},
+}
+
+// QuickRepliesFor returns the replies a role may send
+func QuickRepliesFor(role string) []QuickReply {
+	var out []QuickReply
+	for _, q := range QuickReplies {
+		if q.Role == role {
+			out = append(out, q)
+		}
+	}
+	return out
+}
+
+// ChatText resolves the message body: a quick reply code (allowed for the role) or
+// free text, trimmed and within MaxChatMessageLen
+func ChatText(role, text, quickReply string) (string, error) {
+	if quickReply != "" {
+		for _, q := range QuickRepliesFor(role) {
+			if q.Code == quickReply {
+				return q.Text, nil
+			}
+		}
+		return "", ErrInvalidChatMessage
+	}
+	text = strings.TrimSpace(text)
+	if text == "" || utf8.RuneCountInString(text) > MaxChatMessageLen {
+		return "", ErrInvalidChatMessage
+	}
+	return text, nil
+}
@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MaxDeliveryAttempts — wrong delivery codes accepted before confirmation is locked
+// (support then completes the ride)
+const MaxDeliveryAttempts = 5
+
+var ErrInvalidContacts = errors.New("sender and recipient need a name and a phone number")
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9]{10,15}$`)
+
+// Contact — person handing over or receiving a parcel
+type Contact struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+}
+
+// Delivery — courier ride details. Code is generated on creation and shown to the
+// sender (ride passenger) only; the recipient tells it to the driver on handover.
+type Delivery struct {
+	Sender      Contact    `json:"sender"`
+	Recipient   Contact    `json:"recipient"`
+	Comment     string     `json:"comment,omitempty"`
+	Code        string     `json:"code,omitempty"`
+	Attempts    int        `json:"attempts,omitempty"` // wrong codes entered
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+}
+
+// Normalize trims names, strips phone formatting and validates both contacts
+func (d Delivery) Normalize() (Delivery, error) {
+	for _, c := range []*Contact{&d.Sender, &d.Recipient} {
+		c.Name = strings.Join(strings.Fields(c.Name), " ")
+		c.Phone = strings.Map(func(r rune) rune {
+			if r == ' ' || r == '-' || r == '(' || r == ')' {
+				return -1
+			}
+			return r
+		}, c.Phone)
+		if c.Name == "" || !phonePattern.MatchString(c.Phone) {
+			return Delivery{}, ErrInvalidContacts
+		}
+	}
+	d.Comment = strings.TrimSpace(d.Comment)
+	d.Code, d.Attempts, d.ConfirmedAt = "", 0, nil
+	return d, nil
+}
+
+// NewDeliveryCode returns a random 4-digit confirmation code
+func NewDeliveryCode() (string, error) {
+	return randomDigits()
+}
+
+// randomDigits returns a random 4-digit code
+func randomDigits() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%04d", n.Int64()), nil
+}
@@ -0,0 +1,34 @@
+// Package domain — Driver "destination mode": only offer rides heading toward a chosen point
+package domain
+
+import "time"
+
+// DriverDestination — destination set by a driver (e.g. home at the end of a shift)
+type DriverDestination struct {
+	DriverID  string    `json:"driver_id"`
+	Point     Point     `json:"point"`
+	Active    bool      `json:"active"`
+	UsesToday int       `json:"uses_today"` // activations since midnight (UTC)
+	UsesLimit int       `json:"uses_limit"` // daily activation limit
+	SetAt     time.Time `json:"set_at"`
+}
+
+// Progress returns the fraction by which the ride dropoff reduces the distance
+// from origin (driver position, or ride pickup if unknown) to the destination.
+// 1 = dropoff at the destination, 0 = no progress, negative = moving away.
+func (d *DriverDestination) Progress(origin Point, ride *Ride) float64 {
+	before := HaversineKM(origin, d.Point)
+	if before <= 0 {
+		return 0
+	}
+	after := HaversineKM(ride.To, d.Point)
+	return (before - after) / before
+}
+
+// Accepts reports whether the ride brings the driver at least minProgress closer to the destination
+func (d *DriverDestination) Accepts(origin Point, ride *Ride, minProgress float64) bool {
+	if d == nil || !d.Active {
+		return true
+	}
+	return d.Progress(origin, ride) >= minProgress
+}
@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+// Driver verification statuses (user service)
+const (
+	VerificationPending  = "pending"
+	VerificationApproved = "approved"
+	VerificationRejected = "rejected"
+)
+
+// Reason codes of the verified-driver gate (403 on bids)
+const (
+	IneligibleNotDriver        = "not_driver"
+	IneligibleNotVerified      = "verification_missing"
+	IneligiblePending          = "verification_pending"
+	IneligibleRejected         = "verification_rejected"
+	IneligibleDocumentsMissing = "documents_missing"
+	IneligibleDocumentsExpired = "documents_expired"
+)
+
+// DriverRequiredDocs — documents every driver needs approved to bid, whatever the category
+var DriverRequiredDocs = []string{DocLicense, DocPhoto}
+
+// DriverEligibility — driver verification snapshot from user service events.
+// Status is empty when the driver never applied; Documents maps approved document
+// types to their expiry (nil = does not expire).
+type DriverEligibility struct {
+	DriverID  string                `json:"driver_id"`
+	Status    string                `json:"status"`
+	Documents map[string]*time.Time `json:"documents"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+// Reason returns why the driver may not bid ("" = eligible): verification approved,
+// DriverRequiredDocs approved, and none of those or categoryDocs expired at now.
+// A nil snapshot means the driver never applied.
+func (e *DriverEligibility) Reason(categoryDocs []string, now time.Time) string {
+	if e == nil {
+		return IneligibleNotVerified
+	}
+	switch e.Status {
+	case VerificationApproved:
+	case VerificationPending:
+		return IneligiblePending
+	case VerificationRejected:
+		return IneligibleRejected
+	default:
+		return IneligibleNotVerified
+	}
+	for _, doc := range DriverRequiredDocs {
+		if _, ok := e.Documents[doc]; !ok {
+			return IneligibleDocumentsMissing
+		}
+	}
+	for doc, expires := range e.Documents {
+		if !contains(DriverRequiredDocs, doc) && !contains(categoryDocs, doc) {
+			continue
+		}
+		if expires != nil && !now.Before(*expires) {
+			return IneligibleDocumentsExpired
+		}
+	}
+	return ""
+}
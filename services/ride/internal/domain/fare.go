@@ -0,0 +1,12 @@
+package domain
+
+import "github.com/alexevil1979/indrive/packages/money-go"
+
+// FareCurrency — rides are priced in rubles
+const FareCurrency = money.RUB
+
+// RoundFare rounds a fare to whole kopecks, the precision fares are stored with
+// (NUMERIC(12,2)); half a kopeck rounds away from zero
+func RoundFare(v float64) float64 {
+	return money.FromMajor(v, FareCurrency).Major()
+}
@@ -0,0 +1,25 @@
+// Package domain — geo helpers (great-circle distance between ride points)
+package domain
+
+import "math"
+
+// EarthRadiusKM — mean Earth radius (WGS84 approximation)
+const EarthRadiusKM = 6371.0
+
+// HaversineKM returns the great-circle distance between two points in km.
+// 2026: straight-line distance is good enough for feed filtering; road
+// distance from a routing engine can replace it behind the same helper.
+func HaversineKM(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKM * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// ValidPoint checks lat in [-90,90], lng in [-180,180]
+func ValidPoint(p Point) bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Ride payment methods
+const (
+	PaymentCash = "cash" // paid to the driver
+	PaymentCard = "card" // a card saved in the payment service, held when the ride is matched
+)
+
+var ErrInvalidPayment = errors.New("payment method must be cash, or card with a saved card_id")
+
+// RidePayment — how the passenger pays for the ride
+type RidePayment struct {
+	Method string `json:"method"`            // cash (default), card
+	CardID string `json:"card_id,omitempty"` // saved payment method (payment service), card only
+}
+
+// Normalize defaults the method to cash and validates the card choice
+func (p RidePayment) Normalize() (RidePayment, error) {
+	p.Method, p.CardID = strings.ToLower(strings.TrimSpace(p.Method)), strings.TrimSpace(p.CardID)
+	switch p.Method {
+	case "", PaymentCash:
+		if p.CardID != "" {
+			return p, ErrInvalidPayment
+		}
+		return RidePayment{Method: PaymentCash}, nil
+	case PaymentCard:
+		if p.CardID == "" {
+			return p, ErrInvalidPayment
+		}
+		return p, nil
+	}
+	return p, ErrInvalidPayment
+}
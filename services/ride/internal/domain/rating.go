@@ -6,7 +6,8 @@ import "time"
 // Rating represents a rating given after a ride
 type Rating struct {
 	ID         string    `json:"id"`
-	RideID     string    `json:"ride_id"`
+	RideID     string    `json:"ride_id,omitempty"`
+	TripID     string    `json:"trip_id,omitempty"` // shared trip (carpooling) instead of a ride
 	FromUserID string    `json:"from_user_id"` // Who gave the rating
 	ToUserID   string    `json:"to_user_id"`   // Who received the rating
 	Role       string    `json:"role"`         // "passenger" or "driver" (role of ToUserID)
@@ -20,22 +20,76 @@ type Point struct {
 }
 
 type Ride struct {
-	ID          string    `json:"id"`
-	PassengerID string    `json:"passenger_id"`
-	DriverID    string    `json:"driver_id,omitempty"`
-	Status      string    `json:"status"`
-	From        Point     `json:"from"`
-	To          Point     `json:"to"`
-	Price       *float64  `json:"price,omitempty"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string      `json:"id"`
+	PassengerID string      `json:"passenger_id"`
+	DriverID    string      `json:"driver_id,omitempty"`
+	Status      string      `json:"status"`
+	From        Point       `json:"from"`
+	To          Point       `json:"to"`
+	Price       *float64    `json:"price,omitempty"` // total fare (per-seat price × seats for intercity)
+	Options     RideOptions `json:"options"`         // required vehicle class/features
+	Category    string      `json:"category"`
+	Seats       int         `json:"seats"`
+	ScheduledAt *time.Time  `json:"scheduled_at,omitempty"` // pre-booked pickup time
+	Delivery    *Delivery   `json:"delivery,omitempty"`     // courier rides only
+	Payment     RidePayment `json:"payment"`
+	AutoAccept  *AutoAccept `json:"auto_accept,omitempty"`  // accept the first qualifying bid
+	PickupPIN   *PickupPIN  `json:"pickup_pin,omitempty"`   // driver enters it to start the ride
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// Redacted returns a copy safe to show viewerID when they are not the passenger (sender)
+// or an admin: the delivery code, pickup PIN, saved card and auto-accept rules (the
+// passenger's price limit) are removed, and the courier contacts too unless viewerID is
+// the matched driver
+func (r *Ride) Redacted(viewerID string) *Ride {
+	out := *r
+	out.AutoAccept = nil
+	out.Payment.CardID = ""
+	if r.PickupPIN != nil {
+		p := *r.PickupPIN
+		p.Code = ""
+		out.PickupPIN = &p
+	}
+	if r.Delivery != nil {
+		d := *r.Delivery
+		d.Code = ""
+		if r.DriverID == "" || viewerID != r.DriverID {
+			d.Sender, d.Recipient = Contact{}, Contact{}
+		}
+		out.Delivery = &d
+	}
+	return &out
 }
 
 type Bid struct {
-	ID        string    `json:"id"`
-	RideID    string    `json:"ride_id"`
-	DriverID  string    `json:"driver_id"`
-	Price     float64   `json:"price"`
-	Status    string    `json:"status"` // pending, accepted, rejected
-	CreatedAt time.Time `json:"created_at"`
+	ID           string    `json:"id"`
+	RideID       string    `json:"ride_id"`
+	DriverID     string    `json:"driver_id"`
+	Price        float64   `json:"price"`
+	Status       string    `json:"status"`                  // pending, accepted, rejected, withdrawn
+	CounterPrice *float64  `json:"counter_price,omitempty"` // passenger's counter-offer; driver answers by bidding again
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// DriverCard — bidder's public profile and approved vehicle (user service)
+type DriverCard struct {
+	DriverID     string `json:"driver_id"`
+	DisplayName  string `json:"display_name"`
+	AvatarURL    string `json:"avatar_url,omitempty"`
+	VehicleModel string `json:"vehicle_model"`
+	VehiclePlate string `json:"vehicle_plate"`
+	VehicleColor string `json:"vehicle_color,omitempty"`
+	VehicleClass string `json:"vehicle_class"`
+}
+
+// BidDetails — bid as shown in the passenger's offer list. Enrichment is best-effort:
+// parts whose source is unavailable (or driver offline, for the ETA) are omitted.
+type BidDetails struct {
+	*Bid
+	Driver     *DriverCard `json:"driver,omitempty"`
+	Rating     *UserRating `json:"rating,omitempty"`      // driver's aggregated rating
+	DistanceKM *float64    `json:"distance_km,omitempty"` // driver to pickup, straight line
+	ETAMinutes *int        `json:"eta_minutes,omitempty"` // driver to pickup, estimated
 }
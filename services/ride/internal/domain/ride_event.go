@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Ride stream event types (SSE GET /rides/:id/events)
+const (
+	EventBidPlaced    = "bid.placed"
+	EventBidUpdated   = "bid.updated"   // driver changed the price (e.g. answering a counter-offer)
+	EventBidWithdrawn = "bid.withdrawn" // driver took the bid back
+	EventBidCountered = "bid.countered" // passenger proposed another price
+	EventRideMatched  = "ride.matched"
+	EventRideStatus   = "ride.status"
+	EventChatMessage  = "chat.message"
+	EventChatRead     = "chat.read"    // recipient read the other side's messages up to "read_at"
+	EventStreamReset  = "stream.reset" // replay gap: client must refetch ride and bids
+)
+
+// RideEvent — one change of a ride pushed to its passenger and matched driver.
+// ID grows per ride (Kafka offset of the ride's partition, or a local sequence) and is
+// the SSE event id clients resume from with Last-Event-ID.
+type RideEvent struct {
+	ID       int64           `json:"-"`
+	RideID   string          `json:"ride_id"`
+	Type     string          `json:"type"`
+	DriverID string          `json:"driver_id,omitempty"` // bidder, on bid events
+	To       string          `json:"to,omitempty"`        // recipient, on chat events (WebSocket relay)
+	Data     json.RawMessage `json:"data"`
+	At       time.Time       `json:"at"`
+}
+
+// NewRideEvent builds an event with data marshalled to JSON
+func NewRideEvent(rideID, typ, driverID string, data interface{}, at time.Time) (RideEvent, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return RideEvent{}, err
+	}
+	return RideEvent{RideID: rideID, Type: typ, DriverID: driverID, Data: b, At: at}, nil
+}
+
+// VisibleTo reports whether a participant may see the event: the passenger sees
+// everything, a driver only ride-level events and bid events of their own bid
+func (e RideEvent) VisibleTo(userID string, ride *Ride) bool {
+	return userID == ride.PassengerID || e.DriverID == "" || e.DriverID == userID
+}
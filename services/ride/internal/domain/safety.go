@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// MaxPickupPINAttempts — wrong PINs accepted before the driver can no longer start the
+// ride with a PIN (the passenger or support then starts it)
+const MaxPickupPINAttempts = 5
+
+// PickupPIN — optional 4-digit code the passenger tells the driver at pickup; the driver
+// enters it to start the ride. Code is shown to the passenger only.
+type PickupPIN struct {
+	Code       string     `json:"code,omitempty"`
+	Attempts   int        `json:"attempts,omitempty"` // wrong PINs entered
+	VerifiedAt *time.Time `json:"verified_at,omitempty"`
+}
+
+// NewPickupPIN returns a PIN with a random code
+func NewPickupPIN() (*PickupPIN, error) {
+	code, err := randomDigits()
+	if err != nil {
+		return nil, err
+	}
+	return &PickupPIN{Code: code}, nil
+}
+
+// SOS alert statuses
+const (
+	SOSOpen         = "open"
+	SOSAcknowledged = "acknowledged"
+	SOSResolved     = "resolved"
+)
+
+// SOSAlert — emergency raised by a ride participant, with the ride as it was at that moment
+type SOSAlert struct {
+	ID           string      `json:"id"`
+	RideID       string      `json:"ride_id"`
+	ReporterID   string      `json:"reporter_id"`
+	ReporterRole string      `json:"reporter_role"` // passenger or driver
+	Message      string      `json:"message,omitempty"`
+	Snapshot     SOSSnapshot `json:"snapshot"`
+	Status       string      `json:"status"`
+	HandledBy    string      `json:"handled_by,omitempty"` // admin who acknowledged or resolved
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+}
+
+// SOSSnapshot — what support needs to act: the ride, driver and vehicle, last positions.
+// Parts whose source is unavailable are omitted.
+type SOSSnapshot struct {
+	Ride             *Ride       `json:"ride"`
+	Driver           *DriverCard `json:"driver,omitempty"`
+	DriverLocation   *Point      `json:"driver_location,omitempty"`   // geolocation service
+	ReporterLocation *Point      `json:"reporter_location,omitempty"` // sent by the reporter's device
+}
+
+// ShareLink — revocable public link to follow a ride without logging in. Only the
+// token's hash is stored; the token itself is returned once, on creation.
+type ShareLink struct {
+	ID        string     `json:"id"`
+	RideID    string     `json:"ride_id"`
+	CreatedBy string     `json:"created_by"`
+	Token     string     `json:"token,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Active reports whether the link may still be opened at now
+func (l *ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// NewShareToken returns a random URL-safe token and its hash for storage
+func NewShareToken() (token, hash string, err error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashShareToken(token), nil
+}
+
+// HashShareToken — lookup key of a share token
+func HashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TripShareView — public view of a ride behind a share link: no contact details, prices or codes
+type TripShareView struct {
+	Status         string      `json:"status"`
+	From           Point       `json:"from"`
+	To             Point       `json:"to"`
+	Driver         *DriverCard `json:"driver,omitempty"`
+	DriverLocation *Point      `json:"driver_location,omitempty"` // while matched or in progress
+	UpdatedAt      time.Time   `json:"updated_at"`
+	ExpiresAt      time.Time   `json:"expires_at"`
+}
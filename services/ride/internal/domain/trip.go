@@ -0,0 +1,188 @@
+// Package domain — Shared trips (carpooling): a driver publishes a route with free seats,
+// passengers book seats for the part of the route they need
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// Shared trip statuses
+const (
+	TripStatusOpen       = "open"
+	TripStatusInProgress = "in_progress"
+	TripStatusCompleted  = "completed"
+	TripStatusCancelled  = "cancelled"
+)
+
+// Seat booking statuses
+const (
+	BookingStatusBooked    = "booked"
+	BookingStatusCompleted = "completed"
+	BookingStatusCancelled = "cancelled"
+)
+
+// MaxTripStops — route points of a trip, origin and destination included
+const MaxTripStops = 10
+
+// SharedTrip — trip published by a driver. Route is origin, optional stops and destination;
+// seat price is for the whole route and prorated for shorter segments.
+type SharedTrip struct {
+	ID        string         `json:"id"`
+	DriverID  string         `json:"driver_id"`
+	Category  string         `json:"category"` // seat-priced category (intercity, commuter)
+	Status    string         `json:"status"`
+	Route     []Point        `json:"route"`
+	RouteKM   float64        `json:"route_km"`
+	DepartAt  time.Time      `json:"depart_at"`
+	Capacity  int            `json:"capacity"`   // seats offered
+	SeatPrice float64        `json:"seat_price"` // per seat, whole route
+	Bookings  []*SeatBooking `json:"bookings,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// SeatBooking — seats booked by a passenger between two points of a trip's route.
+// PickupKM/DropoffKM are positions along the route; Price is the total for all seats.
+type SeatBooking struct {
+	ID          string    `json:"id"`
+	TripID      string    `json:"trip_id"`
+	PassengerID string    `json:"passenger_id"`
+	Seats       int       `json:"seats"`
+	Pickup      Point     `json:"pickup"`
+	Dropoff     Point     `json:"dropoff"`
+	PickupKM    float64   `json:"pickup_km"`
+	DropoffKM   float64   `json:"dropoff_km"`
+	Price       float64   `json:"price"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TripMatch — published trip that passes near a passenger's pickup and then dropoff
+type TripMatch struct {
+	Trip         *SharedTrip `json:"trip"`
+	PickupKM     float64     `json:"pickup_km"`
+	DropoffKM    float64     `json:"dropoff_km"`
+	OffRouteKM   float64     `json:"off_route_km"` // pickup + dropoff distance from the route
+	SeatsFree    int         `json:"seats_free"`
+	PricePerSeat float64     `json:"price_per_seat"`
+}
+
+// RouteLengthKM — length of the route polyline
+func RouteLengthKM(route []Point) float64 {
+	var km float64
+	for i := 1; i < len(route); i++ {
+		km += HaversineKM(route[i-1], route[i])
+	}
+	return km
+}
+
+// Locate projects p onto the route: at — distance along the route to the closest
+// route point, off — distance from p to it (km). Segments are projected on a local
+// plane, which is accurate enough for pickups a few km off the road.
+func Locate(route []Point, p Point) (at, off float64) {
+	off = math.Inf(1)
+	var start float64
+	for i := 1; i < len(route); i++ {
+		a, b := route[i-1], route[i]
+		segKM := HaversineKM(a, b)
+		t := projectOnSegment(a, b, p)
+		closest := Point{Lat: a.Lat + t*(b.Lat-a.Lat), Lng: a.Lng + t*(b.Lng-a.Lng)}
+		if d := HaversineKM(p, closest); d < off {
+			at, off = start+t*segKM, d
+		}
+		start += segKM
+	}
+	if len(route) == 1 {
+		return 0, HaversineKM(route[0], p)
+	}
+	return at, off
+}
+
+// projectOnSegment returns the position (0..1) of p's projection on segment a–b
+func projectOnSegment(a, b, p Point) float64 {
+	cos := math.Cos(a.Lat * math.Pi / 180)
+	bx, by := (b.Lng-a.Lng)*cos, b.Lat-a.Lat
+	px, py := (p.Lng-a.Lng)*cos, p.Lat-a.Lat
+	l2 := bx*bx + by*by
+	if l2 == 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, (px*bx+py*by)/l2))
+}
+
+// Match reports whether the trip passes within maxOffKM of from and later of to
+func (t *SharedTrip) Match(from, to Point, maxOffKM float64) (TripMatch, bool) {
+	pickupKM, pickupOff := Locate(t.Route, from)
+	dropoffKM, dropoffOff := Locate(t.Route, to)
+	if pickupOff > maxOffKM || dropoffOff > maxOffKM || dropoffKM <= pickupKM {
+		return TripMatch{}, false
+	}
+	return TripMatch{
+		Trip:       t,
+		PickupKM:   pickupKM,
+		DropoffKM:  dropoffKM,
+		OffRouteKM: pickupOff + dropoffOff,
+		SeatsFree:  t.SeatsFree(pickupKM, dropoffKM),
+	}, true
+}
+
+// SeatsFree — seats free over [fromKM, toKM): capacity minus the peak number of
+// booked seats on that part of the route
+func (t *SharedTrip) SeatsFree(fromKM, toKM float64) int {
+	load := func(at float64) int {
+		n := 0
+		for _, b := range t.Bookings {
+			if b.Status == BookingStatusBooked && b.PickupKM <= at && at < b.DropoffKM {
+				n += b.Seats
+			}
+		}
+		return n
+	}
+	peak := load(fromKM)
+	for _, b := range t.Bookings {
+		if b.PickupKM > fromKM && b.PickupKM < toKM {
+			if n := load(b.PickupKM); n > peak {
+				peak = n
+			}
+		}
+	}
+	if free := t.Capacity - peak; free > 0 {
+		return free
+	}
+	return 0
+}
+
+// FareFor — per-seat price for [fromKM, toKM]: seat price prorated by the share of the
+// route, rounded up to whole units, at least minFare but never above the seat price
+func (t *SharedTrip) FareFor(fromKM, toKM, minFare float64) float64 {
+	if t.RouteKM <= 0 {
+		return t.SeatPrice
+	}
+	fare := math.Ceil(t.SeatPrice * (toKM - fromKM) / t.RouteKM)
+	if fare < minFare {
+		fare = minFare
+	}
+	return math.Min(fare, t.SeatPrice)
+}
+
+// Booking returns the passenger's active booking on the trip, nil if none
+func (t *SharedTrip) Booking(passengerID string) *SeatBooking {
+	for _, b := range t.Bookings {
+		if b.PassengerID == passengerID && b.Status != BookingStatusCancelled {
+			return b
+		}
+	}
+	return nil
+}
+
+// Redacted hides other passengers' bookings: a passenger sees only their own
+func (t *SharedTrip) Redacted(passengerID string) *SharedTrip {
+	out := *t
+	out.Bookings = nil
+	if b := t.Booking(passengerID); b != nil {
+		out.Bookings = []*SeatBooking{b}
+	}
+	return &out
+}
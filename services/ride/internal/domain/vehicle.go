@@ -0,0 +1,161 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Vehicle classes. Passenger classes are ranked lowest to highest (a driver serves
+// rides of their class and below); cargo vans only serve cargo.
+const (
+	VehicleClassEconomy  = "economy"
+	VehicleClassComfort  = "comfort"
+	VehicleClassBusiness = "business"
+	VehicleClassCargo    = "cargo"
+)
+
+// Vehicle features a passenger can require
+const (
+	FeatureMinivan     = "minivan"
+	FeatureChildSeat   = "child_seat"
+	FeaturePetFriendly = "pet_friendly"
+	FeatureWheelchair  = "wheelchair"
+)
+
+var ErrInvalidRideOptions = errors.New("invalid vehicle class or feature")
+
+var vehicleClasses = []string{VehicleClassEconomy, VehicleClassComfort, VehicleClassBusiness}
+
+var knownFeatures = map[string]bool{
+	FeatureMinivan:     true,
+	FeatureChildSeat:   true,
+	FeaturePetFriendly: true,
+	FeatureWheelchair:  true,
+}
+
+func classRank(class string) int {
+	for i, c := range vehicleClasses {
+		if c == class {
+			return i
+		}
+	}
+	return -1
+}
+
+// RideOptions — vehicle requirements of a ride; empty = any driver
+type RideOptions struct {
+	VehicleClass string   `json:"vehicle_class,omitempty"`
+	Features     []string `json:"features,omitempty"`
+}
+
+// Normalize validates options: class must be known (empty = any), features are
+// lowercased, deduped and sorted
+func (o RideOptions) Normalize() (RideOptions, error) {
+	out := RideOptions{VehicleClass: strings.ToLower(strings.TrimSpace(o.VehicleClass))}
+	if out.VehicleClass != "" && out.VehicleClass != VehicleClassCargo && classRank(out.VehicleClass) < 0 {
+		return RideOptions{}, ErrInvalidRideOptions
+	}
+	seen := map[string]bool{}
+	for _, f := range o.Features {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || seen[f] {
+			continue
+		}
+		if !knownFeatures[f] {
+			return RideOptions{}, ErrInvalidRideOptions
+		}
+		seen[f] = true
+		out.Features = append(out.Features, f)
+	}
+	sort.Strings(out.Features)
+	return out, nil
+}
+
+// Empty reports whether the ride has no vehicle requirements
+func (o RideOptions) Empty() bool {
+	return (o.VehicleClass == "" || o.VehicleClass == VehicleClassEconomy) && len(o.Features) == 0
+}
+
+// Vehicle — class, features and approved document types from the driver's
+// verification (user service)
+type Vehicle struct {
+	Class     string   `json:"vehicle_class"`
+	Features  []string `json:"features"`
+	Documents []string `json:"documents"`
+}
+
+// Serves reports whether the vehicle meets the ride options. A nil vehicle
+// (driver without approved attributes) serves only rides without requirements.
+func (v *Vehicle) Serves(o RideOptions) bool {
+	if v == nil {
+		return o.Empty()
+	}
+	if o.VehicleClass != "" && !contains(v.ServedClasses(), o.VehicleClass) {
+		return false
+	}
+	for _, f := range o.Features {
+		if !contains(v.Features, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// ServedClasses — ride classes the vehicle can take (its class and below; cargo only cargo)
+func (v *Vehicle) ServedClasses() []string {
+	if v != nil && v.Class == VehicleClassCargo {
+		return []string{VehicleClassCargo}
+	}
+	return vehicleClasses[:v.rank()+1]
+}
+
+// rank — class rank; unknown or missing class counts as economy
+func (v *Vehicle) rank() int {
+	if v == nil || classRank(v.Class) < 0 {
+		return 0
+	}
+	return classRank(v.Class)
+}
+
+// FeedFilter — open rides a driver may see: category they are eligible for,
+// vehicle class they serve and required features they have
+type FeedFilter struct {
+	Categories []string
+	Classes    []string
+	Features   []string
+}
+
+// FeedFilterFor builds the feed filter for a driver's approved vehicle
+// (nil vehicle matches no category)
+func FeedFilterFor(v *Vehicle) *FeedFilter {
+	f := &FeedFilter{Categories: EligibleCategories(v), Classes: v.ServedClasses(), Features: []string{}}
+	if v != nil && v.Features != nil {
+		f.Features = v.Features
+	}
+	return f
+}
+
+// Matches reports whether the ride passes the filter (nil filter passes everything);
+// mirrors the SQL of RideRepo.ListOpenRides
+func (f *FeedFilter) Matches(r *Ride) bool {
+	if f == nil {
+		return true
+	}
+	category := r.Category
+	if category == "" {
+		category = CategoryEconomy
+	}
+	if !contains(f.Categories, category) {
+		return false
+	}
+	if r.Options.VehicleClass != "" && !contains(f.Classes, r.Options.VehicleClass) {
+		return false
+	}
+	for _, feat := range r.Options.Features {
+		if !contains(f.Features, feat) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+// Package batchcache — TTL cache in front of batch lookups in other services.
+// One Get fetches all missing and expired keys with a single call; keys the
+// source does not know are cached as misses so they are not re-fetched every time.
+package batchcache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Fetch looks up many keys at once; keys absent from the result are unknown to the source
+type Fetch[V any] func(ctx context.Context, keys []string) (map[string]V, error)
+
+type entry[V any] struct {
+	v       V
+	found   bool
+	fetched time.Time
+}
+
+// Cache — in-memory values per key, fetched in batches
+type Cache[V any] struct {
+	mu      sync.RWMutex
+	entries map[string]entry[V]
+	fetch   Fetch[V]
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// New creates the cache; entries are re-fetched once older than ttl
+func New[V any](ttl time.Duration, fetch Fetch[V]) *Cache[V] {
+	return &Cache[V]{entries: map[string]entry[V]{}, fetch: fetch, ttl: ttl, now: time.Now}
+}
+
+// Get returns values for keys, fetching missing and expired ones in one call.
+// When the fetch fails, stale entries are served and the error is returned with them.
+func (c *Cache[V]) Get(ctx context.Context, keys []string) (map[string]V, error) {
+	now := c.now()
+	out := make(map[string]V, len(keys))
+	var missing []string
+	seen := make(map[string]bool, len(keys))
+	c.mu.RLock()
+	for _, k := range keys {
+		if k == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		e, ok := c.entries[k]
+		if ok && e.found {
+			out[k] = e.v
+		}
+		if !ok || now.Sub(e.fetched) >= c.ttl {
+			missing = append(missing, k)
+		}
+	}
+	c.mu.RUnlock()
+	if len(missing) == 0 {
+		return out, nil
+	}
+
+	fetched, err := c.fetch(ctx, missing)
+	if err != nil {
+		return out, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range missing {
+		v, ok := fetched[k]
+		c.entries[k] = entry[V]{v: v, found: ok, fetched: now}
+		if ok {
+			out[k] = v
+		} else {
+			delete(out, k)
+		}
+	}
+	c.evictExpired(now)
+	return out, nil
+}
+
+// evictExpired drops entries well past their TTL so the map does not grow with every
+// driver ever seen; called with the write lock held
+func (c *Cache[V]) evictExpired(now time.Time) {
+	for k, e := range c.entries {
+		if now.Sub(e.fetched) >= 2*c.ttl {
+			delete(c.entries, k)
+		}
+	}
+}
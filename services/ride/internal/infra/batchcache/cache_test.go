@@ -0,0 +1,48 @@
+package batchcache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCache_BatchesMissesAndCachesUnknown(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	var calls [][]string
+	var fail error
+	c := New(time.Minute, func(ctx context.Context, keys []string) (map[string]int, error) {
+		calls = append(calls, keys)
+		if fail != nil {
+			return nil, fail
+		}
+		out := map[string]int{}
+		for _, k := range keys {
+			if k != "unknown" {
+				out[k] = len(k)
+			}
+		}
+		return out, nil
+	})
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	got, err := c.Get(ctx, []string{"a", "bb", "a", "unknown", ""})
+	if err != nil || !reflect.DeepEqual(got, map[string]int{"a": 1, "bb": 2}) {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if len(calls) != 1 || !reflect.DeepEqual(calls[0], []string{"a", "bb", "unknown"}) {
+		t.Fatalf("misses must be fetched once, deduplicated: %v", calls)
+	}
+	if got, _ := c.Get(ctx, []string{"a", "unknown", "ccc"}); len(calls) != 2 || !reflect.DeepEqual(calls[1], []string{"ccc"}) || got["ccc"] != 3 {
+		t.Errorf("only new keys must be fetched, unknown ones cached: %v, %v", got, calls)
+	}
+
+	c.now = func() time.Time { return now.Add(90 * time.Second) }
+	fail = errors.New("connection refused")
+	got, err = c.Get(ctx, []string{"a", "bb"})
+	if err == nil || !reflect.DeepEqual(got, map[string]int{"a": 1, "bb": 2}) {
+		t.Errorf("expired entries must be re-fetched and served stale on failure: %v, %v", got, err)
+	}
+}
@@ -0,0 +1,81 @@
+// Package eligibility — local cache of driver verification snapshots for the bid gate.
+// Fed by driver.eligibility.changed events from the user service; missing and expired
+// entries are re-read from the user service API.
+package eligibility
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+// DefaultTTL — how long a snapshot is trusted before it is re-read (covers lost events)
+const DefaultTTL = 10 * time.Minute
+
+// Source — user service eligibility API (nil snapshot when the driver never applied)
+type Source interface {
+	DriverEligibility(ctx context.Context, driverID string) (*domain.DriverEligibility, error)
+}
+
+type entry struct {
+	e       *domain.DriverEligibility
+	fetched time.Time
+}
+
+// Cache — in-memory eligibility snapshots per driver
+type Cache struct {
+	mu       sync.RWMutex
+	entries  map[string]entry
+	fallback Source // optional: without it only event-fed entries are known
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+// NewCache creates the cache; fallback may be nil (events only, entries never expire)
+func NewCache(fallback Source, ttl time.Duration) *Cache {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Cache{entries: map[string]entry{}, fallback: fallback, ttl: ttl, now: time.Now}
+}
+
+// Put stores a snapshot from an event; snapshots older than the cached one are
+// ignored (replays and redeliveries)
+func (c *Cache) Put(e domain.DriverEligibility) {
+	c.store(e.DriverID, &e)
+}
+
+// DriverEligibility returns the cached snapshot, re-reading missing and expired entries
+// from the user service. While the user service is unreachable the stale entry is served.
+func (c *Cache) DriverEligibility(ctx context.Context, driverID string) (*domain.DriverEligibility, error) {
+	c.mu.RLock()
+	cached, ok := c.entries[driverID]
+	c.mu.RUnlock()
+	if ok && (c.fallback == nil || c.now().Sub(cached.fetched) < c.ttl) {
+		return cached.e, nil
+	}
+	if c.fallback == nil {
+		return nil, nil
+	}
+	e, err := c.fallback.DriverEligibility(ctx, driverID)
+	if err != nil {
+		if ok {
+			return cached.e, nil
+		}
+		return nil, err
+	}
+	return c.store(driverID, e), nil
+}
+
+// store keeps the newer of the cached and the given snapshot and returns it
+func (c *Cache) store(driverID string, e *domain.DriverEligibility) *domain.DriverEligibility {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.entries[driverID]; ok && cached.e != nil && (e == nil || e.UpdatedAt.Before(cached.e.UpdatedAt)) {
+		e = cached.e
+	}
+	c.entries[driverID] = entry{e: e, fetched: c.now()}
+	return e
+}
@@ -0,0 +1,47 @@
+package eligibility
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+type fakeSource struct {
+	e     *domain.DriverEligibility
+	err   error
+	calls int
+}
+
+func (f *fakeSource) DriverEligibility(ctx context.Context, driverID string) (*domain.DriverEligibility, error) {
+	f.calls++
+	return f.e, f.err
+}
+
+func TestCache_EventsFallbackAndStaleness(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	src := &fakeSource{e: &domain.DriverEligibility{DriverID: "d1", Status: domain.VerificationPending, UpdatedAt: now}}
+	c := NewCache(src, time.Minute)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if e, err := c.DriverEligibility(ctx, "d1"); err != nil || e.Status != domain.VerificationPending || src.calls != 1 {
+		t.Fatalf("miss must read the user service: %+v, %v, calls=%d", e, err, src.calls)
+	}
+	c.Put(domain.DriverEligibility{DriverID: "d1", Status: domain.VerificationApproved, UpdatedAt: now.Add(time.Second)})
+	c.Put(domain.DriverEligibility{DriverID: "d1", Status: domain.VerificationPending, UpdatedAt: now}) // redelivered
+	if e, _ := c.DriverEligibility(ctx, "d1"); e.Status != domain.VerificationApproved || src.calls != 1 {
+		t.Errorf("event must win over older snapshots without a user service call: %+v, calls=%d", e, src.calls)
+	}
+
+	c.now = func() time.Time { return now.Add(2 * time.Minute) }
+	src.err = errors.New("connection refused")
+	if e, err := c.DriverEligibility(ctx, "d1"); err != nil || e.Status != domain.VerificationApproved || src.calls != 2 {
+		t.Errorf("expired entry must be re-read, stale served on failure: %+v, %v, calls=%d", e, err, src.calls)
+	}
+	if _, err := c.DriverEligibility(ctx, "d2"); err == nil {
+		t.Error("miss with the user service down must fail")
+	}
+}
@@ -0,0 +1,90 @@
+// Package geosvc — HTTP client for the geolocation service (driver positions)
+package geosvc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+// maxLocationsBatch — driver ids per positions request (geolocation limit)
+const maxLocationsBatch = 100
+
+// TokenSource issues bearer tokens for service-to-service calls
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// Client — geolocation service API client
+type Client struct {
+	baseURL string
+	tokens  TokenSource
+	http    *http.Client
+}
+
+// New creates geolocation service client
+func New(baseURL string, tokens TokenSource, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		tokens:  tokens,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+type driverLocationsResponse struct {
+	Drivers []struct {
+		DriverID string `json:"driver_id"`
+		Location struct {
+			Lat float64 `json:"lat"`
+			Lng float64 `json:"lng"`
+		} `json:"location"`
+	} `json:"drivers"`
+}
+
+// DriverLocations returns current positions (GET /api/v1/drivers/locations?ids=);
+// offline drivers and drivers without a recent fix are omitted
+func (c *Client) DriverLocations(ctx context.Context, driverIDs []string) (map[string]domain.Point, error) {
+	out := make(map[string]domain.Point, len(driverIDs))
+	for start := 0; start < len(driverIDs); start += maxLocationsBatch {
+		end := min(start+maxLocationsBatch, len(driverIDs))
+		path := "/api/v1/drivers/locations?ids=" + url.QueryEscape(strings.Join(driverIDs[start:end], ","))
+		var resp driverLocationsResponse
+		if err := c.get(ctx, path, &resp); err != nil {
+			return nil, err
+		}
+		for _, d := range resp.Drivers {
+			out[d.DriverID] = domain.Point{Lat: d.Location.Lat, Lng: d.Location.Lng}
+		}
+	}
+	return out, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, dst interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	token, err := c.tokens.Token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("geolocation service %s: status %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
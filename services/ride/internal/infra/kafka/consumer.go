@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/IBM/sarama"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+// TopicDriverEligibilityChanged — driver verification snapshots (user service), keyed by driver id
+const TopicDriverEligibilityChanged = "driver.eligibility.changed"
+
+// EligibilitySink — local bid gate cache
+type EligibilitySink interface {
+	Put(e domain.DriverEligibility)
+}
+
+// EligibilityConsumer reads every partition of the eligibility topic from the oldest
+// offset, so each ride instance rebuilds its local cache on start (keep the topic
+// compacted by driver id). No consumer group: every instance needs every driver.
+type EligibilityConsumer struct {
+	consumer sarama.Consumer
+	sink     EligibilitySink
+}
+
+func NewEligibilityConsumer(brokers []string, sink EligibilitySink) (*EligibilityConsumer, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Return.Errors = true
+	consumer, err := sarama.NewConsumer(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	return &EligibilityConsumer{consumer: consumer, sink: sink}, nil
+}
+
+// Run consumes all partitions until ctx is cancelled
+func (c *EligibilityConsumer) Run(ctx context.Context) error {
+	return consumeAll(ctx, c.consumer, TopicDriverEligibilityChanged, sarama.OffsetOldest, func(msg *sarama.ConsumerMessage) {
+		var e domain.DriverEligibility
+		if err := json.Unmarshal(msg.Value, &e); err != nil || e.DriverID == "" {
+			slog.Warn("kafka bad eligibility event", "offset", msg.Offset, "error", err)
+			return
+		}
+		c.sink.Put(e)
+	})
+}
+
+func (c *EligibilityConsumer) Close() error {
+	return c.consumer.Close()
+}
+
+// RideEventSink — local SSE hub
+type RideEventSink interface {
+	Publish(e domain.RideEvent)
+}
+
+// RideEventConsumer reads every partition of ride.events from the newest offset and
+// feeds the local hub, so SSE subscribers on any instance see events produced by all
+// of them. No consumer group: every instance needs every ride.
+type RideEventConsumer struct {
+	consumer sarama.Consumer
+	sink     RideEventSink
+}
+
+func NewRideEventConsumer(brokers []string, sink RideEventSink) (*RideEventConsumer, error) {
+	config := sarama.NewConfig()
+	config.Consumer.Return.Errors = true
+	consumer, err := sarama.NewConsumer(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	return &RideEventConsumer{consumer: consumer, sink: sink}, nil
+}
+
+// Run consumes all partitions until ctx is cancelled. Event ids are partition offsets + 1:
+// a ride's events share a partition, so ids grow per ride and agree across instances.
+func (c *RideEventConsumer) Run(ctx context.Context) error {
+	return consumeAll(ctx, c.consumer, TopicRideEvents, sarama.OffsetNewest, func(msg *sarama.ConsumerMessage) {
+		var e domain.RideEvent
+		if err := json.Unmarshal(msg.Value, &e); err != nil || e.RideID == "" {
+			slog.Warn("kafka bad ride event", "offset", msg.Offset, "error", err)
+			return
+		}
+		e.ID = msg.Offset + 1
+		c.sink.Publish(e)
+	})
+}
+
+func (c *RideEventConsumer) Close() error {
+	return c.consumer.Close()
+}
+
+// consumeAll starts a goroutine per partition of topic from offset, handling messages until ctx is cancelled
+func consumeAll(ctx context.Context, consumer sarama.Consumer, topic string, offset int64, handle func(*sarama.ConsumerMessage)) error {
+	partitions, err := consumer.Partitions(topic)
+	if err != nil {
+		return err
+	}
+	for _, p := range partitions {
+		pc, err := consumer.ConsumePartition(topic, p, offset)
+		if err != nil {
+			return err
+		}
+		go consumePartition(ctx, pc, topic, handle)
+	}
+	return nil
+}
+
+func consumePartition(ctx context.Context, pc sarama.PartitionConsumer, topic string, handle func(*sarama.ConsumerMessage)) {
+	defer pc.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-pc.Messages():
+			if !ok {
+				return
+			}
+			handle(msg)
+		case err, ok := <-pc.Errors():
+			if !ok {
+				return
+			}
+			slog.Warn("kafka consume failed", "topic", topic, "error", err)
+		}
+	}
+}
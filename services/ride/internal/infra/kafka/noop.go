@@ -1,9 +1,17 @@
 package kafka
 
-import "context"
+import (
+	"context"
+	"log/slog"
 
-// NoopProducer — when Kafka is not configured (e.g. local dev)
-type NoopProducer struct{}
+	"github.com/ridehail/ride/internal/domain"
+)
+
+// NoopProducer — when Kafka is not configured (e.g. local dev); ride stream events
+// still reach this instance's SSE subscribers through Events
+type NoopProducer struct {
+	Events RideEventSink // optional
+}
 
 func (p *NoopProducer) SendRideRequested(ctx context.Context, rideID, passengerID string, payload interface{}) error {
 	return nil
@@ -13,10 +21,23 @@ func (p *NoopProducer) SendRideBidPlaced(ctx context.Context, rideID, bidID, dri
 	return nil
 }
 
-func (p *NoopProducer) SendRideMatched(ctx context.Context, rideID, driverID string, price float64) error {
+func (p *NoopProducer) SendRideMatched(ctx context.Context, rideID, passengerID, driverID string, price float64, payment domain.RidePayment, auto bool) error {
+	return nil
+}
+
+func (p *NoopProducer) SendRideStatusChanged(ctx context.Context, ride *domain.Ride, status string) error {
+	return nil
+}
+
+func (p *NoopProducer) SendRideEvent(ctx context.Context, e domain.RideEvent) error {
+	if p.Events != nil {
+		p.Events.Publish(e)
+	}
 	return nil
 }
 
-func (p *NoopProducer) SendRideStatusChanged(ctx context.Context, rideID, status string) error {
+// SendSafetyAlert logs the SOS: without Kafka admins see it only in GET /admin/sos
+func (p *NoopProducer) SendSafetyAlert(ctx context.Context, alert *domain.SOSAlert) error {
+	slog.Warn("SOS alert raised", "alert_id", alert.ID, "ride_id", alert.RideID, "reporter_id", alert.ReporterID)
 	return nil
 }
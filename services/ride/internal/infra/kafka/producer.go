@@ -1,5 +1,7 @@
 // Package kafka — event producer for ride events (2026)
-// Topics: ride.requested, ride.bid.placed, ride.matched, ride.status.changed
+// Topics: ride.requested, ride.bid.placed, ride.matched, ride.status.changed, ride.events
+// (SSE stream, consumed back by every instance), admin.alerts (SOS); consumes
+// driver.eligibility.changed (user service) for the bid gate
 package kafka
 
 import (
@@ -8,6 +10,8 @@ import (
 	"log/slog"
 
 	"github.com/IBM/sarama"
+
+	"github.com/ridehail/ride/internal/domain"
 )
 
 const (
@@ -15,8 +19,16 @@ const (
 	TopicRideBidPlaced   = "ride.bid.placed"
 	TopicRideMatched     = "ride.matched"
 	TopicRideStatusChanged = "ride.status.changed"
+	// TopicRideEvents — per-ride stream events (domain.RideEvent) keyed by ride id, so a
+	// ride's events share a partition and its offsets order them
+	TopicRideEvents = "ride.events"
+	// TopicAdminAlerts — alerts for the admin console, keyed by ride id
+	TopicAdminAlerts = "admin.alerts"
 )
 
+// AlertPrioritySOS — admin alert priority of SOS alerts
+const AlertPrioritySOS = "high"
+
 type Producer struct {
 	prod sarama.SyncProducer
 }
@@ -45,14 +57,33 @@ func (p *Producer) SendRideBidPlaced(ctx context.Context, rideID, bidID, driverI
 	return p.sendJSON(ctx, TopicRideBidPlaced, rideID, payload)
 }
 
-func (p *Producer) SendRideMatched(ctx context.Context, rideID, driverID string, price float64) error {
-	payload := map[string]interface{}{"ride_id": rideID, "driver_id": driverID, "price": price}
+func (p *Producer) SendRideMatched(ctx context.Context, rideID, passengerID, driverID string, price float64, payment domain.RidePayment, auto bool) error {
+	payload := map[string]interface{}{"ride_id": rideID, "passenger_id": passengerID, "driver_id": driverID, "price": price,
+		"payment_method": payment.Method, "card_id": payment.CardID, "auto": auto}
 	return p.sendJSON(ctx, TopicRideMatched, rideID, payload)
 }
 
-func (p *Producer) SendRideStatusChanged(ctx context.Context, rideID, status string) error {
-	payload := map[string]interface{}{"ride_id": rideID, "status": status}
-	return p.sendJSON(ctx, TopicRideStatusChanged, rideID, payload)
+func (p *Producer) SendRideStatusChanged(ctx context.Context, ride *domain.Ride, status string) error {
+	payload := map[string]interface{}{"ride_id": ride.ID, "status": status}
+	if status == domain.StatusCompleted {
+		payload["driver_id"] = ride.DriverID
+		payload["category"] = ride.Category
+		payload["payment_method"] = ride.Payment.Method
+		if ride.Price != nil {
+			payload["fare"] = map[string]float64{"price": *ride.Price}
+		}
+	}
+	return p.sendJSON(ctx, TopicRideStatusChanged, ride.ID, payload)
+}
+
+func (p *Producer) SendRideEvent(ctx context.Context, e domain.RideEvent) error {
+	return p.sendJSON(ctx, TopicRideEvents, e.RideID, e)
+}
+
+// SendSafetyAlert publishes an SOS as a high-priority admin alert
+func (p *Producer) SendSafetyAlert(ctx context.Context, alert *domain.SOSAlert) error {
+	payload := map[string]interface{}{"type": "ride.sos", "priority": AlertPrioritySOS, "alert": alert}
+	return p.sendJSON(ctx, TopicAdminAlerts, alert.RideID, payload)
 }
 
 func (p *Producer) sendJSON(ctx context.Context, topic, key string, payload interface{}) error {
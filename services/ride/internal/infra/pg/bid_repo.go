@@ -13,6 +13,13 @@ import (
 const BidStatusPending = "pending"
 const BidStatusAccepted = "accepted"
 const BidStatusRejected = "rejected"
+const BidStatusWithdrawn = "withdrawn"
+
+// bidColumns — SELECT list matching scanBid
+const bidColumns = `id, ride_id, driver_id, price, status, counter_price, created_at`
+
+// ErrBidStateChanged — conditional update matched no row (bid is no longer pending)
+var ErrBidStateChanged = errors.New("bid status changed concurrently")
 
 type BidRepo struct {
 	pool *pgxpool.Pool
@@ -41,15 +48,57 @@ func (r *BidRepo) Create(ctx context.Context, bid *domain.Bid) error {
 
 func (r *BidRepo) GetByID(ctx context.Context, id string) (*domain.Bid, error) {
 	row := r.pool.QueryRow(ctx,
-		`SELECT id, ride_id, driver_id, price, status, created_at FROM bids WHERE id = $1`,
+		`SELECT `+bidColumns+` FROM bids WHERE id = $1`,
 		id,
 	)
 	return scanBid(row)
 }
 
+// GetPendingByDriver returns the driver's pending bid on the ride (nil if none)
+func (r *BidRepo) GetPendingByDriver(ctx context.Context, rideID, driverID string) (*domain.Bid, error) {
+	row := r.pool.QueryRow(ctx,
+		`SELECT `+bidColumns+` FROM bids WHERE ride_id = $1 AND driver_id = $2 AND status = $3
+		 ORDER BY created_at DESC LIMIT 1`,
+		rideID, driverID, BidStatusPending,
+	)
+	return scanBid(row)
+}
+
+// UpdatePrice changes a pending bid's price and clears the counter-offer it answers
+func (r *BidRepo) UpdatePrice(ctx context.Context, bidID string, price float64) error {
+	return r.updatePending(ctx,
+		`UPDATE bids SET price = $2, counter_price = NULL WHERE id = $1 AND status = $3`,
+		bidID, price, BidStatusPending)
+}
+
+// SetCounter stores the passenger's counter-offer on a pending bid
+func (r *BidRepo) SetCounter(ctx context.Context, bidID string, price float64) error {
+	return r.updatePending(ctx,
+		`UPDATE bids SET counter_price = $2 WHERE id = $1 AND status = $3`,
+		bidID, price, BidStatusPending)
+}
+
+// Withdraw marks a pending bid withdrawn by its driver
+func (r *BidRepo) Withdraw(ctx context.Context, bidID string) error {
+	return r.updatePending(ctx,
+		`UPDATE bids SET status = $2 WHERE id = $1 AND status = $3`,
+		bidID, BidStatusWithdrawn, BidStatusPending)
+}
+
+func (r *BidRepo) updatePending(ctx context.Context, query string, args ...interface{}) error {
+	tag, err := r.pool.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrBidStateChanged
+	}
+	return nil
+}
+
 func (r *BidRepo) ListByRideID(ctx context.Context, rideID string) ([]*domain.Bid, error) {
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, ride_id, driver_id, price, status, created_at FROM bids WHERE ride_id = $1 ORDER BY created_at ASC`,
+		`SELECT `+bidColumns+` FROM bids WHERE ride_id = $1 ORDER BY created_at ASC`,
 		rideID,
 	)
 	if err != nil {
@@ -58,37 +107,18 @@ func (r *BidRepo) ListByRideID(ctx context.Context, rideID string) ([]*domain.Bi
 	defer rows.Close()
 	var out []*domain.Bid
 	for rows.Next() {
-		var bid domain.Bid
-		var createdAt interface{}
-		err := rows.Scan(&bid.ID, &bid.RideID, &bid.DriverID, &bid.Price, &bid.Status, &createdAt)
+		bid, err := scanBid(rows)
 		if err != nil {
 			return nil, err
 		}
-		out = append(out, &bid)
+		out = append(out, bid)
 	}
 	return out, rows.Err()
 }
 
-func (r *BidRepo) AcceptBid(ctx context.Context, bidID string) error {
-	_, err := r.pool.Exec(ctx,
-		`UPDATE bids SET status = $1 WHERE id = $2`,
-		BidStatusAccepted, bidID,
-	)
-	return err
-}
-
-func (r *BidRepo) RejectOtherBidsForRide(ctx context.Context, rideID, exceptBidID string) error {
-	_, err := r.pool.Exec(ctx,
-		`UPDATE bids SET status = $1 WHERE ride_id = $2 AND id != $3`,
-		BidStatusRejected, rideID, exceptBidID,
-	)
-	return err
-}
-
-func scanBid(row pgx.Row) (*domain.Bid, error) {
+func scanBid(row rowScanner) (*domain.Bid, error) {
 	var bid domain.Bid
-	var createdAt interface{}
-	err := row.Scan(&bid.ID, &bid.RideID, &bid.DriverID, &bid.Price, &bid.Status, &createdAt)
+	err := row.Scan(&bid.ID, &bid.RideID, &bid.DriverID, &bid.Price, &bid.Status, &bid.CounterPrice, &bid.CreatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, nil
@@ -0,0 +1,76 @@
+package pg
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+// ChatRepo — in-ride chat messages
+type ChatRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewChatRepo(pool *pgxpool.Pool) *ChatRepo {
+	return &ChatRepo{pool: pool}
+}
+
+func (r *ChatRepo) Create(ctx context.Context, m *domain.ChatMessage) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO ride_chat_messages (ride_id, sender_id, sender_role, recipient_id, body, quick_reply)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, created_at`,
+		m.RideID, m.SenderID, m.SenderRole, m.RecipientID, m.Text, m.QuickReply,
+	).Scan(&m.ID, &m.CreatedAt)
+}
+
+// ListByRide returns the ride's messages oldest first, created after since (zero = all)
+func (r *ChatRepo) ListByRide(ctx context.Context, rideID string, since time.Time, limit int) ([]*domain.ChatMessage, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT id, ride_id, sender_id, sender_role, recipient_id, body, quick_reply, created_at, read_at
+		 FROM ride_chat_messages
+		 WHERE ride_id = $1 AND created_at > $2
+		 ORDER BY created_at ASC
+		 LIMIT $3`,
+		rideID, since, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*domain.ChatMessage
+	for rows.Next() {
+		var m domain.ChatMessage
+		if err := rows.Scan(&m.ID, &m.RideID, &m.SenderID, &m.SenderRole, &m.RecipientID,
+			&m.Text, &m.QuickReply, &m.CreatedAt, &m.ReadAt); err != nil {
+			return nil, err
+		}
+		out = append(out, &m)
+	}
+	return out, rows.Err()
+}
+
+// MarkRead sets the read receipt on the recipient's unread messages of the ride
+func (r *ChatRepo) MarkRead(ctx context.Context, rideID, recipientID string, at time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE ride_chat_messages SET read_at = $3
+		 WHERE ride_id = $1 AND recipient_id = $2 AND read_at IS NULL`,
+		rideID, recipientID, at,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// DeleteBefore purges messages created before cutoff (retention policy)
+func (r *ChatRepo) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM ride_chat_messages WHERE created_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
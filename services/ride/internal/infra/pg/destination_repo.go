@@ -0,0 +1,126 @@
+package pg
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+// ErrDestinationLimit — daily destination mode activations exhausted
+var ErrDestinationLimit = errors.New("destination daily limit reached")
+
+// DestinationRepo — driver destination mode persistence
+type DestinationRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewDestinationRepo creates destination repository
+func NewDestinationRepo(pool *pgxpool.Pool) *DestinationRepo {
+	return &DestinationRepo{pool: pool}
+}
+
+// Get returns driver's destination with today's usage (nil if never set)
+func (r *DestinationRepo) Get(ctx context.Context, driverID string) (*domain.DriverDestination, error) {
+	var d domain.DriverDestination
+	var addr *string
+	err := r.pool.QueryRow(ctx,
+		`SELECT d.driver_id, d.lat, d.lng, d.address, d.active, d.set_at, COALESCE(u.uses, 0)
+		 FROM driver_destinations d
+		 LEFT JOIN driver_destination_usage u ON u.driver_id = d.driver_id AND u.day = (now() AT TIME ZONE 'UTC')::date
+		 WHERE d.driver_id = $1`,
+		driverID,
+	).Scan(&d.DriverID, &d.Point.Lat, &d.Point.Lng, &addr, &d.Active, &d.SetAt, &d.UsesToday)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if addr != nil {
+		d.Point.Address = *addr
+	}
+	return &d, nil
+}
+
+// Activate counts one use for today (bounded by dailyLimit) and upserts the destination in one transaction
+func (r *DestinationRepo) Activate(ctx context.Context, d *domain.DriverDestination, dailyLimit int) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var uses int
+	err = tx.QueryRow(ctx,
+		`INSERT INTO driver_destination_usage (driver_id, day, uses)
+		 VALUES ($1, (now() AT TIME ZONE 'UTC')::date, 1)
+		 ON CONFLICT (driver_id, day) DO UPDATE SET uses = driver_destination_usage.uses + 1
+		 WHERE driver_destination_usage.uses < $2
+		 RETURNING uses`,
+		d.DriverID, dailyLimit,
+	).Scan(&uses)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrDestinationLimit
+		}
+		return err
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO driver_destinations (driver_id, lat, lng, address, active, set_at, updated_at)
+		 VALUES ($1, $2, $3, $4, TRUE, now(), now())
+		 ON CONFLICT (driver_id) DO UPDATE SET
+		     lat = EXCLUDED.lat, lng = EXCLUDED.lng, address = EXCLUDED.address,
+		     active = TRUE, set_at = now(), updated_at = now()
+		 RETURNING set_at`,
+		d.DriverID, d.Point.Lat, d.Point.Lng, nullStr(d.Point.Address),
+	).Scan(&d.SetAt)
+	if err != nil {
+		return err
+	}
+	d.Active = true
+	d.UsesToday = uses
+	return tx.Commit(ctx)
+}
+
+// Deactivate turns destination mode off
+func (r *DestinationRepo) Deactivate(ctx context.Context, driverID string) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE driver_destinations SET active = FALSE, updated_at = now() WHERE driver_id = $1`,
+		driverID,
+	)
+	return err
+}
+
+// ListActive returns active destinations for the given drivers, keyed by driver ID
+func (r *DestinationRepo) ListActive(ctx context.Context, driverIDs []string) (map[string]*domain.DriverDestination, error) {
+	out := make(map[string]*domain.DriverDestination, len(driverIDs))
+	if len(driverIDs) == 0 {
+		return out, nil
+	}
+	rows, err := r.pool.Query(ctx,
+		`SELECT driver_id, lat, lng, address, set_at
+		 FROM driver_destinations WHERE active AND driver_id = ANY($1::uuid[])`,
+		driverIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		d := &domain.DriverDestination{Active: true}
+		var addr *string
+		if err := rows.Scan(&d.DriverID, &d.Point.Lat, &d.Point.Lng, &addr, &d.SetAt); err != nil {
+			return nil, err
+		}
+		if addr != nil {
+			d.Point.Address = *addr
+		}
+		out[d.DriverID] = d
+	}
+	return out, rows.Err()
+}
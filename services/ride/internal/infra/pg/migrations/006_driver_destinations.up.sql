@@ -0,0 +1,20 @@
+-- Driver destination mode: rides heading toward a chosen point (e.g. home after shift)
+CREATE TABLE IF NOT EXISTS driver_destinations (
+    driver_id  UUID PRIMARY KEY,
+    lat        DOUBLE PRECISION NOT NULL,
+    lng        DOUBLE PRECISION NOT NULL,
+    address    TEXT,
+    active     BOOLEAN NOT NULL DEFAULT TRUE,
+    set_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_destinations_active ON driver_destinations (driver_id) WHERE active;
+
+-- Per-driver daily usage (activations), limited by DESTINATION_DAILY_LIMIT
+CREATE TABLE IF NOT EXISTS driver_destination_usage (
+    driver_id UUID NOT NULL,
+    day       DATE NOT NULL,
+    uses      INTEGER NOT NULL DEFAULT 0 CHECK (uses >= 0),
+    PRIMARY KEY (driver_id, day)
+);
@@ -0,0 +1,4 @@
+-- Ride vehicle requirements: class (empty = any) and features every matching driver must have.
+-- Open-rides feed filters by the driver's approved vehicle: class IN served classes AND features ⊆ vehicle features.
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS vehicle_class TEXT NOT NULL DEFAULT '';
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS required_features TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,8 @@
+-- Ride categories (economy, comfort, cargo, courier, intercity); rules live in code (domain.CategoryRules).
+-- seats: intercity seat-based pricing; scheduled_at: pre-booked pickup; delivery: courier contacts + code.
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS category TEXT NOT NULL DEFAULT 'economy';
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS seats INT NOT NULL DEFAULT 1;
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMPTZ;
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS delivery JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_rides_open_category ON rides (category, created_at DESC) WHERE status IN ('requested', 'bidding');
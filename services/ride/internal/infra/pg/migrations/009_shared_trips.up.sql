@@ -0,0 +1,43 @@
+-- Shared trips (carpooling): driver publishes a route with seats, passengers book seats
+-- between two points of it. route/pickup/dropoff are JSON points ({"lat","lng","address"}).
+CREATE TABLE IF NOT EXISTS shared_trips (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    driver_id  UUID NOT NULL,
+    category   TEXT NOT NULL,
+    status     TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'in_progress', 'completed', 'cancelled')),
+    route      JSONB NOT NULL,
+    route_km   DOUBLE PRECISION NOT NULL,
+    depart_at  TIMESTAMPTZ NOT NULL,
+    capacity   INT NOT NULL CHECK (capacity > 0),
+    seat_price DOUBLE PRECISION NOT NULL CHECK (seat_price > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shared_trips_open ON shared_trips (depart_at) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_shared_trips_driver ON shared_trips (driver_id, depart_at DESC);
+
+CREATE TABLE IF NOT EXISTS seat_bookings (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    trip_id      UUID NOT NULL REFERENCES shared_trips(id) ON DELETE CASCADE,
+    passenger_id UUID NOT NULL,
+    seats        INT NOT NULL CHECK (seats > 0),
+    pickup       JSONB NOT NULL,
+    dropoff      JSONB NOT NULL,
+    pickup_km    DOUBLE PRECISION NOT NULL,
+    dropoff_km   DOUBLE PRECISION NOT NULL,
+    price        DOUBLE PRECISION NOT NULL,
+    status       TEXT NOT NULL DEFAULT 'booked' CHECK (status IN ('booked', 'completed', 'cancelled')),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_seat_bookings_active ON seat_bookings (trip_id, passenger_id) WHERE status <> 'cancelled';
+CREATE INDEX IF NOT EXISTS idx_seat_bookings_passenger ON seat_bookings (passenger_id, created_at DESC);
+
+-- Ratings of shared trips: trip_id instead of ride_id, one rating per trip per direction
+ALTER TABLE ratings ADD COLUMN IF NOT EXISTS trip_id UUID REFERENCES shared_trips(id) ON DELETE CASCADE;
+ALTER TABLE ratings ALTER COLUMN ride_id DROP NOT NULL;
+ALTER TABLE ratings DROP CONSTRAINT IF EXISTS ratings_ride_or_trip;
+ALTER TABLE ratings ADD CONSTRAINT ratings_ride_or_trip CHECK (ride_id IS NOT NULL OR trip_id IS NOT NULL);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ratings_trip_pair ON ratings (trip_id, from_user_id, to_user_id) WHERE trip_id IS NOT NULL;
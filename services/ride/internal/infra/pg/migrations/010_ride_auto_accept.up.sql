@@ -0,0 +1,2 @@
+-- Passenger auto-accept rules: {"max_price","min_rating","max_eta_minutes"}; NULL = passenger picks bids manually.
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS auto_accept JSONB;
@@ -0,0 +1,7 @@
+-- Bid negotiation: passenger counter-offer on a pending bid (driver answers by bidding again,
+-- which updates the same bid) and withdrawal by the driver.
+ALTER TABLE bids ADD COLUMN IF NOT EXISTS counter_price DOUBLE PRECISION CHECK (counter_price > 0);
+ALTER TABLE bids DROP CONSTRAINT IF EXISTS bids_status_check;
+ALTER TABLE bids ADD CONSTRAINT bids_status_check CHECK (status IN ('pending', 'accepted', 'rejected', 'withdrawn'));
+
+CREATE INDEX IF NOT EXISTS idx_bids_ride_driver_pending ON bids (ride_id, driver_id) WHERE status = 'pending';
@@ -0,0 +1,17 @@
+-- In-ride chat between the passenger and the matched driver; read_at = read receipt.
+-- Kept for support review for CHAT_RETENTION, then purged by the ride service.
+CREATE TABLE IF NOT EXISTS ride_chat_messages (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ride_id      UUID NOT NULL REFERENCES rides (id) ON DELETE CASCADE,
+    sender_id    UUID NOT NULL,
+    sender_role  TEXT NOT NULL CHECK (sender_role IN ('passenger', 'driver')),
+    recipient_id UUID NOT NULL,
+    body         TEXT NOT NULL,
+    quick_reply  TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    read_at      TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_ride_chat_ride ON ride_chat_messages (ride_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_ride_chat_unread ON ride_chat_messages (ride_id, recipient_id) WHERE read_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_ride_chat_created ON ride_chat_messages (created_at);
@@ -0,0 +1,32 @@
+-- Trip safety. Pickup PIN: {"code","attempts","verified_at"}; NULL = ride starts without a PIN.
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS pickup_pin JSONB;
+
+-- SOS alerts raised by a ride participant; snapshot = ride, driver card and last positions at that moment.
+CREATE TABLE IF NOT EXISTS ride_sos_alerts (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ride_id       UUID NOT NULL REFERENCES rides (id) ON DELETE CASCADE,
+    reporter_id   UUID NOT NULL,
+    reporter_role TEXT NOT NULL CHECK (reporter_role IN ('passenger', 'driver')),
+    message       TEXT NOT NULL DEFAULT '',
+    snapshot      JSONB NOT NULL,
+    status        TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'acknowledged', 'resolved')),
+    handled_by    UUID,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ride_sos_active ON ride_sos_alerts (created_at DESC) WHERE status <> 'resolved';
+CREATE INDEX IF NOT EXISTS idx_ride_sos_ride ON ride_sos_alerts (ride_id);
+
+-- Public trip links for trusted contacts; only the SHA-256 of the token is stored.
+CREATE TABLE IF NOT EXISTS ride_share_links (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ride_id    UUID NOT NULL REFERENCES rides (id) ON DELETE CASCADE,
+    created_by UUID NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ride_share_links_ride ON ride_share_links (ride_id);
@@ -0,0 +1,7 @@
+-- Fares in exact kopecks: NUMERIC(12,2) instead of DOUBLE PRECISION (values are rounded
+-- to kopecks by the service; existing rows are rounded half away from zero)
+ALTER TABLE rides ALTER COLUMN price TYPE NUMERIC(12,2) USING ROUND(price::numeric, 2);
+ALTER TABLE bids ALTER COLUMN price TYPE NUMERIC(12,2) USING ROUND(price::numeric, 2);
+ALTER TABLE bids ALTER COLUMN counter_price TYPE NUMERIC(12,2) USING ROUND(counter_price::numeric, 2);
+ALTER TABLE shared_trips ALTER COLUMN seat_price TYPE NUMERIC(12,2) USING ROUND(seat_price::numeric, 2);
+ALTER TABLE seat_bookings ALTER COLUMN price TYPE NUMERIC(12,2) USING ROUND(price::numeric, 2);
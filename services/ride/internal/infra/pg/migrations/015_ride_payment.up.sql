@@ -0,0 +1,5 @@
+-- How the passenger pays: cash (existing rides) or a card saved in the payment service,
+-- held by it when the ride is matched
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS payment_method TEXT NOT NULL DEFAULT 'cash'
+    CHECK (payment_method IN ('cash', 'card'));
+ALTER TABLE rides ADD COLUMN IF NOT EXISTS card_id TEXT;
@@ -23,12 +23,13 @@ func NewRatingRepo(pool *pgxpool.Pool) *RatingRepo {
 // Create inserts a new rating
 func (r *RatingRepo) Create(ctx context.Context, rating *domain.Rating) error {
 	query := `
-		INSERT INTO ratings (ride_id, from_user_id, to_user_id, role, score, comment, tags)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO ratings (ride_id, trip_id, from_user_id, to_user_id, role, score, comment, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id, created_at
 	`
 	return r.pool.QueryRow(ctx, query,
-		rating.RideID,
+		nullStr(rating.RideID),
+		nullStr(rating.TripID),
 		rating.FromUserID,
 		rating.ToUserID,
 		rating.Role,
@@ -41,7 +42,7 @@ func (r *RatingRepo) Create(ctx context.Context, rating *domain.Rating) error {
 // GetByRideID returns all ratings for a ride
 func (r *RatingRepo) GetByRideID(ctx context.Context, rideID string) ([]domain.Rating, error) {
 	query := `
-		SELECT id, ride_id, from_user_id, to_user_id, role, score, 
+		SELECT id, COALESCE(ride_id::text, ''), COALESCE(trip_id::text, ''), from_user_id, to_user_id, role, score, 
 		       COALESCE(comment, ''), tags, created_at
 		FROM ratings
 		WHERE ride_id = $1
@@ -57,7 +58,7 @@ func (r *RatingRepo) GetByRideID(ctx context.Context, rideID string) ([]domain.R
 	for rows.Next() {
 		var rating domain.Rating
 		if err := rows.Scan(
-			&rating.ID, &rating.RideID, &rating.FromUserID, &rating.ToUserID,
+			&rating.ID, &rating.RideID, &rating.TripID, &rating.FromUserID, &rating.ToUserID,
 			&rating.Role, &rating.Score, &rating.Comment, &rating.Tags, &rating.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -74,7 +75,7 @@ func (r *RatingRepo) GetByUserID(ctx context.Context, userID, role string, limit
 
 	if role != "" {
 		query = `
-			SELECT id, ride_id, from_user_id, to_user_id, role, score, 
+			SELECT id, COALESCE(ride_id::text, ''), COALESCE(trip_id::text, ''), from_user_id, to_user_id, role, score, 
 			       COALESCE(comment, ''), tags, created_at
 			FROM ratings
 			WHERE to_user_id = $1 AND role = $2
@@ -84,7 +85,7 @@ func (r *RatingRepo) GetByUserID(ctx context.Context, userID, role string, limit
 		args = []interface{}{userID, role, limit, offset}
 	} else {
 		query = `
-			SELECT id, ride_id, from_user_id, to_user_id, role, score, 
+			SELECT id, COALESCE(ride_id::text, ''), COALESCE(trip_id::text, ''), from_user_id, to_user_id, role, score, 
 			       COALESCE(comment, ''), tags, created_at
 			FROM ratings
 			WHERE to_user_id = $1
@@ -104,7 +105,7 @@ func (r *RatingRepo) GetByUserID(ctx context.Context, userID, role string, limit
 	for rows.Next() {
 		var rating domain.Rating
 		if err := rows.Scan(
-			&rating.ID, &rating.RideID, &rating.FromUserID, &rating.ToUserID,
+			&rating.ID, &rating.RideID, &rating.TripID, &rating.FromUserID, &rating.ToUserID,
 			&rating.Role, &rating.Score, &rating.Comment, &rating.Tags, &rating.CreatedAt,
 		); err != nil {
 			return nil, err
@@ -142,6 +143,37 @@ func (r *RatingRepo) GetUserRating(ctx context.Context, userID, role string) (*d
 	return &ur, nil
 }
 
+// GetUserRatingSummaries returns aggregated ratings of many users in one role;
+// users without ratings are absent from the result
+func (r *RatingRepo) GetUserRatingSummaries(ctx context.Context, userIDs []string, role string) (map[string]*domain.UserRating, error) {
+	out := make(map[string]*domain.UserRating, len(userIDs))
+	if len(userIDs) == 0 {
+		return out, nil
+	}
+	query := `
+		SELECT user_id, role, average_score, total_ratings,
+		       score_5_count, score_4_count, score_3_count, score_2_count, score_1_count
+		FROM user_ratings
+		WHERE user_id = ANY($1) AND role = $2
+	`
+	rows, err := r.pool.Query(ctx, query, userIDs, role)
+	if err != nil {
+		return nil, fmt.Errorf("get user rating summaries: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ur domain.UserRating
+		if err := rows.Scan(
+			&ur.UserID, &ur.Role, &ur.AverageScore, &ur.TotalRatings,
+			&ur.Score5Count, &ur.Score4Count, &ur.Score3Count, &ur.Score2Count, &ur.Score1Count,
+		); err != nil {
+			return nil, fmt.Errorf("scan user rating: %w", err)
+		}
+		out[ur.UserID] = &ur
+	}
+	return out, rows.Err()
+}
+
 // HasRated checks if a user has already rated another user for a ride
 func (r *RatingRepo) HasRated(ctx context.Context, rideID, fromUserID, toUserID string) (bool, error) {
 	var exists bool
@@ -150,6 +182,43 @@ func (r *RatingRepo) HasRated(ctx context.Context, rideID, fromUserID, toUserID
 	return exists, err
 }
 
+// GetByTripID returns all ratings for a shared trip
+func (r *RatingRepo) GetByTripID(ctx context.Context, tripID string) ([]domain.Rating, error) {
+	query := `
+		SELECT id, COALESCE(ride_id::text, ''), COALESCE(trip_id::text, ''), from_user_id, to_user_id, role, score,
+		       COALESCE(comment, ''), tags, created_at
+		FROM ratings
+		WHERE trip_id = $1
+		ORDER BY created_at DESC
+	`
+	rows, err := r.pool.Query(ctx, query, tripID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratings []domain.Rating
+	for rows.Next() {
+		var rating domain.Rating
+		if err := rows.Scan(
+			&rating.ID, &rating.RideID, &rating.TripID, &rating.FromUserID, &rating.ToUserID,
+			&rating.Role, &rating.Score, &rating.Comment, &rating.Tags, &rating.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, rating)
+	}
+	return ratings, rows.Err()
+}
+
+// HasRatedTrip checks if a user has already rated another user for a shared trip
+func (r *RatingRepo) HasRatedTrip(ctx context.Context, tripID, fromUserID, toUserID string) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM ratings WHERE trip_id = $1 AND from_user_id = $2 AND to_user_id = $3)`
+	err := r.pool.QueryRow(ctx, query, tripID, fromUserID, toUserID).Scan(&exists)
+	return exists, err
+}
+
 // ListAll returns all ratings with pagination (for admin)
 func (r *RatingRepo) ListAll(ctx context.Context, limit, offset int) ([]domain.Rating, int, error) {
 	// Get total count
@@ -160,7 +229,7 @@ func (r *RatingRepo) ListAll(ctx context.Context, limit, offset int) ([]domain.R
 	}
 
 	query := `
-		SELECT id, ride_id, from_user_id, to_user_id, role, score, 
+		SELECT id, COALESCE(ride_id::text, ''), COALESCE(trip_id::text, ''), from_user_id, to_user_id, role, score, 
 		       COALESCE(comment, ''), tags, created_at
 		FROM ratings
 		ORDER BY created_at DESC
@@ -176,7 +245,7 @@ func (r *RatingRepo) ListAll(ctx context.Context, limit, offset int) ([]domain.R
 	for rows.Next() {
 		var rating domain.Rating
 		if err := rows.Scan(
-			&rating.ID, &rating.RideID, &rating.FromUserID, &rating.ToUserID,
+			&rating.ID, &rating.RideID, &rating.TripID, &rating.FromUserID, &rating.ToUserID,
 			&rating.Role, &rating.Score, &rating.Comment, &rating.Tags, &rating.CreatedAt,
 		); err != nil {
 			return nil, 0, err
@@ -2,7 +2,9 @@ package pg
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -10,6 +12,14 @@ import (
 	"github.com/ridehail/ride/internal/domain"
 )
 
+// rideColumns — SELECT list matching scanRide/scanRides
+const rideColumns = `id, passenger_id, driver_id, status, from_lat, from_lng, from_address, to_lat, to_lng, to_address, price,
+		vehicle_class, required_features, category, seats, scheduled_at, delivery, auto_accept, pickup_pin, payment_method, card_id,
+		created_at, updated_at`
+
+// ErrRideStateChanged — conditional update matched no row (ride left the expected status)
+var ErrRideStateChanged = errors.New("ride status changed concurrently")
+
 type RideRepo struct {
 	pool *pgxpool.Pool
 }
@@ -19,13 +29,37 @@ func NewRideRepo(pool *pgxpool.Pool) *RideRepo {
 }
 
 func (r *RideRepo) Create(ctx context.Context, ride *domain.Ride) error {
+	var delivery, autoAccept, pickupPIN []byte
+	if ride.Delivery != nil {
+		var err error
+		if delivery, err = json.Marshal(ride.Delivery); err != nil {
+			return err
+		}
+	}
+	if ride.AutoAccept != nil {
+		var err error
+		if autoAccept, err = json.Marshal(ride.AutoAccept); err != nil {
+			return err
+		}
+	}
+	if ride.PickupPIN != nil {
+		var err error
+		if pickupPIN, err = json.Marshal(ride.PickupPIN); err != nil {
+			return err
+		}
+	}
 	row := r.pool.QueryRow(ctx,
-		`INSERT INTO rides (passenger_id, status, from_lat, from_lng, from_address, to_lat, to_lng, to_address, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, now(), now())
+		`INSERT INTO rides (passenger_id, status, from_lat, from_lng, from_address, to_lat, to_lng, to_address,
+		                    vehicle_class, required_features, category, seats, scheduled_at, delivery, auto_accept, pickup_pin,
+		                    payment_method, card_id, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, now(), now())
 		 RETURNING id, created_at, updated_at`,
 		ride.PassengerID, domain.StatusRequested,
 		ride.From.Lat, ride.From.Lng, nullStr(ride.From.Address),
 		ride.To.Lat, ride.To.Lng, nullStr(ride.To.Address),
+		ride.Options.VehicleClass, nonNil(ride.Options.Features),
+		ride.Category, ride.Seats, ride.ScheduledAt, delivery, autoAccept, pickupPIN,
+		ride.Payment.Method, nullStr(ride.Payment.CardID),
 	)
 	var id string
 	var createdAt, updatedAt interface{}
@@ -39,7 +73,7 @@ func (r *RideRepo) Create(ctx context.Context, ride *domain.Ride) error {
 
 func (r *RideRepo) GetByID(ctx context.Context, id string) (*domain.Ride, error) {
 	row := r.pool.QueryRow(ctx,
-		`SELECT id, passenger_id, driver_id, status, from_lat, from_lng, from_address, to_lat, to_lng, to_address, price, created_at, updated_at
+		`SELECT `+rideColumns+`
 		 FROM rides WHERE id = $1`,
 		id,
 	)
@@ -54,12 +88,115 @@ func (r *RideRepo) UpdateStatus(ctx context.Context, id, status string) error {
 	return err
 }
 
-func (r *RideRepo) SetDriverAndPrice(ctx context.Context, id, driverID string, price float64) error {
-	_, err := r.pool.Exec(ctx,
-		`UPDATE rides SET driver_id = $1, price = $2, status = $3, updated_at = now() WHERE id = $4`,
-		driverID, price, domain.StatusMatched, id,
+// MatchBid matches the ride to the bid in one transaction: the ride is claimed if it is
+// still open (requested/bidding), the bid accepted if it is still pending, and the ride's
+// other pending bids rejected. Of concurrent accepts the first wins, the others get
+// ErrRideStateChanged; a bid withdrawn or settled meanwhile gives ErrBidStateChanged.
+func (r *RideRepo) MatchBid(ctx context.Context, rideID, bidID, driverID string, price float64) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE rides SET driver_id = $1, price = $2, status = $3, updated_at = now()
+		 WHERE id = $4 AND status IN ($5, $6)`,
+		driverID, price, domain.StatusMatched, rideID, domain.StatusRequested, domain.StatusBidding,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRideStateChanged
+	}
+	tag, err = tx.Exec(ctx,
+		`UPDATE bids SET status = $2 WHERE id = $1 AND ride_id = $3 AND status = $4`,
+		bidID, BidStatusAccepted, rideID, BidStatusPending,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrBidStateChanged
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE bids SET status = $1 WHERE ride_id = $2 AND id != $3 AND status = $4`,
+		BidStatusRejected, rideID, bidID, BidStatusPending,
+	); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// AddDeliveryAttempt counts a wrong delivery code; returns attempts so far
+func (r *RideRepo) AddDeliveryAttempt(ctx context.Context, id string) (int, error) {
+	var attempts int
+	err := r.pool.QueryRow(ctx,
+		`UPDATE rides
+		 SET delivery = jsonb_set(delivery, '{attempts}', to_jsonb(COALESCE((delivery->>'attempts')::int, 0) + 1)),
+		     updated_at = now()
+		 WHERE id = $1 AND delivery IS NOT NULL
+		 RETURNING (delivery->>'attempts')::int`,
+		id,
+	).Scan(&attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrRideStateChanged
+	}
+	return attempts, err
+}
+
+// ConfirmDelivery marks the parcel handed over and completes the in-progress ride
+func (r *RideRepo) ConfirmDelivery(ctx context.Context, id string, at time.Time) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE rides
+		 SET delivery = jsonb_set(delivery, '{confirmed_at}', to_jsonb($2::timestamptz)),
+		     status = $3, updated_at = now()
+		 WHERE id = $1 AND status = $4 AND delivery IS NOT NULL`,
+		id, at, domain.StatusCompleted, domain.StatusInProgress,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRideStateChanged
+	}
+	return nil
+}
+
+// AddPickupPINAttempt counts a wrong pickup PIN; returns attempts so far
+func (r *RideRepo) AddPickupPINAttempt(ctx context.Context, id string) (int, error) {
+	var attempts int
+	err := r.pool.QueryRow(ctx,
+		`UPDATE rides
+		 SET pickup_pin = jsonb_set(pickup_pin, '{attempts}', to_jsonb(COALESCE((pickup_pin->>'attempts')::int, 0) + 1)),
+		     updated_at = now()
+		 WHERE id = $1 AND pickup_pin IS NOT NULL
+		 RETURNING (pickup_pin->>'attempts')::int`,
+		id,
+	).Scan(&attempts)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrRideStateChanged
+	}
+	return attempts, err
+}
+
+// StartWithPickupPIN records the verified PIN and starts the matched ride
+func (r *RideRepo) StartWithPickupPIN(ctx context.Context, id string, at time.Time) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE rides
+		 SET pickup_pin = jsonb_set(pickup_pin, '{verified_at}', to_jsonb($2::timestamptz)),
+		     status = $3, updated_at = now()
+		 WHERE id = $1 AND status = $4 AND pickup_pin IS NOT NULL`,
+		id, at, domain.StatusInProgress, domain.StatusMatched,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrRideStateChanged
+	}
+	return nil
 }
 
 func (r *RideRepo) ListByPassenger(ctx context.Context, passengerID string, limit int) ([]*domain.Ride, error) {
@@ -67,7 +204,7 @@ func (r *RideRepo) ListByPassenger(ctx context.Context, passengerID string, limi
 		limit = 20
 	}
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, passenger_id, driver_id, status, from_lat, from_lng, from_address, to_lat, to_lng, to_address, price, created_at, updated_at
+		`SELECT `+rideColumns+`
 		 FROM rides WHERE passenger_id = $1 ORDER BY created_at DESC LIMIT $2`,
 		passengerID, limit,
 	)
@@ -83,7 +220,7 @@ func (r *RideRepo) ListByDriver(ctx context.Context, driverID string, limit int)
 		limit = 20
 	}
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, passenger_id, driver_id, status, from_lat, from_lng, from_address, to_lat, to_lng, to_address, price, created_at, updated_at
+		`SELECT `+rideColumns+`
 		 FROM rides WHERE driver_id = $1 ORDER BY created_at DESC LIMIT $2`,
 		driverID, limit,
 	)
@@ -94,16 +231,21 @@ func (r *RideRepo) ListByDriver(ctx context.Context, driverID string, limit int)
 	return scanRides(rows)
 }
 
-// ListOpenRides — rides with status requested or bidding (for drivers to bid)
-func (r *RideRepo) ListOpenRides(ctx context.Context, limit int) ([]*domain.Ride, error) {
+// ListOpenRides — rides with status requested or bidding (for drivers to bid).
+// filter narrows to rides of the driver's eligible categories whose class the vehicle
+// serves and whose required features it has. nil = no filter.
+func (r *RideRepo) ListOpenRides(ctx context.Context, limit int, filter *domain.FeedFilter) ([]*domain.Ride, error) {
 	if limit <= 0 {
 		limit = 50
 	}
-	rows, err := r.pool.Query(ctx,
-		`SELECT id, passenger_id, driver_id, status, from_lat, from_lng, from_address, to_lat, to_lng, to_address, price, created_at, updated_at
-		 FROM rides WHERE status IN ('requested', 'bidding') ORDER BY created_at DESC LIMIT $1`,
-		limit,
-	)
+	query := `SELECT ` + rideColumns + `
+		 FROM rides WHERE status IN ('requested', 'bidding')`
+	args := []interface{}{limit}
+	if filter != nil {
+		query += ` AND category = ANY($2) AND (vehicle_class = '' OR vehicle_class = ANY($3)) AND required_features <@ $4`
+		args = append(args, nonNil(filter.Categories), nonNil(filter.Classes), nonNil(filter.Features))
+	}
+	rows, err := r.pool.Query(ctx, query+` ORDER BY created_at DESC LIMIT $1`, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -117,7 +259,7 @@ func (r *RideRepo) ListAll(ctx context.Context, limit int) ([]*domain.Ride, erro
 		limit = 100
 	}
 	rows, err := r.pool.Query(ctx,
-		`SELECT id, passenger_id, driver_id, status, from_lat, from_lng, from_address, to_lat, to_lng, to_address, price, created_at, updated_at
+		`SELECT `+rideColumns+`
 		 FROM rides ORDER BY created_at DESC LIMIT $1`,
 		limit,
 	)
@@ -128,63 +270,86 @@ func (r *RideRepo) ListAll(ctx context.Context, limit int) ([]*domain.Ride, erro
 	return scanRides(rows)
 }
 
+// rowScanner — pgx.Row and pgx.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 func scanRide(row pgx.Row) (*domain.Ride, error) {
+	ride, err := scanRideRow(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return ride, err
+}
+
+func scanRides(rows pgx.Rows) ([]*domain.Ride, error) {
+	var out []*domain.Ride
+	for rows.Next() {
+		ride, err := scanRideRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, ride)
+	}
+	return out, rows.Err()
+}
+
+func scanRideRow(row rowScanner) (*domain.Ride, error) {
 	var ride domain.Ride
 	var driverID, fromAddr, toAddr interface{}
 	var price *float64
+	var delivery, autoAccept, pickupPIN []byte
+	var cardID *string
 	err := row.Scan(&ride.ID, &ride.PassengerID, &driverID, &ride.Status,
 		&ride.From.Lat, &ride.From.Lng, &fromAddr, &ride.To.Lat, &ride.To.Lng, &toAddr,
-		&price, &ride.CreatedAt, &ride.UpdatedAt,
+		&price, &ride.Options.VehicleClass, &ride.Options.Features,
+		&ride.Category, &ride.Seats, &ride.ScheduledAt, &delivery, &autoAccept, &pickupPIN,
+		&ride.Payment.Method, &cardID, &ride.CreatedAt, &ride.UpdatedAt,
 	)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
-		}
 		return nil, err
 	}
+	if cardID != nil {
+		ride.Payment.CardID = *cardID
+	}
 	if driverID != nil {
 		ride.DriverID = driverID.(string)
 	}
-	if fromAddr != nil {
-		ride.From.Address = fromAddr.(string)
+	if s, ok := fromAddr.(string); ok {
+		ride.From.Address = s
 	}
-	if toAddr != nil {
-		ride.To.Address = toAddr.(string)
+	if s, ok := toAddr.(string); ok {
+		ride.To.Address = s
 	}
 	ride.Price = price
-	return &ride, nil
-}
-
-func scanRides(rows pgx.Rows) ([]*domain.Ride, error) {
-	var out []*domain.Ride
-	for rows.Next() {
-		var ride domain.Ride
-		var driverID, fromAddr, toAddr interface{}
-		var price *float64
-		err := rows.Scan(&ride.ID, &ride.PassengerID, &driverID, &ride.Status,
-			&ride.From.Lat, &ride.From.Lng, &fromAddr, &ride.To.Lat, &ride.To.Lng, &toAddr,
-			&price, &ride.CreatedAt, &ride.UpdatedAt,
-		)
-		if err != nil {
+	if len(delivery) > 0 {
+		ride.Delivery = &domain.Delivery{}
+		if err := json.Unmarshal(delivery, ride.Delivery); err != nil {
 			return nil, err
 		}
-		if driverID != nil {
-			ride.DriverID = driverID.(string)
-		}
-		if fromAddr != nil {
-			if s, ok := fromAddr.(string); ok {
-				ride.From.Address = s
-			}
+	}
+	if len(autoAccept) > 0 {
+		ride.AutoAccept = &domain.AutoAccept{}
+		if err := json.Unmarshal(autoAccept, ride.AutoAccept); err != nil {
+			return nil, err
 		}
-		if toAddr != nil {
-			if s, ok := toAddr.(string); ok {
-				ride.To.Address = s
-			}
+	}
+	if len(pickupPIN) > 0 {
+		ride.PickupPIN = &domain.PickupPIN{}
+		if err := json.Unmarshal(pickupPIN, ride.PickupPIN); err != nil {
+			return nil, err
 		}
-		ride.Price = price
-		out = append(out, &ride)
 	}
-	return out, rows.Err()
+	return &ride, nil
+}
+
+// nonNil — pgx encodes a nil slice as NULL; array columns are NOT NULL
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
 }
 
 func nullStr(s string) *string {
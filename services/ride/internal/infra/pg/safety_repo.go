@@ -0,0 +1,154 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+const sosColumns = `id, ride_id, reporter_id, reporter_role, message, snapshot, status, COALESCE(handled_by::text, ''), created_at, updated_at`
+
+const shareLinkColumns = `id, ride_id, created_by, expires_at, revoked_at, created_at`
+
+// SafetyRepo — SOS alerts and public trip links
+type SafetyRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewSafetyRepo(pool *pgxpool.Pool) *SafetyRepo {
+	return &SafetyRepo{pool: pool}
+}
+
+func (r *SafetyRepo) CreateAlert(ctx context.Context, a *domain.SOSAlert) error {
+	snapshot, err := json.Marshal(a.Snapshot)
+	if err != nil {
+		return err
+	}
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO ride_sos_alerts (ride_id, reporter_id, reporter_role, message, snapshot, status)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, created_at, updated_at`,
+		a.RideID, a.ReporterID, a.ReporterRole, a.Message, snapshot, domain.SOSOpen,
+	).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+}
+
+// ListAlerts returns alerts newest first; activeOnly skips resolved ones
+func (r *SafetyRepo) ListAlerts(ctx context.Context, activeOnly bool, limit int) ([]*domain.SOSAlert, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	query := `SELECT ` + sosColumns + ` FROM ride_sos_alerts`
+	if activeOnly {
+		query += ` WHERE status <> 'resolved'`
+	}
+	rows, err := r.pool.Query(ctx, query+` ORDER BY created_at DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*domain.SOSAlert
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+// SetAlertStatus records who handled the alert; nil when there is no such alert
+func (r *SafetyRepo) SetAlertStatus(ctx context.Context, id, status, adminID string) (*domain.SOSAlert, error) {
+	a, err := scanAlert(r.pool.QueryRow(ctx,
+		`UPDATE ride_sos_alerts SET status = $2, handled_by = $3, updated_at = now()
+		 WHERE id = $1
+		 RETURNING `+sosColumns,
+		id, status, adminID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return a, err
+}
+
+func scanAlert(row rowScanner) (*domain.SOSAlert, error) {
+	var a domain.SOSAlert
+	var snapshot []byte
+	if err := row.Scan(&a.ID, &a.RideID, &a.ReporterID, &a.ReporterRole, &a.Message, &snapshot,
+		&a.Status, &a.HandledBy, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(snapshot, &a.Snapshot); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// CreateShareLink stores the link under the token's hash
+func (r *SafetyRepo) CreateShareLink(ctx context.Context, l *domain.ShareLink, tokenHash string) error {
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO ride_share_links (ride_id, created_by, token_hash, expires_at)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		l.RideID, l.CreatedBy, tokenHash, l.ExpiresAt,
+	).Scan(&l.ID, &l.CreatedAt)
+}
+
+// GetShareLinkByHash — nil when no link has the token
+func (r *SafetyRepo) GetShareLinkByHash(ctx context.Context, tokenHash string) (*domain.ShareLink, error) {
+	l, err := scanShareLink(r.pool.QueryRow(ctx,
+		`SELECT `+shareLinkColumns+` FROM ride_share_links WHERE token_hash = $1`, tokenHash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return l, err
+}
+
+// ListShareLinks — links the user created for the ride, newest first
+func (r *SafetyRepo) ListShareLinks(ctx context.Context, rideID, createdBy string) ([]*domain.ShareLink, error) {
+	rows, err := r.pool.Query(ctx,
+		`SELECT `+shareLinkColumns+` FROM ride_share_links
+		 WHERE ride_id = $1 AND created_by = $2 ORDER BY created_at DESC`,
+		rideID, createdBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []*domain.ShareLink
+	for rows.Next() {
+		l, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// RevokeShareLink revokes the creator's link; false when there is no such unrevoked link
+func (r *SafetyRepo) RevokeShareLink(ctx context.Context, id, rideID, createdBy string, at time.Time) (bool, error) {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE ride_share_links SET revoked_at = $4
+		 WHERE id = $1 AND ride_id = $2 AND created_by = $3 AND revoked_at IS NULL`,
+		id, rideID, createdBy, at,
+	)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func scanShareLink(row rowScanner) (*domain.ShareLink, error) {
+	var l domain.ShareLink
+	if err := row.Scan(&l.ID, &l.RideID, &l.CreatedBy, &l.ExpiresAt, &l.RevokedAt, &l.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
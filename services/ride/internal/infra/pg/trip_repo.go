@@ -0,0 +1,265 @@
+package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+var (
+	// ErrTripNotFound — booking for a trip that does not exist
+	ErrTripNotFound = errors.New("trip not found")
+	// ErrTripStateChanged — conditional trip/booking update matched no row
+	ErrTripStateChanged = errors.New("trip or booking status changed concurrently")
+)
+
+const tripColumns = `id, driver_id, category, status, route, route_km, depart_at, capacity, seat_price, created_at, updated_at`
+
+const bookingColumns = `id, trip_id, passenger_id, seats, pickup, dropoff, pickup_km, dropoff_km, price, status, created_at, updated_at`
+
+// TripRepo — shared trips and seat bookings persistence
+type TripRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewTripRepo creates shared trip repository
+func NewTripRepo(pool *pgxpool.Pool) *TripRepo {
+	return &TripRepo{pool: pool}
+}
+
+func (r *TripRepo) Create(ctx context.Context, t *domain.SharedTrip) error {
+	route, err := json.Marshal(t.Route)
+	if err != nil {
+		return err
+	}
+	return r.pool.QueryRow(ctx,
+		`INSERT INTO shared_trips (driver_id, category, status, route, route_km, depart_at, capacity, seat_price)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id, created_at, updated_at`,
+		t.DriverID, t.Category, t.Status, route, t.RouteKM, t.DepartAt, t.Capacity, t.SeatPrice,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+// GetByID returns the trip with all its bookings (nil if not found)
+func (r *TripRepo) GetByID(ctx context.Context, id string) (*domain.SharedTrip, error) {
+	trips, err := r.listTrips(ctx, `SELECT `+tripColumns+` FROM shared_trips WHERE id = $1`, id)
+	if err != nil || len(trips) == 0 {
+		return nil, err
+	}
+	return trips[0], nil
+}
+
+// ListOpen — open trips departing in [from, to]; category empty = any
+func (r *TripRepo) ListOpen(ctx context.Context, category string, from, to time.Time, limit int) ([]*domain.SharedTrip, error) {
+	return r.listTrips(ctx,
+		`SELECT `+tripColumns+`
+		 FROM shared_trips
+		 WHERE status = 'open' AND depart_at BETWEEN $1 AND $2 AND ($3 = '' OR category = $3)
+		 ORDER BY depart_at LIMIT $4`,
+		from, to, category, limit,
+	)
+}
+
+func (r *TripRepo) ListByDriver(ctx context.Context, driverID string, limit int) ([]*domain.SharedTrip, error) {
+	return r.listTrips(ctx,
+		`SELECT `+tripColumns+` FROM shared_trips WHERE driver_id = $1 ORDER BY depart_at DESC LIMIT $2`,
+		driverID, limit,
+	)
+}
+
+// ListByPassenger — trips the passenger booked seats on (cancelled bookings included)
+func (r *TripRepo) ListByPassenger(ctx context.Context, passengerID string, limit int) ([]*domain.SharedTrip, error) {
+	return r.listTrips(ctx,
+		`SELECT `+tripColumns+`
+		 FROM shared_trips
+		 WHERE id IN (SELECT trip_id FROM seat_bookings WHERE passenger_id = $1)
+		 ORDER BY depart_at DESC LIMIT $2`,
+		passengerID, limit,
+	)
+}
+
+// Book locks the trip row, loads its bookings, runs check and inserts the booking.
+// Concurrent bookings of the same trip are serialized by the row lock.
+func (r *TripRepo) Book(ctx context.Context, b *domain.SeatBooking, check func(t *domain.SharedTrip) error) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	t, err := scanTrip(tx.QueryRow(ctx, `SELECT `+tripColumns+` FROM shared_trips WHERE id = $1 FOR UPDATE`, b.TripID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrTripNotFound
+	}
+	if err != nil {
+		return err
+	}
+	rows, err := tx.Query(ctx, `SELECT `+bookingColumns+` FROM seat_bookings WHERE trip_id = $1 ORDER BY created_at`, t.ID)
+	if err != nil {
+		return err
+	}
+	t.Bookings, err = scanBookings(rows)
+	if err != nil {
+		return err
+	}
+	if err := check(t); err != nil {
+		return err
+	}
+	pickup, err := json.Marshal(b.Pickup)
+	if err != nil {
+		return err
+	}
+	dropoff, err := json.Marshal(b.Dropoff)
+	if err != nil {
+		return err
+	}
+	err = tx.QueryRow(ctx,
+		`INSERT INTO seat_bookings (trip_id, passenger_id, seats, pickup, dropoff, pickup_km, dropoff_km, price, status)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 RETURNING id, created_at, updated_at`,
+		b.TripID, b.PassengerID, b.Seats, pickup, dropoff, b.PickupKM, b.DropoffKM, b.Price, b.Status,
+	).Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(ctx, `UPDATE shared_trips SET updated_at = now() WHERE id = $1`, t.ID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *TripRepo) CancelBooking(ctx context.Context, tripID, bookingID string) error {
+	tag, err := r.pool.Exec(ctx,
+		`UPDATE seat_bookings SET status = 'cancelled', updated_at = now()
+		 WHERE id = $1 AND trip_id = $2 AND status = 'booked'
+		   AND EXISTS (SELECT 1 FROM shared_trips WHERE id = $2 AND status = 'open')`,
+		bookingID, tripID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTripStateChanged
+	}
+	return nil
+}
+
+// UpdateStatus moves the trip from → to; on completion booked seats become completed,
+// on cancellation they are cancelled
+func (r *TripRepo) UpdateStatus(ctx context.Context, id, from, to string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE shared_trips SET status = $1, updated_at = now() WHERE id = $2 AND status = $3`,
+		to, id, from,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrTripStateChanged
+	}
+	booking := map[string]string{
+		domain.TripStatusCompleted: domain.BookingStatusCompleted,
+		domain.TripStatusCancelled: domain.BookingStatusCancelled,
+	}[to]
+	if booking != "" {
+		_, err = tx.Exec(ctx,
+			`UPDATE seat_bookings SET status = $1, updated_at = now() WHERE trip_id = $2 AND status = 'booked'`,
+			booking, id,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// listTrips runs a trips query and attaches bookings of the returned trips
+func (r *TripRepo) listTrips(ctx context.Context, query string, args ...interface{}) ([]*domain.SharedTrip, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	trips := []*domain.SharedTrip{}
+	byID := map[string]*domain.SharedTrip{}
+	ids := []string{}
+	for rows.Next() {
+		t, err := scanTrip(rows)
+		if err != nil {
+			return nil, err
+		}
+		trips = append(trips, t)
+		byID[t.ID] = t
+		ids = append(ids, t.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return trips, nil
+	}
+	brows, err := r.pool.Query(ctx,
+		`SELECT `+bookingColumns+` FROM seat_bookings WHERE trip_id = ANY($1) ORDER BY created_at`,
+		ids,
+	)
+	if err != nil {
+		return nil, err
+	}
+	bookings, err := scanBookings(brows)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bookings {
+		byID[b.TripID].Bookings = append(byID[b.TripID].Bookings, b)
+	}
+	return trips, nil
+}
+
+func scanTrip(row rowScanner) (*domain.SharedTrip, error) {
+	var t domain.SharedTrip
+	var route []byte
+	err := row.Scan(&t.ID, &t.DriverID, &t.Category, &t.Status, &route, &t.RouteKM, &t.DepartAt,
+		&t.Capacity, &t.SeatPrice, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(route, &t.Route); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func scanBookings(rows pgx.Rows) ([]*domain.SeatBooking, error) {
+	defer rows.Close()
+	var out []*domain.SeatBooking
+	for rows.Next() {
+		var b domain.SeatBooking
+		var pickup, dropoff []byte
+		err := rows.Scan(&b.ID, &b.TripID, &b.PassengerID, &b.Seats, &pickup, &dropoff,
+			&b.PickupKM, &b.DropoffKM, &b.Price, &b.Status, &b.CreatedAt, &b.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(pickup, &b.Pickup); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(dropoff, &b.Dropoff); err != nil {
+			return nil, err
+		}
+		out = append(out, &b)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,169 @@
+// Package stream — per-ride fan-out of ride events to SSE subscribers with a short
+// replay buffer for Last-Event-ID resumption. Every instance feeds its hub from the
+// ride.events topic (all partitions), so a client may reconnect to any replica.
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+const (
+	// DefaultReplaySize — events kept per ride for resumption
+	DefaultReplaySize = 100
+	// DefaultReplayTTL — how long a ride's buffer outlives its last event without subscribers
+	DefaultReplayTTL = 10 * time.Minute
+	// subscriberBuffer — events queued per subscriber; a subscriber that falls further
+	// behind is disconnected and resumes with Last-Event-ID
+	subscriberBuffer = 64
+	sweepInterval    = time.Minute
+)
+
+type rideStream struct {
+	events []domain.RideEvent
+	subs   map[*Subscription]struct{}
+	last   time.Time // last event
+}
+
+// Hub — in-memory ride event streams
+type Hub struct {
+	mu        sync.Mutex
+	rides     map[string]*rideStream
+	seq       int64 // ids of events published without one (no Kafka)
+	size      int
+	ttl       time.Duration
+	lastSweep time.Time
+	closed    bool
+	now       func() time.Time
+}
+
+// NewHub creates the hub; zero size/ttl use the defaults
+func NewHub(size int, ttl time.Duration) *Hub {
+	if size <= 0 {
+		size = DefaultReplaySize
+	}
+	if ttl <= 0 {
+		ttl = DefaultReplayTTL
+	}
+	return &Hub{rides: map[string]*rideStream{}, size: size, ttl: ttl, now: time.Now}
+}
+
+// Subscription — live events of one ride; C is closed when the subscriber is dropped
+// (too slow) or the hub shuts down
+type Subscription struct {
+	C      chan domain.RideEvent
+	hub    *Hub
+	rideID string
+}
+
+// Publish appends the event to the ride's buffer and delivers it to subscribers.
+// Events without an id get the next local sequence number.
+func (h *Hub) Publish(e domain.RideEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	now := h.now()
+	if e.ID == 0 {
+		h.seq++
+		e.ID = h.seq
+	}
+	rs := h.ride(e.RideID)
+	if n := len(rs.events); n > 0 && e.ID <= rs.events[n-1].ID {
+		return // redelivered
+	}
+	rs.events = append(rs.events, e)
+	if len(rs.events) > h.size {
+		rs.events = append(rs.events[:0], rs.events[len(rs.events)-h.size:]...)
+	}
+	rs.last = now
+	for sub := range rs.subs {
+		select {
+		case sub.C <- e:
+		default:
+			delete(rs.subs, sub)
+			close(sub.C)
+		}
+	}
+	h.sweep(now)
+}
+
+// Subscribe registers for the ride's new events. With resume, buffered events after
+// lastID are returned for replay; complete is false when lastID is not in the buffer
+// (evicted, or seen by another replica before this one started) — events may be
+// missing and the client must refetch the ride and its bids.
+func (h *Hub) Subscribe(rideID string, lastID int64, resume bool) (replay []domain.RideEvent, complete bool, sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub = &Subscription{C: make(chan domain.RideEvent, subscriberBuffer), hub: h, rideID: rideID}
+	if h.closed {
+		close(sub.C)
+		return nil, true, sub
+	}
+	rs := h.ride(rideID)
+	rs.subs[sub] = struct{}{}
+	if !resume {
+		return nil, true, sub
+	}
+	for i, e := range rs.events {
+		if e.ID == lastID {
+			return append([]domain.RideEvent(nil), rs.events[i+1:]...), true, sub
+		}
+	}
+	if n := len(rs.events); n > 0 && lastID > rs.events[n-1].ID {
+		return nil, true, sub // client is ahead of this replica's buffer: nothing to replay
+	}
+	return append([]domain.RideEvent(nil), rs.events...), false, sub
+}
+
+// Close unsubscribes
+func (s *Subscription) Close() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if rs, ok := h.rides[s.rideID]; ok {
+		if _, ok := rs.subs[s]; ok {
+			delete(rs.subs, s)
+			close(s.C)
+		}
+	}
+}
+
+// Shutdown disconnects all subscribers; later publishes are dropped
+func (h *Hub) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, rs := range h.rides {
+		for sub := range rs.subs {
+			close(sub.C)
+		}
+		rs.subs = map[*Subscription]struct{}{}
+	}
+}
+
+func (h *Hub) ride(rideID string) *rideStream {
+	rs, ok := h.rides[rideID]
+	if !ok {
+		rs = &rideStream{subs: map[*Subscription]struct{}{}, last: h.now()}
+		h.rides[rideID] = rs
+	}
+	return rs
+}
+
+// sweep drops buffers of rides without subscribers and events for longer than the TTL;
+// called with the lock held, at most once per sweepInterval
+func (h *Hub) sweep(now time.Time) {
+	if now.Sub(h.lastSweep) < sweepInterval {
+		return
+	}
+	h.lastSweep = now
+	for id, rs := range h.rides {
+		if len(rs.subs) == 0 && now.Sub(rs.last) > h.ttl {
+			delete(h.rides, id)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+func ids(events []domain.RideEvent) []int64 {
+	var out []int64
+	for _, e := range events {
+		out = append(out, e.ID)
+	}
+	return out
+}
+
+func TestHub_ReplayFromLastEventID(t *testing.T) {
+	h := NewHub(3, 0)
+	for id := int64(10); id <= 14; id++ {
+		h.Publish(domain.RideEvent{ID: id, RideID: "r1", Type: domain.EventBidPlaced})
+	}
+	h.Publish(domain.RideEvent{ID: 13, RideID: "r1"}) // redelivered
+	h.Publish(domain.RideEvent{ID: 20, RideID: "r2"})
+
+	replay, complete, sub := h.Subscribe("r1", 12, true)
+	defer sub.Close()
+	if !complete || len(replay) != 2 || replay[0].ID != 13 || replay[1].ID != 14 {
+		t.Fatalf("expected 13,14 after 12, got %v complete=%v", ids(replay), complete)
+	}
+	if replay, complete, _ := h.Subscribe("r1", 10, true); complete || len(replay) != 3 {
+		t.Errorf("evicted id must report a gap, got %v complete=%v", ids(replay), complete)
+	}
+	if _, complete, _ := h.Subscribe("r3", 5, true); complete {
+		t.Error("unknown ride must report a gap on resume")
+	}
+
+	h.Publish(domain.RideEvent{ID: 15, RideID: "r1"})
+	h.Publish(domain.RideEvent{ID: 21, RideID: "r2"})
+	if e := <-sub.C; e.ID != 15 {
+		t.Errorf("expected live event 15, got %d", e.ID)
+	}
+	select {
+	case e := <-sub.C:
+		t.Errorf("event of another ride delivered: %+v", e)
+	default:
+	}
+}
+
+func TestHub_DropsSlowSubscriberAndShutsDown(t *testing.T) {
+	h := NewHub(0, 0)
+	_, _, slow := h.Subscribe("r1", 0, false)
+	_, _, other := h.Subscribe("r1", 0, false)
+	for i := 0; i < subscriberBuffer+1; i++ {
+		h.Publish(domain.RideEvent{RideID: "r1"})
+		<-other.C
+	}
+	n := 0
+	for range slow.C {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Errorf("slow subscriber must get its buffer and be closed, got %d events", n)
+	}
+	slow.Close() // already dropped: no double close
+
+	h.Shutdown()
+	if _, ok := <-other.C; ok {
+		t.Error("shutdown must close subscriptions")
+	}
+	other.Close()
+}
@@ -0,0 +1,135 @@
+// Package usersvc — HTTP client for the user service (geocoding, profiles, driver vehicles)
+package usersvc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+var ErrNotFound = errors.New("not found in user service")
+
+// TokenSource issues bearer tokens for service-to-service calls
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// Client — user service API client
+type Client struct {
+	baseURL string
+	tokens  TokenSource
+	http    *http.Client
+}
+
+// New creates user service client
+func New(baseURL string, tokens TokenSource, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		tokens:  tokens,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+type addressResponse struct {
+	Formatted string `json:"formatted"`
+}
+
+// ReverseGeocode returns the formatted address for coordinates
+// (GET /api/v1/maps/geocode/reverse, provider follows admin map settings)
+func (c *Client) ReverseGeocode(ctx context.Context, lat, lng float64) (string, error) {
+	params := url.Values{}
+	params.Set("lat", strconv.FormatFloat(lat, 'f', 6, 64))
+	params.Set("lng", strconv.FormatFloat(lng, 'f', 6, 64))
+	var resp addressResponse
+	if err := c.get(ctx, "/api/v1/maps/geocode/reverse?"+params.Encode(), &resp); err != nil {
+		return "", err
+	}
+	return resp.Formatted, nil
+}
+
+// DriverVehicle returns class/features/approved document types of the driver's vehicle
+// (GET /api/v1/drivers/:id/attributes); nil when the driver has no approved verification
+func (c *Client) DriverVehicle(ctx context.Context, driverID string) (*domain.Vehicle, error) {
+	var v domain.Vehicle
+	err := c.get(ctx, "/api/v1/drivers/"+url.PathEscape(driverID)+"/attributes", &v)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// DriverEligibility returns the driver's verification snapshot for the bid gate
+// (GET /api/v1/drivers/:id/eligibility)
+func (c *Client) DriverEligibility(ctx context.Context, driverID string) (*domain.DriverEligibility, error) {
+	var e domain.DriverEligibility
+	err := c.get(ctx, "/api/v1/drivers/"+url.PathEscape(driverID)+"/eligibility", &e)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// maxCardsBatch — driver ids per cards request (user service limit)
+const maxCardsBatch = 100
+
+type driverCardsResponse struct {
+	Drivers []*domain.DriverCard `json:"drivers"`
+}
+
+// DriverCards returns profile and approved vehicle per driver
+// (GET /api/v1/drivers/cards?ids=); drivers without approved verification are omitted
+func (c *Client) DriverCards(ctx context.Context, driverIDs []string) (map[string]*domain.DriverCard, error) {
+	out := make(map[string]*domain.DriverCard, len(driverIDs))
+	for start := 0; start < len(driverIDs); start += maxCardsBatch {
+		end := min(start+maxCardsBatch, len(driverIDs))
+		var resp driverCardsResponse
+		if err := c.get(ctx, "/api/v1/drivers/cards?ids="+url.QueryEscape(strings.Join(driverIDs[start:end], ",")), &resp); err != nil {
+			return nil, err
+		}
+		for _, d := range resp.Drivers {
+			out[d.DriverID] = d
+		}
+	}
+	return out, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, dst interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	token, err := c.tokens.Token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("user service %s: status %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/kafka"
+	"github.com/ridehail/ride/internal/infra/pg"
+)
+
+type matchEvents struct {
+	kafka.NoopProducer
+	auto []bool
+}
+
+func (p *matchEvents) SendRideMatched(ctx context.Context, rideID, passengerID, driverID string, price float64, payment domain.RidePayment, auto bool) error {
+	p.auto = append(p.auto, auto)
+	return nil
+}
+
+type fakeRatings map[string]*domain.UserRating
+
+func (f fakeRatings) GetUserRatingSummaries(ctx context.Context, ids []string, role string) (map[string]*domain.UserRating, error) {
+	return f, nil
+}
+
+func TestRideUseCase_PlaceBid_AutoAcceptsFirstQualifying(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{
+		ID: "r1", PassengerID: "p1", Status: domain.StatusBidding, Category: domain.CategoryEconomy, Seats: 1,
+		From: testFrom, To: testTo, AutoAccept: &domain.AutoAccept{MaxPrice: 600, MinRating: 4.5},
+	}}}
+	bids := &bidStore{}
+	rides.bids = bids
+	events := &matchEvents{}
+	ratings := fakeRatings{
+		"good":   {UserID: "good", AverageScore: 4.9, TotalRatings: 120},
+		"low":    {UserID: "low", AverageScore: 4.2, TotalRatings: 40},
+		"pricey": {UserID: "pricey", AverageScore: 5, TotalRatings: 10},
+	}
+	enricher := NewBidEnricher(nil, nil, ratings, DefaultBidEnricherConfig())
+	uc := NewRideUseCase(rides, bids, events, nil, nil, nil, enricher)
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		driverID string
+		price    float64
+		status   string
+	}{
+		{"low", 500, pg.BidStatusPending},
+		{"pricey", 650, pg.BidStatusPending},
+		{"new", 400, pg.BidStatusPending}, // no ratings yet
+		{"good", 550, pg.BidStatusAccepted},
+	} {
+		bid, err := uc.PlaceBid(ctx, "r1", tc.driverID, "driver", tc.price)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.driverID, err)
+		}
+		if bid.Status != tc.status {
+			t.Errorf("%s: expected %s, got %s", tc.driverID, tc.status, bid.Status)
+		}
+	}
+	if rides.rides[0].DriverID != "good" || len(bids.accepted) != 1 || len(events.auto) != 1 || !events.auto[0] {
+		t.Fatalf("expected one automatic match with good: driver=%s accepted=%v events=%v", rides.rides[0].DriverID, bids.accepted, events.auto)
+	}
+	if _, err := uc.PlaceBid(ctx, "r1", "good", "driver", 500); err != ErrRideNotBidding {
+		t.Errorf("bids after the match must fail, got %v", err)
+	}
+	if _, err := uc.AcceptBid(ctx, "r1", "b1", "p1"); !errors.Is(err, ErrInvalidStatus) {
+		t.Errorf("manual accept after the automatic match must fail, got %v", err)
+	}
+}
+
+func TestRideUseCase_CreateRide_ValidatesAutoAccept(t *testing.T) {
+	uc := NewRideUseCase(&rideStore{}, nil, &kafka.NoopProducer{}, nil, nil, nil, nil)
+	for _, a := range []domain.AutoAccept{{}, {MinRating: 6}, {MaxPrice: -1}} {
+		_, err := uc.CreateRide(context.Background(), CreateRideInput{PassengerID: "p1", From: testFrom, To: testTo, AutoAccept: &a})
+		if err != domain.ErrInvalidAutoAccept {
+			t.Errorf("%+v: expected ErrInvalidAutoAccept, got %v", a, err)
+		}
+	}
+}
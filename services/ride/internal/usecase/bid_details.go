@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/batchcache"
+)
+
+// DriverDirectory — driver profile and approved vehicle cards (user service), batched;
+// drivers without an approved verification are absent from the result
+type DriverDirectory interface {
+	DriverCards(ctx context.Context, driverIDs []string) (map[string]*domain.DriverCard, error)
+}
+
+// DriverLocator — current driver positions (geolocation service), batched;
+// offline drivers are absent from the result
+type DriverLocator interface {
+	DriverLocations(ctx context.Context, driverIDs []string) (map[string]domain.Point, error)
+}
+
+// RatingSummaries — aggregated ratings of many users in one role
+type RatingSummaries interface {
+	GetUserRatingSummaries(ctx context.Context, userIDs []string, role string) (map[string]*domain.UserRating, error)
+}
+
+// BidEnricherConfig — cache lifetimes and ETA model of bid enrichment
+type BidEnricherConfig struct {
+	CardTTL     time.Duration // profiles and vehicles change rarely
+	RatingTTL   time.Duration
+	LocationTTL time.Duration // drivers move; keep short
+	SpeedKMH    float64       // average urban speed for the ETA
+	RoadFactor  float64       // road distance / straight-line distance
+}
+
+// DefaultBidEnricherConfig — 5m cards, 1m ratings, 10s positions, 25 km/h with roads 1.3× longer
+func DefaultBidEnricherConfig() BidEnricherConfig {
+	return BidEnricherConfig{
+		CardTTL:     5 * time.Minute,
+		RatingTTL:   time.Minute,
+		LocationTTL: 10 * time.Second,
+		SpeedKMH:    25,
+		RoadFactor:  1.3,
+	}
+}
+
+// BidEnricher — attaches driver card, rating and ETA to bids. Each source is asked once
+// per bid list for all bidders, through a cache, so a ride with many bids costs at most
+// one call per source.
+type BidEnricher struct {
+	cards     *batchcache.Cache[*domain.DriverCard]
+	ratings   *batchcache.Cache[*domain.UserRating]
+	locations *batchcache.Cache[domain.Point]
+	cfg       BidEnricherConfig
+}
+
+// NewBidEnricher creates the enricher; any source may be nil (its fields are omitted)
+func NewBidEnricher(directory DriverDirectory, locator DriverLocator, ratings RatingSummaries, cfg BidEnricherConfig) *BidEnricher {
+	def := DefaultBidEnricherConfig()
+	if cfg.CardTTL <= 0 {
+		cfg.CardTTL = def.CardTTL
+	}
+	if cfg.RatingTTL <= 0 {
+		cfg.RatingTTL = def.RatingTTL
+	}
+	if cfg.LocationTTL <= 0 {
+		cfg.LocationTTL = def.LocationTTL
+	}
+	if cfg.SpeedKMH <= 0 {
+		cfg.SpeedKMH = def.SpeedKMH
+	}
+	if cfg.RoadFactor < 1 {
+		cfg.RoadFactor = def.RoadFactor
+	}
+	e := &BidEnricher{cfg: cfg}
+	if directory != nil {
+		e.cards = batchcache.New(cfg.CardTTL, directory.DriverCards)
+	}
+	if ratings != nil {
+		e.ratings = batchcache.New(cfg.RatingTTL, func(ctx context.Context, ids []string) (map[string]*domain.UserRating, error) {
+			return ratings.GetUserRatingSummaries(ctx, ids, "driver")
+		})
+	}
+	if locator != nil {
+		e.locations = batchcache.New(cfg.LocationTTL, locator.DriverLocations)
+	}
+	return e
+}
+
+// Enrich returns bids with driver details; lookups run concurrently and failures only
+// leave the affected fields empty. ETA is measured to the ride's pickup point.
+func (e *BidEnricher) Enrich(ctx context.Context, ride *domain.Ride, bids []*domain.Bid) []*domain.BidDetails {
+	ids := make([]string, 0, len(bids))
+	for _, b := range bids {
+		ids = append(ids, b.DriverID)
+	}
+	var (
+		wg        sync.WaitGroup
+		cards     map[string]*domain.DriverCard
+		ratings   map[string]*domain.UserRating
+		locations map[string]domain.Point
+	)
+	if e.cards != nil {
+		wg.Add(1)
+		go func() { defer wg.Done(); cards, _ = e.cards.Get(ctx, ids) }()
+	}
+	if e.ratings != nil {
+		wg.Add(1)
+		go func() { defer wg.Done(); ratings, _ = e.ratings.Get(ctx, ids) }()
+	}
+	if e.locations != nil && ride != nil {
+		wg.Add(1)
+		go func() { defer wg.Done(); locations, _ = e.locations.Get(ctx, ids) }()
+	}
+	wg.Wait()
+
+	out := make([]*domain.BidDetails, 0, len(bids))
+	for _, b := range bids {
+		d := &domain.BidDetails{Bid: b, Driver: cards[b.DriverID], Rating: ratings[b.DriverID]}
+		if pos, ok := locations[b.DriverID]; ok {
+			km := domain.HaversineKM(pos, ride.From)
+			eta := e.etaMinutes(km)
+			km = math.Round(km*10) / 10
+			d.DistanceKM, d.ETAMinutes = &km, &eta
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// etaMinutes — straight-line km stretched to road distance at the average speed, at least 1 minute
+func (e *BidEnricher) etaMinutes(km float64) int {
+	minutes := int(math.Ceil(km * e.cfg.RoadFactor / e.cfg.SpeedKMH * 60))
+	if minutes < 1 {
+		return 1
+	}
+	return minutes
+}
+
+// plainBidDetails wraps bids without enrichment
+func plainBidDetails(bids []*domain.Bid) []*domain.BidDetails {
+	out := make([]*domain.BidDetails, 0, len(bids))
+	for _, b := range bids {
+		out = append(out, &domain.BidDetails{Bid: b})
+	}
+	return out
+}
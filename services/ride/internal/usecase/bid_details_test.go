@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/kafka"
+)
+
+type fakeDirectory struct{ calls [][]string }
+
+func (f *fakeDirectory) DriverCards(ctx context.Context, ids []string) (map[string]*domain.DriverCard, error) {
+	f.calls = append(f.calls, ids)
+	out := map[string]*domain.DriverCard{}
+	for _, id := range ids {
+		if id != "unverified" {
+			out[id] = &domain.DriverCard{DriverID: id, DisplayName: "Driver " + id, VehiclePlate: "A001AA"}
+		}
+	}
+	return out, nil
+}
+
+type fakeLocator map[string]domain.Point
+
+func (f fakeLocator) DriverLocations(ctx context.Context, ids []string) (map[string]domain.Point, error) {
+	return f, nil
+}
+
+type failingRatings struct{}
+
+func (failingRatings) GetUserRatingSummaries(ctx context.Context, ids []string, role string) (map[string]*domain.UserRating, error) {
+	return nil, errors.New("db down")
+}
+
+func TestRideUseCase_ListBids_Enriched(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{ID: "r1", Status: domain.StatusBidding, From: testFrom, To: testTo}}}
+	bids := &bidStore{bids: []*domain.Bid{
+		{ID: "b1", RideID: "r1", DriverID: "d1", Price: 500},
+		{ID: "b2", RideID: "r1", DriverID: "unverified", Price: 450},
+		{ID: "b3", RideID: "r1", DriverID: "d1", Price: 480},
+	}}
+	directory := &fakeDirectory{}
+	// ~2.2 km north of pickup: 2.2 × 1.3 / 25 km/h ≈ 7 min
+	locator := fakeLocator{"d1": {Lat: testFrom.Lat + 0.02, Lng: testFrom.Lng}}
+	enricher := NewBidEnricher(directory, locator, failingRatings{}, DefaultBidEnricherConfig())
+	uc := NewRideUseCase(rides, bids, &kafka.NoopProducer{}, nil, nil, nil, enricher)
+	ctx := context.Background()
+
+	got, err := uc.ListBids(ctx, "r1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].ID != "b1" || got[0].Driver == nil || got[0].Driver.DisplayName != "Driver d1" {
+		t.Fatalf("expected card on d1's bid, got %+v", got[0])
+	}
+	if got[0].ETAMinutes == nil || *got[0].ETAMinutes != 7 || *got[0].DistanceKM != 2.2 {
+		t.Errorf("expected 7 min / 2.2 km, got %v / %v", got[0].ETAMinutes, got[0].DistanceKM)
+	}
+	if got[1].Driver != nil || got[1].ETAMinutes != nil || got[0].Rating != nil {
+		t.Errorf("unavailable parts must be omitted: %+v", got[1])
+	}
+
+	if _, err := uc.ListBids(ctx, "r1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(directory.calls) != 1 || len(directory.calls[0]) != 2 {
+		t.Errorf("expected one batched lookup of 2 drivers, got %v", directory.calls)
+	}
+}
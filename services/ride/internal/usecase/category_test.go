@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/kafka"
+	"github.com/ridehail/ride/internal/infra/pg"
+)
+
+func TestRideUseCase_CreateRide_CourierNeedsContacts(t *testing.T) {
+	repo := &rideStore{}
+	uc := NewRideUseCase(repo, nil, &kafka.NoopProducer{}, nil, nil, nil, nil)
+	ctx := context.Background()
+	in := CreateRideInput{PassengerID: "p1", From: testFrom, To: testTo, Category: domain.CategoryCourier}
+
+	if _, err := uc.CreateRide(ctx, in); err != ErrDeliveryRequired {
+		t.Fatalf("expected ErrDeliveryRequired, got %v", err)
+	}
+	in.Delivery = &domain.Delivery{
+		Sender:    domain.Contact{Name: "Anna", Phone: "+7 (999) 123-45-67"},
+		Recipient: domain.Contact{Name: "Oleg", Phone: "bad"},
+	}
+	if _, err := uc.CreateRide(ctx, in); err != domain.ErrInvalidContacts {
+		t.Fatalf("expected ErrInvalidContacts, got %v", err)
+	}
+	in.Delivery.Recipient.Phone = "89991234568"
+	ride, err := uc.CreateRide(ctx, in)
+	if err != nil {
+		t.Fatalf("CreateRide: %v", err)
+	}
+	if len(ride.Delivery.Code) != 4 || ride.Delivery.Sender.Phone != "+79991234567" {
+		t.Errorf("delivery not prepared: %+v", ride.Delivery)
+	}
+	if ride.Redacted("").Delivery.Code != "" || ride.Delivery.Code == "" {
+		t.Error("Redacted must strip the code without touching the original")
+	}
+
+	in.Category = domain.CategoryEconomy
+	if _, err := uc.CreateRide(ctx, in); err != ErrDeliveryNotAllowed {
+		t.Errorf("expected ErrDeliveryNotAllowed, got %v", err)
+	}
+}
+
+func TestRideUseCase_CreateRide_IntercitySeatsAndSchedule(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	uc := NewRideUseCase(&rideStore{}, nil, &kafka.NoopProducer{}, nil, nil, nil, nil)
+	uc.now = func() time.Time { return now }
+	ctx := context.Background()
+	at := func(d time.Duration) *time.Time { t := now.Add(d); return &t }
+
+	cases := []struct {
+		name string
+		in   CreateRideInput
+		err  error
+	}{
+		{"intercity in ten days", CreateRideInput{Category: domain.CategoryIntercity, Seats: 3, ScheduledAt: at(240 * time.Hour)}, nil},
+		{"intercity too many seats", CreateRideInput{Category: domain.CategoryIntercity, Seats: 5}, ErrInvalidSeats},
+		{"intercity beyond window", CreateRideInput{Category: domain.CategoryIntercity, ScheduledAt: at(15 * 24 * time.Hour)}, ErrInvalidSchedule},
+		{"economy beyond window", CreateRideInput{ScheduledAt: at(48 * time.Hour)}, ErrInvalidSchedule},
+		{"economy in the past", CreateRideInput{ScheduledAt: at(-time.Minute)}, ErrInvalidSchedule},
+		{"economy seats", CreateRideInput{Seats: 2}, ErrInvalidSeats},
+		{"cargo class on comfort", CreateRideInput{Category: domain.CategoryComfort, Options: domain.RideOptions{VehicleClass: domain.VehicleClassCargo}}, domain.ErrInvalidRideOptions},
+		{"unknown category", CreateRideInput{Category: "helicopter"}, domain.ErrUnknownCategory},
+	}
+	for _, tc := range cases {
+		tc.in.PassengerID, tc.in.From, tc.in.To = "p1", testFrom, testTo
+		ride, err := uc.CreateRide(ctx, tc.in)
+		if err != tc.err {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.err, err)
+			continue
+		}
+		if err == nil && (ride.Category != domain.CategoryIntercity || ride.Seats != 3) {
+			t.Errorf("%s: unexpected ride %+v", tc.name, ride)
+		}
+	}
+}
+
+func TestRideUseCase_PlaceBid_CategoryRules(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{
+		ID: "r1", Status: domain.StatusBidding, Category: domain.CategoryCargo, From: testFrom, To: testTo,
+	}}}
+	vehicles := fakeVehicles{
+		"van":         {Class: domain.VehicleClassCargo, Documents: []string{domain.DocLicense, domain.DocPhoto, domain.DocVehicleReg, domain.DocInsurance}},
+		"van-no-docs": {Class: domain.VehicleClassCargo, Documents: basicDocs},
+		"sedan":       testVehicles["business"],
+	}
+	bids := &bidStore{}
+	uc := NewRideUseCase(rides, bids, &kafka.NoopProducer{}, nil, vehicles, nil, nil)
+	ctx := context.Background()
+
+	for _, driverID := range []string{"van-no-docs", "sedan"} {
+		if _, err := uc.PlaceBid(ctx, "r1", driverID, "driver", 1000); err != ErrCategoryNotAllowed {
+			t.Errorf("%s: expected ErrCategoryNotAllowed, got %v", driverID, err)
+		}
+	}
+	_, err := uc.PlaceBid(ctx, "r1", "van", "driver", 100)
+	var rangeErr *BidRangeError
+	if !errors.As(err, &rangeErr) || !errors.Is(err, ErrBidOutOfRange) || rangeErr.Floor != 600 {
+		t.Fatalf("expected bid range error with floor 600, got %v", err)
+	}
+	if _, err := uc.PlaceBid(ctx, "r1", "van", "driver", rangeErr.Ceiling+1); !errors.Is(err, ErrBidOutOfRange) {
+		t.Errorf("bid over the ceiling: got %v", err)
+	}
+	if _, err := uc.PlaceBid(ctx, "r1", "van", "driver", 1000); err != nil || len(bids.bids) != 1 {
+		t.Errorf("bid in range: err=%v bids=%d", err, len(bids.bids))
+	}
+}
+
+func TestRideUseCase_AcceptBid_SeatPricing(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{
+		ID: "r1", PassengerID: "p1", Status: domain.StatusBidding, Category: domain.CategoryIntercity, Seats: 3,
+	}}}
+	bids := &bidStore{bids: []*domain.Bid{{ID: "b1", RideID: "r1", DriverID: "d1", Price: 700, Status: pg.BidStatusPending}}}
+	rides.bids = bids
+	uc := NewRideUseCase(rides, bids, &kafka.NoopProducer{}, nil, nil, nil, nil)
+
+	ride, err := uc.AcceptBid(context.Background(), "r1", "b1", "p1")
+	if err != nil {
+		t.Fatalf("AcceptBid: %v", err)
+	}
+	if ride.Price == nil || *ride.Price != 2100 {
+		t.Errorf("expected 3 seats × 700 = 2100, got %v", ride.Price)
+	}
+}
+
+func TestCategoryRules_FaresInKopecks(t *testing.T) {
+	rules, _ := domain.RulesFor(domain.CategoryIntercity)
+	if got := rules.Total(333.33, 3); got != 999.99 { // float 333.33*3 is 999.9899999999999
+		t.Errorf("3 seats × 333.33 = %v", got)
+	}
+	floor, ceiling := rules.BidRange(123.4567)
+	if floor != domain.RoundFare(floor) || ceiling != domain.RoundFare(ceiling) {
+		t.Errorf("bid range must be in kopecks: %v–%v", floor, ceiling)
+	}
+	if domain.RoundFare(199.995) != 200 {
+		t.Error("half a kopeck rounds up")
+	}
+}
+
+func TestRideUseCase_ConfirmDelivery(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{
+		ID: "r1", DriverID: "d1", Status: domain.StatusInProgress, Category: domain.CategoryCourier,
+		Delivery: &domain.Delivery{Code: "4821"},
+	}}}
+	uc := NewRideUseCase(rides, nil, &kafka.NoopProducer{}, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	if _, err := uc.UpdateStatus(ctx, "r1", domain.StatusCompleted, "d1", "driver", ""); err != ErrDeliveryCodeRequired {
+		t.Fatalf("courier ride must not complete without the code, got %v", err)
+	}
+	if _, err := uc.ConfirmDelivery(ctx, "r1", "d2", "4821"); err != ErrNotDriver {
+		t.Errorf("other driver: expected ErrNotDriver, got %v", err)
+	}
+	if _, err := uc.ConfirmDelivery(ctx, "r1", "d1", "0000"); err != ErrInvalidDeliveryCode {
+		t.Errorf("wrong code: expected ErrInvalidDeliveryCode, got %v", err)
+	}
+	ride, err := uc.ConfirmDelivery(ctx, "r1", "d1", " 4821 ")
+	if err != nil || ride.Status != domain.StatusCompleted || ride.Delivery.ConfirmedAt == nil {
+		t.Fatalf("ConfirmDelivery = %+v, %v", ride, err)
+	}
+}
+
+func TestRideUseCase_ConfirmDelivery_LocksAfterAttempts(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{
+		ID: "r1", DriverID: "d1", Status: domain.StatusInProgress, Category: domain.CategoryCourier,
+		Delivery: &domain.Delivery{Code: "4821"},
+	}}}
+	uc := NewRideUseCase(rides, nil, &kafka.NoopProducer{}, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	for i := 1; i < domain.MaxDeliveryAttempts; i++ {
+		if _, err := uc.ConfirmDelivery(ctx, "r1", "d1", "1111"); err != ErrInvalidDeliveryCode {
+			t.Fatalf("attempt %d: expected ErrInvalidDeliveryCode, got %v", i, err)
+		}
+	}
+	if _, err := uc.ConfirmDelivery(ctx, "r1", "d1", "1111"); err != ErrDeliveryLocked {
+		t.Fatalf("last attempt: expected ErrDeliveryLocked, got %v", err)
+	}
+	if _, err := uc.ConfirmDelivery(ctx, "r1", "d1", "4821"); err != ErrDeliveryLocked {
+		t.Errorf("locked delivery must reject even the right code, got %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+var ErrChatClosed = errors.New("chat is open only while the ride is matched or in progress")
+
+// ChatRepository — in-ride chat persistence
+type ChatRepository interface {
+	Create(ctx context.Context, m *domain.ChatMessage) error
+	// ListByRide returns messages oldest first, created after since (zero = from the start)
+	ListByRide(ctx context.Context, rideID string, since time.Time, limit int) ([]*domain.ChatMessage, error)
+	// MarkRead sets read_at on the recipient's unread messages; returns how many were marked
+	MarkRead(ctx context.Context, rideID, recipientID string, at time.Time) (int64, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// ChatConfig — chat history and retention
+type ChatConfig struct {
+	Retention time.Duration // messages are kept for support review this long after sending
+	PageSize  int           // messages per history request
+}
+
+// DefaultChatConfig — 90 days retention, 200 messages per page
+func DefaultChatConfig() ChatConfig {
+	return ChatConfig{Retention: 90 * 24 * time.Hour, PageSize: 200}
+}
+
+// ChatUseCase — chat between the ride's passenger and matched driver. Messages and read
+// receipts are pushed as chat.* ride events (SSE stream, relayed to the driver's
+// tracking WebSocket by geolocation).
+type ChatUseCase struct {
+	repo     ChatRepository
+	rideRepo RideRepository
+	pub      EventPublisher
+	cfg      ChatConfig
+	now      func() time.Time
+}
+
+// NewChatUseCase creates chat use case
+func NewChatUseCase(repo ChatRepository, rideRepo RideRepository, pub EventPublisher, cfg ChatConfig) *ChatUseCase {
+	def := DefaultChatConfig()
+	if cfg.Retention <= 0 {
+		cfg.Retention = def.Retention
+	}
+	if cfg.PageSize <= 0 {
+		cfg.PageSize = def.PageSize
+	}
+	return &ChatUseCase{repo: repo, rideRepo: rideRepo, pub: pub, cfg: cfg, now: time.Now}
+}
+
+// SendMessage stores a message from one participant to the other; text is free text
+// or empty when quickReply names a predefined reply of the sender's role
+func (uc *ChatUseCase) SendMessage(ctx context.Context, rideID, userID, text, quickReply string) (*domain.ChatMessage, error) {
+	ride, role, err := uc.participant(ctx, rideID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ride.Status != domain.StatusMatched && ride.Status != domain.StatusInProgress {
+		return nil, ErrChatClosed
+	}
+	body, err := domain.ChatText(role, text, quickReply)
+	if err != nil {
+		return nil, err
+	}
+	m := &domain.ChatMessage{
+		RideID:      rideID,
+		SenderID:    userID,
+		SenderRole:  role,
+		RecipientID: counterpart(ride, userID),
+		Text:        body,
+		QuickReply:  quickReply,
+	}
+	if err := uc.repo.Create(ctx, m); err != nil {
+		return nil, err
+	}
+	uc.emit(ctx, ride, domain.EventChatMessage, m.RecipientID, m)
+	return m, nil
+}
+
+// ListMessages returns the ride's chat history after since; participants and admins
+// (support review) only. History stays readable after the ride ends until retention.
+func (uc *ChatUseCase) ListMessages(ctx context.Context, rideID, userID, userRole string, since time.Time) ([]*domain.ChatMessage, error) {
+	if userRole != "admin" {
+		if _, _, err := uc.participant(ctx, rideID, userID); err != nil {
+			return nil, err
+		}
+	}
+	return uc.repo.ListByRide(ctx, rideID, since, uc.cfg.PageSize)
+}
+
+// MarkRead marks all messages sent to the user as read and notifies the sender
+func (uc *ChatUseCase) MarkRead(ctx context.Context, rideID, userID string) (int64, error) {
+	ride, _, err := uc.participant(ctx, rideID, userID)
+	if err != nil {
+		return 0, err
+	}
+	at := uc.clock()
+	n, err := uc.repo.MarkRead(ctx, rideID, userID, at)
+	if err != nil || n == 0 {
+		return n, err
+	}
+	uc.emit(ctx, ride, domain.EventChatRead, counterpart(ride, userID), map[string]interface{}{
+		"reader_id": userID,
+		"read_at":   at,
+	})
+	return n, nil
+}
+
+// QuickReplies returns the predefined messages the role may send
+func (uc *ChatUseCase) QuickReplies(role string) []domain.QuickReply {
+	return domain.QuickRepliesFor(role)
+}
+
+// PurgeExpired deletes messages older than the retention period
+func (uc *ChatUseCase) PurgeExpired(ctx context.Context) (int64, error) {
+	return uc.repo.DeleteBefore(ctx, uc.clock().Add(-uc.cfg.Retention))
+}
+
+func (uc *ChatUseCase) participant(ctx context.Context, rideID, userID string) (*domain.Ride, string, error) {
+	return rideParticipant(ctx, uc.rideRepo, rideID, userID)
+}
+
+// rideParticipant loads the ride and returns the user's role in it: the passenger or
+// the matched driver (ErrNotRideParticipant for anyone else)
+func rideParticipant(ctx context.Context, rides RideRepository, rideID, userID string) (*domain.Ride, string, error) {
+	ride, err := rides.GetByID(ctx, rideID)
+	if err != nil || ride == nil {
+		return nil, "", ErrRideNotFound
+	}
+	switch {
+	case userID == ride.PassengerID:
+		return ride, "passenger", nil
+	case ride.DriverID != "" && userID == ride.DriverID:
+		return ride, "driver", nil
+	}
+	return nil, "", ErrNotRideParticipant
+}
+
+// emit publishes a chat event addressed to the recipient; best effort
+func (uc *ChatUseCase) emit(ctx context.Context, ride *domain.Ride, typ, to string, data interface{}) {
+	if uc.pub == nil {
+		return
+	}
+	e, err := domain.NewRideEvent(ride.ID, typ, "", data, uc.clock())
+	if err != nil {
+		return
+	}
+	e.To = to
+	_ = uc.pub.SendRideEvent(ctx, e)
+}
+
+func (uc *ChatUseCase) clock() time.Time {
+	if uc.now == nil {
+		return time.Now()
+	}
+	return uc.now()
+}
+
+// counterpart — the other participant of the ride
+func counterpart(ride *domain.Ride, userID string) string {
+	if userID == ride.PassengerID {
+		return ride.DriverID
+	}
+	return ride.PassengerID
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/kafka"
+)
+
+func TestChatUseCase_SendReadAndClose(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{ID: "r1", PassengerID: "p1", DriverID: "d1", Status: domain.StatusMatched}}}
+	repo := &memChat{}
+	events := &recordedEvents{}
+	uc := NewChatUseCase(repo, rides, &kafka.NoopProducer{Events: events}, ChatConfig{})
+	ctx := context.Background()
+
+	m, err := uc.SendMessage(ctx, "r1", "d1", "", "im_here")
+	if err != nil || m.Text != "I'm here" || m.SenderRole != "driver" || m.RecipientID != "p1" {
+		t.Fatalf("quick reply: %+v, %v", m, err)
+	}
+	if _, err := uc.SendMessage(ctx, "r1", "p1", "", "im_here"); err != domain.ErrInvalidChatMessage {
+		t.Errorf("passenger must not send driver quick replies, got %v", err)
+	}
+	if _, err := uc.SendMessage(ctx, "r1", "d2", "hi", ""); err != ErrNotRideParticipant {
+		t.Errorf("other users must not chat, got %v", err)
+	}
+	if _, err := uc.SendMessage(ctx, "r1", "p1", "  coming  ", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(*events) != 2 || (*events)[0].Type != domain.EventChatMessage || (*events)[0].To != "p1" || (*events)[1].To != "d1" {
+		t.Fatalf("chat events: %+v", *events)
+	}
+
+	if n, err := uc.MarkRead(ctx, "r1", "p1"); err != nil || n != 1 || repo.msgs[0].ReadAt == nil {
+		t.Fatalf("mark read: %d, %v", n, err)
+	}
+	if last := (*events)[len(*events)-1]; last.Type != domain.EventChatRead || last.To != "d1" {
+		t.Errorf("sender must get the read receipt: %+v", last)
+	}
+	if n, _ := uc.MarkRead(ctx, "r1", "p1"); n != 0 || len(*events) != 3 {
+		t.Error("nothing new to read must not emit a receipt")
+	}
+
+	rides.rides[0].Status = domain.StatusCompleted
+	if _, err := uc.SendMessage(ctx, "r1", "p1", "thanks", ""); err != ErrChatClosed {
+		t.Errorf("chat must close with the ride, got %v", err)
+	}
+	if msgs, err := uc.ListMessages(ctx, "r1", "p1", "passenger", time.Time{}); err != nil || len(msgs) != 2 {
+		t.Errorf("history stays readable: %d, %v", len(msgs), err)
+	}
+	if msgs, err := uc.ListMessages(ctx, "r1", "support", "admin", time.Time{}); err != nil || len(msgs) != 2 {
+		t.Errorf("admins review chats: %d, %v", len(msgs), err)
+	}
+	if _, err := uc.ListMessages(ctx, "r1", "d2", "driver", time.Time{}); err != ErrNotRideParticipant {
+		t.Errorf("other drivers must not read, got %v", err)
+	}
+
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	uc.now = func() time.Time { return now }
+	if _, err := uc.PurgeExpired(ctx); err != nil || !repo.cutoff.Equal(now.Add(-DefaultChatConfig().Retention)) {
+		t.Errorf("purge cutoff %v, %v", repo.cutoff, err)
+	}
+}
@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/pg"
+)
+
+var (
+	ErrInvalidDestination      = errors.New("invalid destination coordinates")
+	ErrDestinationLimitReached = errors.New("destination mode daily limit reached")
+)
+
+// DestinationRepository — driver destination + per-day usage persistence
+type DestinationRepository interface {
+	Get(ctx context.Context, driverID string) (*domain.DriverDestination, error)
+	// Activate sets the destination and counts one use for today; returns
+	// pg.ErrDestinationLimit when the driver already used dailyLimit activations.
+	Activate(ctx context.Context, d *domain.DriverDestination, dailyLimit int) error
+	Deactivate(ctx context.Context, driverID string) error
+	ListActive(ctx context.Context, driverIDs []string) (map[string]*domain.DriverDestination, error)
+}
+
+// DestinationConfig — destination mode rules
+type DestinationConfig struct {
+	DailyLimit  int     // activations per driver per day
+	MinProgress float64 // min fraction of distance to destination a ride must cover (0..1)
+	FeedFetch   int     // open rides fetched before filtering (filter drops most of them)
+}
+
+// DefaultDestinationConfig — 2 uses/day, ride must cover 30% of the way home
+func DefaultDestinationConfig() DestinationConfig {
+	return DestinationConfig{DailyLimit: 2, MinProgress: 0.3, FeedFetch: 200}
+}
+
+// DispatchCandidate — driver considered for push dispatch of a ride
+type DispatchCandidate struct {
+	DriverID string        `json:"driver_id"`
+	Location *domain.Point `json:"location,omitempty"` // current position from geolocation, if known
+}
+
+// DestinationUseCase — driver destination mode: set/clear destination, filter feed and dispatch
+type DestinationUseCase struct {
+	repo     DestinationRepository
+	rideRepo RideRepository
+	vehicles VehicleSource // optional: feed not filtered by category or ride options when nil
+	cfg      DestinationConfig
+}
+
+// NewDestinationUseCase creates destination mode use case
+func NewDestinationUseCase(repo DestinationRepository, rideRepo RideRepository, vehicles VehicleSource, cfg DestinationConfig) *DestinationUseCase {
+	if cfg.DailyLimit <= 0 {
+		cfg.DailyLimit = DefaultDestinationConfig().DailyLimit
+	}
+	if cfg.MinProgress <= 0 || cfg.MinProgress > 1 {
+		cfg.MinProgress = DefaultDestinationConfig().MinProgress
+	}
+	if cfg.FeedFetch <= 0 {
+		cfg.FeedFetch = DefaultDestinationConfig().FeedFetch
+	}
+	return &DestinationUseCase{repo: repo, rideRepo: rideRepo, vehicles: vehicles, cfg: cfg}
+}
+
+// GetDestination returns driver's destination (inactive with zero uses if never set)
+func (uc *DestinationUseCase) GetDestination(ctx context.Context, driverID string) (*domain.DriverDestination, error) {
+	d, err := uc.repo.Get(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		d = &domain.DriverDestination{DriverID: driverID}
+	}
+	d.UsesLimit = uc.cfg.DailyLimit
+	return d, nil
+}
+
+// SetDestination activates destination mode; each activation counts toward the daily limit
+func (uc *DestinationUseCase) SetDestination(ctx context.Context, driverID string, point domain.Point) (*domain.DriverDestination, error) {
+	if !domain.ValidPoint(point) || (point.Lat == 0 && point.Lng == 0) {
+		return nil, ErrInvalidDestination
+	}
+	d := &domain.DriverDestination{DriverID: driverID, Point: point, Active: true}
+	if err := uc.repo.Activate(ctx, d, uc.cfg.DailyLimit); err != nil {
+		if errors.Is(err, pg.ErrDestinationLimit) {
+			return nil, ErrDestinationLimitReached
+		}
+		return nil, err
+	}
+	return uc.GetDestination(ctx, driverID)
+}
+
+// ClearDestination turns destination mode off (does not refund the use)
+func (uc *DestinationUseCase) ClearDestination(ctx context.Context, driverID string) error {
+	return uc.repo.Deactivate(ctx, driverID)
+}
+
+// ListOpenRides — open rides feed for a driver, filtered by the driver's vehicle
+// (category eligibility, ride options) and active destination.
+// origin is the driver's current position; nil falls back to each ride's pickup.
+func (uc *DestinationUseCase) ListOpenRides(ctx context.Context, driverID string, origin *domain.Point, limit int) ([]*domain.Ride, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	filter, err := uc.feedFilter(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	d, err := uc.repo.Get(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil || !d.Active {
+		return uc.rideRepo.ListOpenRides(ctx, limit, filter)
+	}
+	rides, err := uc.rideRepo.ListOpenRides(ctx, uc.cfg.FeedFetch, filter)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*domain.Ride, 0, limit)
+	for _, ride := range rides {
+		if d.Accepts(originOr(origin, ride), ride, uc.cfg.MinProgress) {
+			out = append(out, ride)
+			if len(out) == limit {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// FilterDispatch — drops push-dispatch candidates whose active destination the ride does not serve
+func (uc *DestinationUseCase) FilterDispatch(ctx context.Context, rideID string, candidates []DispatchCandidate) ([]DispatchCandidate, error) {
+	ride, err := uc.rideRepo.GetByID(ctx, rideID)
+	if err != nil || ride == nil {
+		return nil, ErrRideNotFound
+	}
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.DriverID)
+	}
+	active, err := uc.repo.ListActive(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]DispatchCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if active[c.DriverID].Accepts(originOr(c.Location, ride), ride, uc.cfg.MinProgress) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// feedFilter — nil (no filter) without a vehicle source; drivers without an approved
+// vehicle are eligible for no category and see an empty feed
+func (uc *DestinationUseCase) feedFilter(ctx context.Context, driverID string) (*domain.FeedFilter, error) {
+	if uc.vehicles == nil {
+		return nil, nil
+	}
+	v, err := uc.vehicles.DriverVehicle(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	return domain.FeedFilterFor(v), nil
+}
+
+func originOr(origin *domain.Point, ride *domain.Ride) domain.Point {
+	if origin != nil {
+		return *origin
+	}
+	return ride.From
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+func TestHaversineKM(t *testing.T) {
+	// Moscow (Red Square) -> Saint Petersburg (Palace Square) ≈ 634 km
+	d := domain.HaversineKM(domain.Point{Lat: 55.7539, Lng: 37.6208}, domain.Point{Lat: 59.9390, Lng: 30.3158})
+	if d < 630 || d > 640 {
+		t.Errorf("expected ~634 km, got %.1f", d)
+	}
+}
+
+func TestDestinationUseCase_ListOpenRides_FiltersByProgress(t *testing.T) {
+	home := domain.Point{Lat: 55.90, Lng: 37.60}
+	pickup := domain.Point{Lat: 55.70, Lng: 37.60}
+	rides := &rideStore{rides: []*domain.Ride{
+		{ID: "toward", From: pickup, To: domain.Point{Lat: 55.85, Lng: 37.60}},
+		{ID: "away", From: pickup, To: domain.Point{Lat: 55.60, Lng: 37.60}},
+		{ID: "sideways", From: pickup, To: domain.Point{Lat: 55.70, Lng: 37.75}},
+	}}
+	repo := &fakeDestinationRepo{dest: &domain.DriverDestination{DriverID: "d1", Point: home, Active: true}}
+	uc := NewDestinationUseCase(repo, rides, nil, DestinationConfig{MinProgress: 0.3})
+
+	got, err := uc.ListOpenRides(context.Background(), "d1", nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "toward" {
+		t.Errorf("expected only ride heading home, got %v", got)
+	}
+
+	// Destination mode off: full feed
+	repo.dest.Active = false
+	got, _ = uc.ListOpenRides(context.Background(), "d1", nil, 10)
+	if len(got) != 3 {
+		t.Errorf("expected unfiltered feed, got %d rides", len(got))
+	}
+}
+
+func TestDestinationUseCase_FilterDispatch(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{
+		{ID: "r1", From: domain.Point{Lat: 55.70, Lng: 37.60}, To: domain.Point{Lat: 55.60, Lng: 37.60}},
+	}}
+	repo := &fakeDestinationRepo{dest: &domain.DriverDestination{DriverID: "homebound", Point: domain.Point{Lat: 55.90, Lng: 37.60}, Active: true}}
+	uc := NewDestinationUseCase(repo, rides, nil, DefaultDestinationConfig())
+
+	got, err := uc.FilterDispatch(context.Background(), "r1", []DispatchCandidate{{DriverID: "homebound"}, {DriverID: "free"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].DriverID != "free" {
+		t.Errorf("expected only driver without destination, got %v", got)
+	}
+}
+
+func TestDestinationUseCase_SetDestination_Invalid(t *testing.T) {
+	uc := NewDestinationUseCase(&fakeDestinationRepo{}, &rideStore{}, nil, DefaultDestinationConfig())
+	_, err := uc.SetDestination(context.Background(), "d1", domain.Point{Lat: 91, Lng: 0})
+	if err != ErrInvalidDestination {
+		t.Errorf("expected ErrInvalidDestination, got %v", err)
+	}
+}
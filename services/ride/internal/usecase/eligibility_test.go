@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/kafka"
+)
+
+type fakeDrivers map[string]*domain.DriverEligibility
+
+func (f fakeDrivers) DriverEligibility(ctx context.Context, driverID string) (*domain.DriverEligibility, error) {
+	return f[driverID], nil
+}
+
+func TestRideUseCase_PlaceBid_VerifiedDriverGate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	valid, expired := now.AddDate(1, 0, 0), now.Add(-time.Hour)
+	approved := func(docs map[string]*time.Time) *domain.DriverEligibility {
+		return &domain.DriverEligibility{Status: domain.VerificationApproved, Documents: docs}
+	}
+	drivers := fakeDrivers{
+		"ok":       approved(map[string]*time.Time{domain.DocLicense: &valid, domain.DocPhoto: nil}),
+		"pending":  {Status: domain.VerificationPending},
+		"rejected": {Status: domain.VerificationRejected},
+		"no-photo": approved(map[string]*time.Time{domain.DocLicense: &valid}),
+		"expired":  approved(map[string]*time.Time{domain.DocLicense: &expired, domain.DocPhoto: nil}),
+		// expired insurance is irrelevant for economy
+		"old-insurance": approved(map[string]*time.Time{domain.DocLicense: &valid, domain.DocPhoto: nil, domain.DocInsurance: &expired}),
+	}
+	rides := &rideStore{rides: []*domain.Ride{{
+		ID: "r1", Status: domain.StatusBidding, Category: domain.CategoryEconomy, From: testFrom, To: testTo,
+	}}}
+	bids := &bidStore{}
+	uc := NewRideUseCase(rides, bids, &kafka.NoopProducer{}, nil, nil, drivers, nil)
+	uc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	cases := []struct {
+		driverID, role, reason string
+	}{
+		{"ok", "passenger", domain.IneligibleNotDriver},
+		{"unknown", "driver", domain.IneligibleNotVerified},
+		{"pending", "driver", domain.IneligiblePending},
+		{"rejected", "driver", domain.IneligibleRejected},
+		{"no-photo", "driver", domain.IneligibleDocumentsMissing},
+		{"expired", "driver", domain.IneligibleDocumentsExpired},
+	}
+	for _, tc := range cases {
+		_, err := uc.PlaceBid(ctx, "r1", tc.driverID, tc.role, 500)
+		var ineligible *DriverIneligibleError
+		if !errors.As(err, &ineligible) || !errors.Is(err, ErrDriverIneligible) || ineligible.Reason != tc.reason {
+			t.Errorf("%s as %s: expected reason %s, got %v", tc.driverID, tc.role, tc.reason, err)
+		}
+	}
+	for _, driverID := range []string{"ok", "old-insurance"} {
+		if _, err := uc.PlaceBid(ctx, "r1", driverID, "driver", 500); err != nil {
+			t.Errorf("%s: %v", driverID, err)
+		}
+	}
+	if len(bids.bids) != 2 {
+		t.Errorf("expected 2 bids, got %d", len(bids.bids))
+	}
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/kafka"
+)
+
+func TestRideUseCase_CreateRide_PaymentMethod(t *testing.T) {
+	uc := NewRideUseCase(&rideStore{}, nil, &kafka.NoopProducer{}, nil, nil, nil, nil)
+	ctx := context.Background()
+	create := func(p domain.RidePayment) (*domain.Ride, error) {
+		return uc.CreateRide(ctx, CreateRideInput{PassengerID: "p1", From: testFrom, To: testTo, Payment: p})
+	}
+
+	ride, err := create(domain.RidePayment{})
+	if err != nil || ride.Payment != (domain.RidePayment{Method: domain.PaymentCash}) {
+		t.Errorf("cash by default: %+v, %v", ride, err)
+	}
+	ride, err = create(domain.RidePayment{Method: "Card", CardID: " pm-1 "})
+	if err != nil || ride.Payment != (domain.RidePayment{Method: domain.PaymentCard, CardID: "pm-1"}) {
+		t.Errorf("saved card: %+v, %v", ride, err)
+	}
+	if ride.Redacted("d1").Payment != (domain.RidePayment{Method: domain.PaymentCard}) {
+		t.Error("drivers see how the ride is paid, not the passenger's card")
+	}
+	for _, p := range []domain.RidePayment{{Method: domain.PaymentCard}, {Method: domain.PaymentCash, CardID: "pm-1"}, {Method: "crypto"}} {
+		if _, err := create(p); err != domain.ErrInvalidPayment {
+			t.Errorf("%+v: %v", p, err)
+		}
+	}
+}
@@ -16,19 +16,23 @@ type RatingRepo interface {
 	GetUserRating(ctx context.Context, userID, role string) (*domain.UserRating, error)
 	HasRated(ctx context.Context, rideID, fromUserID, toUserID string) (bool, error)
 	ListAll(ctx context.Context, limit, offset int) ([]domain.Rating, int, error)
+	GetByTripID(ctx context.Context, tripID string) ([]domain.Rating, error)
+	HasRatedTrip(ctx context.Context, tripID, fromUserID, toUserID string) (bool, error)
 }
 
 // RatingUseCase handles rating business logic
 type RatingUseCase struct {
 	ratingRepo RatingRepo
 	rideRepo   RideRepository // To validate ride exists and is completed
+	tripRepo   TripRepository // Shared trips: driver and passengers with completed bookings
 }
 
 // NewRatingUseCase creates a new rating use case
-func NewRatingUseCase(ratingRepo RatingRepo, rideRepo RideRepository) *RatingUseCase {
+func NewRatingUseCase(ratingRepo RatingRepo, rideRepo RideRepository, tripRepo TripRepository) *RatingUseCase {
 	return &RatingUseCase{
 		ratingRepo: ratingRepo,
 		rideRepo:   rideRepo,
+		tripRepo:   tripRepo,
 	}
 }
 
@@ -86,21 +90,8 @@ func (uc *RatingUseCase) SubmitRating(ctx context.Context, input SubmitRatingInp
 	}
 
 	// Validate tags
-	validTags := domain.DriverRatingTags
-	if role == "passenger" {
-		validTags = domain.PassengerRatingTags
-	}
-	for _, tag := range input.Tags {
-		valid := false
-		for _, vt := range validTags {
-			if tag == vt {
-				valid = true
-				break
-			}
-		}
-		if !valid {
-			return nil, fmt.Errorf("invalid tag: %s", tag)
-		}
+	if err := validateTags(role, input.Tags); err != nil {
+		return nil, err
 	}
 
 	// Create rating
@@ -121,6 +112,105 @@ func (uc *RatingUseCase) SubmitRating(ctx context.Context, input SubmitRatingInp
 	return rating, nil
 }
 
+// SubmitTripRatingInput is the input for rating a shared trip participant
+type SubmitTripRatingInput struct {
+	TripID     string
+	FromUserID string
+	ToUserID   string // driver rates each passenger; passengers may leave it empty (the driver)
+	Score      int
+	Comment    string
+	Tags       []string
+}
+
+// SubmitTripRating rates a participant of a completed shared trip: a passenger with a
+// completed booking rates the driver, the driver rates each such passenger
+func (uc *RatingUseCase) SubmitTripRating(ctx context.Context, input SubmitTripRatingInput) (*domain.Rating, error) {
+	if input.Score < 1 || input.Score > 5 {
+		return nil, errors.New("score must be between 1 and 5")
+	}
+	trip, err := uc.tripRepo.GetByID(ctx, input.TripID)
+	if err != nil {
+		return nil, fmt.Errorf("trip not found: %w", err)
+	}
+	if trip == nil {
+		return nil, ErrTripNotFound
+	}
+	if trip.Status != domain.TripStatusCompleted {
+		return nil, errors.New("can only rate completed trips")
+	}
+	rode := func(userID string) bool {
+		b := trip.Booking(userID)
+		return b != nil && b.Status == domain.BookingStatusCompleted
+	}
+
+	var toUserID, role string
+	switch {
+	case input.FromUserID == trip.DriverID:
+		if !rode(input.ToUserID) {
+			return nil, errors.New("user was not a passenger of this trip")
+		}
+		toUserID, role = input.ToUserID, "passenger"
+	case rode(input.FromUserID):
+		if input.ToUserID != "" && input.ToUserID != trip.DriverID {
+			return nil, errors.New("passengers rate the trip driver")
+		}
+		toUserID, role = trip.DriverID, "driver"
+	default:
+		return nil, errors.New("user is not a participant of this trip")
+	}
+
+	hasRated, err := uc.ratingRepo.HasRatedTrip(ctx, input.TripID, input.FromUserID, toUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing rating: %w", err)
+	}
+	if hasRated {
+		return nil, errors.New("you have already rated this user for this trip")
+	}
+	if err := validateTags(role, input.Tags); err != nil {
+		return nil, err
+	}
+
+	rating := &domain.Rating{
+		TripID:     input.TripID,
+		FromUserID: input.FromUserID,
+		ToUserID:   toUserID,
+		Role:       role,
+		Score:      input.Score,
+		Comment:    input.Comment,
+		Tags:       input.Tags,
+	}
+	if err := uc.ratingRepo.Create(ctx, rating); err != nil {
+		return nil, fmt.Errorf("failed to create rating: %w", err)
+	}
+	return rating, nil
+}
+
+// GetTripRatings returns all ratings for a shared trip
+func (uc *RatingUseCase) GetTripRatings(ctx context.Context, tripID string) ([]domain.Rating, error) {
+	return uc.ratingRepo.GetByTripID(ctx, tripID)
+}
+
+// validateTags checks tags against the predefined tags of the rated role
+func validateTags(role string, tags []string) error {
+	validTags := domain.DriverRatingTags
+	if role == "passenger" {
+		validTags = domain.PassengerRatingTags
+	}
+	for _, tag := range tags {
+		valid := false
+		for _, vt := range validTags {
+			if tag == vt {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid tag: %s", tag)
+		}
+	}
+	return nil
+}
+
 // GetRideRatings returns all ratings for a specific ride
 func (uc *RatingUseCase) GetRideRatings(ctx context.Context, rideID string) ([]domain.Rating, error) {
 	return uc.ratingRepo.GetByRideID(ctx, rideID)
@@ -2,9 +2,14 @@ package usecase
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/pg"
 )
 
 var (
@@ -14,70 +19,273 @@ var (
 	ErrNotPassenger   = errors.New("not the ride passenger")
 	ErrNotDriver      = errors.New("not the ride driver")
 	ErrRideNotBidding = errors.New("ride is not in bidding status")
+	// ErrVehicleMismatch — driver's approved vehicle lacks the ride's class or features
+	ErrVehicleMismatch = errors.New("driver vehicle does not meet ride requirements")
+	// ErrCategoryNotAllowed — driver's vehicle class or approved documents do not fit the ride category
+	ErrCategoryNotAllowed = errors.New("driver is not allowed to take rides of this category")
+	ErrBidOutOfRange      = errors.New("bid price outside the allowed range")
+	ErrInvalidSeats       = errors.New("invalid number of seats for the category")
+	ErrInvalidSchedule    = errors.New("scheduled time outside the pre-booking window")
+	ErrDeliveryRequired   = errors.New("courier rides need sender and recipient contacts")
+	ErrDeliveryNotAllowed = errors.New("delivery details are only accepted for courier rides")
+	// ErrDeliveryCodeRequired — courier rides complete through delivery code confirmation
+	ErrDeliveryCodeRequired = errors.New("courier ride completes with the delivery code")
+	ErrInvalidDeliveryCode  = errors.New("invalid delivery code")
+	ErrDeliveryLocked       = errors.New("too many wrong delivery codes; contact support")
+	// ErrDriverIneligible — bidder failed the verified-driver gate (see DriverIneligibleError)
+	ErrDriverIneligible = errors.New("driver is not eligible to bid")
+	// ErrBidNotPending — bid was already accepted, rejected or withdrawn
+	ErrBidNotPending      = errors.New("bid is no longer pending")
+	ErrNotRideParticipant = errors.New("not the ride passenger or matched driver")
+	// ErrPickupPINRequired — the driver starts a PIN-protected ride with the passenger's PIN
+	ErrPickupPINRequired = errors.New("pickup PIN required to start the ride")
+	ErrInvalidPickupPIN  = errors.New("invalid pickup PIN")
+	ErrPickupPINLocked   = errors.New("too many wrong pickup PINs; the passenger starts the ride")
 )
 
+// BidRangeError — ErrBidOutOfRange with the accepted bounds (per seat for seat-priced categories)
+type BidRangeError struct {
+	Floor   float64
+	Ceiling float64
+}
+
+func (e *BidRangeError) Error() string { return ErrBidOutOfRange.Error() }
+
+func (e *BidRangeError) Is(target error) bool { return target == ErrBidOutOfRange }
+
+// DriverIneligibleError — ErrDriverIneligible with the reason code (domain.Ineligible*)
+type DriverIneligibleError struct {
+	Reason string
+}
+
+func (e *DriverIneligibleError) Error() string { return ErrDriverIneligible.Error() + ": " + e.Reason }
+
+func (e *DriverIneligibleError) Is(target error) bool { return target == ErrDriverIneligible }
+
 type RideRepository interface {
 	Create(ctx context.Context, ride *domain.Ride) error
 	GetByID(ctx context.Context, id string) (*domain.Ride, error)
 	UpdateStatus(ctx context.Context, id, status string) error
-	SetDriverAndPrice(ctx context.Context, id, driverID string, price float64) error
+	// MatchBid claims the open ride for the bid's driver, accepts the pending bid and rejects
+	// the other bids in one transaction (pg.ErrRideStateChanged, pg.ErrBidStateChanged)
+	MatchBid(ctx context.Context, rideID, bidID, driverID string, price float64) error
 	ListByPassenger(ctx context.Context, passengerID string, limit int) ([]*domain.Ride, error)
 	ListByDriver(ctx context.Context, driverID string, limit int) ([]*domain.Ride, error)
-	ListOpenRides(ctx context.Context, limit int) ([]*domain.Ride, error)
+	// ListOpenRides — requested/bidding rides; filter (optional) keeps rides a driver may take
+	ListOpenRides(ctx context.Context, limit int, filter *domain.FeedFilter) ([]*domain.Ride, error)
 	ListAll(ctx context.Context, limit int) ([]*domain.Ride, error)
+	// AddDeliveryAttempt counts a wrong delivery code and returns attempts so far
+	AddDeliveryAttempt(ctx context.Context, id string) (int, error)
+	// ConfirmDelivery stores handover time and completes the in-progress ride
+	ConfirmDelivery(ctx context.Context, id string, at time.Time) error
+	// AddPickupPINAttempt counts a wrong pickup PIN and returns attempts so far
+	AddPickupPINAttempt(ctx context.Context, id string) (int, error)
+	// StartWithPickupPIN stores the verification time and starts the matched ride
+	StartWithPickupPIN(ctx context.Context, id string, at time.Time) error
 }
 
 type BidRepository interface {
 	Create(ctx context.Context, bid *domain.Bid) error
 	GetByID(ctx context.Context, id string) (*domain.Bid, error)
 	ListByRideID(ctx context.Context, rideID string) ([]*domain.Bid, error)
-	AcceptBid(ctx context.Context, bidID string) error
-	RejectOtherBidsForRide(ctx context.Context, rideID, exceptBidID string) error
+	// GetPendingByDriver — the driver's pending bid on the ride, nil if none
+	GetPendingByDriver(ctx context.Context, rideID, driverID string) (*domain.Bid, error)
+	// UpdatePrice, SetCounter, Withdraw change pending bids only (pg.ErrBidStateChanged otherwise)
+	UpdatePrice(ctx context.Context, bidID string, price float64) error
+	SetCounter(ctx context.Context, bidID string, price float64) error
+	Withdraw(ctx context.Context, bidID string) error
 }
 
 type EventPublisher interface {
 	SendRideRequested(ctx context.Context, rideID, passengerID string, payload interface{}) error
 	SendRideBidPlaced(ctx context.Context, rideID, bidID, driverID string, price float64) error
-	SendRideMatched(ctx context.Context, rideID, driverID string, price float64) error
-	SendRideStatusChanged(ctx context.Context, rideID, status string) error
+	// SendRideMatched — auto is true when the bid was accepted by the passenger's auto-accept rules;
+	// the payment service holds price on the passenger's card when the ride is paid by card
+	SendRideMatched(ctx context.Context, rideID, passengerID, driverID string, price float64, payment domain.RidePayment, auto bool) error
+	// SendRideStatusChanged — ride moved to status. Completed rides carry their final price
+	// (captured by the payment service), driver and category (for the driver's earnings);
+	// the ride tracks no waiting, stops or tips, so the price is the whole fare
+	SendRideStatusChanged(ctx context.Context, ride *domain.Ride, status string) error
+	// SendRideEvent — SSE stream event for the ride's passenger and matched driver
+	SendRideEvent(ctx context.Context, e domain.RideEvent) error
+	// SendSafetyAlert — high-priority admin alert for an SOS
+	SendSafetyAlert(ctx context.Context, alert *domain.SOSAlert) error
 }
 
+// AddressResolver — reverse geocoding (user service, provider from admin map settings)
+type AddressResolver interface {
+	ReverseGeocode(ctx context.Context, lat, lng float64) (string, error)
+}
+
+// VehicleSource — approved vehicle class/features/documents per driver (user service
+// verification); nil vehicle when the driver has none
+type VehicleSource interface {
+	DriverVehicle(ctx context.Context, driverID string) (*domain.Vehicle, error)
+}
+
+// EligibilitySource — driver verification snapshots (local cache fed by user service
+// events); nil snapshot when the driver never applied
+type EligibilitySource interface {
+	DriverEligibility(ctx context.Context, driverID string) (*domain.DriverEligibility, error)
+}
+
+// addressLookupTimeout bounds geocoding on ride creation; a slow provider must not block the request
+const addressLookupTimeout = 1500 * time.Millisecond
+
 type RideUseCase struct {
-	rideRepo RideRepository
-	bidRepo  BidRepository
-	pub      EventPublisher
+	rideRepo  RideRepository
+	bidRepo   BidRepository
+	pub       EventPublisher
+	addresses AddressResolver   // optional
+	vehicles  VehicleSource     // optional
+	drivers   EligibilitySource // optional
+	bids      *BidEnricher      // optional
+	now       func() time.Time
+}
+
+// NewRideUseCase creates ride use case; addresses may be nil (client-supplied addresses kept as is),
+// vehicles may be nil (bids are not checked against category rules and ride options),
+// drivers may be nil (bidders need the driver role, verification is not checked),
+// bids may be nil (bids are listed without driver details)
+func NewRideUseCase(rideRepo RideRepository, bidRepo BidRepository, pub EventPublisher, addresses AddressResolver, vehicles VehicleSource, drivers EligibilitySource, bids *BidEnricher) *RideUseCase {
+	return &RideUseCase{rideRepo: rideRepo, bidRepo: bidRepo, pub: pub, addresses: addresses, vehicles: vehicles, drivers: drivers, bids: bids, now: time.Now}
 }
 
-func NewRideUseCase(rideRepo RideRepository, bidRepo BidRepository, pub EventPublisher) *RideUseCase {
-	return &RideUseCase{rideRepo: rideRepo, bidRepo: bidRepo, pub: pub}
+// CreateRideInput — passenger's ride request
+type CreateRideInput struct {
+	PassengerID string
+	From        domain.Point
+	To          domain.Point
+	Options     domain.RideOptions
+	Category    string           // empty = economy
+	Seats       int              // seat-priced categories; 0 = 1
+	ScheduledAt *time.Time       // pre-booking; nil = now
+	Delivery    *domain.Delivery // courier only
+	AutoAccept  *domain.AutoAccept
+	PickupPIN   bool // driver must enter the passenger's PIN to start the ride
+	Payment     domain.RidePayment
 }
 
-func (uc *RideUseCase) CreateRide(ctx context.Context, passengerID string, from, to domain.Point) (*domain.Ride, error) {
+func (uc *RideUseCase) CreateRide(ctx context.Context, in CreateRideInput) (*domain.Ride, error) {
+	from, to := in.From, in.To
 	if from.Lat < -90 || from.Lat > 90 || from.Lng < -180 || from.Lng > 180 {
 		return nil, ErrInvalidStatus
 	}
 	if to.Lat < -90 || to.Lat > 90 || to.Lng < -180 || to.Lng > 180 {
 		return nil, ErrInvalidStatus
 	}
+	rules, err := domain.RulesFor(in.Category)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := in.Options.Normalize()
+	if err != nil {
+		return nil, err
+	}
+	if opts.VehicleClass != "" && !rules.AllowsClass(opts.VehicleClass) {
+		return nil, domain.ErrInvalidRideOptions
+	}
+	seats := in.Seats
+	if seats == 0 {
+		seats = 1
+	}
+	if seats < 1 || seats > rules.MaxSeats {
+		return nil, ErrInvalidSeats
+	}
+	if in.ScheduledAt != nil {
+		now := uc.clock()
+		if in.ScheduledAt.Before(now) || in.ScheduledAt.After(now.Add(rules.PrebookWindow())) {
+			return nil, ErrInvalidSchedule
+		}
+	}
 	ride := &domain.Ride{
-		PassengerID: passengerID,
+		PassengerID: in.PassengerID,
 		Status:      domain.StatusRequested,
 		From:        from,
 		To:          to,
+		Options:     opts,
+		Category:    rules.Category,
+		Seats:       seats,
+		ScheduledAt: in.ScheduledAt,
 	}
+	if ride.Payment, err = in.Payment.Normalize(); err != nil {
+		return nil, err
+	}
+	if in.AutoAccept != nil {
+		if err := in.AutoAccept.Validate(); err != nil {
+			return nil, err
+		}
+		ride.AutoAccept = in.AutoAccept
+	}
+	if in.PickupPIN {
+		if ride.PickupPIN, err = domain.NewPickupPIN(); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case rules.Delivery && in.Delivery == nil:
+		return nil, ErrDeliveryRequired
+	case !rules.Delivery && in.Delivery != nil:
+		return nil, ErrDeliveryNotAllowed
+	case rules.Delivery:
+		d, err := in.Delivery.Normalize()
+		if err != nil {
+			return nil, err
+		}
+		if d.Code, err = domain.NewDeliveryCode(); err != nil {
+			return nil, err
+		}
+		ride.Delivery = &d
+	}
+	uc.resolveAddresses(ctx, &ride.From, &ride.To)
 	if err := uc.rideRepo.Create(ctx, ride); err != nil {
 		return nil, err
 	}
 	ride.Status = domain.StatusBidding
-	_ = uc.pub.SendRideRequested(ctx, ride.ID, passengerID, ride)
+	_ = uc.pub.SendRideRequested(ctx, ride.ID, in.PassengerID, ride.Redacted(""))
 	return ride, nil
 }
 
+// Categories — rules of every ride category (for apps)
+func (uc *RideUseCase) Categories() []domain.CategoryRules {
+	return domain.AllCategoryRules()
+}
+
+// resolveAddresses replaces client-supplied address text with the geocoder's
+// formatted address. Best effort: on failure the client text is kept (trimmed).
+func (uc *RideUseCase) resolveAddresses(ctx context.Context, points ...*domain.Point) {
+	for _, p := range points {
+		p.Address = strings.Join(strings.Fields(p.Address), " ")
+	}
+	if uc.addresses == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, addressLookupTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, p := range points {
+		wg.Add(1)
+		go func(p *domain.Point) {
+			defer wg.Done()
+			if addr, err := uc.addresses.ReverseGeocode(ctx, p.Lat, p.Lng); err == nil && addr != "" {
+				p.Address = addr
+			}
+		}(p)
+	}
+	wg.Wait()
+}
+
 func (uc *RideUseCase) GetRide(ctx context.Context, id string) (*domain.Ride, error) {
 	return uc.rideRepo.GetByID(ctx, id)
 }
 
-func (uc *RideUseCase) PlaceBid(ctx context.Context, rideID, driverID string, price float64) (*domain.Bid, error) {
+// PlaceBid — driver's offer; per seat for seat-priced categories. Only drivers with an
+// approved verification and unexpired documents may bid (DriverIneligibleError).
+func (uc *RideUseCase) PlaceBid(ctx context.Context, rideID, driverID, userRole string, price float64) (*domain.Bid, error) {
+	if userRole != "driver" {
+		return nil, &DriverIneligibleError{Reason: domain.IneligibleNotDriver}
+	}
+	price = domain.RoundFare(price)
 	if price <= 0 {
 		return nil, ErrInvalidStatus
 	}
@@ -88,16 +296,181 @@ func (uc *RideUseCase) PlaceBid(ctx context.Context, rideID, driverID string, pr
 	if ride.Status != domain.StatusRequested && ride.Status != domain.StatusBidding {
 		return nil, ErrRideNotBidding
 	}
-	bid := &domain.Bid{RideID: rideID, DriverID: driverID, Price: price}
-	if err := uc.bidRepo.Create(ctx, bid); err != nil {
+	rules, err := domain.RulesFor(ride.Category)
+	if err != nil {
+		return nil, err
+	}
+	if uc.drivers != nil {
+		e, err := uc.drivers.DriverEligibility(ctx, driverID)
+		if err != nil {
+			return nil, err
+		}
+		if reason := e.Reason(rules.RequiredDocs, uc.clock()); reason != "" {
+			return nil, &DriverIneligibleError{Reason: reason}
+		}
+	}
+	if uc.vehicles != nil {
+		vehicle, err := uc.vehicles.DriverVehicle(ctx, driverID)
+		if err != nil {
+			return nil, err
+		}
+		if !rules.Eligible(vehicle) {
+			return nil, ErrCategoryNotAllowed
+		}
+		if !vehicle.Serves(ride.Options) {
+			return nil, ErrVehicleMismatch
+		}
+	}
+	floor, ceiling := rules.BidRange(domain.HaversineKM(ride.From, ride.To))
+	if price < floor || price > ceiling {
+		return nil, &BidRangeError{Floor: floor, Ceiling: ceiling}
+	}
+	// A driver has one pending bid per ride: bidding again updates it (and answers a counter-offer)
+	bid, err := uc.bidRepo.GetPendingByDriver(ctx, rideID, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if bid != nil {
+		if err := uc.bidRepo.UpdatePrice(ctx, bid.ID, price); err != nil {
+			if errors.Is(err, pg.ErrBidStateChanged) {
+				return nil, ErrBidNotPending
+			}
+			return nil, err
+		}
+		bid.Price, bid.CounterPrice = price, nil
+		uc.emit(ctx, rideID, domain.EventBidUpdated, driverID, bid)
+	} else {
+		bid = &domain.Bid{RideID: rideID, DriverID: driverID, Price: price}
+		if err := uc.bidRepo.Create(ctx, bid); err != nil {
+			return nil, err
+		}
+		_ = uc.pub.SendRideBidPlaced(ctx, rideID, bid.ID, driverID, price)
+		uc.emit(ctx, rideID, domain.EventBidPlaced, driverID, bid)
+	}
+	if ride.AutoAccept != nil {
+		uc.autoAccept(ctx, ride, bid, rules)
+	}
+	return bid, nil
+}
+
+// WithdrawBid — driver takes back their pending bid while the ride is open
+func (uc *RideUseCase) WithdrawBid(ctx context.Context, rideID, bidID, driverID string) (*domain.Bid, error) {
+	ride, bid, err := uc.openBid(ctx, rideID, bidID)
+	if err != nil {
+		return nil, err
+	}
+	if bid.DriverID != driverID {
+		return nil, ErrBidNotFound
+	}
+	if err := uc.bidRepo.Withdraw(ctx, bidID); err != nil {
+		if errors.Is(err, pg.ErrBidStateChanged) {
+			return nil, ErrBidNotPending
+		}
+		return nil, err
+	}
+	bid.Status = pg.BidStatusWithdrawn
+	uc.emit(ctx, ride.ID, domain.EventBidWithdrawn, bid.DriverID, bid)
+	return bid, nil
+}
+
+// CounterBid — passenger proposes another price on a pending bid (per seat for
+// seat-priced categories, within the category bid range); the driver answers by bidding again
+func (uc *RideUseCase) CounterBid(ctx context.Context, rideID, bidID, passengerID string, price float64) (*domain.Bid, error) {
+	price = domain.RoundFare(price)
+	ride, bid, err := uc.openBid(ctx, rideID, bidID)
+	if err != nil {
+		return nil, err
+	}
+	if ride.PassengerID != passengerID {
+		return nil, ErrNotPassenger
+	}
+	rules, err := domain.RulesFor(ride.Category)
+	if err != nil {
+		return nil, err
+	}
+	floor, ceiling := rules.BidRange(domain.HaversineKM(ride.From, ride.To))
+	if price < floor || price > ceiling {
+		return nil, &BidRangeError{Floor: floor, Ceiling: ceiling}
+	}
+	if err := uc.bidRepo.SetCounter(ctx, bidID, price); err != nil {
+		if errors.Is(err, pg.ErrBidStateChanged) {
+			return nil, ErrBidNotPending
+		}
 		return nil, err
 	}
-	_ = uc.pub.SendRideBidPlaced(ctx, rideID, bid.ID, driverID, price)
+	bid.CounterPrice = &price
+	uc.emit(ctx, ride.ID, domain.EventBidCountered, bid.DriverID, bid)
 	return bid, nil
 }
 
-func (uc *RideUseCase) ListBids(ctx context.Context, rideID string) ([]*domain.Bid, error) {
-	return uc.bidRepo.ListByRideID(ctx, rideID)
+// openBid loads a pending bid of a ride that still takes bids
+func (uc *RideUseCase) openBid(ctx context.Context, rideID, bidID string) (*domain.Ride, *domain.Bid, error) {
+	ride, err := uc.rideRepo.GetByID(ctx, rideID)
+	if err != nil || ride == nil {
+		return nil, nil, ErrRideNotFound
+	}
+	if ride.Status != domain.StatusRequested && ride.Status != domain.StatusBidding {
+		return nil, nil, ErrRideNotBidding
+	}
+	bid, err := uc.bidRepo.GetByID(ctx, bidID)
+	if err != nil || bid == nil || bid.RideID != rideID {
+		return nil, nil, ErrBidNotFound
+	}
+	if bid.Status != pg.BidStatusPending {
+		return nil, nil, ErrBidNotPending
+	}
+	return ride, bid, nil
+}
+
+// StreamRide authorizes a subscription to the ride's event stream: its passenger or matched driver
+func (uc *RideUseCase) StreamRide(ctx context.Context, rideID, userID string) (*domain.Ride, error) {
+	ride, err := uc.rideRepo.GetByID(ctx, rideID)
+	if err != nil || ride == nil {
+		return nil, ErrRideNotFound
+	}
+	if userID != ride.PassengerID && (ride.DriverID == "" || userID != ride.DriverID) {
+		return nil, ErrNotRideParticipant
+	}
+	return ride, nil
+}
+
+// emit publishes a ride stream event; best effort like the other ride events
+func (uc *RideUseCase) emit(ctx context.Context, rideID, typ, driverID string, data interface{}) {
+	if e, err := domain.NewRideEvent(rideID, typ, driverID, data, uc.clock()); err == nil {
+		_ = uc.pub.SendRideEvent(ctx, e)
+	}
+}
+
+// autoAccept matches the ride to the bid if it passes the passenger's rules. Rating and
+// ETA come from bid enrichment; without it only the price rule can pass. Best effort:
+// the bid stands as pending when the bid does not qualify or another bid matched first.
+func (uc *RideUseCase) autoAccept(ctx context.Context, ride *domain.Ride, bid *domain.Bid, rules domain.CategoryRules) {
+	details := &domain.BidDetails{Bid: bid}
+	if uc.bids != nil {
+		details = uc.bids.Enrich(ctx, ride, []*domain.Bid{bid})[0]
+	}
+	if !ride.AutoAccept.Qualifies(rules.Total(bid.Price, ride.Seats), details.Rating, details.ETAMinutes) {
+		return
+	}
+	if _, err := uc.matchBid(ctx, ride, bid, rules, true); err == nil {
+		bid.Status = pg.BidStatusAccepted
+	}
+}
+
+// ListBids returns the ride's bids with driver card, rating and ETA to pickup when enrichment is configured
+func (uc *RideUseCase) ListBids(ctx context.Context, rideID string) ([]*domain.BidDetails, error) {
+	bids, err := uc.bidRepo.ListByRideID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	if uc.bids == nil || len(bids) == 0 {
+		return plainBidDetails(bids), nil
+	}
+	ride, err := uc.rideRepo.GetByID(ctx, rideID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.bids.Enrich(ctx, ride, bids), nil
 }
 
 func (uc *RideUseCase) AcceptBid(ctx context.Context, rideID, bidID, passengerID string) (*domain.Ride, error) {
@@ -118,20 +491,38 @@ func (uc *RideUseCase) AcceptBid(ctx context.Context, rideID, bidID, passengerID
 	if bid.RideID != rideID {
 		return nil, ErrBidNotFound
 	}
-	if err := uc.bidRepo.AcceptBid(ctx, bidID); err != nil {
-		return nil, err
-	}
-	if err := uc.bidRepo.RejectOtherBidsForRide(ctx, rideID, bidID); err != nil {
+	rules, err := domain.RulesFor(ride.Category)
+	if err != nil {
 		return nil, err
 	}
-	if err := uc.rideRepo.SetDriverAndPrice(ctx, rideID, bid.DriverID, bid.Price); err != nil {
+	return uc.matchBid(ctx, ride, bid, rules, false)
+}
+
+// matchBid — the accept path shared by passenger and auto-accept: the ride is claimed and
+// the bids settled in one transaction, so of concurrent accepts only one matches
+// (ErrInvalidStatus for the rest) and a bid withdrawn meanwhile is not accepted
+// (ErrBidNotPending); then ride.matched is sent.
+func (uc *RideUseCase) matchBid(ctx context.Context, ride *domain.Ride, bid *domain.Bid, rules domain.CategoryRules, auto bool) (*domain.Ride, error) {
+	total := rules.Total(bid.Price, ride.Seats)
+	if err := uc.rideRepo.MatchBid(ctx, ride.ID, bid.ID, bid.DriverID, total); err != nil {
+		switch {
+		case errors.Is(err, pg.ErrRideStateChanged):
+			return nil, ErrInvalidStatus
+		case errors.Is(err, pg.ErrBidStateChanged):
+			return nil, ErrBidNotPending
+		}
 		return nil, err
 	}
-	_ = uc.pub.SendRideMatched(ctx, rideID, bid.DriverID, bid.Price)
-	return uc.rideRepo.GetByID(ctx, rideID)
+	_ = uc.pub.SendRideMatched(ctx, ride.ID, ride.PassengerID, bid.DriverID, total, ride.Payment, auto)
+	uc.emit(ctx, ride.ID, domain.EventRideMatched, "", map[string]interface{}{
+		"bid_id": bid.ID, "driver_id": bid.DriverID, "price": total, "auto": auto,
+	})
+	return uc.rideRepo.GetByID(ctx, ride.ID)
 }
 
-func (uc *RideUseCase) UpdateStatus(ctx context.Context, rideID, status, userID, userRole string) (*domain.Ride, error) {
+// UpdateStatus moves the ride on; pin is the passenger's pickup PIN, needed when the
+// driver starts a PIN-protected ride (the passenger and admins start it without)
+func (uc *RideUseCase) UpdateStatus(ctx context.Context, rideID, status, userID, userRole, pin string) (*domain.Ride, error) {
 	valid := map[string]bool{
 		domain.StatusInProgress: true,
 		domain.StatusCompleted:   true,
@@ -150,13 +541,96 @@ func (uc *RideUseCase) UpdateStatus(ctx context.Context, rideID, status, userID,
 	if userRole == "driver" && ride.DriverID != userID {
 		return nil, ErrNotDriver
 	}
-	if err := uc.rideRepo.UpdateStatus(ctx, rideID, status); err != nil {
+	if status == domain.StatusCompleted && ride.Delivery != nil && userRole != "admin" {
+		return nil, ErrDeliveryCodeRequired
+	}
+	if status == domain.StatusInProgress && ride.PickupPIN != nil && userRole == "driver" {
+		if err := uc.startWithPIN(ctx, ride, pin); err != nil {
+			return nil, err
+		}
+	} else if err := uc.rideRepo.UpdateStatus(ctx, rideID, status); err != nil {
+		return nil, err
+	}
+	_ = uc.pub.SendRideStatusChanged(ctx, ride, status)
+	uc.emit(ctx, rideID, domain.EventRideStatus, "", map[string]string{"status": status})
+	return uc.rideRepo.GetByID(ctx, rideID)
+}
+
+// startWithPIN checks the driver's PIN like the delivery code: constant-time compare,
+// attempts counted and locked after MaxPickupPINAttempts
+func (uc *RideUseCase) startWithPIN(ctx context.Context, ride *domain.Ride, pin string) error {
+	if ride.Status != domain.StatusMatched {
+		return ErrInvalidStatus
+	}
+	if ride.PickupPIN.Attempts >= domain.MaxPickupPINAttempts {
+		return ErrPickupPINLocked
+	}
+	pin = strings.TrimSpace(pin)
+	if pin == "" {
+		return ErrPickupPINRequired
+	}
+	if subtle.ConstantTimeCompare([]byte(pin), []byte(ride.PickupPIN.Code)) != 1 {
+		attempts, err := uc.rideRepo.AddPickupPINAttempt(ctx, ride.ID)
+		if err != nil {
+			return err
+		}
+		if attempts >= domain.MaxPickupPINAttempts {
+			return ErrPickupPINLocked
+		}
+		return ErrInvalidPickupPIN
+	}
+	if err := uc.rideRepo.StartWithPickupPIN(ctx, ride.ID, uc.clock()); err != nil {
+		if errors.Is(err, pg.ErrRideStateChanged) {
+			return ErrInvalidStatus
+		}
+		return err
+	}
+	return nil
+}
+
+// ConfirmDelivery — driver enters the code the recipient gave them; completes the courier ride
+func (uc *RideUseCase) ConfirmDelivery(ctx context.Context, rideID, driverID, code string) (*domain.Ride, error) {
+	ride, err := uc.rideRepo.GetByID(ctx, rideID)
+	if err != nil || ride == nil {
+		return nil, ErrRideNotFound
+	}
+	if ride.DriverID != driverID {
+		return nil, ErrNotDriver
+	}
+	if ride.Delivery == nil || ride.Status != domain.StatusInProgress {
+		return nil, ErrInvalidStatus
+	}
+	if ride.Delivery.Attempts >= domain.MaxDeliveryAttempts {
+		return nil, ErrDeliveryLocked
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(code)), []byte(ride.Delivery.Code)) != 1 {
+		attempts, err := uc.rideRepo.AddDeliveryAttempt(ctx, rideID)
+		if err != nil {
+			return nil, err
+		}
+		if attempts >= domain.MaxDeliveryAttempts {
+			return nil, ErrDeliveryLocked
+		}
+		return nil, ErrInvalidDeliveryCode
+	}
+	if err := uc.rideRepo.ConfirmDelivery(ctx, rideID, uc.clock()); err != nil {
+		if errors.Is(err, pg.ErrRideStateChanged) {
+			return nil, ErrInvalidStatus
+		}
 		return nil, err
 	}
-	_ = uc.pub.SendRideStatusChanged(ctx, rideID, status)
+	_ = uc.pub.SendRideStatusChanged(ctx, ride, domain.StatusCompleted)
+	uc.emit(ctx, rideID, domain.EventRideStatus, "", map[string]string{"status": domain.StatusCompleted})
 	return uc.rideRepo.GetByID(ctx, rideID)
 }
 
+func (uc *RideUseCase) clock() time.Time {
+	if uc.now == nil {
+		return time.Now()
+	}
+	return uc.now()
+}
+
 func (uc *RideUseCase) ListRidesByPassenger(ctx context.Context, passengerID string, limit int) ([]*domain.Ride, error) {
 	return uc.rideRepo.ListByPassenger(ctx, passengerID, limit)
 }
@@ -167,7 +641,7 @@ func (uc *RideUseCase) ListRidesByDriver(ctx context.Context, driverID string, l
 
 // ListOpenRides — rides in requested/bidding for drivers to bid
 func (uc *RideUseCase) ListOpenRides(ctx context.Context, limit int) ([]*domain.Ride, error) {
-	return uc.rideRepo.ListOpenRides(ctx, limit)
+	return uc.rideRepo.ListOpenRides(ctx, limit, nil)
 }
 
 // ListAllRides — admin: all rides
@@ -0,0 +1,94 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/kafka"
+	"github.com/ridehail/ride/internal/infra/pg"
+)
+
+type recordedEvents []domain.RideEvent
+
+func (r *recordedEvents) Publish(e domain.RideEvent) { *r = append(*r, e) }
+
+func (r recordedEvents) types() []string {
+	var out []string
+	for _, e := range r {
+		out = append(out, e.Type)
+	}
+	return out
+}
+
+func TestRideUseCase_BidNegotiation_Events(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{
+		ID: "r1", PassengerID: "p1", Status: domain.StatusBidding, Category: domain.CategoryEconomy, Seats: 1,
+		From: testFrom, To: testTo,
+	}}}
+	bids := &bidStore{}
+	rides.bids = bids
+	events := &recordedEvents{}
+	uc := NewRideUseCase(rides, bids, &kafka.NoopProducer{Events: events}, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	bid, err := uc.PlaceBid(ctx, "r1", "d1", "driver", 600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.CounterBid(ctx, "r1", bid.ID, "d1", 500); err != ErrNotPassenger {
+		t.Errorf("only the passenger counters, got %v", err)
+	}
+	if _, err := uc.CounterBid(ctx, "r1", bid.ID, "p1", 50); err == nil {
+		t.Error("counter-offer below the bid floor must fail")
+	}
+	if countered, err := uc.CounterBid(ctx, "r1", bid.ID, "p1", 500); err != nil || *countered.CounterPrice != 500 {
+		t.Fatalf("counter: %+v, %v", countered, err)
+	}
+	updated, err := uc.PlaceBid(ctx, "r1", "d1", "driver", 500)
+	if err != nil || updated.ID != bid.ID || updated.CounterPrice != nil || len(bids.bids) != 1 {
+		t.Fatalf("bidding again must update the pending bid: %+v, %v, created=%d", updated, err, len(bids.bids))
+	}
+	other, _ := uc.PlaceBid(ctx, "r1", "d2", "driver", 450)
+	if _, err := uc.WithdrawBid(ctx, "r1", other.ID, "d1"); err != ErrBidNotFound {
+		t.Errorf("drivers withdraw only their own bids, got %v", err)
+	}
+	if w, err := uc.WithdrawBid(ctx, "r1", other.ID, "d2"); err != nil || w.Status != pg.BidStatusWithdrawn {
+		t.Fatalf("withdraw: %+v, %v", w, err)
+	}
+	if _, err := uc.WithdrawBid(ctx, "r1", other.ID, "d2"); err != ErrBidNotPending {
+		t.Errorf("second withdraw must fail, got %v", err)
+	}
+	if _, err := uc.AcceptBid(ctx, "r1", other.ID, "p1"); err != ErrBidNotPending || rides.rides[0].Status != domain.StatusBidding {
+		t.Fatalf("a withdrawn bid must not match the ride: %v, status %s", err, rides.rides[0].Status)
+	}
+	if _, err := uc.AcceptBid(ctx, "r1", bid.ID, "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.UpdateStatus(ctx, "r1", domain.StatusInProgress, "d1", "driver", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{domain.EventBidPlaced, domain.EventBidCountered, domain.EventBidUpdated, domain.EventBidPlaced,
+		domain.EventBidWithdrawn, domain.EventRideMatched, domain.EventRideStatus}
+	got := events.types()
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+
+	ride, _ := uc.StreamRide(ctx, "r1", "d1")
+	if ride == nil {
+		t.Fatal("matched driver must be allowed to stream")
+	}
+	if _, err := uc.StreamRide(ctx, "r1", "d2"); err != ErrNotRideParticipant {
+		t.Errorf("other drivers must not stream, got %v", err)
+	}
+	if (*events)[3].VisibleTo("d1", ride) || !(*events)[3].VisibleTo("p1", ride) || !(*events)[5].VisibleTo("d1", ride) {
+		t.Error("matched driver must not see other drivers' bids")
+	}
+}
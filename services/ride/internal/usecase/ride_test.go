@@ -2,14 +2,25 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/kafka"
 )
 
+type fakeAddresses map[float64]string
+
+func (f fakeAddresses) ReverseGeocode(ctx context.Context, lat, lng float64) (string, error) {
+	if addr, ok := f[lat]; ok {
+		return addr, nil
+	}
+	return "", errors.New("geocoder down")
+}
+
 func TestRideUseCase_CreateRide_InvalidCoords(t *testing.T) {
 	uc := &RideUseCase{}
-	_, err := uc.CreateRide(context.Background(), "user1", domain.Point{Lat: 100, Lng: 0}, domain.Point{Lat: 55, Lng: 37})
+	_, err := uc.CreateRide(context.Background(), CreateRideInput{PassengerID: "user1", From: domain.Point{Lat: 100, Lng: 0}, To: domain.Point{Lat: 55, Lng: 37}})
 	if err != ErrInvalidStatus {
 		t.Errorf("expected ErrInvalidStatus, got %v", err)
 	}
@@ -17,8 +28,30 @@ func TestRideUseCase_CreateRide_InvalidCoords(t *testing.T) {
 
 func TestRideUseCase_PlaceBid_InvalidPrice(t *testing.T) {
 	uc := &RideUseCase{}
-	_, err := uc.PlaceBid(context.Background(), "ride1", "driver1", 0)
+	_, err := uc.PlaceBid(context.Background(), "ride1", "driver1", "driver", 0)
 	if err != ErrInvalidStatus {
 		t.Errorf("expected ErrInvalidStatus, got %v", err)
 	}
 }
+
+func TestRideUseCase_CreateRide_ResolvesAddresses(t *testing.T) {
+	repo := &rideStore{}
+	addresses := fakeAddresses{55.7616: "Россия, Москва, Тверская улица, 13"}
+	uc := NewRideUseCase(repo, nil, &kafka.NoopProducer{}, addresses, nil, nil, nil)
+
+	ride, err := uc.CreateRide(context.Background(), CreateRideInput{
+		PassengerID: "user1",
+		From:        domain.Point{Lat: 55.7616, Lng: 37.6094, Address: "тверская 13"},
+		To:          domain.Point{Lat: 55.9726, Lng: 37.4146, Address: "  Шереметьево,   терминал B "},
+	})
+	if err != nil {
+		t.Fatalf("CreateRide: %v", err)
+	}
+	if ride.From.Address != "Россия, Москва, Тверская улица, 13" {
+		t.Errorf("from address not normalized: %q", ride.From.Address)
+	}
+	// geocoder failed for the destination — client text kept, whitespace collapsed
+	if ride.To.Address != "Шереметьево, терминал B" {
+		t.Errorf("to address: %q", ride.To.Address)
+	}
+}
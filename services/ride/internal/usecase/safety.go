@@ -0,0 +1,263 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/batchcache"
+)
+
+var (
+	ErrAlertNotFound     = errors.New("SOS alert not found")
+	ErrInvalidAlertState = errors.New("alert status must be acknowledged or resolved")
+	// ErrShareLinkNotFound — unknown, revoked or expired trip link
+	ErrShareLinkNotFound = errors.New("trip link not found or no longer active")
+	ErrRideEnded         = errors.New("ride already ended")
+)
+
+// SafetyRepository — SOS alerts and public trip links
+type SafetyRepository interface {
+	CreateAlert(ctx context.Context, a *domain.SOSAlert) error
+	ListAlerts(ctx context.Context, activeOnly bool, limit int) ([]*domain.SOSAlert, error)
+	// SetAlertStatus returns the updated alert, nil when there is no such alert
+	SetAlertStatus(ctx context.Context, id, status, adminID string) (*domain.SOSAlert, error)
+	CreateShareLink(ctx context.Context, l *domain.ShareLink, tokenHash string) error
+	// GetShareLinkByHash returns nil when no link has the token
+	GetShareLinkByHash(ctx context.Context, tokenHash string) (*domain.ShareLink, error)
+	ListShareLinks(ctx context.Context, rideID, createdBy string) ([]*domain.ShareLink, error)
+	// RevokeShareLink returns false when the creator has no such unrevoked link
+	RevokeShareLink(ctx context.Context, id, rideID, createdBy string, at time.Time) (bool, error)
+}
+
+// SafetyConfig — trip link lifetime and address
+type SafetyConfig struct {
+	ShareLinkTTL time.Duration // links expire this long after creation even if not revoked
+	ShareBaseURL string        // token is appended to form the link sent to the contact
+}
+
+// DefaultSafetyConfig — links live 24 hours
+func DefaultSafetyConfig() SafetyConfig {
+	return SafetyConfig{ShareLinkTTL: 24 * time.Hour, ShareBaseURL: "http://localhost:8083/api/v1/public/trips/"}
+}
+
+const (
+	// maxSOSMessageLen — characters kept of the reporter's note
+	maxSOSMessageLen = 500
+	// sosLookupTimeout bounds the driver and location lookups of the snapshot; the alert
+	// must go out even when those services are slow
+	sosLookupTimeout = 2 * time.Second
+	// shareLocationTTL — public trip pages are polled; positions are reused this long
+	shareLocationTTL = 5 * time.Second
+)
+
+// SafetyUseCase — SOS alerts, and public trip links for trusted contacts
+type SafetyUseCase struct {
+	repo      SafetyRepository
+	rideRepo  RideRepository
+	pub       EventPublisher
+	cards     *batchcache.Cache[*domain.DriverCard] // optional
+	locations *batchcache.Cache[domain.Point]       // optional
+	cfg       SafetyConfig
+	now       func() time.Time
+}
+
+// NewSafetyUseCase creates safety use case; directory and locator may be nil (snapshots
+// and trip pages then lack the driver card and position)
+func NewSafetyUseCase(repo SafetyRepository, rideRepo RideRepository, pub EventPublisher, directory DriverDirectory, locator DriverLocator, cfg SafetyConfig) *SafetyUseCase {
+	def := DefaultSafetyConfig()
+	if cfg.ShareLinkTTL <= 0 {
+		cfg.ShareLinkTTL = def.ShareLinkTTL
+	}
+	if cfg.ShareBaseURL == "" {
+		cfg.ShareBaseURL = def.ShareBaseURL
+	}
+	uc := &SafetyUseCase{repo: repo, rideRepo: rideRepo, pub: pub, cfg: cfg, now: time.Now}
+	if directory != nil {
+		uc.cards = batchcache.New(DefaultBidEnricherConfig().CardTTL, directory.DriverCards)
+	}
+	if locator != nil {
+		uc.locations = batchcache.New(shareLocationTTL, locator.DriverLocations)
+	}
+	return uc
+}
+
+// RaiseSOS stores an alert with a snapshot of the ride, driver and last positions and
+// sends it to admins as a high-priority alert. Participants may raise it in any ride
+// status; location is the reporter's device position (optional).
+func (uc *SafetyUseCase) RaiseSOS(ctx context.Context, rideID, userID, message string, location *domain.Point) (*domain.SOSAlert, error) {
+	ride, role, err := uc.participant(ctx, rideID, userID)
+	if err != nil {
+		return nil, err
+	}
+	message = strings.TrimSpace(message)
+	if utf8.RuneCountInString(message) > maxSOSMessageLen {
+		message = string([]rune(message)[:maxSOSMessageLen])
+	}
+	if location != nil && (location.Lat < -90 || location.Lat > 90 || location.Lng < -180 || location.Lng > 180) {
+		location = nil
+	}
+	snapshot := domain.SOSSnapshot{Ride: ride.Redacted(ride.DriverID), ReporterLocation: location}
+	if ride.DriverID != "" {
+		lookupCtx, cancel := context.WithTimeout(ctx, sosLookupTimeout)
+		snapshot.Driver, snapshot.DriverLocation = uc.driver(lookupCtx, ride.DriverID, true)
+		cancel()
+	}
+	alert := &domain.SOSAlert{
+		RideID:       ride.ID,
+		ReporterID:   userID,
+		ReporterRole: role,
+		Message:      message,
+		Snapshot:     snapshot,
+		Status:       domain.SOSOpen,
+	}
+	if err := uc.repo.CreateAlert(ctx, alert); err != nil {
+		return nil, err
+	}
+	_ = uc.pub.SendSafetyAlert(ctx, alert)
+	return alert, nil
+}
+
+// ListAlerts — admin: alerts newest first; activeOnly skips resolved ones
+func (uc *SafetyUseCase) ListAlerts(ctx context.Context, activeOnly bool, limit int) ([]*domain.SOSAlert, error) {
+	return uc.repo.ListAlerts(ctx, activeOnly, limit)
+}
+
+// UpdateAlert — admin acknowledges or resolves an alert
+func (uc *SafetyUseCase) UpdateAlert(ctx context.Context, alertID, status, adminID string) (*domain.SOSAlert, error) {
+	if status != domain.SOSAcknowledged && status != domain.SOSResolved {
+		return nil, ErrInvalidAlertState
+	}
+	a, err := uc.repo.SetAlertStatus(ctx, alertID, status, adminID)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, ErrAlertNotFound
+	}
+	return a, nil
+}
+
+// CreateShareLink returns a new public link to the ride (the only time its token is
+// shown); the ride must not have ended
+func (uc *SafetyUseCase) CreateShareLink(ctx context.Context, rideID, userID string) (*domain.ShareLink, error) {
+	ride, _, err := uc.participant(ctx, rideID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ride.Status == domain.StatusCompleted || ride.Status == domain.StatusCancelled {
+		return nil, ErrRideEnded
+	}
+	token, hash, err := domain.NewShareToken()
+	if err != nil {
+		return nil, err
+	}
+	link := &domain.ShareLink{RideID: ride.ID, CreatedBy: userID, ExpiresAt: uc.clock().Add(uc.cfg.ShareLinkTTL)}
+	if err := uc.repo.CreateShareLink(ctx, link, hash); err != nil {
+		return nil, err
+	}
+	link.Token, link.URL = token, uc.cfg.ShareBaseURL+token
+	return link, nil
+}
+
+// ListShareLinks — links the user created for the ride (without tokens)
+func (uc *SafetyUseCase) ListShareLinks(ctx context.Context, rideID, userID string) ([]*domain.ShareLink, error) {
+	if _, _, err := uc.participant(ctx, rideID, userID); err != nil {
+		return nil, err
+	}
+	return uc.repo.ListShareLinks(ctx, rideID, userID)
+}
+
+// RevokeShareLink disables a link the user created; the page stops working at once
+func (uc *SafetyUseCase) RevokeShareLink(ctx context.Context, rideID, linkID, userID string) error {
+	ok, err := uc.repo.RevokeShareLink(ctx, linkID, rideID, userID, uc.clock())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrShareLinkNotFound
+	}
+	return nil
+}
+
+// ViewSharedTrip — public trip page behind a link token: status, route, driver and
+// vehicle, and the driver's live position while the ride is matched or in progress
+func (uc *SafetyUseCase) ViewSharedTrip(ctx context.Context, token string) (*domain.TripShareView, error) {
+	if token == "" {
+		return nil, ErrShareLinkNotFound
+	}
+	link, err := uc.repo.GetShareLinkByHash(ctx, domain.HashShareToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if link == nil || !link.Active(uc.clock()) {
+		return nil, ErrShareLinkNotFound
+	}
+	ride, err := uc.rideRepo.GetByID(ctx, link.RideID)
+	if err != nil {
+		return nil, err
+	}
+	if ride == nil {
+		return nil, ErrShareLinkNotFound
+	}
+	view := &domain.TripShareView{
+		Status:    ride.Status,
+		From:      ride.From,
+		To:        ride.To,
+		UpdatedAt: ride.UpdatedAt,
+		ExpiresAt: link.ExpiresAt,
+	}
+	if ride.DriverID != "" {
+		live := ride.Status == domain.StatusMatched || ride.Status == domain.StatusInProgress
+		view.Driver, view.DriverLocation = uc.driver(ctx, ride.DriverID, live)
+	}
+	return view, nil
+}
+
+// driver looks up the driver card and, with withLocation, the current position
+// concurrently; failures leave the part empty
+func (uc *SafetyUseCase) driver(ctx context.Context, driverID string, withLocation bool) (*domain.DriverCard, *domain.Point) {
+	var (
+		wg    sync.WaitGroup
+		card  *domain.DriverCard
+		point *domain.Point
+	)
+	ids := []string{driverID}
+	if uc.cards != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if cards, _ := uc.cards.Get(ctx, ids); cards != nil {
+				card = cards[driverID]
+			}
+		}()
+	}
+	if uc.locations != nil && withLocation {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if positions, _ := uc.locations.Get(ctx, ids); positions != nil {
+				if pos, ok := positions[driverID]; ok {
+					point = &pos
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return card, point
+}
+
+func (uc *SafetyUseCase) participant(ctx context.Context, rideID, userID string) (*domain.Ride, string, error) {
+	return rideParticipant(ctx, uc.rideRepo, rideID, userID)
+}
+
+func (uc *SafetyUseCase) clock() time.Time {
+	if uc.now == nil {
+		return time.Now()
+	}
+	return uc.now()
+}
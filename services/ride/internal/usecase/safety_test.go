@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/kafka"
+)
+
+func TestRideUseCase_UpdateStatus_PickupPIN(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{
+		ID: "r1", PassengerID: "p1", DriverID: "d1", Status: domain.StatusMatched,
+		PickupPIN: &domain.PickupPIN{Code: "0427"},
+	}}}
+	uc := NewRideUseCase(rides, &bidStore{}, &kafka.NoopProducer{}, nil, nil, nil, nil)
+	ctx := context.Background()
+
+	if _, err := uc.UpdateStatus(ctx, "r1", domain.StatusInProgress, "d1", "driver", ""); err != ErrPickupPINRequired {
+		t.Errorf("driver must enter the PIN, got %v", err)
+	}
+	if _, err := uc.UpdateStatus(ctx, "r1", domain.StatusInProgress, "d1", "driver", "1111"); err != ErrInvalidPickupPIN {
+		t.Errorf("wrong PIN, got %v", err)
+	}
+	ride, err := uc.UpdateStatus(ctx, "r1", domain.StatusInProgress, "d1", "driver", " 0427 ")
+	if err != nil || ride.Status != domain.StatusInProgress || ride.PickupPIN.VerifiedAt == nil {
+		t.Fatalf("correct PIN must start the ride: %+v, %v", ride, err)
+	}
+	if ride.Redacted("").PickupPIN.Code != "" {
+		t.Error("the PIN must be hidden from the driver")
+	}
+
+	rides.rides[0].Status, rides.rides[0].PickupPIN = domain.StatusMatched, &domain.PickupPIN{Code: "0427"}
+	for i := 1; i < domain.MaxPickupPINAttempts; i++ {
+		_, _ = uc.UpdateStatus(ctx, "r1", domain.StatusInProgress, "d1", "driver", "9999")
+	}
+	if _, err := uc.UpdateStatus(ctx, "r1", domain.StatusInProgress, "d1", "driver", "9999"); err != ErrPickupPINLocked {
+		t.Errorf("attempts must lock, got %v", err)
+	}
+	if _, err := uc.UpdateStatus(ctx, "r1", domain.StatusInProgress, "d1", "driver", "0427"); err != ErrPickupPINLocked {
+		t.Errorf("locked PIN stays locked, got %v", err)
+	}
+	if _, err := uc.UpdateStatus(ctx, "r1", domain.StatusInProgress, "p1", "passenger", ""); err != nil {
+		t.Errorf("the passenger starts a locked ride, got %v", err)
+	}
+}
+
+type alertEvents struct {
+	kafka.NoopProducer
+	alerts []*domain.SOSAlert
+}
+
+func (p *alertEvents) SendSafetyAlert(ctx context.Context, a *domain.SOSAlert) error {
+	p.alerts = append(p.alerts, a)
+	return nil
+}
+
+func TestSafetyUseCase_SOSAndShareLinks(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{
+		ID: "r1", PassengerID: "p1", DriverID: "d1", Status: domain.StatusInProgress,
+		PickupPIN: &domain.PickupPIN{Code: "0427"},
+	}}}
+	repo := &memSafety{links: map[string]*domain.ShareLink{}}
+	pub := &alertEvents{}
+	uc := NewSafetyUseCase(repo, rides, pub, &fakeDirectory{}, fakeLocator{"d1": {Lat: 55.7, Lng: 37.6}}, SafetyConfig{ShareBaseURL: "https://t.example/"})
+	ctx := context.Background()
+
+	alert, err := uc.RaiseSOS(ctx, "r1", "p1", " help ", &domain.Point{Lat: 55.71, Lng: 37.61})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := alert.Snapshot
+	if alert.ReporterRole != "passenger" || alert.Message != "help" || s.Driver == nil || s.Driver.VehiclePlate != "A001AA" || s.DriverLocation == nil || s.ReporterLocation == nil {
+		t.Fatalf("snapshot: %+v", alert)
+	}
+	if s.Ride.PickupPIN.Code != "" {
+		t.Error("the snapshot must not carry the PIN")
+	}
+	if len(pub.alerts) != 1 {
+		t.Error("admins must be alerted")
+	}
+	if _, err := uc.RaiseSOS(ctx, "r1", "d2", "", nil); err != ErrNotRideParticipant {
+		t.Errorf("outsiders must not raise SOS, got %v", err)
+	}
+
+	link, err := uc.CreateShareLink(ctx, "r1", "p1")
+	if err != nil || link.Token == "" || link.URL != "https://t.example/"+link.Token {
+		t.Fatalf("link: %+v, %v", link, err)
+	}
+	view, err := uc.ViewSharedTrip(ctx, link.Token)
+	if err != nil || view.Status != domain.StatusInProgress || view.DriverLocation == nil || view.Driver == nil {
+		t.Fatalf("view: %+v, %v", view, err)
+	}
+	if _, err := uc.ViewSharedTrip(ctx, "guess"); err != ErrShareLinkNotFound {
+		t.Errorf("unknown token, got %v", err)
+	}
+	if err := uc.RevokeShareLink(ctx, "r1", link.ID, "d1"); err != ErrShareLinkNotFound {
+		t.Errorf("only the creator revokes, got %v", err)
+	}
+	if err := uc.RevokeShareLink(ctx, "r1", link.ID, "p1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.ViewSharedTrip(ctx, link.Token); err != ErrShareLinkNotFound {
+		t.Errorf("revoked link must stop working, got %v", err)
+	}
+
+	other, _ := uc.CreateShareLink(ctx, "r1", "p1")
+	uc.now = func() time.Time { return time.Now().Add(25 * time.Hour) }
+	if _, err := uc.ViewSharedTrip(ctx, other.Token); err != ErrShareLinkNotFound {
+		t.Errorf("expired link, got %v", err)
+	}
+	rides.rides[0].Status = domain.StatusCompleted
+	if _, err := uc.CreateShareLink(ctx, "r1", "p1"); err != ErrRideEnded {
+		t.Errorf("ended rides are not shared, got %v", err)
+	}
+}
@@ -0,0 +1,341 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/pg"
+)
+
+// rideStore — rides kept in memory; updates behave like the pg ride repo, and MatchBid
+// like its transaction, settling the ride's bids in bids
+type rideStore struct {
+	RideRepository
+	rides []*domain.Ride
+	bids  *bidStore
+}
+
+func (f *rideStore) find(id string) *domain.Ride {
+	for _, r := range f.rides {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func (f *rideStore) Create(ctx context.Context, ride *domain.Ride) error {
+	ride.ID = fmt.Sprintf("ride%d", len(f.rides)+1)
+	f.rides = append(f.rides, ride)
+	return nil
+}
+
+func (f *rideStore) GetByID(ctx context.Context, id string) (*domain.Ride, error) {
+	r := f.find(id)
+	if r == nil {
+		return nil, nil
+	}
+	ride := *r
+	return &ride, nil
+}
+
+func (f *rideStore) ListOpenRides(ctx context.Context, limit int, filter *domain.FeedFilter) ([]*domain.Ride, error) {
+	var out []*domain.Ride
+	for _, r := range f.rides {
+		if filter.Matches(r) && len(out) < limit {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func (f *rideStore) UpdateStatus(ctx context.Context, id, status string) error {
+	f.find(id).Status = status
+	return nil
+}
+
+func (f *rideStore) MatchBid(ctx context.Context, rideID, bidID, driverID string, price float64) error {
+	r := f.find(rideID)
+	if r.Status != domain.StatusRequested && r.Status != domain.StatusBidding {
+		return pg.ErrRideStateChanged
+	}
+	if err := f.bids.accept(rideID, bidID); err != nil {
+		return err
+	}
+	r.DriverID, r.Price, r.Status = driverID, &price, domain.StatusMatched
+	return nil
+}
+
+func (f *rideStore) AddPickupPINAttempt(ctx context.Context, id string) (int, error) {
+	r := f.find(id)
+	r.PickupPIN.Attempts++
+	return r.PickupPIN.Attempts, nil
+}
+
+func (f *rideStore) StartWithPickupPIN(ctx context.Context, id string, at time.Time) error {
+	r := f.find(id)
+	r.PickupPIN.VerifiedAt, r.Status = &at, domain.StatusInProgress
+	return nil
+}
+
+func (f *rideStore) AddDeliveryAttempt(ctx context.Context, id string) (int, error) {
+	r := f.find(id)
+	r.Delivery.Attempts++
+	return r.Delivery.Attempts, nil
+}
+
+func (f *rideStore) ConfirmDelivery(ctx context.Context, id string, at time.Time) error {
+	r := f.find(id)
+	r.Delivery.ConfirmedAt, r.Status = &at, domain.StatusCompleted
+	return nil
+}
+
+// bidStore — bids kept in memory; a bid changes only while pending, like in the pg bid repo
+type bidStore struct {
+	BidRepository
+	bids     []*domain.Bid
+	accepted []string
+}
+
+func (f *bidStore) Create(ctx context.Context, bid *domain.Bid) error {
+	f.bids = append(f.bids, bid)
+	bid.ID, bid.Status = fmt.Sprintf("b%d", len(f.bids)), pg.BidStatusPending
+	return nil
+}
+
+func (f *bidStore) GetByID(ctx context.Context, id string) (*domain.Bid, error) {
+	for _, b := range f.bids {
+		if b.ID == id {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *bidStore) GetPendingByDriver(ctx context.Context, rideID, driverID string) (*domain.Bid, error) {
+	for _, b := range f.bids {
+		if b.RideID == rideID && b.DriverID == driverID && b.Status == pg.BidStatusPending {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *bidStore) ListByRideID(ctx context.Context, rideID string) ([]*domain.Bid, error) {
+	var out []*domain.Bid
+	for _, b := range f.bids {
+		if b.RideID == rideID {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func (f *bidStore) pending(bidID string, update func(b *domain.Bid)) error {
+	b, _ := f.GetByID(context.Background(), bidID)
+	if b == nil || b.Status != pg.BidStatusPending {
+		return pg.ErrBidStateChanged
+	}
+	update(b)
+	return nil
+}
+
+func (f *bidStore) UpdatePrice(ctx context.Context, bidID string, price float64) error {
+	return f.pending(bidID, func(b *domain.Bid) { b.Price, b.CounterPrice = price, nil })
+}
+
+func (f *bidStore) SetCounter(ctx context.Context, bidID string, price float64) error {
+	return f.pending(bidID, func(b *domain.Bid) { b.CounterPrice = &price })
+}
+
+func (f *bidStore) Withdraw(ctx context.Context, bidID string) error {
+	return f.pending(bidID, func(b *domain.Bid) { b.Status = pg.BidStatusWithdrawn })
+}
+
+// accept settles the ride's bids as MatchBid does: the pending bid accepted, the others rejected
+func (f *bidStore) accept(rideID, bidID string) error {
+	if err := f.pending(bidID, func(b *domain.Bid) { b.Status = pg.BidStatusAccepted }); err != nil {
+		return err
+	}
+	f.accepted = append(f.accepted, bidID)
+	for _, b := range f.bids {
+		if b.RideID == rideID && b.ID != bidID && b.Status == pg.BidStatusPending {
+			b.Status = pg.BidStatusRejected
+		}
+	}
+	return nil
+}
+
+type memChat struct {
+	msgs   []*domain.ChatMessage
+	cutoff time.Time
+}
+
+func (f *memChat) Create(ctx context.Context, m *domain.ChatMessage) error {
+	m.ID = fmt.Sprintf("m%d", len(f.msgs)+1)
+	m.CreatedAt = time.Now()
+	f.msgs = append(f.msgs, m)
+	return nil
+}
+
+func (f *memChat) ListByRide(ctx context.Context, rideID string, since time.Time, limit int) ([]*domain.ChatMessage, error) {
+	var out []*domain.ChatMessage
+	for _, m := range f.msgs {
+		if m.RideID == rideID && m.CreatedAt.After(since) && len(out) < limit {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+func (f *memChat) MarkRead(ctx context.Context, rideID, recipientID string, at time.Time) (int64, error) {
+	var n int64
+	for _, m := range f.msgs {
+		if m.RideID == rideID && m.RecipientID == recipientID && m.ReadAt == nil {
+			m.ReadAt = &at
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *memChat) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	f.cutoff = cutoff
+	return 0, nil
+}
+
+type fakeDestinationRepo struct {
+	dest *domain.DriverDestination
+}
+
+func (f *fakeDestinationRepo) Get(ctx context.Context, driverID string) (*domain.DriverDestination, error) {
+	return f.dest, nil
+}
+
+func (f *fakeDestinationRepo) Activate(ctx context.Context, d *domain.DriverDestination, dailyLimit int) error {
+	f.dest = d
+	return nil
+}
+
+func (f *fakeDestinationRepo) Deactivate(ctx context.Context, driverID string) error {
+	f.dest = nil
+	return nil
+}
+
+func (f *fakeDestinationRepo) ListActive(ctx context.Context, driverIDs []string) (map[string]*domain.DriverDestination, error) {
+	out := map[string]*domain.DriverDestination{}
+	if f.dest != nil {
+		out[f.dest.DriverID] = f.dest
+	}
+	return out, nil
+}
+
+type memSafety struct {
+	SafetyRepository
+	alerts []*domain.SOSAlert
+	links  map[string]*domain.ShareLink // by token hash
+}
+
+func (f *memSafety) CreateAlert(ctx context.Context, a *domain.SOSAlert) error {
+	a.ID = fmt.Sprintf("a%d", len(f.alerts)+1)
+	f.alerts = append(f.alerts, a)
+	return nil
+}
+
+func (f *memSafety) CreateShareLink(ctx context.Context, l *domain.ShareLink, tokenHash string) error {
+	l.ID = fmt.Sprintf("l%d", len(f.links)+1)
+	f.links[tokenHash] = l
+	return nil
+}
+
+func (f *memSafety) GetShareLinkByHash(ctx context.Context, tokenHash string) (*domain.ShareLink, error) {
+	return f.links[tokenHash], nil
+}
+
+func (f *memSafety) RevokeShareLink(ctx context.Context, id, rideID, createdBy string, at time.Time) (bool, error) {
+	for _, l := range f.links {
+		if l.ID == id && l.RideID == rideID && l.CreatedBy == createdBy && l.RevokedAt == nil {
+			l.RevokedAt = &at
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeTrips struct {
+	TripRepository
+	trips map[string]*domain.SharedTrip
+	seq   int
+}
+
+func newFakeTrips() *fakeTrips {
+	return &fakeTrips{trips: map[string]*domain.SharedTrip{}}
+}
+
+func (f *fakeTrips) Create(ctx context.Context, t *domain.SharedTrip) error {
+	f.seq++
+	t.ID = fmt.Sprintf("t%d", f.seq)
+	f.trips[t.ID] = t
+	return nil
+}
+
+func (f *fakeTrips) GetByID(ctx context.Context, id string) (*domain.SharedTrip, error) {
+	return f.trips[id], nil
+}
+
+func (f *fakeTrips) ListOpen(ctx context.Context, category string, from, to time.Time, limit int) ([]*domain.SharedTrip, error) {
+	var out []*domain.SharedTrip
+	for _, t := range f.trips {
+		if t.Status == domain.TripStatusOpen && !t.DepartAt.Before(from) && !t.DepartAt.After(to) {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeTrips) Book(ctx context.Context, b *domain.SeatBooking, check func(t *domain.SharedTrip) error) error {
+	t := f.trips[b.TripID]
+	if t == nil {
+		return errors.New("no trip")
+	}
+	if err := check(t); err != nil {
+		return err
+	}
+	f.seq++
+	b.ID = fmt.Sprintf("b%d", f.seq)
+	t.Bookings = append(t.Bookings, b)
+	return nil
+}
+
+func (f *fakeTrips) UpdateStatus(ctx context.Context, id, from, to string) error {
+	t := f.trips[id]
+	t.Status = to
+	for _, b := range t.Bookings {
+		if to == domain.TripStatusCompleted && b.Status == domain.BookingStatusBooked {
+			b.Status = domain.BookingStatusCompleted
+		}
+	}
+	return nil
+}
+
+type memRatings struct {
+	RatingRepo
+	created []*domain.Rating
+}
+
+func (f *memRatings) HasRatedTrip(ctx context.Context, tripID, fromUserID, toUserID string) (bool, error) {
+	for _, r := range f.created {
+		if r.TripID == tripID && r.FromUserID == fromUserID && r.ToUserID == toUserID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *memRatings) Create(ctx context.Context, r *domain.Rating) error {
+	f.created = append(f.created, r)
+	return nil
+}
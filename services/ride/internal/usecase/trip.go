@@ -0,0 +1,363 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/pg"
+)
+
+var (
+	ErrTripNotFound    = errors.New("trip not found")
+	ErrInvalidTrip     = errors.New("invalid trip route, departure, capacity or seat price")
+	ErrTripsNotAllowed = errors.New("category does not allow shared trips")
+	ErrTripNotOpen     = errors.New("trip is not open for booking")
+	ErrNotEnoughSeats  = errors.New("not enough free seats on this part of the route")
+	ErrOffRoute        = errors.New("pickup or dropoff is too far from the trip route")
+	ErrAlreadyBooked   = errors.New("passenger already booked this trip")
+	ErrOwnTrip         = errors.New("driver cannot book their own trip")
+	ErrBookingNotFound = errors.New("booking not found")
+)
+
+// maxTripCapacity — seats a driver may offer on one trip (minivan)
+const maxTripCapacity = 8
+
+// TripRepository — shared trips and seat bookings; trips are returned with their bookings
+type TripRepository interface {
+	Create(ctx context.Context, t *domain.SharedTrip) error
+	GetByID(ctx context.Context, id string) (*domain.SharedTrip, error)
+	// ListOpen — open trips of the category departing in [from, to], earliest first
+	ListOpen(ctx context.Context, category string, from, to time.Time, limit int) ([]*domain.SharedTrip, error)
+	ListByDriver(ctx context.Context, driverID string, limit int) ([]*domain.SharedTrip, error)
+	ListByPassenger(ctx context.Context, passengerID string, limit int) ([]*domain.SharedTrip, error)
+	// Book locks the trip, runs check on it with current bookings and inserts the booking
+	// if check passes; check fills the booking's route positions and price
+	Book(ctx context.Context, b *domain.SeatBooking, check func(t *domain.SharedTrip) error) error
+	// CancelBooking cancels a booked seat; pg.ErrTripStateChanged if it is no longer booked
+	CancelBooking(ctx context.Context, tripID, bookingID string) error
+	// UpdateStatus moves the trip from one status to another (bookings follow);
+	// pg.ErrTripStateChanged if the trip is no longer in from
+	UpdateStatus(ctx context.Context, id, from, to string) error
+}
+
+// TripConfig — shared trip matching rules
+type TripConfig struct {
+	MaxOffRouteKM float64       // pickup/dropoff distance from the route a passenger accepts
+	SearchWindow  time.Duration // default departure window of a search
+	SearchFetch   int           // open trips fetched before route matching
+}
+
+// DefaultTripConfig — pickups within 3 km of the route, trips departing in the next 24h
+func DefaultTripConfig() TripConfig {
+	return TripConfig{MaxOffRouteKM: 3, SearchWindow: 24 * time.Hour, SearchFetch: 200}
+}
+
+// TripUseCase — shared trips (carpooling): publish, route-overlap search, seat booking
+type TripUseCase struct {
+	repo     TripRepository
+	vehicles VehicleSource // optional: drivers not checked against category rules when nil
+	cfg      TripConfig
+	now      func() time.Time
+}
+
+// NewTripUseCase creates shared trips use case
+func NewTripUseCase(repo TripRepository, vehicles VehicleSource, cfg TripConfig) *TripUseCase {
+	def := DefaultTripConfig()
+	if cfg.MaxOffRouteKM <= 0 {
+		cfg.MaxOffRouteKM = def.MaxOffRouteKM
+	}
+	if cfg.SearchWindow <= 0 {
+		cfg.SearchWindow = def.SearchWindow
+	}
+	if cfg.SearchFetch <= 0 {
+		cfg.SearchFetch = def.SearchFetch
+	}
+	return &TripUseCase{repo: repo, vehicles: vehicles, cfg: cfg, now: time.Now}
+}
+
+// PublishTripInput — driver's trip offer
+type PublishTripInput struct {
+	DriverID  string
+	Category  string // seat-priced category with shared trips; empty = intercity
+	Route     []domain.Point
+	DepartAt  time.Time
+	Capacity  int
+	SeatPrice float64 // per seat, whole route
+}
+
+// PublishTrip validates the offer against the category rules and stores an open trip
+func (uc *TripUseCase) PublishTrip(ctx context.Context, in PublishTripInput) (*domain.SharedTrip, error) {
+	if in.Category == "" {
+		in.Category = domain.CategoryIntercity
+	}
+	rules, err := domain.RulesFor(in.Category)
+	if err != nil {
+		return nil, err
+	}
+	if !rules.SharedTrips {
+		return nil, ErrTripsNotAllowed
+	}
+	if len(in.Route) < 2 || len(in.Route) > domain.MaxTripStops {
+		return nil, ErrInvalidTrip
+	}
+	for _, p := range in.Route {
+		if !domain.ValidPoint(p) {
+			return nil, ErrInvalidTrip
+		}
+	}
+	routeKM := domain.RouteLengthKM(in.Route)
+	now := uc.now()
+	if routeKM <= 0 || in.DepartAt.Before(now) || in.DepartAt.After(now.Add(rules.PrebookWindow())) {
+		return nil, ErrInvalidTrip
+	}
+	if in.Capacity < 1 || in.Capacity > maxTripCapacity {
+		return nil, ErrInvalidTrip
+	}
+	in.SeatPrice = domain.RoundFare(in.SeatPrice)
+	if floor, ceiling := rules.BidRange(routeKM); in.SeatPrice < floor || in.SeatPrice > ceiling {
+		return nil, &BidRangeError{Floor: floor, Ceiling: ceiling}
+	}
+	if uc.vehicles != nil {
+		vehicle, err := uc.vehicles.DriverVehicle(ctx, in.DriverID)
+		if err != nil {
+			return nil, err
+		}
+		if !rules.Eligible(vehicle) {
+			return nil, ErrCategoryNotAllowed
+		}
+	}
+	t := &domain.SharedTrip{
+		DriverID:  in.DriverID,
+		Category:  rules.Category,
+		Status:    domain.TripStatusOpen,
+		Route:     in.Route,
+		RouteKM:   routeKM,
+		DepartAt:  in.DepartAt,
+		Capacity:  in.Capacity,
+		SeatPrice: in.SeatPrice,
+	}
+	if err := uc.repo.Create(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTrip returns the trip; the driver and admins see all bookings, others only their own
+func (uc *TripUseCase) GetTrip(ctx context.Context, id, userID, userRole string) (*domain.SharedTrip, error) {
+	t, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrTripNotFound
+	}
+	if userRole == "admin" || t.DriverID == userID {
+		return t, nil
+	}
+	return t.Redacted(userID), nil
+}
+
+// SearchTripsInput — passenger's route and departure window
+type SearchTripsInput struct {
+	From     domain.Point
+	To       domain.Point
+	Seats    int       // 0 = 1
+	After    time.Time // zero = now
+	Before   time.Time // zero = After + search window
+	Category string    // empty = any category with shared trips
+	Limit    int
+}
+
+// SearchTrips — open trips whose route passes near From and then To with enough free
+// seats on that part of the route, closest routes first
+func (uc *TripUseCase) SearchTrips(ctx context.Context, in SearchTripsInput) ([]domain.TripMatch, error) {
+	if !domain.ValidPoint(in.From) || !domain.ValidPoint(in.To) {
+		return nil, ErrInvalidStatus
+	}
+	if in.Seats <= 0 {
+		in.Seats = 1
+	}
+	if in.Limit <= 0 || in.Limit > 50 {
+		in.Limit = 20
+	}
+	if now := uc.now(); in.After.Before(now) {
+		in.After = now
+	}
+	if in.Before.IsZero() {
+		in.Before = in.After.Add(uc.cfg.SearchWindow)
+	}
+	if in.Category != "" {
+		rules, err := domain.RulesFor(in.Category)
+		if err != nil {
+			return nil, err
+		}
+		if !rules.SharedTrips {
+			return nil, ErrTripsNotAllowed
+		}
+	}
+	trips, err := uc.repo.ListOpen(ctx, in.Category, in.After, in.Before, uc.cfg.SearchFetch)
+	if err != nil {
+		return nil, err
+	}
+	out := []domain.TripMatch{}
+	for _, t := range trips {
+		m, ok := t.Match(in.From, in.To, uc.cfg.MaxOffRouteKM)
+		if !ok || m.SeatsFree < in.Seats {
+			continue
+		}
+		rules, err := domain.RulesFor(t.Category)
+		if err != nil {
+			continue
+		}
+		m.PricePerSeat = t.FareFor(m.PickupKM, m.DropoffKM, rules.MinFare)
+		m.Trip = t.Redacted("")
+		out = append(out, m)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].OffRouteKM < out[j].OffRouteKM })
+	if len(out) > in.Limit {
+		out = out[:in.Limit]
+	}
+	return out, nil
+}
+
+// BookSeatsInput — passenger's booking on a trip
+type BookSeatsInput struct {
+	TripID      string
+	PassengerID string
+	Seats       int // 0 = 1
+	Pickup      domain.Point
+	Dropoff     domain.Point
+}
+
+// BookSeats books seats between pickup and dropoff; capacity is checked per route
+// segment on the locked trip, so concurrent bookings cannot oversell it
+func (uc *TripUseCase) BookSeats(ctx context.Context, in BookSeatsInput) (*domain.SeatBooking, error) {
+	if in.Seats == 0 {
+		in.Seats = 1
+	}
+	if !domain.ValidPoint(in.Pickup) || !domain.ValidPoint(in.Dropoff) {
+		return nil, ErrInvalidStatus
+	}
+	b := &domain.SeatBooking{
+		TripID:      in.TripID,
+		PassengerID: in.PassengerID,
+		Seats:       in.Seats,
+		Pickup:      in.Pickup,
+		Dropoff:     in.Dropoff,
+		Status:      domain.BookingStatusBooked,
+	}
+	err := uc.repo.Book(ctx, b, func(t *domain.SharedTrip) error {
+		rules, err := domain.RulesFor(t.Category)
+		if err != nil {
+			return err
+		}
+		switch {
+		case t.Status != domain.TripStatusOpen || !t.DepartAt.After(uc.now()):
+			return ErrTripNotOpen
+		case t.DriverID == in.PassengerID:
+			return ErrOwnTrip
+		case t.Booking(in.PassengerID) != nil:
+			return ErrAlreadyBooked
+		case in.Seats < 1 || in.Seats > rules.MaxSeats:
+			return ErrInvalidSeats
+		}
+		m, ok := t.Match(in.Pickup, in.Dropoff, uc.cfg.MaxOffRouteKM)
+		if !ok {
+			return ErrOffRoute
+		}
+		if m.SeatsFree < in.Seats {
+			return ErrNotEnoughSeats
+		}
+		b.PickupKM, b.DropoffKM = m.PickupKM, m.DropoffKM
+		b.Price = domain.RoundFare(t.FareFor(m.PickupKM, m.DropoffKM, rules.MinFare) * float64(in.Seats))
+		return nil
+	})
+	if errors.Is(err, pg.ErrTripNotFound) {
+		return nil, ErrTripNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// CancelBooking — passenger cancels their seats before the trip starts
+func (uc *TripUseCase) CancelBooking(ctx context.Context, tripID, bookingID, passengerID string) error {
+	t, err := uc.repo.GetByID(ctx, tripID)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return ErrTripNotFound
+	}
+	var booking *domain.SeatBooking
+	for _, b := range t.Bookings {
+		if b.ID == bookingID && b.PassengerID == passengerID {
+			booking = b
+		}
+	}
+	if booking == nil {
+		return ErrBookingNotFound
+	}
+	if t.Status != domain.TripStatusOpen || booking.Status != domain.BookingStatusBooked {
+		return ErrTripNotOpen
+	}
+	if err := uc.repo.CancelBooking(ctx, tripID, bookingID); err != nil {
+		if errors.Is(err, pg.ErrTripStateChanged) {
+			return ErrTripNotOpen
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateTripStatus — driver starts (open → in_progress), completes (in_progress →
+// completed) or cancels (open → cancelled) the trip; admins may do the same
+func (uc *TripUseCase) UpdateTripStatus(ctx context.Context, tripID, status, userID, userRole string) (*domain.SharedTrip, error) {
+	from := map[string]string{
+		domain.TripStatusInProgress: domain.TripStatusOpen,
+		domain.TripStatusCompleted:  domain.TripStatusInProgress,
+		domain.TripStatusCancelled:  domain.TripStatusOpen,
+	}[status]
+	if from == "" {
+		return nil, ErrInvalidStatus
+	}
+	t, err := uc.repo.GetByID(ctx, tripID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrTripNotFound
+	}
+	if t.DriverID != userID && userRole != "admin" {
+		return nil, ErrNotDriver
+	}
+	if err := uc.repo.UpdateStatus(ctx, tripID, from, status); err != nil {
+		if errors.Is(err, pg.ErrTripStateChanged) {
+			return nil, ErrInvalidStatus
+		}
+		return nil, err
+	}
+	return uc.repo.GetByID(ctx, tripID)
+}
+
+// ListMyTrips — driver: published trips; passenger: trips with their bookings
+func (uc *TripUseCase) ListMyTrips(ctx context.Context, userID, userRole string, limit int) ([]*domain.SharedTrip, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if userRole == "driver" {
+		return uc.repo.ListByDriver(ctx, userID, limit)
+	}
+	trips, err := uc.repo.ListByPassenger(ctx, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i, t := range trips {
+		trips[i] = t.Redacted(userID)
+	}
+	return trips, nil
+}
@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ridehail/ride/internal/domain"
+)
+
+var (
+	moscow = domain.Point{Lat: 55.7558, Lng: 37.6173}
+	tver   = domain.Point{Lat: 56.8587, Lng: 35.9176}
+	spb    = domain.Point{Lat: 59.9343, Lng: 30.3351}
+)
+
+func newTripUseCase(repo TripRepository, now time.Time) *TripUseCase {
+	uc := NewTripUseCase(repo, nil, DefaultTripConfig())
+	uc.now = func() time.Time { return now }
+	return uc
+}
+
+func publishMoscowSPb(t *testing.T, uc *TripUseCase, now time.Time, capacity int) *domain.SharedTrip {
+	t.Helper()
+	trip, err := uc.PublishTrip(context.Background(), PublishTripInput{
+		DriverID:  "d1",
+		Route:     []domain.Point{moscow, tver, spb},
+		DepartAt:  now.Add(3 * time.Hour),
+		Capacity:  capacity,
+		SeatPrice: 2000,
+	})
+	if err != nil {
+		t.Fatalf("PublishTrip: %v", err)
+	}
+	return trip
+}
+
+func TestTripUseCase_PublishTrip_CategoryRules(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	uc := newTripUseCase(newFakeTrips(), now)
+	ctx := context.Background()
+	base := PublishTripInput{DriverID: "d1", Route: []domain.Point{moscow, spb}, DepartAt: now.Add(time.Hour), Capacity: 3, SeatPrice: 2000}
+
+	in := base
+	in.Category = domain.CategoryComfort
+	if _, err := uc.PublishTrip(ctx, in); err != ErrTripsNotAllowed {
+		t.Errorf("comfort: expected ErrTripsNotAllowed, got %v", err)
+	}
+	in = base
+	in.SeatPrice = 100
+	if _, err := uc.PublishTrip(ctx, in); !errors.Is(err, ErrBidOutOfRange) {
+		t.Errorf("cheap seat: expected ErrBidOutOfRange, got %v", err)
+	}
+	in = base
+	in.DepartAt = now.Add(15 * 24 * time.Hour)
+	if _, err := uc.PublishTrip(ctx, in); err != ErrInvalidTrip {
+		t.Errorf("beyond pre-booking window: expected ErrInvalidTrip, got %v", err)
+	}
+	trip, err := uc.PublishTrip(ctx, base)
+	if err != nil {
+		t.Fatalf("PublishTrip: %v", err)
+	}
+	if trip.Category != domain.CategoryIntercity || trip.Status != domain.TripStatusOpen || trip.RouteKM < 600 {
+		t.Errorf("unexpected trip %+v", trip)
+	}
+}
+
+func TestTripUseCase_SearchTrips_MatchesRouteOverlap(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	uc := newTripUseCase(newFakeTrips(), now)
+	trip := publishMoscowSPb(t, uc, now, 3)
+	ctx := context.Background()
+	nearMoscow := domain.Point{Lat: 55.77, Lng: 37.60}
+	nearTver := domain.Point{Lat: 56.87, Lng: 35.90}
+
+	got, err := uc.SearchTrips(ctx, SearchTripsInput{From: nearMoscow, To: nearTver})
+	if err != nil || len(got) != 1 || got[0].Trip.ID != trip.ID {
+		t.Fatalf("SearchTrips = %+v, %v", got, err)
+	}
+	if got[0].PricePerSeat >= trip.SeatPrice || got[0].PricePerSeat < 500 {
+		t.Errorf("Moscow–Tver leg must be prorated, got %v", got[0].PricePerSeat)
+	}
+	if got, _ := uc.SearchTrips(ctx, SearchTripsInput{From: nearTver, To: nearMoscow}); len(got) != 0 {
+		t.Errorf("opposite direction must not match, got %d", len(got))
+	}
+	kazan := domain.Point{Lat: 55.7961, Lng: 49.1064}
+	if got, _ := uc.SearchTrips(ctx, SearchTripsInput{From: nearMoscow, To: kazan}); len(got) != 0 {
+		t.Errorf("dropoff off the route must not match, got %d", len(got))
+	}
+	if got, _ := uc.SearchTrips(ctx, SearchTripsInput{From: nearMoscow, To: nearTver, Seats: 4}); len(got) != 0 {
+		t.Errorf("more seats than capacity must not match, got %d", len(got))
+	}
+}
+
+func TestTripUseCase_BookSeats_CapacityPerSegment(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	uc := newTripUseCase(newFakeTrips(), now)
+	trip := publishMoscowSPb(t, uc, now, 2)
+	ctx := context.Background()
+	book := func(passenger string, seats int, from, to domain.Point) (*domain.SeatBooking, error) {
+		return uc.BookSeats(ctx, BookSeatsInput{TripID: trip.ID, PassengerID: passenger, Seats: seats, Pickup: from, Dropoff: to})
+	}
+
+	first, err := book("p1", 2, moscow, tver)
+	if err != nil {
+		t.Fatalf("Moscow–Tver: %v", err)
+	}
+	if first.Price != 2*trip.FareFor(first.PickupKM, first.DropoffKM, 500) {
+		t.Errorf("price must be fare × seats, got %v", first.Price)
+	}
+	// seats free again after Tver
+	if _, err := book("p2", 2, tver, spb); err != nil {
+		t.Fatalf("Tver–SPb: %v", err)
+	}
+	if _, err := book("p3", 1, moscow, spb); err != ErrNotEnoughSeats {
+		t.Errorf("whole route: expected ErrNotEnoughSeats, got %v", err)
+	}
+	if _, err := book("p1", 1, tver, spb); err != ErrAlreadyBooked {
+		t.Errorf("second booking: expected ErrAlreadyBooked, got %v", err)
+	}
+	if _, err := book("d1", 1, moscow, tver); err != ErrOwnTrip {
+		t.Errorf("driver: expected ErrOwnTrip, got %v", err)
+	}
+
+	uc.now = func() time.Time { return trip.DepartAt.Add(time.Minute) }
+	if _, err := book("p4", 1, moscow, tver); err != ErrTripNotOpen {
+		t.Errorf("after departure: expected ErrTripNotOpen, got %v", err)
+	}
+}
+
+func TestRatingUseCase_SubmitTripRating(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	trips := newFakeTrips()
+	uc := newTripUseCase(trips, now)
+	trip := publishMoscowSPb(t, uc, now, 3)
+	ctx := context.Background()
+	if _, err := uc.BookSeats(ctx, BookSeatsInput{TripID: trip.ID, PassengerID: "p1", Pickup: moscow, Dropoff: tver}); err != nil {
+		t.Fatalf("BookSeats: %v", err)
+	}
+	ratings := &memRatings{}
+	ratingUC := NewRatingUseCase(ratings, nil, trips)
+
+	if _, err := ratingUC.SubmitTripRating(ctx, SubmitTripRatingInput{TripID: trip.ID, FromUserID: "p1", Score: 5}); err == nil {
+		t.Fatal("open trip must not be rated")
+	}
+	for _, status := range []string{domain.TripStatusInProgress, domain.TripStatusCompleted} {
+		if _, err := uc.UpdateTripStatus(ctx, trip.ID, status, "d1", "driver"); err != nil {
+			t.Fatalf("UpdateTripStatus(%s): %v", status, err)
+		}
+	}
+
+	r, err := ratingUC.SubmitTripRating(ctx, SubmitTripRatingInput{TripID: trip.ID, FromUserID: "p1", Score: 5, Tags: []string{"polite"}})
+	if err != nil || r.ToUserID != "d1" || r.Role != "driver" || r.TripID != trip.ID {
+		t.Fatalf("passenger rating = %+v, %v", r, err)
+	}
+	r, err = ratingUC.SubmitTripRating(ctx, SubmitTripRatingInput{TripID: trip.ID, FromUserID: "d1", ToUserID: "p1", Score: 4})
+	if err != nil || r.ToUserID != "p1" || r.Role != "passenger" {
+		t.Fatalf("driver rating = %+v, %v", r, err)
+	}
+	if _, err := ratingUC.SubmitTripRating(ctx, SubmitTripRatingInput{TripID: trip.ID, FromUserID: "d1", ToUserID: "p9", Score: 4}); err == nil {
+		t.Error("driver must not rate a user without a completed booking")
+	}
+	if _, err := ratingUC.SubmitTripRating(ctx, SubmitTripRatingInput{TripID: trip.ID, FromUserID: "p1", Score: 3}); err == nil {
+		t.Error("second rating of the same driver must fail")
+	}
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ridehail/ride/internal/domain"
+	"github.com/ridehail/ride/internal/infra/kafka"
+)
+
+type fakeVehicles map[string]*domain.Vehicle
+
+func (f fakeVehicles) DriverVehicle(ctx context.Context, driverID string) (*domain.Vehicle, error) {
+	return f[driverID], nil
+}
+
+var basicDocs = []string{domain.DocLicense, domain.DocPhoto}
+
+var testVehicles = fakeVehicles{
+	"economy-kids": {Class: domain.VehicleClassEconomy, Features: []string{domain.FeatureChildSeat}, Documents: basicDocs},
+	"business":     {Class: domain.VehicleClassBusiness, Documents: basicDocs},
+	"comfort-kids": {Class: domain.VehicleClassComfort, Features: []string{domain.FeatureChildSeat, domain.FeaturePetFriendly}, Documents: basicDocs},
+}
+
+// ~11 km across Moscow: economy bids between 150 and ~810
+var (
+	testFrom = domain.Point{Lat: 55.7539, Lng: 37.6208}
+	testTo   = domain.Point{Lat: 55.8304, Lng: 37.5010}
+)
+
+func TestRideUseCase_CreateRide_NormalizesOptions(t *testing.T) {
+	uc := NewRideUseCase(&rideStore{}, nil, &kafka.NoopProducer{}, nil, nil, nil, nil)
+	p := domain.Point{Lat: 55.75, Lng: 37.62}
+
+	ride, err := uc.CreateRide(context.Background(), CreateRideInput{PassengerID: "p1", From: p, To: p,
+		Options: domain.RideOptions{VehicleClass: " Comfort", Features: []string{"pet_friendly", "CHILD_SEAT", "child_seat"}}})
+	if err != nil {
+		t.Fatalf("CreateRide: %v", err)
+	}
+	if ride.Options.VehicleClass != domain.VehicleClassComfort || len(ride.Options.Features) != 2 || ride.Options.Features[0] != domain.FeatureChildSeat {
+		t.Errorf("options not normalized: %+v", ride.Options)
+	}
+	_, err = uc.CreateRide(context.Background(), CreateRideInput{PassengerID: "p1", From: p, To: p,
+		Options: domain.RideOptions{Features: []string{"hot_tub"}}})
+	if err != domain.ErrInvalidRideOptions {
+		t.Errorf("expected ErrInvalidRideOptions, got %v", err)
+	}
+}
+
+func TestRideUseCase_PlaceBid_RequiresMatchingVehicle(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{{
+		ID:      "r1",
+		Status:  domain.StatusBidding,
+		From:    testFrom,
+		To:      testTo,
+		Options: domain.RideOptions{VehicleClass: domain.VehicleClassComfort, Features: []string{domain.FeatureChildSeat}},
+	}}}
+	bids := &bidStore{}
+	uc := NewRideUseCase(rides, bids, &kafka.NoopProducer{}, nil, testVehicles, nil, nil)
+	ctx := context.Background()
+
+	for _, driverID := range []string{"economy-kids", "business"} {
+		if _, err := uc.PlaceBid(ctx, "r1", driverID, "driver", 500); err != ErrVehicleMismatch {
+			t.Errorf("%s: expected ErrVehicleMismatch, got %v", driverID, err)
+		}
+	}
+	if _, err := uc.PlaceBid(ctx, "r1", "unverified", "driver", 500); err != ErrCategoryNotAllowed {
+		t.Errorf("unverified: expected ErrCategoryNotAllowed, got %v", err)
+	}
+	if _, err := uc.PlaceBid(ctx, "r1", "comfort-kids", "driver", 500); err != nil {
+		t.Fatalf("matching driver: %v", err)
+	}
+	if len(bids.bids) != 1 || bids.bids[0].DriverID != "comfort-kids" {
+		t.Errorf("expected one bid from comfort-kids, got %+v", bids.bids)
+	}
+}
+
+func TestDestinationUseCase_ListOpenRides_FiltersByVehicle(t *testing.T) {
+	rides := &rideStore{rides: []*domain.Ride{
+		{ID: "any"},
+		{ID: "economy", Options: domain.RideOptions{VehicleClass: domain.VehicleClassEconomy}},
+		{ID: "comfort", Options: domain.RideOptions{VehicleClass: domain.VehicleClassComfort}},
+		{ID: "kids", Options: domain.RideOptions{Features: []string{domain.FeatureChildSeat}}},
+	}}
+	uc := NewDestinationUseCase(&fakeDestinationRepo{}, rides, testVehicles, DefaultDestinationConfig())
+	ids := func(driverID string) []string {
+		got, err := uc.ListOpenRides(context.Background(), driverID, nil, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, r := range got {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+
+	if got := ids("unverified"); len(got) != 0 {
+		t.Errorf("driver without an approved vehicle must see no rides, got %v", got)
+	}
+	if got := ids("business"); len(got) != 3 {
+		t.Errorf("business driver: expected any, economy, comfort; got %v", got)
+	}
+	if got := ids("comfort-kids"); len(got) != 4 {
+		t.Errorf("comfort driver with child seat sees all rides, got %v", got)
+	}
+}
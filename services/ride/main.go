@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -19,9 +20,13 @@ import (
 	"github.com/alexevil1979/indrive/packages/otel-go/tracing"
 
 	httphandler "github.com/ridehail/ride/internal/delivery/http"
+	"github.com/ridehail/ride/internal/infra/eligibility"
+	"github.com/ridehail/ride/internal/infra/geosvc"
 	"github.com/ridehail/ride/internal/infra/jwt"
 	"github.com/ridehail/ride/internal/infra/kafka"
 	"github.com/ridehail/ride/internal/infra/pg"
+	"github.com/ridehail/ride/internal/infra/stream"
+	"github.com/ridehail/ride/internal/infra/usersvc"
 	"github.com/ridehail/ride/internal/usecase"
 )
 
@@ -36,6 +41,24 @@ func main() {
 	kafkaBrokers := getEnv("KAFKA_BROKERS", "")
 	jwtSecret := getEnv("JWT_SECRET", "dev-secret-change-in-production")
 	otlpEndpoint := getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	userServiceURL := getEnv("USER_SERVICE_URL", "")       // address normalization via user-service geocoding
+	geoServiceURL := getEnv("GEOLOCATION_SERVICE_URL", "") // driver ETA on bids
+	destCfg := usecase.DefaultDestinationConfig()
+	destCfg.DailyLimit = getEnvInt("DESTINATION_DAILY_LIMIT", destCfg.DailyLimit)
+	destCfg.MinProgress = getEnvFloat("DESTINATION_MIN_PROGRESS", destCfg.MinProgress)
+	tripCfg := usecase.DefaultTripConfig()
+	tripCfg.MaxOffRouteKM = getEnvFloat("TRIP_MAX_OFF_ROUTE_KM", tripCfg.MaxOffRouteKM)
+	eligibilityTTL, _ := time.ParseDuration(getEnv("ELIGIBILITY_CACHE_TTL", eligibility.DefaultTTL.String()))
+	chatCfg := usecase.DefaultChatConfig()
+	chatCfg.Retention, _ = time.ParseDuration(getEnv("CHAT_RETENTION", chatCfg.Retention.String()))
+	safetyCfg := usecase.DefaultSafetyConfig()
+	safetyCfg.ShareLinkTTL, _ = time.ParseDuration(getEnv("SHARE_LINK_TTL", safetyCfg.ShareLinkTTL.String()))
+	safetyCfg.ShareBaseURL = getEnv("SHARE_LINK_BASE_URL", safetyCfg.ShareBaseURL)
+	// Bids are gated on driver verification; without a source of it every driver could bid
+	if userServiceURL == "" && kafkaBrokers == "" {
+		log.Error("driver eligibility source not configured: set USER_SERVICE_URL and/or KAFKA_BROKERS")
+		os.Exit(1)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -71,12 +94,18 @@ func main() {
 	log.Info("postgres + migrations ready")
 
 	// Initialize Kafka publisher
-	var pub usecase.EventPublisher = &kafka.NoopProducer{}
+	var brokers []string
 	if kafkaBrokers != "" {
-		brokers := strings.Split(kafkaBrokers, ",")
+		brokers = strings.Split(kafkaBrokers, ",")
 		for i := range brokers {
 			brokers[i] = strings.TrimSpace(brokers[i])
 		}
+	}
+	// Ride event stream (SSE): with Kafka every instance consumes ride.events into its hub,
+	// without it events go straight to the local hub
+	rideEvents := stream.NewHub(stream.DefaultReplaySize, stream.DefaultReplayTTL)
+	var pub usecase.EventPublisher = &kafka.NoopProducer{Events: rideEvents}
+	if len(brokers) > 0 {
 		kp, err := kafka.NewProducer(brokers)
 		if err != nil {
 			log.Warn("kafka connect failed, using noop", "error", err)
@@ -84,6 +113,16 @@ func main() {
 			defer kp.Close()
 			pub = kp
 			log.Info("kafka ready")
+			consumeCtx, stopConsume := context.WithCancel(context.Background())
+			defer stopConsume()
+			rc, err := kafka.NewRideEventConsumer(brokers, rideEvents)
+			if err == nil {
+				err = rc.Run(consumeCtx)
+				defer rc.Close()
+			}
+			if err != nil {
+				log.Warn("ride event stream consumer unavailable, SSE gets no events", "error", err)
+			}
 		}
 	}
 
@@ -92,10 +131,66 @@ func main() {
 	rideRepo := pg.NewRideRepo(pool)
 	bidRepo := pg.NewBidRepo(pool)
 	ratingRepo := pg.NewRatingRepo(pool)
-	rideUC := usecase.NewRideUseCase(rideRepo, bidRepo, pub)
-	ratingUC := usecase.NewRatingUseCase(ratingRepo, rideRepo)
+	var addresses usecase.AddressResolver
+	var vehicles usecase.VehicleSource
+	var eligibilityAPI eligibility.Source
+	var directory usecase.DriverDirectory
+	if userServiceURL != "" {
+		users := usersvc.New(userServiceURL, jwt.NewSigner(jwtSecret, serviceName), 3*time.Second)
+		addresses, vehicles, eligibilityAPI, directory = users, users, users, users
+	}
+	var locator usecase.DriverLocator
+	if geoServiceURL != "" {
+		locator = geosvc.New(geoServiceURL, jwt.NewSigner(jwtSecret, serviceName), 2*time.Second)
+	}
+	bidEnricher := usecase.NewBidEnricher(directory, locator, ratingRepo, usecase.DefaultBidEnricherConfig())
+
+	// Verified-driver gate: eligibility cache fed by user service events, misses read from its API
+	// (fails closed: a driver with no snapshot is not verified)
+	cache := eligibility.NewCache(eligibilityAPI, eligibilityTTL)
+	if len(brokers) > 0 {
+		consumeCtx, stopConsume := context.WithCancel(context.Background())
+		defer stopConsume()
+		ec, err := kafka.NewEligibilityConsumer(brokers, cache)
+		if err == nil {
+			err = ec.Run(consumeCtx)
+			defer ec.Close()
+		}
+		if err != nil {
+			log.Warn("driver eligibility events unavailable, using user service only", "error", err)
+		} else {
+			log.Info("driver eligibility consumer ready")
+		}
+	}
+	rideUC := usecase.NewRideUseCase(rideRepo, bidRepo, pub, addresses, vehicles, cache, bidEnricher)
+	tripRepo := pg.NewTripRepo(pool)
+	ratingUC := usecase.NewRatingUseCase(ratingRepo, rideRepo, tripRepo)
+	tripUC := usecase.NewTripUseCase(tripRepo, vehicles, tripCfg)
+	destinationUC := usecase.NewDestinationUseCase(pg.NewDestinationRepo(pool), rideRepo, vehicles, destCfg)
+	chatUC := usecase.NewChatUseCase(pg.NewChatRepo(pool), rideRepo, pub, chatCfg)
+	safetyUC := usecase.NewSafetyUseCase(pg.NewSafetyRepo(pool), rideRepo, pub, directory, locator, safetyCfg)
 	ratingHandler := httphandler.NewRatingHandler(ratingUC)
 
+	// Chat retention: purge messages past the support review period
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			if n, err := chatUC.PurgeExpired(purgeCtx); err != nil {
+				log.Warn("chat purge failed", "error", err)
+			} else if n > 0 {
+				log.Info("chat messages purged", "count", n)
+			}
+			select {
+			case <-purgeCtx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
 	// Setup Echo
 	e := echo.New()
 	e.HideBanner = true
@@ -107,18 +202,51 @@ func main() {
 	e.GET("/health", httphandler.Health)
 	e.GET("/ready", httphandler.Ready(pool))
 	e.GET("/metrics", echo.WrapHandler(m.Handler()))
+	e.GET("/api/v1/public/trips/:token", httphandler.ViewSharedTrip(safetyUC)) // trusted contacts, no login
 
 	api := e.Group("/api/v1")
 	api.Use(httphandler.JWTAuth(jwtValidator))
 	api.POST("/rides", httphandler.CreateRide(rideUC))
 	api.GET("/rides", httphandler.ListMyRides(rideUC))
-	api.GET("/rides/available", httphandler.ListAvailableRides(rideUC))
+	api.GET("/rides/available", httphandler.ListAvailableRides(destinationUC))
+	api.GET("/rides/categories", httphandler.ListCategories(rideUC))
 	api.GET("/admin/rides", httphandler.ListAllRides(rideUC))
 	api.GET("/rides/:id", httphandler.GetRide(rideUC))
 	api.POST("/rides/:id/bids", httphandler.PlaceBid(rideUC))
 	api.GET("/rides/:id/bids", httphandler.ListBids(rideUC))
+	api.DELETE("/rides/:id/bids/:bid_id", httphandler.WithdrawBid(rideUC))
+	api.POST("/rides/:id/bids/:bid_id/counter", httphandler.CounterBid(rideUC))
+	api.GET("/rides/:id/events", httphandler.RideEvents(rideUC, rideEvents))
 	api.POST("/rides/:id/accept", httphandler.AcceptBid(rideUC))
+	api.GET("/rides/:id/chat", httphandler.ListChatMessages(chatUC))
+	api.POST("/rides/:id/chat", httphandler.SendChatMessage(chatUC))
+	api.POST("/rides/:id/chat/read", httphandler.MarkChatRead(chatUC))
+	api.GET("/chat/quick-replies", httphandler.ListQuickReplies(chatUC))
+	api.POST("/rides/:id/sos", httphandler.RaiseSOS(safetyUC))
+	api.POST("/rides/:id/share", httphandler.CreateShareLink(safetyUC))
+	api.GET("/rides/:id/share", httphandler.ListShareLinks(safetyUC))
+	api.DELETE("/rides/:id/share/:link_id", httphandler.RevokeShareLink(safetyUC))
+	api.GET("/admin/sos", httphandler.ListSOSAlerts(safetyUC))
+	api.PATCH("/admin/sos/:id", httphandler.UpdateSOSAlert(safetyUC))
 	api.PATCH("/rides/:id/status", httphandler.UpdateRideStatus(rideUC))
+	api.POST("/rides/:id/delivery/confirm", httphandler.ConfirmDelivery(rideUC))
+	api.POST("/rides/:id/dispatch/filter", httphandler.FilterDispatch(destinationUC))
+
+	// Driver destination mode
+	api.GET("/drivers/me/destination", httphandler.GetDestination(destinationUC))
+	api.PUT("/drivers/me/destination", httphandler.SetDestination(destinationUC))
+	api.DELETE("/drivers/me/destination", httphandler.ClearDestination(destinationUC))
+
+	// Shared trips (carpooling)
+	api.POST("/trips", httphandler.PublishTrip(tripUC))
+	api.GET("/trips", httphandler.ListMyTrips(tripUC))
+	api.GET("/trips/search", httphandler.SearchTrips(tripUC))
+	api.GET("/trips/:id", httphandler.GetTrip(tripUC))
+	api.PATCH("/trips/:id/status", httphandler.UpdateTripStatus(tripUC))
+	api.POST("/trips/:id/bookings", httphandler.BookSeats(tripUC))
+	api.DELETE("/trips/:id/bookings/:booking_id", httphandler.CancelBooking(tripUC))
+	api.POST("/trips/:id/rating", ratingHandler.SubmitTripRating)
+	api.GET("/trips/:id/ratings", ratingHandler.GetTripRatings)
 
 	// Rating routes
 	api.POST("/rides/:id/rating", ratingHandler.SubmitRating)
@@ -144,6 +272,7 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Info("shutting down...")
+	rideEvents.Shutdown() // end SSE streams so the server can drain
 	graceCtx, graceCancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer graceCancel()
 	if err := e.Shutdown(graceCtx); err != nil {
@@ -192,3 +321,17 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if v, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/ridehail/user/internal/domain"
+	"github.com/ridehail/user/internal/usecase"
+)
+
+// GeocodingHandler handles geocoding and maps proxy HTTP requests
+type GeocodingHandler struct {
+	uc *usecase.GeocodingUseCase
+}
+
+// NewGeocodingHandler creates a new geocoding handler
+func NewGeocodingHandler(uc *usecase.GeocodingUseCase) *GeocodingHandler {
+	return &GeocodingHandler{uc: uc}
+}
+
+// Geocode — GET /api/v1/maps/geocode?q=Тверская 13&lang=ru
+func (h *GeocodingHandler) Geocode() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		results, err := h.uc.Geocode(c.Request().Context(), quotaUserID(c), c.QueryParam("q"), c.QueryParam("lang"))
+		if err != nil {
+			return geocodeError(c, err)
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"results": results})
+	}
+}
+
+// Reverse — GET /api/v1/maps/geocode/reverse?lat=55.7616&lng=37.6094&lang=ru
+func (h *GeocodingHandler) Reverse() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		p, ok := queryLatLng(c, "lat", "lng")
+		if !ok {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "lat and lng are required"})
+		}
+		addr, err := h.uc.Reverse(c.Request().Context(), quotaUserID(c), p.Lat, p.Lng, c.QueryParam("lang"))
+		if err != nil {
+			return geocodeError(c, err)
+		}
+		return c.JSON(http.StatusOK, addr)
+	}
+}
+
+// Suggest — GET /api/v1/maps/suggest?q=Твер&lat=55.75&lng=37.62&lang=ru (lat/lng optional)
+func (h *GeocodingHandler) Suggest() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		var near *domain.LatLng
+		if p, ok := queryLatLng(c, "lat", "lng"); ok {
+			near = &p
+		}
+		results, err := h.uc.Suggest(c.Request().Context(), quotaUserID(c), c.QueryParam("q"), c.QueryParam("lang"), near)
+		if err != nil {
+			return geocodeError(c, err)
+		}
+		return c.JSON(http.StatusOK, map[string]interface{}{"results": results})
+	}
+}
+
+// Route — GET /api/v1/maps/route?from_lat=&from_lng=&to_lat=&to_lng=
+func (h *GeocodingHandler) Route() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		from, okFrom := queryLatLng(c, "from_lat", "from_lng")
+		to, okTo := queryLatLng(c, "to_lat", "to_lng")
+		if !okFrom || !okTo {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": "from_lat, from_lng, to_lat and to_lng are required"})
+		}
+		route, err := h.uc.Route(c.Request().Context(), quotaUserID(c), from, to)
+		if err != nil {
+			return geocodeError(c, err)
+		}
+		return c.JSON(http.StatusOK, route)
+	}
+}
+
+// quotaUserID — user charged for the request; service tokens (ride service etc.) are not limited
+func quotaUserID(c echo.Context) string {
+	if role, _ := c.Get(UserRoleKey).(string); role == "service" {
+		return ""
+	}
+	userID, _ := c.Get(UserIDKey).(string)
+	return userID
+}
+
+func queryLatLng(c echo.Context, latParam, lngParam string) (domain.LatLng, bool) {
+	lat, errLat := strconv.ParseFloat(c.QueryParam(latParam), 64)
+	lng, errLng := strconv.ParseFloat(c.QueryParam(lngParam), 64)
+	if errLat != nil || errLng != nil {
+		return domain.LatLng{}, false
+	}
+	return domain.LatLng{Lat: lat, Lng: lng}, true
+}
+
+func geocodeError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrEmptyQuery), errors.Is(err, usecase.ErrInvalidCoordinates):
+		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, domain.ErrAddressNotFound), errors.Is(err, domain.ErrRouteNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrGeocodeQuotaExceeded):
+		return c.JSON(http.StatusTooManyRequests, map[string]string{"error": err.Error()})
+	case errors.Is(err, usecase.ErrGeocoderFailed):
+		return c.JSON(http.StatusBadGateway, map[string]string{"error": "geocoding provider unavailable"})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"error": "geocoding failed"})
+}
@@ -18,9 +18,12 @@ func NewSettingsHandler(uc *usecase.SettingsUseCase) *SettingsHandler {
 	return &SettingsHandler{uc: uc}
 }
 
-// GetSettings returns all app settings (admin only)
+// GetSettings returns all app settings with API keys masked (admin only)
 func (h *SettingsHandler) GetSettings() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		if c.Get(UserRoleKey) != "admin" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin only"})
+		}
 		settings, err := h.uc.GetSettings(c.Request().Context())
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
@@ -29,18 +32,26 @@ func (h *SettingsHandler) GetSettings() echo.HandlerFunc {
 	}
 }
 
-// UpdateSettingsRequest represents the update settings request body
+// UpdateSettingsRequest represents the update settings request body.
+// Masked keys (as returned by GET) are kept unchanged; empty clears the key.
 type UpdateSettingsRequest struct {
-	MapProvider      string `json:"map_provider"`
-	GoogleMapsAPIKey string `json:"google_maps_api_key,omitempty"`
-	YandexMapsAPIKey string `json:"yandex_maps_api_key,omitempty"`
-	DefaultLanguage  string `json:"default_language,omitempty"`
-	DefaultCurrency  string `json:"default_currency,omitempty"`
+	MapProvider          string `json:"map_provider"`
+	GoogleMapsAPIKey     string `json:"google_maps_api_key,omitempty"`
+	YandexMapsAPIKey     string `json:"yandex_maps_api_key,omitempty"`
+	GoogleMapsAndroidKey string `json:"google_maps_android_key,omitempty"`
+	GoogleMapsIOSKey     string `json:"google_maps_ios_key,omitempty"`
+	YandexMapsAndroidKey string `json:"yandex_maps_android_key,omitempty"`
+	YandexMapsIOSKey     string `json:"yandex_maps_ios_key,omitempty"`
+	DefaultLanguage      string `json:"default_language,omitempty"`
+	DefaultCurrency      string `json:"default_currency,omitempty"`
 }
 
 // UpdateSettings updates app settings (admin only)
 func (h *SettingsHandler) UpdateSettings() echo.HandlerFunc {
 	return func(c echo.Context) error {
+		if c.Get(UserRoleKey) != "admin" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin only"})
+		}
 		var req UpdateSettingsRequest
 		if err := c.Bind(&req); err != nil {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
@@ -49,12 +60,16 @@ func (h *SettingsHandler) UpdateSettings() echo.HandlerFunc {
 		userID := c.Get(UserIDKey).(string)
 
 		settings := &domain.AppSettings{
-			ID:               "default",
-			MapProvider:      domain.MapProvider(req.MapProvider),
-			GoogleMapsAPIKey: req.GoogleMapsAPIKey,
-			YandexMapsAPIKey: req.YandexMapsAPIKey,
-			DefaultLanguage:  req.DefaultLanguage,
-			DefaultCurrency:  req.DefaultCurrency,
+			ID:                   "default",
+			MapProvider:          domain.MapProvider(req.MapProvider),
+			GoogleMapsAPIKey:     req.GoogleMapsAPIKey,
+			YandexMapsAPIKey:     req.YandexMapsAPIKey,
+			GoogleMapsAndroidKey: req.GoogleMapsAndroidKey,
+			GoogleMapsIOSKey:     req.GoogleMapsIOSKey,
+			YandexMapsAndroidKey: req.YandexMapsAndroidKey,
+			YandexMapsIOSKey:     req.YandexMapsIOSKey,
+			DefaultLanguage:      req.DefaultLanguage,
+			DefaultCurrency:      req.DefaultCurrency,
 		}
 
 		if settings.DefaultLanguage == "" {
@@ -75,10 +90,16 @@ func (h *SettingsHandler) UpdateSettings() echo.HandlerFunc {
 	}
 }
 
-// GetMapSettings returns map settings for mobile apps (public endpoint)
+// GetMapSettings returns map settings for mobile apps (public endpoint).
+// GET /api/v1/settings/maps?platform=android|ios (or X-Platform header) —
+// only the restricted key for that platform is returned.
 func (h *SettingsHandler) GetMapSettings() echo.HandlerFunc {
 	return func(c echo.Context) error {
-		settings, err := h.uc.GetMapSettings(c.Request().Context())
+		platform := domain.ParsePlatform(c.QueryParam("platform"))
+		if platform == "" {
+			platform = domain.ParsePlatform(c.Request().Header.Get("X-Platform"))
+		}
+		settings, err := h.uc.GetMapSettings(c.Request().Context(), platform)
 		if err != nil {
 			return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		}
@@ -2,8 +2,11 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
@@ -23,10 +26,13 @@ func NewVerificationHandler(uc *usecase.VerificationUseCase) *VerificationHandle
 
 // StartVerificationRequest — POST /api/v1/verification
 type StartVerificationRequest struct {
-	LicenseNumber string `json:"license_number"`
-	VehicleModel  string `json:"vehicle_model"`
-	VehiclePlate  string `json:"vehicle_plate"`
-	VehicleYear   int    `json:"vehicle_year"`
+	LicenseNumber string   `json:"license_number"`
+	VehicleModel  string   `json:"vehicle_model"`
+	VehiclePlate  string   `json:"vehicle_plate"`
+	VehicleColor  string   `json:"vehicle_color"`
+	VehicleYear   int      `json:"vehicle_year"`
+	VehicleClass  string   `json:"vehicle_class"` // economy (default), comfort, business
+	Features      []string `json:"features"`      // minivan, child_seat, pet_friendly, wheelchair
 }
 
 // StartVerification creates a new verification request.
@@ -44,8 +50,20 @@ func (h *VerificationHandler) StartVerification() echo.HandlerFunc {
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": "license_number required"})
 		}
 
-		v, err := h.uc.StartVerification(c.Request().Context(), userID, req.LicenseNumber, req.VehicleModel, req.VehiclePlate, req.VehicleYear)
+		v, err := h.uc.StartVerification(c.Request().Context(), usecase.StartVerificationInput{
+			UserID:        userID,
+			LicenseNumber: req.LicenseNumber,
+			VehicleModel:  req.VehicleModel,
+			VehiclePlate:  req.VehiclePlate,
+			VehicleColor:  req.VehicleColor,
+			VehicleYear:   req.VehicleYear,
+			VehicleClass:  req.VehicleClass,
+			Features:      req.Features,
+		})
 		if err != nil {
+			if err == domain.ErrInvalidVehicleClass || err == domain.ErrInvalidVehicleFeature {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
 			if err == domain.ErrVerificationPending {
 				return c.JSON(http.StatusConflict, map[string]string{"error": "verification already pending"})
 			}
@@ -233,9 +251,12 @@ func (h *VerificationHandler) GetVerificationByID() echo.HandlerFunc {
 }
 
 // ReviewVerificationRequest — POST /api/v1/admin/verifications/:id/review
+// vehicle_class/features (optional) correct the driver's declaration on approval.
 type ReviewVerificationRequest struct {
-	Approved     bool   `json:"approved"`
-	RejectReason string `json:"reject_reason"`
+	Approved     bool     `json:"approved"`
+	RejectReason string   `json:"reject_reason"`
+	VehicleClass string   `json:"vehicle_class"`
+	Features     []string `json:"features"`
 }
 
 // ReviewVerification approves or rejects a verification (admin only).
@@ -260,9 +281,15 @@ func (h *VerificationHandler) ReviewVerification() echo.HandlerFunc {
 			AdminUserID:    adminUserID,
 			Approved:       req.Approved,
 			RejectReason:   req.RejectReason,
+			VehicleClass:   req.VehicleClass,
+			Features:       req.Features,
 		}
 
 		if err := h.uc.AdminReviewVerification(c.Request().Context(), input); err != nil {
+			if errors.Is(err, usecase.ErrAttributesSyncFailed) {
+				// Approval is stored; admin retries via /admin/drivers/:id/attributes/sync
+				return c.JSON(http.StatusOK, map[string]interface{}{"status": "reviewed", "attributes_synced": false})
+			}
 			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
 		}
 
@@ -272,8 +299,9 @@ func (h *VerificationHandler) ReviewVerification() echo.HandlerFunc {
 
 // ReviewDocumentRequest — POST /api/v1/admin/documents/:id/review
 type ReviewDocumentRequest struct {
-	Approved     bool   `json:"approved"`
-	RejectReason string `json:"reject_reason"`
+	Approved     bool       `json:"approved"`
+	RejectReason string     `json:"reject_reason"`
+	ExpiresAt    *time.Time `json:"expires_at"` // approval only; omit for documents without expiry
 }
 
 // ReviewDocument approves or rejects a document (admin only).
@@ -298,6 +326,7 @@ func (h *VerificationHandler) ReviewDocument() echo.HandlerFunc {
 			AdminUserID:  adminUserID,
 			Approved:     req.Approved,
 			RejectReason: req.RejectReason,
+			ExpiresAt:    req.ExpiresAt,
 		}
 
 		if err := h.uc.AdminReviewDocument(c.Request().Context(), input); err != nil {
@@ -307,3 +336,92 @@ func (h *VerificationHandler) ReviewDocument() echo.HandlerFunc {
 		return c.JSON(http.StatusOK, map[string]string{"status": "reviewed"})
 	}
 }
+
+// GetDriverAttributes returns vehicle class/features of an approved driver
+// (ride service, admin, or the driver themself).
+// GET /api/v1/drivers/:id/attributes
+func (h *VerificationHandler) GetDriverAttributes() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		driverID := c.Param("id")
+		role := c.Get(UserRoleKey)
+		if role != "service" && role != "admin" && c.Get(UserIDKey) != driverID {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
+
+		a, err := h.uc.GetDriverAttributes(c.Request().Context(), driverID)
+		if err != nil {
+			if err == usecase.ErrDriverNotVerified {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get attributes"})
+		}
+
+		return c.JSON(http.StatusOK, a)
+	}
+}
+
+// GetDriverCards returns public cards (name, avatar, vehicle) of approved drivers
+// (ride service bid list, admin).
+// GET /api/v1/drivers/cards?ids=a,b,c
+func (h *VerificationHandler) GetDriverCards() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		role := c.Get(UserRoleKey)
+		if role != "service" && role != "admin" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
+
+		cards, err := h.uc.DriverCards(c.Request().Context(), strings.Split(c.QueryParam("ids"), ","))
+		if err != nil {
+			if err == usecase.ErrTooManyDrivers {
+				return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get driver cards"})
+		}
+
+		return c.JSON(http.StatusOK, map[string]interface{}{"drivers": cards})
+	}
+}
+
+// GetDriverEligibility returns the driver's verification snapshot (ride service bid gate,
+// admin, or the driver themself).
+// GET /api/v1/drivers/:id/eligibility
+func (h *VerificationHandler) GetDriverEligibility() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		driverID := c.Param("id")
+		role := c.Get(UserRoleKey)
+		if role != "service" && role != "admin" && c.Get(UserIDKey) != driverID {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "forbidden"})
+		}
+
+		e, err := h.uc.DriverEligibility(c.Request().Context(), driverID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to get eligibility"})
+		}
+
+		return c.JSON(http.StatusOK, e)
+	}
+}
+
+// SyncDriverAttributes re-pushes approved vehicle attributes to geolocation (admin only).
+// POST /api/v1/admin/drivers/:id/attributes/sync
+func (h *VerificationHandler) SyncDriverAttributes() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		role := c.Get(UserRoleKey)
+		if role != "admin" {
+			return c.JSON(http.StatusForbidden, map[string]string{"error": "admin only"})
+		}
+
+		a, err := h.uc.SyncDriverAttributes(c.Request().Context(), c.Param("id"))
+		if err != nil {
+			if err == usecase.ErrDriverNotVerified {
+				return c.JSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			}
+			if errors.Is(err, usecase.ErrAttributesSyncFailed) {
+				return c.JSON(http.StatusBadGateway, map[string]string{"error": err.Error()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"error": "failed to sync attributes"})
+		}
+
+		return c.JSON(http.StatusOK, a)
+	}
+}
@@ -0,0 +1,57 @@
+// Package domain — Geocoding: address <-> coordinates via the active map provider,
+// plus address suggest and driving routes for the maps proxy
+package domain
+
+import "errors"
+
+// MapProviderStub — fixture-based geocoder (tests, offline dev); never stored in settings
+const MapProviderStub MapProvider = "stub"
+
+var (
+	ErrAddressNotFound = errors.New("address not found")
+	ErrRouteNotFound   = errors.New("route not found")
+)
+
+// LatLng — coordinates pair
+type LatLng struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// Valid reports whether coordinates are within WGS84 bounds
+func (p LatLng) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180
+}
+
+// Address — normalized geocoder result (same shape for every provider)
+type Address struct {
+	Formatted string      `json:"formatted"`
+	Country   string      `json:"country,omitempty"`
+	City      string      `json:"city,omitempty"`
+	District  string      `json:"district,omitempty"`
+	Street    string      `json:"street,omitempty"`
+	House     string      `json:"house,omitempty"`
+	Lat       float64     `json:"lat"`
+	Lng       float64     `json:"lng"`
+	Provider  MapProvider `json:"provider"`
+}
+
+// Suggestion — address autocomplete item; coordinates only when the provider returns them
+// (Yandex/Google suggestions are resolved with a follow-up geocode call)
+type Suggestion struct {
+	Title     string      `json:"title"`
+	Subtitle  string      `json:"subtitle,omitempty"`
+	Formatted string      `json:"formatted,omitempty"`
+	PlaceID   string      `json:"place_id,omitempty"`
+	Lat       float64     `json:"lat,omitempty"`
+	Lng       float64     `json:"lng,omitempty"`
+	Provider  MapProvider `json:"provider"`
+}
+
+// Route — driving route between two points
+type Route struct {
+	DistanceM int         `json:"distance_m"`
+	DurationS int         `json:"duration_s"`
+	Polyline  string      `json:"polyline"` // Google encoded polyline (precision 5), same for every provider
+	Provider  MapProvider `json:"provider"`
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // MapProvider represents map service provider
 type MapProvider string
@@ -8,16 +11,30 @@ type MapProvider string
 const (
 	MapProviderGoogle MapProvider = "google"
 	MapProviderYandex MapProvider = "yandex"
+	MapProviderOSM    MapProvider = "osm" // OpenStreetMap tiles + Nominatim geocoding, no API key
+)
+
+// Platform — client platform a restricted map key is issued for
+type Platform string
+
+const (
+	PlatformAndroid Platform = "android"
+	PlatformIOS     Platform = "ios"
 )
 
 // AppSettings represents application-wide settings
 type AppSettings struct {
 	ID          string      `json:"id"`
 	MapProvider MapProvider `json:"map_provider"`
-	// Google Maps settings
+	// Google Maps settings (server key: used only by the maps proxy, never sent to clients)
 	GoogleMapsAPIKey string `json:"google_maps_api_key,omitempty"`
-	// Yandex Maps settings
+	// Yandex Maps settings (server key: used only by the maps proxy, never sent to clients)
 	YandexMapsAPIKey string `json:"yandex_maps_api_key,omitempty"`
+	// Restricted client keys (locked to app package / bundle ID in the provider console)
+	GoogleMapsAndroidKey string `json:"google_maps_android_key,omitempty"`
+	GoogleMapsIOSKey     string `json:"google_maps_ios_key,omitempty"`
+	YandexMapsAndroidKey string `json:"yandex_maps_android_key,omitempty"`
+	YandexMapsIOSKey     string `json:"yandex_maps_ios_key,omitempty"`
 	// Additional settings
 	DefaultLanguage string    `json:"default_language"`
 	DefaultCurrency string    `json:"default_currency"`
@@ -28,10 +45,84 @@ type AppSettings struct {
 // MapSettings represents map-specific settings for mobile apps
 type MapSettings struct {
 	Provider MapProvider `json:"provider"`
-	APIKey   string      `json:"api_key"`
+	Platform Platform    `json:"platform,omitempty"`
+	APIKey   string      `json:"api_key"`   // restricted key for Platform only; empty when not configured
+	ProxyURL string      `json:"proxy_url"` // geocode/suggest/route go through the server-side proxy
 }
 
 // Validate validates MapProvider value
 func (p MapProvider) Validate() bool {
-	return p == MapProviderGoogle || p == MapProviderYandex
+	return p == MapProviderGoogle || p == MapProviderYandex || p == MapProviderOSM
+}
+
+// ParsePlatform returns the platform or "" if unknown
+func ParsePlatform(s string) Platform {
+	switch Platform(strings.ToLower(strings.TrimSpace(s))) {
+	case PlatformAndroid:
+		return PlatformAndroid
+	case PlatformIOS:
+		return PlatformIOS
+	}
+	return ""
+}
+
+// ClientKey returns the restricted key of the active provider for a platform
+func (s *AppSettings) ClientKey(platform Platform) string {
+	switch {
+	case s.MapProvider == MapProviderGoogle && platform == PlatformAndroid:
+		return s.GoogleMapsAndroidKey
+	case s.MapProvider == MapProviderGoogle && platform == PlatformIOS:
+		return s.GoogleMapsIOSKey
+	case s.MapProvider == MapProviderYandex && platform == PlatformAndroid:
+		return s.YandexMapsAndroidKey
+	case s.MapProvider == MapProviderYandex && platform == PlatformIOS:
+		return s.YandexMapsIOSKey
+	}
+	return ""
+}
+
+// secretMask prefixes masked values; a masked value sent back on update means "unchanged"
+const secretMask = "••••"
+
+// secretSuffixLen — trailing characters MaskSecret keeps, so admins can tell keys apart
+const secretSuffixLen = 4
+
+// MaskSecret keeps only the last 4 characters of a secret ("" stays "")
+func MaskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	return secretMask + s[max(0, len(s)-secretSuffixLen):]
+}
+
+// IsMaskedSecret reports whether s is a MaskSecret output rather than a real key
+func IsMaskedSecret(s string) bool {
+	return strings.HasPrefix(s, secretMask)
+}
+
+// Masked returns a copy safe to show in admin UI
+func (s *AppSettings) Masked() *AppSettings {
+	m := *s
+	for _, f := range m.secrets() {
+		*f = MaskSecret(*f)
+	}
+	return &m
+}
+
+// KeepMaskedSecrets restores secrets sent back masked (unchanged) from current
+func (s *AppSettings) KeepMaskedSecrets(current *AppSettings) {
+	next, cur := s.secrets(), current.secrets()
+	for i := range next {
+		if IsMaskedSecret(*next[i]) {
+			*next[i] = *cur[i]
+		}
+	}
+}
+
+func (s *AppSettings) secrets() []*string {
+	return []*string{
+		&s.GoogleMapsAPIKey, &s.YandexMapsAPIKey,
+		&s.GoogleMapsAndroidKey, &s.GoogleMapsIOSKey,
+		&s.YandexMapsAndroidKey, &s.YandexMapsIOSKey,
+	}
 }
@@ -3,6 +3,8 @@ package domain
 
 import (
 	"errors"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -23,6 +25,29 @@ const (
 	DocTypeVehiclePhoto = "vehicle_photo" // Vehicle photo
 )
 
+// Vehicle classes, lowest to highest (a driver also serves lower classes)
+const (
+	VehicleClassEconomy  = "economy"
+	VehicleClassComfort  = "comfort"
+	VehicleClassBusiness = "business"
+)
+
+// VehicleClassCargo — cargo van: takes cargo and courier rides only
+const VehicleClassCargo = "cargo"
+
+// Vehicle features passengers can require
+const (
+	VehicleFeatureMinivan     = "minivan"
+	VehicleFeatureChildSeat   = "child_seat"
+	VehicleFeaturePetFriendly = "pet_friendly"
+	VehicleFeatureWheelchair  = "wheelchair"
+)
+
+var (
+	ErrInvalidVehicleClass   = errors.New("invalid vehicle class")
+	ErrInvalidVehicleFeature = errors.New("invalid vehicle feature")
+)
+
 var (
 	ErrDocumentNotFound    = errors.New("document not found")
 	ErrInvalidDocumentType = errors.New("invalid document type")
@@ -43,6 +68,7 @@ type DriverDocument struct {
 	StorageURL  string // Public or presigned URL
 	Status      string // pending, approved, rejected
 	RejectReason string
+	ExpiresAt   *time.Time // set by admin on approval; nil = does not expire
 	UploadedAt  time.Time
 	ReviewedAt  *time.Time
 	ReviewedBy  *string // Admin user ID
@@ -56,7 +82,10 @@ type DriverVerification struct {
 	LicenseNumber string
 	VehicleModel  string
 	VehiclePlate  string
+	VehicleColor  string
 	VehicleYear   int
+	VehicleClass  string   // economy, comfort, business, cargo
+	Features      []string // minivan, child_seat, pet_friendly, wheelchair
 	Documents     []*DriverDocument
 	SubmittedAt   time.Time
 	ReviewedAt    *time.Time
@@ -66,6 +95,100 @@ type DriverVerification struct {
 	UpdatedAt     time.Time
 }
 
+// DriverAttributes — vehicle class/features of an approved driver (synced to geolocation
+// for nearest-driver filtering; read by the ride service for feed and bids).
+// Documents — approved document types (ride category eligibility); filled on read only.
+type DriverAttributes struct {
+	DriverID     string   `json:"driver_id"`
+	VehicleClass string   `json:"vehicle_class"`
+	Features     []string `json:"features"`
+	Documents    []string `json:"documents,omitempty"`
+}
+
+// MaxDriverCardsBatch — driver ids accepted per driver cards request
+const MaxDriverCardsBatch = 100
+
+// DriverCard — public card of an approved driver shown to passengers on bids
+type DriverCard struct {
+	DriverID     string `json:"driver_id"`
+	DisplayName  string `json:"display_name"`
+	AvatarURL    string `json:"avatar_url,omitempty"`
+	VehicleModel string `json:"vehicle_model"`
+	VehiclePlate string `json:"vehicle_plate"`
+	VehicleColor string `json:"vehicle_color,omitempty"`
+	VehicleClass string `json:"vehicle_class"`
+}
+
+// DriverEligibility — verification snapshot published to the ride service, which gates
+// bidding on it. Status is empty when the driver never applied; Documents maps approved
+// document types to their expiry (nil = does not expire).
+type DriverEligibility struct {
+	DriverID  string                `json:"driver_id"`
+	Status    string                `json:"status"`
+	Documents map[string]*time.Time `json:"documents"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+// NewDriverEligibility builds the snapshot from the driver's verification (nil if none)
+// and documents; of several approved documents of a type the latest expiry wins.
+func NewDriverEligibility(driverID string, v *DriverVerification, docs []*DriverDocument, at time.Time) DriverEligibility {
+	e := DriverEligibility{DriverID: driverID, Documents: map[string]*time.Time{}, UpdatedAt: at}
+	if v != nil {
+		e.Status = v.Status
+	}
+	for _, d := range docs {
+		if d.Status != VerificationStatusApproved {
+			continue
+		}
+		prev, seen := e.Documents[d.DocType]
+		switch {
+		case !seen, d.ExpiresAt == nil:
+			e.Documents[d.DocType] = d.ExpiresAt
+		case prev != nil && d.ExpiresAt.After(*prev):
+			e.Documents[d.DocType] = d.ExpiresAt
+		}
+	}
+	return e
+}
+
+// Attributes returns the vehicle attributes of the verification
+func (v *DriverVerification) Attributes() DriverAttributes {
+	features := v.Features
+	if features == nil {
+		features = []string{}
+	}
+	return DriverAttributes{DriverID: v.UserID, VehicleClass: v.VehicleClass, Features: features}
+}
+
+// NormalizeVehicle validates class (empty = economy) and features (lowercased, deduped, sorted)
+func NormalizeVehicle(class string, features []string) (string, []string, error) {
+	class = strings.ToLower(strings.TrimSpace(class))
+	switch class {
+	case "":
+		class = VehicleClassEconomy
+	case VehicleClassEconomy, VehicleClassComfort, VehicleClassBusiness, VehicleClassCargo:
+	default:
+		return "", nil, ErrInvalidVehicleClass
+	}
+	out := []string{}
+	seen := map[string]bool{}
+	for _, f := range features {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" || seen[f] {
+			continue
+		}
+		switch f {
+		case VehicleFeatureMinivan, VehicleFeatureChildSeat, VehicleFeaturePetFriendly, VehicleFeatureWheelchair:
+		default:
+			return "", nil, ErrInvalidVehicleFeature
+		}
+		seen[f] = true
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	return class, out, nil
+}
+
 // IsValidDocType checks if document type is valid
 func IsValidDocType(docType string) bool {
 	switch docType {
@@ -0,0 +1,104 @@
+// Package geocoding — geocoding providers (Yandex, Google, Nominatim) behind one interface;
+// they also serve address suggest and driving routes for the maps proxy
+package geocoding
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ridehail/user/internal/domain"
+)
+
+// Common provider errors
+var (
+	ErrProviderUnavailable = errors.New("geocoding provider unavailable")
+	ErrMissingAPIKey       = errors.New("geocoding provider requires an API key")
+	ErrQuotaExceeded       = errors.New("geocoding provider quota exceeded")
+)
+
+// Provider — geocoding provider interface
+type Provider interface {
+	// Name returns the map provider this geocoder belongs to
+	Name() domain.MapProvider
+
+	// Geocode resolves free-text address to candidates (best match first)
+	Geocode(ctx context.Context, query, lang string, limit int) ([]domain.Address, error)
+
+	// Reverse resolves coordinates to the nearest address
+	Reverse(ctx context.Context, lat, lng float64, lang string) (*domain.Address, error)
+
+	// Suggest returns autocomplete items for partial input; near biases results (may be nil)
+	Suggest(ctx context.Context, query, lang string, near *domain.LatLng, limit int) ([]domain.Suggestion, error)
+
+	// Route returns the driving route between two points
+	Route(ctx context.Context, from, to domain.LatLng) (*domain.Route, error)
+}
+
+// Config — shared provider settings
+type Config struct {
+	NominatimURL string // self-hosted Nominatim recommended in production (usage policy: 1 rps)
+	OSRMURL      string // OSRM routing for the Nominatim provider (public demo server is not for production)
+	UserAgent    string // required by Nominatim usage policy
+	Timeout      time.Duration
+}
+
+// New creates a provider for the given map provider and API key
+func New(provider domain.MapProvider, apiKey string, cfg Config) (Provider, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+	if cfg.Timeout <= 0 {
+		client.Timeout = 5 * time.Second
+	}
+	switch provider {
+	case domain.MapProviderYandex:
+		if apiKey == "" {
+			return nil, ErrMissingAPIKey
+		}
+		return NewYandexProvider(apiKey, client), nil
+	case domain.MapProviderGoogle:
+		if apiKey == "" {
+			return nil, ErrMissingAPIKey
+		}
+		return NewGoogleProvider(apiKey, client), nil
+	case domain.MapProviderOSM:
+		return NewNominatimProvider(cfg.NominatimURL, cfg.OSRMURL, cfg.UserAgent, client), nil
+	case domain.MapProviderStub:
+		return DefaultStubProvider(), nil
+	}
+	return nil, ErrProviderUnavailable
+}
+
+// EncodePolyline encodes points in Google polyline format (precision 5)
+func EncodePolyline(points []domain.LatLng) string {
+	var b strings.Builder
+	var prevLat, prevLng int64
+	for _, p := range points {
+		lat := roundE5(p.Lat)
+		lng := roundE5(p.Lng)
+		encodeSigned(&b, lat-prevLat)
+		encodeSigned(&b, lng-prevLng)
+		prevLat, prevLng = lat, lng
+	}
+	return b.String()
+}
+
+func roundE5(v float64) int64 {
+	if v < 0 {
+		return int64(v*1e5 - 0.5)
+	}
+	return int64(v*1e5 + 0.5)
+}
+
+func encodeSigned(b *strings.Builder, v int64) {
+	u := v << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		b.WriteByte(byte((0x20 | (u & 0x1f)) + 63))
+		u >>= 5
+	}
+	b.WriteByte(byte(u + 63))
+}
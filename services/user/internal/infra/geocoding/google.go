@@ -0,0 +1,244 @@
+// Package geocoding — Google Geocoding API (plus Places Autocomplete and Directions)
+// Docs: https://developers.google.com/maps/documentation/geocoding
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ridehail/user/internal/domain"
+)
+
+const (
+	googleGeocodeURL      = "https://maps.googleapis.com/maps/api/geocode/json"
+	googleAutocompleteURL = "https://maps.googleapis.com/maps/api/place/autocomplete/json"
+	googleDirectionsURL   = "https://maps.googleapis.com/maps/api/directions/json"
+)
+
+// GoogleProvider — Google Geocoding API
+type GoogleProvider struct {
+	apiKey string
+	client *http.Client
+}
+
+// NewGoogleProvider creates Google geocoder
+func NewGoogleProvider(apiKey string, client *http.Client) *GoogleProvider {
+	return &GoogleProvider{apiKey: apiKey, client: client}
+}
+
+// Name returns provider name
+func (p *GoogleProvider) Name() domain.MapProvider {
+	return domain.MapProviderGoogle
+}
+
+// googleStatus — status envelope shared by Google web services
+type googleStatus struct {
+	Status       string `json:"status"` // OK, ZERO_RESULTS, OVER_QUERY_LIMIT, REQUEST_DENIED, ...
+	ErrorMessage string `json:"error_message"`
+}
+
+type googleResponse struct {
+	googleStatus
+	Results []struct {
+		FormattedAddress  string `json:"formatted_address"`
+		AddressComponents []struct {
+			LongName string   `json:"long_name"`
+			Types    []string `json:"types"`
+		} `json:"address_components"`
+		Geometry struct {
+			Location struct {
+				Lat float64 `json:"lat"`
+				Lng float64 `json:"lng"`
+			} `json:"location"`
+		} `json:"geometry"`
+	} `json:"results"`
+}
+
+// Geocode resolves address text
+func (p *GoogleProvider) Geocode(ctx context.Context, query, lang string, limit int) ([]domain.Address, error) {
+	params := url.Values{}
+	params.Set("address", query)
+	out, err := p.request(ctx, params, lang)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// Reverse resolves coordinates
+func (p *GoogleProvider) Reverse(ctx context.Context, lat, lng float64, lang string) (*domain.Address, error) {
+	params := url.Values{}
+	params.Set("latlng", strconv.FormatFloat(lat, 'f', 6, 64)+","+strconv.FormatFloat(lng, 'f', 6, 64))
+	out, err := p.request(ctx, params, lang)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, domain.ErrAddressNotFound
+	}
+	return &out[0], nil
+}
+
+func (p *GoogleProvider) request(ctx context.Context, params url.Values, lang string) ([]domain.Address, error) {
+	var gr googleResponse
+	if ok, err := p.get(ctx, googleGeocodeURL, params, lang, &gr, &gr.googleStatus); err != nil || !ok {
+		return nil, err
+	}
+
+	out := make([]domain.Address, 0, len(gr.Results))
+	for _, r := range gr.Results {
+		addr := domain.Address{
+			Formatted: r.FormattedAddress,
+			Lat:       r.Geometry.Location.Lat,
+			Lng:       r.Geometry.Location.Lng,
+			Provider:  domain.MapProviderGoogle,
+		}
+		for _, c := range r.AddressComponents {
+			switch {
+			case hasType(c.Types, "country"):
+				addr.Country = c.LongName
+			case hasType(c.Types, "locality"):
+				addr.City = c.LongName
+			case hasType(c.Types, "sublocality_level_1"), hasType(c.Types, "sublocality"):
+				if addr.District == "" {
+					addr.District = c.LongName
+				}
+			case hasType(c.Types, "route"):
+				addr.Street = c.LongName
+			case hasType(c.Types, "street_number"):
+				addr.House = c.LongName
+			}
+		}
+		out = append(out, addr)
+	}
+	return out, nil
+}
+
+type googleAutocompleteResponse struct {
+	googleStatus
+	Predictions []struct {
+		Description          string `json:"description"`
+		PlaceID              string `json:"place_id"`
+		StructuredFormatting struct {
+			MainText      string `json:"main_text"`
+			SecondaryText string `json:"secondary_text"`
+		} `json:"structured_formatting"`
+	} `json:"predictions"`
+}
+
+// Suggest — Places Autocomplete (no coordinates; resolve the pick with Geocode)
+func (p *GoogleProvider) Suggest(ctx context.Context, query, lang string, near *domain.LatLng, limit int) ([]domain.Suggestion, error) {
+	params := url.Values{}
+	params.Set("input", query)
+	if near != nil {
+		params.Set("location", fmt.Sprintf("%f,%f", near.Lat, near.Lng))
+		params.Set("radius", "50000")
+	}
+	var ar googleAutocompleteResponse
+	if ok, err := p.get(ctx, googleAutocompleteURL, params, lang, &ar, &ar.googleStatus); err != nil || !ok {
+		return nil, err
+	}
+	out := make([]domain.Suggestion, 0, len(ar.Predictions))
+	for _, pr := range ar.Predictions {
+		out = append(out, domain.Suggestion{
+			Title:     pr.StructuredFormatting.MainText,
+			Subtitle:  pr.StructuredFormatting.SecondaryText,
+			Formatted: pr.Description,
+			PlaceID:   pr.PlaceID,
+			Provider:  domain.MapProviderGoogle,
+		})
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+type googleDirectionsResponse struct {
+	googleStatus
+	Routes []struct {
+		OverviewPolyline struct {
+			Points string `json:"points"`
+		} `json:"overview_polyline"`
+		Legs []struct {
+			Distance struct {
+				Value int `json:"value"` // meters
+			} `json:"distance"`
+			Duration struct {
+				Value int `json:"value"` // seconds
+			} `json:"duration"`
+		} `json:"legs"`
+	} `json:"routes"`
+}
+
+// Route — Directions API, driving mode
+func (p *GoogleProvider) Route(ctx context.Context, from, to domain.LatLng) (*domain.Route, error) {
+	params := url.Values{}
+	params.Set("origin", fmt.Sprintf("%f,%f", from.Lat, from.Lng))
+	params.Set("destination", fmt.Sprintf("%f,%f", to.Lat, to.Lng))
+	params.Set("mode", "driving")
+	var dr googleDirectionsResponse
+	ok, err := p.get(ctx, googleDirectionsURL, params, "", &dr, &dr.googleStatus)
+	if err != nil {
+		return nil, err
+	}
+	if !ok || len(dr.Routes) == 0 {
+		return nil, domain.ErrRouteNotFound
+	}
+	r := dr.Routes[0]
+	route := &domain.Route{Polyline: r.OverviewPolyline.Points, Provider: domain.MapProviderGoogle}
+	for _, leg := range r.Legs {
+		route.DistanceM += leg.Distance.Value
+		route.DurationS += leg.Duration.Value
+	}
+	return route, nil
+}
+
+// get calls a Google web service; returns false (no error) for ZERO_RESULTS/NOT_FOUND
+func (p *GoogleProvider) get(ctx context.Context, endpoint string, params url.Values, lang string, dst interface{}, status *googleStatus) (bool, error) {
+	params.Set("key", p.apiKey)
+	if lang != "" {
+		params.Set("language", lang)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return false, fmt.Errorf("decode google response: %w", err)
+	}
+
+	switch status.Status {
+	case "OK":
+		return true, nil
+	case "ZERO_RESULTS", "NOT_FOUND":
+		return false, nil
+	case "OVER_QUERY_LIMIT", "OVER_DAILY_LIMIT":
+		return false, ErrQuotaExceeded
+	case "REQUEST_DENIED":
+		return false, fmt.Errorf("%w: %s", ErrMissingAPIKey, status.ErrorMessage)
+	}
+	return false, fmt.Errorf("%w: %s %s", ErrProviderUnavailable, status.Status, status.ErrorMessage)
+}
+
+func hasType(types []string, t string) bool {
+	for _, v := range types {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+// Package geocoding — Nominatim (OpenStreetMap) geocoder, no API key; routes via OSRM
+// Docs: https://nominatim.org/release-docs/latest/api/Overview/, https://project-osrm.org/docs/v5.24.0/api/
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ridehail/user/internal/domain"
+)
+
+const (
+	defaultNominatimURL = "https://nominatim.openstreetmap.org"
+	defaultOSRMURL      = "https://router.project-osrm.org"
+)
+
+// NominatimProvider — OSM Nominatim geocoder (routes via OSRM)
+type NominatimProvider struct {
+	baseURL   string
+	osrmURL   string
+	userAgent string
+	client    *http.Client
+}
+
+// NewNominatimProvider creates Nominatim geocoder
+func NewNominatimProvider(nominatimURL, osrmURL, userAgent string, client *http.Client) *NominatimProvider {
+	if nominatimURL == "" {
+		nominatimURL = defaultNominatimURL
+	}
+	if osrmURL == "" {
+		osrmURL = defaultOSRMURL
+	}
+	if userAgent == "" {
+		userAgent = "ridehail-user-service/0.1"
+	}
+	return &NominatimProvider{
+		baseURL:   strings.TrimRight(nominatimURL, "/"),
+		osrmURL:   strings.TrimRight(osrmURL, "/"),
+		userAgent: userAgent,
+		client:    client,
+	}
+}
+
+// Name returns provider name
+func (p *NominatimProvider) Name() domain.MapProvider {
+	return domain.MapProviderOSM
+}
+
+type nominatimPlace struct {
+	DisplayName string `json:"display_name"`
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	Address     struct {
+		Country      string `json:"country"`
+		City         string `json:"city"`
+		Town         string `json:"town"`
+		Village      string `json:"village"`
+		CityDistrict string `json:"city_district"`
+		Suburb       string `json:"suburb"`
+		Road         string `json:"road"`
+		HouseNumber  string `json:"house_number"`
+	} `json:"address"`
+	Error string `json:"error"`
+}
+
+// Geocode resolves address text (/search)
+func (p *NominatimProvider) Geocode(ctx context.Context, query, lang string, limit int) ([]domain.Address, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", strconv.Itoa(limit))
+	var places []nominatimPlace
+	if err := p.get(ctx, "/search", params, lang, &places); err != nil {
+		return nil, err
+	}
+	out := make([]domain.Address, 0, len(places))
+	for _, pl := range places {
+		out = append(out, pl.toAddress())
+	}
+	return out, nil
+}
+
+// Reverse resolves coordinates (/reverse)
+func (p *NominatimProvider) Reverse(ctx context.Context, lat, lng float64, lang string) (*domain.Address, error) {
+	params := url.Values{}
+	params.Set("lat", strconv.FormatFloat(lat, 'f', 6, 64))
+	params.Set("lon", strconv.FormatFloat(lng, 'f', 6, 64))
+	var place nominatimPlace
+	if err := p.get(ctx, "/reverse", params, lang, &place); err != nil {
+		return nil, err
+	}
+	if place.Error != "" || place.DisplayName == "" {
+		return nil, domain.ErrAddressNotFound
+	}
+	addr := place.toAddress()
+	return &addr, nil
+}
+
+// Suggest — Nominatim has no autocomplete endpoint; /search results carry coordinates
+func (p *NominatimProvider) Suggest(ctx context.Context, query, lang string, near *domain.LatLng, limit int) ([]domain.Suggestion, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("limit", strconv.Itoa(limit))
+	if near != nil {
+		// ~20 km box around the user, preferred but not bounded
+		params.Set("viewbox", fmt.Sprintf("%f,%f,%f,%f", near.Lng-0.2, near.Lat+0.2, near.Lng+0.2, near.Lat-0.2))
+	}
+	var places []nominatimPlace
+	if err := p.get(ctx, "/search", params, lang, &places); err != nil {
+		return nil, err
+	}
+	out := make([]domain.Suggestion, 0, len(places))
+	for _, pl := range places {
+		addr := pl.toAddress()
+		title := strings.TrimSpace(strings.Join([]string{addr.Street, addr.House}, " "))
+		if title == "" {
+			title = addr.Formatted
+		}
+		out = append(out, domain.Suggestion{
+			Title:     title,
+			Subtitle:  addr.City,
+			Formatted: addr.Formatted,
+			Lat:       addr.Lat,
+			Lng:       addr.Lng,
+			Provider:  domain.MapProviderOSM,
+		})
+	}
+	return out, nil
+}
+
+type osrmResponse struct {
+	Code   string `json:"code"`
+	Routes []struct {
+		Distance float64 `json:"distance"` // meters
+		Duration float64 `json:"duration"` // seconds
+		Geometry string  `json:"geometry"` // encoded polyline (geometries=polyline)
+	} `json:"routes"`
+}
+
+// Route — OSRM /route/v1/driving
+func (p *NominatimProvider) Route(ctx context.Context, from, to domain.LatLng) (*domain.Route, error) {
+	path := fmt.Sprintf("/route/v1/driving/%f,%f;%f,%f?overview=full&geometries=polyline", from.Lng, from.Lat, to.Lng, to.Lat)
+	var or osrmResponse
+	if err := p.do(ctx, p.osrmURL+path, &or); err != nil {
+		return nil, err
+	}
+	if or.Code != "Ok" || len(or.Routes) == 0 {
+		return nil, domain.ErrRouteNotFound
+	}
+	r := or.Routes[0]
+	return &domain.Route{
+		DistanceM: int(r.Distance),
+		DurationS: int(r.Duration),
+		Polyline:  r.Geometry,
+		Provider:  domain.MapProviderOSM,
+	}, nil
+}
+
+func (p *NominatimProvider) get(ctx context.Context, path string, params url.Values, lang string, dst interface{}) error {
+	params.Set("format", "jsonv2")
+	params.Set("addressdetails", "1")
+	if lang != "" {
+		params.Set("accept-language", lang)
+	}
+	return p.do(ctx, p.baseURL+path+"?"+params.Encode(), dst)
+}
+
+func (p *NominatimProvider) do(ctx context.Context, rawURL string, dst interface{}) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("User-Agent", p.userAgent)
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return ErrQuotaExceeded
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%w: status %d", ErrProviderUnavailable, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("decode nominatim response: %w", err)
+	}
+	return nil
+}
+
+func (pl nominatimPlace) toAddress() domain.Address {
+	addr := domain.Address{
+		Formatted: pl.DisplayName,
+		Country:   pl.Address.Country,
+		City:      firstNonEmpty(pl.Address.City, pl.Address.Town, pl.Address.Village),
+		District:  firstNonEmpty(pl.Address.CityDistrict, pl.Address.Suburb),
+		Street:    pl.Address.Road,
+		House:     pl.Address.HouseNumber,
+		Provider:  domain.MapProviderOSM,
+	}
+	addr.Lat, _ = strconv.ParseFloat(pl.Lat, 64)
+	addr.Lng, _ = strconv.ParseFloat(pl.Lon, 64)
+	return addr
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,133 @@
+// Package geocoding — fixture-based stub provider for tests and offline development
+package geocoding
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/ridehail/user/internal/domain"
+)
+
+//go:embed fixtures/default.json
+var defaultFixtures []byte
+
+const (
+	// stubReverseRadiusKM — reverse lookup matches the nearest fixture within this radius
+	stubReverseRadiusKM = 1.0
+	// stubDetourFactor, stubSpeedKMH — straight-line route scaled to a plausible road distance/time
+	stubDetourFactor = 1.3
+	stubSpeedKMH     = 30.0
+)
+
+// StubProvider — answers from a fixed list of addresses; no network
+type StubProvider struct {
+	fixtures []domain.Address
+}
+
+// NewStubProvider creates stub provider over the given fixtures
+func NewStubProvider(fixtures []domain.Address) *StubProvider {
+	out := make([]domain.Address, len(fixtures))
+	for i, f := range fixtures {
+		f.Provider = domain.MapProviderStub
+		out[i] = f
+	}
+	return &StubProvider{fixtures: out}
+}
+
+// LoadFixtures reads a JSON array of addresses
+func LoadFixtures(r io.Reader) ([]domain.Address, error) {
+	var fixtures []domain.Address
+	if err := json.NewDecoder(r).Decode(&fixtures); err != nil {
+		return nil, err
+	}
+	return fixtures, nil
+}
+
+// DefaultStubProvider — stub over embedded central Moscow fixtures
+func DefaultStubProvider() *StubProvider {
+	fixtures, err := LoadFixtures(bytes.NewReader(defaultFixtures))
+	if err != nil {
+		panic("geocoding: invalid embedded fixtures: " + err.Error())
+	}
+	return NewStubProvider(fixtures)
+}
+
+// Name returns provider name
+func (p *StubProvider) Name() domain.MapProvider {
+	return domain.MapProviderStub
+}
+
+// Geocode returns fixtures whose formatted address contains the query (case-insensitive)
+func (p *StubProvider) Geocode(_ context.Context, query, _ string, limit int) ([]domain.Address, error) {
+	q := strings.ToLower(strings.TrimSpace(query))
+	var out []domain.Address
+	for _, f := range p.fixtures {
+		if strings.Contains(strings.ToLower(f.Formatted), q) {
+			out = append(out, f)
+			if limit > 0 && len(out) == limit {
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+// Reverse returns the nearest fixture within stubReverseRadiusKM
+func (p *StubProvider) Reverse(_ context.Context, lat, lng float64, _ string) (*domain.Address, error) {
+	best, bestKM := -1, stubReverseRadiusKM
+	for i, f := range p.fixtures {
+		if d := distanceKM(lat, lng, f.Lat, f.Lng); d <= bestKM {
+			best, bestKM = i, d
+		}
+	}
+	if best < 0 {
+		return nil, domain.ErrAddressNotFound
+	}
+	addr := p.fixtures[best]
+	return &addr, nil
+}
+
+// Suggest returns fixtures matching the query, as suggestions with coordinates
+func (p *StubProvider) Suggest(ctx context.Context, query, lang string, _ *domain.LatLng, limit int) ([]domain.Suggestion, error) {
+	found, _ := p.Geocode(ctx, query, lang, limit)
+	out := make([]domain.Suggestion, 0, len(found))
+	for _, f := range found {
+		title := strings.TrimSpace(f.Street + " " + f.House)
+		out = append(out, domain.Suggestion{
+			Title:     title,
+			Subtitle:  f.City,
+			Formatted: f.Formatted,
+			Lat:       f.Lat,
+			Lng:       f.Lng,
+			Provider:  domain.MapProviderStub,
+		})
+	}
+	return out, nil
+}
+
+// Route returns a straight-line route with detour factor and constant speed
+func (p *StubProvider) Route(_ context.Context, from, to domain.LatLng) (*domain.Route, error) {
+	km := distanceKM(from.Lat, from.Lng, to.Lat, to.Lng) * stubDetourFactor
+	return &domain.Route{
+		DistanceM: int(km * 1000),
+		DurationS: int(km / stubSpeedKMH * 3600),
+		Polyline:  EncodePolyline([]domain.LatLng{from, to}),
+		Provider:  domain.MapProviderStub,
+	}, nil
+}
+
+// distanceKM — haversine distance
+func distanceKM(lat1, lng1, lat2, lng2 float64) float64 {
+	const earthRadiusKM = 6371.0
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKM * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,259 @@
+// Package geocoding — Yandex Geocoder HTTP API (plus Geosuggest and Router)
+// Docs: https://yandex.ru/dev/geocode/doc/ru/, https://yandex.ru/dev/geosuggest/doc/ru/, https://yandex.ru/dev/router/doc/ru/
+package geocoding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ridehail/user/internal/domain"
+)
+
+const (
+	yandexGeocoderURL = "https://geocode-maps.yandex.ru/1.x/"
+	yandexSuggestURL  = "https://suggest-maps.yandex.ru/v1/suggest"
+	yandexRouterURL   = "https://api.routing.yandex.net/v2/route"
+)
+
+// YandexProvider — Yandex Geocoder
+type YandexProvider struct {
+	apiKey string
+	client *http.Client
+}
+
+// NewYandexProvider creates Yandex geocoder
+func NewYandexProvider(apiKey string, client *http.Client) *YandexProvider {
+	return &YandexProvider{apiKey: apiKey, client: client}
+}
+
+// Name returns provider name
+func (p *YandexProvider) Name() domain.MapProvider {
+	return domain.MapProviderYandex
+}
+
+type yandexResponse struct {
+	Response struct {
+		GeoObjectCollection struct {
+			FeatureMember []struct {
+				GeoObject struct {
+					MetaDataProperty struct {
+						GeocoderMetaData struct {
+							Text    string `json:"text"`
+							Address struct {
+								Formatted  string `json:"formatted"`
+								Components []struct {
+									Kind string `json:"kind"`
+									Name string `json:"name"`
+								} `json:"Components"`
+							} `json:"Address"`
+						} `json:"GeocoderMetaData"`
+					} `json:"metaDataProperty"`
+					Point struct {
+						Pos string `json:"pos"` // "lng lat"
+					} `json:"Point"`
+				} `json:"GeoObject"`
+			} `json:"featureMember"`
+		} `json:"GeoObjectCollection"`
+	} `json:"response"`
+}
+
+// Geocode resolves address text
+func (p *YandexProvider) Geocode(ctx context.Context, query, lang string, limit int) ([]domain.Address, error) {
+	return p.request(ctx, query, lang, limit)
+}
+
+// Reverse resolves coordinates ("lng,lat" order for Yandex)
+func (p *YandexProvider) Reverse(ctx context.Context, lat, lng float64, lang string) (*domain.Address, error) {
+	geocode := strconv.FormatFloat(lng, 'f', 6, 64) + "," + strconv.FormatFloat(lat, 'f', 6, 64)
+	out, err := p.request(ctx, geocode, lang, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, domain.ErrAddressNotFound
+	}
+	return &out[0], nil
+}
+
+func (p *YandexProvider) request(ctx context.Context, geocode, lang string, limit int) ([]domain.Address, error) {
+	params := url.Values{}
+	params.Set("apikey", p.apiKey)
+	params.Set("geocode", geocode)
+	params.Set("format", "json")
+	params.Set("results", strconv.Itoa(limit))
+	params.Set("lang", yandexLang(lang))
+
+	var yr yandexResponse
+	if err := p.get(ctx, yandexGeocoderURL, params, &yr); err != nil {
+		return nil, err
+	}
+
+	members := yr.Response.GeoObjectCollection.FeatureMember
+	out := make([]domain.Address, 0, len(members))
+	for _, m := range members {
+		meta := m.GeoObject.MetaDataProperty.GeocoderMetaData
+		addr := domain.Address{
+			Formatted: meta.Address.Formatted,
+			Provider:  domain.MapProviderYandex,
+		}
+		if addr.Formatted == "" {
+			addr.Formatted = meta.Text
+		}
+		for _, c := range meta.Address.Components {
+			switch c.Kind {
+			case "country":
+				addr.Country = c.Name
+			case "locality":
+				addr.City = c.Name
+			case "district":
+				if addr.District == "" {
+					addr.District = c.Name
+				}
+			case "street":
+				addr.Street = c.Name
+			case "house":
+				addr.House = c.Name
+			}
+		}
+		if pos := strings.Fields(m.GeoObject.Point.Pos); len(pos) == 2 {
+			addr.Lng, _ = strconv.ParseFloat(pos[0], 64)
+			addr.Lat, _ = strconv.ParseFloat(pos[1], 64)
+		}
+		out = append(out, addr)
+	}
+	return out, nil
+}
+
+type yandexSuggestResponse struct {
+	Results []struct {
+		Title struct {
+			Text string `json:"text"`
+		} `json:"title"`
+		Subtitle struct {
+			Text string `json:"text"`
+		} `json:"subtitle"`
+		Address struct {
+			FormattedAddress string `json:"formatted_address"`
+		} `json:"address"`
+		URI string `json:"uri"`
+	} `json:"results"`
+}
+
+// Suggest — Geosuggest API (no coordinates; resolve the pick with Geocode)
+func (p *YandexProvider) Suggest(ctx context.Context, query, lang string, near *domain.LatLng, limit int) ([]domain.Suggestion, error) {
+	params := url.Values{}
+	params.Set("apikey", p.apiKey)
+	params.Set("text", query)
+	params.Set("lang", lang)
+	params.Set("results", strconv.Itoa(limit))
+	params.Set("print_address", "1")
+	params.Set("attrs", "uri")
+	if near != nil {
+		params.Set("ll", strconv.FormatFloat(near.Lng, 'f', 6, 64)+","+strconv.FormatFloat(near.Lat, 'f', 6, 64))
+	}
+	var sr yandexSuggestResponse
+	if err := p.get(ctx, yandexSuggestURL, params, &sr); err != nil {
+		return nil, err
+	}
+	out := make([]domain.Suggestion, 0, len(sr.Results))
+	for _, r := range sr.Results {
+		out = append(out, domain.Suggestion{
+			Title:     r.Title.Text,
+			Subtitle:  r.Subtitle.Text,
+			Formatted: r.Address.FormattedAddress,
+			PlaceID:   r.URI,
+			Provider:  domain.MapProviderYandex,
+		})
+	}
+	return out, nil
+}
+
+type yandexRouteResponse struct {
+	Route struct {
+		Legs []struct {
+			Status string `json:"status"`
+			Steps  []struct {
+				Length   float64 `json:"length"`   // meters
+				Duration float64 `json:"duration"` // seconds
+				Polyline struct {
+					Points [][2]float64 `json:"points"` // [lat, lng]
+				} `json:"polyline"`
+			} `json:"steps"`
+		} `json:"legs"`
+	} `json:"route"`
+}
+
+// Route — Router API v2 (waypoints are "lat,lng")
+func (p *YandexProvider) Route(ctx context.Context, from, to domain.LatLng) (*domain.Route, error) {
+	params := url.Values{}
+	params.Set("apikey", p.apiKey)
+	params.Set("mode", "driving")
+	params.Set("waypoints", fmt.Sprintf("%f,%f|%f,%f", from.Lat, from.Lng, to.Lat, to.Lng))
+	var rr yandexRouteResponse
+	if err := p.get(ctx, yandexRouterURL, params, &rr); err != nil {
+		return nil, err
+	}
+	route := &domain.Route{Provider: domain.MapProviderYandex}
+	var points []domain.LatLng
+	for _, leg := range rr.Route.Legs {
+		if leg.Status != "" && leg.Status != "OK" {
+			return nil, domain.ErrRouteNotFound
+		}
+		for _, step := range leg.Steps {
+			route.DistanceM += int(step.Length)
+			route.DurationS += int(step.Duration)
+			for _, pt := range step.Polyline.Points {
+				points = append(points, domain.LatLng{Lat: pt[0], Lng: pt[1]})
+			}
+		}
+	}
+	if len(points) == 0 {
+		return nil, domain.ErrRouteNotFound
+	}
+	route.Polyline = EncodePolyline(points)
+	return route, nil
+}
+
+func (p *YandexProvider) get(ctx context.Context, endpoint string, params url.Values, dst interface{}) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return ErrQuotaExceeded
+	case resp.StatusCode == http.StatusForbidden:
+		return ErrMissingAPIKey
+	case resp.StatusCode >= 400:
+		return fmt.Errorf("%w: status %d", ErrProviderUnavailable, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("decode yandex response: %w", err)
+	}
+	return nil
+}
+
+// yandexLang maps "ru" -> "ru_RU", "en" -> "en_US"
+func yandexLang(lang string) string {
+	switch lang {
+	case "en":
+		return "en_US"
+	case "uk":
+		return "uk_UA"
+	case "tr":
+		return "tr_TR"
+	default:
+		return "ru_RU"
+	}
+}
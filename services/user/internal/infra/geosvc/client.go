@@ -0,0 +1,72 @@
+// Package geosvc — HTTP client for the geolocation service (driver vehicle attributes)
+package geosvc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ridehail/user/internal/domain"
+)
+
+// TokenSource issues bearer tokens for service-to-service calls
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// Client — geolocation service API client
+type Client struct {
+	baseURL string
+	tokens  TokenSource
+	http    *http.Client
+}
+
+// New creates geolocation client
+func New(baseURL string, tokens TokenSource, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		tokens:  tokens,
+		http:    &http.Client{Timeout: timeout},
+	}
+}
+
+type attributesRequest struct {
+	VehicleClass string   `json:"vehicle_class"`
+	Features     []string `json:"features"`
+}
+
+// SetDriverAttributes — PUT /api/v1/drivers/:id/attributes
+func (c *Client) SetDriverAttributes(ctx context.Context, a domain.DriverAttributes) error {
+	body, err := json.Marshal(attributesRequest{VehicleClass: a.VehicleClass, Features: a.Features})
+	if err != nil {
+		return err
+	}
+	path := "/api/v1/drivers/" + url.PathEscape(a.DriverID) + "/attributes"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	token, err := c.tokens.Token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("geolocation %s: status %d", path, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Package kafka — event producer for driver events
+// Topics: driver.eligibility.changed (consumed by the ride service bid gate)
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/IBM/sarama"
+
+	"github.com/ridehail/user/internal/domain"
+)
+
+const TopicDriverEligibilityChanged = "driver.eligibility.changed"
+
+type Producer struct {
+	prod sarama.SyncProducer
+}
+
+func NewProducer(brokers []string) (*Producer, error) {
+	config := sarama.NewConfig()
+	config.Producer.RequiredAcks = sarama.WaitForLocal
+	config.Producer.Return.Successes = true
+	prod, err := sarama.NewSyncProducer(brokers, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Producer{prod: prod}, nil
+}
+
+func (p *Producer) Close() error {
+	return p.prod.Close()
+}
+
+// PublishDriverEligibility sends the snapshot keyed by driver id (per-driver ordering)
+func (p *Producer) PublishDriverEligibility(ctx context.Context, e domain.DriverEligibility) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	msg := &sarama.ProducerMessage{
+		Topic: TopicDriverEligibilityChanged,
+		Key:   sarama.StringEncoder(e.DriverID),
+		Value: sarama.ByteEncoder(body),
+	}
+	if _, _, err := p.prod.SendMessage(msg); err != nil {
+		slog.Warn("kafka send failed", "topic", TopicDriverEligibilityChanged, "error", err)
+		return err
+	}
+	return nil
+}
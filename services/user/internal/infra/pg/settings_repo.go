@@ -20,6 +20,8 @@ func NewSettingsRepo(pool *pgxpool.Pool) *SettingsRepo {
 func (r *SettingsRepo) GetSettings(ctx context.Context) (*domain.AppSettings, error) {
 	query := `
 		SELECT id, map_provider, google_maps_api_key, yandex_maps_api_key,
+		       google_maps_android_key, google_maps_ios_key,
+		       yandex_maps_android_key, yandex_maps_ios_key,
 		       default_language, default_currency, updated_at, updated_by
 		FROM app_settings
 		WHERE id = 'default'
@@ -27,12 +29,17 @@ func (r *SettingsRepo) GetSettings(ctx context.Context) (*domain.AppSettings, er
 
 	var s domain.AppSettings
 	var googleKey, yandexKey, updatedBy *string
+	var googleAndroid, googleIOS, yandexAndroid, yandexIOS *string
 
 	err := r.pool.QueryRow(ctx, query).Scan(
 		&s.ID,
 		&s.MapProvider,
 		&googleKey,
 		&yandexKey,
+		&googleAndroid,
+		&googleIOS,
+		&yandexAndroid,
+		&yandexIOS,
 		&s.DefaultLanguage,
 		&s.DefaultCurrency,
 		&s.UpdatedAt,
@@ -42,12 +49,12 @@ func (r *SettingsRepo) GetSettings(ctx context.Context) (*domain.AppSettings, er
 		return nil, err
 	}
 
-	if googleKey != nil {
-		s.GoogleMapsAPIKey = *googleKey
-	}
-	if yandexKey != nil {
-		s.YandexMapsAPIKey = *yandexKey
-	}
+	s.GoogleMapsAPIKey = fromNullString(googleKey)
+	s.YandexMapsAPIKey = fromNullString(yandexKey)
+	s.GoogleMapsAndroidKey = fromNullString(googleAndroid)
+	s.GoogleMapsIOSKey = fromNullString(googleIOS)
+	s.YandexMapsAndroidKey = fromNullString(yandexAndroid)
+	s.YandexMapsIOSKey = fromNullString(yandexIOS)
 	if updatedBy != nil {
 		s.UpdatedBy = *updatedBy
 	}
@@ -62,10 +69,14 @@ func (r *SettingsRepo) UpdateSettings(ctx context.Context, settings *domain.AppS
 		SET map_provider = $1,
 		    google_maps_api_key = $2,
 		    yandex_maps_api_key = $3,
-		    default_language = $4,
-		    default_currency = $5,
-		    updated_at = $6,
-		    updated_by = $7
+		    google_maps_android_key = $4,
+		    google_maps_ios_key = $5,
+		    yandex_maps_android_key = $6,
+		    yandex_maps_ios_key = $7,
+		    default_language = $8,
+		    default_currency = $9,
+		    updated_at = $10,
+		    updated_by = $11
 		WHERE id = 'default'
 	`
 
@@ -76,6 +87,10 @@ func (r *SettingsRepo) UpdateSettings(ctx context.Context, settings *domain.AppS
 		settings.MapProvider,
 		toNullString(settings.GoogleMapsAPIKey),
 		toNullString(settings.YandexMapsAPIKey),
+		toNullString(settings.GoogleMapsAndroidKey),
+		toNullString(settings.GoogleMapsIOSKey),
+		toNullString(settings.YandexMapsAndroidKey),
+		toNullString(settings.YandexMapsIOSKey),
 		settings.DefaultLanguage,
 		settings.DefaultCurrency,
 		settings.UpdatedAt,
@@ -84,44 +99,16 @@ func (r *SettingsRepo) UpdateSettings(ctx context.Context, settings *domain.AppS
 	return err
 }
 
-// GetMapSettings returns map-specific settings for mobile apps (without exposing all API keys)
-func (r *SettingsRepo) GetMapSettings(ctx context.Context) (*domain.MapSettings, error) {
-	query := `
-		SELECT map_provider, google_maps_api_key, yandex_maps_api_key
-		FROM app_settings
-		WHERE id = 'default'
-	`
-
-	var provider domain.MapProvider
-	var googleKey, yandexKey *string
-
-	err := r.pool.QueryRow(ctx, query).Scan(&provider, &googleKey, &yandexKey)
-	if err != nil {
-		return nil, err
-	}
-
-	ms := &domain.MapSettings{
-		Provider: provider,
-	}
-
-	// Return only the active provider's API key
-	switch provider {
-	case domain.MapProviderGoogle:
-		if googleKey != nil {
-			ms.APIKey = *googleKey
-		}
-	case domain.MapProviderYandex:
-		if yandexKey != nil {
-			ms.APIKey = *yandexKey
-		}
-	}
-
-	return ms, nil
-}
-
 func toNullString(s string) *string {
 	if s == "" {
 		return nil
 	}
 	return &s
 }
+
+func fromNullString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -27,18 +27,18 @@ func NewVerificationRepo(pool *pgxpool.Pool) *VerificationRepo {
 // CreateVerification creates a new verification request.
 func (r *VerificationRepo) CreateVerification(ctx context.Context, v *domain.DriverVerification) error {
 	_, err := r.pool.Exec(ctx, `
-		INSERT INTO driver_verifications (user_id, status, license_number, vehicle_model, vehicle_plate, vehicle_year)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO driver_verifications (user_id, status, license_number, vehicle_model, vehicle_plate, vehicle_color, vehicle_year, vehicle_class, vehicle_features)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id, created_at
-	`, v.UserID, domain.VerificationStatusPending, v.LicenseNumber, v.VehicleModel, v.VehiclePlate, v.VehicleYear)
+	`, v.UserID, domain.VerificationStatusPending, v.LicenseNumber, v.VehicleModel, v.VehiclePlate, v.VehicleColor, v.VehicleYear, v.VehicleClass, v.Features)
 	return err
 }
 
 // GetVerification returns verification by user ID.
 func (r *VerificationRepo) GetVerification(ctx context.Context, userID string) (*domain.DriverVerification, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, user_id, status, license_number, vehicle_model, vehicle_plate, vehicle_year,
-		       reject_reason, submitted_at, reviewed_at, reviewed_by, created_at, updated_at
+		SELECT id, user_id, status, license_number, vehicle_model, vehicle_plate, vehicle_color, vehicle_year,
+		       vehicle_class, vehicle_features, reject_reason, submitted_at, reviewed_at, reviewed_by, created_at, updated_at
 		FROM driver_verifications
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -48,8 +48,8 @@ func (r *VerificationRepo) GetVerification(ctx context.Context, userID string) (
 	var v domain.DriverVerification
 	var vehicleYear *int
 	err := row.Scan(
-		&v.ID, &v.UserID, &v.Status, &v.LicenseNumber, &v.VehicleModel, &v.VehiclePlate, &vehicleYear,
-		&v.RejectReason, &v.SubmittedAt, &v.ReviewedAt, &v.ReviewedBy, &v.CreatedAt, &v.UpdatedAt,
+		&v.ID, &v.UserID, &v.Status, &v.LicenseNumber, &v.VehicleModel, &v.VehiclePlate, &v.VehicleColor, &vehicleYear,
+		&v.VehicleClass, &v.Features, &v.RejectReason, &v.SubmittedAt, &v.ReviewedAt, &v.ReviewedBy, &v.CreatedAt, &v.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -66,8 +66,8 @@ func (r *VerificationRepo) GetVerification(ctx context.Context, userID string) (
 // GetVerificationByID returns verification by ID.
 func (r *VerificationRepo) GetVerificationByID(ctx context.Context, id string) (*domain.DriverVerification, error) {
 	row := r.pool.QueryRow(ctx, `
-		SELECT id, user_id, status, license_number, vehicle_model, vehicle_plate, vehicle_year,
-		       reject_reason, submitted_at, reviewed_at, reviewed_by, created_at, updated_at
+		SELECT id, user_id, status, license_number, vehicle_model, vehicle_plate, vehicle_color, vehicle_year,
+		       vehicle_class, vehicle_features, reject_reason, submitted_at, reviewed_at, reviewed_by, created_at, updated_at
 		FROM driver_verifications
 		WHERE id = $1
 	`, id)
@@ -75,8 +75,8 @@ func (r *VerificationRepo) GetVerificationByID(ctx context.Context, id string) (
 	var v domain.DriverVerification
 	var vehicleYear *int
 	err := row.Scan(
-		&v.ID, &v.UserID, &v.Status, &v.LicenseNumber, &v.VehicleModel, &v.VehiclePlate, &vehicleYear,
-		&v.RejectReason, &v.SubmittedAt, &v.ReviewedAt, &v.ReviewedBy, &v.CreatedAt, &v.UpdatedAt,
+		&v.ID, &v.UserID, &v.Status, &v.LicenseNumber, &v.VehicleModel, &v.VehiclePlate, &v.VehicleColor, &vehicleYear,
+		&v.VehicleClass, &v.Features, &v.RejectReason, &v.SubmittedAt, &v.ReviewedAt, &v.ReviewedBy, &v.CreatedAt, &v.UpdatedAt,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, domain.ErrDocumentNotFound
@@ -101,11 +101,21 @@ func (r *VerificationRepo) UpdateVerificationStatus(ctx context.Context, id, sta
 	return err
 }
 
+// UpdateVehicleAttributes sets vehicle class and features (admin correction on approval).
+func (r *VerificationRepo) UpdateVehicleAttributes(ctx context.Context, id, class string, features []string) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE driver_verifications
+		SET vehicle_class = $1, vehicle_features = $2, updated_at = NOW()
+		WHERE id = $3
+	`, class, features, id)
+	return err
+}
+
 // ListPendingVerifications returns pending verifications (for admin).
 func (r *VerificationRepo) ListPendingVerifications(ctx context.Context, limit, offset int) ([]*domain.DriverVerification, error) {
 	rows, err := r.pool.Query(ctx, `
-		SELECT id, user_id, status, license_number, vehicle_model, vehicle_plate, vehicle_year,
-		       reject_reason, submitted_at, reviewed_at, reviewed_by, created_at, updated_at
+		SELECT id, user_id, status, license_number, vehicle_model, vehicle_plate, vehicle_color, vehicle_year,
+		       vehicle_class, vehicle_features, reject_reason, submitted_at, reviewed_at, reviewed_by, created_at, updated_at
 		FROM driver_verifications
 		WHERE status = $1
 		ORDER BY submitted_at ASC
@@ -121,8 +131,8 @@ func (r *VerificationRepo) ListPendingVerifications(ctx context.Context, limit,
 		var v domain.DriverVerification
 		var vehicleYear *int
 		if err := rows.Scan(
-			&v.ID, &v.UserID, &v.Status, &v.LicenseNumber, &v.VehicleModel, &v.VehiclePlate, &vehicleYear,
-			&v.RejectReason, &v.SubmittedAt, &v.ReviewedAt, &v.ReviewedBy, &v.CreatedAt, &v.UpdatedAt,
+			&v.ID, &v.UserID, &v.Status, &v.LicenseNumber, &v.VehicleModel, &v.VehiclePlate, &v.VehicleColor, &vehicleYear,
+			&v.VehicleClass, &v.Features, &v.RejectReason, &v.SubmittedAt, &v.ReviewedAt, &v.ReviewedBy, &v.CreatedAt, &v.UpdatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -134,6 +144,32 @@ func (r *VerificationRepo) ListPendingVerifications(ctx context.Context, limit,
 	return verifications, rows.Err()
 }
 
+// DriverCards returns cards of drivers with an approved verification (one query;
+// drivers without one are missing from the result).
+func (r *VerificationRepo) DriverCards(ctx context.Context, driverIDs []string) ([]*domain.DriverCard, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT v.user_id, COALESCE(p.display_name, ''), COALESCE(p.avatar_url, ''),
+		       COALESCE(v.vehicle_model, ''), COALESCE(v.vehicle_plate, ''), v.vehicle_color, v.vehicle_class
+		FROM driver_verifications v
+		LEFT JOIN profiles p ON p.user_id = v.user_id
+		WHERE v.user_id::text = ANY($1) AND v.status = $2
+	`, driverIDs, domain.VerificationStatusApproved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []*domain.DriverCard
+	for rows.Next() {
+		var c domain.DriverCard
+		if err := rows.Scan(&c.DriverID, &c.DisplayName, &c.AvatarURL, &c.VehicleModel, &c.VehiclePlate, &c.VehicleColor, &c.VehicleClass); err != nil {
+			return nil, err
+		}
+		cards = append(cards, &c)
+	}
+	return cards, rows.Err()
+}
+
 // --- Driver Documents ---
 
 // CreateDocument creates a new document record.
@@ -150,7 +186,7 @@ func (r *VerificationRepo) CreateDocument(ctx context.Context, d *domain.DriverD
 func (r *VerificationRepo) GetDocument(ctx context.Context, id string) (*domain.DriverDocument, error) {
 	row := r.pool.QueryRow(ctx, `
 		SELECT id, user_id, doc_type, file_name, file_size, content_type, storage_key, status,
-		       reject_reason, uploaded_at, reviewed_at, reviewed_by
+		       reject_reason, expires_at, uploaded_at, reviewed_at, reviewed_by
 		FROM driver_documents
 		WHERE id = $1
 	`, id)
@@ -158,7 +194,7 @@ func (r *VerificationRepo) GetDocument(ctx context.Context, id string) (*domain.
 	var d domain.DriverDocument
 	err := row.Scan(
 		&d.ID, &d.UserID, &d.DocType, &d.FileName, &d.FileSize, &d.ContentType, &d.StorageKey, &d.Status,
-		&d.RejectReason, &d.UploadedAt, &d.ReviewedAt, &d.ReviewedBy,
+		&d.RejectReason, &d.ExpiresAt, &d.UploadedAt, &d.ReviewedAt, &d.ReviewedBy,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, domain.ErrDocumentNotFound
@@ -173,7 +209,7 @@ func (r *VerificationRepo) GetDocument(ctx context.Context, id string) (*domain.
 func (r *VerificationRepo) ListDocumentsByUser(ctx context.Context, userID string) ([]*domain.DriverDocument, error) {
 	rows, err := r.pool.Query(ctx, `
 		SELECT id, user_id, doc_type, file_name, file_size, content_type, storage_key, status,
-		       reject_reason, uploaded_at, reviewed_at, reviewed_by
+		       reject_reason, expires_at, uploaded_at, reviewed_at, reviewed_by
 		FROM driver_documents
 		WHERE user_id = $1
 		ORDER BY uploaded_at DESC
@@ -188,7 +224,7 @@ func (r *VerificationRepo) ListDocumentsByUser(ctx context.Context, userID strin
 		var d domain.DriverDocument
 		if err := rows.Scan(
 			&d.ID, &d.UserID, &d.DocType, &d.FileName, &d.FileSize, &d.ContentType, &d.StorageKey, &d.Status,
-			&d.RejectReason, &d.UploadedAt, &d.ReviewedAt, &d.ReviewedBy,
+			&d.RejectReason, &d.ExpiresAt, &d.UploadedAt, &d.ReviewedAt, &d.ReviewedBy,
 		); err != nil {
 			return nil, err
 		}
@@ -208,6 +244,12 @@ func (r *VerificationRepo) UpdateDocumentStatus(ctx context.Context, id, status,
 	return err
 }
 
+// SetDocumentExpiry sets the document expiry (nil = does not expire).
+func (r *VerificationRepo) SetDocumentExpiry(ctx context.Context, id string, expiresAt *time.Time) error {
+	_, err := r.pool.Exec(ctx, `UPDATE driver_documents SET expires_at = $1 WHERE id = $2`, expiresAt, id)
+	return err
+}
+
 // DeleteDocument removes a document record.
 func (r *VerificationRepo) DeleteDocument(ctx context.Context, id string) error {
 	_, err := r.pool.Exec(ctx, `DELETE FROM driver_documents WHERE id = $1`, id)
@@ -218,7 +260,7 @@ func (r *VerificationRepo) DeleteDocument(ctx context.Context, id string) error
 func (r *VerificationRepo) GetDocumentByType(ctx context.Context, userID, docType string) (*domain.DriverDocument, error) {
 	row := r.pool.QueryRow(ctx, `
 		SELECT id, user_id, doc_type, file_name, file_size, content_type, storage_key, status,
-		       reject_reason, uploaded_at, reviewed_at, reviewed_by
+		       reject_reason, expires_at, uploaded_at, reviewed_at, reviewed_by
 		FROM driver_documents
 		WHERE user_id = $1 AND doc_type = $2
 		ORDER BY uploaded_at DESC
@@ -228,7 +270,7 @@ func (r *VerificationRepo) GetDocumentByType(ctx context.Context, userID, docTyp
 	var d domain.DriverDocument
 	err := row.Scan(
 		&d.ID, &d.UserID, &d.DocType, &d.FileName, &d.FileSize, &d.ContentType, &d.StorageKey, &d.Status,
-		&d.RejectReason, &d.UploadedAt, &d.ReviewedAt, &d.ReviewedBy,
+		&d.RejectReason, &d.ExpiresAt, &d.UploadedAt, &d.ReviewedAt, &d.ReviewedBy,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
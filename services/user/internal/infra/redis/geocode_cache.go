@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const geocodeKeyPrefix = "geocode:"
+
+// GeocodeCache — JSON cache for geocoder responses; nil client disables caching
+type GeocodeCache struct {
+	rdb *redis.Client
+	ttl time.Duration
+}
+
+// NewGeocodeCache creates geocode cache (rdb may be nil when Redis is not configured)
+func NewGeocodeCache(rdb *redis.Client, ttl time.Duration) *GeocodeCache {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &GeocodeCache{rdb: rdb, ttl: ttl}
+}
+
+// Get loads cached value into dst; returns false on miss or when caching is disabled
+func (c *GeocodeCache) Get(ctx context.Context, key string, dst interface{}) (bool, error) {
+	if c == nil || c.rdb == nil {
+		return false, nil
+	}
+	data, err := c.rdb.Get(ctx, geocodeKeyPrefix+key).Bytes()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Set stores value as JSON with the cache TTL
+func (c *GeocodeCache) Set(ctx context.Context, key string, value interface{}) error {
+	if c == nil || c.rdb == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.rdb.Set(ctx, geocodeKeyPrefix+key, data, c.ttl).Err()
+}
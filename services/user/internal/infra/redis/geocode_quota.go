@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GeocodeQuota — per-user fixed-window limits (per minute and per day) for the maps proxy;
+// nil client disables the quota
+type GeocodeQuota struct {
+	rdb       *redis.Client
+	perMinute int64
+	perDay    int64
+}
+
+// NewGeocodeQuota creates geocode quota (limit <= 0 disables that window)
+func NewGeocodeQuota(rdb *redis.Client, perMinute, perDay int) *GeocodeQuota {
+	return &GeocodeQuota{rdb: rdb, perMinute: int64(perMinute), perDay: int64(perDay)}
+}
+
+// Allow counts one request for the user and reports whether it is within both windows
+func (q *GeocodeQuota) Allow(ctx context.Context, userID string) (bool, error) {
+	if q == nil || q.rdb == nil {
+		return true, nil
+	}
+	now := time.Now().UTC()
+	minuteKey := fmt.Sprintf("geocode:quota:m:%s:%d", userID, now.Unix()/60)
+	dayKey := fmt.Sprintf("geocode:quota:d:%s:%s", userID, now.Format("20060102"))
+
+	pipe := q.rdb.TxPipeline()
+	minute := pipe.Incr(ctx, minuteKey)
+	pipe.Expire(ctx, minuteKey, 2*time.Minute)
+	day := pipe.Incr(ctx, dayKey)
+	pipe.Expire(ctx, dayKey, 25*time.Hour)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	if q.perMinute > 0 && minute.Val() > q.perMinute {
+		return false, nil
+	}
+	if q.perDay > 0 && day.Val() > q.perDay {
+		return false, nil
+	}
+	return true, nil
+}
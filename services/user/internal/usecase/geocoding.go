@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ridehail/user/internal/domain"
+)
+
+var (
+	ErrInvalidCoordinates   = errors.New("invalid coordinates")
+	ErrEmptyQuery           = errors.New("query is required")
+	ErrGeocoderFailed       = errors.New("geocoding provider failed")
+	ErrGeocodeQuotaExceeded = errors.New("maps request quota exceeded")
+)
+
+// CoordPrecision — decimals kept when rounding coordinates for provider calls
+// and cache keys (4 decimals ≈ 11 m, close enough for a street address)
+const CoordPrecision = 4
+
+// GeocodeCache — optional response cache (Redis)
+type GeocodeCache interface {
+	Get(ctx context.Context, key string, dst interface{}) (bool, error)
+	Set(ctx context.Context, key string, value interface{}) error
+}
+
+// GeocodeQuota — per-user request quota for the maps proxy
+type GeocodeQuota interface {
+	Allow(ctx context.Context, userID string) (bool, error)
+}
+
+// Geocoder — a geocoding provider (Yandex, Google, Nominatim, stub)
+type Geocoder interface {
+	Name() domain.MapProvider
+	Geocode(ctx context.Context, query, lang string, limit int) ([]domain.Address, error)
+	Reverse(ctx context.Context, lat, lng float64, lang string) (*domain.Address, error)
+	Suggest(ctx context.Context, query, lang string, near *domain.LatLng, limit int) ([]domain.Suggestion, error)
+	Route(ctx context.Context, from, to domain.LatLng) (*domain.Route, error)
+}
+
+// GeocoderFactory builds a geocoder for the configured map provider and key
+type GeocoderFactory func(provider domain.MapProvider, apiKey string) (Geocoder, error)
+
+// GeocodingConfig — geocoding module settings
+type GeocodingConfig struct {
+	// ForceProvider overrides AppSettings.MapProvider (e.g. "stub" in tests/offline dev)
+	ForceProvider domain.MapProvider
+	// SettingsTTL — how long the active provider is reused before re-reading settings
+	SettingsTTL time.Duration
+	// Limit — max candidates returned by Geocode/Suggest
+	Limit int
+}
+
+// GeocodingUseCase — geocoding via the provider selected in admin settings; also the
+// server-side maps proxy (suggest, route), so server API keys never leave the service
+type GeocodingUseCase struct {
+	settings SettingsRepository
+	factory  GeocoderFactory
+	cache    GeocodeCache
+	quota    GeocodeQuota
+	cfg      GeocodingConfig
+
+	mu         sync.Mutex
+	provider   Geocoder
+	providerAt time.Time
+}
+
+// NewGeocodingUseCase creates geocoding use case (cache and quota may be nil)
+func NewGeocodingUseCase(settings SettingsRepository, factory GeocoderFactory, cache GeocodeCache, quota GeocodeQuota, cfg GeocodingConfig) *GeocodingUseCase {
+	if cfg.SettingsTTL <= 0 {
+		cfg.SettingsTTL = time.Minute
+	}
+	if cfg.Limit <= 0 {
+		cfg.Limit = 5
+	}
+	return &GeocodingUseCase{settings: settings, factory: factory, cache: cache, quota: quota, cfg: cfg}
+}
+
+// Geocode resolves address text to candidates.
+// userID is charged against the quota; empty userID (service callers) is not limited.
+func (uc *GeocodingUseCase) Geocode(ctx context.Context, userID, query, lang string) ([]domain.Address, error) {
+	query = normalizeQuery(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	p, err := uc.begin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("fwd:%s:%s:%s", p.Name(), lang, strings.ToLower(query))
+	var cached []domain.Address
+	if ok, _ := uc.cacheGet(ctx, key, &cached); ok {
+		return cached, nil
+	}
+	out, err := p.Geocode(ctx, query, lang, uc.cfg.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGeocoderFailed, err)
+	}
+	if out == nil {
+		out = []domain.Address{}
+	}
+	uc.cacheSet(ctx, key, out)
+	return out, nil
+}
+
+// Reverse resolves coordinates to an address. Coordinates are rounded to
+// CoordPrecision first so nearby points share one provider call and cache entry.
+func (uc *GeocodingUseCase) Reverse(ctx context.Context, userID string, lat, lng float64, lang string) (*domain.Address, error) {
+	point := roundPoint(domain.LatLng{Lat: lat, Lng: lng})
+	if !point.Valid() {
+		return nil, ErrInvalidCoordinates
+	}
+	p, err := uc.begin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("rev:%s:%s:%s", p.Name(), lang, pointKey(point))
+	var cached domain.Address
+	if ok, _ := uc.cacheGet(ctx, key, &cached); ok {
+		return &cached, nil
+	}
+	addr, err := p.Reverse(ctx, point.Lat, point.Lng, lang)
+	if err != nil {
+		if errors.Is(err, domain.ErrAddressNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrGeocoderFailed, err)
+	}
+	uc.cacheSet(ctx, key, addr)
+	return addr, nil
+}
+
+// Suggest returns autocomplete items; near (optional) biases results to the user's area
+func (uc *GeocodingUseCase) Suggest(ctx context.Context, userID, query, lang string, near *domain.LatLng) ([]domain.Suggestion, error) {
+	query = normalizeQuery(query)
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+	if near != nil {
+		if !near.Valid() {
+			return nil, ErrInvalidCoordinates
+		}
+		// bias only needs to be coarse; ~1 km keeps the cache useful across nearby users
+		rounded := domain.LatLng{Lat: math.Round(near.Lat*100) / 100, Lng: math.Round(near.Lng*100) / 100}
+		near = &rounded
+	}
+	p, err := uc.begin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("sug:%s:%s:%s", p.Name(), lang, strings.ToLower(query))
+	if near != nil {
+		key += ":" + pointKey(*near)
+	}
+	var cached []domain.Suggestion
+	if ok, _ := uc.cacheGet(ctx, key, &cached); ok {
+		return cached, nil
+	}
+	out, err := p.Suggest(ctx, query, lang, near, uc.cfg.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGeocoderFailed, err)
+	}
+	if out == nil {
+		out = []domain.Suggestion{}
+	}
+	uc.cacheSet(ctx, key, out)
+	return out, nil
+}
+
+// Route returns the driving route between two points (rounded to CoordPrecision)
+func (uc *GeocodingUseCase) Route(ctx context.Context, userID string, from, to domain.LatLng) (*domain.Route, error) {
+	from, to = roundPoint(from), roundPoint(to)
+	if !from.Valid() || !to.Valid() {
+		return nil, ErrInvalidCoordinates
+	}
+	p, err := uc.begin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("route:%s:%s:%s", p.Name(), pointKey(from), pointKey(to))
+	var cached domain.Route
+	if ok, _ := uc.cacheGet(ctx, key, &cached); ok {
+		return &cached, nil
+	}
+	route, err := p.Route(ctx, from, to)
+	if err != nil {
+		if errors.Is(err, domain.ErrRouteNotFound) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrGeocoderFailed, err)
+	}
+	uc.cacheSet(ctx, key, route)
+	return route, nil
+}
+
+// RoundCoord rounds a coordinate to CoordPrecision decimals
+func RoundCoord(v float64) float64 {
+	scale := math.Pow10(CoordPrecision)
+	return math.Round(v*scale) / scale
+}
+
+func roundPoint(p domain.LatLng) domain.LatLng {
+	return domain.LatLng{Lat: RoundCoord(p.Lat), Lng: RoundCoord(p.Lng)}
+}
+
+func pointKey(p domain.LatLng) string {
+	return fmt.Sprintf("%.*f,%.*f", CoordPrecision, p.Lat, CoordPrecision, p.Lng)
+}
+
+func normalizeQuery(q string) string {
+	return strings.Join(strings.Fields(q), " ")
+}
+
+// begin charges the user's quota and returns the active provider
+func (uc *GeocodingUseCase) begin(ctx context.Context, userID string) (Geocoder, error) {
+	if userID != "" && uc.quota != nil {
+		ok, err := uc.quota.Allow(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrGeocodeQuotaExceeded
+		}
+	}
+	return uc.activeProvider(ctx)
+}
+
+// activeProvider returns the geocoder for current settings. Providers that need a
+// key fall back to OSM/Nominatim when the admin has not configured one.
+func (uc *GeocodingUseCase) activeProvider(ctx context.Context) (Geocoder, error) {
+	uc.mu.Lock()
+	defer uc.mu.Unlock()
+	if uc.provider != nil && time.Since(uc.providerAt) < uc.cfg.SettingsTTL {
+		return uc.provider, nil
+	}
+
+	provider, apiKey := uc.cfg.ForceProvider, ""
+	if provider == "" {
+		settings, err := uc.settings.GetSettings(ctx)
+		if err != nil {
+			return nil, err
+		}
+		provider = settings.MapProvider
+		switch provider {
+		case domain.MapProviderGoogle:
+			apiKey = settings.GoogleMapsAPIKey
+		case domain.MapProviderYandex:
+			apiKey = settings.YandexMapsAPIKey
+		}
+	}
+
+	if apiKey == "" && (provider == domain.MapProviderGoogle || provider == domain.MapProviderYandex) {
+		provider = domain.MapProviderOSM
+	}
+	p, err := uc.factory(provider, apiKey)
+	if err != nil {
+		return nil, err
+	}
+	uc.provider, uc.providerAt = p, time.Now()
+	return p, nil
+}
+
+func (uc *GeocodingUseCase) cacheGet(ctx context.Context, key string, dst interface{}) (bool, error) {
+	if uc.cache == nil {
+		return false, nil
+	}
+	return uc.cache.Get(ctx, key, dst)
+}
+
+func (uc *GeocodingUseCase) cacheSet(ctx context.Context, key string, value interface{}) {
+	if uc.cache == nil {
+		return
+	}
+	_ = uc.cache.Set(ctx, key, value)
+}
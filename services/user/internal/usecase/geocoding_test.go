@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ridehail/user/internal/domain"
+	"github.com/ridehail/user/internal/infra/geocoding"
+)
+
+type fakeSettingsRepo struct {
+	settings *domain.AppSettings
+}
+
+func (r *fakeSettingsRepo) GetSettings(ctx context.Context) (*domain.AppSettings, error) {
+	return r.settings, nil
+}
+
+func (r *fakeSettingsRepo) UpdateSettings(ctx context.Context, s *domain.AppSettings, userID string) error {
+	r.settings = s
+	return nil
+}
+
+type fakeQuota struct {
+	left  int
+	users []string
+}
+
+func (q *fakeQuota) Allow(ctx context.Context, userID string) (bool, error) {
+	q.users = append(q.users, userID)
+	q.left--
+	return q.left >= 0, nil
+}
+
+type memoryCache struct {
+	data map[string][]byte
+}
+
+func (c *memoryCache) Get(ctx context.Context, key string, dst interface{}) (bool, error) {
+	b, ok := c.data[key]
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(b, dst)
+}
+
+func (c *memoryCache) Set(ctx context.Context, key string, value interface{}) error {
+	b, err := json.Marshal(value)
+	c.data[key] = b
+	return err
+}
+
+// countingGeocoder wraps the stub and counts reverse calls
+type countingGeocoder struct {
+	*geocoding.StubProvider
+	reverseCalls int
+	lastLat      float64
+}
+
+func (g *countingGeocoder) Reverse(ctx context.Context, lat, lng float64, lang string) (*domain.Address, error) {
+	g.reverseCalls++
+	g.lastLat = lat
+	return g.StubProvider.Reverse(ctx, lat, lng, lang)
+}
+
+func newStubGeocoding(provider domain.MapProvider, apiKey string, quota GeocodeQuota) (*GeocodingUseCase, *countingGeocoder, *[]domain.MapProvider) {
+	stub := &countingGeocoder{StubProvider: geocoding.DefaultStubProvider()}
+	var requested []domain.MapProvider
+	factory := func(p domain.MapProvider, key string) (Geocoder, error) {
+		requested = append(requested, p)
+		return stub, nil
+	}
+	settings := &fakeSettingsRepo{settings: &domain.AppSettings{MapProvider: provider, YandexMapsAPIKey: apiKey}}
+	uc := NewGeocodingUseCase(settings, factory, &memoryCache{data: map[string][]byte{}}, quota, GeocodingConfig{})
+	return uc, stub, &requested
+}
+
+func TestGeocodingUseCase_Geocode(t *testing.T) {
+	uc, _, _ := newStubGeocoding(domain.MapProviderYandex, "key", nil)
+	results, err := uc.Geocode(context.Background(), "u1", "  тверская   улица ", "ru")
+	if err != nil {
+		t.Fatalf("Geocode: %v", err)
+	}
+	if len(results) != 1 || results[0].House != "13" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if _, err := uc.Geocode(context.Background(), "u1", " ", "ru"); err != ErrEmptyQuery {
+		t.Errorf("want ErrEmptyQuery, got %v", err)
+	}
+}
+
+func TestGeocodingUseCase_ReverseRoundsAndCaches(t *testing.T) {
+	uc, stub, _ := newStubGeocoding(domain.MapProviderYandex, "key", nil)
+	ctx := context.Background()
+
+	addr, err := uc.Reverse(ctx, "u1", 55.761612, 37.609388, "ru")
+	if err != nil {
+		t.Fatalf("Reverse: %v", err)
+	}
+	if addr.Street != "Тверская улица" {
+		t.Errorf("unexpected address: %+v", addr)
+	}
+	if stub.lastLat != 55.7616 {
+		t.Errorf("provider got unrounded lat %v", stub.lastLat)
+	}
+	// a point ~5 m away rounds to the same key — served from cache
+	if _, err := uc.Reverse(ctx, "u1", 55.761597, 37.609402, "ru"); err != nil {
+		t.Fatalf("Reverse (cached): %v", err)
+	}
+	if stub.reverseCalls != 1 {
+		t.Errorf("want 1 provider call, got %d", stub.reverseCalls)
+	}
+}
+
+func TestGeocodingUseCase_ReverseErrors(t *testing.T) {
+	uc, _, _ := newStubGeocoding(domain.MapProviderYandex, "key", nil)
+	if _, err := uc.Reverse(context.Background(), "u1", 91, 0, ""); err != ErrInvalidCoordinates {
+		t.Errorf("want ErrInvalidCoordinates, got %v", err)
+	}
+	if _, err := uc.Reverse(context.Background(), "u1", 10, 10, ""); !errors.Is(err, domain.ErrAddressNotFound) {
+		t.Errorf("want ErrAddressNotFound, got %v", err)
+	}
+}
+
+func TestGeocodingUseCase_FallsBackToOSMWithoutKey(t *testing.T) {
+	uc, _, requested := newStubGeocoding(domain.MapProviderYandex, "", nil)
+	if _, err := uc.Geocode(context.Background(), "u1", "Москва", ""); err != nil {
+		t.Fatalf("Geocode: %v", err)
+	}
+	if len(*requested) != 1 || (*requested)[0] != domain.MapProviderOSM {
+		t.Errorf("want osm fallback, got %v", *requested)
+	}
+}
+
+func TestGeocodingUseCase_SuggestAndRoute(t *testing.T) {
+	uc, _, _ := newStubGeocoding(domain.MapProviderYandex, "key", nil)
+	ctx := context.Background()
+
+	sugs, err := uc.Suggest(ctx, "u1", "проспект", "ru", &domain.LatLng{Lat: 55.75, Lng: 37.62})
+	if err != nil {
+		t.Fatalf("Suggest: %v", err)
+	}
+	if len(sugs) != 2 {
+		t.Fatalf("want 2 suggestions, got %+v", sugs)
+	}
+
+	route, err := uc.Route(ctx, "u1", domain.LatLng{Lat: 55.7539, Lng: 37.6208}, domain.LatLng{Lat: 55.9726, Lng: 37.4146})
+	if err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if route.DistanceM < 30000 || route.DistanceM > 45000 || route.DurationS <= 0 || route.Polyline == "" {
+		t.Errorf("unexpected route: %+v", route)
+	}
+	if _, err := uc.Route(ctx, "u1", domain.LatLng{Lat: 95}, domain.LatLng{}); err != ErrInvalidCoordinates {
+		t.Errorf("want ErrInvalidCoordinates, got %v", err)
+	}
+}
+
+func TestGeocodingUseCase_Quota(t *testing.T) {
+	quota := &fakeQuota{left: 1}
+	uc, _, _ := newStubGeocoding(domain.MapProviderYandex, "key", quota)
+	ctx := context.Background()
+
+	if _, err := uc.Geocode(ctx, "u1", "Москва", ""); err != nil {
+		t.Fatalf("first request: %v", err)
+	}
+	if _, err := uc.Geocode(ctx, "u1", "Москва", ""); err != ErrGeocodeQuotaExceeded {
+		t.Errorf("want ErrGeocodeQuotaExceeded, got %v", err)
+	}
+	// service callers (no user) are not charged
+	if _, err := uc.Geocode(ctx, "", "Москва", ""); err != nil {
+		t.Errorf("service request: %v", err)
+	}
+	if len(quota.users) != 2 {
+		t.Errorf("want 2 quota checks, got %v", quota.users)
+	}
+}
+
+func TestEncodePolyline(t *testing.T) {
+	// reference example from the polyline algorithm docs
+	got := geocoding.EncodePolyline([]domain.LatLng{{Lat: 38.5, Lng: -120.2}, {Lat: 40.7, Lng: -120.95}, {Lat: 43.252, Lng: -126.453}})
+	if want := "_p~iF~ps|U_ulLnnqC_mqNvxq`@"; got != want {
+		t.Errorf("EncodePolyline = %q, want %q", got, want)
+	}
+}
@@ -8,7 +8,7 @@ import (
 )
 
 var (
-	ErrInvalidMapProvider = errors.New("invalid map provider, must be 'google' or 'yandex'")
+	ErrInvalidMapProvider = errors.New("invalid map provider, must be 'google', 'yandex' or 'osm'")
 	ErrUnauthorized       = errors.New("unauthorized to modify settings")
 )
 
@@ -16,9 +16,11 @@ var (
 type SettingsRepository interface {
 	GetSettings(ctx context.Context) (*domain.AppSettings, error)
 	UpdateSettings(ctx context.Context, settings *domain.AppSettings, userID string) error
-	GetMapSettings(ctx context.Context) (*domain.MapSettings, error)
 }
 
+// MapsProxyPath — base path of the server-side maps proxy advertised to clients
+const MapsProxyPath = "/api/v1/maps"
+
 // SettingsUseCase handles settings business logic
 type SettingsUseCase struct {
 	repo SettingsRepository
@@ -29,9 +31,13 @@ func NewSettingsUseCase(repo SettingsRepository) *SettingsUseCase {
 	return &SettingsUseCase{repo: repo}
 }
 
-// GetSettings retrieves current app settings (admin only)
+// GetSettings retrieves current app settings with API keys masked (admin only)
 func (uc *SettingsUseCase) GetSettings(ctx context.Context) (*domain.AppSettings, error) {
-	return uc.repo.GetSettings(ctx)
+	settings, err := uc.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return settings.Masked(), nil
 }
 
 // UpdateMapProvider updates the active map provider
@@ -49,15 +55,31 @@ func (uc *SettingsUseCase) UpdateMapProvider(ctx context.Context, provider domai
 	return uc.repo.UpdateSettings(ctx, settings, userID)
 }
 
-// UpdateSettings updates all settings (admin only)
+// UpdateSettings updates all settings (admin only). Keys sent back masked
+// (as returned by GetSettings) keep their stored value; empty clears the key.
 func (uc *SettingsUseCase) UpdateSettings(ctx context.Context, settings *domain.AppSettings, userID string) error {
 	if !settings.MapProvider.Validate() {
 		return ErrInvalidMapProvider
 	}
+	current, err := uc.repo.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+	settings.KeepMaskedSecrets(current)
 	return uc.repo.UpdateSettings(ctx, settings, userID)
 }
 
-// GetMapSettings returns map settings for mobile apps
-func (uc *SettingsUseCase) GetMapSettings(ctx context.Context) (*domain.MapSettings, error) {
-	return uc.repo.GetMapSettings(ctx)
+// GetMapSettings returns map settings for mobile apps: only the restricted key
+// of the active provider for the caller's platform, never the server keys
+func (uc *SettingsUseCase) GetMapSettings(ctx context.Context, platform domain.Platform) (*domain.MapSettings, error) {
+	settings, err := uc.repo.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.MapSettings{
+		Provider: settings.MapProvider,
+		Platform: platform,
+		APIKey:   settings.ClientKey(platform),
+		ProxyURL: MapsProxyPath,
+	}, nil
 }
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ridehail/user/internal/domain"
+)
+
+func newSettingsRepo() *fakeSettingsRepo {
+	return &fakeSettingsRepo{settings: &domain.AppSettings{
+		ID:                   "default",
+		MapProvider:          domain.MapProviderGoogle,
+		GoogleMapsAPIKey:     "AIzaSyServerSecret0001",
+		YandexMapsAPIKey:     "yandex-server-secret-0002",
+		GoogleMapsAndroidKey: "AIzaSyAndroidRestricted03",
+	}}
+}
+
+func TestSettingsUseCase_GetSettings_MasksSecrets(t *testing.T) {
+	uc := NewSettingsUseCase(newSettingsRepo())
+	s, err := uc.GetSettings(context.Background())
+	if err != nil {
+		t.Fatalf("GetSettings: %v", err)
+	}
+	if s.GoogleMapsAPIKey != "••••0001" || s.YandexMapsAPIKey != "••••0002" || s.GoogleMapsAndroidKey != "••••ed03" {
+		t.Errorf("secrets not masked: %+v", s)
+	}
+	if s.GoogleMapsIOSKey != "" {
+		t.Errorf("empty key should stay empty, got %q", s.GoogleMapsIOSKey)
+	}
+}
+
+func TestSettingsUseCase_UpdateSettings_KeepsMaskedSecrets(t *testing.T) {
+	repo := newSettingsRepo()
+	uc := NewSettingsUseCase(repo)
+	masked, _ := uc.GetSettings(context.Background())
+
+	masked.MapProvider = domain.MapProviderYandex
+	masked.YandexMapsAPIKey = "yandex-new-secret"
+	masked.GoogleMapsAndroidKey = ""
+	if err := uc.UpdateSettings(context.Background(), masked, "admin1"); err != nil {
+		t.Fatalf("UpdateSettings: %v", err)
+	}
+	got := repo.settings
+	if got.GoogleMapsAPIKey != "AIzaSyServerSecret0001" {
+		t.Errorf("masked key overwritten: %q", got.GoogleMapsAPIKey)
+	}
+	if got.YandexMapsAPIKey != "yandex-new-secret" {
+		t.Errorf("new key not saved: %q", got.YandexMapsAPIKey)
+	}
+	if got.GoogleMapsAndroidKey != "" {
+		t.Errorf("empty key should clear, got %q", got.GoogleMapsAndroidKey)
+	}
+}
+
+func TestSettingsUseCase_GetMapSettings_OnlyPlatformKey(t *testing.T) {
+	uc := NewSettingsUseCase(newSettingsRepo())
+	ms, err := uc.GetMapSettings(context.Background(), domain.PlatformAndroid)
+	if err != nil {
+		t.Fatalf("GetMapSettings: %v", err)
+	}
+	if ms.APIKey != "AIzaSyAndroidRestricted03" || ms.ProxyURL != MapsProxyPath {
+		t.Errorf("unexpected map settings: %+v", ms)
+	}
+	ms, _ = uc.GetMapSettings(context.Background(), "")
+	if ms.APIKey != "" {
+		t.Errorf("server key leaked to unknown platform: %q", ms.APIKey)
+	}
+}
+
+func TestMaskSecret_KeepsFixedSuffix(t *testing.T) {
+	for key, want := range map[string]string{
+		"":                       "",
+		"abc":                    "••••abc",
+		"short1":                 "••••ort1",
+		"AIzaSyServerSecret0001": "••••0001",
+	} {
+		if got := domain.MaskSecret(key); got != want {
+			t.Errorf("MaskSecret(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
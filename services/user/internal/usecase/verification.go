@@ -3,23 +3,40 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/ridehail/user/internal/domain"
 )
 
+var (
+	// ErrDriverNotVerified — driver has no approved verification (no vehicle attributes)
+	ErrDriverNotVerified = errors.New("driver has no approved verification")
+	// ErrAttributesSyncFailed — approval stored, but geolocation did not accept the attributes
+	ErrAttributesSyncFailed = errors.New("vehicle attributes sync failed")
+	// ErrDocumentExpired — document approved with an expiry date in the past
+	ErrDocumentExpired = errors.New("document expiry must be in the future")
+	// ErrTooManyDrivers — driver cards batch over domain.MaxDriverCardsBatch
+	ErrTooManyDrivers = errors.New("too many driver ids")
+)
+
 // VerificationRepository interface for verification persistence.
 type VerificationRepository interface {
 	CreateVerification(ctx context.Context, v *domain.DriverVerification) error
 	GetVerification(ctx context.Context, userID string) (*domain.DriverVerification, error)
 	GetVerificationByID(ctx context.Context, id string) (*domain.DriverVerification, error)
 	UpdateVerificationStatus(ctx context.Context, id, status, reviewerID, rejectReason string) error
+	UpdateVehicleAttributes(ctx context.Context, id, class string, features []string) error
 	ListPendingVerifications(ctx context.Context, limit, offset int) ([]*domain.DriverVerification, error)
+	DriverCards(ctx context.Context, driverIDs []string) ([]*domain.DriverCard, error)
 
 	CreateDocument(ctx context.Context, d *domain.DriverDocument) error
 	GetDocument(ctx context.Context, id string) (*domain.DriverDocument, error)
+	SetDocumentExpiry(ctx context.Context, id string, expiresAt *time.Time) error
 	ListDocumentsByUser(ctx context.Context, userID string) ([]*domain.DriverDocument, error)
 	UpdateDocumentStatus(ctx context.Context, id, status, reviewerID, rejectReason string) error
 	DeleteDocument(ctx context.Context, id string) error
@@ -34,6 +51,16 @@ type StorageClient interface {
 	GetPresignedURL(ctx context.Context, key string, expires interface{}) (string, error)
 }
 
+// AttributesSync pushes approved vehicle attributes to geolocation (nearest-driver filters).
+type AttributesSync interface {
+	SetDriverAttributes(ctx context.Context, a domain.DriverAttributes) error
+}
+
+// EligibilityPublisher publishes driver eligibility snapshots (ride service bid gate).
+type EligibilityPublisher interface {
+	PublishDriverEligibility(ctx context.Context, e domain.DriverEligibility) error
+}
+
 // UploadResult from storage.
 type UploadResult struct {
 	Key         string
@@ -46,20 +73,44 @@ type UploadResult struct {
 type VerificationUseCase struct {
 	repo    VerificationRepository
 	storage StorageClient
+	attrs   AttributesSync       // optional
+	events  EligibilityPublisher // optional
+	now     func() time.Time
 }
 
-// NewVerificationUseCase creates verification use case.
-func NewVerificationUseCase(repo VerificationRepository, storage StorageClient) *VerificationUseCase {
+// NewVerificationUseCase creates verification use case; attrs may be nil (no geolocation sync),
+// events may be nil (ride service falls back to GET /drivers/:id/eligibility).
+func NewVerificationUseCase(repo VerificationRepository, storage StorageClient, attrs AttributesSync, events EligibilityPublisher) *VerificationUseCase {
 	return &VerificationUseCase{
 		repo:    repo,
 		storage: storage,
+		attrs:   attrs,
+		events:  events,
+		now:     time.Now,
 	}
 }
 
+// StartVerificationInput for a new verification request.
+type StartVerificationInput struct {
+	UserID        string
+	LicenseNumber string
+	VehicleModel  string
+	VehiclePlate  string
+	VehicleColor  string
+	VehicleYear   int
+	VehicleClass  string   // empty = economy
+	Features      []string // declared; admin confirms on review
+}
+
 // StartVerification creates a new verification request.
-func (uc *VerificationUseCase) StartVerification(ctx context.Context, userID, licenseNumber, vehicleModel, vehiclePlate string, vehicleYear int) (*domain.DriverVerification, error) {
+func (uc *VerificationUseCase) StartVerification(ctx context.Context, input StartVerificationInput) (*domain.DriverVerification, error) {
+	class, features, err := domain.NormalizeVehicle(input.VehicleClass, input.Features)
+	if err != nil {
+		return nil, err
+	}
+
 	// Check if already has pending/approved verification
-	existing, err := uc.repo.GetVerification(ctx, userID)
+	existing, err := uc.repo.GetVerification(ctx, input.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -74,19 +125,22 @@ func (uc *VerificationUseCase) StartVerification(ctx context.Context, userID, li
 	}
 
 	v := &domain.DriverVerification{
-		UserID:        userID,
+		UserID:        input.UserID,
 		Status:        domain.VerificationStatusPending,
-		LicenseNumber: licenseNumber,
-		VehicleModel:  vehicleModel,
-		VehiclePlate:  vehiclePlate,
-		VehicleYear:   vehicleYear,
+		LicenseNumber: input.LicenseNumber,
+		VehicleModel:  input.VehicleModel,
+		VehiclePlate:  input.VehiclePlate,
+		VehicleColor:  strings.TrimSpace(input.VehicleColor),
+		VehicleYear:   input.VehicleYear,
+		VehicleClass:  class,
+		Features:      features,
 	}
 
 	if err := uc.repo.CreateVerification(ctx, v); err != nil {
 		return nil, err
 	}
 
-	return uc.repo.GetVerification(ctx, userID)
+	return uc.repo.GetVerification(ctx, input.UserID)
 }
 
 // UploadDocumentInput for document upload.
@@ -224,9 +278,14 @@ type AdminReviewInput struct {
 	AdminUserID    string
 	Approved       bool
 	RejectReason   string
+	// VehicleClass/Features override the driver's declaration on approval (nil Features = keep)
+	VehicleClass string
+	Features     []string
 }
 
 // AdminReviewVerification approves or rejects verification (admin).
+// Approved vehicle attributes are pushed to geolocation; ErrAttributesSyncFailed means
+// the approval is stored but the push should be retried (SyncDriverAttributes).
 func (uc *VerificationUseCase) AdminReviewVerification(ctx context.Context, input AdminReviewInput) error {
 	v, err := uc.repo.GetVerificationByID(ctx, input.VerificationID)
 	if err != nil {
@@ -237,6 +296,22 @@ func (uc *VerificationUseCase) AdminReviewVerification(ctx context.Context, inpu
 		return fmt.Errorf("verification already reviewed")
 	}
 
+	if input.Approved && (input.VehicleClass != "" || input.Features != nil) {
+		class, features := input.VehicleClass, input.Features
+		if class == "" {
+			class = v.VehicleClass
+		}
+		if features == nil {
+			features = v.Features
+		}
+		if v.VehicleClass, v.Features, err = domain.NormalizeVehicle(class, features); err != nil {
+			return err
+		}
+		if err := uc.repo.UpdateVehicleAttributes(ctx, v.ID, v.VehicleClass, v.Features); err != nil {
+			return err
+		}
+	}
+
 	status := domain.VerificationStatusRejected
 	if input.Approved {
 		status = domain.VerificationStatusApproved
@@ -253,16 +328,133 @@ func (uc *VerificationUseCase) AdminReviewVerification(ctx context.Context, inpu
 			return err
 		}
 	}
+	uc.publishEligibility(ctx, v.UserID)
+	if input.Approved {
+		return uc.pushAttributes(ctx, v)
+	}
 
 	return nil
 }
 
+// GetDriverAttributes returns vehicle attributes of the driver's approved verification.
+func (uc *VerificationUseCase) GetDriverAttributes(ctx context.Context, driverID string) (*domain.DriverAttributes, error) {
+	v, err := uc.repo.GetVerification(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil || v.Status != domain.VerificationStatusApproved {
+		return nil, ErrDriverNotVerified
+	}
+	a := v.Attributes()
+	if a.Documents, err = uc.approvedDocTypes(ctx, driverID); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// approvedDocTypes — sorted types of the driver's approved documents
+func (uc *VerificationUseCase) approvedDocTypes(ctx context.Context, userID string) ([]string, error) {
+	docs, err := uc.repo.ListDocumentsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var types []string
+	for _, d := range docs {
+		if d.Status == domain.VerificationStatusApproved {
+			types = append(types, d.DocType)
+		}
+	}
+	sort.Strings(types)
+	return types, nil
+}
+
+// DriverCards returns public cards of approved drivers (ride service bid list);
+// unknown and unapproved ids are skipped, duplicates collapsed.
+func (uc *VerificationUseCase) DriverCards(ctx context.Context, driverIDs []string) ([]*domain.DriverCard, error) {
+	ids := make([]string, 0, len(driverIDs))
+	seen := map[string]bool{}
+	for _, id := range driverIDs {
+		if id = strings.TrimSpace(id); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > domain.MaxDriverCardsBatch {
+		return nil, ErrTooManyDrivers
+	}
+	if len(ids) == 0 {
+		return []*domain.DriverCard{}, nil
+	}
+	cards, err := uc.repo.DriverCards(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	if cards == nil {
+		cards = []*domain.DriverCard{}
+	}
+	return cards, nil
+}
+
+// DriverEligibility returns the driver's verification snapshot for the ride service bid gate.
+func (uc *VerificationUseCase) DriverEligibility(ctx context.Context, driverID string) (*domain.DriverEligibility, error) {
+	v, err := uc.repo.GetVerification(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := uc.repo.ListDocumentsByUser(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	e := domain.NewDriverEligibility(driverID, v, docs, uc.now())
+	return &e, nil
+}
+
+// publishEligibility sends the driver's current snapshot; best effort — the ride service
+// re-reads it over HTTP when its cached entry expires.
+func (uc *VerificationUseCase) publishEligibility(ctx context.Context, driverID string) {
+	if uc.events == nil {
+		return
+	}
+	e, err := uc.DriverEligibility(ctx, driverID)
+	if err != nil {
+		return
+	}
+	_ = uc.events.PublishDriverEligibility(ctx, *e)
+}
+
+// SyncDriverAttributes re-pushes approved vehicle attributes to geolocation (admin retry).
+func (uc *VerificationUseCase) SyncDriverAttributes(ctx context.Context, driverID string) (*domain.DriverAttributes, error) {
+	v, err := uc.repo.GetVerification(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil || v.Status != domain.VerificationStatusApproved {
+		return nil, ErrDriverNotVerified
+	}
+	if err := uc.pushAttributes(ctx, v); err != nil {
+		return nil, err
+	}
+	a := v.Attributes()
+	return &a, nil
+}
+
+func (uc *VerificationUseCase) pushAttributes(ctx context.Context, v *domain.DriverVerification) error {
+	if uc.attrs == nil {
+		return nil
+	}
+	if err := uc.attrs.SetDriverAttributes(ctx, v.Attributes()); err != nil {
+		return fmt.Errorf("%w: %v", ErrAttributesSyncFailed, err)
+	}
+	return nil
+}
+
 // AdminReviewDocumentInput for document review.
 type AdminReviewDocumentInput struct {
 	DocumentID   string
 	AdminUserID  string
 	Approved     bool
 	RejectReason string
+	ExpiresAt    *time.Time // approval only; nil = does not expire
 }
 
 // AdminReviewDocument approves or rejects a specific document (admin).
@@ -281,7 +473,19 @@ func (uc *VerificationUseCase) AdminReviewDocument(ctx context.Context, input Ad
 		status = domain.VerificationStatusApproved
 	}
 
-	return uc.repo.UpdateDocumentStatus(ctx, input.DocumentID, status, input.AdminUserID, input.RejectReason)
+	if input.Approved && input.ExpiresAt != nil && !input.ExpiresAt.After(uc.now()) {
+		return ErrDocumentExpired
+	}
+	if err := uc.repo.UpdateDocumentStatus(ctx, input.DocumentID, status, input.AdminUserID, input.RejectReason); err != nil {
+		return err
+	}
+	if input.Approved && input.ExpiresAt != nil {
+		if err := uc.repo.SetDocumentExpiry(ctx, input.DocumentID, input.ExpiresAt); err != nil {
+			return err
+		}
+	}
+	uc.publishEligibility(ctx, doc.UserID)
+	return nil
 }
 
 // GetVerificationByID returns verification by ID (admin).
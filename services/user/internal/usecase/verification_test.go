@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ridehail/user/internal/domain"
+)
+
+type fakeVerificationRepo struct {
+	VerificationRepository
+	v        *domain.DriverVerification
+	docs     []*domain.DriverDocument
+	verified bool
+}
+
+func (f *fakeVerificationRepo) ListDocumentsByUser(ctx context.Context, userID string) ([]*domain.DriverDocument, error) {
+	return f.docs, nil
+}
+
+func (f *fakeVerificationRepo) GetVerification(ctx context.Context, userID string) (*domain.DriverVerification, error) {
+	if f.v == nil || f.v.UserID != userID {
+		return nil, nil
+	}
+	return f.v, nil
+}
+
+func (f *fakeVerificationRepo) GetVerificationByID(ctx context.Context, id string) (*domain.DriverVerification, error) {
+	if f.v == nil || f.v.ID != id {
+		return nil, domain.ErrDocumentNotFound
+	}
+	return f.v, nil
+}
+
+func (f *fakeVerificationRepo) UpdateVerificationStatus(ctx context.Context, id, status, reviewerID, rejectReason string) error {
+	f.v.Status = status
+	return nil
+}
+
+func (f *fakeVerificationRepo) UpdateVehicleAttributes(ctx context.Context, id, class string, features []string) error {
+	f.v.VehicleClass, f.v.Features = class, features
+	return nil
+}
+
+func (f *fakeVerificationRepo) SetUserVerified(ctx context.Context, userID string, verified bool, verificationID string) error {
+	f.verified = verified
+	return nil
+}
+
+func (f *fakeVerificationRepo) GetDocument(ctx context.Context, id string) (*domain.DriverDocument, error) {
+	for _, d := range f.docs {
+		if d.ID == id {
+			return d, nil
+		}
+	}
+	return nil, domain.ErrDocumentNotFound
+}
+
+func (f *fakeVerificationRepo) UpdateDocumentStatus(ctx context.Context, id, status, reviewerID, rejectReason string) error {
+	d, _ := f.GetDocument(ctx, id)
+	d.Status = status
+	return nil
+}
+
+func (f *fakeVerificationRepo) SetDocumentExpiry(ctx context.Context, id string, expiresAt *time.Time) error {
+	d, _ := f.GetDocument(ctx, id)
+	d.ExpiresAt = expiresAt
+	return nil
+}
+
+type fakeEligibilityEvents struct {
+	published []domain.DriverEligibility
+}
+
+func (f *fakeEligibilityEvents) PublishDriverEligibility(ctx context.Context, e domain.DriverEligibility) error {
+	f.published = append(f.published, e)
+	return nil
+}
+
+type fakeAttributesSync struct {
+	pushed []domain.DriverAttributes
+	err    error
+}
+
+func (f *fakeAttributesSync) SetDriverAttributes(ctx context.Context, a domain.DriverAttributes) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.pushed = append(f.pushed, a)
+	return nil
+}
+
+func pendingVerification() *domain.DriverVerification {
+	return &domain.DriverVerification{
+		ID:           "v1",
+		UserID:       "d1",
+		Status:       domain.VerificationStatusPending,
+		VehicleClass: domain.VehicleClassComfort,
+		Features:     []string{domain.VehicleFeatureChildSeat},
+	}
+}
+
+func TestVerificationUseCase_Approve_PushesCorrectedAttributes(t *testing.T) {
+	repo := &fakeVerificationRepo{v: pendingVerification(), docs: []*domain.DriverDocument{
+		{DocType: domain.DocTypePhoto, Status: domain.VerificationStatusApproved},
+		{DocType: domain.DocTypeInsurance, Status: domain.VerificationStatusRejected},
+		{DocType: domain.DocTypeLicense, Status: domain.VerificationStatusApproved},
+	}}
+	sync := &fakeAttributesSync{}
+	uc := NewVerificationUseCase(repo, nil, sync, nil)
+	ctx := context.Background()
+
+	if _, err := uc.GetDriverAttributes(ctx, "d1"); err != ErrDriverNotVerified {
+		t.Errorf("pending driver must have no attributes, got %v", err)
+	}
+
+	err := uc.AdminReviewVerification(ctx, AdminReviewInput{
+		VerificationID: "v1",
+		AdminUserID:    "admin",
+		Approved:       true,
+		Features:       []string{"Pet_Friendly", "child_seat", "pet_friendly"},
+	})
+	if err != nil {
+		t.Fatalf("AdminReviewVerification: %v", err)
+	}
+	want := domain.DriverAttributes{
+		DriverID:     "d1",
+		VehicleClass: domain.VehicleClassComfort,
+		Features:     []string{domain.VehicleFeatureChildSeat, domain.VehicleFeaturePetFriendly},
+	}
+	if len(sync.pushed) != 1 || !reflect.DeepEqual(sync.pushed[0], want) {
+		t.Fatalf("expected push %+v, got %+v", want, sync.pushed)
+	}
+	want.Documents = []string{domain.DocTypeLicense, domain.DocTypePhoto}
+	got, err := uc.GetDriverAttributes(ctx, "d1")
+	if err != nil || !reflect.DeepEqual(*got, want) {
+		t.Errorf("GetDriverAttributes = %+v, %v; want %+v", got, err, want)
+	}
+}
+
+func TestVerificationUseCase_Approve_SyncFailureKeepsApproval(t *testing.T) {
+	repo := &fakeVerificationRepo{v: pendingVerification()}
+	sync := &fakeAttributesSync{err: errors.New("connection refused")}
+	uc := NewVerificationUseCase(repo, nil, sync, nil)
+
+	err := uc.AdminReviewVerification(context.Background(), AdminReviewInput{VerificationID: "v1", Approved: true})
+	if !errors.Is(err, ErrAttributesSyncFailed) {
+		t.Fatalf("expected ErrAttributesSyncFailed, got %v", err)
+	}
+	if repo.v.Status != domain.VerificationStatusApproved || !repo.verified {
+		t.Errorf("approval must be stored despite sync failure: %+v", repo.v)
+	}
+
+	sync.err = nil
+	if _, err := uc.SyncDriverAttributes(context.Background(), "d1"); err != nil || len(sync.pushed) != 1 {
+		t.Errorf("retry sync: err=%v pushed=%d", err, len(sync.pushed))
+	}
+}
+
+func TestVerificationUseCase_Approve_RejectsUnknownClass(t *testing.T) {
+	repo := &fakeVerificationRepo{v: pendingVerification()}
+	uc := NewVerificationUseCase(repo, nil, nil, nil)
+
+	err := uc.AdminReviewVerification(context.Background(), AdminReviewInput{VerificationID: "v1", Approved: true, VehicleClass: "limo"})
+	if err != domain.ErrInvalidVehicleClass {
+		t.Fatalf("expected ErrInvalidVehicleClass, got %v", err)
+	}
+	if repo.v.Status != domain.VerificationStatusPending {
+		t.Errorf("verification must stay pending, got %s", repo.v.Status)
+	}
+}
+
+func TestVerificationUseCase_Reviews_PublishEligibility(t *testing.T) {
+	now := time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC)
+	repo := &fakeVerificationRepo{v: pendingVerification(), docs: []*domain.DriverDocument{
+		{ID: "lic", UserID: "d1", DocType: domain.DocTypeLicense, Status: domain.VerificationStatusPending},
+		{ID: "photo", UserID: "d1", DocType: domain.DocTypePhoto, Status: domain.VerificationStatusApproved},
+	}}
+	events := &fakeEligibilityEvents{}
+	uc := NewVerificationUseCase(repo, nil, nil, events)
+	uc.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	past := now.Add(-time.Hour)
+	err := uc.AdminReviewDocument(ctx, AdminReviewDocumentInput{DocumentID: "lic", AdminUserID: "admin", Approved: true, ExpiresAt: &past})
+	if err != ErrDocumentExpired || repo.docs[0].Status != domain.VerificationStatusPending {
+		t.Fatalf("expired license must be refused before review is stored, got %v", err)
+	}
+	expires := now.AddDate(1, 0, 0)
+	if err := uc.AdminReviewDocument(ctx, AdminReviewDocumentInput{DocumentID: "lic", AdminUserID: "admin", Approved: true, ExpiresAt: &expires}); err != nil {
+		t.Fatalf("AdminReviewDocument: %v", err)
+	}
+	if err := uc.AdminReviewVerification(ctx, AdminReviewInput{VerificationID: "v1", AdminUserID: "admin", Approved: true}); err != nil {
+		t.Fatalf("AdminReviewVerification: %v", err)
+	}
+
+	if len(events.published) != 2 {
+		t.Fatalf("expected a snapshot per review, got %d", len(events.published))
+	}
+	if e := events.published[0]; e.Status != domain.VerificationStatusPending || !e.Documents[domain.DocTypeLicense].Equal(expires) {
+		t.Errorf("after document review: %+v", e)
+	}
+	want := domain.DriverEligibility{
+		DriverID:  "d1",
+		Status:    domain.VerificationStatusApproved,
+		Documents: map[string]*time.Time{domain.DocTypeLicense: &expires, domain.DocTypePhoto: nil},
+		UpdatedAt: now,
+	}
+	if got := events.published[1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("after approval = %+v, want %+v", got, want)
+	}
+}
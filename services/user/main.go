@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -19,7 +21,11 @@ import (
 	"github.com/alexevil1979/indrive/packages/otel-go/tracing"
 
 	httphandler "github.com/ridehail/user/internal/delivery/http"
+	"github.com/ridehail/user/internal/domain"
+	"github.com/ridehail/user/internal/infra/geocoding"
+	"github.com/ridehail/user/internal/infra/geosvc"
 	"github.com/ridehail/user/internal/infra/jwt"
+	"github.com/ridehail/user/internal/infra/kafka"
 	"github.com/ridehail/user/internal/infra/pg"
 	"github.com/ridehail/user/internal/infra/redis"
 	"github.com/ridehail/user/internal/infra/storage"
@@ -45,6 +51,19 @@ func main() {
 	minioBucket := getEnv("MINIO_BUCKET", "ridehail-documents")
 	minioPublicURL := getEnv("MINIO_PUBLIC_URL", "")
 
+	// Geocoding configuration (also the maps proxy)
+	geocoderProvider := getEnv("GEOCODER_PROVIDER", "") // "stub" forces fixture geocoder (offline dev)
+	nominatimURL := getEnv("NOMINATIM_URL", "")
+	osrmURL := getEnv("OSRM_URL", "")
+	geocodeCacheTTL, _ := time.ParseDuration(getEnv("GEOCODE_CACHE_TTL", "24h"))
+	geocodeQuotaPerMinute := getEnvInt("GEOCODE_QUOTA_PER_MINUTE", 60)
+	geocodeQuotaPerDay := getEnvInt("GEOCODE_QUOTA_PER_DAY", 2000)
+
+	// Geolocation service (approved vehicle attributes are pushed there)
+	geolocationURL := getEnv("GEOLOCATION_SERVICE_URL", "")
+	// Kafka (driver eligibility events for the ride service bid gate)
+	kafkaBrokers := getEnv("KAFKA_BROKERS", "")
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -112,12 +131,41 @@ func main() {
 	// Initialize use cases
 	jwtValidator := jwt.NewValidator(jwtSecret)
 	profileUC := usecase.NewProfileUseCase(profileRepo)
-	verificationUC := usecase.NewVerificationUseCase(verificationRepo, &storageAdapter{client: storageClient})
+	var attrsSync usecase.AttributesSync
+	if geolocationURL != "" {
+		attrsSync = geosvc.New(geolocationURL, jwt.NewSigner(jwtSecret, serviceName), 3*time.Second)
+	}
+	var eligibilityEvents usecase.EligibilityPublisher
+	if kafkaBrokers != "" {
+		brokers := strings.Split(kafkaBrokers, ",")
+		for i := range brokers {
+			brokers[i] = strings.TrimSpace(brokers[i])
+		}
+		kp, err := kafka.NewProducer(brokers)
+		if err != nil {
+			log.Warn("kafka connect failed, driver eligibility events disabled", "error", err)
+		} else {
+			defer kp.Close()
+			eligibilityEvents = kp
+			log.Info("kafka ready")
+		}
+	}
+	verificationUC := usecase.NewVerificationUseCase(verificationRepo, &storageAdapter{client: storageClient}, attrsSync, eligibilityEvents)
 	settingsUC := usecase.NewSettingsUseCase(settingsRepo)
+	geocoderCfg := geocoding.Config{NominatimURL: nominatimURL, OSRMURL: osrmURL, Timeout: 5 * time.Second}
+	geocodingUC := usecase.NewGeocodingUseCase(settingsRepo,
+		func(p domain.MapProvider, apiKey string) (usecase.Geocoder, error) {
+			return geocoding.New(p, apiKey, geocoderCfg)
+		},
+		redis.NewGeocodeCache(rdb, geocodeCacheTTL),
+		redis.NewGeocodeQuota(rdb, geocodeQuotaPerMinute, geocodeQuotaPerDay),
+		usecase.GeocodingConfig{ForceProvider: domain.MapProvider(geocoderProvider)},
+	)
 
 	// Handlers
 	verificationHandler := httphandler.NewVerificationHandler(verificationUC)
 	settingsHandler := httphandler.NewSettingsHandler(settingsUC)
+	geocodingHandler := httphandler.NewGeocodingHandler(geocodingUC)
 
 	// Setup Echo
 	e := echo.New()
@@ -137,6 +185,12 @@ func main() {
 	api.PATCH("/users/me", httphandler.UpdateProfile(profileUC))
 	api.POST("/users/me/driver", httphandler.CreateDriverProfile(profileUC))
 
+	// Maps proxy (provider follows admin map settings; server keys stay here)
+	api.GET("/maps/geocode", geocodingHandler.Geocode())
+	api.GET("/maps/geocode/reverse", geocodingHandler.Reverse())
+	api.GET("/maps/suggest", geocodingHandler.Suggest())
+	api.GET("/maps/route", geocodingHandler.Route())
+
 	// Driver verification routes
 	api.POST("/verification", verificationHandler.StartVerification())
 	api.GET("/verification", verificationHandler.GetVerificationStatus())
@@ -144,6 +198,9 @@ func main() {
 	api.GET("/verification/documents", verificationHandler.ListDocuments())
 	api.GET("/verification/documents/:id", verificationHandler.GetDocument())
 	api.DELETE("/verification/documents/:id", verificationHandler.DeleteDocument())
+	api.GET("/drivers/:id/attributes", verificationHandler.GetDriverAttributes())
+	api.GET("/drivers/:id/eligibility", verificationHandler.GetDriverEligibility())
+	api.GET("/drivers/cards", verificationHandler.GetDriverCards())
 
 	// Admin verification routes
 	admin := e.Group("/api/v1/admin")
@@ -152,12 +209,13 @@ func main() {
 	admin.GET("/verifications/:id", verificationHandler.GetVerificationByID())
 	admin.POST("/verifications/:id/review", verificationHandler.ReviewVerification())
 	admin.POST("/documents/:id/review", verificationHandler.ReviewDocument())
+	admin.POST("/drivers/:id/attributes/sync", verificationHandler.SyncDriverAttributes())
 
 	// Admin settings routes
 	admin.GET("/settings", settingsHandler.GetSettings())
 	admin.PUT("/settings", settingsHandler.UpdateSettings())
 
-	// Public settings endpoint (for mobile apps)
+	// Public settings endpoint (for mobile apps; restricted platform key only)
 	e.GET("/api/v1/settings/maps", settingsHandler.GetMapSettings())
 
 	// Start server
@@ -223,6 +281,13 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvInt(key string, fallback int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return fallback
+}
+
 // storageAdapter adapts storage.Client to usecase.StorageClient interface
 type storageAdapter struct {
 	client *storage.Client